@@ -0,0 +1,160 @@
+package accounting
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// exportTimeFormat has a fixed number of fractional digits so the exported times sort as strings
+const exportTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// ExportRecord is a trade normalized for tax and accounting tools. Amounts are decimal strings so they are exported without rounding.
+type ExportRecord struct {
+	Timestamp   int64  `json:"timestamp"` // unix millis
+	Time        string `json:"time"`      // UTC
+	Exchange    string `json:"exchange"`
+	TradeID     string `json:"trade_id"`
+	Side        string `json:"side"`
+	BaseAsset   string `json:"base_asset"`
+	BaseAmount  string `json:"base_amount"`
+	QuoteAsset  string `json:"quote_asset"`
+	QuoteAmount string `json:"quote_amount"` // value of the trade in the counter asset
+	Price       string `json:"price"`
+	Fee         string `json:"fee"`       // as reported by the exchange, SDEX reports network fees in XLM
+	FeeAsset    string `json:"fee_asset"` // empty when the exchange does not report the asset of the fee
+}
+
+var exportCSVHeader = []string{"timestamp", "time", "exchange", "trade_id", "side", "base_asset", "base_amount", "quote_asset", "quote_amount", "price", "fee", "fee_asset"}
+
+func (r ExportRecord) csvRow() []string {
+	return []string{
+		fmt.Sprintf("%d", r.Timestamp),
+		r.Time,
+		r.Exchange,
+		r.TradeID,
+		r.Side,
+		r.BaseAsset,
+		r.BaseAmount,
+		r.QuoteAsset,
+		r.QuoteAmount,
+		r.Price,
+		r.Fee,
+		r.FeeAsset,
+	}
+}
+
+// MakeExportRecords normalizes the trades made on an exchange, the asset names are passed in since the names used by exchanges differ
+func MakeExportRecords(exchange string, baseAsset string, quoteAsset string, trades []model.Trade) []ExportRecord {
+	records := []ExportRecord{}
+	for _, t := range trades {
+		var ts int64
+		if t.Timestamp != nil {
+			ts = t.Timestamp.AsInt64()
+		}
+		quoteAmount := t.Cost
+		if quoteAmount == nil {
+			quoteAmount = t.Volume.Multiply(*t.Price)
+		}
+		fee := "0"
+		if t.Fee != nil {
+			fee = t.Fee.AsString()
+		}
+		tradeID := ""
+		if t.TransactionID != nil {
+			tradeID = t.TransactionID.String()
+		}
+
+		records = append(records, ExportRecord{
+			Timestamp:   ts,
+			Time:        time.Unix(0, ts*int64(time.Millisecond)).UTC().Format(exportTimeFormat),
+			Exchange:    exchange,
+			TradeID:     tradeID,
+			Side:        t.OrderAction.String(),
+			BaseAsset:   baseAsset,
+			BaseAmount:  t.Volume.AsString(),
+			QuoteAsset:  quoteAsset,
+			QuoteAmount: quoteAmount.AsString(),
+			Price:       t.Price.AsString(),
+			Fee:         fee,
+			FeeAsset:    string(t.FeeAsset),
+		})
+	}
+	return records
+}
+
+// SortExportRecords sorts the records by time, keeping the order of records with the same time
+func SortExportRecords(records []ExportRecord) {
+	sort.SliceStable(records, func(i int, j int) bool {
+		return records[i].Timestamp < records[j].Timestamp
+	})
+}
+
+// WriteCSV writes the records as CSV with a header row
+func WriteCSV(w io.Writer, records []ExportRecord) error {
+	writer := csv.NewWriter(w)
+	e := writer.Write(exportCSVHeader)
+	if e != nil {
+		return fmt.Errorf("could not write csv header: %s", e)
+	}
+	for _, r := range records {
+		e = writer.Write(r.csvRow())
+		if e != nil {
+			return fmt.Errorf("could not write csv row for trade '%s': %s", r.TradeID, e)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSON writes the records as a JSON array
+func WriteJSON(w io.Writer, records []ExportRecord) error {
+	b, e := json.MarshalIndent(records, "", "  ")
+	if e != nil {
+		return fmt.Errorf("could not marshal records: %s", e)
+	}
+	_, e = w.Write(append(b, '\n'))
+	return e
+}
+
+// FetchTradeHistory pages through the trade history of the fetcher from cursorStart and returns the trades between from and to (inclusive).
+// It stops once the history has passed to or the cursor stops moving, since exchanges return a limited number of trades per call.
+func FetchTradeHistory(fetcher api.TradeFetcher, pair model.TradingPair, cursorStart interface{}, from time.Time, to time.Time) ([]model.Trade, error) {
+	fromMillis := from.UnixNano() / int64(time.Millisecond)
+	toMillis := to.UnixNano() / int64(time.Millisecond)
+
+	trades := []model.Trade{}
+	cursor := cursorStart
+	for {
+		result, e := fetcher.GetTradeHistory(pair, cursor, nil)
+		if e != nil {
+			return nil, fmt.Errorf("error while fetching trade history (cursor=%v): %s", cursor, e)
+		}
+
+		pastTo := false
+		for _, t := range result.Trades {
+			if t.Timestamp == nil {
+				continue
+			}
+			ts := t.Timestamp.AsInt64()
+			if ts > toMillis {
+				pastTo = true
+				continue
+			}
+			if ts >= fromMillis {
+				trades = append(trades, t)
+			}
+		}
+
+		if len(result.Trades) == 0 || pastTo || fmt.Sprintf("%v", result.Cursor) == fmt.Sprintf("%v", cursor) {
+			return trades, nil
+		}
+		cursor = result.Cursor
+	}
+}
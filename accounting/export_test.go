@@ -0,0 +1,82 @@
+package accounting
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func makeExportTrade(id string, ts int64, action model.OrderAction, price float64, volume float64) model.Trade {
+	t := makeFill(action, price, volume, 0.01)
+	t.Timestamp = model.MakeTimestamp(ts)
+	t.TransactionID = model.MakeTransactionID(id)
+	return t
+}
+
+func TestExportRecords(t *testing.T) {
+	trades := []model.Trade{
+		makeExportTrade("2", 1577836860000, model.OrderActionSell, 0.05, 100),
+		makeExportTrade("1", 1577836800000, model.OrderActionBuy, 0.04, 10),
+	}
+	records := MakeExportRecords("sdex", "XLM", "USD", trades)
+	SortExportRecords(records)
+
+	if !assert.Equal(t, 2, len(records)) {
+		return
+	}
+	assert.Equal(t, "1", records[0].TradeID)
+	assert.Equal(t, "2020-01-01T00:00:00.000Z", records[0].Time)
+	assert.Equal(t, "buy", records[0].Side)
+	assert.Equal(t, "0.4000000", records[0].QuoteAmount)
+	assert.Equal(t, "sell", records[1].Side)
+	assert.Equal(t, "5.0000000", records[1].QuoteAmount)
+
+	var buf bytes.Buffer
+	e := WriteCSV(&buf, records)
+	if !assert.NoError(t, e) {
+		return
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, 3, len(lines))
+	assert.Equal(t, "timestamp,time,exchange,trade_id,side,base_asset,base_amount,quote_asset,quote_amount,price,fee,fee_asset", lines[0])
+	assert.Equal(t, "1577836800000,2020-01-01T00:00:00.000Z,sdex,1,buy,XLM,10.0000000,USD,0.4000000,0.0400000,0.0100000,USD", lines[1])
+}
+
+// pagedTradeFetcher returns one trade per call starting from the index in the cursor
+type pagedTradeFetcher struct {
+	trades []model.Trade
+}
+
+func (f *pagedTradeFetcher) GetTradeHistory(pair model.TradingPair, maybeCursorStart interface{}, maybeCursorEnd interface{}) (*api.TradeHistoryResult, error) {
+	i := 0
+	if maybeCursorStart != nil {
+		i = maybeCursorStart.(int)
+	}
+	if i >= len(f.trades) {
+		return &api.TradeHistoryResult{Cursor: maybeCursorStart, Trades: []model.Trade{}}, nil
+	}
+	return &api.TradeHistoryResult{Cursor: i + 1, Trades: f.trades[i : i+1]}, nil
+}
+
+func TestFetchTradeHistory(t *testing.T) {
+	fetcher := &pagedTradeFetcher{}
+	for i := 0; i < 5; i++ {
+		fetcher.trades = append(fetcher.trades, makeExportTrade(fmt.Sprintf("%d", i), int64(i)*1000, model.OrderActionBuy, 1, 1))
+	}
+
+	trades, e := FetchTradeHistory(fetcher, *testPair, nil, time.Unix(1, 0), time.Unix(3, 0))
+	if !assert.NoError(t, e) {
+		return
+	}
+	ids := []string{}
+	for _, trade := range trades {
+		ids = append(ids, trade.TransactionID.String())
+	}
+	assert.Equal(t, []string{"1", "2", "3"}, ids)
+}
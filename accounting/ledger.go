@@ -0,0 +1,259 @@
+package accounting
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// LedgerVersion is the format version of the ledger file written by this version of Kelp. Version 1 recorded the total of the fees without
+// their assets, those totals are kept under an unknown asset when a version 1 file is loaded.
+const LedgerVersion = 2
+
+// Position is the accounting state of a trading pair, built up from all the fills on that pair.
+// Prices and P&L are in units of the quote asset, quantities are in units of the base asset.
+type Position struct {
+	Pair         string             `json:"pair"`
+	BaseAsset    string             `json:"base_asset"`
+	QuoteAsset   string             `json:"quote_asset"`
+	Quantity     float64            `json:"quantity"`     // negative when the bot has sold more than it has bought
+	AverageCost  float64            `json:"average_cost"` // average price of the open quantity, 0 when flat
+	RealizedPnL  float64            `json:"realized_pnl"` // excludes fees
+	Fees         map[string]float64 `json:"fees"`         // as reported by the exchange keyed by the asset they were charged in, "" when not reported
+	BoughtVolume float64            `json:"bought_volume"`
+	SoldVolume   float64            `json:"sold_volume"`
+	NumFills     int64              `json:"num_fills"`
+
+	FeesPaidDeprecated float64 `json:"fees_paid,omitempty"` // only read from version 1 files
+}
+
+// PositionPnL is a Position valued at a mark price
+type PositionPnL struct {
+	Position
+	MarkPrice     *float64 `json:"mark_price"`     // nil when there is no price available for the pair
+	UnrealizedPnL *float64 `json:"unrealized_pnl"` // nil when there is no mark price
+	// fees in units of the quote asset, fees charged in the base asset are converted at the mark price
+	FeesInQuote float64 `json:"fees_in_quote"`
+	// fees that cannot be converted to the quote asset keyed by their asset, they are not included in the NetPnL
+	UnconvertedFees map[string]float64 `json:"unconverted_fees"`
+	NetPnL          float64            `json:"net_pnl"` // realized + unrealized - fees in quote
+}
+
+// Report is the P&L of all the positions in the ledger
+type Report struct {
+	Positions []PositionPnL `json:"positions"`
+}
+
+// ledgerFile is the persisted form of the ledger
+type ledgerFile struct {
+	Version   int                  `json:"version"`
+	Positions map[string]*Position `json:"positions"`
+	Baselines map[string]*Baseline `json:"baselines,omitempty"`
+}
+
+// Ledger keeps the realized P&L, average cost basis and fees of the fills seen by the bot, using the average cost method
+type Ledger struct {
+	filepath  string // empty when the ledger is only kept in memory
+	mutex     *sync.Mutex
+	positions map[string]*Position
+	baselines map[string]*Baseline
+}
+
+// ensure it implements FillHandler
+var _ api.FillHandler = &Ledger{}
+
+// MakeLedger is a factory method, it loads the ledger from filepath if the file exists. Use an empty filepath to keep the ledger in memory.
+func MakeLedger(filepath string) (*Ledger, error) {
+	l := &Ledger{
+		filepath:  filepath,
+		mutex:     &sync.Mutex{},
+		positions: map[string]*Position{},
+		baselines: map[string]*Baseline{},
+	}
+	if filepath == "" {
+		return l, nil
+	}
+
+	contents, e := ioutil.ReadFile(filepath)
+	if os.IsNotExist(e) {
+		return l, nil
+	} else if e != nil {
+		return nil, fmt.Errorf("could not read ledger file '%s': %s", filepath, e)
+	}
+
+	var f ledgerFile
+	e = json.Unmarshal(contents, &f)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse ledger file '%s': %s", filepath, e)
+	}
+	if f.Version > LedgerVersion {
+		return nil, fmt.Errorf("ledger file '%s' was written by a newer version of Kelp (version=%d, max supported version=%d), upgrade Kelp or move the file", filepath, f.Version, LedgerVersion)
+	}
+	if f.Positions != nil {
+		l.positions = f.Positions
+	}
+	if f.Baselines != nil {
+		l.baselines = f.Baselines
+	}
+	for _, p := range l.positions {
+		p.Fees = migrateFees(p.Fees, &p.FeesPaidDeprecated)
+	}
+	for _, b := range l.baselines {
+		b.Fees = migrateFees(b.Fees, &b.FeesPaidDeprecated)
+	}
+	return l, nil
+}
+
+// migrateFees moves the total of the fees recorded by version 1 of the ledger, which did not record the assets of the fees, to the unknown asset
+func migrateFees(fees map[string]float64, deprecatedTotal *float64) map[string]float64 {
+	if fees == nil {
+		fees = map[string]float64{}
+	}
+	if *deprecatedTotal != 0 {
+		fees[""] += *deprecatedTotal
+		*deprecatedTotal = 0
+	}
+	return fees
+}
+
+// HandleFill impl, records the fill and persists the ledger
+func (l *Ledger) HandleFill(trade model.Trade) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	e := l.record(trade)
+	if e != nil {
+		return fmt.Errorf("unable to record fill in ledger: %s", e)
+	}
+	return l.save()
+}
+
+func (l *Ledger) record(trade model.Trade) error {
+	if trade.Pair == nil || trade.Price == nil || trade.Volume == nil {
+		return fmt.Errorf("fill is missing the pair, price or volume: %s", trade)
+	}
+
+	pair := trade.Pair.String()
+	p, ok := l.positions[pair]
+	if !ok {
+		p = &Position{Pair: pair, Fees: map[string]float64{}}
+		l.positions[pair] = p
+	}
+	p.BaseAsset = string(trade.Pair.Base)
+	p.QuoteAsset = string(trade.Pair.Quote)
+
+	volume := trade.Volume.AsFloat()
+	if trade.OrderAction.IsBuy() {
+		p.BoughtVolume += volume
+		applyFill(p, volume, trade.Price.AsFloat())
+	} else {
+		p.SoldVolume += volume
+		applyFill(p, -volume, trade.Price.AsFloat())
+	}
+	if trade.Fee != nil && trade.Fee.AsFloat() != 0 {
+		p.Fees[string(trade.FeeAsset)] += trade.Fee.AsFloat()
+	}
+	p.NumFills++
+	return nil
+}
+
+// applyFill updates the position for a fill of signedVolume (positive for buys) at price. Fills that increase the position move the average
+// cost towards the price, fills that reduce the position realize the difference between the price and the average cost.
+func applyFill(p *Position, signedVolume float64, price float64) {
+	if p.Quantity == 0 || math.Signbit(p.Quantity) == math.Signbit(signedVolume) {
+		newQuantity := p.Quantity + signedVolume
+		p.AverageCost = (math.Abs(p.Quantity)*p.AverageCost + math.Abs(signedVolume)*price) / math.Abs(newQuantity)
+		p.Quantity = newQuantity
+		return
+	}
+
+	closedVolume := math.Min(math.Abs(signedVolume), math.Abs(p.Quantity))
+	if p.Quantity > 0 {
+		p.RealizedPnL += closedVolume * (price - p.AverageCost)
+	} else {
+		p.RealizedPnL += closedVolume * (p.AverageCost - price)
+	}
+
+	p.Quantity += signedVolume
+	if math.Abs(signedVolume) > closedVolume {
+		// the fill flipped the position, the remaining quantity was opened at this price
+		p.AverageCost = price
+	} else if p.Quantity == 0 {
+		p.AverageCost = 0
+	}
+}
+
+// save writes the ledger to a temporary file first so a crash does not leave a partially written ledger behind. Callers need to hold mutex.
+func (l *Ledger) save() error {
+	if l.filepath == "" {
+		return nil
+	}
+
+	contents, e := json.MarshalIndent(ledgerFile{Version: LedgerVersion, Positions: l.positions, Baselines: l.baselines}, "", "  ")
+	if e != nil {
+		return fmt.Errorf("could not marshal ledger: %s", e)
+	}
+	tmpFilepath := l.filepath + ".tmp"
+	e = ioutil.WriteFile(tmpFilepath, contents, 0644)
+	if e != nil {
+		return fmt.Errorf("could not write ledger file '%s': %s", tmpFilepath, e)
+	}
+	e = os.Rename(tmpFilepath, l.filepath)
+	if e != nil {
+		return fmt.Errorf("could not move ledger file '%s' to '%s': %s", tmpFilepath, l.filepath, e)
+	}
+	return nil
+}
+
+// Flush waits for a fill that is being recorded and writes the ledger, so the ledger is complete when the bot shuts down
+func (l *Ledger) Flush() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.save()
+}
+
+// Report values the positions at the markPrices, keyed by the display string of the trading pair, sorted by pair. Fees are subtracted from
+// the NetPnL when they are charged in the quote asset, or in the base asset when there is a mark price to convert them with.
+func (l *Ledger) Report(markPrices map[string]float64) *Report {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	positions := []PositionPnL{}
+	for pair, p := range l.positions {
+		pnl := PositionPnL{
+			Position:        *p,
+			NetPnL:          p.RealizedPnL,
+			UnconvertedFees: map[string]float64{},
+		}
+		mark, hasMark := markPrices[pair]
+		if hasMark {
+			unrealized := p.Quantity * (mark - p.AverageCost)
+			pnl.MarkPrice = &mark
+			pnl.UnrealizedPnL = &unrealized
+			pnl.NetPnL += unrealized
+		}
+		for asset, fee := range p.Fees {
+			switch {
+			case asset != "" && asset == p.QuoteAsset:
+				pnl.FeesInQuote += fee
+			case asset != "" && asset == p.BaseAsset && hasMark:
+				pnl.FeesInQuote += fee * mark
+			default:
+				pnl.UnconvertedFees[asset] += fee
+			}
+		}
+		pnl.NetPnL -= pnl.FeesInQuote
+		positions = append(positions, pnl)
+	}
+	sort.Slice(positions, func(i int, j int) bool {
+		return positions[i].Pair < positions[j].Pair
+	})
+	return &Report{Positions: positions}
+}
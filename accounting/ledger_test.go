@@ -0,0 +1,221 @@
+package accounting
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var testPair = &model.TradingPair{Base: model.Asset("XLM"), Quote: model.Asset("USD")}
+
+func makeFill(action model.OrderAction, price float64, volume float64, fee float64) model.Trade {
+	return model.Trade{
+		Order: model.Order{
+			Pair:        testPair,
+			OrderAction: action,
+			OrderType:   model.OrderTypeLimit,
+			Price:       model.NumberFromFloat(price, 7),
+			Volume:      model.NumberFromFloat(volume, 7),
+		},
+		Fee:      model.NumberFromFloat(fee, 7),
+		FeeAsset: testPair.Quote,
+	}
+}
+
+func TestLedgerHandleFill(t *testing.T) {
+	testCases := []struct {
+		name             string
+		fills            []model.Trade
+		wantQuantity     float64
+		wantAverageCost  float64
+		wantRealizedPnL  float64
+		wantFeesPaid     float64
+		wantUnrealizedAt float64 // unrealized P&L at a mark price of 1.0
+	}{
+		{
+			name: "buys average the cost",
+			fills: []model.Trade{
+				makeFill(model.OrderActionBuy, 0.9, 10, 0.01),
+				makeFill(model.OrderActionBuy, 0.8, 30, 0.01),
+			},
+			wantQuantity:     40,
+			wantAverageCost:  0.825,
+			wantRealizedPnL:  0,
+			wantFeesPaid:     0.02,
+			wantUnrealizedAt: 7,
+		}, {
+			name: "partial close realizes against the average cost",
+			fills: []model.Trade{
+				makeFill(model.OrderActionBuy, 0.9, 10, 0),
+				makeFill(model.OrderActionBuy, 0.8, 30, 0),
+				makeFill(model.OrderActionSell, 1.1, 20, 0.05),
+			},
+			wantQuantity:     20,
+			wantAverageCost:  0.825,
+			wantRealizedPnL:  5.5,
+			wantFeesPaid:     0.05,
+			wantUnrealizedAt: 3.5,
+		}, {
+			name: "full close resets the average cost",
+			fills: []model.Trade{
+				makeFill(model.OrderActionBuy, 1.0, 10, 0),
+				makeFill(model.OrderActionSell, 0.9, 10, 0),
+			},
+			wantQuantity:     0,
+			wantAverageCost:  0,
+			wantRealizedPnL:  -1,
+			wantUnrealizedAt: 0,
+		}, {
+			name: "short position",
+			fills: []model.Trade{
+				makeFill(model.OrderActionSell, 1.2, 10, 0),
+				makeFill(model.OrderActionBuy, 1.1, 5, 0),
+			},
+			wantQuantity:     -5,
+			wantAverageCost:  1.2,
+			wantRealizedPnL:  0.5,
+			wantUnrealizedAt: 1,
+		}, {
+			name: "fill flips the position",
+			fills: []model.Trade{
+				makeFill(model.OrderActionBuy, 1.0, 10, 0),
+				makeFill(model.OrderActionSell, 1.5, 15, 0),
+			},
+			wantQuantity:     -5,
+			wantAverageCost:  1.5,
+			wantRealizedPnL:  5,
+			wantUnrealizedAt: 2.5,
+		},
+	}
+
+	for _, kase := range testCases {
+		t.Run(kase.name, func(t *testing.T) {
+			l, e := MakeLedger("")
+			if !assert.NoError(t, e) {
+				return
+			}
+			for _, f := range kase.fills {
+				if !assert.NoError(t, l.HandleFill(f)) {
+					return
+				}
+			}
+
+			report := l.Report(map[string]float64{testPair.String(): 1.0})
+			if !assert.Equal(t, 1, len(report.Positions)) {
+				return
+			}
+			p := report.Positions[0]
+			assert.Equal(t, int64(len(kase.fills)), p.NumFills)
+			assert.InDelta(t, kase.wantQuantity, p.Quantity, 0.0000001)
+			assert.InDelta(t, kase.wantAverageCost, p.AverageCost, 0.0000001)
+			assert.InDelta(t, kase.wantRealizedPnL, p.RealizedPnL, 0.0000001)
+			assert.InDelta(t, kase.wantFeesPaid, p.FeesInQuote, 0.0000001)
+			assert.Equal(t, 0, len(p.UnconvertedFees))
+			if !assert.NotNil(t, p.UnrealizedPnL) {
+				return
+			}
+			assert.InDelta(t, kase.wantUnrealizedAt, *p.UnrealizedPnL, 0.0000001)
+			assert.InDelta(t, kase.wantRealizedPnL+kase.wantUnrealizedAt-kase.wantFeesPaid, p.NetPnL, 0.0000001)
+		})
+	}
+}
+
+func TestLedgerReportWithoutMarkPrice(t *testing.T) {
+	l, e := MakeLedger("")
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.NoError(t, l.HandleFill(makeFill(model.OrderActionBuy, 1.0, 10, 0.1)))
+
+	report := l.Report(map[string]float64{})
+	if !assert.Equal(t, 1, len(report.Positions)) {
+		return
+	}
+	assert.Nil(t, report.Positions[0].MarkPrice)
+	assert.Nil(t, report.Positions[0].UnrealizedPnL)
+	assert.InDelta(t, -0.1, report.Positions[0].NetPnL, 0.0000001)
+}
+
+func TestLedgerReportFeeAssets(t *testing.T) {
+	l, e := MakeLedger("")
+	if !assert.NoError(t, e) {
+		return
+	}
+	fill := makeFill(model.OrderActionBuy, 1.0, 10, 0.1)
+	assert.NoError(t, l.HandleFill(fill))
+	fill.Fee = model.NumberFromFloat(0.5, 7)
+	fill.FeeAsset = testPair.Base
+	assert.NoError(t, l.HandleFill(fill))
+	fill.Fee = model.NumberFromFloat(0.00001, 7)
+	fill.FeeAsset = model.Asset("BNB")
+	assert.NoError(t, l.HandleFill(fill))
+
+	// fees in the base asset are converted at the mark price, fees in other assets are reported separately
+	p := l.Report(map[string]float64{testPair.String(): 2.0}).Positions[0]
+	assert.InDelta(t, 1.1, p.FeesInQuote, 0.0000001)
+	assert.Equal(t, map[string]float64{"BNB": 0.00001}, p.UnconvertedFees)
+	assert.InDelta(t, 30-1.1, p.NetPnL, 0.0000001)
+
+	// without a mark price the fees in the base asset cannot be converted
+	p = l.Report(map[string]float64{}).Positions[0]
+	assert.InDelta(t, 0.1, p.FeesInQuote, 0.0000001)
+	assert.Equal(t, map[string]float64{"XLM": 0.5, "BNB": 0.00001}, p.UnconvertedFees)
+	assert.InDelta(t, -0.1, p.NetPnL, 0.0000001)
+}
+
+func TestLedgerLoadVersion1(t *testing.T) {
+	dir, e := ioutil.TempDir("", "ledger")
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "pnl.json")
+	contents := `{"version": 1, "positions": {"XLM/USD": {"pair": "XLM/USD", "quantity": 10, "average_cost": 1, "fees_paid": 0.3, "num_fills": 1}}}`
+	if !assert.NoError(t, ioutil.WriteFile(filename, []byte(contents), 0644)) {
+		return
+	}
+
+	l, e := MakeLedger(filename)
+	if !assert.NoError(t, e) {
+		return
+	}
+	// the asset of the fees of version 1 files is unknown so they are not subtracted from the P&L
+	p := l.Report(map[string]float64{"XLM/USD": 1.0}).Positions[0]
+	assert.Equal(t, map[string]float64{"": 0.3}, p.Fees)
+	assert.Equal(t, 0.0, p.FeesPaidDeprecated)
+	assert.Equal(t, map[string]float64{"": 0.3}, p.UnconvertedFees)
+	assert.InDelta(t, 0.0, p.NetPnL, 0.0000001)
+}
+
+func TestLedgerPersistence(t *testing.T) {
+	dir, e := ioutil.TempDir("", "ledger")
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "pnl.json")
+
+	l, e := MakeLedger(filename)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.NoError(t, l.HandleFill(makeFill(model.OrderActionBuy, 1.0, 10, 0)))
+	assert.NoError(t, l.HandleFill(makeFill(model.OrderActionSell, 1.2, 4, 0)))
+
+	reloaded, e := MakeLedger(filename)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, l.Report(nil), reloaded.Report(nil))
+
+	e = ioutil.WriteFile(filename, []byte(`{"version": 3, "positions": {}}`), 0644)
+	if !assert.NoError(t, e) {
+		return
+	}
+	_, e = MakeLedger(filename)
+	assert.Error(t, e)
+}
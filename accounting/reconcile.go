@@ -0,0 +1,252 @@
+package accounting
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/stellar/kelp/api"
+)
+
+// DefaultDriftThreshold is the fraction of a balance that it can drift from the expected balance before the drift is reported
+const DefaultDriftThreshold = 0.01
+
+// minDrift is the smallest unit of an asset on SDEX
+const minDrift = 0.0000001
+
+// Baseline is a snapshot of the balances of the account along with the position in the ledger at that time. After the baseline the
+// balances are expected to change only by the fills that the ledger records.
+type Baseline struct {
+	Pair      string             `json:"pair"`
+	Time      string             `json:"time"` // RFC3339
+	Base      float64            `json:"base"`
+	Quote     float64            `json:"quote"`
+	Quantity  float64            `json:"quantity"`   // quantity of the position in the ledger
+	QuoteFlow float64            `json:"quote_flow"` // net quote received by the position in the ledger
+	Fees      map[string]float64 `json:"fees"`       // fees of the position in the ledger keyed by their asset
+	NumFills  int64              `json:"num_fills"`
+
+	FeesPaidDeprecated float64 `json:"fees_paid,omitempty"` // only read from version 1 files
+}
+
+// Reconciliation compares the balances expected from the baseline and the fills recorded since then against the actual balances.
+// Drift is the actual balance minus the expected balance, it comes from fees, fills that were missed or transfers in or out of the account.
+type Reconciliation struct {
+	Pair          string             `json:"pair"`
+	BaselineTime  string             `json:"baseline_time"`
+	NumFills      int64              `json:"num_fills"` // since the baseline
+	Fees          map[string]float64 `json:"fees"`      // since the baseline, as reported by the exchange keyed by the asset they were charged in
+	ExpectedBase  float64            `json:"expected_base"`
+	ActualBase    float64            `json:"actual_base"`
+	BaseDrift     float64            `json:"base_drift"`
+	ExpectedQuote float64            `json:"expected_quote"`
+	ActualQuote   float64            `json:"actual_quote"`
+	QuoteDrift    float64            `json:"quote_drift"`
+	Threshold     float64            `json:"threshold"`
+	Exceeded      bool               `json:"exceeded"`
+}
+
+// String is the stringer function
+func (r *Reconciliation) String() string {
+	return fmt.Sprintf("Reconciliation[pair=%s, baselineTime=%s, numFills=%d, fees=%v, base(expected=%.8f, actual=%.8f, drift=%.8f), quote(expected=%.8f, actual=%.8f, drift=%.8f), threshold=%.4f, exceeded=%v]",
+		r.Pair, r.BaselineTime, r.NumFills, r.Fees, r.ExpectedBase, r.ActualBase, r.BaseDrift, r.ExpectedQuote, r.ActualQuote, r.QuoteDrift, r.Threshold, r.Exceeded)
+}
+
+// QuoteFlow is the net quote received by the position, which is the realized P&L less the cost of the open quantity under the average cost method
+func (p Position) QuoteFlow() float64 {
+	return p.RealizedPnL - p.Quantity*p.AverageCost
+}
+
+// GetBaseline returns the baseline of the pair, nil when there is none
+func (l *Ledger) GetBaseline(pair string) *Baseline {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	b, ok := l.baselines[pair]
+	if !ok {
+		return nil
+	}
+	bCopy := *b
+	return &bCopy
+}
+
+// SetBaseline records the balances of the account and the current position of the pair as the baseline to reconcile against and persists it
+func (l *Ledger) SetBaseline(pair string, base float64, quote float64, t time.Time) (*Baseline, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	var p Position
+	if position, ok := l.positions[pair]; ok {
+		p = *position
+	}
+	b := &Baseline{
+		Pair:      pair,
+		Time:      t.UTC().Format(time.RFC3339),
+		Base:      base,
+		Quote:     quote,
+		Quantity:  p.Quantity,
+		QuoteFlow: p.QuoteFlow(),
+		Fees:      copyFees(p.Fees),
+		NumFills:  p.NumFills,
+	}
+	l.baselines[pair] = b
+	e := l.save()
+	if e != nil {
+		return nil, fmt.Errorf("unable to save baseline in ledger: %s", e)
+	}
+	bCopy := *b
+	return &bCopy, nil
+}
+
+// Reconcile compares the actual balances against the balances expected from the baseline of the pair and the fills since then. The drift
+// exceeds the threshold when it is more than that fraction of the balance.
+func (l *Ledger) Reconcile(pair string, actualBase float64, actualQuote float64, threshold float64) (*Reconciliation, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	b, ok := l.baselines[pair]
+	if !ok {
+		return nil, fmt.Errorf("there is no baseline for pair %s to reconcile against", pair)
+	}
+	var p Position
+	if position, ok := l.positions[pair]; ok {
+		p = *position
+	}
+
+	expectedBase := b.Base + p.Quantity - b.Quantity
+	expectedQuote := b.Quote + p.QuoteFlow() - b.QuoteFlow
+	r := &Reconciliation{
+		Pair:          pair,
+		BaselineTime:  b.Time,
+		NumFills:      p.NumFills - b.NumFills,
+		Fees:          subtractFees(p.Fees, b.Fees),
+		ExpectedBase:  expectedBase,
+		ActualBase:    actualBase,
+		BaseDrift:     actualBase - expectedBase,
+		ExpectedQuote: expectedQuote,
+		ActualQuote:   actualQuote,
+		QuoteDrift:    actualQuote - expectedQuote,
+		Threshold:     threshold,
+	}
+	r.Exceeded = driftExceeds(r.BaseDrift, expectedBase, actualBase, threshold) || driftExceeds(r.QuoteDrift, expectedQuote, actualQuote, threshold)
+	return r, nil
+}
+
+// copyFees returns a copy of the fees keyed by their asset
+func copyFees(fees map[string]float64) map[string]float64 {
+	c := map[string]float64{}
+	for asset, fee := range fees {
+		c[asset] = fee
+	}
+	return c
+}
+
+// subtractFees returns the fees in each asset that were paid after the baseline fees, leaving out the assets without any fees since then
+func subtractFees(fees map[string]float64, baseline map[string]float64) map[string]float64 {
+	diff := map[string]float64{}
+	for asset, fee := range fees {
+		if d := fee - baseline[asset]; d != 0 {
+			diff[asset] = d
+		}
+	}
+	return diff
+}
+
+// driftExceeds is true when the drift is more than the threshold fraction of the larger of the expected and actual balance, drift below the
+// smallest unit of an asset is rounding error
+func driftExceeds(drift float64, expected float64, actual float64, threshold float64) bool {
+	if math.Abs(drift) < minDrift {
+		return false
+	}
+	return math.Abs(drift) > threshold*math.Max(math.Abs(expected), math.Abs(actual))
+}
+
+// reconcileAlertAfterChecks is the number of consecutive checks that need to exceed the threshold before alerting, since a fill that the
+// fill tracker has not seen yet shows up as drift until the next check
+const reconcileAlertAfterChecks = 2
+
+// BalanceFetcher returns the balances of the base and quote assets of the account
+type BalanceFetcher func() (base float64, quote float64, e error)
+
+// Reconciler reconciles the balances of the account against the ledger on a schedule and alerts when the drift exceeds the threshold
+type Reconciler struct {
+	ledger        *Ledger
+	pair          string
+	fetchBalances BalanceFetcher
+	threshold     float64
+	alert         api.Alert
+
+	// uninitialized
+	numExceeded int
+}
+
+// MakeReconciler is a factory method, a threshold of 0 uses DefaultDriftThreshold
+func MakeReconciler(ledger *Ledger, pair string, fetchBalances BalanceFetcher, threshold float64, alert api.Alert) *Reconciler {
+	if threshold == 0 {
+		threshold = DefaultDriftThreshold
+	}
+	return &Reconciler{
+		ledger:        ledger,
+		pair:          pair,
+		fetchBalances: fetchBalances,
+		threshold:     threshold,
+		alert:         alert,
+	}
+}
+
+// Init sets the baseline to the current balances when the ledger does not have one for the pair yet
+func (r *Reconciler) Init() error {
+	if b := r.ledger.GetBaseline(r.pair); b != nil {
+		log.Printf("reconciling balances of pair %s against the baseline from %s\n", r.pair, b.Time)
+		return nil
+	}
+
+	base, quote, e := r.fetchBalances()
+	if e != nil {
+		return fmt.Errorf("unable to fetch balances for the reconciliation baseline: %s", e)
+	}
+	b, e := r.ledger.SetBaseline(r.pair, base, quote, time.Now())
+	if e != nil {
+		return e
+	}
+	log.Printf("set reconciliation baseline for pair %s: base=%.8f, quote=%.8f\n", r.pair, b.Base, b.Quote)
+	return nil
+}
+
+// Check reconciles the current balances and alerts when the drift has exceeded the threshold in consecutive checks
+func (r *Reconciler) Check() (*Reconciliation, error) {
+	base, quote, e := r.fetchBalances()
+	if e != nil {
+		return nil, fmt.Errorf("unable to fetch balances to reconcile: %s", e)
+	}
+	rec, e := r.ledger.Reconcile(r.pair, base, quote, r.threshold)
+	if e != nil {
+		return nil, e
+	}
+	log.Printf("reconciled balances: %s\n", rec)
+
+	if !rec.Exceeded {
+		r.numExceeded = 0
+		return rec, nil
+	}
+	r.numExceeded++
+	if r.numExceeded == reconcileAlertAfterChecks {
+		e = r.alert.Trigger(fmt.Sprintf("balance drift on pair %s exceeds the threshold of %.4f", r.pair, r.threshold), rec)
+		if e != nil {
+			return rec, fmt.Errorf("unable to trigger alert for balance drift: %s", e)
+		}
+	}
+	return rec, nil
+}
+
+// Run checks the balances every interval, it should be executed in a new thread
+func (r *Reconciler) Run(interval time.Duration) {
+	for {
+		time.Sleep(interval)
+		_, e := r.Check()
+		if e != nil {
+			log.Printf("error reconciling balances: %s\n", e)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package accounting
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPositionQuoteFlow(t *testing.T) {
+	l, e := MakeLedger("")
+	if !assert.NoError(t, e) {
+		return
+	}
+	// -10 + 30 - 2.5 = 17.5 quote received, including the flip from long to short
+	assert.NoError(t, l.HandleFill(makeFill(model.OrderActionBuy, 1.0, 10, 0)))
+	assert.NoError(t, l.HandleFill(makeFill(model.OrderActionSell, 2.0, 15, 0)))
+	assert.NoError(t, l.HandleFill(makeFill(model.OrderActionBuy, 0.5, 5, 0)))
+
+	p := l.Report(nil).Positions[0].Position
+	assert.InDelta(t, 0.0, p.Quantity, 0.0000001)
+	assert.InDelta(t, 17.5, p.QuoteFlow(), 0.0000001)
+}
+
+func TestLedgerReconcile(t *testing.T) {
+	pair := testPair.String()
+	now := time.Unix(1600000000, 0)
+
+	testCases := []struct {
+		name           string
+		actualBase     float64
+		actualQuote    float64
+		wantBaseDrift  float64
+		wantQuoteDrift float64
+		wantExceeded   bool
+	}{
+		{
+			name:         "no drift",
+			actualBase:   106,
+			actualQuote:  45.2,
+			wantExceeded: false,
+		}, {
+			name:           "fees below the threshold",
+			actualBase:     106,
+			actualQuote:    45.0,
+			wantQuoteDrift: -0.2,
+			wantExceeded:   false,
+		}, {
+			name:           "missed fill",
+			actualBase:     96,
+			actualQuote:    57.2,
+			wantBaseDrift:  -10,
+			wantQuoteDrift: 12,
+			wantExceeded:   true,
+		}, {
+			name:          "transfer out",
+			actualBase:    56,
+			actualQuote:   45.2,
+			wantBaseDrift: -50,
+			wantExceeded:  true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			l, e := MakeLedger("")
+			if !assert.NoError(t, e) {
+				return
+			}
+			// fills before the baseline are already part of the balances
+			assert.NoError(t, l.HandleFill(makeFill(model.OrderActionBuy, 1.0, 20, 0)))
+			_, e = l.SetBaseline(pair, 100, 50, now)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.NoError(t, l.HandleFill(makeFill(model.OrderActionBuy, 1.2, 10, 0.01)))
+			assert.NoError(t, l.HandleFill(makeFill(model.OrderActionSell, 1.8, 4, 0.01)))
+
+			r, e := l.Reconcile(pair, k.actualBase, k.actualQuote, DefaultDriftThreshold)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, "2020-09-13T12:26:40Z", r.BaselineTime)
+			assert.Equal(t, int64(2), r.NumFills)
+			assert.InDelta(t, 0.02, r.Fees["USD"], 0.0000001)
+			// 100 + 10 - 4 base and 50 - 12 + 7.2 quote
+			assert.InDelta(t, 106.0, r.ExpectedBase, 0.0000001)
+			assert.InDelta(t, 45.2, r.ExpectedQuote, 0.0000001)
+			assert.InDelta(t, k.wantBaseDrift, r.BaseDrift, 0.0000001)
+			assert.InDelta(t, k.wantQuoteDrift, r.QuoteDrift, 0.0000001)
+			assert.Equal(t, k.wantExceeded, r.Exceeded)
+		})
+	}
+}
+
+func TestLedgerReconcileWithoutBaseline(t *testing.T) {
+	l, e := MakeLedger("")
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Nil(t, l.GetBaseline(testPair.String()))
+	_, e = l.Reconcile(testPair.String(), 1, 1, DefaultDriftThreshold)
+	assert.Error(t, e)
+}
+
+func TestLedgerBaselinePersistence(t *testing.T) {
+	dir, e := ioutil.TempDir("", "ledger")
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "pnl.json")
+
+	l, e := MakeLedger(filename)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.NoError(t, l.HandleFill(makeFill(model.OrderActionBuy, 1.0, 10, 0)))
+	baseline, e := l.SetBaseline(testPair.String(), 100, 50, time.Unix(1600000000, 0))
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	reloaded, e := MakeLedger(filename)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, baseline, reloaded.GetBaseline(testPair.String()))
+	assert.InDelta(t, 10.0, baseline.Quantity, 0.0000001)
+	assert.InDelta(t, -10.0, baseline.QuoteFlow, 0.0000001)
+}
+
+type testAlert struct {
+	descriptions []string
+}
+
+func (a *testAlert) Trigger(description string, details interface{}) error {
+	a.descriptions = append(a.descriptions, description)
+	return nil
+}
+
+func TestReconcilerCheck(t *testing.T) {
+	l, e := MakeLedger("")
+	if !assert.NoError(t, e) {
+		return
+	}
+	base, quote := 100.0, 50.0
+	alert := &testAlert{}
+	r := MakeReconciler(l, testPair.String(), func() (float64, float64, error) { return base, quote, nil }, 0, alert)
+
+	if !assert.NoError(t, r.Init()) {
+		return
+	}
+	assert.Equal(t, 100.0, l.GetBaseline(testPair.String()).Base)
+	// the baseline is kept once it is set
+	base = 90
+	if !assert.NoError(t, r.Init()) {
+		return
+	}
+	assert.Equal(t, 100.0, l.GetBaseline(testPair.String()).Base)
+
+	// the first check that exceeds the threshold can be a fill that was not tracked yet
+	rec, e := r.Check()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.True(t, rec.Exceeded)
+	assert.Equal(t, 0, len(alert.descriptions))
+
+	// the fill is tracked by the next check
+	assert.NoError(t, l.HandleFill(makeFill(model.OrderActionSell, 1.0, 10, 0)))
+	quote = 60
+	rec, e = r.Check()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.False(t, rec.Exceeded)
+
+	// a transfer out of the account exceeds the threshold in consecutive checks and alerts once
+	base = 80
+	for i := 0; i < 3; i++ {
+		_, e = r.Check()
+		if !assert.NoError(t, e) {
+			return
+		}
+	}
+	assert.Equal(t, []string{"balance drift on pair XLM/USD exceeds the threshold of 0.0100"}, alert.descriptions)
+}
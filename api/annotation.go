@@ -0,0 +1,31 @@
+package api
+
+// AnnotationLevel is the severity of an Annotation
+type AnnotationLevel string
+
+// AnnotationLevel values
+const (
+	AnnotationLevelInfo    AnnotationLevel = "info"
+	AnnotationLevelWarning AnnotationLevel = "warning"
+	AnnotationLevelError   AnnotationLevel = "error"
+)
+
+// Annotation is a structured hint emitted by a strategy so the operator can see why it behaves the way it does
+type Annotation struct {
+	Level           AnnotationLevel `json:"level"`
+	Source          string          `json:"source"`
+	Message         string          `json:"message"`
+	Count           int             `json:"count"` // number of times the same annotation was emitted while it was visible
+	FirstSeenMillis int64           `json:"first_seen_millis"`
+	LastSeenMillis  int64           `json:"last_seen_millis"`
+}
+
+// Annotator receives the annotations emitted by a strategy
+type Annotator interface {
+	Annotate(level AnnotationLevel, source string, message string)
+}
+
+// Annotatable is implemented by strategies that emit annotations, the annotator is set before the bot starts updating
+type Annotatable interface {
+	SetAnnotator(annotator Annotator)
+}
@@ -1,7 +1,9 @@
 package api
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/stellar/go/build"
 	hProtocol "github.com/stellar/go/protocols/horizon"
@@ -55,6 +57,13 @@ type TickerAPI interface {
 	GetTickerPrice(pairs []model.TradingPair) (map[model.TradingPair]Ticker, error)
 }
 
+// CandleAPI is the interface we use to fetch OHLCV candles from any crypto exchange
+type CandleAPI interface {
+	// GetCandles returns the candles of the pair for the interval in ascending order of time, starting at the candle that contains since,
+	// or the most recent candles when since is nil. The number of candles returned by one call depends on the exchange.
+	GetCandles(pair *model.TradingPair, interval time.Duration, since *model.Timestamp) ([]model.Candle, error)
+}
+
 // FillTracker knows how to track fills against open orders
 type FillTracker interface {
 	GetPair() (pair *model.TradingPair)
@@ -81,6 +90,35 @@ type FillTrackable interface {
 	GetLatestTradeCursor() (interface{}, error)
 }
 
+// TradeStream is implemented by exchanges that push the trades of the account as they happen, the FillTracker uses it to detect fills with
+// lower latency than polling the trade history
+type TradeStream interface {
+	// StreamTrades blocks and invokes the handler for every new trade on the pair until the stream fails, returning the error that ended it.
+	// Wrappers return ErrTradeStreamUnsupported when the exchange they wrap cannot stream trades.
+	StreamTrades(pair *model.TradingPair, handler func(trade model.Trade)) error
+}
+
+// ErrTradeStreamUnsupported is returned by StreamTrades when trades cannot be streamed
+var ErrTradeStreamUnsupported = errors.New("streaming trades is not supported by the exchange")
+
+// OrderBookStream is implemented by exchanges that push updates of the orderbook, strategies use it to read the latest orderbook without
+// making a request on every update cycle
+type OrderBookStream interface {
+	// StreamOrderBook blocks and invokes the handler with the latest orderbook of the pair (up to maxCount levels on each side) every time
+	// it changes, until the stream fails, returning the error that ended it.
+	// Wrappers return ErrOrderBookStreamUnsupported when the exchange they wrap cannot stream orderbooks.
+	StreamOrderBook(pair *model.TradingPair, maxCount int32, handler func(ob *model.OrderBook)) error
+}
+
+// ErrOrderBookStreamUnsupported is returned by StreamOrderBook when orderbooks cannot be streamed
+var ErrOrderBookStreamUnsupported = errors.New("streaming orderbooks is not supported by the exchange")
+
+// StreamingExchange is implemented by exchanges that can stream both the orderbook and the trades of the account
+type StreamingExchange interface {
+	OrderBookStream
+	TradeStream
+}
+
 // Constrainable extracts out the method that SDEX can implement for now
 type Constrainable interface {
 	// return nil if the constraint does not exist for the exchange
@@ -89,6 +127,11 @@ type Constrainable interface {
 	OverrideOrderConstraints(pair *model.TradingPair, override *model.OrderConstraintsOverride)
 }
 
+// ConstraintsRefresher is implemented by exchanges that can reload their order constraints without a restart
+type ConstraintsRefresher interface {
+	RefreshOrderConstraints() error
+}
+
 // OrderbookFetcher extracts out the method that should go into ExchangeShim for now
 type OrderbookFetcher interface {
 	GetOrderBook(pair *model.TradingPair, maxCount int32) (*model.OrderBook, error)
@@ -111,12 +154,49 @@ type TradeAPI interface {
 	AddOrder(order *model.Order) (*model.TransactionID, error)
 
 	CancelOrder(txID *model.TransactionID, pair model.TradingPair) (model.CancelOrderResult, error)
+
+	// GetTradesForOrder returns the trades of the account that filled the order in ascending order of time, with the prices and fees as
+	// reported by the exchange. It is empty when the order has not been filled.
+	GetTradesForOrder(txID *model.TransactionID, pair model.TradingPair) ([]model.Trade, error)
+}
+
+// OrderModifier is implemented by exchanges that can amend the price and volume of an open order in place, which takes one request instead
+// of two and, on exchanges that keep the queue position of amended orders, avoids the loss of priority of cancelling and re-placing the order
+type OrderModifier interface {
+	// ModifyOrder changes the open limit order with txID to the price, volume and post-only flag of order, returning the transaction ID of the
+	// modified order which can differ from txID. Wrappers return ErrModifyOrderUnsupported when the exchange they wrap cannot modify orders.
+	ModifyOrder(txID *model.TransactionID, order *model.Order) (*model.TransactionID, error)
 }
 
+// ErrModifyOrderUnsupported is returned by ModifyOrder when orders cannot be modified in place, callers should cancel and re-place the order
+var ErrModifyOrderUnsupported = errors.New("modifying orders is not supported by the exchange")
+
+// ServerClock is implemented by exchanges that report their current time, which is used to detect clock skew before signed requests
+// are rejected because of it
+type ServerClock interface {
+	// GetServerTime returns the current time of the exchange. Wrappers return ErrServerTimeUnsupported when the exchange they wrap
+	// does not report its time.
+	GetServerTime() (time.Time, error)
+}
+
+// ErrServerTimeUnsupported is returned by GetServerTime when the exchange does not report its time
+var ErrServerTimeUnsupported = errors.New("fetching the server time is not supported by the exchange")
+
+// OrderValidator is implemented by exchanges that can check an order without placing it, which verifies that the API key is allowed to trade
+type OrderValidator interface {
+	// ValidateOrder returns an error when the exchange would reject the order. Wrappers return ErrValidateOrderUnsupported when the exchange
+	// they wrap cannot validate orders.
+	ValidateOrder(order *model.Order) error
+}
+
+// ErrValidateOrderUnsupported is returned by ValidateOrder when orders cannot be validated without placing them
+var ErrValidateOrderUnsupported = errors.New("validating orders is not supported by the exchange")
+
 // PrepareDepositResult is the result of a PrepareDeposit call
 type PrepareDepositResult struct {
 	Fee      *model.Number // fee that will be deducted from your deposit, i.e. amount available is depositAmount - fee
 	Address  string        // address you should send the funds to
+	Memo     string        // memo or tag to set on the transfer so the exchange can credit the deposit, empty if not needed
 	ExpireTs int64         // expire time as a unix timestamp, 0 if it does not expire
 }
 
@@ -204,10 +284,65 @@ func MakeErrWithdrawAmountInvalid(amountToWithdraw *model.Number, fee *model.Num
 	return fmt.Errorf("amountToWithdraw is invalid: %s, fee: %s", amountToWithdraw.AsString(), fee.AsString())
 }
 
+// WithdrawalStatus is the state of a withdrawal from an exchange
+type WithdrawalStatus string
+
+// WithdrawalStatus values
+const (
+	WithdrawalStatusPending  WithdrawalStatus = "pending"  // the exchange has not sent the funds yet, or the transfer is not confirmed yet
+	WithdrawalStatusComplete WithdrawalStatus = "complete" // the funds were sent to the address
+	WithdrawalStatusFailed   WithdrawalStatus = "failed"   // the withdrawal failed or was canceled and the funds stay on the exchange
+)
+
+// IsFinal returns true when the status of the withdrawal will not change anymore
+func (s WithdrawalStatus) IsFinal() bool {
+	return s == WithdrawalStatusComplete || s == WithdrawalStatusFailed
+}
+
+// Withdrawal is a withdrawal from an exchange
+type Withdrawal struct {
+	ID      string // ID of the withdrawal on the exchange
+	Asset   model.Asset
+	Amount  *model.Number // nil when the exchange does not report the amount
+	Address string        // empty when the exchange does not report the address
+	Memo    string        // memo or tag of the transfer, empty if not set or not reported
+	Status  WithdrawalStatus
+	TxID    string // transaction ID of the transfer on the network of the asset, empty until the exchange sends the funds
+}
+
+// String is the stringer method
+func (w Withdrawal) String() string {
+	amount := "<nil>"
+	if w.Amount != nil {
+		amount = w.Amount.AsString()
+	}
+	return fmt.Sprintf("Withdrawal[id=%s, asset=%s, amount=%s, address=%s, memo=%s, status=%s, txID=%s]", w.ID, w.Asset, amount, w.Address, w.Memo, w.Status, w.TxID)
+}
+
+// WithdrawalExchange is implemented by exchanges that can withdraw to an address with a memo and report the status of the withdrawal.
+// It is optional, check whether an exchange implements it with a type assertion.
+type WithdrawalExchange interface {
+	/*
+		Input:
+			asset - asset you want to withdraw
+			amount - amount you want deducted from your account
+			address - address you want to withdraw to
+			memo - memo or tag that the receiver needs to credit the transfer, empty if not needed
+		Output:
+			Withdrawal - the withdrawal as it was accepted by the exchange
+			error - any error
+	*/
+	Withdraw(asset model.Asset, amount *model.Number, address string, memo string) (*Withdrawal, error)
+
+	// GetWithdrawal returns the current state of a withdrawal of the asset
+	GetWithdrawal(asset model.Asset, withdrawalID string) (*Withdrawal, error)
+}
+
 // Exchange is the interface we use as a generic API for all crypto exchanges
 type Exchange interface {
 	Account
 	TickerAPI
+	CandleAPI
 	TradeAPI
 	DepositAPI
 	WithdrawAPI
@@ -215,9 +350,16 @@ type Exchange interface {
 
 // Balance repesents various aspects of an asset's balance
 type Balance struct {
-	Balance float64
-	Trust   float64
-	Reserve float64
+	Balance     float64
+	Trust       float64
+	Reserve     float64
+	Liabilities *BalanceLiabilities // nil if the exchange does not report liabilities
+}
+
+// BalanceLiabilities are the units of an asset committed to open offers as reported by the exchange
+type BalanceLiabilities struct {
+	Buying  float64
+	Selling float64
 }
 
 // ExchangeShim is the interface we use as a generic API for all crypto exchanges
@@ -4,8 +4,9 @@ import "github.com/stellar/kelp/model"
 
 // Level represents a layer in the orderbook
 type Level struct {
-	Price  model.Number
-	Amount model.Number
+	Price   model.Number
+	Amount  model.Number
+	Passive bool // new offers for this level are placed as passive offers when trading on SDEX
 }
 
 // LevelProvider returns the levels for the given center price, which controls the spread and number of levels
@@ -1,12 +1,62 @@
 package api
 
-import "log"
+import (
+	"log"
+	"time"
+)
 
 // PriceFeed allows you to fetch the price of a feed
 type PriceFeed interface {
 	GetPrice() (float64, error)
 }
 
+// PriceQuote is a detailed price from a feed so its quality can be judged, fields that the feed cannot provide are nil
+type PriceQuote struct {
+	Bid           *float64
+	Ask           *float64
+	Mid           float64 // the price returned by GetPrice
+	LastTradeTime *time.Time
+	Source        string
+}
+
+// PriceQuoteFeed is implemented by price feeds that can provide more detail than a single price
+type PriceQuoteFeed interface {
+	PriceFeed
+	GetPriceQuote() (*PriceQuote, error)
+}
+
+// TimestampedPrice is a price along with when it was fetched and when its source last updated it
+type TimestampedPrice struct {
+	Price     float64
+	UpdatedAt time.Time // time the source last updated the price, the same as FetchedAt when the source does not report it
+	FetchedAt time.Time
+}
+
+// TimestampedPriceFeed is implemented by price feeds that know when their price was last updated
+type TimestampedPriceFeed interface {
+	PriceFeed
+	GetTimestampedPrice() (*TimestampedPrice, error)
+}
+
+// GetTimestampedPrice fetches the price of any feed along with its timestamps, the price is considered to be updated when it is fetched
+// for feeds that do not implement TimestampedPriceFeed
+func GetTimestampedPrice(feed PriceFeed) (*TimestampedPrice, error) {
+	if tf, ok := feed.(TimestampedPriceFeed); ok {
+		return tf.GetTimestampedPrice()
+	}
+
+	price, e := feed.GetPrice()
+	if e != nil {
+		return nil, e
+	}
+	now := time.Now()
+	return &TimestampedPrice{
+		Price:     price,
+		UpdatedAt: now,
+		FetchedAt: now,
+	}, nil
+}
+
 // FeedPair is the struct representing a price feed for a trading pair
 type FeedPair struct {
 	FeedA PriceFeed
@@ -23,3 +23,16 @@ type SideStrategy interface {
 	PostUpdate() error
 	GetFillHandlers() ([]FillHandler, error)
 }
+
+// OffsetFillReporter is implemented by strategies that offset the fills of the bot by trading on another exchange, the handler is set before
+// the bot starts updating and receives the offsetting trades in terms of the trading pair of the bot
+type OffsetFillReporter interface {
+	SetOffsetFillHandler(handler FillHandler)
+}
+
+// StateSnapshotter is implemented by strategies and submit filters whose state changes when they compute ops, such as the volume skew of a
+// strategy or the randomness of a filter. Snapshot returns a function that restores the state as of the call to Snapshot, which is used to
+// preview the ops of the next update without changing the ops that the update produces. Annotations are not emitted until the state is restored.
+type StateSnapshotter interface {
+	Snapshot() (restore func())
+}
@@ -0,0 +1,130 @@
+package backtest
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/stellar/kelp/model"
+)
+
+// ScoreBy is the metric that ranks the results of an optimization
+type ScoreBy string
+
+// ScoreBy values
+const (
+	ScoreBySharpe ScoreBy = "sharpe"
+	ScoreByPnL    ScoreBy = "pnl"
+)
+
+// ParseScoreBy converts the value of a flag to a ScoreBy
+func ParseScoreBy(s string) (ScoreBy, error) {
+	switch ScoreBy(s) {
+	case ScoreBySharpe, ScoreByPnL:
+		return ScoreBy(s), nil
+	}
+	return "", fmt.Errorf("invalid score '%s', needs to be either '%s' or '%s'", s, ScoreBySharpe, ScoreByPnL)
+}
+
+// ParamSpace is the set of values of each ladder parameter that is searched
+type ParamSpace struct {
+	Spreads []float64
+	Amounts []float64
+	Depths  []int
+}
+
+// validate checks that every parameter has values
+func (s ParamSpace) validate() error {
+	if len(s.Spreads) == 0 || len(s.Amounts) == 0 || len(s.Depths) == 0 {
+		return fmt.Errorf("need at least one value for each of spread, amount and depth")
+	}
+	return nil
+}
+
+// Grid returns every combination of the values of the parameters
+func (s ParamSpace) Grid() ([]LadderParams, error) {
+	e := s.validate()
+	if e != nil {
+		return nil, e
+	}
+
+	params := []LadderParams{}
+	for _, spread := range s.Spreads {
+		for _, amount := range s.Amounts {
+			for _, depth := range s.Depths {
+				params = append(params, LadderParams{Spread: spread, Amount: amount, Depth: depth})
+			}
+		}
+	}
+	return params, nil
+}
+
+// Random returns numSamples combinations where each parameter is drawn uniformly between its smallest and its largest value
+func (s ParamSpace) Random(numSamples int, rng *rand.Rand) ([]LadderParams, error) {
+	e := s.validate()
+	if e != nil {
+		return nil, e
+	}
+	if numSamples <= 0 {
+		return nil, fmt.Errorf("number of samples needs to be positive: %d", numSamples)
+	}
+
+	minSpread, maxSpread := floatRange(s.Spreads)
+	minAmount, maxAmount := floatRange(s.Amounts)
+	minDepth, maxDepth := intRange(s.Depths)
+	params := []LadderParams{}
+	for i := 0; i < numSamples; i++ {
+		params = append(params, LadderParams{
+			Spread: minSpread + rng.Float64()*(maxSpread-minSpread),
+			Amount: minAmount + rng.Float64()*(maxAmount-minAmount),
+			Depth:  minDepth + rng.Intn(maxDepth-minDepth+1),
+		})
+	}
+	return params, nil
+}
+
+func floatRange(values []float64) (float64, float64) {
+	min, max := values[0], values[0]
+	for _, v := range values {
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+	return min, max
+}
+
+func intRange(values []int) (int, int) {
+	min, max := values[0], values[0]
+	for _, v := range values {
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+	return min, max
+}
+
+// Optimize simulates every set of params against the candles and returns the results from the best to the worst score
+func Optimize(candles []model.Candle, params []LadderParams, startBase float64, startQuote float64, scoreBy ScoreBy) ([]Result, error) {
+	results := []Result{}
+	for _, p := range params {
+		r, e := Simulate(candles, p, startBase, startQuote)
+		if e != nil {
+			return nil, fmt.Errorf("unable to simulate %s: %s", p, e)
+		}
+		results = append(results, *r)
+	}
+
+	sort.SliceStable(results, func(i int, j int) bool {
+		if scoreBy == ScoreByPnL {
+			return results[i].PnL > results[j].PnL
+		}
+		return results[i].Sharpe > results[j].Sharpe
+	})
+	return results, nil
+}
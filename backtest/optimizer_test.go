@@ -0,0 +1,59 @@
+package backtest
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParamSpaceGrid(t *testing.T) {
+	params, e := ParamSpace{Spreads: []float64{0.01, 0.02}, Amounts: []float64{10}, Depths: []int{1, 2, 3}}.Grid()
+	if assert.NoError(t, e) {
+		assert.Equal(t, 6, len(params))
+		assert.Equal(t, LadderParams{Spread: 0.01, Amount: 10, Depth: 1}, params[0])
+		assert.Equal(t, LadderParams{Spread: 0.02, Amount: 10, Depth: 3}, params[5])
+	}
+
+	_, e = ParamSpace{Spreads: []float64{0.01}, Amounts: []float64{10}}.Grid()
+	assert.Error(t, e)
+}
+
+func TestParamSpaceRandom(t *testing.T) {
+	space := ParamSpace{Spreads: []float64{0.01, 0.05}, Amounts: []float64{10, 20}, Depths: []int{1, 5}}
+	params, e := space.Random(100, rand.New(rand.NewSource(1)))
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 100, len(params))
+	for _, p := range params {
+		assert.True(t, p.Spread >= 0.01 && p.Spread <= 0.05, p.String())
+		assert.True(t, p.Amount >= 10 && p.Amount <= 20, p.String())
+		assert.True(t, p.Depth >= 1 && p.Depth <= 5, p.String())
+	}
+
+	_, e = space.Random(0, rand.New(rand.NewSource(1)))
+	assert.Error(t, e)
+}
+
+func TestOptimize(t *testing.T) {
+	candles := makeCandles([][4]float64{
+		{1.0, 1.03, 0.97, 1.0},
+		{1.0, 1.03, 0.97, 1.0},
+		{1.0, 1.01, 0.99, 1.0},
+	})
+	params := []LadderParams{
+		{Spread: 0.1, Amount: 10, Depth: 1},  // never fills
+		{Spread: 0.04, Amount: 10, Depth: 1}, // fills on both sides in the first two candles
+	}
+
+	results, e := Optimize(candles, params, 100, 100, ScoreByPnL)
+	if assert.NoError(t, e) && assert.Equal(t, 2, len(results)) {
+		assert.Equal(t, 0.04, results[0].Params.Spread)
+		assert.True(t, results[0].PnL > results[1].PnL)
+		assert.Equal(t, 0, results[1].NumFills)
+	}
+
+	_, e = ParseScoreBy("returns")
+	assert.Error(t, e)
+}
@@ -0,0 +1,149 @@
+package backtest
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/stellar/kelp/model"
+)
+
+// LadderParams are the parameters of a ladder of offers on both sides of the mid price, as placed by the buysell strategy. Level i (starting
+// at 0) is placed at a distance of Spread/2 * (i+1) from the mid price, so Spread is the distance between the top bid and the top ask.
+type LadderParams struct {
+	Spread float64 // fraction of the mid price
+	Amount float64 // in units of the base asset, for each level
+	Depth  int     // number of levels on each side
+}
+
+// String is the stringer method
+func (p LadderParams) String() string {
+	return fmt.Sprintf("LadderParams[spread=%.6f, amount=%.6f, depth=%d]", p.Spread, p.Amount, p.Depth)
+}
+
+// validate checks the params
+func (p LadderParams) validate() error {
+	if p.Spread <= 0 || p.Spread >= 2 {
+		return fmt.Errorf("spread needs to be between 0 and 2: %f", p.Spread)
+	}
+	if p.Amount <= 0 {
+		return fmt.Errorf("amount needs to be positive: %f", p.Amount)
+	}
+	if p.Depth <= 0 {
+		return fmt.Errorf("depth needs to be positive: %d", p.Depth)
+	}
+	return nil
+}
+
+// Result is the performance of a ladder over the historical candles, values are in units of the quote asset
+type Result struct {
+	Params      LadderParams
+	PnL         float64 // final value of the balances less the final value of holding the starting balances, which removes the price move
+	ReturnPct   float64 // return of the final value of the balances over the starting value, includes the price move
+	Sharpe      float64 // annualized Sharpe ratio of the returns of each candle in excess of holding the starting balances
+	MaxDrawdown float64 // largest decline of the value of the balances from a previous peak, as a fraction of the peak
+	NumFills    int
+	FinalBase   float64
+	FinalQuote  float64
+}
+
+// Simulate replays the candles against the ladder. The ladder is placed around the open price of every candle, a bid fills when the low of
+// the candle reaches its price and an ask fills when the high of the candle reaches its price, as long as the balances cover the fill.
+// Fills are assumed to be complete and free of fees, and the ladder does not move the market.
+func Simulate(candles []model.Candle, params LadderParams, startBase float64, startQuote float64) (*Result, error) {
+	e := params.validate()
+	if e != nil {
+		return nil, fmt.Errorf("invalid ladder params: %s", e)
+	}
+	if len(candles) < 2 {
+		return nil, fmt.Errorf("need at least 2 candles to simulate, found %d", len(candles))
+	}
+	if startBase < 0 || startQuote < 0 || startBase+startQuote == 0 {
+		return nil, fmt.Errorf("need non-negative starting balances that are not both 0 (base=%f, quote=%f)", startBase, startQuote)
+	}
+
+	base := startBase
+	quote := startQuote
+	numFills := 0
+	startValue := base*candles[0].Open.AsFloat() + quote
+	prevValue := startValue
+	prevHoldValue := startValue
+	peak := startValue
+	maxDrawdown := 0.0
+	excessReturns := []float64{}
+	for _, c := range candles {
+		mid := c.Open.AsFloat()
+		low := c.Low.AsFloat()
+		high := c.High.AsFloat()
+		for i := 0; i < params.Depth; i++ {
+			distance := params.Spread / 2 * float64(i+1)
+
+			bidPrice := mid * (1 - distance)
+			if bidPrice > 0 && low <= bidPrice && quote >= bidPrice*params.Amount {
+				base += params.Amount
+				quote -= bidPrice * params.Amount
+				numFills++
+			}
+
+			askPrice := mid * (1 + distance)
+			if high >= askPrice && base >= params.Amount {
+				base -= params.Amount
+				quote += askPrice * params.Amount
+				numFills++
+			}
+		}
+
+		closePrice := c.Close.AsFloat()
+		value := base*closePrice + quote
+		holdValue := startBase*closePrice + startQuote
+		if prevValue > 0 && prevHoldValue > 0 {
+			excessReturns = append(excessReturns, (value/prevValue-1)-(holdValue/prevHoldValue-1))
+		}
+		prevValue = value
+		prevHoldValue = holdValue
+
+		if value > peak {
+			peak = value
+		} else if peak > 0 && (peak-value)/peak > maxDrawdown {
+			maxDrawdown = (peak - value) / peak
+		}
+	}
+
+	lastClose := candles[len(candles)-1].Close.AsFloat()
+	finalValue := base*lastClose + quote
+	return &Result{
+		Params:      params,
+		PnL:         finalValue - (startBase*lastClose + startQuote),
+		ReturnPct:   (finalValue/startValue - 1) * 100,
+		Sharpe:      annualizedSharpe(excessReturns, candles[0].Interval),
+		MaxDrawdown: maxDrawdown,
+		NumFills:    numFills,
+		FinalBase:   base,
+		FinalQuote:  quote,
+	}, nil
+}
+
+// annualizedSharpe returns the Sharpe ratio of the returns of each candle scaled to a year, 0 when the returns do not vary
+func annualizedSharpe(returns []float64, interval time.Duration) float64 {
+	if len(returns) < 2 || interval <= 0 {
+		return 0
+	}
+
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	stdDev := math.Sqrt(variance / float64(len(returns)-1))
+	if stdDev == 0 {
+		return 0
+	}
+
+	periodsPerYear := float64(365*24*time.Hour) / float64(interval)
+	return mean / stdDev * math.Sqrt(periodsPerYear)
+}
@@ -0,0 +1,65 @@
+package backtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func makeCandles(ohlcs [][4]float64) []model.Candle {
+	pair := &model.TradingPair{Base: model.XLM, Quote: model.USD}
+	candles := []model.Candle{}
+	for i, ohlc := range ohlcs {
+		candles = append(candles, model.Candle{
+			Pair:      pair,
+			Timestamp: model.MakeTimestamp(int64(i) * 3600000),
+			Interval:  time.Hour,
+			Open:      model.NumberFromFloat(ohlc[0], 7),
+			High:      model.NumberFromFloat(ohlc[1], 7),
+			Low:       model.NumberFromFloat(ohlc[2], 7),
+			Close:     model.NumberFromFloat(ohlc[3], 7),
+			Volume:    model.NumberFromFloat(1000, 7),
+		})
+	}
+	return candles
+}
+
+func TestSimulate(t *testing.T) {
+	candles := makeCandles([][4]float64{
+		// both levels on the bid side fill, no ask fills
+		{1.0, 1.0, 0.9, 0.95},
+		// only the first level on the ask side fills
+		{1.0, 1.015, 1.0, 1.0},
+	})
+
+	r, e := Simulate(candles, LadderParams{Spread: 0.02, Amount: 10, Depth: 2}, 0, 100)
+	if !assert.NoError(t, e) {
+		return
+	}
+	// bought 10 at 0.99 and 10 at 0.98, then sold 10 at 1.01
+	assert.Equal(t, 3, r.NumFills)
+	assert.InDelta(t, 10.0, r.FinalBase, 0.0000001)
+	assert.InDelta(t, 100-9.9-9.8+10.1, r.FinalQuote, 0.0000001)
+	assert.InDelta(t, 10+100-9.9-9.8+10.1-100, r.PnL, 0.0000001)
+	assert.True(t, r.MaxDrawdown > 0)
+}
+
+func TestSimulateInvalid(t *testing.T) {
+	candles := makeCandles([][4]float64{{1, 1, 1, 1}, {1, 1, 1, 1}})
+
+	_, e := Simulate(candles, LadderParams{Spread: 0, Amount: 10, Depth: 1}, 10, 10)
+	assert.Error(t, e)
+	_, e = Simulate(candles[:1], LadderParams{Spread: 0.01, Amount: 10, Depth: 1}, 10, 10)
+	assert.Error(t, e)
+	_, e = Simulate(candles, LadderParams{Spread: 0.01, Amount: 10, Depth: 1}, 0, 0)
+	assert.Error(t, e)
+}
+
+func TestAnnualizedSharpe(t *testing.T) {
+	assert.Equal(t, 0.0, annualizedSharpe([]float64{0.01, 0.01, 0.01}, time.Hour))
+	assert.Equal(t, 0.0, annualizedSharpe([]float64{0.01}, time.Hour))
+	assert.True(t, annualizedSharpe([]float64{0.01, 0.02, 0.03}, time.Hour) > 0)
+	assert.True(t, annualizedSharpe([]float64{-0.01, -0.02, -0.03}, time.Hour) < 0)
+}
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/support/config"
+	"github.com/stellar/kelp/accounting"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/plugins"
+	"github.com/stellar/kelp/support/sdk"
+	"github.com/stellar/kelp/support/utils"
+	"github.com/stellar/kelp/trader"
+)
+
+const exportTradesExamples = `  kelp export-trades --botConf ./path/trader.cfg --from 2020-01-01 --to 2020-12-31 --format csv
+  kelp export-trades --botConf ./path/trader.cfg --strategy mirror --stratConf ./path/mirror.cfg --from 2020-01-01 --format json --output trades.json`
+
+// exportDateFormat is accepted by the --from and --to flags along with RFC3339 times
+const exportDateFormat = "2006-01-02"
+
+var exportTradesCmd = &cobra.Command{
+	Use:     "export-trades",
+	Short:   "Exports the trades of a bot, including the trades offset on the backing exchange, for tax and accounting tools",
+	Example: exportTradesExamples,
+}
+
+func init() {
+	botConfigPath := exportTradesCmd.Flags().StringP("botConf", "c", "", "(required) trading bot's basic config file path")
+	fromString := exportTradesCmd.Flags().String("from", "", "(required) export trades from this date (YYYY-MM-DD, in UTC) or RFC3339 time")
+	toString := exportTradesCmd.Flags().String("to", "", "export trades until the end of this date (YYYY-MM-DD, in UTC) or RFC3339 time, defaults to now")
+	format := exportTradesCmd.Flags().String("format", "csv", "format of the export, either 'csv' or 'json'")
+	output := exportTradesCmd.Flags().StringP("output", "o", "", "file to write the export to, defaults to stdout")
+	strategy := exportTradesCmd.Flags().StringP("strategy", "s", "", "type of strategy of the bot, the mirror strategy also exports the trades offset on its backing exchange")
+	stratConfigPath := exportTradesCmd.Flags().StringP("stratConf", "f", "", "strategy config file path, needed for the mirror strategy")
+
+	for _, flag := range []string{"botConf", "from"} {
+		e := exportTradesCmd.MarkFlagRequired(flag)
+		if e != nil {
+			panic(e)
+		}
+	}
+	exportTradesCmd.Flags().SortFlags = false
+
+	exportTradesCmd.Run = func(ccmd *cobra.Command, args []string) {
+		checkInitRootFlags()
+		from, e := parseExportTime(*fromString, false)
+		if e != nil {
+			log.Fatalf("invalid --from: %s\n", e)
+		}
+		to := time.Now()
+		if *toString != "" {
+			to, e = parseExportTime(*toString, true)
+			if e != nil {
+				log.Fatalf("invalid --to: %s\n", e)
+			}
+		}
+		if to.Before(from) {
+			log.Fatalf("--to (%s) needs to be after --from (%s)\n", to, from)
+		}
+		if *format != "csv" && *format != "json" {
+			log.Fatalf("invalid --format, needs to be either 'csv' or 'json': %s\n", *format)
+		}
+		if *strategy == "mirror" && *stratConfigPath == "" {
+			log.Fatalf("need to pass --stratConf for the mirror strategy\n")
+		}
+
+		var botConfig trader.BotConfig
+		e = config.Read(*botConfigPath, &botConfig)
+		utils.CheckConfigError(botConfig, e, *botConfigPath)
+		e = botConfig.Init()
+		if e != nil {
+			log.Fatal(e)
+		}
+		if *rootCcxtRestURL == "" && botConfig.CcxtRestURL != nil {
+			e = sdk.SetBaseURL(*botConfig.CcxtRestURL)
+			if e != nil {
+				log.Fatalf("unable to set CCXT-rest URL to '%s': %s\n", *botConfig.CcxtRestURL, e)
+			}
+		}
+
+		records, e := exportBotTrades(&botConfig, from, to)
+		if e != nil {
+			log.Fatal(e)
+		}
+		if *strategy == "mirror" {
+			offsetRecords, e := exportOffsetTrades(*stratConfigPath, from, to)
+			if e != nil {
+				log.Fatal(e)
+			}
+			records = append(records, offsetRecords...)
+		}
+		accounting.SortExportRecords(records)
+
+		var w io.Writer = os.Stdout
+		if *output != "" {
+			f, e := os.Create(*output)
+			if e != nil {
+				log.Fatalf("could not create output file '%s': %s\n", *output, e)
+			}
+			defer f.Close()
+			w = f
+		}
+
+		if *format == "json" {
+			e = accounting.WriteJSON(w, records)
+		} else {
+			e = accounting.WriteCSV(w, records)
+		}
+		if e != nil {
+			log.Fatalf("could not write export: %s\n", e)
+		}
+		if *output != "" {
+			log.Printf("exported %d trades to %s\n", len(records), *output)
+		}
+	}
+}
+
+// parseExportTime parses a date or an RFC3339 time, dates are the end of the day when isEnd is set so the whole day is included
+func parseExportTime(s string, isEnd bool) (time.Time, error) {
+	if t, e := time.Parse(exportDateFormat, s); e == nil {
+		if isEnd {
+			return t.Add(24*time.Hour - time.Millisecond), nil
+		}
+		return t, nil
+	}
+	t, e := time.Parse(time.RFC3339, s)
+	if e != nil {
+		return time.Time{}, fmt.Errorf("needs to be a date (YYYY-MM-DD) or an RFC3339 time: %s", s)
+	}
+	return t, nil
+}
+
+// exportBotTrades exports the trades of the trading account on the trading exchange of the bot
+func exportBotTrades(botConfig *trader.BotConfig, from time.Time, to time.Time) ([]accounting.ExportRecord, error) {
+	assetBase := botConfig.AssetBase()
+	assetQuote := botConfig.AssetQuote()
+	tradingPair := &model.TradingPair{
+		Base:  model.Asset(utils.Asset2CodeString(assetBase)),
+		Quote: model.Asset(utils.Asset2CodeString(assetQuote)),
+	}
+
+	if botConfig.IsTradingSdex() {
+		client := &horizonclient.Client{
+			HorizonURL: botConfig.HorizonURL,
+			HTTP:       http.DefaultClient,
+			AppName:    "kelp",
+			AppVersion: version,
+		}
+		trades, e := plugins.LoadAccountTrades(client, botConfig.TradingAccount(), tradingPair, assetBase, assetQuote, from, to)
+		if e != nil {
+			return nil, fmt.Errorf("unable to load trades of the trading account: %s", e)
+		}
+		return accounting.MakeExportRecords("sdex", utils.Asset2String(assetBase), utils.Asset2String(assetQuote), trades), nil
+	}
+
+	exchange, e := makeConfiguredTradingExchange(botConfig)
+	if e != nil {
+		return nil, e
+	}
+	trades, e := accounting.FetchTradeHistory(exchange, *tradingPair, tradeHistoryStartCursor(botConfig.TradingExchange, from), from, to)
+	if e != nil {
+		return nil, fmt.Errorf("unable to load trades on trading exchange '%s': %s", botConfig.TradingExchange, e)
+	}
+	return accounting.MakeExportRecords(botConfig.TradingExchange, string(tradingPair.Base), string(tradingPair.Quote), trades), nil
+}
+
+// makeConfiguredTradingExchange makes the centralized trading exchange of the bot with the keys, params and headers in the bot config
+func makeConfiguredTradingExchange(botConfig *trader.BotConfig) (api.Exchange, error) {
+	exchangeParams := []api.ExchangeParam{}
+	for _, param := range botConfig.ExchangeParams {
+		exchangeParams = append(exchangeParams, api.ExchangeParam{
+			Param: param.Param,
+			Value: param.Value,
+		})
+	}
+	exchangeHeaders := []api.ExchangeHeader{}
+	for _, header := range botConfig.ExchangeHeaders {
+		exchangeHeaders = append(exchangeHeaders, api.ExchangeHeader{
+			Header: header.Header,
+			Value:  header.Value,
+		})
+	}
+	exchange, e := plugins.MakeTradingExchange(botConfig.TradingExchange, botConfig.ExchangeAPIKeys.ToExchangeAPIKeys(), exchangeParams, exchangeHeaders, false)
+	if e != nil {
+		return nil, fmt.Errorf("unable to make trading exchange: %s", e)
+	}
+	return exchange, nil
+}
+
+// exportOffsetTrades exports the trades on the backing exchange of the mirror strategy, there are none when it does not offset trades
+func exportOffsetTrades(stratConfigPath string, from time.Time, to time.Time) ([]accounting.ExportRecord, error) {
+	backing, e := plugins.LoadMirrorBackingExchange(stratConfigPath)
+	if e != nil {
+		return nil, e
+	}
+	if backing == nil {
+		log.Printf("the mirror strategy does not set OFFSET_TRADES, only exporting the trades of the trading account\n")
+		return []accounting.ExportRecord{}, nil
+	}
+
+	trades, e := accounting.FetchTradeHistory(backing.Exchange, *backing.Pair, tradeHistoryStartCursor(backing.Name, from), from, to)
+	if e != nil {
+		return nil, fmt.Errorf("unable to load trades on backing exchange '%s': %s", backing.Name, e)
+	}
+	return accounting.MakeExportRecords(backing.Name, string(backing.Pair.Base), string(backing.Pair.Quote), trades), nil
+}
+
+// tradeHistoryStartCursor returns the cursor that starts the trade history of the exchange at the time, which is a unix timestamp
+// in millis for ccxt exchanges and in seconds for kraken. Other exchanges start from the beginning of their trade history.
+func tradeHistoryStartCursor(exchangeName string, from time.Time) interface{} {
+	if strings.HasPrefix(exchangeName, "ccxt-") {
+		return strconv.FormatInt(from.UnixNano()/int64(time.Millisecond), 10)
+	}
+	if exchangeName == "kraken" {
+		return strconv.FormatInt(from.Unix(), 10)
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/backtest"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/plugins"
+)
+
+const optimizeExamples = `  kelp optimize --exchange kraken --base XLM --quote USD --interval 1h --from 2020-01-01 --to 2020-03-31 --spread 0.002,0.005,0.01 --amount 100,500 --depth 1,3,5 --startBase 10000 --startQuote 1000
+  kelp optimize --exchange ccxt-binance --base XLM --quote USDT --from 2020-01-01 --search random --samples 200 --spread 0.001,0.02 --amount 50,1000 --depth 1,10 --startBase 10000 --startQuote 1000 --score pnl`
+
+// maxOptimizeCandleRequests bounds the number of requests made to fetch the candles of the period
+const maxOptimizeCandleRequests = 1000
+
+var optimizeCmd = &cobra.Command{
+	Use:     "optimize",
+	Short:   "Searches for the spread, amount and depth of a ladder of offers that performed best on the historical candles of an exchange",
+	Example: optimizeExamples,
+}
+
+func init() {
+	exchangeType := optimizeCmd.Flags().String("exchange", "", "(required) exchange to fetch the historical candles from, see 'kelp exchanges'")
+	base := optimizeCmd.Flags().String("base", "", "(required) base asset of the trading pair on the exchange")
+	quote := optimizeCmd.Flags().String("quote", "", "(required) quote asset of the trading pair on the exchange")
+	intervalString := optimizeCmd.Flags().String("interval", "1h", "interval of the candles, the ladder is placed again around the open price of every candle")
+	fromString := optimizeCmd.Flags().String("from", "", "(required) start of the historical period as a date (YYYY-MM-DD, in UTC) or RFC3339 time")
+	toString := optimizeCmd.Flags().String("to", "", "end of the historical period as a date (YYYY-MM-DD, in UTC) or RFC3339 time, defaults to now")
+	spreads := optimizeCmd.Flags().String("spread", "0.002,0.005,0.01", "comma-separated spreads between the top bid and the top ask as a fraction of the mid price, levels are spaced by half the spread")
+	amounts := optimizeCmd.Flags().String("amount", "", "(required) comma-separated amounts of each level in units of the base asset")
+	depths := optimizeCmd.Flags().String("depth", "1,3,5", "comma-separated number of levels on each side")
+	search := optimizeCmd.Flags().String("search", "grid", "'grid' tries every combination of the values, 'random' draws each parameter between its smallest and largest value")
+	numSamples := optimizeCmd.Flags().Int("samples", 100, "number of combinations to try for the random search")
+	seed := optimizeCmd.Flags().Int64("seed", 0, "seed of the random search, 0 uses the current time")
+	scoreString := optimizeCmd.Flags().String("score", string(backtest.ScoreBySharpe), "rank the configurations by 'sharpe' (annualized, of the returns in excess of holding the starting balances) or 'pnl'")
+	top := optimizeCmd.Flags().Int("top", 10, "number of configurations to print")
+	startBase := optimizeCmd.Flags().Float64("startBase", 0, "starting balance of the base asset")
+	startQuote := optimizeCmd.Flags().Float64("startQuote", 0, "starting balance of the quote asset")
+
+	for _, flag := range []string{"exchange", "base", "quote", "from", "amount"} {
+		e := optimizeCmd.MarkFlagRequired(flag)
+		if e != nil {
+			panic(e)
+		}
+	}
+	optimizeCmd.Flags().SortFlags = false
+
+	optimizeCmd.Run = func(ccmd *cobra.Command, args []string) {
+		checkInitRootFlags()
+		interval, e := time.ParseDuration(*intervalString)
+		if e != nil {
+			log.Fatalf("invalid --interval, needs to be a duration such as 5m, 1h or 24h: %s\n", e)
+		}
+		from, e := parseExportTime(*fromString, false)
+		if e != nil {
+			log.Fatalf("invalid --from: %s\n", e)
+		}
+		to := time.Now()
+		if *toString != "" {
+			to, e = parseExportTime(*toString, true)
+			if e != nil {
+				log.Fatalf("invalid --to: %s\n", e)
+			}
+		}
+		if !to.After(from) {
+			log.Fatalf("--to (%s) needs to be after --from (%s)\n", to, from)
+		}
+		scoreBy, e := backtest.ParseScoreBy(*scoreString)
+		if e != nil {
+			log.Fatal(e)
+		}
+		space, e := parseParamSpace(*spreads, *amounts, *depths)
+		if e != nil {
+			log.Fatal(e)
+		}
+
+		var params []backtest.LadderParams
+		switch *search {
+		case "grid":
+			params, e = space.Grid()
+		case "random":
+			if *seed == 0 {
+				*seed = time.Now().UnixNano()
+			}
+			log.Printf("random search with seed %d\n", *seed)
+			params, e = space.Random(*numSamples, rand.New(rand.NewSource(*seed)))
+		default:
+			e = fmt.Errorf("invalid --search, needs to be either 'grid' or 'random': %s", *search)
+		}
+		if e != nil {
+			log.Fatal(e)
+		}
+
+		exchange, e := plugins.MakeExchange(*exchangeType, false)
+		if e != nil {
+			log.Fatalf("unable to make exchange '%s': %s\n", *exchangeType, e)
+		}
+		candleAPI, ok := exchange.(api.CandleAPI)
+		if !ok {
+			log.Fatalf("exchange '%s' does not provide candles\n", *exchangeType)
+		}
+		pair := &model.TradingPair{Base: model.Asset(*base), Quote: model.Asset(*quote)}
+		candles, e := fetchCandles(candleAPI, pair, interval, from, to)
+		if e != nil {
+			log.Fatal(e)
+		}
+		log.Printf("fetched %d candles of %s from exchange '%s', simulating %d configurations\n", len(candles), pair, *exchangeType, len(params))
+
+		results, e := backtest.Optimize(candles, params, *startBase, *startQuote, scoreBy)
+		if e != nil {
+			log.Fatal(e)
+		}
+		printOptimizeResults(results, *top)
+	}
+}
+
+// parseParamSpace parses the comma-separated values of the parameters
+func parseParamSpace(spreads string, amounts string, depths string) (backtest.ParamSpace, error) {
+	space := backtest.ParamSpace{}
+	for _, s := range strings.Split(spreads, ",") {
+		v, e := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if e != nil {
+			return space, fmt.Errorf("invalid --spread value '%s': %s", s, e)
+		}
+		space.Spreads = append(space.Spreads, v)
+	}
+	for _, s := range strings.Split(amounts, ",") {
+		v, e := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if e != nil {
+			return space, fmt.Errorf("invalid --amount value '%s': %s", s, e)
+		}
+		space.Amounts = append(space.Amounts, v)
+	}
+	for _, s := range strings.Split(depths, ",") {
+		v, e := strconv.Atoi(strings.TrimSpace(s))
+		if e != nil {
+			return space, fmt.Errorf("invalid --depth value '%s': %s", s, e)
+		}
+		space.Depths = append(space.Depths, v)
+	}
+	return space, nil
+}
+
+// fetchCandles fetches the candles of the period page by page, since exchanges limit the number of candles in a response
+func fetchCandles(candleAPI api.CandleAPI, pair *model.TradingPair, interval time.Duration, from time.Time, to time.Time) ([]model.Candle, error) {
+	candles := []model.Candle{}
+	since := model.MakeTimestampFromTime(from)
+	toMillis := model.MakeTimestampFromTime(to).AsInt64()
+	for i := 0; i < maxOptimizeCandleRequests; i++ {
+		page, e := candleAPI.GetCandles(pair, interval, since)
+		if e != nil {
+			return nil, fmt.Errorf("unable to fetch candles of %s since %s: %s", pair, since, e)
+		}
+
+		numAdded := 0
+		for _, c := range page {
+			ts := c.Timestamp.AsInt64()
+			if ts < since.AsInt64() {
+				continue
+			}
+			if ts > toMillis {
+				return candles, nil
+			}
+			candles = append(candles, c)
+			numAdded++
+		}
+		if numAdded == 0 {
+			return candles, nil
+		}
+		since = model.MakeTimestamp(candles[len(candles)-1].Timestamp.AsInt64() + int64(interval/time.Millisecond))
+	}
+	return nil, fmt.Errorf("the period has more candles than can be fetched in %d requests, use a shorter period or a longer interval", maxOptimizeCandleRequests)
+}
+
+// printOptimizeResults prints the best configurations with their metrics
+func printOptimizeResults(results []backtest.Result, top int) {
+	if top > len(results) {
+		top = len(results)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "rank\tspread\tamount\tdepth\tsharpe\tpnl\treturn_pct\tmax_drawdown_pct\tfills\tfinal_base\tfinal_quote\t")
+	for i, r := range results[:top] {
+		fmt.Fprintf(w, "%d\t%.6f\t%.6f\t%d\t%.4f\t%.6f\t%.4f\t%.4f\t%d\t%.6f\t%.6f\t\n",
+			i+1,
+			r.Params.Spread,
+			r.Params.Amount,
+			r.Params.Depth,
+			r.Sharpe,
+			r.PnL,
+			r.ReturnPct,
+			r.MaxDrawdown*100,
+			r.NumFills,
+			r.FinalBase,
+			r.FinalQuote,
+		)
+	}
+	w.Flush()
+}
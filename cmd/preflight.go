@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/stellar/kelp/plugins"
+)
+
+const preflightExamples = `  kelp preflight --stratConf ./path/mirror.cfg`
+
+var preflightCmd = &cobra.Command{
+	Use:     "preflight",
+	Short:   "Checks the backing exchange of a mirror strategy config (API keys, permissions, clock skew and trading pair) without trading",
+	Example: preflightExamples,
+}
+
+func init() {
+	stratConfigPath := preflightCmd.Flags().StringP("stratConf", "f", "", "(required) mirror strategy config file path")
+	e := preflightCmd.MarkFlagRequired("stratConf")
+	if e != nil {
+		panic(e)
+	}
+
+	preflightCmd.Run = func(ccmd *cobra.Command, args []string) {
+		checkInitRootFlags()
+		checks, e := plugins.RunMirrorPreflight(*stratConfigPath)
+		if e != nil {
+			fmt.Println(e)
+			os.Exit(1)
+		}
+
+		fmt.Println()
+		for _, c := range checks {
+			fmt.Printf("  %s\n", c)
+		}
+		if e = plugins.PreflightError(checks); e != nil {
+			fmt.Println()
+			fmt.Println(e)
+			os.Exit(1)
+		}
+		fmt.Println()
+		fmt.Println("the backing exchange is ready")
+	}
+}
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/support/config"
+	"github.com/stellar/kelp/accounting"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/sdk"
+	"github.com/stellar/kelp/support/utils"
+	"github.com/stellar/kelp/trader"
+)
+
+const reconcileExamples = `  kelp reconcile --botConf ./path/trader.cfg --pnlFile ./path/pnl.json
+  kelp reconcile --botConf ./path/trader.cfg --pnlFile ./path/pnl.json --threshold 0.005
+  kelp reconcile --botConf ./path/trader.cfg --pnlFile ./path/pnl.json --reset`
+
+var reconcileCmd = &cobra.Command{
+	Use:     "reconcile",
+	Short:   "Reconciles the balances of a bot against the fills in its profit and loss ledger to report drift from fees, missed fills or transfers",
+	Example: reconcileExamples,
+}
+
+func init() {
+	botConfigPath := reconcileCmd.Flags().StringP("botConf", "c", "", "(required) trading bot's basic config file path")
+	pnlFile := reconcileCmd.Flags().String("pnlFile", "", "(required) profit and loss ledger file of the bot, as passed to the trade command with --pnlFile")
+	threshold := reconcileCmd.Flags().Float64("threshold", 0, "fraction of a balance that it can drift before the drift is reported, defaults to RECONCILE_DRIFT_THRESHOLD in the botConf or 0.01")
+	reset := reconcileCmd.Flags().Bool("reset", false, "set the baseline to the current balances, for example after a transfer. Only use this when the bot is stopped since a running bot overwrites the ledger file")
+
+	for _, flag := range []string{"botConf", "pnlFile"} {
+		e := reconcileCmd.MarkFlagRequired(flag)
+		if e != nil {
+			panic(e)
+		}
+	}
+	reconcileCmd.Flags().SortFlags = false
+
+	reconcileCmd.Run = func(ccmd *cobra.Command, args []string) {
+		checkInitRootFlags()
+		var botConfig trader.BotConfig
+		e := config.Read(*botConfigPath, &botConfig)
+		utils.CheckConfigError(botConfig, e, *botConfigPath)
+		e = botConfig.Init()
+		if e != nil {
+			log.Fatal(e)
+		}
+		if *rootCcxtRestURL == "" && botConfig.CcxtRestURL != nil {
+			e = sdk.SetBaseURL(*botConfig.CcxtRestURL)
+			if e != nil {
+				log.Fatalf("unable to set CCXT-rest URL to '%s': %s\n", *botConfig.CcxtRestURL, e)
+			}
+		}
+		if *threshold == 0 {
+			*threshold = botConfig.ReconcileDriftThreshold
+		}
+		if *threshold == 0 {
+			*threshold = accounting.DefaultDriftThreshold
+		}
+		if *threshold < 0 {
+			log.Fatalf("--threshold cannot be negative: %f\n", *threshold)
+		}
+
+		if _, e = os.Stat(*pnlFile); e != nil {
+			log.Fatalf("could not find ledger file '%s': %s\n", *pnlFile, e)
+		}
+		ledger, e := accounting.MakeLedger(*pnlFile)
+		if e != nil {
+			log.Fatal(e)
+		}
+		tradingPair := &model.TradingPair{
+			Base:  model.Asset(utils.Asset2CodeString(botConfig.AssetBase())),
+			Quote: model.Asset(utils.Asset2CodeString(botConfig.AssetQuote())),
+		}
+		pair := tradingPair.String()
+		base, quote, e := fetchConfiguredBalances(&botConfig, tradingPair)
+		if e != nil {
+			log.Fatal(e)
+		}
+
+		if *reset || ledger.GetBaseline(pair) == nil {
+			b, e := ledger.SetBaseline(pair, base, quote, time.Now())
+			if e != nil {
+				log.Fatal(e)
+			}
+			log.Printf("set reconciliation baseline for pair %s in ledger file %s: base=%.8f, quote=%.8f\n", pair, *pnlFile, b.Base, b.Quote)
+			return
+		}
+
+		rec, e := ledger.Reconcile(pair, base, quote, *threshold)
+		if e != nil {
+			log.Fatal(e)
+		}
+		b, e := json.MarshalIndent(rec, "", "  ")
+		if e != nil {
+			log.Fatalf("could not marshal reconciliation: %s\n", e)
+		}
+		fmt.Println(string(b))
+		if rec.Exceeded {
+			log.Printf("balance drift exceeds the threshold of %.4f\n", rec.Threshold)
+			os.Exit(1)
+		}
+	}
+}
+
+// fetchConfiguredBalances fetches the balances of the base and quote assets of the trading account on the trading exchange of the bot
+func fetchConfiguredBalances(botConfig *trader.BotConfig, tradingPair *model.TradingPair) (float64, float64, error) {
+	if botConfig.IsTradingSdex() {
+		client := &horizonclient.Client{
+			HorizonURL: botConfig.HorizonURL,
+			HTTP:       http.DefaultClient,
+			AppName:    "kelp",
+			AppVersion: version,
+		}
+		account, e := client.AccountDetail(horizonclient.AccountRequest{AccountID: botConfig.TradingAccount()})
+		if e != nil {
+			return 0, 0, fmt.Errorf("unable to load trading account %s: %s", botConfig.TradingAccount(), e)
+		}
+
+		balances := []float64{}
+		for _, asset := range []hProtocol.Asset{botConfig.AssetBase(), botConfig.AssetQuote()} {
+			// the account has a balance of 0 when it does not trust the asset
+			balance := 0.0
+			for _, b := range account.Balances {
+				if utils.AssetsEqual(b.Asset, asset) {
+					balance, e = strconv.ParseFloat(b.Balance, 64)
+					if e != nil {
+						return 0, 0, fmt.Errorf("cannot parse balance '%s': %s", b.Balance, e)
+					}
+				}
+			}
+			balances = append(balances, balance)
+		}
+		return balances[0], balances[1], nil
+	}
+
+	exchange, e := makeConfiguredTradingExchange(botConfig)
+	if e != nil {
+		return 0, 0, e
+	}
+	balances, e := exchange.GetAccountBalances([]interface{}{tradingPair.Base, tradingPair.Quote})
+	if e != nil {
+		return 0, 0, fmt.Errorf("unable to fetch balances on trading exchange '%s': %s", botConfig.TradingExchange, e)
+	}
+	base := balances[tradingPair.Base]
+	quote := balances[tradingPair.Quote]
+	return base.AsFloat(), quote.AsFloat(), nil
+}
@@ -55,8 +55,6 @@ var RootCmd = &cobra.Command{
 var rootCcxtRestURL *string
 
 func init() {
-	validateBuild()
-
 	rootCcxtRestURL = RootCmd.PersistentFlags().String("ccxt-rest-url", "", "URL to use for the CCXT-rest API. Takes precendence over the CCXT_REST_URL param set in the botConfg file for the trade command and passed as a parameter into the Kelp subprocesses started by the GUI (default URL is https://localhost:3000)")
 
 	RootCmd.AddCommand(tradeCmd)
@@ -65,6 +63,13 @@ func init() {
 	}
 	RootCmd.AddCommand(strategiesCmd)
 	RootCmd.AddCommand(exchanagesCmd)
+	RootCmd.AddCommand(validateCmd)
+	RootCmd.AddCommand(preflightCmd)
+	RootCmd.AddCommand(trustCmd)
+	RootCmd.AddCommand(exportTradesCmd)
+	RootCmd.AddCommand(reconcileCmd)
+	RootCmd.AddCommand(optimizeCmd)
+	RootCmd.AddCommand(serviceCmd)
 	RootCmd.AddCommand(terminateCmd)
 	RootCmd.AddCommand(versionCmd)
 }
@@ -88,7 +93,9 @@ func checkInitRootFlags() {
 	}
 }
 
-func validateBuild() {
+// ValidateBuild exits when the binary was not built with the version information, which is checked by main instead of when the package is
+// initialized so the tests of the package can run
+func ValidateBuild() {
 	if version == "" || buildDate == "" || gitBranch == "" || gitHash == "" {
 		fmt.Println("version information not included, please build using the build script (scripts/build.sh)")
 		os.Exit(1)
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
@@ -13,9 +14,12 @@ import (
 	"github.com/go-chi/chi/middleware"
 	"github.com/rs/cors"
 	"github.com/spf13/cobra"
+	"github.com/stellar/go/build"
 	"github.com/stellar/kelp/gui"
 	"github.com/stellar/kelp/gui/backend"
 	"github.com/stellar/kelp/support/kelpos"
+	"github.com/stellar/kelp/support/logger"
+	"github.com/stellar/kelp/support/utils"
 )
 
 var serverCmd = &cobra.Command{
@@ -29,6 +33,77 @@ type serverInputs struct {
 	devAPIPort        *uint16
 	horizonTestnetURI *string
 	horizonPubnetURI  *string
+	testnetPassphrase *string
+	pubnetPassphrase  *string
+	friendbotURL      *string
+	maxDrawdown       *float64
+	drawdownInterval  *uint32
+	scheduleInterval  *uint32
+	containerImage    *string
+	containerBinPath  *string
+	containerCPUs     *string
+	containerMemory   *string
+	containerRestart  *string
+	containerNetwork  *string
+	shutdownGrace     *uint32
+	confirmActions    *string
+	totpSecretFile    *string
+	logFormat         *string
+	logLevel          *string
+}
+
+// serverEnvVars are the environment variables that set the flags of the server command, so deployments can configure the server without
+// changing its command line
+var serverEnvVars = map[string]string{
+	"horizon-testnet-uri": "KELP_HORIZON_TESTNET_URI",
+	"horizon-pubnet-uri":  "KELP_HORIZON_PUBNET_URI",
+	"testnet-passphrase":  "KELP_TESTNET_PASSPHRASE",
+	"pubnet-passphrase":   "KELP_PUBNET_PASSPHRASE",
+	"friendbot-url":       "KELP_FRIENDBOT_URL",
+	"ccxt-rest-url":       "KELP_CCXT_REST_URL",
+	"confirm-actions":     "KELP_CONFIRM_ACTIONS",
+	"totp-secret-file":    "KELP_TOTP_SECRET_FILE",
+	"log-format":          "KELP_LOG_FORMAT",
+	"log-level":           "KELP_LOG_LEVEL",
+}
+
+// setFlagsFromEnv sets the flags that were not passed on the command line from their environment variables, flags take precedence
+func setFlagsFromEnv(ccmd *cobra.Command, envVars map[string]string) error {
+	for flagName, envVar := range envVars {
+		value, ok := os.LookupEnv(envVar)
+		if !ok || ccmd.Flags().Changed(flagName) {
+			continue
+		}
+
+		e := ccmd.Flags().Set(flagName, value)
+		if e != nil {
+			return fmt.Errorf("could not set flag '%s' from environment variable '%s': %s", flagName, envVar, e)
+		}
+		log.Printf("set flag '%s' from environment variable '%s'\n", flagName, envVar)
+	}
+	return nil
+}
+
+// setServerLogOutput sets the output of the standard library logger to the log format of the server, the bots started by the server use
+// the LOG_FORMAT and LOG_LEVEL of their own trader config
+func setServerLogOutput(logFormat string, logLevel string) error {
+	switch logFormat {
+	case "", "text":
+		return nil
+	case "json":
+		level, e := logger.ParseLevel(logLevel)
+		if e != nil {
+			return fmt.Errorf("invalid log-level: %s", e)
+		}
+		jsonLogger := logger.MakeJSONLogger(os.Stdout, level, map[string]interface{}{
+			"component": "server",
+		})
+		// the json logger adds its own timestamp to each entry
+		log.SetFlags(0)
+		log.SetOutput(jsonLogger)
+		return nil
+	}
+	return fmt.Errorf("invalid log-format '%s', should be either 'text' or 'json'", logFormat)
 }
 
 func init() {
@@ -38,8 +113,33 @@ func init() {
 	options.devAPIPort = serverCmd.Flags().Uint16("dev-api-port", 8001, "port on which to run API server when in dev mode")
 	options.horizonTestnetURI = serverCmd.Flags().String("horizon-testnet-uri", "https://horizon-testnet.stellar.org", "URI to use for the horizon instance connected to the Stellar Test Network (must contain the word 'test')")
 	options.horizonPubnetURI = serverCmd.Flags().String("horizon-pubnet-uri", "https://horizon.stellar.org", "URI to use for the horizon instance connected to the Stellar Public Network (must not contain the word 'test')")
+	options.testnetPassphrase = serverCmd.Flags().String("testnet-passphrase", build.TestNetwork.Passphrase, "network passphrase of the Stellar Test Network, used to sign the transactions of backend operations on the test network")
+	options.pubnetPassphrase = serverCmd.Flags().String("pubnet-passphrase", build.PublicNetwork.Passphrase, "network passphrase of the Stellar Public Network, used to sign the transactions of backend operations on the public network")
+	options.friendbotURL = serverCmd.Flags().String("friendbot-url", "https://friendbot.stellar.org", "URL of the friendbot used to fund accounts on the Stellar Test Network")
+	options.maxDrawdown = serverCmd.Flags().Float64("max-portfolio-drawdown", 0, "pause all running bots when their aggregate drawdown reaches this fraction (0 < value < 1), bots stay paused until resumed from the GUI. 0 disables the circuit breaker")
+	options.drawdownInterval = serverCmd.Flags().Uint32("drawdown-check-interval-seconds", 60, "how often to check the aggregate drawdown of all running bots when the circuit breaker is enabled")
+	options.scheduleInterval = serverCmd.Flags().Uint32("schedule-check-interval-seconds", 60, "how often to pause bots outside of their trading hours and resume them when their trading hours start. 0 disables the trading hours")
+	options.containerImage = serverCmd.Flags().String("bot-container-image", "", "run each bot in its own Docker container with this image instead of a child process of the server. Empty runs bots as child processes")
+	options.containerBinPath = serverCmd.Flags().String("bot-container-bin", "kelp", "path of the kelp binary inside the bot container image")
+	options.containerCPUs = serverCmd.Flags().String("bot-container-cpus", "", "CPU limit of each bot container, passed to the --cpus flag of docker run. Empty for no limit")
+	options.containerMemory = serverCmd.Flags().String("bot-container-memory", "", "memory limit of each bot container, passed to the --memory flag of docker run (example: 256m). Empty for no limit")
+	options.containerRestart = serverCmd.Flags().String("bot-container-restart", "no", "restart policy of each bot container, passed to the --restart flag of docker run (example: on-failure:3)")
+	options.containerNetwork = serverCmd.Flags().String("bot-container-network", "host", "network of each bot container, passed to the --network flag of docker run. The host network lets bots reach ccxt-rest on localhost")
+	options.shutdownGrace = serverCmd.Flags().Uint32("bot-shutdown-grace-seconds", uint32(kelpos.DefaultShutdownGracePeriod/time.Second), "how long a stopped bot has to delete its offers, cancel its backing orders and save its state before it is killed")
+	options.confirmActions = serverCmd.Flags().String("confirm-actions", "", fmt.Sprintf("require a second factor to delete bots or their offers and to start bots on the main network: '%s' needs a single-use token from the requestConfirmation endpoint, '%s' needs the code of an authenticator app. Empty does not require confirmation", backend.ConfirmationPolicyToken, backend.ConfirmationPolicyTOTP))
+	options.totpSecretFile = serverCmd.Flags().String("totp-secret-file", "", fmt.Sprintf("file with the base32 TOTP secret used when confirm-actions is '%s', a new secret is generated into the file when it does not exist", backend.ConfirmationPolicyTOTP))
+	options.logFormat = serverCmd.Flags().String("log-format", "text", "format of the log output of the server, either 'text' or 'json'. The json format writes one object per line so the logs can be shipped to log aggregators")
+	options.logLevel = serverCmd.Flags().String("log-level", "info", "minimum level of log entries to write when log-format is 'json', one of: debug, info, warn, error")
 
 	serverCmd.Run = func(ccmd *cobra.Command, args []string) {
+		e := setFlagsFromEnv(ccmd, serverEnvVars)
+		if e != nil {
+			panic(e)
+		}
+		e = setServerLogOutput(*options.logFormat, *options.logLevel)
+		if e != nil {
+			panic(e)
+		}
 		checkInitRootFlags()
 		if !strings.Contains(*options.horizonTestnetURI, "test") {
 			panic("'horizon-testnet-uri' argument must contain the word 'test'")
@@ -49,10 +149,53 @@ func init() {
 		}
 
 		kos := kelpos.GetKelpOS()
-		s, e := backend.MakeAPIServer(kos, *options.horizonTestnetURI, *options.horizonPubnetURI, *rootCcxtRestURL)
+		s, e := backend.MakeAPIServer(kos, backend.ServerConfig{
+			HorizonTestnetURL: *options.horizonTestnetURI,
+			HorizonPubnetURL:  *options.horizonPubnetURI,
+			CcxtRestURL:       *rootCcxtRestURL,
+			TestnetPassphrase: *options.testnetPassphrase,
+			PubnetPassphrase:  *options.pubnetPassphrase,
+			FriendbotURL:      *options.friendbotURL,
+		})
 		if e != nil {
 			panic(e)
 		}
+		if *options.maxDrawdown != 0 {
+			e = s.EnableCircuitBreaker(*options.maxDrawdown, time.Duration(*options.drawdownInterval)*time.Second)
+			if e != nil {
+				panic(e)
+			}
+		}
+		if *options.scheduleInterval != 0 {
+			e = s.EnableScheduler(time.Duration(*options.scheduleInterval) * time.Second)
+			if e != nil {
+				panic(e)
+			}
+		}
+		s.SetShutdownGracePeriod(time.Duration(*options.shutdownGrace) * time.Second)
+		if *options.confirmActions != "" {
+			totpSecret := ""
+			if *options.confirmActions == backend.ConfirmationPolicyTOTP {
+				totpSecret, e = loadTOTPSecret(*options.totpSecretFile)
+				if e != nil {
+					panic(e)
+				}
+			}
+			e = s.EnableActionConfirmation(*options.confirmActions, totpSecret)
+			if e != nil {
+				panic(e)
+			}
+		}
+		if *options.containerImage != "" {
+			s.EnableContainers(kelpos.ContainerConfig{
+				Image:         *options.containerImage,
+				BinPath:       *options.containerBinPath,
+				CPUs:          *options.containerCPUs,
+				Memory:        *options.containerMemory,
+				RestartPolicy: *options.containerRestart,
+				Network:       *options.containerNetwork,
+			})
+		}
 
 		if env == envDev && *options.dev {
 			checkHomeDir()
@@ -85,6 +228,34 @@ func init() {
 	}
 }
 
+// loadTOTPSecret reads the TOTP secret from the file, or generates a new secret into the file when it does not exist and logs how to add it
+// to an authenticator app
+func loadTOTPSecret(filePath string) (string, error) {
+	if filePath == "" {
+		return "", fmt.Errorf("need to specify --totp-secret-file when --confirm-actions is '%s'", backend.ConfirmationPolicyTOTP)
+	}
+
+	secretBytes, e := ioutil.ReadFile(filePath)
+	if e == nil {
+		return strings.TrimSpace(string(secretBytes)), nil
+	}
+	if !os.IsNotExist(e) {
+		return "", fmt.Errorf("unable to read TOTP secret file '%s': %s", filePath, e)
+	}
+
+	secret, e := utils.GenerateTOTPSecret()
+	if e != nil {
+		return "", fmt.Errorf("unable to generate TOTP secret: %s", e)
+	}
+	e = ioutil.WriteFile(filePath, []byte(secret+"\n"), 0600)
+	if e != nil {
+		return "", fmt.Errorf("unable to write TOTP secret file '%s': %s", filePath, e)
+	}
+	log.Printf("generated a new TOTP secret into '%s', add it to your authenticator app with the key '%s' or the URI 'otpauth://totp/Kelp?secret=%s&issuer=Kelp'\n",
+		filePath, secret, secret)
+	return secret, nil
+}
+
 func setMiddleware(r *chi.Mux) {
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
@@ -98,6 +269,7 @@ func runAPIServerDevBlocking(s *backend.APIServer, frontendPort uint16, devAPIPo
 	// Add CORS middleware around every request since both ports are different when running server in dev mode
 	r.Use(cors.New(cors.Options{
 		AllowedOrigins: []string{fmt.Sprintf("http://localhost:%d", frontendPort)},
+		ExposedHeaders: []string{backend.NextCursorHeader},
 	}).Handler)
 
 	setMiddleware(r)
@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stellar/kelp/support/kelpos"
+)
+
+const serviceExamples = `  kelp service install
+  kelp service install --user -- --port 8000
+  kelp service install --botConf ./path/trader.cfg --strategy buysell --stratConf ./path/buysell.cfg
+  kelp service status --name kelp-bot-trader
+  kelp service uninstall --name kelp-bot-trader`
+
+var serviceCmd = &cobra.Command{
+	Use:     "service",
+	Short:   "Registers the GUI server or a bot as a systemd unit on Linux or a Windows service so it starts at boot and is restarted when it exits",
+	Example: serviceExamples,
+}
+
+var serviceInstallCmd = &cobra.Command{
+	Use:   "install [-- extra args of the kelp command]",
+	Short: "Installs and starts the GUI server as a service, or a bot when --botConf is set",
+}
+
+var serviceUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Stops and removes a service",
+}
+
+var serviceStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Prints the status of a service as reported by the service manager",
+}
+
+var serviceRunCmd = &cobra.Command{
+	Hidden: true,
+	Use:    "run -- args of the kelp command",
+	Short:  "Runs the kelp command under the Windows service control manager, used by the services installed on Windows",
+}
+
+func init() {
+	installName := serviceInstallCmd.Flags().String("name", "", "name of the service, defaults to 'kelp-server' or to 'kelp-bot-' followed by the file name of the botConf")
+	installUser := serviceInstallCmd.Flags().Bool("user", false, "install a systemd user unit instead of a system unit, which does not need root (Linux only). Run 'loginctl enable-linger' to keep it running after logging out")
+	installLog := serviceInstallCmd.Flags().String("log", "", "file that the output of the service is appended to, defaults to ./logs/<name>.log")
+	installRunAs := serviceInstallCmd.Flags().String("run-as", "", "user that runs a systemd system unit (Linux only), defaults to the user that invoked sudo")
+	installBotConf := serviceInstallCmd.Flags().StringP("botConf", "c", "", "install a bot with this basic config file instead of the GUI server")
+	installStrategy := serviceInstallCmd.Flags().StringP("strategy", "s", "", "type of strategy of the bot, required with --botConf")
+	installStratConf := serviceInstallCmd.Flags().StringP("stratConf", "f", "", "strategy config file path of the bot")
+	serviceInstallCmd.Flags().SortFlags = false
+	serviceInstallCmd.Run = func(ccmd *cobra.Command, args []string) {
+		checkInitRootFlags()
+		config, e := makeServiceConfig(*installName, *installUser, *installLog, *installRunAs, *installBotConf, *installStrategy, *installStratConf, args)
+		if e != nil {
+			log.Fatal(e)
+		}
+
+		e = kelpos.InstallService(config)
+		if e != nil {
+			log.Fatalf("unable to install service '%s': %s\n", config.Name, e)
+		}
+		log.Printf("installed and started service '%s' running '%s %s', its output is appended to %s\n", config.Name, config.BinPath, strings.Join(config.Args, " "), config.LogPath)
+	}
+
+	uninstallName := serviceUninstallCmd.Flags().String("name", "", "(required) name of the service")
+	uninstallUser := serviceUninstallCmd.Flags().Bool("user", false, "the service is a systemd user unit (Linux only)")
+	serviceUninstallCmd.Run = func(ccmd *cobra.Command, args []string) {
+		e := kelpos.UninstallService(*uninstallName, *uninstallUser)
+		if e != nil {
+			log.Fatalf("unable to uninstall service '%s': %s\n", *uninstallName, e)
+		}
+		log.Printf("uninstalled service '%s'\n", *uninstallName)
+	}
+
+	statusName := serviceStatusCmd.Flags().String("name", "", "(required) name of the service")
+	statusUser := serviceStatusCmd.Flags().Bool("user", false, "the service is a systemd user unit (Linux only)")
+	serviceStatusCmd.Run = func(ccmd *cobra.Command, args []string) {
+		status, e := kelpos.ServiceStatus(*statusName, *statusUser)
+		if e != nil {
+			log.Fatalf("unable to get the status of service '%s': %s\n", *statusName, e)
+		}
+		fmt.Print(status)
+	}
+
+	runName := serviceRunCmd.Flags().String("name", "", "(required) name of the service")
+	runLog := serviceRunCmd.Flags().String("log", "", "(required) file that the output of the kelp command is appended to")
+	runWorkingDir := serviceRunCmd.Flags().String("working-dir", "", "(required) directory the kelp command runs in")
+	serviceRunCmd.Run = func(ccmd *cobra.Command, args []string) {
+		isService, e := kelpos.IsWindowsService()
+		if e != nil {
+			log.Fatalf("unable to check whether kelp runs as a Windows service: %s\n", e)
+		}
+		if !isService {
+			log.Fatal("the run command can only be used by the service control manager of Windows, use 'kelp service install' instead")
+		}
+		binPath, e := os.Executable()
+		if e != nil {
+			log.Fatalf("unable to find the path of the kelp binary: %s\n", e)
+		}
+
+		e = kelpos.RunWindowsService(*runName, binPath, args, *runWorkingDir, *runLog)
+		if e != nil {
+			log.Fatalf("unable to run service '%s': %s\n", *runName, e)
+		}
+	}
+
+	for _, c := range []*cobra.Command{serviceUninstallCmd, serviceStatusCmd, serviceRunCmd} {
+		e := c.MarkFlagRequired("name")
+		if e != nil {
+			panic(e)
+		}
+	}
+	for _, flag := range []string{"log", "working-dir"} {
+		e := serviceRunCmd.MarkFlagRequired(flag)
+		if e != nil {
+			panic(e)
+		}
+	}
+
+	serviceCmd.AddCommand(serviceInstallCmd)
+	serviceCmd.AddCommand(serviceUninstallCmd)
+	serviceCmd.AddCommand(serviceStatusCmd)
+	serviceCmd.AddCommand(serviceRunCmd)
+}
+
+// makeServiceConfig makes the config of the service that runs the GUI server, or the bot when botConf is set, with the extra args appended
+// to the kelp command. Paths are made absolute since services do not run in the directory they were installed from.
+func makeServiceConfig(
+	name string,
+	userUnit bool,
+	logPath string,
+	runAs string,
+	botConf string,
+	strategy string,
+	stratConf string,
+	extraArgs []string,
+) (*kelpos.ServiceConfig, error) {
+	if userUnit && runtime.GOOS != "linux" {
+		return nil, fmt.Errorf("--user is only supported on linux")
+	}
+	workingDir, e := os.Getwd()
+	if e != nil {
+		return nil, fmt.Errorf("unable to get the working directory: %s", e)
+	}
+	binPath, e := os.Executable()
+	if e != nil {
+		return nil, fmt.Errorf("unable to find the path of the kelp binary: %s", e)
+	}
+	binPath, e = filepath.EvalSymlinks(binPath)
+	if e != nil {
+		return nil, fmt.Errorf("unable to resolve the path of the kelp binary: %s", e)
+	}
+
+	var args []string
+	var description string
+	if botConf != "" {
+		if strategy == "" {
+			return nil, fmt.Errorf("need to specify --strategy with --botConf")
+		}
+		absBotConf, e := filepath.Abs(botConf)
+		if e != nil {
+			return nil, fmt.Errorf("unable to make the path of the botConf absolute: %s", e)
+		}
+		args = []string{"trade", "--botConf", absBotConf, "--strategy", strategy}
+		if stratConf != "" {
+			absStratConf, e := filepath.Abs(stratConf)
+			if e != nil {
+				return nil, fmt.Errorf("unable to make the path of the stratConf absolute: %s", e)
+			}
+			args = append(args, "--stratConf", absStratConf)
+		}
+		if name == "" {
+			name = "kelp-bot-" + strings.TrimSuffix(filepath.Base(absBotConf), filepath.Ext(absBotConf))
+		}
+		description = fmt.Sprintf("Kelp bot (%s strategy) with config %s", strategy, absBotConf)
+	} else {
+		if strategy != "" || stratConf != "" {
+			return nil, fmt.Errorf("--strategy and --stratConf can only be used with --botConf")
+		}
+		args = []string{"server"}
+		if name == "" {
+			name = "kelp-server"
+		}
+		description = "Kelp GUI server"
+	}
+	if *rootCcxtRestURL != "" {
+		args = append(args, "--ccxt-rest-url", *rootCcxtRestURL)
+	}
+	args = append(args, extraArgs...)
+
+	if logPath == "" {
+		logPath = filepath.Join("logs", name+".log")
+	}
+	logPath, e = filepath.Abs(logPath)
+	if e != nil {
+		return nil, fmt.Errorf("unable to make the path of the log file absolute: %s", e)
+	}
+	if runAs == "" && !userUnit {
+		runAs = os.Getenv("SUDO_USER")
+	}
+
+	return &kelpos.ServiceConfig{
+		Name:        name,
+		Description: description,
+		BinPath:     binPath,
+		Args:        args,
+		WorkingDir:  workingDir,
+		LogPath:     logPath,
+		RunAs:       runAs,
+		UserUnit:    userUnit,
+	}, nil
+}
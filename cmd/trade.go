@@ -6,8 +6,10 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
 	"runtime/debug"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/nikhilsaraf/go-tools/multithreading"
@@ -16,10 +18,12 @@ import (
 	"github.com/stellar/go/clients/horizonclient"
 	hProtocol "github.com/stellar/go/protocols/horizon"
 	"github.com/stellar/go/support/config"
+	"github.com/stellar/kelp/accounting"
 	"github.com/stellar/kelp/api"
 	"github.com/stellar/kelp/model"
 	"github.com/stellar/kelp/plugins"
 	"github.com/stellar/kelp/query"
+	"github.com/stellar/kelp/support/kelpos"
 	"github.com/stellar/kelp/support/logger"
 	"github.com/stellar/kelp/support/monitoring"
 	"github.com/stellar/kelp/support/networking"
@@ -75,6 +79,13 @@ type inputs struct {
 	logPrefix                     *string
 	fixedIterations               *uint64
 	noHeaders                     *bool
+	pnlFile                       *string
+	offersJournalFile             *string
+	runHistoryFile                *string
+	dryRunDiff                    *bool
+	shutdownGracePeriod           *uint32
+	killSwitchFile                *string
+	customPriceFeedsFile          *string
 }
 
 func validateCliParams(l logger.Logger, options inputs) {
@@ -88,6 +99,11 @@ func validateCliParams(l logger.Logger, options inputs) {
 		panic(fmt.Sprintf("invalid operationalBufferNonNativePct argument, must be between 0 and 1 inclusive: %f", *options.operationalBufferNonNativePct))
 	}
 
+	if *options.dryRunDiff && !*options.simMode {
+		*options.simMode = true
+		l.Info("running in simulation mode because --dry-run-diff is set")
+	}
+
 	if *options.fixedIterations == 0 {
 		options.fixedIterations = nil
 		l.Info("will run unbounded iterations")
@@ -97,24 +113,59 @@ func validateCliParams(l logger.Logger, options inputs) {
 }
 
 func validateBotConfig(l logger.Logger, botConfig trader.BotConfig) {
+	errs := botConfigErrors(botConfig)
+	if len(errs) > 0 {
+		logger.Fatal(l, errs[0])
+	}
+}
+
+// botConfigErrors returns all the cross-field validation errors in the bot config
+func botConfigErrors(botConfig trader.BotConfig) []error {
+	errs := []error{}
 	if botConfig.IsTradingSdex() && botConfig.Fee == nil {
-		logger.Fatal(l, fmt.Errorf("The `FEE` object needs to exist in the trader config file when trading on SDEX"))
+		errs = append(errs, fmt.Errorf("The `FEE` object needs to exist in the trader config file when trading on SDEX"))
+	}
+
+	if !botConfig.IsTradingSdex() && botConfig.CoreURL != "" {
+		errs = append(errs, fmt.Errorf("CORE_URL can only be set when trading on SDEX"))
+	}
+
+	if !botConfig.IsTradingSdex() && botConfig.CoreDBURL != "" {
+		errs = append(errs, fmt.Errorf("CORE_DB_URL can only be set when trading on SDEX"))
+	}
+
+	if !botConfig.IsTradingSdex() && botConfig.PassiveOffers {
+		errs = append(errs, fmt.Errorf("PASSIVE_OFFERS can only be set when trading on SDEX"))
+	}
+
+	if len(botConfig.Pairs) > 0 && botConfig.ReconcileIntervalSeconds != 0 {
+		errs = append(errs, fmt.Errorf("RECONCILE_INTERVAL_SECONDS cannot be used with PAIRS because the pairs share the balances of the account"))
+	}
+
+	if !botConfig.IsTradingSdex() && (botConfig.OpBudgetPerLedger != 0 || botConfig.OpBudgetBurst != 0) {
+		errs = append(errs, fmt.Errorf("OP_BUDGET_PER_LEDGER and OP_BUDGET_BURST can only be set when trading on SDEX"))
 	}
 
 	if !botConfig.IsTradingSdex() && botConfig.CentralizedMinBaseVolumeOverride != nil && *botConfig.CentralizedMinBaseVolumeOverride <= 0.0 {
-		logger.Fatal(l, fmt.Errorf("need to specify positive CENTRALIZED_MIN_BASE_VOLUME_OVERRIDE config param in trader config file when not trading on SDEX"))
+		errs = append(errs, fmt.Errorf("need to specify positive CENTRALIZED_MIN_BASE_VOLUME_OVERRIDE config param in trader config file when not trading on SDEX"))
 	}
 	if !botConfig.IsTradingSdex() && botConfig.CentralizedMinQuoteVolumeOverride != nil && *botConfig.CentralizedMinQuoteVolumeOverride <= 0.0 {
-		logger.Fatal(l, fmt.Errorf("need to specify positive CENTRALIZED_MIN_QUOTE_VOLUME_OVERRIDE config param in trader config file when not trading on SDEX"))
+		errs = append(errs, fmt.Errorf("need to specify positive CENTRALIZED_MIN_QUOTE_VOLUME_OVERRIDE config param in trader config file when not trading on SDEX"))
 	}
-	validatePrecisionConfig(l, botConfig.IsTradingSdex(), botConfig.CentralizedVolumePrecisionOverride, "CENTRALIZED_VOLUME_PRECISION_OVERRIDE")
-	validatePrecisionConfig(l, botConfig.IsTradingSdex(), botConfig.CentralizedPricePrecisionOverride, "CENTRALIZED_PRICE_PRECISION_OVERRIDE")
+	if e := validatePrecisionConfig(botConfig.IsTradingSdex(), botConfig.CentralizedVolumePrecisionOverride, "CENTRALIZED_VOLUME_PRECISION_OVERRIDE"); e != nil {
+		errs = append(errs, e)
+	}
+	if e := validatePrecisionConfig(botConfig.IsTradingSdex(), botConfig.CentralizedPricePrecisionOverride, "CENTRALIZED_PRICE_PRECISION_OVERRIDE"); e != nil {
+		errs = append(errs, e)
+	}
+	return errs
 }
 
-func validatePrecisionConfig(l logger.Logger, isTradingSdex bool, precisionField *int8, name string) {
+func validatePrecisionConfig(isTradingSdex bool, precisionField *int8, name string) error {
 	if !isTradingSdex && precisionField != nil && *precisionField < 0 {
-		logger.Fatal(l, fmt.Errorf("need to specify non-negative %s config param in trader config file when not trading on SDEX", name))
+		return fmt.Errorf("need to specify non-negative %s config param in trader config file when not trading on SDEX", name)
 	}
+	return nil
 }
 
 func init() {
@@ -128,9 +179,16 @@ func init() {
 	options.operationalBufferNonNativePct = tradeCmd.Flags().Float64("operationalBufferNonNativePct", 0.001, "buffer of non-native assets to maintain as a percentage (0.001 = 0.1%)")
 	options.withIPC = tradeCmd.Flags().Bool("with-ipc", false, "enable IPC communication when spawned as a child process from the GUI")
 	options.simMode = tradeCmd.Flags().Bool("sim", false, "simulate the bot's actions without placing any trades")
+	options.dryRunDiff = tradeCmd.Flags().Bool("dry-run-diff", false, "log the offers that every update cycle would create, modify and delete as a table and as JSON without submitting them (implies --sim)")
 	options.logPrefix = tradeCmd.Flags().StringP("log", "l", "", "log to a file (and stdout) with this prefix for the filename")
 	options.fixedIterations = tradeCmd.Flags().Uint64("iter", 0, "only run the bot for the first N iterations (defaults value 0 runs unboundedly)")
 	options.noHeaders = tradeCmd.Flags().Bool("no-headers", false, "do not set X-App-Name and X-App-Version headers on requests to horizon")
+	options.pnlFile = tradeCmd.Flags().String("pnlFile", "", "persist the profit and loss accounting of the bot's fills to this file so it survives restarts (kept in memory when not set)")
+	options.offersJournalFile = tradeCmd.Flags().String("offersJournalFile", "", "journal the bot's offers to this file so the next run can recover the offers left behind after a crash (all offers on the trading pair are recovered when not set)")
+	options.shutdownGracePeriod = tradeCmd.Flags().Uint32("shutdownGracePeriod", uint32(kelpos.DefaultShutdownGracePeriod/time.Second), "seconds the bot has to delete its offers, cancel its orders on the backing exchange and save its state when it receives SIGTERM or SIGINT")
+	options.runHistoryFile = tradeCmd.Flags().String("runHistoryFile", "", "record a snapshot of every update cycle to this file so the operational history of the bot survives restarts (kept in memory when not set)")
+	options.killSwitchFile = tradeCmd.Flags().String("killSwitchFile", "", "stop quoting and delete all offers in the next update cycle while this file exists, quoting resumes once it is removed. Use the same file for all bots on a host to stop them all at once")
+	options.customPriceFeedsFile = tradeCmd.Flags().String("customPriceFeedsFile", "", "JSON file with the custom price feeds that can be used with the \"custom\" feed type and the name of the feed as the URL, such as the file saved by the GUI")
 
 	requiredFlag("botConf")
 	requiredFlag("strategy")
@@ -145,7 +203,9 @@ func init() {
 
 func makeStartupMessage(options inputs) string {
 	startupMessage := "Starting Kelp Trader: " + version + " [" + gitHash + "]"
-	if *options.simMode {
+	if *options.dryRunDiff {
+		startupMessage += " (dry run diff mode)"
+	} else if *options.simMode {
 		startupMessage += " (simulation mode)"
 	}
 	return startupMessage
@@ -168,7 +228,8 @@ func makeFeeFn(l logger.Logger, botConfig trader.BotConfig, newClient *horizoncl
 	return feeFn
 }
 
-func readBotConfig(l logger.Logger, options inputs) trader.BotConfig {
+// readBotConfig returns the bot config and the logger to use from here on, which depends on the log config values
+func readBotConfig(l logger.Logger, options inputs) (trader.BotConfig, logger.Logger) {
 	var botConfig trader.BotConfig
 	e := config.Read(*options.botConfigPath, &botConfig)
 	utils.CheckConfigError(botConfig, e, *options.botConfigPath)
@@ -177,9 +238,7 @@ func readBotConfig(l logger.Logger, options inputs) trader.BotConfig {
 		logger.Fatal(l, e)
 	}
 
-	if *options.logPrefix != "" {
-		setLogFile(l, options, botConfig)
-	}
+	l = setLogOutput(l, options, botConfig)
 
 	l.Info(makeStartupMessage(options))
 	// now that we've got the basic messages logged, validate the cli params
@@ -189,7 +248,7 @@ func readBotConfig(l logger.Logger, options inputs) trader.BotConfig {
 	utils.LogConfig(botConfig)
 	validateBotConfig(l, botConfig)
 
-	return botConfig
+	return botConfig, l
 }
 
 func makeExchangeShimSdex(
@@ -282,6 +341,46 @@ func makeExchangeShimSdex(
 		feeFn,
 	)
 
+	if botConfig.CoreURL != "" {
+		e = sdex.SetCoreURL(botConfig.CoreURL)
+		if e != nil {
+			logger.Fatal(l, fmt.Errorf("unable to set CORE_URL: %s", e))
+			return nil, nil
+		}
+	}
+	if botConfig.CoreDBURL != "" {
+		e = sdex.SetCoreDB(botConfig.CoreDBURL)
+		if e != nil {
+			logger.Fatal(l, fmt.Errorf("unable to set CORE_DB_URL: %s", e))
+			return nil, nil
+		}
+	}
+	sdex.SetPassiveOffers(botConfig.PassiveOffers)
+	if botConfig.HorizonStreaming {
+		if botConfig.IsTradingSdex() {
+			sdex.EnableStreaming()
+		} else {
+			l.Infof("ignoring HORIZON_STREAMING because the bot is not trading on SDEX\n")
+		}
+	}
+	if len(botConfig.AdditionalSourceSecretSeeds) > 0 {
+		e = sdex.SetAdditionalSourceSeeds(botConfig.AdditionalSourceSecretSeeds)
+		if e != nil {
+			logger.Fatal(l, fmt.Errorf("invalid ADDITIONAL_SOURCE_SECRET_SEEDS: %s", e))
+			return nil, nil
+		}
+	}
+	if botConfig.OpBudgetPerLedger != 0 {
+		e = sdex.SetOpBudget(botConfig.OpBudgetPerLedger, botConfig.OpBudgetBurst)
+		if e != nil {
+			logger.Fatal(l, fmt.Errorf("invalid OP_BUDGET_PER_LEDGER or OP_BUDGET_BURST: %s", e))
+			return nil, nil
+		}
+	} else if botConfig.OpBudgetBurst != 0 {
+		logger.Fatal(l, fmt.Errorf("OP_BUDGET_BURST can only be set together with OP_BUDGET_PER_LEDGER"))
+		return nil, nil
+	}
+
 	if botConfig.IsTradingSdex() {
 		exchangeShim = sdex
 	}
@@ -302,6 +401,15 @@ func makeStrategy(
 	options inputs,
 	threadTracker *multithreading.ThreadTracker,
 ) api.Strategy {
+	if botConfig.PriceFeedMaxUnchangedSeconds > 0 || botConfig.PriceFeedMaxAgeSeconds > 0 {
+		// needs to be enabled before the strategy makes its price feeds
+		plugins.EnablePriceFeedFreshnessMonitor(
+			time.Duration(botConfig.PriceFeedMaxUnchangedSeconds)*time.Second,
+			time.Duration(botConfig.PriceFeedMaxAgeSeconds)*time.Second,
+			botConfig.PriceFeedHaltWhenStale,
+		)
+	}
+
 	// setting the temp hack variables for the sdex price feeds
 	e := plugins.SetPrivateSdexHack(client, plugins.MakeIEIF(true), network)
 	if e != nil {
@@ -321,6 +429,39 @@ func makeStrategy(
 	return strategy
 }
 
+func makeVolumeLimits(botConfig trader.BotConfig) ([]plugins.VolumeLimit, error) {
+	volumeLimits := []plugins.VolumeLimit{}
+	for _, c := range botConfig.VolumeLimits {
+		limit, e := plugins.ParseVolumeLimit(c.Window, c.Action, c.BaseCap, c.QuoteCap)
+		if e != nil {
+			return nil, fmt.Errorf("invalid VOLUME_LIMITS config: %s", e)
+		}
+		volumeLimits = append(volumeLimits, limit)
+	}
+	return volumeLimits, nil
+}
+
+// makeFillAnomalyLimits returns nil when the fills are not checked for anomalies
+func makeFillAnomalyLimits(botConfig trader.BotConfig) (*plugins.FillAnomalyLimits, error) {
+	if botConfig.FillAnomalyMaxSizeMultiple <= 0 && botConfig.FillAnomalyMaxPriceDeviationPct <= 0 {
+		return nil, nil
+	}
+
+	limits := &plugins.FillAnomalyLimits{
+		LevelAmount:          botConfig.FillAnomalyLevelAmount,
+		MaxSizeMultiple:      botConfig.FillAnomalyMaxSizeMultiple,
+		MaxPriceDeviationPct: botConfig.FillAnomalyMaxPriceDeviationPct,
+	}
+	if botConfig.FillAnomalyMaxPriceDeviationPct > 0 {
+		feedPair, e := plugins.MakeFeedPair(botConfig.FillAnomalyFeedAType, botConfig.FillAnomalyFeedAURL, botConfig.FillAnomalyFeedBType, botConfig.FillAnomalyFeedBURL)
+		if e != nil {
+			return nil, fmt.Errorf("invalid FILL_ANOMALY_FEED config: %s", e)
+		}
+		limits.ReferenceFeed = feedPair
+	}
+	return limits, nil
+}
+
 func makeBot(
 	l logger.Logger,
 	botConfig trader.BotConfig,
@@ -333,10 +474,19 @@ func makeBot(
 	threadTracker *multithreading.ThreadTracker,
 	options inputs,
 ) *trader.Trader {
-	timeController := plugins.MakeIntervalTimeController(
-		time.Duration(botConfig.TickIntervalSeconds)*time.Second,
-		botConfig.MaxTickDelayMillis,
-	)
+	var timeController api.TimeController
+	if botConfig.TickAlignToClock {
+		timeController = plugins.MakeAlignedTimeController(
+			time.Duration(botConfig.TickIntervalSeconds)*time.Second,
+			time.Duration(botConfig.TickAlignOffsetSeconds)*time.Second,
+		)
+	} else {
+		timeController = plugins.MakeIntervalTimeController(
+			time.Duration(botConfig.TickIntervalSeconds)*time.Second,
+			botConfig.MaxTickDelayMillis,
+			botConfig.TickIntervalJitter,
+		)
+	}
 	submitMode, e := api.ParseSubmitMode(botConfig.SubmitMode)
 	if e != nil {
 		log.Println()
@@ -344,28 +494,68 @@ func makeBot(
 		// we want to delete all the offers and exit here since there is something wrong with our setup
 		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker)
 	}
-	dataKey := model.MakeSortedBotKey(botConfig.AssetBase(), botConfig.AssetQuote())
+	volumeLimits, e := makeVolumeLimits(botConfig)
+	if e != nil {
+		log.Println()
+		log.Println(e)
+		// we want to delete all the offers and exit here since there is something wrong with our setup
+		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker)
+	}
+	fillAnomalyLimits, e := makeFillAnomalyLimits(botConfig)
+	if e != nil {
+		log.Println()
+		log.Println(e)
+		// we want to delete all the offers and exit here since there is something wrong with our setup
+		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker)
+	}
 	alert, e := monitoring.MakeAlert(botConfig.AlertType, botConfig.AlertAPIKey)
 	if e != nil {
 		l.Infof("Unable to set up monitoring for alert type '%s' with the given API key\n", botConfig.AlertType)
 	}
+	submitFilters := plugins.MakeFilterPipeline(
+		submitMode,
+		botConfig.MaxChurnPerCycle,
+		botConfig.AmountJitter,
+		botConfig.AmountJitterSeed,
+		volumeLimits,
+		botConfig.MinSellPrice,
+		botConfig.MaxBuyPrice,
+		fillAnomalyLimits,
+		alert,
+		exchangeShim,
+		sdex,
+		tradingPair,
+		botConfig.AssetBase(),
+		botConfig.AssetQuote(),
+	)
+	for _, f := range botConfig.SubmitFilters {
+		e = submitFilters.Configure(f.Name, f.Enabled, f.Priority)
+		if e != nil {
+			log.Println()
+			log.Printf("invalid SUBMIT_FILTERS config: %s\n", e)
+			// we want to delete all the offers and exit here since there is something wrong with our setup
+			deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker)
+		}
+	}
+	l.Infof("submit filters: %s\n", submitFilters)
+	dataKey := model.MakeSortedBotKey(botConfig.AssetBase(), botConfig.AssetQuote())
 	bot := trader.MakeBot(
 		client,
 		ieif,
 		botConfig.AssetBase(),
 		botConfig.AssetQuote(),
-		tradingPair,
 		botConfig.TradingAccount(),
 		sdex,
 		exchangeShim,
 		strategy,
 		timeController,
 		botConfig.DeleteCyclesThreshold,
-		submitMode,
+		submitFilters,
 		threadTracker,
 		options.fixedIterations,
 		dataKey,
 		alert,
+		l,
 	)
 	return bot
 }
@@ -384,28 +574,30 @@ func convertDeprecatedBotConfigValues(l logger.Logger, botConfig trader.BotConfi
 
 func runTradeCmd(options inputs) {
 	l := logger.MakeBasicLogger()
-	botConfig := readBotConfig(l, options)
+	botConfig, l := readBotConfig(l, options)
 	botConfig = convertDeprecatedBotConfigValues(l, botConfig)
-	l.Infof("Trading %s:%s for %s:%s\n", botConfig.AssetCodeA, botConfig.IssuerA, botConfig.AssetCodeB, botConfig.IssuerB)
-
-	// --- start initialization of objects ----
-	threadTracker := multithreading.MakeThreadTracker()
-	assetBase := botConfig.AssetBase()
-	assetQuote := botConfig.AssetQuote()
-	tradingPair := &model.TradingPair{
-		Base:  model.Asset(utils.Asset2CodeString(assetBase)),
-		Quote: model.Asset(utils.Asset2CodeString(assetQuote)),
-	}
 
 	client := &horizonclient.Client{
 		HorizonURL: botConfig.HorizonURL,
 		HTTP:       http.DefaultClient,
 	}
+	if botConfig.HorizonCacheOrderbookTTLMillis > 0 || botConfig.HorizonCacheAccountTTLMillis > 0 {
+		orderbookTTL := time.Duration(botConfig.HorizonCacheOrderbookTTLMillis) * time.Millisecond
+		accountTTL := time.Duration(botConfig.HorizonCacheAccountTTLMillis) * time.Millisecond
+		client.HTTP = networking.MakeCachingHTTPClient(http.DefaultClient, networking.HorizonCacheRules(orderbookTTL, accountTTL))
+		l.Infof("caching horizon responses for the orderbook (ttl=%s) and the account details (ttl=%s)\n", orderbookTTL, accountTTL)
+	}
+	// check the version of persisted state before using it so we fail fast instead of misreading a format from a different version
+	p := prefs.Make(prefsFilename)
+	e := p.CheckCompat()
+	if e != nil {
+		logger.Fatal(l, e)
+	}
+
 	if !*options.noHeaders {
 		client.AppName = "kelp"
 		client.AppVersion = version
 
-		p := prefs.Make(prefsFilename)
 		if p.FirstTime() {
 			log.Printf("Kelp sets the `X-App-Name` and `X-App-Version` headers on requests made to Horizon. These headers help us track overall Kelp usage, so that we can learn about general usage patterns and adapt Kelp to be more useful in the future. These can be turned off using the `--no-headers` flag. See `kelp trade --help` for more information.\n")
 			e := p.SetNotFirstTime()
@@ -425,6 +617,146 @@ func runTradeCmd(options inputs) {
 	}
 	l.Infof("using CCXT-rest URL: %s\n", sdk.GetBaseURL())
 
+	if *options.customPriceFeedsFile != "" {
+		e := plugins.LoadCustomPriceFeeds(*options.customPriceFeedsFile)
+		if e != nil {
+			logger.Fatal(l, e)
+		}
+	}
+
+	shutdownHooks := kelpos.MakeShutdownHooks(time.Duration(*options.shutdownGracePeriod) * time.Second)
+	if len(botConfig.Pairs) > 0 {
+		runTradingPairs(l, botConfig, options, client, shutdownHooks)
+		return
+	}
+
+	bot, _ := startTradingPair(l, botConfig, options, client, nil, shutdownHooks)
+	shutdownHooks.RunOnSignal(os.Exit)
+
+	l.Info("Starting the trader bot...")
+	bot.Start()
+	runExitHooks(l, shutdownHooks)
+}
+
+// runTradingPairs runs a bot for each of the PAIRS in this process, the bots share the horizon client, the sequence numbers of the
+// accounts and the op budget while each pair keeps its own strategy state, fill tracking and state files
+func runTradingPairs(
+	l logger.Logger,
+	botConfig trader.BotConfig,
+	options inputs,
+	client *horizonclient.Client,
+	shutdownHooks *kelpos.ShutdownHooks,
+) {
+	if *options.withIPC {
+		logger.Fatal(l, fmt.Errorf("PAIRS cannot be used with --with-ipc because the query server can only serve one trading pair"))
+	}
+
+	bots := []*trader.Trader{}
+	// the op budget is shared by the pairs of each trading account
+	budgetSdexes := map[string]*plugins.SDEX{}
+	for i, p := range botConfig.Pairs {
+		pairConfig, e := botConfig.ForPair(p)
+		if e != nil {
+			logger.Fatal(l, e)
+		}
+		if i > 0 {
+			// the monitoring server of the process reports the metrics of the first pair
+			pairConfig.MonitoringPort = 0
+		}
+
+		baseShare, quoteShare, e := botConfig.BalanceShares(p)
+		if e != nil {
+			logger.Fatal(l, e)
+		}
+
+		budgetSdex := budgetSdexes[pairConfig.TradingAccount()]
+		bot, sdex := startTradingPair(l, pairConfig, optionsForPair(options, p), client, budgetSdex, shutdownHooks)
+		if budgetSdex == nil {
+			budgetSdexes[pairConfig.TradingAccount()] = sdex
+		}
+		// pairs of the same account that trade the same asset split its balance so they do not place offers against the same units
+		bot.SetBalanceShares(baseShare, quoteShare)
+		bots = append(bots, bot)
+	}
+	shutdownHooks.RunOnSignal(os.Exit)
+
+	l.Infof("Starting the trader bots for %d pairs...\n", len(bots))
+	var wg sync.WaitGroup
+	for _, bot := range bots {
+		wg.Add(1)
+		go func(bot *trader.Trader) {
+			defer logPanic(l, true)
+			defer wg.Done()
+			bot.Start()
+		}(bot)
+	}
+	wg.Wait()
+	runExitHooks(l, shutdownHooks)
+}
+
+// runExitHooks runs the shutdown hooks that also need to run when the bots finish on their own, which happens after the last of the
+// --iter update cycles
+func runExitHooks(l logger.Logger, shutdownHooks *kelpos.ShutdownHooks) {
+	for _, e := range shutdownHooks.RunOnExit() {
+		l.Errorf("%s", e)
+	}
+}
+
+// optionsForPair returns the options for one of the PAIRS, the strategy of the pair overrides the --strategy and --stratConf flags and the
+// state files get the assets of the pair added to their names so every pair keeps its own state
+func optionsForPair(options inputs, p trader.PairConfig) inputs {
+	stringOption := func(value string) *string {
+		return &value
+	}
+
+	pairOptions := options
+	if p.Strategy != "" {
+		pairOptions.strategy = stringOption(p.Strategy)
+		pairOptions.stratConfigPath = stringOption(p.StrategyConfig)
+	} else if p.StrategyConfig != "" {
+		pairOptions.stratConfigPath = stringOption(p.StrategyConfig)
+	}
+	pairOptions.pnlFile = stringOption(pairFilePath(*options.pnlFile, p))
+	pairOptions.offersJournalFile = stringOption(pairFilePath(*options.offersJournalFile, p))
+	pairOptions.runHistoryFile = stringOption(pairFilePath(*options.runHistoryFile, p))
+	if options.fixedIterations != nil {
+		// every bot counts down its own iterations
+		fixedIterations := *options.fixedIterations
+		pairOptions.fixedIterations = &fixedIterations
+	}
+	return pairOptions
+}
+
+// pairFilePath adds the asset codes of the pair to the file name before the extension, an empty path stays empty
+func pairFilePath(path string, p trader.PairConfig) string {
+	if path == "" {
+		return ""
+	}
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s_%s_%s%s", strings.TrimSuffix(path, ext), p.AssetCodeA, p.AssetCodeB, ext)
+}
+
+// startTradingPair initializes the bot and the services of the trading pair of the botConfig, budgetSdex is the SDEX whose op budget is
+// shared when running multiple pairs of the same trading account and is nil otherwise
+func startTradingPair(
+	l logger.Logger,
+	botConfig trader.BotConfig,
+	options inputs,
+	client *horizonclient.Client,
+	budgetSdex *plugins.SDEX,
+	shutdownHooks *kelpos.ShutdownHooks,
+) (*trader.Trader, *plugins.SDEX) {
+	l.Infof("Trading %s:%s for %s:%s\n", botConfig.AssetCodeA, botConfig.IssuerA, botConfig.AssetCodeB, botConfig.IssuerB)
+
+	// --- start initialization of objects ----
+	threadTracker := multithreading.MakeThreadTracker()
+	assetBase := botConfig.AssetBase()
+	assetQuote := botConfig.AssetQuote()
+	tradingPair := &model.TradingPair{
+		Base:  model.Asset(utils.Asset2CodeString(assetBase)),
+		Quote: model.Asset(utils.Asset2CodeString(assetQuote)),
+	}
+
 	ieif := plugins.MakeIEIF(botConfig.IsTradingSdex())
 	network := utils.ParseNetwork(botConfig.HorizonURL)
 	exchangeShim, sdex := makeExchangeShimSdex(
@@ -437,6 +769,13 @@ func runTradeCmd(options inputs) {
 		threadTracker,
 		tradingPair,
 	)
+	if budgetSdex != nil {
+		sdex.ShareOpBudget(budgetSdex)
+	}
+	// share one load of the balances and offers of the account between the trader, the strategy and the submit filters in each update cycle
+	cycleContext := plugins.MakeCycleContext(exchangeShim)
+	ieif.SetExchangeShim(cycleContext)
+	exchangeShim = cycleContext
 	strategy := makeStrategy(
 		l,
 		network,
@@ -463,12 +802,35 @@ func runTradeCmd(options inputs) {
 		threadTracker,
 		options,
 	)
+	ledger, e := accounting.MakeLedger(*options.pnlFile)
+	if e != nil {
+		logger.Fatal(l, fmt.Errorf("unable to load the profit and loss ledger: %s", e))
+	}
+	runHistory, e := trader.MakeRunHistory(*options.runHistoryFile)
+	if e != nil {
+		logger.Fatal(l, fmt.Errorf("unable to load the run history: %s", e))
+	}
+	bot.SetRunHistory(runHistory)
+	opOrderingPolicy, e := trader.ParseOpOrderingPolicy(botConfig.OpOrderingPolicy)
+	if e != nil {
+		logger.Fatal(l, e)
+	}
+	bot.SetOpOrderingPolicy(opOrderingPolicy)
+	bot.SetCycleContext(cycleContext)
+	if *options.killSwitchFile != "" {
+		bot.EnableKillSwitch(*options.killSwitchFile)
+	}
 	// --- end initialization of objects ---
 	// --- start initialization of services ---
 	validateTrustlines(l, client, &botConfig)
 	if botConfig.MonitoringPort != 0 {
+		kelpMetrics, e := monitoring.MakeMetricsRecorder(nil)
+		if e != nil {
+			logger.Fatal(l, fmt.Errorf("unable to make metrics recorder for the /metrics endpoint: %s", e))
+		}
+		bot.SetMetrics(kelpMetrics)
 		go func() {
-			e := startMonitoringServer(l, botConfig)
+			e := startMonitoringServer(l, botConfig, kelpMetrics)
 			if e != nil {
 				l.Info("")
 				l.Info("unable to start the monitoring server or problem encountered while running server:")
@@ -480,19 +842,23 @@ func runTradeCmd(options inputs) {
 			}
 		}()
 	}
-	startFillTracking(
+	// the query server is started before fill tracking so it can keep the recent fills of the bot
+	qs := startQueryServer(
 		l,
+		*options.strategy,
 		strategy,
 		botConfig,
 		client,
 		sdex,
 		exchangeShim,
 		tradingPair,
+		bot,
+		ledger,
 		threadTracker,
+		&options,
 	)
-	startQueryServer(
+	startFillTracking(
 		l,
-		*options.strategy,
 		strategy,
 		botConfig,
 		client,
@@ -500,15 +866,96 @@ func runTradeCmd(options inputs) {
 		exchangeShim,
 		tradingPair,
 		threadTracker,
-		&options,
+		qs,
+		ledger,
+		bot.GetFillHandlers(),
+	)
+	startWatchdog(
+		l,
+		botConfig,
+		client,
+		sdex,
+		exchangeShim,
+		bot,
+		threadTracker,
+	)
+	startReconciliation(
+		l,
+		botConfig,
+		client,
+		sdex,
+		exchangeShim,
+		tradingPair,
+		threadTracker,
+		ledger,
 	)
 	// --- end initialization of services ---
 
-	l.Info("Starting the trader bot...")
-	bot.Start()
+	if *options.dryRunDiff {
+		l.Info("not recovering offers from previous runs in dry run diff mode, update cycles will log the offers they would change without submitting them")
+		bot.EnableDryRunDiff()
+	} else {
+		recoverOffers(
+			l,
+			botConfig,
+			client,
+			sdex,
+			exchangeShim,
+			bot,
+			threadTracker,
+			options,
+		)
+	}
+	registerShutdownHooks(
+		l,
+		botConfig,
+		client,
+		sdex,
+		exchangeShim,
+		strategy,
+		bot,
+		ledger,
+		threadTracker,
+		shutdownHooks,
+	)
+	return bot, sdex
+}
+
+// registerShutdownHooks stops the bot, deletes its offers, cancels its orders on the backing exchange and saves its state when the bot is
+// asked to stop, instead of leaving them behind
+func registerShutdownHooks(
+	l logger.Logger,
+	botConfig trader.BotConfig,
+	client *horizonclient.Client,
+	sdex *plugins.SDEX,
+	exchangeShim api.ExchangeShim,
+	strategy api.Strategy,
+	bot *trader.Trader,
+	ledger *accounting.Ledger,
+	threadTracker *multithreading.ThreadTracker,
+	shutdownHooks *kelpos.ShutdownHooks,
+) {
+	shutdownHooks.Register("stop update loop", func() error {
+		bot.Stop()
+		threadTracker.Stop(multithreading.StopModeError)
+		threadTracker.Wait()
+		return nil
+	})
+	shutdownHooks.Register("delete offers", func() error {
+		e := deleteAllOffers(l, botConfig, client, sdex, exchangeShim)
+		if e != nil {
+			return e
+		}
+		return bot.MarkStopped()
+	})
+	if canceller, ok := strategy.(plugins.BackingOrderCanceller); ok {
+		// the orders on the backing exchange are not visible on SDEX so they are also canceled when the bot finishes on its own
+		shutdownHooks.RegisterOnExit("cancel backing orders", canceller.CancelBackingOrders)
+	}
+	shutdownHooks.Register("flush ledger", ledger.Flush)
 }
 
-func startMonitoringServer(l logger.Logger, botConfig trader.BotConfig) error {
+func startMonitoringServer(l logger.Logger, botConfig trader.BotConfig, kelpMetrics monitoring.Metrics) error {
 	healthMetrics, e := monitoring.MakeMetricsRecorder(map[string]interface{}{"success": true})
 	if e != nil {
 		return fmt.Errorf("unable to make metrics recorder for the /health endpoint: %s", e)
@@ -518,10 +965,6 @@ func startMonitoringServer(l logger.Logger, botConfig trader.BotConfig) error {
 		return fmt.Errorf("unable to make /health endpoint: %s", e)
 	}
 
-	kelpMetrics, e := monitoring.MakeMetricsRecorder(nil)
-	if e != nil {
-		return fmt.Errorf("unable to make metrics recorder for the /metrics endpoint: %s", e)
-	}
 	metricsAuth := networking.NoAuth
 	if botConfig.GoogleClientID != "" || botConfig.GoogleClientSecret != "" {
 		metricsAuth = networking.GoogleAuth
@@ -557,6 +1000,9 @@ func startFillTracking(
 	exchangeShim api.ExchangeShim,
 	tradingPair *model.TradingPair,
 	threadTracker *multithreading.ThreadTracker,
+	qs *query.Server,
+	ledger *accounting.Ledger,
+	filterFillHandlers []api.FillHandler,
 ) {
 	strategyFillHandlers, e := strategy.GetFillHandlers()
 	if e != nil {
@@ -570,11 +1016,31 @@ func startFillTracking(
 		fillTracker := plugins.MakeFillTracker(tradingPair, threadTracker, exchangeShim, botConfig.FillTrackerSleepMillis, botConfig.FillTrackerDeleteCyclesThreshold)
 		fillLogger := plugins.MakeFillLogger()
 		fillTracker.RegisterHandler(fillLogger)
+		if qs != nil {
+			fillTracker.RegisterHandler(qs)
+		}
+		fillTracker.RegisterHandler(ledger)
+		if r, ok := strategy.(api.OffsetFillReporter); ok {
+			// trades on the backing exchange are not tracked by the fill tracker so the strategy reports them to the ledger directly
+			r.SetOffsetFillHandler(ledger)
+		}
 		if strategyFillHandlers != nil {
 			for _, h := range strategyFillHandlers {
 				fillTracker.RegisterHandler(h)
 			}
 		}
+		if len(filterFillHandlers) > 0 {
+			e = loadVolumeLimitFills(l, botConfig, client, exchangeShim, tradingPair, filterFillHandlers)
+			if e != nil {
+				l.Info("")
+				l.Errorf("problem encountered while loading the fills tracked by the submit filters: %s", e)
+				// we want to delete all the offers and exit here because the volume limits would not include the earlier fills
+				deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker)
+			}
+			for _, h := range filterFillHandlers {
+				fillTracker.RegisterHandler(h)
+			}
+		}
 
 		l.Infof("Starting fill tracker with %d handlers\n", fillTracker.NumHandlers())
 		go func() {
@@ -591,9 +1057,56 @@ func startFillTracking(
 		l.Error("error: strategy has FillHandlers but fill tracking was disabled (set FILL_TRACKER_SLEEP_MILLIS to a non-zero value)")
 		// we want to delete all the offers and exit here because we don't want the bot to run if fill tracking isn't working
 		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker)
+	} else if len(filterFillHandlers) > 0 {
+		l.Info("")
+		l.Error("error: VOLUME_LIMITS and FILL_ANOMALY_* need fill tracking but fill tracking was disabled (set FILL_TRACKER_SLEEP_MILLIS to a non-zero value)")
+		// we want to delete all the offers and exit here because we don't want the bot to run if fill tracking isn't working
+		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker)
 	}
 }
 
+// loadVolumeLimitFills passes the fills of the longest window of the volume limits to the handlers so the limits include the volume that
+// was traded before the bot started
+func loadVolumeLimitFills(
+	l logger.Logger,
+	botConfig trader.BotConfig,
+	client *horizonclient.Client,
+	exchangeShim api.ExchangeShim,
+	tradingPair *model.TradingPair,
+	handlers []api.FillHandler,
+) error {
+	volumeLimits, e := makeVolumeLimits(botConfig)
+	if e != nil {
+		return e
+	}
+	if len(volumeLimits) == 0 {
+		return nil
+	}
+	to := time.Now()
+	from := to.Add(-plugins.MaxVolumeWindow(volumeLimits))
+
+	var trades []model.Trade
+	if botConfig.IsTradingSdex() {
+		trades, e = plugins.LoadAccountTrades(client, botConfig.TradingAccount(), tradingPair, botConfig.AssetBase(), botConfig.AssetQuote(), from, to)
+	} else {
+		trades, e = accounting.FetchTradeHistory(exchangeShim, *tradingPair, tradeHistoryStartCursor(botConfig.TradingExchange, from), from, to)
+	}
+	if e != nil {
+		return fmt.Errorf("unable to load the trades since %s: %s", from, e)
+	}
+
+	for _, h := range handlers {
+		for _, trade := range trades {
+			e = h.HandleFill(trade)
+			if e != nil {
+				return fmt.Errorf("error handling trade %v: %s", trade.TransactionID, e)
+			}
+		}
+	}
+	l.Infof("loaded %d trades since %s into the volume limits\n", len(trades), from.Format(time.RFC3339))
+	return nil
+}
+
 func startQueryServer(
 	l logger.Logger,
 	strategyName string,
@@ -603,13 +1116,15 @@ func startQueryServer(
 	sdex *plugins.SDEX,
 	exchangeShim api.ExchangeShim,
 	tradingPair *model.TradingPair,
+	bot *trader.Trader,
+	ledger *accounting.Ledger,
 	threadTracker *multithreading.ThreadTracker,
 	options *inputs,
-) {
+) *query.Server {
 	// only start query server (with IPC) if specifically instructed to so so from the command line.
 	// File descriptors in the IPC receiver will be invalid and will crash the bot if the other end of the pipe does not exist.
 	if !*options.withIPC {
-		return
+		return nil
 	}
 
 	qs := query.MakeServer(
@@ -621,6 +1136,9 @@ func startQueryServer(
 		sdex,
 		exchangeShim,
 		tradingPair,
+		bot,
+		ledger,
+		*options.stratConfigPath,
 	)
 
 	go func() {
@@ -633,6 +1151,116 @@ func startQueryServer(
 			deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker)
 		}
 	}()
+	return qs
+}
+
+func startWatchdog(
+	l logger.Logger,
+	botConfig trader.BotConfig,
+	client *horizonclient.Client,
+	sdex *plugins.SDEX,
+	exchangeShim api.ExchangeShim,
+	bot *trader.Trader,
+	threadTracker *multithreading.ThreadTracker,
+) {
+	if botConfig.WatchdogMaxMissedIntervals == 0 {
+		l.Info("watchdog is disabled because WATCHDOG_MAX_MISSED_INTERVALS is not set")
+		return
+	}
+
+	action, e := trader.ParseWatchdogAction(botConfig.WatchdogAction)
+	if e != nil {
+		l.Info("")
+		l.Errorf("%s", e)
+		// we want to delete all the offers and exit here since there is something wrong with our setup
+		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker)
+	}
+
+	maxStall := time.Duration(botConfig.WatchdogMaxMissedIntervals) * time.Duration(botConfig.TickIntervalSeconds) * time.Second
+	bot.StartWatchdog(maxStall, action, func() {
+		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker)
+	})
+}
+
+func startReconciliation(
+	l logger.Logger,
+	botConfig trader.BotConfig,
+	client *horizonclient.Client,
+	sdex *plugins.SDEX,
+	exchangeShim api.ExchangeShim,
+	tradingPair *model.TradingPair,
+	threadTracker *multithreading.ThreadTracker,
+	ledger *accounting.Ledger,
+) {
+	if botConfig.ReconcileIntervalSeconds == 0 {
+		return
+	}
+	if botConfig.FillTrackerSleepMillis == 0 {
+		l.Info("")
+		l.Error("error: RECONCILE_INTERVAL_SECONDS needs fill tracking but fill tracking was disabled (set FILL_TRACKER_SLEEP_MILLIS to a non-zero value)")
+		// we want to delete all the offers and exit here since there is something wrong with our setup
+		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker)
+	}
+
+	alert, e := monitoring.MakeAlert(botConfig.AlertType, botConfig.AlertAPIKey)
+	if e != nil {
+		l.Infof("Unable to set up monitoring for alert type '%s' with the given API key\n", botConfig.AlertType)
+	}
+	fetchBalances := func() (float64, float64, error) {
+		baseBalance, e := exchangeShim.GetBalanceHack(botConfig.AssetBase())
+		if e != nil {
+			return 0, 0, fmt.Errorf("unable to fetch balance of base asset: %s", e)
+		}
+		quoteBalance, e := exchangeShim.GetBalanceHack(botConfig.AssetQuote())
+		if e != nil {
+			return 0, 0, fmt.Errorf("unable to fetch balance of quote asset: %s", e)
+		}
+		return baseBalance.Balance, quoteBalance.Balance, nil
+	}
+	reconciler := accounting.MakeReconciler(ledger, tradingPair.String(), fetchBalances, botConfig.ReconcileDriftThreshold, alert)
+	e = reconciler.Init()
+	if e != nil {
+		l.Info("")
+		l.Errorf("unable to start reconciling balances: %s", e)
+		// we want to delete all the offers and exit here since there is something wrong with our setup
+		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker)
+	}
+
+	l.Infof("Starting balance reconciliation every %d seconds\n", botConfig.ReconcileIntervalSeconds)
+	go reconciler.Run(time.Duration(botConfig.ReconcileIntervalSeconds) * time.Second)
+}
+
+func recoverOffers(
+	l logger.Logger,
+	botConfig trader.BotConfig,
+	client *horizonclient.Client,
+	sdex *plugins.SDEX,
+	exchangeShim api.ExchangeShim,
+	bot *trader.Trader,
+	threadTracker *multithreading.ThreadTracker,
+	options inputs,
+) {
+	action, e := trader.ParseStartupOffersAction(botConfig.StartupOffersAction)
+	if e != nil {
+		l.Info("")
+		l.Errorf("%s", e)
+		// we want to delete all the offers and exit here since there is something wrong with our setup
+		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker)
+	}
+	journal, e := trader.MakeOfferJournal(*options.offersJournalFile)
+	if e != nil {
+		l.Info("")
+		l.Errorf("unable to load the offer journal: %s", e)
+		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker)
+	}
+
+	e = bot.RecoverOffers(journal, action, botConfig.IsTradingSdex())
+	if e != nil {
+		l.Info("")
+		l.Errorf("unable to recover offers from previous runs: %s", e)
+		// we do not want to trade on top of stale offers so we delete all the offers and exit
+		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker)
+	}
 }
 
 func validateTrustlines(l logger.Logger, client *horizonclient.Client, botConfig *trader.BotConfig) {
@@ -642,31 +1270,35 @@ func validateTrustlines(l logger.Logger, client *horizonclient.Client, botConfig
 	}
 
 	log.Printf("validating trustlines...\n")
-	acctReq := horizonclient.AccountRequest{AccountID: botConfig.TradingAccount()}
-	account, e := client.AccountDetail(acctReq)
+	problems, e := trustlineProblems(client, botConfig)
 	if e != nil {
 		logger.Fatal(l, e)
 	}
-
-	missingTrustlines := []string{}
-	if botConfig.IssuerA != "" {
-		balance := utils.GetCreditBalance(account, botConfig.AssetCodeA, botConfig.IssuerA)
-		if balance == nil {
-			missingTrustlines = append(missingTrustlines, fmt.Sprintf("%s:%s", botConfig.AssetCodeA, botConfig.IssuerA))
-		}
+	if len(problems) > 0 {
+		logger.Fatal(l, fmt.Errorf("error: the trustlines of your trading account cannot be used for trading, run `kelp trust check` for details: %v", problems))
 	}
+	l.Info("trustlines valid")
+}
 
-	if botConfig.IssuerB != "" {
-		balance := utils.GetCreditBalance(account, botConfig.AssetCodeB, botConfig.IssuerB)
-		if balance == nil {
-			missingTrustlines = append(missingTrustlines, fmt.Sprintf("%s:%s", botConfig.AssetCodeB, botConfig.IssuerB))
-		}
+// trustlineProblems returns the problems with the trustlines of the trading account for the non-native assets of the bot config
+func trustlineProblems(client *horizonclient.Client, botConfig *trader.BotConfig) ([]string, error) {
+	statuses, e := plugins.LoadTrustlines(client, botConfig.TradingAccount(), []hProtocol.Asset{botConfig.AssetBase(), botConfig.AssetQuote()})
+	if e != nil {
+		return nil, e
 	}
 
-	if len(missingTrustlines) > 0 {
-		logger.Fatal(l, fmt.Errorf("error: your trading account does not have the required trustlines: %v", missingTrustlines))
+	problems := []string{}
+	for _, s := range statuses {
+		if !s.Exists {
+			problems = append(problems, fmt.Sprintf("%s, add it with `kelp trust add`", s.Problem))
+		} else if s.Problem != "" {
+			problems = append(problems, s.Problem)
+		}
+		if s.Warning != "" {
+			log.Printf("warning: %s\n", s.Warning)
+		}
 	}
-	l.Info("trustlines valid")
+	return problems, nil
 }
 
 func deleteAllOffersAndExit(
@@ -686,56 +1318,115 @@ func deleteAllOffersAndExit(
 	l.Info("")
 	l.Info("deleting all offers and then exiting...")
 
-	offers, e := utils.LoadAllOffers(botConfig.TradingAccount(), client)
+	e := deleteAllOffers(l, botConfig, client, sdex, exchangeShim)
 	if e != nil {
 		logger.Fatal(l, e)
 		return
 	}
+	logger.Fatal(l, fmt.Errorf("...deleted all offers, exiting"))
+}
+
+// deleteAllOffers deletes all offers of the trading account on the trading pair of the bot and waits for the deletion to be confirmed
+func deleteAllOffers(
+	l logger.Logger,
+	botConfig trader.BotConfig,
+	client *horizonclient.Client,
+	sdex *plugins.SDEX,
+	exchangeShim api.ExchangeShim,
+) error {
+	offers, e := utils.LoadAllOffers(botConfig.TradingAccount(), client)
+	if e != nil {
+		return fmt.Errorf("unable to load offers to delete: %s", e)
+	}
 	sellingAOffers, buyingAOffers := utils.FilterOffers(offers, botConfig.AssetBase(), botConfig.AssetQuote())
 	allOffers := append(sellingAOffers, buyingAOffers...)
 
 	dOps := sdex.DeleteAllOffers(allOffers)
 	l.Infof("created %d operations to delete offers\n", len(dOps))
+	if len(dOps) == 0 {
+		return nil
+	}
 
-	if len(dOps) > 0 {
-		e := exchangeShim.SubmitOpsSynch(dOps, func(hash string, e error) {
-			if e != nil {
-				logger.Fatal(l, e)
-				return
-			}
-			logger.Fatal(l, fmt.Errorf("...deleted all offers, exiting"))
-		})
-		if e != nil {
-			logger.Fatal(l, e)
-			return
+	// the callback may be invoked on a different goroutine, buffer it so it never blocks
+	results := make(chan error, 1)
+	e = exchangeShim.SubmitOpsSynch(dOps, func(hash string, e error) {
+		results <- e
+	})
+	if e != nil {
+		return fmt.Errorf("unable to submit operations to delete offers: %s", e)
+	}
+	e = <-results
+	if e != nil {
+		return fmt.Errorf("transaction to delete offers failed: %s", e)
+	}
+	l.Infof("deleted %d offers\n", len(dOps))
+	return nil
+}
+
+// setLogOutput sets the output of the standard library logger based on the --log flag and the LOG_* config values and returns the logger to use from here on
+func setLogOutput(l logger.Logger, options inputs, botConfig trader.BotConfig) logger.Logger {
+	var out io.Writer = os.Stdout
+	fileName := ""
+	if *options.logPrefix != "" {
+		t := time.Now().Format("20060102T150405MST")
+		fileName = fmt.Sprintf("%s_%s_%s_%s_%s_%s.log", *options.logPrefix, botConfig.AssetCodeA, botConfig.IssuerA, botConfig.AssetCodeB, botConfig.IssuerB, t)
+		if !botConfig.IsTradingSdex() {
+			fileName = fmt.Sprintf("%s_%s_%s_%s.log", *options.logPrefix, botConfig.AssetCodeA, botConfig.AssetCodeB, t)
+		}
+		if len(botConfig.Pairs) > 0 {
+			fileName = fmt.Sprintf("%s_%dpairs_%s.log", *options.logPrefix, len(botConfig.Pairs), t)
 		}
 
-		for {
-			sleepSeconds := 10
-			l.Infof("sleeping for %d seconds until our deletion is confirmed and we exit...(should never reach this line since we submit delete ops synchronously)\n", sleepSeconds)
-			time.Sleep(time.Duration(sleepSeconds) * time.Second)
+		f, e := logger.MakeRotatingFile(
+			fileName,
+			int64(botConfig.LogMaxSizeMB)*1024*1024,
+			time.Duration(botConfig.LogRotateIntervalHours)*time.Hour,
+			int(botConfig.LogMaxBackups),
+		)
+		if e != nil {
+			logger.Fatal(l, fmt.Errorf("failed to set log file: %s", e))
+			return l
 		}
-	} else {
-		logger.Fatal(l, fmt.Errorf("...nothing to delete, exiting"))
+		out = io.MultiWriter(os.Stdout, f)
 	}
-}
 
-func setLogFile(l logger.Logger, options inputs, botConfig trader.BotConfig) {
-	t := time.Now().Format("20060102T150405MST")
-	fileName := fmt.Sprintf("%s_%s_%s_%s_%s_%s.log", *options.logPrefix, botConfig.AssetCodeA, botConfig.IssuerA, botConfig.AssetCodeB, botConfig.IssuerB, t)
-	if !botConfig.IsTradingSdex() {
-		fileName = fmt.Sprintf("%s_%s_%s_%s.log", *options.logPrefix, botConfig.AssetCodeA, botConfig.AssetCodeB, t)
-	}
+	switch botConfig.LogFormat {
+	case "", "text":
+		log.SetOutput(out)
+	case "json":
+		level, e := logger.ParseLevel(botConfig.LogLevel)
+		if e != nil {
+			logger.Fatal(l, fmt.Errorf("invalid LOG_LEVEL: %s", e))
+			return l
+		}
 
-	f, e := os.OpenFile(fileName, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
-	if e != nil {
-		logger.Fatal(l, fmt.Errorf("failed to set log file: %s", e))
-		return
+		botName := strings.TrimSuffix(filepath.Base(*options.botConfigPath), filepath.Ext(*options.botConfigPath))
+		tradingPair := fmt.Sprintf("%s/%s", botConfig.AssetCodeA, botConfig.AssetCodeB)
+		if len(botConfig.Pairs) > 0 {
+			pairs := []string{}
+			for _, p := range botConfig.Pairs {
+				pairs = append(pairs, fmt.Sprintf("%s/%s", p.AssetCodeA, p.AssetCodeB))
+			}
+			tradingPair = strings.Join(pairs, ",")
+		}
+		jsonLogger := logger.MakeJSONLogger(out, level, map[string]interface{}{
+			"bot_name":     botName,
+			"strategy":     *options.strategy,
+			"trading_pair": tradingPair,
+		})
+		// the json logger adds its own timestamp to each entry
+		log.SetFlags(0)
+		log.SetOutput(jsonLogger)
+		l = jsonLogger
+	default:
+		logger.Fatal(l, fmt.Errorf("invalid LOG_FORMAT '%s', should be either 'text' or 'json'", botConfig.LogFormat))
+		return l
 	}
-	mw := io.MultiWriter(os.Stdout, f)
-	log.SetOutput(mw)
 
-	l.Infof("logging to file: %s\n", fileName)
-	// we want to create a deferred recovery function here that will log panics to the log file and then exit
-	defer logPanic(l, false)
+	if fileName != "" {
+		l.Infof("logging to file: %s\n", fileName)
+		// we want to create a deferred recovery function here that will log panics to the log file and then exit
+		defer logPanic(l, false)
+	}
+	return l
 }
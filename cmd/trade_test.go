@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stellar/kelp/trader"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPairFilePath(t *testing.T) {
+	pair := trader.PairConfig{AssetCodeA: "XLM", AssetCodeB: "USD"}
+
+	testCases := []struct {
+		path string
+		want string
+	}{
+		{path: "", want: ""},
+		{path: "pnl.json", want: "pnl_XLM_USD.json"},
+		{path: "logs/journal.csv", want: "logs/journal_XLM_USD.csv"},
+		{path: "logs.d/history", want: "logs.d/history_XLM_USD"},
+	}
+
+	for _, kase := range testCases {
+		t.Run(kase.path, func(t *testing.T) {
+			assert.Equal(t, kase.want, pairFilePath(kase.path, pair))
+		})
+	}
+}
+
+func TestOptionsForPair(t *testing.T) {
+	stringOption := func(value string) *string {
+		return &value
+	}
+	fixedIterations := uint64(5)
+	options := inputs{
+		strategy:          stringOption("buysell"),
+		stratConfigPath:   stringOption("buysell.cfg"),
+		pnlFile:           stringOption("pnl.json"),
+		offersJournalFile: stringOption(""),
+		runHistoryFile:    stringOption("history.csv"),
+		fixedIterations:   &fixedIterations,
+	}
+
+	testCases := []struct {
+		name         string
+		pair         trader.PairConfig
+		wantStrategy string
+		wantConfig   string
+	}{
+		{
+			name:         "flags",
+			pair:         trader.PairConfig{AssetCodeA: "XLM", AssetCodeB: "USD"},
+			wantStrategy: "buysell",
+			wantConfig:   "buysell.cfg",
+		}, {
+			name:         "strategy config",
+			pair:         trader.PairConfig{AssetCodeA: "XLM", AssetCodeB: "USD", StrategyConfig: "other.cfg"},
+			wantStrategy: "buysell",
+			wantConfig:   "other.cfg",
+		}, {
+			name:         "strategy without config",
+			pair:         trader.PairConfig{AssetCodeA: "XLM", AssetCodeB: "USD", Strategy: "balanced"},
+			wantStrategy: "balanced",
+			wantConfig:   "",
+		}, {
+			name:         "strategy and config",
+			pair:         trader.PairConfig{AssetCodeA: "XLM", AssetCodeB: "USD", Strategy: "balanced", StrategyConfig: "balanced.cfg"},
+			wantStrategy: "balanced",
+			wantConfig:   "balanced.cfg",
+		},
+	}
+
+	for _, kase := range testCases {
+		t.Run(kase.name, func(t *testing.T) {
+			pairOptions := optionsForPair(options, kase.pair)
+
+			assert.Equal(t, kase.wantStrategy, *pairOptions.strategy)
+			assert.Equal(t, kase.wantConfig, *pairOptions.stratConfigPath)
+			assert.Equal(t, "pnl_XLM_USD.json", *pairOptions.pnlFile)
+			assert.Equal(t, "", *pairOptions.offersJournalFile)
+			assert.Equal(t, "history_XLM_USD.csv", *pairOptions.runHistoryFile)
+
+			// every pair counts down its own iterations and the options of the bot are not changed
+			*pairOptions.fixedIterations--
+			assert.Equal(t, uint64(5), *options.fixedIterations)
+			assert.Equal(t, "buysell", *options.strategy)
+			assert.Equal(t, "pnl.json", *options.pnlFile)
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/nikhilsaraf/go-tools/multithreading"
+	"github.com/spf13/cobra"
+	"github.com/stellar/go/build"
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/support/config"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/plugins"
+	"github.com/stellar/kelp/support/utils"
+	"github.com/stellar/kelp/trader"
+)
+
+const trustExamples = `  kelp trust check --botConf ./path/trader.cfg
+  kelp trust add --botConf ./path/trader.cfg
+  kelp trust remove --botConf ./path/trader.cfg`
+
+var trustCmd = &cobra.Command{
+	Use:     "trust",
+	Short:   "Checks, adds and removes the trustlines of a bot's trading account for the assets in its trader config",
+	Example: trustExamples,
+}
+
+var trustCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Checks that the trustlines exist, are authorized by the issuer and have room to receive the assets",
+}
+
+var trustAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Adds the missing trustlines with the maximum limit",
+}
+
+var trustRemoveCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Removes the trustlines, which is only possible once their balances are zero",
+}
+
+func init() {
+	trustCmd.AddCommand(trustCheckCmd)
+	trustCmd.AddCommand(trustAddCmd)
+	trustCmd.AddCommand(trustRemoveCmd)
+
+	for _, c := range []*cobra.Command{trustCheckCmd, trustAddCmd, trustRemoveCmd} {
+		botConfigPath := c.Flags().StringP("botConf", "c", "", "(required) trading bot's basic config file path")
+		e := c.MarkFlagRequired("botConf")
+		if e != nil {
+			panic(e)
+		}
+
+		switch c {
+		case trustCheckCmd:
+			c.Run = func(ccmd *cobra.Command, args []string) {
+				botConfig, client := readTrustBotConfig(*botConfigPath)
+				statuses := loadTrustlinesOrExit(client, botConfig)
+				if !printTrustlines(statuses) {
+					os.Exit(1)
+				}
+			}
+		case trustAddCmd:
+			c.Run = func(ccmd *cobra.Command, args []string) {
+				botConfig, client := readTrustBotConfig(*botConfigPath)
+				statuses := loadTrustlinesOrExit(client, botConfig)
+				ops := plugins.AddTrustlineOps(statuses, botConfig.TradingAccount())
+				if len(ops) == 0 {
+					fmt.Println("all trustlines already exist")
+				} else {
+					submitTrustlineOpsOrExit(client, botConfig, ops, "adding")
+				}
+				// the issuer may still need to authorize the new trustlines
+				if !printTrustlines(loadTrustlinesOrExit(client, botConfig)) {
+					os.Exit(1)
+				}
+			}
+		case trustRemoveCmd:
+			c.Run = func(ccmd *cobra.Command, args []string) {
+				botConfig, client := readTrustBotConfig(*botConfigPath)
+				statuses := loadTrustlinesOrExit(client, botConfig)
+				ops, e := plugins.RemoveTrustlineOps(statuses, botConfig.TradingAccount())
+				if e != nil {
+					log.Fatal(e)
+				}
+				if len(ops) == 0 {
+					fmt.Println("there are no trustlines to remove")
+					return
+				}
+				submitTrustlineOpsOrExit(client, botConfig, ops, "removing")
+			}
+		}
+	}
+}
+
+func readTrustBotConfig(botConfigPath string) (*trader.BotConfig, *horizonclient.Client) {
+	var botConfig trader.BotConfig
+	e := config.Read(botConfigPath, &botConfig)
+	utils.CheckConfigError(botConfig, e, botConfigPath)
+	e = botConfig.Init()
+	if e != nil {
+		log.Fatal(e)
+	}
+	if !botConfig.IsTradingSdex() {
+		fmt.Printf("note: the bot trades on '%s', trustlines are only needed when the trading account holds the assets on the Stellar network\n", botConfig.TradingExchange)
+	}
+
+	client := &horizonclient.Client{
+		HorizonURL: botConfig.HorizonURL,
+		HTTP:       http.DefaultClient,
+		AppName:    "kelp",
+		AppVersion: version,
+	}
+	return &botConfig, client
+}
+
+func loadTrustlinesOrExit(client *horizonclient.Client, botConfig *trader.BotConfig) []plugins.TrustlineStatus {
+	statuses, e := plugins.LoadTrustlines(client, botConfig.TradingAccount(), []hProtocol.Asset{botConfig.AssetBase(), botConfig.AssetQuote()})
+	if e != nil {
+		log.Fatal(e)
+	}
+	return statuses
+}
+
+// printTrustlines prints the status of the trustlines and returns true if all of them can be used for trading
+func printTrustlines(statuses []plugins.TrustlineStatus) bool {
+	if len(statuses) == 0 {
+		fmt.Println("both assets are native, no trustlines are needed")
+		return true
+	}
+
+	ok := true
+	for _, s := range statuses {
+		fmt.Printf("%s:%s\n", s.AssetCode, s.AssetIssuer)
+		fmt.Printf("  exists: %v\n", s.Exists)
+		fmt.Printf("  issuer requires authorization: %v\n", s.AuthRequired)
+		fmt.Printf("  issuer can revoke authorization: %v\n", s.AuthRevocable)
+		fmt.Printf("  clawback enabled: %v\n", s.ClawbackEnabled)
+		if s.Exists {
+			fmt.Printf("  authorized: %v\n", s.Authorized)
+			fmt.Printf("  authorized to maintain liabilities: %v\n", s.AuthorizedToMaintainLiabilities)
+			fmt.Printf("  balance: %s\n", s.Balance)
+			fmt.Printf("  limit: %s\n", s.Limit)
+			fmt.Printf("  available limit: %s\n", s.AvailableLimit)
+		}
+		if s.Warning != "" {
+			fmt.Printf("  warning: %s\n", s.Warning)
+		}
+		if s.Problem != "" {
+			fmt.Printf("  problem: %s\n", s.Problem)
+			ok = false
+		}
+	}
+	return ok
+}
+
+func submitTrustlineOpsOrExit(client *horizonclient.Client, botConfig *trader.BotConfig, ops []build.TransactionMutator, action string) {
+	sdex := plugins.MakeSDEX(
+		client,
+		plugins.MakeIEIF(true),
+		nil,
+		botConfig.SourceSecretSeed,
+		botConfig.TradingSecretSeed,
+		botConfig.SourceAccount(),
+		botConfig.TradingAccount(),
+		utils.ParseNetwork(botConfig.HorizonURL),
+		multithreading.MakeThreadTracker(),
+		-1, // not needed here
+		-1, // not needed here
+		false,
+		nil, // not needed here
+		map[model.Asset]hProtocol.Asset{},
+		plugins.SdexFixedFeeFn(0),
+	)
+
+	fmt.Printf("%s %d trustline(s) for account %s ...\n", action, len(ops), botConfig.TradingAccount())
+	txHash, e := plugins.SubmitTrustlineOps(sdex, ops)
+	if e != nil {
+		log.Fatal(e)
+	}
+	fmt.Printf("done, tx hash: %s\n", txHash)
+}
@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/nikhilsaraf/go-tools/multithreading"
+	"github.com/spf13/cobra"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/support/config"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/plugins"
+	"github.com/stellar/kelp/support/logger"
+	"github.com/stellar/kelp/support/sdk"
+	"github.com/stellar/kelp/support/utils"
+	"github.com/stellar/kelp/trader"
+)
+
+const validateExamples = `  kelp validate --botConf ./path/trader.cfg --strategy mirror --stratConf ./path/mirror.cfg`
+
+var validateCmd = &cobra.Command{
+	Use:     "validate",
+	Short:   "Validates the config files of a bot, and the accounts they use, without trading",
+	Example: validateExamples,
+}
+
+func init() {
+	options := inputs{}
+	options.botConfigPath = validateCmd.Flags().StringP("botConf", "c", "", "(required) trading bot's basic config file path")
+	options.strategy = validateCmd.Flags().StringP("strategy", "s", "", "(required) type of strategy to validate")
+	options.stratConfigPath = validateCmd.Flags().StringP("stratConf", "f", "", "strategy config file path")
+	// the strategy is made in simulation mode so it never places any trades, the remaining options are not needed for validation
+	simMode := true
+	operationalBuffer := 20.0
+	operationalBufferNonNativePct := 0.001
+	options.simMode = &simMode
+	options.operationalBuffer = &operationalBuffer
+	options.operationalBufferNonNativePct = &operationalBufferNonNativePct
+
+	for _, flag := range []string{"botConf", "strategy"} {
+		e := validateCmd.MarkFlagRequired(flag)
+		if e != nil {
+			panic(e)
+		}
+	}
+	validateCmd.Flags().SortFlags = false
+
+	validateCmd.Run = func(ccmd *cobra.Command, args []string) {
+		checkInitRootFlags()
+		problems := runValidate(options)
+		if len(problems) == 0 {
+			fmt.Println("config is valid")
+			return
+		}
+
+		fmt.Println()
+		fmt.Printf("found %d problem(s):\n", len(problems))
+		for i, p := range problems {
+			fmt.Printf("  %d. %s\n", i+1, p)
+		}
+		os.Exit(1)
+	}
+}
+
+// runValidate returns the problems found in the configs. It stops early when a problem prevents the remaining checks from running.
+func runValidate(options inputs) []string {
+	l := logger.MakeBasicLogger()
+
+	var botConfig trader.BotConfig
+	e := config.Read(*options.botConfigPath, &botConfig)
+	if e != nil {
+		return []string{fmt.Sprintf("could not parse the trader config file '%s': %s", *options.botConfigPath, e)}
+	}
+	e = botConfig.Init()
+	if e != nil {
+		return []string{fmt.Sprintf("invalid trader config file '%s': %s", *options.botConfigPath, e)}
+	}
+	botConfig = convertDeprecatedBotConfigValues(l, botConfig)
+
+	problems := []string{}
+	for _, e := range botConfigErrors(botConfig) {
+		problems = append(problems, e.Error())
+	}
+	if botConfig.TickIntervalSeconds <= 0 {
+		problems = append(problems, "need to specify positive TICK_INTERVAL_SECONDS config param in trader config file")
+	}
+	if _, e := api.ParseSubmitMode(botConfig.SubmitMode); e != nil {
+		problems = append(problems, e.Error())
+	}
+	if _, e := trader.ParseWatchdogAction(botConfig.WatchdogAction); e != nil {
+		problems = append(problems, e.Error())
+	}
+	if _, e := trader.ParseStartupOffersAction(botConfig.StartupOffersAction); e != nil {
+		problems = append(problems, e.Error())
+	}
+	if _, e := trader.ParseOpOrderingPolicy(botConfig.OpOrderingPolicy); e != nil {
+		problems = append(problems, e.Error())
+	}
+
+	strategyContainer, ok := plugins.Strategies()[*options.strategy]
+	if !ok {
+		problems = append(problems, fmt.Sprintf("unknown strategy '%s', run `kelp strategies` to list the available strategies", *options.strategy))
+	} else if strategyContainer.NeedsConfig && *options.stratConfigPath == "" {
+		problems = append(problems, fmt.Sprintf("the '%s' strategy needs a config file, pass it with --stratConf", *options.strategy))
+	}
+
+	if *rootCcxtRestURL == "" && botConfig.CcxtRestURL != nil {
+		e := sdk.SetBaseURL(*botConfig.CcxtRestURL)
+		if e != nil {
+			problems = append(problems, fmt.Sprintf("unable to set CCXT-rest URL to '%s': %s", *botConfig.CcxtRestURL, e))
+		}
+	}
+
+	client := &horizonclient.Client{
+		HorizonURL: botConfig.HorizonURL,
+		HTTP:       http.DefaultClient,
+		AppName:    "kelp",
+		AppVersion: version,
+	}
+	problems = append(problems, accountProblems(client, &botConfig)...)
+
+	tradingPair := &model.TradingPair{
+		Base:  model.Asset(utils.Asset2CodeString(botConfig.AssetBase())),
+		Quote: model.Asset(utils.Asset2CodeString(botConfig.AssetQuote())),
+	}
+	if !botConfig.IsTradingSdex() {
+		problems = append(problems, exchangeProblems(botConfig, tradingPair)...)
+	}
+
+	// making the strategy exits on some problems so only attempt it once everything else is valid
+	if len(problems) > 0 {
+		return problems
+	}
+	return strategyProblems(l, botConfig, options, client, tradingPair)
+}
+
+// accountProblems checks that the accounts exist, are funded and have the required trustlines
+func accountProblems(client *horizonclient.Client, botConfig *trader.BotConfig) []string {
+	problems := []string{}
+	account, e := client.AccountDetail(horizonclient.AccountRequest{AccountID: botConfig.TradingAccount()})
+	if e != nil {
+		return append(problems, fmt.Sprintf("unable to load the trading account %s from HORIZON_URL (%s), it needs to be created and funded: %s", botConfig.TradingAccount(), botConfig.HorizonURL, e))
+	}
+
+	nativeBalanceString, e := account.GetNativeBalance()
+	if e != nil {
+		problems = append(problems, fmt.Sprintf("unable to read the XLM balance of the trading account %s: %s", botConfig.TradingAccount(), e))
+	} else if nativeBalance, e := strconv.ParseFloat(nativeBalanceString, 64); e != nil {
+		problems = append(problems, fmt.Sprintf("unable to parse the XLM balance of the trading account %s (%s): %s", botConfig.TradingAccount(), nativeBalanceString, e))
+	} else if minBalance := plugins.MinAccountBalance(account.SubentryCount); nativeBalance <= minBalance {
+		problems = append(problems, fmt.Sprintf("the trading account %s has %s XLM which does not exceed its minimum balance of %.7f XLM, fund it so it can place offers", botConfig.TradingAccount(), nativeBalanceString, minBalance))
+	}
+
+	if botConfig.IsTradingSdex() {
+		trustProblems, e := trustlineProblems(client, botConfig)
+		if e != nil {
+			problems = append(problems, fmt.Sprintf("unable to check the trustlines of the trading account %s: %s", botConfig.TradingAccount(), e))
+		}
+		problems = append(problems, trustProblems...)
+	}
+
+	if botConfig.SourceAccount() != "" && botConfig.SourceAccount() != botConfig.TradingAccount() {
+		_, e := client.AccountDetail(horizonclient.AccountRequest{AccountID: botConfig.SourceAccount()})
+		if e != nil {
+			problems = append(problems, fmt.Sprintf("unable to load the source account %s from HORIZON_URL (%s), it needs to be created and funded: %s", botConfig.SourceAccount(), botConfig.HorizonURL, e))
+		}
+	}
+	for _, sourceAccount := range botConfig.AdditionalSourceAccounts() {
+		_, e := client.AccountDetail(horizonclient.AccountRequest{AccountID: sourceAccount})
+		if e != nil {
+			problems = append(problems, fmt.Sprintf("unable to load the additional source account %s from HORIZON_URL (%s), it needs to be created and funded: %s", sourceAccount, botConfig.HorizonURL, e))
+		}
+	}
+	return problems
+}
+
+// exchangeProblems checks that the trading exchange can be made and that it can resolve the assets being traded
+func exchangeProblems(botConfig trader.BotConfig, tradingPair *model.TradingPair) []string {
+	exchangeParams := []api.ExchangeParam{}
+	for _, param := range botConfig.ExchangeParams {
+		exchangeParams = append(exchangeParams, api.ExchangeParam{Param: param.Param, Value: param.Value})
+	}
+	exchangeHeaders := []api.ExchangeHeader{}
+	for _, header := range botConfig.ExchangeHeaders {
+		exchangeHeaders = append(exchangeHeaders, api.ExchangeHeader{Header: header.Header, Value: header.Value})
+	}
+
+	exchange, e := plugins.MakeTradingExchange(botConfig.TradingExchange, botConfig.ExchangeAPIKeys.ToExchangeAPIKeys(), exchangeParams, exchangeHeaders, true)
+	if e != nil {
+		return []string{fmt.Sprintf("unable to make the trading exchange '%s' (check TRADING_EXCHANGE and EXCHANGE_API_KEYS): %s", botConfig.TradingExchange, e)}
+	}
+
+	problems := []string{}
+	for _, asset := range []model.Asset{tradingPair.Base, tradingPair.Quote} {
+		_, e := exchange.GetAssetConverter().ToString(asset)
+		if e != nil {
+			problems = append(problems, fmt.Sprintf("the asset %s cannot be resolved on the trading exchange '%s': %s", asset, botConfig.TradingExchange, e))
+		}
+	}
+	return problems
+}
+
+// strategyProblems makes the strategy in simulation mode, which parses and validates the strategy config
+func strategyProblems(l logger.Logger, botConfig trader.BotConfig, options inputs, client *horizonclient.Client, tradingPair *model.TradingPair) []string {
+	network := utils.ParseNetwork(botConfig.HorizonURL)
+	ieif := plugins.MakeIEIF(botConfig.IsTradingSdex())
+	_, sdex := makeExchangeShimSdex(
+		l,
+		botConfig,
+		options,
+		client,
+		ieif,
+		network,
+		multithreading.MakeThreadTracker(),
+		tradingPair,
+	)
+
+	e := plugins.SetPrivateSdexHack(client, plugins.MakeIEIF(true), network)
+	if e != nil {
+		return []string{fmt.Sprintf("unable to set up the sdex price feeds: %s", e)}
+	}
+
+	assetBase := botConfig.AssetBase()
+	assetQuote := botConfig.AssetQuote()
+	_, e = plugins.MakeStrategy(sdex, ieif, tradingPair, &assetBase, &assetQuote, *options.strategy, *options.stratConfigPath, *options.simMode)
+	if e != nil {
+		return []string{fmt.Sprintf("invalid '%s' strategy config: %s", *options.strategy, e)}
+	}
+	return []string{}
+}
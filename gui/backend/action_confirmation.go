@@ -0,0 +1,213 @@
+package backend
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/stellar/kelp/support/utils"
+)
+
+// confirmCodeParam is the query param that carries the TOTP code or the confirmation token of a destructive action
+const confirmCodeParam = "confirmCode"
+
+// policies that destructive actions can be confirmed with
+const (
+	ConfirmationPolicyToken = "token" // a single-use token from the requestConfirmation endpoint, so an action takes two deliberate requests
+	ConfirmationPolicyTOTP  = "totp"  // a time-based one-time password from an authenticator app
+)
+
+// confirmationTokenTTL is how long a token from the requestConfirmation endpoint can be used
+const confirmationTokenTTL = 2 * time.Minute
+
+// usedTOTPCodeTTL is how long a TOTP code is remembered so it cannot be used twice, codes are valid for up to 3 time steps of 30 seconds
+const usedTOTPCodeTTL = 2 * time.Minute
+
+// actionConfirmer requires a second factor for actions that cannot be undone or that put funds at risk on the main network
+type actionConfirmer struct {
+	policy     string
+	totpSecret string // only set for the totp policy
+	mutex      *sync.Mutex
+	tokens     map[string]pendingConfirmation // single-use tokens issued for the token policy
+	usedCodes  map[string]time.Time           // TOTP codes that were used, with the time they can be forgotten
+}
+
+// pendingConfirmation is the action that a confirmation token was issued for
+type pendingConfirmation struct {
+	action    string
+	botName   string
+	expiresAt time.Time
+}
+
+type requestConfirmationInput struct {
+	Action  string `json:"action"`
+	BotName string `json:"bot_name"`
+}
+
+type requestConfirmationOutput struct {
+	Token     string `json:"token"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// EnableActionConfirmation requires the deleteBot action, the batch delete and deleteOffers actions and starting bots on the main network
+// to be confirmed with the policy, the totpSecret is the base32 encoded secret of the totp policy. Stopping bots is never gated so a bot can
+// always be stopped quickly.
+func (s *APIServer) EnableActionConfirmation(policy string, totpSecret string) error {
+	switch policy {
+	case ConfirmationPolicyToken:
+		if totpSecret != "" {
+			return fmt.Errorf("a TOTP secret can only be used with the '%s' confirmation policy", ConfirmationPolicyTOTP)
+		}
+	case ConfirmationPolicyTOTP:
+		_, e := utils.TOTP(totpSecret, time.Now())
+		if e != nil {
+			return fmt.Errorf("invalid TOTP secret: %s", e)
+		}
+	default:
+		return fmt.Errorf("invalid confirmation policy '%s', needs to be one of '%s' or '%s'", policy, ConfirmationPolicyToken, ConfirmationPolicyTOTP)
+	}
+
+	s.confirmer = &actionConfirmer{
+		policy:     policy,
+		totpSecret: totpSecret,
+		mutex:      &sync.Mutex{},
+		tokens:     map[string]pendingConfirmation{},
+		usedCodes:  map[string]time.Time{},
+	}
+	s.config.ConfirmationPolicy = policy
+	log.Printf("enabled confirmation of destructive actions with policy '%s'\n", policy)
+	return nil
+}
+
+// requestConfirmation issues a single-use token that confirms the action on the bot, the batch actions use an empty bot name
+func (s *APIServer) requestConfirmation(w http.ResponseWriter, r *http.Request) {
+	if s.confirmer == nil || s.confirmer.policy != ConfirmationPolicyToken {
+		s.writeErrorJson(w, fmt.Sprintf("confirmation tokens are only issued when the confirmation policy is '%s'", ConfirmationPolicyToken))
+		return
+	}
+
+	bodyBytes, e := ioutil.ReadAll(r.Body)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error when reading request input: %s\n", e))
+		return
+	}
+	var input requestConfirmationInput
+	e = json.Unmarshal(bodyBytes, &input)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error unmarshaling json: %s; bodyString = %s", e, string(bodyBytes)))
+		return
+	}
+	if input.Action == "" {
+		s.writeErrorJson(w, "need to specify the action to confirm")
+		return
+	}
+
+	tokenBytes := make([]byte, 16)
+	_, e = rand.Read(tokenBytes)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("unable to generate confirmation token: %s", e))
+		return
+	}
+	token := hex.EncodeToString(tokenBytes)
+	expiresAt := time.Now().Add(confirmationTokenTTL)
+
+	c := s.confirmer
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.prune(time.Now())
+	c.tokens[token] = pendingConfirmation{
+		action:    input.Action,
+		botName:   input.BotName,
+		expiresAt: expiresAt,
+	}
+	log.Printf("issued confirmation token for action '%s' on bot '%s'\n", input.Action, input.BotName)
+
+	s.writeJson(w, requestConfirmationOutput{
+		Token:     token,
+		ExpiresAt: expiresAt.Format(time.RFC3339),
+	})
+}
+
+// checkActionConfirmed returns true when no confirmation policy is enabled or when the request carries a valid confirmation of the action on
+// the bot, otherwise it writes the error response
+func (s *APIServer) checkActionConfirmed(w http.ResponseWriter, r *http.Request, action string, botName string) bool {
+	if s.confirmer == nil {
+		return true
+	}
+
+	code := r.URL.Query().Get(confirmCodeParam)
+	if code == "" {
+		s.writeErrorJsonWithStatus(w, http.StatusPreconditionRequired, fmt.Sprintf("the '%s' action on bot '%s' needs to be confirmed, set the query param '%s' to %s",
+			action, botName, confirmCodeParam, s.confirmer.describe()))
+		return false
+	}
+
+	e := s.confirmer.confirm(action, botName, code, time.Now())
+	if e != nil {
+		s.writeErrorJsonWithStatus(w, http.StatusForbidden, fmt.Sprintf("the '%s' action on bot '%s' was not confirmed: %s", action, botName, e))
+		return false
+	}
+	log.Printf("confirmed action '%s' on bot '%s' with policy '%s'\n", action, botName, s.confirmer.policy)
+	return true
+}
+
+// describe explains what the confirmCode param needs to be set to
+func (c *actionConfirmer) describe() string {
+	if c.policy == ConfirmationPolicyTOTP {
+		return "the current code of your authenticator app"
+	}
+	return "a token from the requestConfirmation endpoint"
+}
+
+// confirm checks the code and consumes it so it cannot be used again
+func (c *actionConfirmer) confirm(action string, botName string, code string, now time.Time) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.prune(now)
+
+	if c.policy == ConfirmationPolicyTOTP {
+		if _, isUsed := c.usedCodes[code]; isUsed {
+			return fmt.Errorf("code was already used, wait for the next code")
+		}
+		isValid, e := utils.CheckTOTP(c.totpSecret, code, now)
+		if e != nil {
+			return fmt.Errorf("unable to check code: %s", e)
+		}
+		if !isValid {
+			return fmt.Errorf("invalid code")
+		}
+		c.usedCodes[code] = now.Add(usedTOTPCodeTTL)
+		return nil
+	}
+
+	pending, ok := c.tokens[code]
+	if !ok {
+		return fmt.Errorf("unknown or expired token")
+	}
+	// a token is consumed even when it was issued for another action so a mistaken request needs a new token
+	delete(c.tokens, code)
+	if pending.action != action || pending.botName != botName {
+		return fmt.Errorf("token was issued for the '%s' action on bot '%s'", pending.action, pending.botName)
+	}
+	return nil
+}
+
+// prune forgets expired tokens and used codes, needs c.mutex to be held
+func (c *actionConfirmer) prune(now time.Time) {
+	for token, pending := range c.tokens {
+		if now.After(pending.expiresAt) {
+			delete(c.tokens, token)
+		}
+	}
+	for code, forgetAt := range c.usedCodes {
+		if now.After(forgetAt) {
+			delete(c.usedCodes, code)
+		}
+	}
+}
@@ -8,11 +8,13 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
-	"strings"
+	"time"
 
 	"github.com/stellar/go/clients/horizon"
 	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/kelp/plugins"
 	"github.com/stellar/kelp/support/kelpos"
+	"github.com/stellar/kelp/support/networking"
 )
 
 // APIServer is an instance of the API service
@@ -21,19 +23,30 @@ type APIServer struct {
 	binPath               string
 	configsDir            string
 	logsDir               string
+	dataDir               string
 	kos                   *kelpos.KelpOS
-	horizonTestnetURI     string
-	horizonPubnetURI      string
-	ccxtRestUrl           string
+	config                ServerConfig
 	apiTestNet            *horizonclient.Client
 	apiPubNet             *horizonclient.Client
 	apiTestNetOld         *horizon.Client
 	apiPubNetOld          *horizon.Client
+	horizonHTTP           *networking.CachingHTTPClient // shared by the clients of all networks, the old clients are not cached because they load sequence numbers
 	cachedOptionsMetadata metadata
+	breaker               *circuitBreaker         // nil when the circuit breaker is not enabled
+	scheduler             *botScheduler           // nil when the trading hours of the bots are not enforced
+	containers            *kelpos.ContainerConfig // nil when bots run as child processes
+	confirmer             *actionConfirmer        // nil when destructive actions do not need to be confirmed
+	shutdownGracePeriod   time.Duration
+	priceHistoryCache     *priceHistoryCache
+	botInfoCache          *botInfoCache
 }
 
+// horizonCacheTTL is how long the orderbook and account details from horizon are reused, so the bot info and the circuit breaker of the
+// same bot and multiple open GUI tabs share requests
+const horizonCacheTTL = 2 * time.Second
+
 // MakeAPIServer is a factory method
-func MakeAPIServer(kos *kelpos.KelpOS, horizonTestnetURI string, horizonPubnetURI string, ccxtRestUrl string) (*APIServer, error) {
+func MakeAPIServer(kos *kelpos.KelpOS, config ServerConfig) (*APIServer, error) {
 	binPath, e := filepath.Abs(os.Args[0])
 	if e != nil {
 		return nil, fmt.Errorf("could not get binPath of currently running binary: %s", e)
@@ -42,26 +55,28 @@ func MakeAPIServer(kos *kelpos.KelpOS, horizonTestnetURI string, horizonPubnetUR
 	dirPath := filepath.Dir(binPath)
 	configsDir := dirPath + "/ops/configs"
 	logsDir := dirPath + "/ops/logs"
+	dataDir := dirPath + "/ops/data"
 
-	horizonTestnetURI = strings.TrimSuffix(horizonTestnetURI, "/")
-	horizonPubnetURI = strings.TrimSuffix(horizonPubnetURI, "/")
-	log.Printf("using horizonTestnetURI: %s\n", horizonTestnetURI)
-	log.Printf("using horizonPubnetURI: %s\n", horizonPubnetURI)
-	log.Printf("using ccxtRestUrl: %s\n", ccxtRestUrl)
+	config, e = config.normalize()
+	if e != nil {
+		return nil, fmt.Errorf("invalid server config: %s", e)
+	}
+	log.Printf("using server config: %s\n", config)
+	horizonHTTP := networking.MakeCachingHTTPClient(http.DefaultClient, networking.HorizonCacheRules(horizonCacheTTL, horizonCacheTTL))
 	apiTestNet := &horizonclient.Client{
-		HorizonURL: horizonTestnetURI,
-		HTTP:       http.DefaultClient,
+		HorizonURL: config.HorizonTestnetURL,
+		HTTP:       horizonHTTP,
 	}
 	apiPubNet := &horizonclient.Client{
-		HorizonURL: horizonPubnetURI,
-		HTTP:       http.DefaultClient,
+		HorizonURL: config.HorizonPubnetURL,
+		HTTP:       horizonHTTP,
 	}
 	apiTestNetOld := &horizon.Client{
-		URL:  horizonTestnetURI,
+		URL:  config.HorizonTestnetURL,
 		HTTP: http.DefaultClient,
 	}
 	apiPubNetOld := &horizon.Client{
-		URL:  horizonPubnetURI,
+		URL:  config.HorizonPubnetURL,
 		HTTP: http.DefaultClient,
 	}
 
@@ -70,20 +85,30 @@ func MakeAPIServer(kos *kelpos.KelpOS, horizonTestnetURI string, horizonPubnetUR
 		return nil, fmt.Errorf("error while loading options metadata when making APIServer: %s", e)
 	}
 
+	log.Printf("bots stop quoting and delete their offers while the kill switch file '%s/%s' exists\n", dataDir, killSwitchFilename)
+
+	e = plugins.LoadCustomPriceFeeds(fmt.Sprintf("%s/%s", dataDir, customPriceFeedsFilename))
+	if e != nil {
+		return nil, fmt.Errorf("error while loading custom price feeds when making APIServer: %s", e)
+	}
+
 	return &APIServer{
 		dirPath:               dirPath,
 		binPath:               binPath,
 		configsDir:            configsDir,
 		logsDir:               logsDir,
+		dataDir:               dataDir,
 		kos:                   kos,
-		horizonTestnetURI:     horizonTestnetURI,
-		horizonPubnetURI:      horizonPubnetURI,
-		ccxtRestUrl:           ccxtRestUrl,
+		config:                config,
 		apiTestNet:            apiTestNet,
 		apiPubNet:             apiPubNet,
 		apiTestNetOld:         apiTestNetOld,
 		apiPubNetOld:          apiPubNetOld,
+		horizonHTTP:           horizonHTTP,
 		cachedOptionsMetadata: optionsMetadata,
+		priceHistoryCache:     makePriceHistoryCache(),
+		botInfoCache:          makeBotInfoCache(),
+		shutdownGracePeriod:   kelpos.DefaultShutdownGracePeriod,
 	}, nil
 }
 
@@ -107,8 +132,13 @@ type ErrorResponse struct {
 }
 
 func (s *APIServer) writeErrorJson(w http.ResponseWriter, message string) {
+	s.writeErrorJsonWithStatus(w, http.StatusInternalServerError, message)
+}
+
+func (s *APIServer) writeErrorJsonWithStatus(w http.ResponseWriter, status int, message string) {
 	log.Println(message)
-	w.WriteHeader(http.StatusInternalServerError)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
 
 	marshalledJson, e := json.MarshalIndent(ErrorResponse{Error: message}, "", "    ")
 	if e != nil {
@@ -133,6 +163,7 @@ func (s *APIServer) writeJsonWithLog(w http.ResponseWriter, v interface{}, doLog
 	if doLog {
 		log.Printf("responseJson: %s\n", string(marshalledJson))
 	}
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	w.Write(marshalledJson)
 }
@@ -146,3 +177,26 @@ func (s *APIServer) runKelpCommandBackground(namespace string, cmd string) (*kel
 	cmdString := fmt.Sprintf("%s %s", s.binPath, cmd)
 	return s.kos.Background(namespace, cmdString)
 }
+
+// SetShutdownGracePeriod sets how long a stopped bot has to run its shutdown hooks before it is killed
+func (s *APIServer) SetShutdownGracePeriod(gracePeriod time.Duration) {
+	s.shutdownGracePeriod = gracePeriod
+	log.Printf("bots have a shutdown grace period of %s\n", gracePeriod)
+}
+
+// EnableContainers runs each bot in its own Docker container with the given image and resource limits instead of a child process. The
+// configs, logs and data dirs are mounted in the container at the same paths.
+func (s *APIServer) EnableContainers(config kelpos.ContainerConfig) {
+	config.Mounts = []string{s.configsDir, s.logsDir, s.dataDir}
+	config.IPCDir = filepath.Join(s.dataDir, "ipc")
+	s.containers = &config
+	log.Printf("running bots in containers with image '%s' (cpus=%s, memory=%s, restart=%s, network=%s)\n", config.Image, config.CPUs, config.Memory, config.RestartPolicy, config.Network)
+}
+
+// runBotCommandBackground runs the kelp command of a bot in a container when containers are enabled, otherwise as a child process
+func (s *APIServer) runBotCommandBackground(botName string, cmd string) (*kelpos.Process, error) {
+	if s.containers == nil {
+		return s.runKelpCommandBackground(botName, cmd)
+	}
+	return s.kos.BackgroundContainer(botName, s.containers, cmd)
+}
@@ -97,16 +97,16 @@ func (s *APIServer) autogenerateBot(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *APIServer) setupAccount(address string, signer string, botName string) error {
-	_, e := s.checkFundAccount(address, botName)
+	n := s.testnet()
+	_, e := s.checkFundAccount(n, address, botName)
 	if e != nil {
 		return fmt.Errorf("error checking and funding account: %s\n", e)
 	}
 
-	client := s.apiTestNetOld
 	txn, e := build.Transaction(
 		build.SourceAccount{AddressOrSeed: address},
-		build.AutoSequence{SequenceProvider: client},
-		build.TestNetwork,
+		build.AutoSequence{SequenceProvider: n.apiOld},
+		n.network,
 		build.Trust("COUPON", "GBMMZMK2DC4FFP4CAI6KCVNCQ7WLO5A7DQU7EC7WGHRDQBZB763X4OQI"),
 		build.Payment(
 			build.Destination{AddressOrSeed: address},
@@ -128,7 +128,7 @@ func (s *APIServer) setupAccount(address string, signer string, botName string)
 		return fmt.Errorf("cannot convert trustline transaction to base64 for account %s for bot '%s': %s\n", address, botName, e)
 	}
 
-	resp, e := client.SubmitTransaction(txn64)
+	resp, e := n.apiOld.SubmitTransaction(txn64)
 	if e != nil {
 		return fmt.Errorf("error submitting change trust transaction for address %s for bot '%s': %s\n", address, botName, e)
 	}
@@ -137,8 +137,8 @@ func (s *APIServer) setupAccount(address string, signer string, botName string)
 	return nil
 }
 
-func (s *APIServer) checkFundAccount(address string, botName string) (*hProtocol.Account, error) {
-	account, e := s.apiTestNet.AccountDetail(horizonclient.AccountRequest{AccountID: address})
+func (s *APIServer) checkFundAccount(n *botNetwork, address string, botName string) (*hProtocol.Account, error) {
+	account, e := n.api.AccountDetail(horizonclient.AccountRequest{AccountID: address})
 	if e == nil {
 		log.Printf("account already exists %s for bot '%s', no need to fund via friendbot\n", address, botName)
 		return &account, nil
@@ -158,9 +158,14 @@ func (s *APIServer) checkFundAccount(address string, botName string) (*hProtocol
 		}
 	}
 
+	// friendbot can only fund accounts on the test network
+	if !n.isTestnet() {
+		return nil, fmt.Errorf("account %s for bot '%s' does not exist on network %s, it needs to be created and funded before it can be used", address, botName, n)
+	}
+
 	// since it's a 404 we want to continue funding below
 	var fundResponse interface{}
-	e = networking.JSONRequest(http.DefaultClient, "GET", "https://friendbot.stellar.org/?addr="+address, "", nil, &fundResponse, "")
+	e = networking.JSONRequest(http.DefaultClient, "GET", s.config.FriendbotURL+"/?addr="+address, "", nil, &fundResponse, "")
 	if e != nil {
 		return nil, fmt.Errorf("error funding address %s for bot '%s': %s\n", address, botName, e)
 	}
@@ -182,7 +187,7 @@ func (s *APIServer) makeSampleTrader(seed string) *trader.BotConfig {
 		"both",
 		0,
 		0,
-		s.horizonTestnetURI,
+		s.config.HorizonTestnetURL,
 		nil,
 		&trader.FeeConfig{
 			CapacityTrigger: 0.8,
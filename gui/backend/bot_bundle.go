@@ -0,0 +1,380 @@
+package backend
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/stellar/kelp/gui/model2"
+	"github.com/stellar/kelp/plugins"
+	kelptoml "github.com/stellar/kelp/support/toml"
+	"github.com/stellar/kelp/support/utils"
+	"github.com/stellar/kelp/trader"
+)
+
+// bundleVersion is the version of the layout of bot bundles, bundles with a newer version cannot be imported
+const bundleVersion = 1
+
+// names of the files in a bot bundle
+const (
+	bundleManifestFile = "manifest.json"
+	bundleTraderFile   = "trader.cfg"
+	bundleStrategyFile = "strategy.cfg"
+	bundleSecretsFile  = "secrets.enc"
+)
+
+// maxBundleFileSize bounds the size of each file read from a bundle
+const maxBundleFileSize = 1 << 20
+
+// bundleSecrets is how the secrets of a bot are handled when it is exported
+type bundleSecrets string
+
+// bundleSecrets values
+const (
+	bundleSecretsInclude bundleSecrets = "include" // the secrets are left in the trader config
+	bundleSecretsStrip   bundleSecrets = "strip"   // the secrets are removed and need to be provided on import
+	bundleSecretsEncrypt bundleSecrets = "encrypt" // the secrets are removed from the trader config and encrypted with a passphrase
+)
+
+type bundleFile struct {
+	name     string
+	contents []byte
+}
+
+type bundleManifest struct {
+	Version    int           `json:"version"`
+	Name       string        `json:"name"`
+	Strategy   string        `json:"strategy"`
+	ExportedAt string        `json:"exported_at"`
+	Secrets    bundleSecrets `json:"secrets"`
+}
+
+// botSecrets are the fields of the trader config that give access to the accounts of the bot
+type botSecrets struct {
+	SourceSecretSeed            string                       `json:"source_secret_seed"`
+	TradingSecretSeed           string                       `json:"trading_secret_seed"`
+	AdditionalSourceSecretSeeds []string                     `json:"additional_source_secret_seeds"`
+	ExchangeAPIKeys             kelptoml.ExchangeAPIKeysToml `json:"exchange_api_keys"`
+	AlertAPIKey                 string                       `json:"alert_api_key"`
+	GoogleClientSecret          string                       `json:"google_client_secret"`
+}
+
+// extractSecrets removes the secrets from the trader config and returns them
+func extractSecrets(botConfig *trader.BotConfig) botSecrets {
+	secrets := botSecrets{
+		SourceSecretSeed:            botConfig.SourceSecretSeed,
+		TradingSecretSeed:           botConfig.TradingSecretSeed,
+		AdditionalSourceSecretSeeds: botConfig.AdditionalSourceSecretSeeds,
+		ExchangeAPIKeys:             botConfig.ExchangeAPIKeys,
+		AlertAPIKey:                 botConfig.AlertAPIKey,
+		GoogleClientSecret:          botConfig.GoogleClientSecret,
+	}
+	botConfig.SourceSecretSeed = ""
+	botConfig.TradingSecretSeed = ""
+	botConfig.AdditionalSourceSecretSeeds = nil
+	botConfig.ExchangeAPIKeys = nil
+	botConfig.AlertAPIKey = ""
+	botConfig.GoogleClientSecret = ""
+	return secrets
+}
+
+// apply puts the secrets back into the trader config
+func (s botSecrets) apply(botConfig *trader.BotConfig) {
+	botConfig.SourceSecretSeed = s.SourceSecretSeed
+	botConfig.TradingSecretSeed = s.TradingSecretSeed
+	botConfig.AdditionalSourceSecretSeeds = s.AdditionalSourceSecretSeeds
+	botConfig.ExchangeAPIKeys = s.ExchangeAPIKeys
+	botConfig.AlertAPIKey = s.AlertAPIKey
+	botConfig.GoogleClientSecret = s.GoogleClientSecret
+}
+
+type exportBotRequest struct {
+	BotName    string        `json:"bot_name"`
+	Secrets    bundleSecrets `json:"secrets"`    // defaults to strip so secrets are never exported by accident
+	Passphrase string        `json:"passphrase"` // required when the secrets are encrypted
+}
+
+type exportBotResponse struct {
+	Filename string `json:"filename"`
+	Archive  []byte `json:"archive"` // tar.gz archive, base64 encoded in the json response
+}
+
+func (s *APIServer) exportBot(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, e := ioutil.ReadAll(r.Body)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error reading request input: %s", e))
+		return
+	}
+
+	var req exportBotRequest
+	e = json.Unmarshal(bodyBytes, &req)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error unmarshaling json: %s", e))
+		return
+	}
+	if req.Secrets == "" {
+		req.Secrets = bundleSecretsStrip
+	}
+
+	configs, e := s.readBotConfigs(req.BotName)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("cannot read configs of bot '%s': %s", req.BotName, e))
+		return
+	}
+
+	archive, e := makeBotBundle(configs, req.Secrets, req.Passphrase, time.Now())
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("cannot export bot '%s': %s", req.BotName, e))
+		return
+	}
+	log.Printf("exported bot '%s' (secrets=%s, %d bytes)\n", req.BotName, req.Secrets, len(archive))
+
+	s.writeJson(w, exportBotResponse{
+		Filename: fmt.Sprintf("%s.kelp.tar.gz", req.BotName),
+		Archive:  archive,
+	})
+}
+
+// makeBotBundle packages the configs of the bot into a tar.gz archive
+func makeBotBundle(configs *botConfigResponse, secretsMode bundleSecrets, passphrase string, now time.Time) ([]byte, error) {
+	traderConfig := configs.TraderConfig
+	var encryptedSecrets []byte
+	switch secretsMode {
+	case bundleSecretsInclude:
+	case bundleSecretsStrip:
+		extractSecrets(&traderConfig)
+	case bundleSecretsEncrypt:
+		secretsJSON, e := json.Marshal(extractSecrets(&traderConfig))
+		if e != nil {
+			return nil, fmt.Errorf("cannot marshal secrets: %s", e)
+		}
+		encryptedSecrets, e = utils.EncryptWithPassphrase(secretsJSON, passphrase)
+		if e != nil {
+			return nil, fmt.Errorf("cannot encrypt secrets: %s", e)
+		}
+	default:
+		return nil, fmt.Errorf("invalid secrets '%s', needs to be one of '%s', '%s' or '%s'", secretsMode, bundleSecretsInclude, bundleSecretsStrip, bundleSecretsEncrypt)
+	}
+
+	manifestJSON, e := json.MarshalIndent(bundleManifest{
+		Version:    bundleVersion,
+		Name:       configs.Name,
+		Strategy:   configs.Strategy,
+		ExportedAt: now.UTC().Format(time.RFC3339),
+		Secrets:    secretsMode,
+	}, "", "  ")
+	if e != nil {
+		return nil, fmt.Errorf("cannot marshal manifest: %s", e)
+	}
+	traderToml, e := encodeToml(&traderConfig)
+	if e != nil {
+		return nil, fmt.Errorf("cannot encode trader config: %s", e)
+	}
+	strategyConfig := configs.StrategyConfig
+	strategyToml, e := encodeToml(&strategyConfig)
+	if e != nil {
+		return nil, fmt.Errorf("cannot encode strategy config: %s", e)
+	}
+
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+	files := []bundleFile{
+		{name: bundleManifestFile, contents: manifestJSON},
+		{name: bundleTraderFile, contents: traderToml},
+		{name: bundleStrategyFile, contents: strategyToml},
+	}
+	if encryptedSecrets != nil {
+		files = append(files, bundleFile{name: bundleSecretsFile, contents: encryptedSecrets})
+	}
+	for _, f := range files {
+		e = tw.WriteHeader(&tar.Header{
+			Name:    f.name,
+			Mode:    0600,
+			Size:    int64(len(f.contents)),
+			ModTime: now,
+		})
+		if e != nil {
+			return nil, fmt.Errorf("cannot write header of '%s' to archive: %s", f.name, e)
+		}
+		if _, e = tw.Write(f.contents); e != nil {
+			return nil, fmt.Errorf("cannot write '%s' to archive: %s", f.name, e)
+		}
+	}
+	if e = tw.Close(); e != nil {
+		return nil, fmt.Errorf("cannot close archive: %s", e)
+	}
+	if e = gzw.Close(); e != nil {
+		return nil, fmt.Errorf("cannot compress archive: %s", e)
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeToml(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	e := toml.NewEncoder(&buf).Encode(v)
+	if e != nil {
+		return nil, e
+	}
+	return buf.Bytes(), nil
+}
+
+// importBotRequest imports a bundle made by exportBot, the overrides are applied after the secrets are restored so they can provide the
+// secrets that were stripped or move the bot to a different account or pair
+type importBotRequest struct {
+	Archive    []byte            `json:"archive"` // tar.gz archive, base64 encoded in the json request
+	Name       string            `json:"name"`    // defaults to the name of the exported bot
+	Passphrase string            `json:"passphrase"`
+	Overrides  cloneBotOverrides `json:"overrides"`
+}
+
+func (s *APIServer) importBot(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, e := ioutil.ReadAll(r.Body)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error reading request input: %s", e))
+		return
+	}
+
+	// the request is not logged since it can contain secrets
+	var req importBotRequest
+	e = json.Unmarshal(bodyBytes, &req)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error unmarshaling json: %s", e))
+		return
+	}
+
+	imported, e := readBotBundle(req.Archive, req.Passphrase)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("cannot import bot: %s", e))
+		return
+	}
+	if req.Name != "" {
+		imported.Name = req.Name
+	}
+	req.Overrides.apply(imported)
+
+	filenamePair := model2.GetBotFilenames(imported.Name, imported.Strategy)
+	if _, e := os.Stat(fmt.Sprintf("%s/%s", s.configsDir, filenamePair.Trader)); e == nil {
+		s.writeErrorJson(w, fmt.Sprintf("a bot with the name '%s' already exists, import it with a different name", imported.Name))
+		return
+	}
+
+	if errResp := s.validateConfigs(*imported); errResp != nil {
+		s.writeJson(w, errResp)
+		return
+	}
+
+	n := s.networkForHorizonURL(imported.TraderConfig.HorizonURL)
+	if !isMainnetConfirmed(r, n) {
+		s.writeMainnetConfirmationRequired(w, imported.Name, "importBot", n)
+		return
+	}
+
+	_, e = s.kos.Blocking("mkdir", "mkdir -p "+s.configsDir)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error running mkdir command for configsDir: %s", e))
+		return
+	}
+
+	e = s.writeBotConfigs(*imported)
+	if e != nil {
+		s.writeErrorJson(w, e.Error())
+		return
+	}
+	log.Printf("imported bot '%s'\n", imported.Name)
+
+	// registers the new bot and creates funding accounts and trustlines if needed
+	s.reinitBotCheck(*imported, n)
+
+	s.writeJson(w, upsertBotConfigResponse{Success: true})
+}
+
+// readBotBundle reads the configs from a bundle made by makeBotBundle, decrypting the secrets when they were encrypted
+func readBotBundle(archive []byte, passphrase string) (*upsertBotConfigRequest, error) {
+	gzr, e := gzip.NewReader(bytes.NewReader(archive))
+	if e != nil {
+		return nil, fmt.Errorf("archive is not a gzip file: %s", e)
+	}
+	defer gzr.Close()
+
+	files := map[string][]byte{}
+	tr := tar.NewReader(gzr)
+	for {
+		header, e := tr.Next()
+		if e == io.EOF {
+			break
+		}
+		if e != nil {
+			return nil, fmt.Errorf("cannot read archive: %s", e)
+		}
+		if header.Size > maxBundleFileSize {
+			return nil, fmt.Errorf("file '%s' in archive is too large (%d bytes)", header.Name, header.Size)
+		}
+		contents, e := ioutil.ReadAll(io.LimitReader(tr, maxBundleFileSize))
+		if e != nil {
+			return nil, fmt.Errorf("cannot read '%s' from archive: %s", header.Name, e)
+		}
+		files[header.Name] = contents
+	}
+
+	for _, name := range []string{bundleManifestFile, bundleTraderFile, bundleStrategyFile} {
+		if _, ok := files[name]; !ok {
+			return nil, fmt.Errorf("archive is missing '%s'", name)
+		}
+	}
+	var manifest bundleManifest
+	e = json.Unmarshal(files[bundleManifestFile], &manifest)
+	if e != nil {
+		return nil, fmt.Errorf("cannot parse manifest: %s", e)
+	}
+	if manifest.Version > bundleVersion {
+		return nil, fmt.Errorf("archive has version %d, this version of kelp can import archives up to version %d", manifest.Version, bundleVersion)
+	}
+	if manifest.Strategy != buysell {
+		return nil, fmt.Errorf("strategy '%s' of the archive is not supported, only '%s' bots can be imported", manifest.Strategy, buysell)
+	}
+
+	var traderConfig trader.BotConfig
+	if _, e = toml.Decode(string(files[bundleTraderFile]), &traderConfig); e != nil {
+		return nil, fmt.Errorf("cannot parse trader config: %s", e)
+	}
+	var strategyConfig plugins.BuySellConfig
+	if _, e = toml.Decode(string(files[bundleStrategyFile]), &strategyConfig); e != nil {
+		return nil, fmt.Errorf("cannot parse strategy config: %s", e)
+	}
+
+	if manifest.Secrets == bundleSecretsEncrypt {
+		encrypted, ok := files[bundleSecretsFile]
+		if !ok {
+			return nil, fmt.Errorf("archive is missing '%s'", bundleSecretsFile)
+		}
+		if passphrase == "" {
+			return nil, fmt.Errorf("the secrets of the archive are encrypted, need the passphrase to import it")
+		}
+		secretsJSON, e := utils.DecryptWithPassphrase(encrypted, passphrase)
+		if e != nil {
+			return nil, fmt.Errorf("cannot decrypt secrets: %s", e)
+		}
+		var secrets botSecrets
+		if e = json.Unmarshal(secretsJSON, &secrets); e != nil {
+			return nil, fmt.Errorf("cannot parse secrets: %s", e)
+		}
+		secrets.apply(&traderConfig)
+	}
+
+	return &upsertBotConfigRequest{
+		Name:           manifest.Name,
+		Strategy:       manifest.Strategy,
+		TraderConfig:   traderConfig,
+		StrategyConfig: strategyConfig,
+	}, nil
+}
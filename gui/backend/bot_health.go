@@ -0,0 +1,20 @@
+package backend
+
+import "net/http"
+
+// botHealth reports the liveness of the update loop of a bot so it can be polled by external health checks,
+// the bot name is passed as the botName query parameter
+func (s *APIServer) botHealth(w http.ResponseWriter, r *http.Request) {
+	botName := r.URL.Query().Get("botName")
+	if botName == "" {
+		s.writeErrorJsonWithStatus(w, http.StatusBadRequest, "need to specify the botName query parameter in botHealth\n")
+		return
+	}
+
+	if _, exists := s.kos.GetProcess(botName); !exists {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"status": "not_running"}`))
+		return
+	}
+	s.writeIPCCommandResponse(w, botName, "getHealth")
+}
@@ -0,0 +1,56 @@
+package backend
+
+import (
+	"sync"
+	"time"
+)
+
+// botInfoCacheTTL is how long a bot info response is served from the cache, so dashboards that poll several bots do not hit horizon on
+// every poll of every open GUI tab
+const botInfoCacheTTL = 3 * time.Second
+
+// botInfoCache keeps the most recent bot info response of each bot, keyed by bot name
+type botInfoCache struct {
+	mutex   *sync.Mutex
+	entries map[string]*botInfoCacheEntry
+}
+
+type botInfoCacheEntry struct {
+	fetchedAt time.Time
+	response  []byte
+}
+
+func makeBotInfoCache() *botInfoCache {
+	return &botInfoCache{
+		mutex:   &sync.Mutex{},
+		entries: map[string]*botInfoCacheEntry{},
+	}
+}
+
+// get returns the cached response of the bot, nil when there is none or it has expired
+func (c *botInfoCache) get(botName string) []byte {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, ok := c.entries[botName]
+	if !ok || time.Since(entry.fetchedAt) > botInfoCacheTTL {
+		return nil
+	}
+	return entry.response
+}
+
+func (c *botInfoCache) set(botName string, response []byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// evict expired entries so the cache does not keep the responses of deleted bots
+	for k, entry := range c.entries {
+		if time.Since(entry.fetchedAt) > botInfoCacheTTL {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[botName] = &botInfoCacheEntry{
+		fetchedAt: time.Now(),
+		response:  response,
+	}
+}
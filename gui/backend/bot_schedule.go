@@ -0,0 +1,483 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stellar/kelp/gui/model2"
+	"github.com/stellar/kelp/support/kelpos"
+)
+
+// weekdays maps the day names used in trading windows to weekdays
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// tradingWindow is a period on the given days of the week during which a bot trades. Start and end are "HH:MM" in the timezone of the
+// schedule, the end can be "24:00" for a window that runs until midnight and a window that ends before it starts runs past midnight into
+// the next day.
+type tradingWindow struct {
+	Days  []string `json:"days"` // "mon" to "sun", empty for every day
+	Start string   `json:"start"`
+	End   string   `json:"end"`
+}
+
+// botSchedule holds the trading hours of a bot, the bot is paused outside of these hours
+type botSchedule struct {
+	Timezone string          `json:"timezone"` // IANA name such as "America/New_York", defaults to UTC
+	Windows  []tradingWindow `json:"windows"`
+}
+
+// botScheduleVersion is the format version of the schedule files written by this version of Kelp
+const botScheduleVersion = 1
+
+// botScheduleFile is the persisted form of a botSchedule, files written before the version was added have version 0
+type botScheduleFile struct {
+	Version int `json:"version"`
+	botSchedule
+}
+
+type setBotScheduleRequest struct {
+	BotName  string       `json:"bot_name"`
+	Schedule *botSchedule `json:"schedule"` // nil removes the schedule so the bot can run at all times
+}
+
+type botScheduleResponse struct {
+	BotName          string       `json:"bot_name"`
+	Schedule         *botSchedule `json:"schedule"`           // nil when the bot can run at all times
+	Open             bool         `json:"open"`               // true when the bot is inside its trading hours
+	PausedBySchedule bool         `json:"paused_by_schedule"` // true when the bot will be resumed when its trading hours start
+}
+
+// parsedWindow is a tradingWindow with its times as durations since midnight
+type parsedWindow struct {
+	days  map[time.Weekday]bool
+	start time.Duration
+	end   time.Duration
+}
+
+// parsedSchedule is a validated botSchedule
+type parsedSchedule struct {
+	location *time.Location
+	windows  []parsedWindow
+}
+
+// parse validates the schedule
+func (b *botSchedule) parse() (*parsedSchedule, error) {
+	location, e := time.LoadLocation(b.Timezone)
+	if e != nil {
+		return nil, fmt.Errorf("invalid timezone '%s': %s", b.Timezone, e)
+	}
+	if len(b.Windows) == 0 {
+		return nil, fmt.Errorf("schedule needs at least one trading window")
+	}
+
+	windows := []parsedWindow{}
+	for i, w := range b.Windows {
+		days := map[time.Weekday]bool{}
+		for _, d := range w.Days {
+			day, ok := weekdays[strings.ToLower(d)]
+			if !ok {
+				return nil, fmt.Errorf("invalid day '%s' in trading window %d, needs to be one of sun, mon, tue, wed, thu, fri or sat", d, i)
+			}
+			days[day] = true
+		}
+		if len(w.Days) == 0 {
+			for _, day := range weekdays {
+				days[day] = true
+			}
+		}
+
+		start, e := parseClockTime(w.Start)
+		if e != nil {
+			return nil, fmt.Errorf("invalid start of trading window %d: %s", i, e)
+		}
+		if start == 24*time.Hour {
+			return nil, fmt.Errorf("invalid start of trading window %d: a window cannot start at 24:00, use 00:00 instead", i)
+		}
+		end, e := parseClockTime(w.End)
+		if e != nil {
+			return nil, fmt.Errorf("invalid end of trading window %d: %s", i, e)
+		}
+		if start == end {
+			return nil, fmt.Errorf("trading window %d cannot start and end at the same time", i)
+		}
+		windows = append(windows, parsedWindow{days: days, start: start, end: end})
+	}
+	return &parsedSchedule{location: location, windows: windows}, nil
+}
+
+// parseClockTime parses a "HH:MM" time into the duration since midnight, "24:00" is the midnight at the end of the day
+func parseClockTime(clock string) (time.Duration, error) {
+	if clock == "24:00" {
+		return 24 * time.Hour, nil
+	}
+	t, e := time.Parse("15:04", clock)
+	if e != nil {
+		return 0, fmt.Errorf("time '%s' needs to be in the format HH:MM", clock)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// isOpen returns true when the time is inside one of the trading windows
+func (p *parsedSchedule) isOpen(t time.Time) bool {
+	local := t.In(p.location)
+	sinceMidnight := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute + time.Duration(local.Second())*time.Second
+	today := local.Weekday()
+	yesterday := (today + 6) % 7
+
+	for _, w := range p.windows {
+		if w.start < w.end {
+			if w.days[today] && sinceMidnight >= w.start && sinceMidnight < w.end {
+				return true
+			}
+			continue
+		}
+
+		// the window runs past midnight so it can have started today or yesterday
+		if w.days[today] && sinceMidnight >= w.start {
+			return true
+		}
+		if w.days[yesterday] && sinceMidnight < w.end {
+			return true
+		}
+	}
+	return false
+}
+
+// schedulerPausedBotsFilename is the name of the file in the data dir that holds the bots paused by the scheduler, so they are still
+// resumed when their trading hours start after the server restarts
+const schedulerPausedBotsFilename = "scheduler_paused_bots.json"
+
+// botScheduler pauses running bots outside of their trading hours, which deletes their offers, and resumes them when their trading hours start
+type botScheduler struct {
+	interval   time.Duration
+	mutex      *sync.Mutex
+	pausedBots map[string]bool // bots that were paused by the scheduler, bots that were stopped by the user are not resumed
+}
+
+// EnableScheduler starts enforcing the trading hours of the bots every interval
+func (s *APIServer) EnableScheduler(interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("interval of the scheduler needs to be positive: %s", interval)
+	}
+
+	pausedBots, e := s.loadPausedBots()
+	if e != nil {
+		return fmt.Errorf("unable to load the bots paused by the scheduler: %s", e)
+	}
+	s.scheduler = &botScheduler{
+		interval:   interval,
+		mutex:      &sync.Mutex{},
+		pausedBots: pausedBots,
+	}
+	log.Printf("enabled bot scheduler with interval=%s, bots paused outside of their trading hours: %d\n", interval, len(pausedBots))
+
+	go func() {
+		for {
+			s.checkSchedules()
+			time.Sleep(interval)
+		}
+	}()
+	return nil
+}
+
+// checkSchedules pauses running bots that are outside of their trading hours and resumes the bots it paused once their trading hours start
+func (s *APIServer) checkSchedules() {
+	sc := s.scheduler
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+
+	changed := false
+	defer func() {
+		if !changed {
+			return
+		}
+		e := s.savePausedBots(sc.pausedBots)
+		if e != nil {
+			log.Printf("scheduler could not save the bots it paused: %s\n", e)
+		}
+	}()
+
+	now := time.Now()
+	for _, botName := range s.kos.RegisteredBots() {
+		schedule, e := s.loadBotSchedule(botName)
+		if e != nil {
+			log.Printf("scheduler could not load the schedule of bot '%s': %s\n", botName, e)
+			continue
+		}
+		if schedule == nil {
+			if sc.pausedBots[botName] {
+				delete(sc.pausedBots, botName)
+				changed = true
+			}
+			continue
+		}
+		parsed, e := schedule.parse()
+		if e != nil {
+			log.Printf("scheduler skipping bot '%s' because of an invalid schedule: %s\n", botName, e)
+			continue
+		}
+		botState, e := s.doGetBotState(botName)
+		if e != nil {
+			log.Printf("scheduler could not get the state of bot '%s': %s\n", botName, e)
+			continue
+		}
+
+		isOpen := parsed.isOpen(now)
+		if !isOpen && botState == kelpos.BotStateRunning {
+			e = s.doStopBot(botName)
+			if e != nil {
+				log.Printf("scheduler could not pause bot '%s': %s\n", botName, e)
+				continue
+			}
+			sc.pausedBots[botName] = true
+			changed = true
+			log.Printf("scheduler paused bot '%s' outside of its trading hours\n", botName)
+		} else if isOpen && botState == kelpos.BotStateStopped && sc.pausedBots[botName] {
+			if s.isCircuitBreakerTripped() {
+				log.Printf("scheduler not resuming bot '%s' because the circuit breaker has been tripped\n", botName)
+				continue
+			}
+			e = s.doStartBot(botName, buysell, nil, nil)
+			if e != nil {
+				log.Printf("scheduler could not resume bot '%s': %s\n", botName, e)
+				continue
+			}
+			e = s.kos.AdvanceBotState(botName, kelpos.BotStateStopped)
+			if e != nil {
+				log.Printf("scheduler could not advance the state of bot '%s' when resuming it: %s\n", botName, e)
+				continue
+			}
+			delete(sc.pausedBots, botName)
+			changed = true
+			log.Printf("scheduler resumed bot '%s' at the start of its trading hours\n", botName)
+		}
+	}
+}
+
+func (s *APIServer) schedulerPausedBotsFilePath() string {
+	return filepath.Join(s.dataDir, schedulerPausedBotsFilename)
+}
+
+// loadPausedBots returns the bots paused by the scheduler before the server was restarted
+func (s *APIServer) loadPausedBots() (map[string]bool, error) {
+	pausedBots := map[string]bool{}
+	b, e := ioutil.ReadFile(s.schedulerPausedBotsFilePath())
+	if os.IsNotExist(e) {
+		return pausedBots, nil
+	}
+	if e != nil {
+		return nil, fmt.Errorf("cannot read paused bots file: %s", e)
+	}
+
+	var botNames []string
+	e = json.Unmarshal(b, &botNames)
+	if e != nil {
+		return nil, fmt.Errorf("cannot parse paused bots file: %s", e)
+	}
+	for _, botName := range botNames {
+		pausedBots[botName] = true
+	}
+	return pausedBots, nil
+}
+
+// savePausedBots writes the bots paused by the scheduler to a temporary file that is then moved into place, like saveBotSchedule
+func (s *APIServer) savePausedBots(pausedBots map[string]bool) error {
+	botNames := []string{}
+	for botName := range pausedBots {
+		botNames = append(botNames, botName)
+	}
+	sort.Strings(botNames)
+
+	b, e := json.MarshalIndent(botNames, "", "  ")
+	if e != nil {
+		return fmt.Errorf("error marshaling paused bots: %s", e)
+	}
+	e = os.MkdirAll(s.dataDir, 0755)
+	if e != nil {
+		return fmt.Errorf("error creating dataDir: %s", e)
+	}
+	path := s.schedulerPausedBotsFilePath()
+	tmpPath := path + ".tmp"
+	e = ioutil.WriteFile(tmpPath, b, 0644)
+	if e != nil {
+		return fmt.Errorf("error writing paused bots file '%s': %s", tmpPath, e)
+	}
+	e = os.Rename(tmpPath, path)
+	if e != nil {
+		return fmt.Errorf("error moving paused bots file '%s' to '%s': %s", tmpPath, path, e)
+	}
+	return nil
+}
+
+// isOutsideTradingHours returns true when the bot has a schedule and it is currently outside of its trading hours, the trading hours
+// only apply when the scheduler is enabled
+func (s *APIServer) isOutsideTradingHours(botName string) (bool, error) {
+	if s.scheduler == nil {
+		return false, nil
+	}
+	schedule, e := s.loadBotSchedule(botName)
+	if e != nil || schedule == nil {
+		return false, e
+	}
+	parsed, e := schedule.parse()
+	if e != nil {
+		return false, fmt.Errorf("invalid schedule for bot '%s': %s", botName, e)
+	}
+	return !parsed.isOpen(time.Now()), nil
+}
+
+func (s *APIServer) botScheduleFilePath(botName string) string {
+	return filepath.Join(s.dataDir, model2.GetScheduleFilename(botName))
+}
+
+// loadBotSchedule returns nil when the bot does not have a schedule
+func (s *APIServer) loadBotSchedule(botName string) (*botSchedule, error) {
+	b, e := ioutil.ReadFile(s.botScheduleFilePath(botName))
+	if os.IsNotExist(e) {
+		return nil, nil
+	}
+	if e != nil {
+		return nil, fmt.Errorf("cannot read schedule file: %s", e)
+	}
+
+	var f botScheduleFile
+	e = json.Unmarshal(b, &f)
+	if e != nil {
+		return nil, fmt.Errorf("cannot parse schedule file: %s", e)
+	}
+	if f.Version > botScheduleVersion {
+		return nil, fmt.Errorf("schedule file was written by a newer version of Kelp (version=%d, max supported version=%d), upgrade Kelp or remove the schedule", f.Version, botScheduleVersion)
+	}
+	return &f.botSchedule, nil
+}
+
+// saveBotSchedule writes the schedule to a temporary file that is then moved into place so a crash does not leave a partial file behind
+func (s *APIServer) saveBotSchedule(botName string, schedule *botSchedule) ([]byte, error) {
+	scheduleBytes, e := json.MarshalIndent(botScheduleFile{Version: botScheduleVersion, botSchedule: *schedule}, "", "  ")
+	if e != nil {
+		return nil, fmt.Errorf("error marshaling schedule: %s", e)
+	}
+	e = os.MkdirAll(s.dataDir, 0755)
+	if e != nil {
+		return nil, fmt.Errorf("error creating dataDir: %s", e)
+	}
+	path := s.botScheduleFilePath(botName)
+	tmpPath := path + ".tmp"
+	e = ioutil.WriteFile(tmpPath, scheduleBytes, 0644)
+	if e != nil {
+		return nil, fmt.Errorf("error writing schedule file '%s': %s", tmpPath, e)
+	}
+	e = os.Rename(tmpPath, path)
+	if e != nil {
+		return nil, fmt.Errorf("error moving schedule file '%s' to '%s': %s", tmpPath, path, e)
+	}
+	return scheduleBytes, nil
+}
+
+func (s *APIServer) getBotSchedule(w http.ResponseWriter, r *http.Request) {
+	botName, e := s.parseBotName(r)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error parsing bot name in getBotSchedule: %s\n", e))
+		return
+	}
+	s.writeBotSchedule(w, botName)
+}
+
+// setBotSchedule saves the trading hours of a bot, they are enforced by the scheduler when it is enabled
+func (s *APIServer) setBotSchedule(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, e := ioutil.ReadAll(r.Body)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error when reading request input: %s\n", e))
+		return
+	}
+	var req setBotScheduleRequest
+	e = json.Unmarshal(bodyBytes, &req)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error unmarshaling json: %s; bodyString = %s", e, string(bodyBytes)))
+		return
+	}
+	if req.BotName == "" {
+		s.writeErrorJson(w, "need to specify the bot_name in setBotSchedule")
+		return
+	}
+
+	if req.Schedule == nil {
+		e = os.Remove(s.botScheduleFilePath(req.BotName))
+		if e != nil && !os.IsNotExist(e) {
+			s.writeErrorJson(w, fmt.Sprintf("error removing schedule of bot '%s': %s\n", req.BotName, e))
+			return
+		}
+		log.Printf("removed schedule of bot '%s'\n", req.BotName)
+		s.writeBotSchedule(w, req.BotName)
+		return
+	}
+
+	_, e = req.Schedule.parse()
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("invalid schedule for bot '%s': %s\n", req.BotName, e))
+		return
+	}
+	// the scheduler starts and stops the bot without further confirmation
+	n, e := s.networkForBot(req.BotName, buysell)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error loading network of bot '%s': %s\n", req.BotName, e))
+		return
+	}
+	if !isMainnetConfirmed(r, n) {
+		s.writeMainnetConfirmationRequired(w, req.BotName, "setBotSchedule", n)
+		return
+	}
+
+	scheduleBytes, e := s.saveBotSchedule(req.BotName, req.Schedule)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error writing schedule of bot '%s': %s\n", req.BotName, e))
+		return
+	}
+	log.Printf("saved schedule of bot '%s': %s\n", req.BotName, string(scheduleBytes))
+	s.writeBotSchedule(w, req.BotName)
+}
+
+func (s *APIServer) writeBotSchedule(w http.ResponseWriter, botName string) {
+	schedule, e := s.loadBotSchedule(botName)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error loading schedule of bot '%s': %s\n", botName, e))
+		return
+	}
+
+	resp := botScheduleResponse{
+		BotName:  botName,
+		Schedule: schedule,
+		Open:     true,
+	}
+	if schedule != nil {
+		parsed, e := schedule.parse()
+		if e != nil {
+			s.writeErrorJson(w, fmt.Sprintf("invalid schedule for bot '%s': %s\n", botName, e))
+			return
+		}
+		resp.Open = parsed.isOpen(time.Now())
+	}
+	if s.scheduler != nil {
+		s.scheduler.mutex.Lock()
+		resp.PausedBySchedule = s.scheduler.pausedBots[botName]
+		s.scheduler.mutex.Unlock()
+	}
+	s.writeJson(w, resp)
+}
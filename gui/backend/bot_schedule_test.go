@@ -0,0 +1,207 @@
+package backend
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBotScheduleParse(t *testing.T) {
+	testCases := []struct {
+		name     string
+		schedule botSchedule
+		wantErr  bool
+	}{
+		{
+			name:     "valid",
+			schedule: botSchedule{Windows: []tradingWindow{{Days: []string{"Mon", "tue"}, Start: "09:30", End: "16:00"}}},
+		}, {
+			name:     "runs until midnight",
+			schedule: botSchedule{Windows: []tradingWindow{{Start: "22:00", End: "24:00"}}},
+		}, {
+			name:     "whole day",
+			schedule: botSchedule{Windows: []tradingWindow{{Start: "00:00", End: "24:00"}}},
+		}, {
+			name:     "starts at 24:00",
+			schedule: botSchedule{Windows: []tradingWindow{{Start: "24:00", End: "02:00"}}},
+			wantErr:  true,
+		}, {
+			name:     "invalid timezone",
+			schedule: botSchedule{Timezone: "Mars/Olympus_Mons", Windows: []tradingWindow{{Start: "09:00", End: "17:00"}}},
+			wantErr:  true,
+		}, {
+			name:     "no windows",
+			schedule: botSchedule{},
+			wantErr:  true,
+		}, {
+			name:     "invalid day",
+			schedule: botSchedule{Windows: []tradingWindow{{Days: []string{"monday"}, Start: "09:00", End: "17:00"}}},
+			wantErr:  true,
+		}, {
+			name:     "invalid time",
+			schedule: botSchedule{Windows: []tradingWindow{{Start: "9am", End: "17:00"}}},
+			wantErr:  true,
+		}, {
+			name:     "past 24:00",
+			schedule: botSchedule{Windows: []tradingWindow{{Start: "09:00", End: "24:30"}}},
+			wantErr:  true,
+		}, {
+			name:     "starts and ends at the same time",
+			schedule: botSchedule{Windows: []tradingWindow{{Start: "09:00", End: "09:00"}}},
+			wantErr:  true,
+		},
+	}
+
+	for _, kase := range testCases {
+		t.Run(kase.name, func(t *testing.T) {
+			_, e := kase.schedule.parse()
+			if kase.wantErr {
+				assert.Error(t, e)
+			} else {
+				assert.NoError(t, e)
+			}
+		})
+	}
+}
+
+func TestParsedScheduleIsOpen(t *testing.T) {
+	// 2026-10-12 is a Monday
+	monday := func(hour int, minute int, second int) time.Time {
+		return time.Date(2026, 10, 12, hour, minute, second, 0, time.UTC)
+	}
+	tuesday := func(hour int, minute int, second int) time.Time {
+		return time.Date(2026, 10, 13, hour, minute, second, 0, time.UTC)
+	}
+
+	testCases := []struct {
+		name     string
+		schedule botSchedule
+		t        time.Time
+		want     bool
+	}{
+		{
+			name:     "inside window",
+			schedule: botSchedule{Windows: []tradingWindow{{Days: []string{"mon"}, Start: "09:30", End: "16:00"}}},
+			t:        monday(12, 0, 0),
+			want:     true,
+		}, {
+			name:     "at the start",
+			schedule: botSchedule{Windows: []tradingWindow{{Days: []string{"mon"}, Start: "09:30", End: "16:00"}}},
+			t:        monday(9, 30, 0),
+			want:     true,
+		}, {
+			name:     "at the end",
+			schedule: botSchedule{Windows: []tradingWindow{{Days: []string{"mon"}, Start: "09:30", End: "16:00"}}},
+			t:        monday(16, 0, 0),
+			want:     false,
+		}, {
+			name:     "other day",
+			schedule: botSchedule{Windows: []tradingWindow{{Days: []string{"mon"}, Start: "09:30", End: "16:00"}}},
+			t:        tuesday(12, 0, 0),
+			want:     false,
+		}, {
+			name:     "every day",
+			schedule: botSchedule{Windows: []tradingWindow{{Start: "09:30", End: "16:00"}}},
+			t:        tuesday(12, 0, 0),
+			want:     true,
+		}, {
+			name:     "runs until midnight",
+			schedule: botSchedule{Windows: []tradingWindow{{Days: []string{"mon"}, Start: "22:00", End: "24:00"}}},
+			t:        monday(23, 59, 59),
+			want:     true,
+		}, {
+			name:     "ends at midnight",
+			schedule: botSchedule{Windows: []tradingWindow{{Days: []string{"mon"}, Start: "22:00", End: "24:00"}}},
+			t:        tuesday(0, 0, 0),
+			want:     false,
+		}, {
+			name:     "past midnight on the day it started",
+			schedule: botSchedule{Windows: []tradingWindow{{Days: []string{"mon"}, Start: "22:00", End: "02:00"}}},
+			t:        monday(23, 0, 0),
+			want:     true,
+		}, {
+			name:     "past midnight on the next day",
+			schedule: botSchedule{Windows: []tradingWindow{{Days: []string{"mon"}, Start: "22:00", End: "02:00"}}},
+			t:        tuesday(1, 0, 0),
+			want:     true,
+		}, {
+			name:     "past midnight after it ended",
+			schedule: botSchedule{Windows: []tradingWindow{{Days: []string{"mon"}, Start: "22:00", End: "02:00"}}},
+			t:        tuesday(2, 0, 0),
+			want:     false,
+		}, {
+			name:     "past midnight before the start of the window on the next day",
+			schedule: botSchedule{Windows: []tradingWindow{{Days: []string{"mon"}, Start: "22:00", End: "02:00"}}},
+			t:        monday(1, 0, 0),
+			want:     false,
+		}, {
+			name: "second window",
+			schedule: botSchedule{Windows: []tradingWindow{
+				{Days: []string{"mon"}, Start: "09:00", End: "12:00"},
+				{Days: []string{"mon"}, Start: "13:00", End: "17:00"},
+			}},
+			t:    monday(14, 0, 0),
+			want: true,
+		}, {
+			name:     "timezone",
+			schedule: botSchedule{Timezone: "America/New_York", Windows: []tradingWindow{{Days: []string{"mon"}, Start: "09:30", End: "16:00"}}},
+			// 13:30 UTC is 09:30 in New York during daylight saving time
+			t:    monday(13, 30, 0),
+			want: true,
+		}, {
+			name:     "timezone before the start",
+			schedule: botSchedule{Timezone: "America/New_York", Windows: []tradingWindow{{Days: []string{"mon"}, Start: "09:30", End: "16:00"}}},
+			t:        monday(12, 0, 0),
+			want:     false,
+		},
+	}
+
+	for _, kase := range testCases {
+		t.Run(kase.name, func(t *testing.T) {
+			parsed, e := kase.schedule.parse()
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, kase.want, parsed.isOpen(kase.t))
+		})
+	}
+}
+
+func TestPausedBotsPersistence(t *testing.T) {
+	dataDir, e := ioutil.TempDir("", "kelp_paused_bots")
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer os.RemoveAll(dataDir)
+	s := &APIServer{dataDir: dataDir}
+
+	// nothing has been paused before the first run
+	pausedBots, e := s.loadPausedBots()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, map[string]bool{}, pausedBots)
+
+	e = s.savePausedBots(map[string]bool{"bot_b": true, "bot_a": true})
+	if !assert.NoError(t, e) {
+		return
+	}
+	pausedBots, e = s.loadPausedBots()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, map[string]bool{"bot_a": true, "bot_b": true}, pausedBots)
+
+	e = s.savePausedBots(map[string]bool{})
+	if !assert.NoError(t, e) {
+		return
+	}
+	pausedBots, e = s.loadPausedBots()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, map[string]bool{}, pausedBots)
+}
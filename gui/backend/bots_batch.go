@@ -0,0 +1,179 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/stellar/kelp/support/kelpos"
+)
+
+// actions of the batch endpoint
+const (
+	batchActionStart        = "start"
+	batchActionStop         = "stop"
+	batchActionDelete       = "delete"
+	batchActionDeleteOffers = "deleteOffers"
+)
+
+type botsBatchRequest struct {
+	BotNames []string `json:"bot_names"`
+	Action   string   `json:"action"`
+}
+
+type botBatchResult struct {
+	BotName string `json:"bot_name"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+type botsBatchResponse struct {
+	Action  string           `json:"action"`
+	Results []botBatchResult `json:"results"` // in the order of the bot names of the request
+}
+
+// botsBatch runs the same action on a list of bots and reports the result of each bot, a bot that fails does not stop the action on the
+// other bots
+func (s *APIServer) botsBatch(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, e := ioutil.ReadAll(r.Body)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error when reading request input: %s\n", e))
+		return
+	}
+	var req botsBatchRequest
+	e = json.Unmarshal(bodyBytes, &req)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error unmarshaling json: %s; bodyString = %s", e, string(bodyBytes)))
+		return
+	}
+
+	var actionFn func(botName string) error
+	switch req.Action {
+	case batchActionStart:
+		actionFn = s.doStartBotChecked
+	case batchActionStop:
+		actionFn = s.doStopBot
+	case batchActionDelete:
+		actionFn = s.doDeleteBot
+	case batchActionDeleteOffers:
+		actionFn = s.doDeleteBotOffers
+	default:
+		s.writeErrorJson(w, fmt.Sprintf("invalid action '%s' in bots batch, needs to be one of '%s', '%s', '%s' or '%s'",
+			req.Action, batchActionStart, batchActionStop, batchActionDelete, batchActionDeleteOffers))
+		return
+	}
+	if len(req.BotNames) == 0 {
+		s.writeErrorJson(w, "need to specify at least one bot in bot_names")
+		return
+	}
+	seen := map[string]bool{}
+	for _, botName := range req.BotNames {
+		if botName == "" || seen[botName] {
+			s.writeErrorJson(w, fmt.Sprintf("bot_names cannot contain empty or repeated names: %v", req.BotNames))
+			return
+		}
+		seen[botName] = true
+	}
+
+	// starting bots on the main network needs to be confirmed once for the whole batch
+	needsConfirmation := req.Action == batchActionDelete || req.Action == batchActionDeleteOffers
+	if req.Action == batchActionStart {
+		for _, botName := range req.BotNames {
+			n, e := s.networkForBot(botName, buysell)
+			if e != nil {
+				s.writeErrorJson(w, fmt.Sprintf("error loading network of bot '%s': %s\n", botName, e))
+				return
+			}
+			if !isMainnetConfirmed(r, n) {
+				s.writeMainnetConfirmationRequired(w, botName, "bots batch start", n)
+				return
+			}
+			needsConfirmation = needsConfirmation || !n.isTestnet()
+		}
+	}
+	// the confirmation of a batch action is not tied to a bot
+	if needsConfirmation && !s.checkActionConfirmed(w, r, "bots batch "+req.Action, "") {
+		return
+	}
+
+	log.Printf("running bots batch action '%s' on %d bots: %v\n", req.Action, len(req.BotNames), req.BotNames)
+	results := make([]botBatchResult, len(req.BotNames))
+	var wg sync.WaitGroup
+	for i, botName := range req.BotNames {
+		wg.Add(1)
+		go func(i int, botName string) {
+			defer wg.Done()
+			results[i] = botBatchResult{BotName: botName, Success: true}
+			e := actionFn(botName)
+			if e != nil {
+				log.Printf("bots batch action '%s' failed for bot '%s': %s\n", req.Action, botName, e)
+				results[i].Success = false
+				results[i].Error = e.Error()
+			}
+		}(i, botName)
+	}
+	wg.Wait()
+
+	s.writeJson(w, botsBatchResponse{
+		Action:  req.Action,
+		Results: results,
+	})
+}
+
+// doStartBotChecked starts the bot with the same checks as the start endpoint
+func (s *APIServer) doStartBotChecked(botName string) error {
+	if s.isCircuitBreakerTripped() {
+		return fmt.Errorf("cannot start bot '%s' because the circuit breaker has been tripped, review and resume the circuit breaker first", botName)
+	}
+	isKilled, e := s.isKillSwitchActive()
+	if e != nil {
+		return fmt.Errorf("error checking kill switch: %s", e)
+	}
+	if isKilled {
+		return fmt.Errorf("cannot start bot '%s' because the kill switch is active", botName)
+	}
+	isOutside, e := s.isOutsideTradingHours(botName)
+	if e != nil {
+		return fmt.Errorf("error checking trading hours of bot: %s", e)
+	}
+	if isOutside {
+		return fmt.Errorf("cannot start bot '%s' outside of its trading hours", botName)
+	}
+	e = s.checkTrustlinesReady(botName)
+	if e != nil {
+		return fmt.Errorf("cannot start bot: %s", e)
+	}
+
+	e = s.doStartBot(botName, buysell, nil, nil)
+	if e != nil {
+		return fmt.Errorf("error starting bot: %s", e)
+	}
+	e = s.kos.AdvanceBotState(botName, kelpos.BotStateStopped)
+	if e != nil {
+		return fmt.Errorf("error advancing bot state: %s", e)
+	}
+	return nil
+}
+
+// doDeleteBotOffers deletes the offers of a stopped bot, such as offers that were left behind when the bot was killed
+func (s *APIServer) doDeleteBotOffers(botName string) error {
+	botState, e := s.doGetBotState(botName)
+	if e != nil {
+		return fmt.Errorf("unable to get botState: %s", e)
+	}
+	if botState != kelpos.BotStateStopped {
+		return fmt.Errorf("bot '%s' needs to be stopped to delete its offers, current state is '%s'", botName, botState)
+	}
+
+	var numIterations uint8 = 1
+	e = s.doStartBot(botName, "delete", &numIterations, func() {
+		log.Printf("deleted offers for bot '%s'\n", botName)
+	})
+	if e != nil {
+		return fmt.Errorf("error when deleting bot orders %s: %s", botName, e)
+	}
+	return nil
+}
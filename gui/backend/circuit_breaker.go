@@ -0,0 +1,322 @@
+package backend
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/support/kelpos"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// circuitBreaker pauses all running bots at once when the aggregate drawdown across them reaches maxDrawdown.
+// Every bot is valued in units of its own quote asset and normalized against its value when the breaker started watching it,
+// so bots trading different markets can be aggregated. The aggregate drawdown is 1 - (sum of normalized values / sum of normalized peaks).
+type circuitBreaker struct {
+	maxDrawdown float64
+	interval    time.Duration
+
+	mutex      *sync.Mutex
+	baselines  map[string]float64 // value of each bot when the breaker started watching it
+	peaks      map[string]float64 // highest normalized value of each bot
+	latest     map[string]float64 // latest normalized value of each bot
+	drawdown   float64
+	tripped    bool
+	trippedAt  time.Time
+	pausedBots []string
+}
+
+// circuitBreakerStatus is the response of the getCircuitBreaker endpoint
+type circuitBreakerStatus struct {
+	Enabled       bool     `json:"enabled"`
+	MaxDrawdown   float64  `json:"max_drawdown"`
+	Drawdown      float64  `json:"drawdown"`
+	Tripped       bool     `json:"tripped"`
+	TrippedAt     string   `json:"tripped_at"`
+	PausedBots    []string `json:"paused_bots"`
+	MonitoredBots []string `json:"monitored_bots"`
+}
+
+// EnableCircuitBreaker starts monitoring the aggregate drawdown of all running bots every interval, pausing all of them
+// when it reaches maxDrawdown (0 < maxDrawdown < 1). Paused bots are only restarted by the resumeCircuitBreaker endpoint.
+func (s *APIServer) EnableCircuitBreaker(maxDrawdown float64, interval time.Duration) error {
+	if maxDrawdown <= 0 || maxDrawdown >= 1 {
+		return fmt.Errorf("max drawdown of the circuit breaker needs to be in the range (0, 1): %f", maxDrawdown)
+	}
+	if interval <= 0 {
+		return fmt.Errorf("interval of the circuit breaker needs to be positive: %s", interval)
+	}
+
+	s.breaker = &circuitBreaker{
+		maxDrawdown: maxDrawdown,
+		interval:    interval,
+		mutex:       &sync.Mutex{},
+		baselines:   map[string]float64{},
+		peaks:       map[string]float64{},
+		latest:      map[string]float64{},
+	}
+	log.Printf("enabled portfolio circuit breaker with maxDrawdown=%.4f, interval=%s\n", maxDrawdown, interval)
+
+	go func() {
+		for {
+			s.checkCircuitBreaker()
+			time.Sleep(interval)
+		}
+	}()
+	return nil
+}
+
+// isCircuitBreakerTripped returns true when bots should not be started until the breaker is resumed
+func (s *APIServer) isCircuitBreakerTripped() bool {
+	if s.breaker == nil {
+		return false
+	}
+
+	s.breaker.mutex.Lock()
+	defer s.breaker.mutex.Unlock()
+	return s.breaker.tripped
+}
+
+// checkCircuitBreaker values all running bots and pauses them if the aggregate drawdown has reached the limit
+func (s *APIServer) checkCircuitBreaker() {
+	b := s.breaker
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.tripped {
+		return
+	}
+
+	running := map[string]bool{}
+	values := map[string]float64{}
+	for _, botName := range s.kos.RegisteredBots() {
+		botState, e := s.doGetBotState(botName)
+		if e != nil || botState != kelpos.BotStateRunning {
+			continue
+		}
+		running[botName] = true
+
+		value, e := s.botValue(botName)
+		if e != nil {
+			// keep the last known value so a temporary failure does not change the drawdown
+			log.Printf("circuit breaker could not value bot '%s', using last known value: %s\n", botName, e)
+			continue
+		}
+		values[botName] = value
+	}
+
+	if !b.observe(running, values) {
+		return
+	}
+
+	log.Printf("circuit breaker tripped: drawdown (%.4f) >= maxDrawdown (%.4f), pausing all running bots\n", b.drawdown, b.maxDrawdown)
+	b.tripped = true
+	b.trippedAt = time.Now()
+	b.pausedBots = []string{}
+	for botName := range running {
+		e := s.doStopBot(botName)
+		if e != nil {
+			log.Printf("circuit breaker could not pause bot '%s': %s\n", botName, e)
+			continue
+		}
+		b.pausedBots = append(b.pausedBots, botName)
+	}
+	sort.Strings(b.pausedBots)
+}
+
+// observe updates the drawdown with the values of the running bots, bots missing from values keep their last known value.
+// Returns true when the drawdown has reached maxDrawdown.
+func (b *circuitBreaker) observe(running map[string]bool, values map[string]float64) bool {
+	for botName, value := range values {
+		if value <= 0 {
+			continue
+		}
+
+		baseline, ok := b.baselines[botName]
+		if !ok {
+			b.baselines[botName] = value
+			baseline = value
+		}
+		normalized := value / baseline
+		b.latest[botName] = normalized
+		if normalized > b.peaks[botName] {
+			b.peaks[botName] = normalized
+		}
+	}
+
+	// bots that were stopped no longer contribute to the drawdown
+	for botName := range b.baselines {
+		if !running[botName] {
+			delete(b.baselines, botName)
+			delete(b.peaks, botName)
+			delete(b.latest, botName)
+		}
+	}
+
+	sumLatest := 0.0
+	sumPeaks := 0.0
+	for botName, normalized := range b.latest {
+		sumLatest += normalized
+		sumPeaks += b.peaks[botName]
+	}
+	b.drawdown = 0.0
+	if sumPeaks > 0 {
+		b.drawdown = 1 - sumLatest/sumPeaks
+	}
+	log.Printf("circuit breaker: drawdown=%.4f, maxDrawdown=%.4f, monitoredBots=%d\n", b.drawdown, b.maxDrawdown, len(b.latest))
+	return b.drawdown >= b.maxDrawdown
+}
+
+// botValue returns the value of the bot's trading account in units of its quote asset, using the mid price of the SDEX orderbook
+func (s *APIServer) botValue(botName string) (float64, error) {
+	botConfig, e := s.loadBotConfig(botName)
+	if e != nil {
+		return 0, e
+	}
+	n := s.networkForHorizonURL(botConfig.HorizonURL)
+	assetBase := botConfig.AssetBase()
+	assetQuote := botConfig.AssetQuote()
+
+	account, e := n.api.AccountDetail(horizonclient.AccountRequest{AccountID: botConfig.TradingAccount()})
+	if e != nil {
+		return 0, fmt.Errorf("cannot get account data for account '%s': %s", botConfig.TradingAccount(), e)
+	}
+	balanceBase, e := getBalance(account, assetBase)
+	if e != nil {
+		return 0, e
+	}
+	balanceQuote, e := getBalance(account, assetQuote)
+	if e != nil {
+		return 0, e
+	}
+
+	obs, e := n.api.OrderBook(horizonclient.OrderBookRequest{
+		SellingAssetType:   horizonclient.AssetType(assetBase.Type),
+		SellingAssetCode:   assetBase.Code,
+		SellingAssetIssuer: assetBase.Issuer,
+		BuyingAssetType:    horizonclient.AssetType(assetQuote.Type),
+		BuyingAssetCode:    assetQuote.Code,
+		BuyingAssetIssuer:  assetQuote.Issuer,
+		Limit:              1,
+	})
+	if e != nil {
+		return 0, fmt.Errorf("error getting orderbook for assets (base=%v, quote=%v): %s", assetBase, assetQuote, e)
+	}
+	if len(obs.Asks) == 0 || len(obs.Bids) == 0 {
+		return 0, fmt.Errorf("cannot compute mid price for assets (base=%v, quote=%v) with an empty side of the orderbook", assetBase, assetQuote)
+	}
+	topAsk := float64(obs.Asks[0].PriceR.N) / float64(obs.Asks[0].PriceR.D)
+	topBid := float64(obs.Bids[0].PriceR.N) / float64(obs.Bids[0].PriceR.D)
+	midPrice := (topAsk + topBid) / 2
+
+	return balanceBase*midPrice + balanceQuote, nil
+}
+
+func getBalance(account hProtocol.Account, asset hProtocol.Asset) (float64, error) {
+	if asset == utils.NativeAsset {
+		return getNativeBalance(account)
+	}
+	return getCreditBalance(account, asset)
+}
+
+func (s *APIServer) getCircuitBreaker(w http.ResponseWriter, r *http.Request) {
+	if s.breaker == nil {
+		s.writeJson(w, circuitBreakerStatus{Enabled: false})
+		return
+	}
+
+	b := s.breaker
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	trippedAt := ""
+	if b.tripped {
+		trippedAt = b.trippedAt.Format(time.RFC3339)
+	}
+	monitoredBots := []string{}
+	for botName := range b.latest {
+		monitoredBots = append(monitoredBots, botName)
+	}
+	sort.Strings(monitoredBots)
+
+	s.writeJson(w, circuitBreakerStatus{
+		Enabled:       true,
+		MaxDrawdown:   b.maxDrawdown,
+		Drawdown:      b.drawdown,
+		Tripped:       b.tripped,
+		TrippedAt:     trippedAt,
+		PausedBots:    append([]string{}, b.pausedBots...),
+		MonitoredBots: monitoredBots,
+	})
+}
+
+// resumeCircuitBreaker resets a tripped breaker after review and restarts all the bots it paused
+func (s *APIServer) resumeCircuitBreaker(w http.ResponseWriter, r *http.Request) {
+	if s.breaker == nil {
+		s.writeErrorJson(w, "circuit breaker is not enabled")
+		return
+	}
+
+	b := s.breaker
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !b.tripped {
+		s.writeErrorJson(w, "circuit breaker has not been tripped, nothing to resume")
+		return
+	}
+
+	// restarting paused bots on the main network needs the same confirmation as starting them individually
+	hasMainnetBots := false
+	for _, botName := range b.pausedBots {
+		n, e := s.networkForBot(botName, buysell)
+		if e != nil {
+			s.writeErrorJson(w, fmt.Sprintf("error loading network of bot '%s': %s", botName, e))
+			return
+		}
+		if !isMainnetConfirmed(r, n) {
+			s.writeMainnetConfirmationRequired(w, botName, "resumeCircuitBreaker", n)
+			return
+		}
+		hasMainnetBots = hasMainnetBots || !n.isTestnet()
+	}
+	if hasMainnetBots && !s.checkActionConfirmed(w, r, "resumeCircuitBreaker", "") {
+		return
+	}
+
+	pausedBots := b.reset()
+	resumedBots := []string{}
+	for _, botName := range pausedBots {
+		e := s.doStartBot(botName, buysell, nil, nil)
+		if e != nil {
+			log.Printf("error resuming bot '%s' after circuit breaker: %s\n", botName, e)
+			continue
+		}
+		e = s.kos.AdvanceBotState(botName, kelpos.BotStateStopped)
+		if e != nil {
+			log.Printf("error advancing bot state when resuming bot '%s' after circuit breaker: %s\n", botName, e)
+			continue
+		}
+		resumedBots = append(resumedBots, botName)
+	}
+	log.Printf("circuit breaker resumed, restarted %d of %d paused bots: %v\n", len(resumedBots), len(pausedBots), resumedBots)
+
+	s.writeJson(w, map[string][]string{"resumed_bots": resumedBots})
+}
+
+// reset clears a tripped breaker so it starts watching the resumed bots from their current values, returns the bots it had paused
+func (b *circuitBreaker) reset() []string {
+	pausedBots := b.pausedBots
+	b.baselines = map[string]float64{}
+	b.peaks = map[string]float64{}
+	b.latest = map[string]float64{}
+	b.drawdown = 0.0
+	b.tripped = false
+	b.pausedBots = []string{}
+	return pausedBots
+}
@@ -0,0 +1,157 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func makeTestCircuitBreaker(maxDrawdown float64) *circuitBreaker {
+	return &circuitBreaker{
+		maxDrawdown: maxDrawdown,
+		mutex:       &sync.Mutex{},
+		baselines:   map[string]float64{},
+		peaks:       map[string]float64{},
+		latest:      map[string]float64{},
+	}
+}
+
+// breakerObservation is one check of the circuit breaker, running bots that are missing from values could not be valued
+type breakerObservation struct {
+	running      []string
+	values       map[string]float64
+	wantDrawdown float64
+	wantTrip     bool
+}
+
+func TestCircuitBreakerObserve(t *testing.T) {
+	testCases := []struct {
+		name         string
+		maxDrawdown  float64
+		observations []breakerObservation
+	}{
+		{
+			name:        "single bot crosses the threshold",
+			maxDrawdown: 0.1,
+			observations: []breakerObservation{
+				{running: []string{"a"}, values: map[string]float64{"a": 100}, wantDrawdown: 0},
+				{running: []string{"a"}, values: map[string]float64{"a": 91}, wantDrawdown: 0.09},
+				{running: []string{"a"}, values: map[string]float64{"a": 89}, wantDrawdown: 0.11, wantTrip: true},
+			},
+		}, {
+			name:        "drawdown is measured from the peak",
+			maxDrawdown: 0.1,
+			observations: []breakerObservation{
+				{running: []string{"a"}, values: map[string]float64{"a": 100}, wantDrawdown: 0},
+				{running: []string{"a"}, values: map[string]float64{"a": 120}, wantDrawdown: 0},
+				{running: []string{"a"}, values: map[string]float64{"a": 100}, wantDrawdown: 1 - 100.0/120, wantTrip: true},
+			},
+		}, {
+			// bots are normalized against their baselines so the drawdown of the smaller bot counts as much as that of the larger bot
+			name:        "aggregate of bots in different units",
+			maxDrawdown: 0.2,
+			observations: []breakerObservation{
+				{running: []string{"a", "b"}, values: map[string]float64{"a": 1000, "b": 10}, wantDrawdown: 0},
+				{running: []string{"a", "b"}, values: map[string]float64{"a": 1000, "b": 7}, wantDrawdown: 0.15},
+				{running: []string{"a", "b"}, values: map[string]float64{"a": 880, "b": 7}, wantDrawdown: 0.21, wantTrip: true},
+			},
+		}, {
+			name:        "bot that could not be valued keeps its last value",
+			maxDrawdown: 0.1,
+			observations: []breakerObservation{
+				{running: []string{"a", "b"}, values: map[string]float64{"a": 100, "b": 100}, wantDrawdown: 0},
+				{running: []string{"a", "b"}, values: map[string]float64{"a": 90, "b": 100}, wantDrawdown: 0.05},
+				{running: []string{"a", "b"}, values: map[string]float64{"b": 100}, wantDrawdown: 0.05},
+				{running: []string{"a", "b"}, values: map[string]float64{"b": 0}, wantDrawdown: 0.05},
+			},
+		}, {
+			name:        "stopped bot no longer counts",
+			maxDrawdown: 0.3,
+			observations: []breakerObservation{
+				{running: []string{"a", "b"}, values: map[string]float64{"a": 100, "b": 100}, wantDrawdown: 0},
+				{running: []string{"a", "b"}, values: map[string]float64{"a": 50, "b": 100}, wantDrawdown: 0.25},
+				{running: []string{"b"}, values: map[string]float64{"b": 100}, wantDrawdown: 0},
+				// a restarted bot is watched from its new value
+				{running: []string{"a", "b"}, values: map[string]float64{"a": 50, "b": 100}, wantDrawdown: 0},
+			},
+		}, {
+			name:        "no bots",
+			maxDrawdown: 0.1,
+			observations: []breakerObservation{
+				{running: []string{}, values: map[string]float64{}, wantDrawdown: 0},
+			},
+		},
+	}
+
+	for _, kase := range testCases {
+		t.Run(kase.name, func(t *testing.T) {
+			b := makeTestCircuitBreaker(kase.maxDrawdown)
+			for i, o := range kase.observations {
+				running := map[string]bool{}
+				for _, botName := range o.running {
+					running[botName] = true
+				}
+				tripped := b.observe(running, o.values)
+				assert.InDelta(t, o.wantDrawdown, b.drawdown, 1e-9, "observation %d", i)
+				assert.Equal(t, o.wantTrip, tripped, "observation %d", i)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerReset(t *testing.T) {
+	b := makeTestCircuitBreaker(0.1)
+	b.observe(map[string]bool{"a": true, "b": true}, map[string]float64{"a": 100, "b": 100})
+	b.observe(map[string]bool{"a": true, "b": true}, map[string]float64{"a": 50, "b": 100})
+	b.tripped = true
+	b.pausedBots = []string{"a", "b"}
+
+	assert.Equal(t, []string{"a", "b"}, b.reset())
+	assert.False(t, b.tripped)
+	assert.Equal(t, 0.0, b.drawdown)
+	assert.Equal(t, []string{}, b.pausedBots)
+	assert.Equal(t, map[string]float64{}, b.latest)
+
+	// the resumed bots are watched from their current values
+	assert.False(t, b.observe(map[string]bool{"a": true, "b": true}, map[string]float64{"a": 50, "b": 100}))
+	assert.Equal(t, 0.0, b.drawdown)
+}
+
+func TestResumeCircuitBreaker(t *testing.T) {
+	resume := func(s *APIServer) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.resumeCircuitBreaker(w, httptest.NewRequest("GET", "/api/v1/resumeCircuitBreaker", nil))
+		return w
+	}
+
+	// cannot resume when the breaker is not enabled
+	s := &APIServer{}
+	assert.Equal(t, http.StatusInternalServerError, resume(s).Code)
+	assert.False(t, s.isCircuitBreakerTripped())
+
+	s.breaker = makeTestCircuitBreaker(0.1)
+	// cannot resume a breaker that has not been tripped
+	assert.Equal(t, http.StatusInternalServerError, resume(s).Code)
+
+	s.breaker.tripped = true
+	s.breaker.pausedBots = []string{}
+	assert.True(t, s.isCircuitBreakerTripped())
+
+	w := resume(s)
+	if !assert.Equal(t, http.StatusOK, w.Code) {
+		return
+	}
+	var resp map[string][]string
+	if !assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp)) {
+		return
+	}
+	assert.Equal(t, []string{}, resp["resumed_bots"])
+	assert.False(t, s.isCircuitBreakerTripped())
+
+	// a single resume action resets the breaker so resuming again has nothing to do
+	assert.Equal(t, http.StatusInternalServerError, resume(s).Code)
+}
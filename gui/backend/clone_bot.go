@@ -0,0 +1,145 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/stellar/kelp/gui/model2"
+)
+
+// cloneBotRequest copies the configs of SourceBot, or of Template when SourceBot is empty, into a new bot
+type cloneBotRequest struct {
+	SourceBot string            `json:"source_bot"`
+	Template  string            `json:"template"`
+	Name      string            `json:"name"` // a name is generated when empty
+	Overrides cloneBotOverrides `json:"overrides"`
+}
+
+// cloneBotOverrides are applied to the cloned trader config, empty values keep the value from the source
+type cloneBotOverrides struct {
+	AssetCodeA        string `json:"asset_code_a"`
+	IssuerA           string `json:"issuer_a"`
+	AssetCodeB        string `json:"asset_code_b"`
+	IssuerB           string `json:"issuer_b"`
+	TradingSecretSeed string `json:"trading_secret_seed"`
+	SourceSecretSeed  string `json:"source_secret_seed"`
+}
+
+func (s *APIServer) cloneBot(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, e := ioutil.ReadAll(r.Body)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error reading request input: %s", e))
+		return
+	}
+
+	var req cloneBotRequest
+	e = json.Unmarshal(bodyBytes, &req)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error unmarshaling json: %s; bodyString = %s", e, string(bodyBytes)))
+		return
+	}
+
+	var source *botConfigResponse
+	if req.SourceBot != "" {
+		source, e = s.readBotConfigs(req.SourceBot)
+		if e != nil {
+			s.writeErrorJson(w, fmt.Sprintf("cannot read configs of source bot '%s': %s", req.SourceBot, e))
+			return
+		}
+	} else if t, ok := configTemplates[req.Template]; ok {
+		source = &botConfigResponse{
+			Strategy:       buysell,
+			TraderConfig:   *s.makeSampleTrader(""),
+			StrategyConfig: *t.makeConfig(),
+		}
+	} else {
+		s.writeErrorJson(w, fmt.Sprintf("need to specify either a source_bot or a valid template, template was '%s'", req.Template))
+		return
+	}
+
+	name := req.Name
+	if name == "" {
+		name, e = s.doGenerateBotName()
+		if e != nil {
+			s.writeErrorJson(w, fmt.Sprintf("cannot generate a name for the cloned bot: %s", e))
+			return
+		}
+	}
+	filenamePair := model2.GetBotFilenames(name, source.Strategy)
+	if _, e := os.Stat(fmt.Sprintf("%s/%s", s.configsDir, filenamePair.Trader)); e == nil {
+		s.writeErrorJson(w, fmt.Sprintf("a bot with the name '%s' already exists", name))
+		return
+	}
+
+	clone := upsertBotConfigRequest{
+		Name:           name,
+		Strategy:       source.Strategy,
+		TraderConfig:   source.TraderConfig,
+		StrategyConfig: source.StrategyConfig,
+	}
+	req.Overrides.apply(&clone)
+	if req.SourceBot != "" &&
+		clone.TraderConfig.TradingSecretSeed == source.TraderConfig.TradingSecretSeed &&
+		clone.TraderConfig.AssetCodeA == source.TraderConfig.AssetCodeA &&
+		clone.TraderConfig.IssuerA == source.TraderConfig.IssuerA &&
+		clone.TraderConfig.AssetCodeB == source.TraderConfig.AssetCodeB &&
+		clone.TraderConfig.IssuerB == source.TraderConfig.IssuerB {
+		s.writeErrorJson(w, fmt.Sprintf("cloned bot would trade the same pair on the same account as '%s', override the trading pair or the account", req.SourceBot))
+		return
+	}
+
+	if errResp := s.validateConfigs(clone); errResp != nil {
+		s.writeJson(w, errResp)
+		return
+	}
+
+	n := s.networkForHorizonURL(clone.TraderConfig.HorizonURL)
+	if !isMainnetConfirmed(r, n) {
+		s.writeMainnetConfirmationRequired(w, clone.Name, "cloneBot", n)
+		return
+	}
+
+	_, e = s.kos.Blocking("mkdir", "mkdir -p "+s.configsDir)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error running mkdir command for configsDir: %s", e))
+		return
+	}
+
+	e = s.writeBotConfigs(clone)
+	if e != nil {
+		s.writeErrorJson(w, e.Error())
+		return
+	}
+	log.Printf("cloned bot '%s' (source_bot='%s', template='%s')\n", name, req.SourceBot, req.Template)
+
+	// registers the new bot and creates funding accounts and trustlines if needed
+	s.reinitBotCheck(clone, n)
+
+	s.writeJson(w, botConfigResponse{
+		Name:           clone.Name,
+		Strategy:       clone.Strategy,
+		TraderConfig:   clone.TraderConfig,
+		StrategyConfig: clone.StrategyConfig,
+	})
+}
+
+func (o cloneBotOverrides) apply(req *upsertBotConfigRequest) {
+	if o.AssetCodeA != "" {
+		req.TraderConfig.AssetCodeA = o.AssetCodeA
+		req.TraderConfig.IssuerA = o.IssuerA
+	}
+	if o.AssetCodeB != "" {
+		req.TraderConfig.AssetCodeB = o.AssetCodeB
+		req.TraderConfig.IssuerB = o.IssuerB
+	}
+	if o.TradingSecretSeed != "" {
+		req.TraderConfig.TradingSecretSeed = o.TradingSecretSeed
+	}
+	if o.SourceSecretSeed != "" {
+		req.TraderConfig.SourceSecretSeed = o.SourceSecretSeed
+	}
+}
@@ -0,0 +1,86 @@
+package backend
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/stellar/kelp/plugins"
+)
+
+// configTemplate is a sample strategy config that can be used as the starting point for a new bot
+type configTemplate struct {
+	Description string
+	makeConfig  func() *plugins.BuySellConfig
+}
+
+// configTemplates is the library of templates that can be used when cloning a bot
+var configTemplates = map[string]configTemplate{
+	"sample": configTemplate{
+		Description: "four tight levels priced off the Kraken XLM/USD market",
+		makeConfig:  makeSampleBuysell,
+	},
+	"wide_spread": configTemplate{
+		Description: "three levels with wide spreads for illiquid markets",
+		makeConfig: func() *plugins.BuySellConfig {
+			c := makeSampleBuysell()
+			c.Levels = []plugins.StaticLevel{
+				plugins.StaticLevel{
+					SPREAD: 0.0100,
+					AMOUNT: 100.0,
+				}, plugins.StaticLevel{
+					SPREAD: 0.0200,
+					AMOUNT: 200.0,
+				}, plugins.StaticLevel{
+					SPREAD: 0.0400,
+					AMOUNT: 400.0,
+				},
+			}
+			return c
+		},
+	},
+	"fixed_price": configTemplate{
+		Description: "two levels around a fixed center price, useful for pegged assets",
+		makeConfig: func() *plugins.BuySellConfig {
+			c := makeSampleBuysell()
+			c.DataTypeA = "fixed"
+			c.DataFeedAURL = "1.0"
+			c.Levels = []plugins.StaticLevel{
+				plugins.StaticLevel{
+					SPREAD: 0.0010,
+					AMOUNT: 500.0,
+				}, plugins.StaticLevel{
+					SPREAD: 0.0050,
+					AMOUNT: 1000.0,
+				},
+			}
+			return c
+		},
+	},
+}
+
+type configTemplateResponse struct {
+	Name           string                `json:"name"`
+	Description    string                `json:"description"`
+	Strategy       string                `json:"strategy"`
+	StrategyConfig plugins.BuySellConfig `json:"strategy_config"`
+}
+
+func (s *APIServer) listConfigTemplates(w http.ResponseWriter, r *http.Request) {
+	names := []string{}
+	for name := range configTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	templates := []configTemplateResponse{}
+	for _, name := range names {
+		t := configTemplates[name]
+		templates = append(templates, configTemplateResponse{
+			Name:           name,
+			Description:    t.Description,
+			Strategy:       buysell,
+			StrategyConfig: *t.makeConfig(),
+		})
+	}
+	s.writeJson(w, templates)
+}
@@ -0,0 +1,159 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/stellar/kelp/plugins"
+)
+
+// customPriceFeedsFilename is the name of the file in the data dir that holds the custom price feeds, bots started by the server load it
+// so they can resolve the "custom" feed type
+const customPriceFeedsFilename = "custom_price_feeds.json"
+
+// customPriceFeedsMutex serializes the changes to the custom price feeds so concurrent requests do not lose each other's changes
+var customPriceFeedsMutex = &sync.Mutex{}
+
+type removeCustomPriceFeedRequest struct {
+	Name string `json:"name"`
+}
+
+type listCustomPriceFeedsResponse struct {
+	Feeds []plugins.CustomPriceFeed `json:"feeds"`
+}
+
+// registerCustomPriceFeedResponse includes the price fetched from the feed so the user can check that the URL and JSON path are right
+type registerCustomPriceFeedResponse struct {
+	Feed  plugins.CustomPriceFeed `json:"feed"`
+	Price float64                 `json:"price"`
+}
+
+func (s *APIServer) customPriceFeedsFilePath() string {
+	return fmt.Sprintf("%s/%s", s.dataDir, customPriceFeedsFilename)
+}
+
+// listCustomPriceFeeds returns the registered custom price feeds
+func (s *APIServer) listCustomPriceFeeds(w http.ResponseWriter, r *http.Request) {
+	s.writeJson(w, listCustomPriceFeedsResponse{Feeds: plugins.GetCustomPriceFeeds()})
+}
+
+// registerCustomPriceFeed adds a custom price feed or replaces the feed with the same name, the feed needs to return a price to be saved.
+// Running bots keep the definition they loaded when they started.
+func (s *APIServer) registerCustomPriceFeed(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, e := ioutil.ReadAll(r.Body)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error when reading request input: %s\n", e))
+		return
+	}
+	var feed plugins.CustomPriceFeed
+	e = json.Unmarshal(bodyBytes, &feed)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error unmarshaling json: %s; bodyString = %s", e, string(bodyBytes)))
+		return
+	}
+
+	pf, e := plugins.MakeCustomPriceFeed(feed)
+	if e != nil {
+		s.writeErrorJsonWithStatus(w, http.StatusBadRequest, e.Error())
+		return
+	}
+	price, e := pf.GetPrice()
+	if e != nil {
+		s.writeErrorJsonWithStatus(w, http.StatusBadRequest, fmt.Sprintf("unable to fetch a price from custom price feed '%s': %s", feed.Name, e))
+		return
+	}
+
+	customPriceFeedsMutex.Lock()
+	defer customPriceFeedsMutex.Unlock()
+	feeds := []plugins.CustomPriceFeed{feed}
+	for _, f := range plugins.GetCustomPriceFeeds() {
+		if f.Name != feed.Name {
+			feeds = append(feeds, f)
+		}
+	}
+	e = s.saveCustomPriceFeeds(feeds)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error saving custom price feed: %s", e))
+		return
+	}
+	log.Printf("registered custom price feed '%s' (type=%s, url=%s, jsonPath=%s) with price %.7f\n", feed.Name, feed.Type, feed.URL, feed.JSONPath, price)
+
+	s.writeJson(w, registerCustomPriceFeedResponse{Feed: feed, Price: price})
+}
+
+// removeCustomPriceFeed removes a custom price feed, bots that use it cannot be started until it is registered again
+func (s *APIServer) removeCustomPriceFeed(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, e := ioutil.ReadAll(r.Body)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error when reading request input: %s\n", e))
+		return
+	}
+	var req removeCustomPriceFeedRequest
+	e = json.Unmarshal(bodyBytes, &req)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error unmarshaling json: %s; bodyString = %s", e, string(bodyBytes)))
+		return
+	}
+
+	customPriceFeedsMutex.Lock()
+	defer customPriceFeedsMutex.Unlock()
+	found := false
+	feeds := []plugins.CustomPriceFeed{}
+	for _, f := range plugins.GetCustomPriceFeeds() {
+		if f.Name == req.Name {
+			found = true
+			continue
+		}
+		feeds = append(feeds, f)
+	}
+	if !found {
+		s.writeErrorJsonWithStatus(w, http.StatusNotFound, fmt.Sprintf("no custom price feed is registered with the name '%s'", req.Name))
+		return
+	}
+	e = s.saveCustomPriceFeeds(feeds)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error removing custom price feed: %s", e))
+		return
+	}
+	log.Printf("removed custom price feed '%s'\n", req.Name)
+
+	s.writeJson(w, listCustomPriceFeedsResponse{Feeds: plugins.GetCustomPriceFeeds()})
+}
+
+// saveCustomPriceFeeds registers the feeds and persists them to the data dir. Needs to be called while holding customPriceFeedsMutex.
+func (s *APIServer) saveCustomPriceFeeds(feeds []plugins.CustomPriceFeed) error {
+	e := plugins.SetCustomPriceFeeds(feeds)
+	if e != nil {
+		return e
+	}
+	_, e = s.kos.Blocking("mkdir", "mkdir -p "+s.dataDir)
+	if e != nil {
+		return fmt.Errorf("error running mkdir command for dataDir: %s", e)
+	}
+	return plugins.SaveCustomPriceFeeds(s.customPriceFeedsFilePath())
+}
+
+// withCustomPriceFeeds adds the custom price feeds to the price feed options so they can be selected when creating a bot, the options are
+// copied since the registered feeds change while the other options are loaded once
+func withCustomPriceFeeds(m metadata) metadata {
+	feeds := plugins.GetCustomPriceFeeds()
+	options, ok := m.(dropdownType)
+	if !ok || len(feeds) == 0 {
+		return m
+	}
+
+	builder := optionsBuilder()
+	for k, v := range options.Options {
+		builder.option(k, v.Text, v.Subtype)
+	}
+	customOptions := optionsBuilder()
+	for _, f := range feeds {
+		customOptions._leaf(f.Name, f.Name)
+	}
+	builder.option("custom", "Custom", dropdown(customOptions))
+	return dropdown(builder)
+}
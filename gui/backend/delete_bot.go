@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/stellar/kelp/gui/model2"
@@ -16,27 +17,37 @@ func (s *APIServer) deleteBot(w http.ResponseWriter, r *http.Request) {
 		s.writeError(w, fmt.Sprintf("error in deleteBot: %s\n", e))
 		return
 	}
+	if !s.checkActionConfirmed(w, r, "deleteBot", botName) {
+		return
+	}
 
+	e = s.doDeleteBot(botName)
+	if e != nil {
+		s.writeError(w, fmt.Sprintf("error in deleteBot: %s\n", e))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// doDeleteBot stops the bot if it is running, waits for its offers to be deleted and removes its configs
+func (s *APIServer) doDeleteBot(botName string) error {
 	// only stop bot if current state is running
 	botState, e := s.doGetBotState(botName)
 	if e != nil {
-		s.writeError(w, fmt.Sprintf("error in deleteBot unable to get botState: %s\n", e))
-		return
+		return fmt.Errorf("unable to get botState: %s", e)
 	}
 	log.Printf("current botState: %s\n", botState)
 	if botState == kelpos.BotStateRunning {
 		e = s.doStopBot(botName)
 		if e != nil {
-			s.writeError(w, fmt.Sprintf("error stopping bot when trying to delete: %s\n", e))
-			return
+			return fmt.Errorf("error stopping bot when trying to delete: %s", e)
 		}
 	}
 
 	for {
 		botState, e := s.doGetBotState(botName)
 		if e != nil {
-			s.writeError(w, fmt.Sprintf("error in deleteBot for loop, unable to get botState: %s\n", e))
-			return
+			return fmt.Errorf("unable to get botState while waiting for the bot to stop: %s", e)
 		}
 		log.Printf("deleteBot for loop, current botState: %s\n", botState)
 
@@ -54,10 +65,14 @@ func (s *APIServer) deleteBot(w http.ResponseWriter, r *http.Request) {
 	botPrefix := model2.GetPrefix(botName)
 	_, e = s.kos.Blocking("rm", fmt.Sprintf("rm %s/%s*", s.configsDir, botPrefix))
 	if e != nil {
-		s.writeError(w, fmt.Sprintf("error running rm command for bot configs: %s\n", e))
-		return
+		return fmt.Errorf("error running rm command for bot configs: %s", e)
 	}
 	log.Printf("removed bot configs for prefix '%s'\n", botPrefix)
 
-	w.WriteHeader(http.StatusOK)
+	// a new bot with the same name should not inherit the trading hours
+	e = os.Remove(s.botScheduleFilePath(botName))
+	if e != nil && !os.IsNotExist(e) {
+		return fmt.Errorf("error removing schedule of bot: %s", e)
+	}
+	return nil
 }
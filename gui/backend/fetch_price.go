@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/stellar/kelp/api"
 	"github.com/stellar/kelp/plugins"
 )
 
@@ -16,8 +17,16 @@ type fetchPriceInput struct {
 	FeedURL string `json:"feed_url"`
 }
 
+// fetchPriceOutput includes the details of the price when the feed provides them so users can judge the quality of the feed,
+// price is always the value that a strategy would use
 type fetchPriceOutput struct {
-	Price float64 `json:"price"`
+	Price         float64  `json:"price"`
+	Bid           *float64 `json:"bid"`
+	Ask           *float64 `json:"ask"`
+	Mid           float64  `json:"mid"`
+	LastTradeTime string   `json:"last_trade_time"`
+	Source        string   `json:"source"`
+	FetchedAt     string   `json:"fetched_at"`
 }
 
 func (s *APIServer) fetchPrice(w http.ResponseWriter, r *http.Request) {
@@ -41,7 +50,7 @@ func (s *APIServer) fetchPrice(w http.ResponseWriter, r *http.Request) {
 		s.writeErrorJson(w, fmt.Sprintf("unable to make price feed: %s", e))
 		return
 	}
-	price, e := pf.GetPrice()
+	output, e := fetchPriceDetails(pf, input.Type)
 	if e != nil {
 		s.writeErrorJson(w, fmt.Sprintf("unable to fetch price: %s", e))
 		return
@@ -54,7 +63,42 @@ func (s *APIServer) fetchPrice(w http.ResponseWriter, r *http.Request) {
 	log.Printf("force sleep for %d nanoseconds\n", nanos)
 	time.Sleep(time.Duration(nanos))
 
-	s.writeJson(w, fetchPriceOutput{
-		Price: price,
-	})
+	s.writeJson(w, output)
+}
+
+// fetchPriceDetails uses the detailed quote of the feed when available, otherwise only the price is known
+func fetchPriceDetails(pf api.PriceFeed, feedType string) (*fetchPriceOutput, error) {
+	fetchedAt := time.Now().UTC().Format(time.RFC3339)
+
+	qf, ok := pf.(api.PriceQuoteFeed)
+	if !ok {
+		price, e := pf.GetPrice()
+		if e != nil {
+			return nil, e
+		}
+		return &fetchPriceOutput{
+			Price:     price,
+			Mid:       price,
+			Source:    feedType,
+			FetchedAt: fetchedAt,
+		}, nil
+	}
+
+	q, e := qf.GetPriceQuote()
+	if e != nil {
+		return nil, e
+	}
+	lastTradeTime := ""
+	if q.LastTradeTime != nil {
+		lastTradeTime = q.LastTradeTime.UTC().Format(time.RFC3339)
+	}
+	return &fetchPriceOutput{
+		Price:         q.Mid,
+		Bid:           q.Bid,
+		Ask:           q.Ask,
+		Mid:           q.Mid,
+		LastTradeTime: lastTradeTime,
+		Source:        q.Source,
+		FetchedAt:     fetchedAt,
+	}, nil
 }
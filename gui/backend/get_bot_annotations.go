@@ -0,0 +1,17 @@
+package backend
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// getBotAnnotations returns the recent annotations emitted by the strategy of a running bot
+func (s *APIServer) getBotAnnotations(w http.ResponseWriter, r *http.Request) {
+	botName, e := s.parseBotName(r)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error parsing bot name in getBotAnnotations: %s\n", e))
+		return
+	}
+
+	s.writeIPCCommandResponse(w, botName, "getAnnotations")
+}
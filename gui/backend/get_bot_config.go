@@ -26,34 +26,40 @@ func (s *APIServer) getBotConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	filenamePair := model2.GetBotFilenames(botName, "buysell")
+	response, e := s.readBotConfigs(botName)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("%s\n", e))
+		return
+	}
+	jsonBytes, e := json.MarshalIndent(*response, "", "  ")
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("cannot marshal botConfigResponse: %s\n", e))
+		return
+	}
+	log.Printf("getBotConfig response for botName '%s': %s\n", botName, string(jsonBytes))
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonBytes)
+}
+
+func (s *APIServer) readBotConfigs(botName string) (*botConfigResponse, error) {
+	filenamePair := model2.GetBotFilenames(botName, buysell)
 	traderFilePath := fmt.Sprintf("%s/%s", s.configsDir, filenamePair.Trader)
 	var botConfig trader.BotConfig
-	e = config.Read(traderFilePath, &botConfig)
+	e := config.Read(traderFilePath, &botConfig)
 	if e != nil {
-		s.writeErrorJson(w, fmt.Sprintf("cannot read bot config at path '%s': %s\n", traderFilePath, e))
-		return
+		return nil, fmt.Errorf("cannot read bot config at path '%s': %s", traderFilePath, e)
 	}
 	strategyFilePath := fmt.Sprintf("%s/%s", s.configsDir, filenamePair.Strategy)
 	var buysellConfig plugins.BuySellConfig
 	e = config.Read(strategyFilePath, &buysellConfig)
 	if e != nil {
-		s.writeErrorJson(w, fmt.Sprintf("cannot read strategy config at path '%s': %s\n", strategyFilePath, e))
-		return
+		return nil, fmt.Errorf("cannot read strategy config at path '%s': %s", strategyFilePath, e)
 	}
 
-	response := botConfigResponse{
+	return &botConfigResponse{
 		Name:           botName,
-		Strategy:       "buysell",
+		Strategy:       buysell,
 		TraderConfig:   botConfig,
 		StrategyConfig: buysellConfig,
-	}
-	jsonBytes, e := json.MarshalIndent(response, "", "  ")
-	if e != nil {
-		s.writeErrorJson(w, fmt.Sprintf("cannot marshal botConfigResponse: %s\n", e))
-		return
-	}
-	log.Printf("getBotConfig response for botName '%s': %s\n", botName, string(jsonBytes))
-	w.WriteHeader(http.StatusOK)
-	w.Write(jsonBytes)
+	}, nil
 }
@@ -1,14 +1,12 @@
 package backend
 
 import (
-	"bufio"
 	"bytes"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/stellar/go/clients/horizon"
@@ -46,18 +44,16 @@ func (s *APIServer) runGetBotInfoViaIPC(w http.ResponseWriter, botName string) {
 	}
 
 	log.Printf("getBotInfo is making IPC request for botName: %s\n", botName)
-	p.PipeIn.Write([]byte("getBotInfo\n"))
-	scanner := bufio.NewScanner(p.PipeOut)
-	output := ""
-	for scanner.Scan() {
-		text := scanner.Text()
-		if strings.Contains(text, utils.IPCBoundary) {
-			break
-		}
-		output += text
+	payload, e := doIPCRequest(p, "getBotInfo")
+	if e != nil {
+		log.Printf("IPC request failed for botName '%s': %s\n", botName, e)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("{}"))
+		return
 	}
+	output := string(payload)
 	var buf bytes.Buffer
-	e := json.Indent(&buf, []byte(output), "", "  ")
+	e = json.Indent(&buf, payload, "", "  ")
 	if e != nil {
 		log.Printf("cannot indent json response (error=%s), json_response: %s\n", e, output)
 		w.WriteHeader(http.StatusInternalServerError)
@@ -70,7 +66,71 @@ func (s *APIServer) runGetBotInfoViaIPC(w http.ResponseWriter, botName string) {
 	w.Write(buf.Bytes())
 }
 
+// doIPCRequest sends the command to the bot process and returns the payload of the response, requests to the same process are serialized
+// so every caller reads the response to its own request
+func doIPCRequest(p *kelpos.Process, command string) ([]byte, error) {
+	p.IPCMutex.Lock()
+	defer p.IPCMutex.Unlock()
+
+	if *p.IPCVersion == utils.IPCVersionUnknown {
+		// bots can run an older version of kelp, such as in a container, so the protocol is negotiated before the first request
+		version, e := utils.NegotiateIPCVersion(p.PipeIn, p.PipeOutReader)
+		if e != nil {
+			return nil, fmt.Errorf("cannot negotiate IPC protocol version: %s", e)
+		}
+		*p.IPCVersion = version
+	}
+
+	e := utils.WriteIPCRequest(p.PipeIn, *p.IPCVersion, []byte(command))
+	if e != nil {
+		return nil, fmt.Errorf("cannot write IPC request '%s': %s", command, e)
+	}
+	// bots using the legacy protocol respond with messages terminated by the IPCBoundary
+	msg, e := utils.ReadIPCMessage(p.PipeOutReader, utils.IPCBoundary)
+	if e != nil {
+		return nil, fmt.Errorf("cannot read IPC response for request '%s': %s", command, e)
+	}
+	return msg.Payload, nil
+}
+
+// writeIPCCommandResponse writes the JSON response of an IPC command sent to a running bot
+func (s *APIServer) writeIPCCommandResponse(w http.ResponseWriter, botName string, command string) {
+	p, exists := s.kos.GetProcess(botName)
+	if !exists {
+		log.Printf("kelp bot process with name '%s' does not exist; processes available: %v\n", botName, s.kos.RegisteredProcesses())
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("{}"))
+		return
+	}
+
+	log.Printf("making IPC request '%s' for botName: %s\n", command, botName)
+	payload, e := doIPCRequest(p, command)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("IPC request failed for botName '%s': %s\n", botName, e))
+		return
+	}
+
+	// older bots respond with an empty payload for unrecognized commands
+	if len(bytes.TrimSpace(payload)) == 0 {
+		payload = []byte("{}")
+	}
+	var buf bytes.Buffer
+	e = json.Indent(&buf, payload, "", "  ")
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("cannot indent json response (error=%s), json_response: %s\n", e, string(payload)))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
 func (s *APIServer) runGetBotInfoDirect(w http.ResponseWriter, botName string) {
+	if cached := s.botInfoCache.get(botName); cached != nil {
+		w.WriteHeader(http.StatusOK)
+		w.Write(cached)
+		return
+	}
 	log.Printf("getBotInfo is invoking logic directly for botName: %s\n", botName)
 
 	botState, e := s.doGetBotState(botName)
@@ -85,31 +145,63 @@ func (s *APIServer) runGetBotInfoDirect(w http.ResponseWriter, botName string) {
 		return
 	}
 
-	filenamePair := model2.GetBotFilenames(botName, buysell)
-	traderFilePath := fmt.Sprintf("%s/%s", s.configsDir, filenamePair.Trader)
-	var botConfig trader.BotConfig
-	e = config.Read(traderFilePath, &botConfig)
-	if e != nil {
-		s.writeErrorJson(w, fmt.Sprintf("cannot read bot config at path '%s': %s\n", traderFilePath, e))
-		return
-	}
-	e = botConfig.Init()
+	botConfig, e := s.loadBotConfig(botName)
 	if e != nil {
-		s.writeErrorJson(w, fmt.Sprintf("cannot init bot config at path '%s': %s\n", traderFilePath, e))
+		s.writeErrorJson(w, e.Error())
 		return
 	}
 
+	n := s.networkForHorizonURL(botConfig.HorizonURL)
+
 	assetBase := botConfig.AssetBase()
 	assetQuote := botConfig.AssetQuote()
 	tradingPair := &model.TradingPair{
 		Base:  model.Asset(utils.Asset2CodeString(assetBase)),
 		Quote: model.Asset(utils.Asset2CodeString(assetQuote)),
 	}
-	account, e := s.apiTestNet.AccountDetail(horizonclient.AccountRequest{AccountID: botConfig.TradingAccount()})
+	// the account, offers and orderbook are independent so they are fetched from horizon concurrently
+	var account hProtocol.Account
+	var offers []hProtocol.Offer
+	var obs hProtocol.OrderBookSummary
+	e = utils.RunConcurrently(
+		func() error {
+			var e error
+			account, e = n.api.AccountDetail(horizonclient.AccountRequest{AccountID: botConfig.TradingAccount()})
+			if e != nil {
+				return fmt.Errorf("cannot get account data for account '%s' for botName '%s': %s\n", botConfig.TradingAccount(), botName, e)
+			}
+			return nil
+		},
+		func() error {
+			var e error
+			offers, e = utils.LoadAllOffers(botConfig.TradingAccount(), n.api)
+			if e != nil {
+				return fmt.Errorf("error getting offers for account '%s' for botName '%s': %s\n", botConfig.TradingAccount(), botName, e)
+			}
+			return nil
+		},
+		func() error {
+			var e error
+			obs, e = n.api.OrderBook(horizonclient.OrderBookRequest{
+				SellingAssetType:   horizonclient.AssetType(assetBase.Type),
+				SellingAssetCode:   assetBase.Code,
+				SellingAssetIssuer: assetBase.Issuer,
+				BuyingAssetType:    horizonclient.AssetType(assetQuote.Type),
+				BuyingAssetCode:    assetQuote.Code,
+				BuyingAssetIssuer:  assetQuote.Issuer,
+				Limit:              1,
+			})
+			if e != nil {
+				return fmt.Errorf("error getting orderbook for assets (base=%v, quote=%v) for botName '%s': %s\n", assetBase, assetQuote, botName, e)
+			}
+			return nil
+		},
+	)
 	if e != nil {
-		s.writeErrorJson(w, fmt.Sprintf("cannot get account data for account '%s' for botName '%s': %s\n", botConfig.TradingAccount(), botName, e))
+		s.writeErrorJson(w, e.Error())
 		return
 	}
+
 	var balanceBase float64
 	if assetBase == utils.NativeAsset {
 		balanceBase, e = getNativeBalance(account)
@@ -139,28 +231,10 @@ func (s *APIServer) runGetBotInfoDirect(w http.ResponseWriter, botName string) {
 		}
 	}
 
-	offers, e := utils.LoadAllOffers(account.AccountID, s.apiTestNet)
-	if e != nil {
-		s.writeErrorJson(w, fmt.Sprintf("error getting offers for account '%s' for botName '%s': %s\n", botConfig.TradingAccount(), botName, e))
-		return
-	}
 	sellingAOffers, buyingAOffers := utils.FilterOffers(offers, assetBase, assetQuote)
 	numBids := len(buyingAOffers)
 	numAsks := len(sellingAOffers)
 
-	obs, e := s.apiTestNet.OrderBook(horizonclient.OrderBookRequest{
-		SellingAssetType:   horizonclient.AssetType(assetBase.Type),
-		SellingAssetCode:   assetBase.Code,
-		SellingAssetIssuer: assetBase.Issuer,
-		BuyingAssetType:    horizonclient.AssetType(assetQuote.Type),
-		BuyingAssetCode:    assetQuote.Code,
-		BuyingAssetIssuer:  assetQuote.Issuer,
-		Limit:              1,
-	})
-	if e != nil {
-		s.writeErrorJson(w, fmt.Sprintf("error getting orderbook for assets (base=%v, quote=%v) for botName '%s': %s\n", assetBase, assetQuote, botName, e))
-		return
-	}
 	spread := -1.0
 	spreadPct := -1.0
 	if len(obs.Asks) > 0 && len(obs.Bids) > 0 {
@@ -175,7 +249,7 @@ func (s *APIServer) runGetBotInfoDirect(w http.ResponseWriter, botName string) {
 	bi := query.BotInfo{
 		LastUpdated:   time.Now().Format("1/_2/2006 15:04:05"),
 		Strategy:      buysell,
-		IsTestnet:     strings.Contains(botConfig.HorizonURL, "test"),
+		IsTestnet:     n.isTestnet(),
 		TradingPair:   tradingPair,
 		AssetBase:     assetBase,
 		AssetQuote:    assetQuote,
@@ -196,11 +270,28 @@ func (s *APIServer) runGetBotInfoDirect(w http.ResponseWriter, botName string) {
 	}
 	marshalledJsonString := string(marshalledJson)
 	log.Printf("getBotInfo returned direct response for botName '%s': %s\n", botName, marshalledJsonString)
+	s.botInfoCache.set(botName, marshalledJson)
 
 	w.WriteHeader(http.StatusOK)
 	w.Write(marshalledJson)
 }
 
+// loadBotConfig reads and initializes the trader config of the bot
+func (s *APIServer) loadBotConfig(botName string) (*trader.BotConfig, error) {
+	filenamePair := model2.GetBotFilenames(botName, buysell)
+	traderFilePath := fmt.Sprintf("%s/%s", s.configsDir, filenamePair.Trader)
+	var botConfig trader.BotConfig
+	e := config.Read(traderFilePath, &botConfig)
+	if e != nil {
+		return nil, fmt.Errorf("cannot read bot config at path '%s': %s\n", traderFilePath, e)
+	}
+	e = botConfig.Init()
+	if e != nil {
+		return nil, fmt.Errorf("cannot init bot config at path '%s': %s\n", traderFilePath, e)
+	}
+	return &botConfig, nil
+}
+
 func getNativeBalance(account hProtocol.Account) (float64, error) {
 	balanceString, e := account.GetNativeBalance()
 	if e != nil {
@@ -0,0 +1,17 @@
+package backend
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// getMirrorSnapshot returns the backing orderbook used by the mirror strategy in its last update aligned with the SDEX offers derived from it
+func (s *APIServer) getMirrorSnapshot(w http.ResponseWriter, r *http.Request) {
+	botName, e := s.parseBotName(r)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error parsing bot name in getMirrorSnapshot: %s\n", e))
+		return
+	}
+
+	s.writeIPCCommandResponse(w, botName, "getMirrorSnapshot")
+}
@@ -0,0 +1,163 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// NextCursorHeader is the response header that holds the cursor to fetch the next page, it is not set on the last page
+const NextCursorHeader = "X-Next-Cursor"
+
+// bufferedResponseWriter holds on to the response so it can be transformed before it is written out
+type bufferedResponseWriter struct {
+	header http.Header
+	status int
+	body   *bytes.Buffer
+}
+
+// Header impl
+func (b *bufferedResponseWriter) Header() http.Header {
+	return b.header
+}
+
+// Write impl
+func (b *bufferedResponseWriter) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
+
+// WriteHeader impl
+func (b *bufferedResponseWriter) WriteHeader(status int) {
+	b.status = status
+}
+
+// jsonResponseTransformer is a middleware that lets clients reduce the size of large JSON responses with these optional query params:
+// "limit" and "cursor" paginate a response that is a JSON array, the cursor for the next page is returned in the X-Next-Cursor header,
+// and "fields" is a comma-separated list of the only fields to include in the response object or in each object of the response array.
+func jsonResponseTransformer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		limitParam := query.Get("limit")
+		cursorParam := query.Get("cursor")
+		fieldsParam := query.Get("fields")
+		if limitParam == "" && cursorParam == "" && fieldsParam == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		buffered := &bufferedResponseWriter{
+			header: w.Header(),
+			status: http.StatusOK,
+			body:   &bytes.Buffer{},
+		}
+		next.ServeHTTP(buffered, r)
+
+		body := buffered.body.Bytes()
+		if buffered.status == http.StatusOK {
+			transformed, nextCursor, e := transformJSONResponse(body, limitParam, cursorParam, fieldsParam)
+			if e != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(fmt.Sprintf("unable to apply query params to response: %s\n", e)))
+				return
+			}
+			body = transformed
+			if nextCursor != "" {
+				w.Header().Set(NextCursorHeader, nextCursor)
+			}
+		}
+		w.WriteHeader(buffered.status)
+		w.Write(body)
+	})
+}
+
+// transformJSONResponse returns the transformed body and the cursor for the next page, which is empty when there are no more pages
+func transformJSONResponse(body []byte, limitParam string, cursorParam string, fieldsParam string) ([]byte, string, error) {
+	var v interface{}
+	e := json.Unmarshal(body, &v)
+	if e != nil {
+		return nil, "", fmt.Errorf("response is not valid json: %s", e)
+	}
+
+	nextCursor := ""
+	if limitParam != "" || cursorParam != "" {
+		items, ok := v.([]interface{})
+		if !ok {
+			return nil, "", fmt.Errorf("pagination is only supported on responses that are a json array")
+		}
+
+		v, nextCursor, e = paginate(items, limitParam, cursorParam)
+		if e != nil {
+			return nil, "", e
+		}
+	}
+
+	if fieldsParam != "" {
+		fields := map[string]bool{}
+		for _, f := range strings.Split(fieldsParam, ",") {
+			fields[strings.TrimSpace(f)] = true
+		}
+		v = selectFields(v, fields)
+	}
+
+	transformed, e := json.MarshalIndent(v, "", "    ")
+	if e != nil {
+		return nil, "", fmt.Errorf("unable to marshal json with indentation: %s", e)
+	}
+	return transformed, nextCursor, nil
+}
+
+// paginate uses the index of the first item in the page as the cursor
+func paginate(items []interface{}, limitParam string, cursorParam string) ([]interface{}, string, error) {
+	start := 0
+	if cursorParam != "" {
+		c, e := strconv.Atoi(cursorParam)
+		if e != nil || c < 0 {
+			return nil, "", fmt.Errorf("invalid cursor '%s'", cursorParam)
+		}
+		start = c
+	}
+	if start > len(items) {
+		start = len(items)
+	}
+
+	end := len(items)
+	if limitParam != "" {
+		limit, e := strconv.Atoi(limitParam)
+		if e != nil || limit <= 0 {
+			return nil, "", fmt.Errorf("invalid limit '%s', needs to be a positive integer", limitParam)
+		}
+		if start+limit < end {
+			end = start + limit
+		}
+	}
+
+	nextCursor := ""
+	if end < len(items) {
+		nextCursor = strconv.Itoa(end)
+	}
+	return items[start:end], nextCursor, nil
+}
+
+// selectFields keeps only the passed in fields of the object or of each object in the array, other values are returned as-is
+func selectFields(v interface{}, fields map[string]bool) interface{} {
+	switch value := v.(type) {
+	case []interface{}:
+		selected := []interface{}{}
+		for _, item := range value {
+			selected = append(selected, selectFields(item, fields))
+		}
+		return selected
+	case map[string]interface{}:
+		selected := map[string]interface{}{}
+		for k, item := range value {
+			if fields[k] {
+				selected[k] = item
+			}
+		}
+		return selected
+	}
+	return v
+}
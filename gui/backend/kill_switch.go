@@ -0,0 +1,138 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+// killSwitchFilename is the name of the kill switch file in the data dir, every bot started by the server stops quoting and deletes its
+// offers while it exists so it can also be created directly on the host during an incident
+const killSwitchFilename = "kill_switch"
+
+// killSwitchInput is the input of the POST killSwitch endpoint
+type killSwitchInput struct {
+	Active bool   `json:"active"`
+	Reason string `json:"reason"`
+}
+
+// killSwitchContents is written to the kill switch file when it is activated through the API
+type killSwitchContents struct {
+	ActivatedAt string `json:"activated_at"`
+	Reason      string `json:"reason"`
+}
+
+// killSwitchStatus is the output of the killSwitch endpoints
+type killSwitchStatus struct {
+	Active      bool   `json:"active"`
+	File        string `json:"file"`
+	ActivatedAt string `json:"activated_at,omitempty"` // empty when the file was not created through the API
+	Reason      string `json:"reason,omitempty"`
+}
+
+func (s *APIServer) killSwitchFilePath() string {
+	return fmt.Sprintf("%s/%s", s.dataDir, killSwitchFilename)
+}
+
+// isKillSwitchActive returns true when the kill switch file exists
+func (s *APIServer) isKillSwitchActive() (bool, error) {
+	_, e := os.Stat(s.killSwitchFilePath())
+	if e == nil {
+		return true, nil
+	}
+	if os.IsNotExist(e) {
+		return false, nil
+	}
+	return false, fmt.Errorf("unable to check kill switch file: %s", e)
+}
+
+// getKillSwitch reports whether the kill switch is active
+func (s *APIServer) getKillSwitch(w http.ResponseWriter, r *http.Request) {
+	status, e := s.readKillSwitchStatus()
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error reading kill switch: %s", e))
+		return
+	}
+	s.writeJson(w, status)
+}
+
+// setKillSwitch activates the kill switch, which stops all bots from quoting and deletes their offers in their next update cycle, or
+// deactivates it so they resume quoting. Deactivating needs to be confirmed when a confirmation policy is enabled, activating never does.
+func (s *APIServer) setKillSwitch(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, e := ioutil.ReadAll(r.Body)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error when reading request input: %s\n", e))
+		return
+	}
+	var input killSwitchInput
+	e = json.Unmarshal(bodyBytes, &input)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error unmarshaling json: %s; bodyString = %s", e, string(bodyBytes)))
+		return
+	}
+
+	filePath := s.killSwitchFilePath()
+	if input.Active {
+		_, e = s.kos.Blocking("mkdir", "mkdir -p "+s.dataDir)
+		if e != nil {
+			s.writeErrorJson(w, fmt.Sprintf("error running mkdir command for dataDir: %s", e))
+			return
+		}
+		contents, e := json.MarshalIndent(killSwitchContents{
+			ActivatedAt: time.Now().UTC().Format(time.RFC3339),
+			Reason:      input.Reason,
+		}, "", "    ")
+		if e != nil {
+			s.writeErrorJson(w, fmt.Sprintf("unable to marshal kill switch file: %s", e))
+			return
+		}
+		e = ioutil.WriteFile(filePath, contents, 0644)
+		if e != nil {
+			s.writeErrorJson(w, fmt.Sprintf("unable to write kill switch file: %s", e))
+			return
+		}
+		log.Printf("activated kill switch '%s' with reason '%s', all bots stop quoting and delete their offers in their next update cycle\n", filePath, input.Reason)
+	} else {
+		if !s.checkActionConfirmed(w, r, "deactivateKillSwitch", "") {
+			return
+		}
+		e = os.Remove(filePath)
+		if e != nil && !os.IsNotExist(e) {
+			s.writeErrorJson(w, fmt.Sprintf("unable to remove kill switch file: %s", e))
+			return
+		}
+		log.Printf("deactivated kill switch '%s', running bots resume quoting in their next update cycle\n", filePath)
+	}
+
+	status, e := s.readKillSwitchStatus()
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error reading kill switch: %s", e))
+		return
+	}
+	s.writeJson(w, status)
+}
+
+// readKillSwitchStatus reads the kill switch file, which can be empty or have other contents when it was created directly on the host
+func (s *APIServer) readKillSwitchStatus() (*killSwitchStatus, error) {
+	filePath := s.killSwitchFilePath()
+	status := &killSwitchStatus{File: filePath}
+	contents, e := ioutil.ReadFile(filePath)
+	if os.IsNotExist(e) {
+		return status, nil
+	}
+	if e != nil {
+		return nil, fmt.Errorf("unable to read kill switch file: %s", e)
+	}
+
+	status.Active = true
+	var c killSwitchContents
+	if json.Unmarshal(contents, &c) == nil {
+		status.ActivatedAt = c.ActivatedAt
+		status.Reason = c.Reason
+	}
+	return status, nil
+}
@@ -29,7 +29,16 @@ func (s *APIServer) listBots(w http.ResponseWriter, r *http.Request) {
 	}
 	log.Printf("bots available: %v", bots)
 
-	for _, bot := range bots {
+	for i := range bots {
+		bot := &bots[i]
+		n, e := s.networkForBot(bot.Name, bot.Strategy)
+		if e != nil {
+			s.writeErrorJson(w, fmt.Sprintf("unable to load network for bot '%s': %s\n", bot.Name, e))
+			return
+		}
+		bot.Test = n.isTestnet()
+		bot.Network = n.name
+
 		botState, e := s.kos.QueryBotState(bot.Name)
 		if e != nil {
 			s.writeErrorJson(w, fmt.Sprintf("unable to query bot state for bot '%s': %s\n", bot.Name, e))
@@ -39,7 +48,7 @@ func (s *APIServer) listBots(w http.ResponseWriter, r *http.Request) {
 		log.Printf("found bot '%s' with state '%s'\n", bot.Name, botState)
 		// if page is reloaded then bot would already be registered, which is ok -- but we upsert here so it doesn't matter
 		if botState != kelpos.InitState() {
-			s.kos.RegisterBotWithStateUpsert(&bot, botState)
+			s.kos.RegisterBotWithStateUpsert(bot, botState)
 		}
 	}
 
@@ -0,0 +1,112 @@
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/stellar/go/build"
+	"github.com/stellar/go/clients/horizon"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/support/config"
+	"github.com/stellar/kelp/gui/model2"
+	"github.com/stellar/kelp/support/utils"
+	"github.com/stellar/kelp/trader"
+)
+
+// confirmMainnetParam is the query param that needs to be set to "true" for actions that affect a bot running on the main network
+const confirmMainnetParam = "confirmMainnet"
+
+// botNetwork is the stellar network that a bot runs against, as selected by the HORIZON_URL in its trader config
+type botNetwork struct {
+	name       string
+	horizonURL string
+	network    build.Network
+	testnet    bool
+	api        *horizonclient.Client
+	apiOld     *horizon.Client
+}
+
+// isTestnet returns true when the bot does not run on the main network
+func (n *botNetwork) isTestnet() bool {
+	return n.testnet
+}
+
+// String is the stringer function
+func (n *botNetwork) String() string {
+	return fmt.Sprintf("botNetwork[name=%s, horizonURL=%s, isTestnet=%v]", n.name, n.horizonURL, n.isTestnet())
+}
+
+// testnet returns the network used for bots that have not selected one, such as autogenerated bots
+func (s *APIServer) testnet() *botNetwork {
+	return &botNetwork{
+		name:       model2.NetworkTestnet,
+		horizonURL: s.config.HorizonTestnetURL,
+		network:    build.Network{Passphrase: s.config.TestnetPassphrase},
+		testnet:    true,
+		api:        s.apiTestNet,
+		apiOld:     s.apiTestNetOld,
+	}
+}
+
+// networkForHorizonURL returns the network for a bot that uses the horizonURL, reusing the clients of the server when it is a known horizon
+func (s *APIServer) networkForHorizonURL(horizonURL string) *botNetwork {
+	horizonURL = strings.TrimSuffix(horizonURL, "/")
+	switch horizonURL {
+	case "", s.config.HorizonTestnetURL:
+		return s.testnet()
+	case s.config.HorizonPubnetURL:
+		return &botNetwork{
+			name:       model2.NetworkPubnet,
+			horizonURL: s.config.HorizonPubnetURL,
+			network:    build.Network{Passphrase: s.config.PubnetPassphrase},
+			testnet:    false,
+			api:        s.apiPubNet,
+			apiOld:     s.apiPubNetOld,
+		}
+	}
+
+	// a custom horizon is on the test network if the URL says so, consistent with how the trade command selects the network
+	testnet := utils.ParseNetwork(horizonURL) == build.TestNetwork
+	passphrase := s.config.PubnetPassphrase
+	if testnet {
+		passphrase = s.config.TestnetPassphrase
+	}
+	return &botNetwork{
+		name:       model2.NetworkCustom,
+		horizonURL: horizonURL,
+		network:    build.Network{Passphrase: passphrase},
+		testnet:    testnet,
+		api: &horizonclient.Client{
+			HorizonURL: horizonURL,
+			HTTP:       s.horizonHTTP,
+		},
+		apiOld: &horizon.Client{
+			URL:  horizonURL,
+			HTTP: http.DefaultClient,
+		},
+	}
+}
+
+// networkForBot reads the trader config of the bot to find the network it runs against
+func (s *APIServer) networkForBot(botName string, strategy string) (*botNetwork, error) {
+	filenamePair := model2.GetBotFilenames(botName, strategy)
+	traderFilePath := fmt.Sprintf("%s/%s", s.configsDir, filenamePair.Trader)
+	var botConfig trader.BotConfig
+	e := config.Read(traderFilePath, &botConfig)
+	if e != nil {
+		return nil, fmt.Errorf("cannot read bot config at path '%s': %s", traderFilePath, e)
+	}
+	return s.networkForHorizonURL(botConfig.HorizonURL), nil
+}
+
+// isMainnetConfirmed returns true if the action is not on the main network or if the request explicitly confirms the action
+func isMainnetConfirmed(r *http.Request, n *botNetwork) bool {
+	return n.isTestnet() || r.URL.Query().Get(confirmMainnetParam) == "true"
+}
+
+// writeMainnetConfirmationRequired rejects an unconfirmed action on the main network so the user can review it before retrying
+func (s *APIServer) writeMainnetConfirmationRequired(w http.ResponseWriter, botName string, action string, n *botNetwork) {
+	s.writeErrorJsonWithStatus(w, http.StatusPreconditionRequired, fmt.Sprintf("bot '%s' runs on the main network (%s), set the query param '%s=true' to confirm the '%s' action",
+		botName, n.horizonURL, confirmMainnetParam, action))
+}
@@ -382,11 +382,12 @@ func loadOptionsMetadata() (metadata, error) {
 			currencylayer("ZMK", "Zambian Kwacha (pre-2013)").
 			currencylayer("ZMW", "Zambian Kwacha").
 			currencylayer("ZWL", "Zimbabwean Dollar"))).
-		option("fixed", "Fixed Value", text("1.0"))
+		option("fixed", "Fixed Value", text("1.0")).
+		option("ramp", "Price Ramp", text("0.10/0.20/2020-06-01T00:00:00Z/2020-06-30T00:00:00Z"))
 	mdata := dropdown(builder)
 	return mdata, nil
 }
 
 func (s *APIServer) optionsMetadata(w http.ResponseWriter, r *http.Request) {
-	s.writeJsonWithLog(w, s.cachedOptionsMetadata, false)
+	s.writeJsonWithLog(w, withCustomPriceFeeds(s.cachedOptionsMetadata), false)
 }
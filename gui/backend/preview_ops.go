@@ -0,0 +1,17 @@
+package backend
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// previewOps returns the ops the next update cycle of a running bot would produce if it ran now, without submitting them
+func (s *APIServer) previewOps(w http.ResponseWriter, r *http.Request) {
+	botName, e := s.parseBotName(r)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error parsing bot name in previewOps: %s\n", e))
+		return
+	}
+
+	s.writeIPCCommandResponse(w, botName, "previewOps")
+}
@@ -0,0 +1,263 @@
+package backend
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// priceHistoryResolutions are the resolutions supported by the trade aggregations endpoint of horizon
+var priceHistoryResolutions = map[string]time.Duration{
+	"1m":  time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"1h":  time.Hour,
+	"1d":  24 * time.Hour,
+	"1w":  7 * 24 * time.Hour,
+}
+
+// maxPriceHistorySamples bounds the number of samples in a response, which is enough for a day of 1m samples
+const maxPriceHistorySamples = 1500
+
+// priceHistoryPageLimit is the max number of records that horizon returns in a page of trade aggregations
+const priceHistoryPageLimit = 200
+
+// priceHistoryCacheTTL is how long a response is served from the cache, so the chart of every open GUI tab does not hit horizon
+const priceHistoryCacheTTL = 30 * time.Second
+
+// priceHistory is the response of the getPriceHistory endpoint, prices are in units of the quote asset and samples are sorted by time.
+// Samples are only included for intervals that had trades on SDEX.
+type priceHistory struct {
+	BaseAsset  string        `json:"base_asset"`
+	QuoteAsset string        `json:"quote_asset"`
+	Resolution string        `json:"resolution"`
+	Lookback   string        `json:"lookback"`
+	FetchedAt  string        `json:"fetched_at"`
+	Samples    []priceSample `json:"samples"`
+}
+
+// priceSample is the OHLC of the trades in an interval that starts at TimestampMillis
+type priceSample struct {
+	TimestampMillis int64   `json:"timestamp_millis"`
+	Open            float64 `json:"open"`
+	High            float64 `json:"high"`
+	Low             float64 `json:"low"`
+	Close           float64 `json:"close"`
+	BaseVolume      float64 `json:"base_volume"`
+	QuoteVolume     float64 `json:"quote_volume"`
+	TradeCount      int64   `json:"trade_count"`
+}
+
+// priceHistoryCache keeps recent responses keyed by the horizon, trading pair, resolution and lookback
+type priceHistoryCache struct {
+	mutex   *sync.Mutex
+	entries map[string]*priceHistoryCacheEntry
+}
+
+type priceHistoryCacheEntry struct {
+	fetchedAt time.Time
+	history   *priceHistory
+}
+
+func makePriceHistoryCache() *priceHistoryCache {
+	return &priceHistoryCache{
+		mutex:   &sync.Mutex{},
+		entries: map[string]*priceHistoryCacheEntry{},
+	}
+}
+
+func (c *priceHistoryCache) get(key string) *priceHistory {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Since(entry.fetchedAt) > priceHistoryCacheTTL {
+		return nil
+	}
+	return entry.history
+}
+
+func (c *priceHistoryCache) set(key string, history *priceHistory) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// evict expired entries so the cache does not grow with every combination of params that was requested
+	for k, entry := range c.entries {
+		if time.Since(entry.fetchedAt) > priceHistoryCacheTTL {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = &priceHistoryCacheEntry{
+		fetchedAt: time.Now(),
+		history:   history,
+	}
+}
+
+// getPriceHistory serves OHLC samples of the SDEX trading pair of a bot, sampled from the trade aggregations of horizon.
+// It takes the query params botName, resolution (1m, 5m, 15m, 1h, 1d or 1w; defaults to 1m) and lookback (such as 24h or 7d; defaults to 24h).
+func (s *APIServer) getPriceHistory(w http.ResponseWriter, r *http.Request) {
+	botName := r.URL.Query().Get("botName")
+	if botName == "" {
+		s.writeErrorJsonWithStatus(w, http.StatusBadRequest, "need to specify the botName query parameter in getPriceHistory\n")
+		return
+	}
+	resolutionString := r.URL.Query().Get("resolution")
+	if resolutionString == "" {
+		resolutionString = "1m"
+	}
+	resolution, ok := priceHistoryResolutions[resolutionString]
+	if !ok {
+		s.writeErrorJsonWithStatus(w, http.StatusBadRequest, fmt.Sprintf("invalid resolution '%s', needs to be one of 1m, 5m, 15m, 1h, 1d or 1w\n", resolutionString))
+		return
+	}
+	lookbackString := r.URL.Query().Get("lookback")
+	if lookbackString == "" {
+		lookbackString = "24h"
+	}
+	lookback, e := parseLookback(lookbackString)
+	if e != nil {
+		s.writeErrorJsonWithStatus(w, http.StatusBadRequest, fmt.Sprintf("invalid lookback '%s': %s\n", lookbackString, e))
+		return
+	}
+	if lookback/resolution > maxPriceHistorySamples {
+		s.writeErrorJsonWithStatus(w, http.StatusBadRequest, fmt.Sprintf("lookback '%s' at resolution '%s' exceeds the max of %d samples, use a larger resolution\n", lookbackString, resolutionString, maxPriceHistorySamples))
+		return
+	}
+
+	botConfig, e := s.loadBotConfig(botName)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("unable to load bot config for bot '%s': %s\n", botName, e))
+		return
+	}
+	if !botConfig.IsTradingSdex() {
+		s.writeErrorJsonWithStatus(w, http.StatusBadRequest, fmt.Sprintf("bot '%s' does not trade on SDEX, price history is only available for SDEX trading pairs\n", botName))
+		return
+	}
+	n := s.networkForHorizonURL(botConfig.HorizonURL)
+	assetBase := botConfig.AssetBase()
+	assetQuote := botConfig.AssetQuote()
+
+	cacheKey := strings.Join([]string{n.horizonURL, assetBase.Code, assetBase.Issuer, assetQuote.Code, assetQuote.Issuer, resolutionString, lookbackString}, "|")
+	history := s.priceHistoryCache.get(cacheKey)
+	if history == nil {
+		history, e = fetchPriceHistory(n.api, assetBase, assetQuote, resolution, lookback)
+		if e != nil {
+			s.writeErrorJson(w, fmt.Sprintf("unable to fetch price history for bot '%s': %s\n", botName, e))
+			return
+		}
+		history.Resolution = resolutionString
+		history.Lookback = lookbackString
+		s.priceHistoryCache.set(cacheKey, history)
+		log.Printf("fetched %d price history samples for bot '%s' (resolution=%s, lookback=%s)\n", len(history.Samples), botName, resolutionString, lookbackString)
+	}
+	s.writeJsonWithLog(w, history, false)
+}
+
+// parseLookback parses a duration, which additionally supports a number of days such as "7d"
+func parseLookback(lookback string) (time.Duration, error) {
+	var d time.Duration
+	if strings.HasSuffix(lookback, "d") {
+		days, e := strconv.Atoi(strings.TrimSuffix(lookback, "d"))
+		if e != nil {
+			return 0, fmt.Errorf("invalid number of days: %s", e)
+		}
+		d = time.Duration(days) * 24 * time.Hour
+	} else {
+		var e error
+		d, e = time.ParseDuration(lookback)
+		if e != nil {
+			return 0, e
+		}
+	}
+
+	if d <= 0 {
+		return 0, fmt.Errorf("needs to be positive")
+	}
+	return d, nil
+}
+
+// fetchPriceHistory pages through the trade aggregations of the trading pair between now and lookback ago
+func fetchPriceHistory(api *horizonclient.Client, assetBase hProtocol.Asset, assetQuote hProtocol.Asset, resolution time.Duration, lookback time.Duration) (*priceHistory, error) {
+	fetchedAt := time.Now()
+	// horizon aligns the buckets to multiples of the resolution since the unix epoch
+	endMillis := alignMillis(fetchedAt, resolution) + resolution.Nanoseconds()/int64(time.Millisecond)
+	startMillis := alignMillis(fetchedAt.Add(-lookback), resolution)
+
+	samples := []priceSample{}
+	for startMillis < endMillis {
+		page, e := api.TradeAggregations(horizonclient.TradeAggregationRequest{
+			StartTime:          time.Unix(0, startMillis*int64(time.Millisecond)),
+			EndTime:            time.Unix(0, endMillis*int64(time.Millisecond)),
+			Resolution:         resolution,
+			BaseAssetType:      horizonclient.AssetType(assetBase.Type),
+			BaseAssetCode:      assetBase.Code,
+			BaseAssetIssuer:    assetBase.Issuer,
+			CounterAssetType:   horizonclient.AssetType(assetQuote.Type),
+			CounterAssetCode:   assetQuote.Code,
+			CounterAssetIssuer: assetQuote.Issuer,
+			Order:              horizonclient.OrderAsc,
+			Limit:              priceHistoryPageLimit,
+		})
+		if e != nil {
+			return nil, fmt.Errorf("error fetching trade aggregations: %s", e)
+		}
+
+		records := page.Embedded.Records
+		for _, record := range records {
+			sample, e := tradeAggregation2PriceSample(record)
+			if e != nil {
+				return nil, fmt.Errorf("unable to convert trade aggregation (timestamp=%d): %s", record.Timestamp, e)
+			}
+			samples = append(samples, *sample)
+		}
+		if len(records) < priceHistoryPageLimit {
+			break
+		}
+		startMillis = records[len(records)-1].Timestamp + resolution.Nanoseconds()/int64(time.Millisecond)
+	}
+
+	return &priceHistory{
+		BaseAsset:  utils.Asset2CodeString(assetBase),
+		QuoteAsset: utils.Asset2CodeString(assetQuote),
+		FetchedAt:  fetchedAt.UTC().Format(time.RFC3339),
+		Samples:    samples,
+	}, nil
+}
+
+// alignMillis returns the start of the interval of size resolution that contains t, in millis since the unix epoch
+func alignMillis(t time.Time, resolution time.Duration) int64 {
+	millis := t.UnixNano() / int64(time.Millisecond)
+	resolutionMillis := resolution.Nanoseconds() / int64(time.Millisecond)
+	return millis - millis%resolutionMillis
+}
+
+func tradeAggregation2PriceSample(record hProtocol.TradeAggregation) (*priceSample, error) {
+	values := []string{record.Open, record.High, record.Low, record.Close, record.BaseVolume, record.CounterVolume}
+	floats := []float64{}
+	for _, v := range values {
+		f, e := strconv.ParseFloat(v, 64)
+		if e != nil {
+			return nil, fmt.Errorf("unable to parse value '%s': %s", v, e)
+		}
+		floats = append(floats, f)
+	}
+
+	return &priceSample{
+		TimestampMillis: record.Timestamp,
+		Open:            floats[0],
+		High:            floats[1],
+		Low:             floats[2],
+		Close:           floats[3],
+		BaseVolume:      floats[4],
+		QuoteVolume:     floats[5],
+		TradeCount:      record.TradeCount,
+	}, nil
+}
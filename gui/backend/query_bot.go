@@ -0,0 +1,20 @@
+package backend
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// makeQueryBotHandler returns a handler that forwards the IPC command to the query server of a running bot so its state can be
+// introspected without going to Horizon
+func (s *APIServer) makeQueryBotHandler(command string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		botName, e := s.parseBotName(r)
+		if e != nil {
+			s.writeErrorJson(w, fmt.Sprintf("error parsing bot name in %s: %s\n", command, e))
+			return
+		}
+
+		s.writeIPCCommandResponse(w, botName, command)
+	}
+}
@@ -0,0 +1,52 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+type approveRebalanceRequest struct {
+	BotName    string `json:"bot_name"`
+	TransferID string `json:"transfer_id"`
+	Approve    bool   `json:"approve"` // false rejects the transfer
+}
+
+// approveRebalance approves or rejects a pending transfer of a bot that rebalances its assets in approval mode
+func (s *APIServer) approveRebalance(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, e := ioutil.ReadAll(r.Body)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error when reading request input: %s\n", e))
+		return
+	}
+	var req approveRebalanceRequest
+	e = json.Unmarshal(bodyBytes, &req)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error unmarshaling json: %s; bodyString = %s", e, string(bodyBytes)))
+		return
+	}
+	if req.BotName == "" || req.TransferID == "" {
+		s.writeErrorJson(w, "need to specify the bot_name and the transfer_id in approveRebalance")
+		return
+	}
+
+	// an approved transfer moves funds without further confirmation
+	if req.Approve {
+		n, e := s.networkForBot(req.BotName, buysell)
+		if e != nil {
+			s.writeErrorJson(w, fmt.Sprintf("error loading network of bot '%s': %s\n", req.BotName, e))
+			return
+		}
+		if !isMainnetConfirmed(r, n) {
+			s.writeMainnetConfirmationRequired(w, req.BotName, "approveRebalance", n)
+			return
+		}
+	}
+
+	command := fmt.Sprintf("rejectRebalance %s", req.TransferID)
+	if req.Approve {
+		command = fmt.Sprintf("approveRebalance %s", req.TransferID)
+	}
+	s.writeIPCCommandResponse(w, req.BotName, command)
+}
@@ -4,26 +4,68 @@ import (
 	"net/http"
 
 	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
 )
 
 // SetRoutes
 func SetRoutes(r *chi.Mux, s *APIServer) {
 	r.Route("/api/v1", func(r chi.Router) {
+		// gzip is the outer middleware so it compresses the response after it is paginated and its fields are selected
+		r.Use(middleware.Compress(5, "application/json"))
+		r.Use(jsonResponseTransformer)
+
 		r.Get("/version", http.HandlerFunc(s.version))
+		r.Get("/serverConfig", http.HandlerFunc(s.serverConfig))
 		r.Get("/listBots", http.HandlerFunc(s.listBots))
 		r.Get("/autogenerate", http.HandlerFunc(s.autogenerateBot))
 		r.Get("/genBotName", http.HandlerFunc(s.generateBotName))
 		r.Get("/getNewBotConfig", http.HandlerFunc(s.getNewBotConfig))
 		r.Get("/newSecretKey", http.HandlerFunc(s.newSecretKey))
 		r.Get("/optionsMetadata", http.HandlerFunc(s.optionsMetadata))
+		r.Get("/listConfigTemplates", http.HandlerFunc(s.listConfigTemplates))
+		r.Get("/getCircuitBreaker", http.HandlerFunc(s.getCircuitBreaker))
+		r.Get("/botHealth", http.HandlerFunc(s.botHealth))
+		r.Get("/getPriceHistory", http.HandlerFunc(s.getPriceHistory))
+		r.Get("/killSwitch", http.HandlerFunc(s.getKillSwitch))
+		r.Get("/listCustomPriceFeeds", http.HandlerFunc(s.listCustomPriceFeeds))
 
 		r.Post("/start", http.HandlerFunc(s.startBot))
 		r.Post("/stop", http.HandlerFunc(s.stopBot))
 		r.Post("/deleteBot", http.HandlerFunc(s.deleteBot))
+		r.Post("/killSwitch", http.HandlerFunc(s.setKillSwitch))
+		r.Post("/requestConfirmation", http.HandlerFunc(s.requestConfirmation))
+		r.Post("/bots/batch", http.HandlerFunc(s.botsBatch))
 		r.Post("/getState", http.HandlerFunc(s.getBotState))
 		r.Post("/getBotInfo", http.HandlerFunc(s.getBotInfo))
+		r.Post("/getMirrorSnapshot", http.HandlerFunc(s.getMirrorSnapshot))
+		r.Post("/getBotAnnotations", http.HandlerFunc(s.getBotAnnotations))
+		r.Post("/getBotOffers", s.makeQueryBotHandler("getOffers"))
+		r.Post("/getBotTrades", s.makeQueryBotHandler("getTrades"))
+		r.Post("/getRunningBotConfig", s.makeQueryBotHandler("getConfig"))
+		r.Post("/getBotUpdateTiming", s.makeQueryBotHandler("getUpdateTiming"))
+		r.Post("/getBotTxFailures", s.makeQueryBotHandler("getTxFailures"))
+		r.Post("/getBotCapacity", s.makeQueryBotHandler("getCapacity"))
+		r.Post("/getBotRunHistory", http.HandlerFunc(s.getBotRunHistory))
+		r.Post("/getPnL", s.makeQueryBotHandler("getPnL"))
+		r.Post("/getBotRebalance", s.makeQueryBotHandler("getRebalance"))
+		r.Post("/approveRebalance", http.HandlerFunc(s.approveRebalance))
+		r.Post("/previewOps", http.HandlerFunc(s.previewOps))
 		r.Post("/getBotConfig", http.HandlerFunc(s.getBotConfig))
 		r.Post("/fetchPrice", http.HandlerFunc(s.fetchPrice))
+		r.Post("/registerCustomPriceFeed", http.HandlerFunc(s.registerCustomPriceFeed))
+		r.Post("/removeCustomPriceFeed", http.HandlerFunc(s.removeCustomPriceFeed))
 		r.Post("/upsertBotConfig", http.HandlerFunc(s.upsertBotConfig))
+		r.Post("/cloneBot", http.HandlerFunc(s.cloneBot))
+		r.Post("/exportBot", http.HandlerFunc(s.exportBot))
+		r.Post("/importBot", http.HandlerFunc(s.importBot))
+		r.Post("/resumeCircuitBreaker", http.HandlerFunc(s.resumeCircuitBreaker))
+		r.Post("/getBotSchedule", http.HandlerFunc(s.getBotSchedule))
+		r.Post("/setBotSchedule", http.HandlerFunc(s.setBotSchedule))
+		r.Post("/getTrustlines", http.HandlerFunc(s.getTrustlines))
+		r.Post("/addTrustlines", http.HandlerFunc(s.addTrustlines))
+		r.Post("/removeTrustlines", http.HandlerFunc(s.removeTrustlines))
+		r.Post("/testnet/fundAccount", http.HandlerFunc(s.fundTestnetAccount))
+		r.Post("/testnet/issueAsset", http.HandlerFunc(s.issueTestAsset))
+		r.Post("/testnet/setupBot", http.HandlerFunc(s.setupTestnetBot))
 	})
 }
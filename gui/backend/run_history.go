@@ -0,0 +1,35 @@
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+
+	"github.com/stellar/kelp/gui/model2"
+	"github.com/stellar/kelp/trader"
+)
+
+// runHistoryResponse is the response of the getBotRunHistory endpoint, snapshots are sorted by time so they can be charted
+type runHistoryResponse struct {
+	BotName   string                 `json:"bot_name"`
+	Snapshots []trader.CycleSnapshot `json:"snapshots"`
+}
+
+// getBotRunHistory reads the run history file of the bot instead of querying the bot, so the history is available when the bot is stopped
+func (s *APIServer) getBotRunHistory(w http.ResponseWriter, r *http.Request) {
+	botName, e := s.parseBotName(r)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error parsing bot name in getBotRunHistory: %s\n", e))
+		return
+	}
+
+	snapshots, e := trader.ReadRunHistory(filepath.Join(s.dataDir, model2.GetRunHistoryFilename(botName)))
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error reading run history of bot '%s': %s\n", botName, e))
+		return
+	}
+	s.writeJson(w, runHistoryResponse{
+		BotName:   botName,
+		Snapshots: snapshots,
+	})
+}
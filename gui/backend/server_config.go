@@ -0,0 +1,49 @@
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ServerConfig contains the infrastructure that the backend operations of the server use, so self-hosted deployments can point the server
+// at their own horizon, ccxt-rest and friendbot instances
+type ServerConfig struct {
+	HorizonTestnetURL string `json:"horizon_testnet_url"`
+	HorizonPubnetURL  string `json:"horizon_pubnet_url"`
+	CcxtRestURL       string `json:"ccxt_rest_url"` // empty when the bots use the CCXT_REST_URL of their config or the default URL
+	TestnetPassphrase string `json:"testnet_passphrase"`
+	PubnetPassphrase  string `json:"pubnet_passphrase"`
+	FriendbotURL      string `json:"friendbot_url"`
+	// set by EnableActionConfirmation so the GUI knows how to confirm destructive actions, empty when they do not need to be confirmed
+	ConfirmationPolicy string `json:"confirmation_policy"`
+}
+
+// String is the stringer method
+func (c ServerConfig) String() string {
+	return fmt.Sprintf("ServerConfig[horizonTestnetURL=%s, horizonPubnetURL=%s, ccxtRestURL=%s, testnetPassphrase=%s, pubnetPassphrase=%s, friendbotURL=%s, confirmationPolicy=%s]",
+		c.HorizonTestnetURL, c.HorizonPubnetURL, c.CcxtRestURL, c.TestnetPassphrase, c.PubnetPassphrase, c.FriendbotURL, c.ConfirmationPolicy)
+}
+
+// normalize trims the trailing slashes of the URLs and checks that the required settings are set
+func (c ServerConfig) normalize() (ServerConfig, error) {
+	c.HorizonTestnetURL = strings.TrimSuffix(c.HorizonTestnetURL, "/")
+	c.HorizonPubnetURL = strings.TrimSuffix(c.HorizonPubnetURL, "/")
+	c.CcxtRestURL = strings.TrimSuffix(c.CcxtRestURL, "/")
+	c.FriendbotURL = strings.TrimSuffix(c.FriendbotURL, "/")
+
+	if c.HorizonTestnetURL == "" || c.HorizonPubnetURL == "" {
+		return c, fmt.Errorf("need to specify the horizon URLs of both the test network and the public network")
+	}
+	if c.TestnetPassphrase == "" || c.PubnetPassphrase == "" {
+		return c, fmt.Errorf("need to specify the network passphrases of both the test network and the public network")
+	}
+	if c.TestnetPassphrase == c.PubnetPassphrase {
+		return c, fmt.Errorf("the network passphrases of the test network and the public network need to be different")
+	}
+	return c, nil
+}
+
+func (s *APIServer) serverConfig(w http.ResponseWriter, r *http.Request) {
+	s.writeJson(w, s.config)
+}
@@ -20,6 +20,47 @@ func (s *APIServer) startBot(w http.ResponseWriter, r *http.Request) {
 	}
 
 	botName := string(botNameBytes)
+	if s.isCircuitBreakerTripped() {
+		s.writeErrorJsonWithStatus(w, http.StatusLocked, fmt.Sprintf("cannot start bot '%s' because the circuit breaker has been tripped, review and resume the circuit breaker first", botName))
+		return
+	}
+	isKilled, e := s.isKillSwitchActive()
+	if e != nil {
+		s.writeError(w, fmt.Sprintf("error checking kill switch: %s\n", e))
+		return
+	}
+	if isKilled {
+		s.writeErrorJsonWithStatus(w, http.StatusLocked, fmt.Sprintf("cannot start bot '%s' because the kill switch is active, deactivate the kill switch first", botName))
+		return
+	}
+	isOutside, e := s.isOutsideTradingHours(botName)
+	if e != nil {
+		s.writeError(w, fmt.Sprintf("error checking trading hours of bot: %s\n", e))
+		return
+	}
+	if isOutside {
+		s.writeErrorJsonWithStatus(w, http.StatusLocked, fmt.Sprintf("cannot start bot '%s' outside of its trading hours, change its schedule with setBotSchedule to start it now", botName))
+		return
+	}
+	n, e := s.networkForBot(botName, "buysell")
+	if e != nil {
+		s.writeError(w, fmt.Sprintf("error loading network of bot: %s\n", e))
+		return
+	}
+	if !isMainnetConfirmed(r, n) {
+		s.writeMainnetConfirmationRequired(w, botName, "start", n)
+		return
+	}
+	if !n.isTestnet() && !s.checkActionConfirmed(w, r, "start", botName) {
+		return
+	}
+	e = s.checkTrustlinesReady(botName)
+	if e != nil {
+		s.writeErrorJsonWithStatus(w, http.StatusPreconditionFailed, fmt.Sprintf("cannot start bot: %s", e))
+		return
+	}
+	log.Printf("starting bot '%s' on network: %s\n", botName, n)
+
 	e = s.doStartBot(botName, "buysell", nil, nil)
 	if e != nil {
 		s.writeError(w, fmt.Sprintf("error starting bot: %s\n", e))
@@ -38,16 +79,23 @@ func (s *APIServer) startBot(w http.ResponseWriter, r *http.Request) {
 func (s *APIServer) doStartBot(botName string, strategy string, iterations *uint8, maybeFinishCallback func()) error {
 	filenamePair := model2.GetBotFilenames(botName, strategy)
 	logPrefix := model2.GetLogPrefix(botName, strategy)
-	command := fmt.Sprintf("trade -c %s/%s -s %s -f %s/%s -l %s/%s --with-ipc", s.configsDir, filenamePair.Trader, strategy, s.configsDir, filenamePair.Strategy, s.logsDir, logPrefix)
+	// the data dir is not listed with the configs so the ledger, offer journal and run history files do not show up as bots
+	_, e := s.kos.Blocking("mkdir", "mkdir -p "+s.dataDir)
+	if e != nil {
+		return fmt.Errorf("error running mkdir command for dataDir: %s", e)
+	}
+	command := fmt.Sprintf("trade -c %s/%s -s %s -f %s/%s -l %s/%s --pnlFile %s/%s --offersJournalFile %s/%s --runHistoryFile %s/%s --with-ipc", s.configsDir, filenamePair.Trader, strategy, s.configsDir, filenamePair.Strategy, s.logsDir, logPrefix, s.dataDir, model2.GetPnLFilename(botName), s.dataDir, model2.GetOffersJournalFilename(botName), s.dataDir, model2.GetRunHistoryFilename(botName))
+	command = fmt.Sprintf("%s --shutdownGracePeriod %d --killSwitchFile %s", command, int(s.shutdownGracePeriod.Seconds()), s.killSwitchFilePath())
+	command = fmt.Sprintf("%s --customPriceFeedsFile %s", command, s.customPriceFeedsFilePath())
 	if iterations != nil {
 		command = fmt.Sprintf("%s --iter %d", command, *iterations)
 	}
-	if s.ccxtRestUrl != "" {
-		command = fmt.Sprintf("%s --ccxt-rest-url %s", command, s.ccxtRestUrl)
+	if s.config.CcxtRestURL != "" {
+		command = fmt.Sprintf("%s --ccxt-rest-url %s", command, s.config.CcxtRestURL)
 	}
 	log.Printf("run command for bot '%s': %s\n", botName, command)
 
-	p, e := s.runKelpCommandBackground(botName, command)
+	p, e := s.runBotCommandBackground(botName, command)
 	if e != nil {
 		return fmt.Errorf("could not start bot %s: %s", botName, e)
 	}
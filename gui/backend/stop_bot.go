@@ -4,10 +4,15 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/stellar/kelp/support/kelpos"
 )
 
+// shutdownKillMargin is how much longer than the shutdown grace period of a bot the server waits before it kills the bot, so the bot can
+// exit on its own once the grace period is over
+const shutdownKillMargin = 5 * time.Second
+
 func (s *APIServer) stopBot(w http.ResponseWriter, r *http.Request) {
 	botName, e := s.parseBotName(r)
 	if e != nil {
@@ -29,12 +34,14 @@ func (s *APIServer) doStopBot(botName string) error {
 		return fmt.Errorf("error advancing bot state: %s\n", e)
 	}
 
-	e = s.kos.Stop(botName)
+	// the bot deletes its offers, cancels its backing orders and saves its state in its shutdown hooks
+	e = s.kos.StopGracefully(botName, s.shutdownGracePeriod+shutdownKillMargin)
 	if e != nil {
-		return fmt.Errorf("error when killing bot %s: %s\n", botName, e)
+		return fmt.Errorf("error when stopping bot %s: %s\n", botName, e)
 	}
 	log.Printf("stopped bot '%s'\n", botName)
 
+	// delete any offers that are left in case the shutdown hooks did not finish
 	var numIterations uint8 = 1
 	e = s.doStartBot(botName, "delete", &numIterations, func() {
 		s.deleteFinishCallback(botName)
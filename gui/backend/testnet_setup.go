@@ -0,0 +1,307 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/stellar/go/build"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/gui/model2"
+	"github.com/stellar/kelp/support/kelpos"
+	"github.com/stellar/kelp/support/toml"
+	"github.com/stellar/kelp/trader"
+)
+
+// defaultTestAssetAmount is the amount of a test asset issued to each recipient when the request does not specify one
+const defaultTestAssetAmount = "10000"
+
+type fundTestnetAccountRequest struct {
+	Address string `json:"address"`
+}
+
+type fundTestnetAccountResponse struct {
+	Address string `json:"address"`
+	Funded  bool   `json:"funded"` // false when the account already existed
+}
+
+type issueTestAssetRequest struct {
+	AssetCode      string   `json:"asset_code"`
+	Amount         string   `json:"amount"`
+	RecipientSeeds []string `json:"recipient_seeds"`
+}
+
+type setupTestnetBotRequest struct {
+	BotName string `json:"bot_name"`
+	Amount  string `json:"amount"`
+}
+
+// testAssetRecipient is an account that trusts a test asset and received the issued amount
+type testAssetRecipient struct {
+	Address string `json:"address"`
+	TxHash  string `json:"tx_hash"`
+}
+
+// issueTestAssetResponse describes the test assets issued from a generated issuer account. The issuer seed is returned so more of the
+// assets can be issued later, which is only acceptable because the account only exists on the test network.
+type issueTestAssetResponse struct {
+	AssetCodes    []string             `json:"asset_codes"`
+	IssuerAddress string               `json:"issuer_address"`
+	IssuerSeed    string               `json:"issuer_seed"`
+	Recipients    []testAssetRecipient `json:"recipients"`
+}
+
+// fundTestnetAccount funds an account using friendbot on the test network
+func (s *APIServer) fundTestnetAccount(w http.ResponseWriter, r *http.Request) {
+	var req fundTestnetAccountRequest
+	e := readJsonRequest(r, &req)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error reading fundTestnetAccount request: %s", e))
+		return
+	}
+	if _, e = keypair.Parse(req.Address); e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("invalid address '%s': %s", req.Address, e))
+		return
+	}
+
+	funded, e := s.fundIfMissing(req.Address, "fundTestnetAccount")
+	if e != nil {
+		s.writeErrorJson(w, e.Error())
+		return
+	}
+	s.writeJson(w, fundTestnetAccountResponse{
+		Address: req.Address,
+		Funded:  funded,
+	})
+}
+
+// issueTestAsset issues a test asset from a generated issuer account to each recipient, adding their trustlines for it
+func (s *APIServer) issueTestAsset(w http.ResponseWriter, r *http.Request) {
+	var req issueTestAssetRequest
+	e := readJsonRequest(r, &req)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error reading issueTestAsset request: %s", e))
+		return
+	}
+	if req.AssetCode == "" {
+		s.writeErrorJson(w, "asset_code is required to issue a test asset")
+		return
+	}
+
+	resp, e := s.issueTestAssets([]string{req.AssetCode}, req.Amount, req.RecipientSeeds)
+	if e != nil {
+		s.writeErrorJson(w, e.Error())
+		return
+	}
+	s.writeJson(w, resp)
+}
+
+// setupTestnetBot sets up a fully functional market for a stopped bot on the test network: it funds the accounts of the bot, issues every
+// non-native asset of the bot from a generated issuer to the trading account and updates the trader config to use the new issuer
+func (s *APIServer) setupTestnetBot(w http.ResponseWriter, r *http.Request) {
+	var req setupTestnetBotRequest
+	e := readJsonRequest(r, &req)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error reading setupTestnetBot request: %s", e))
+		return
+	}
+
+	botState, e := s.kos.QueryBotState(req.BotName)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error getting bot state for bot '%s': %s", req.BotName, e))
+		return
+	}
+	if botState != kelpos.BotStateStopped {
+		s.writeErrorJson(w, fmt.Sprintf("bot state needs to be '%s' when setting up a testnet bot, but was '%s'\n", kelpos.BotStateStopped, botState))
+		return
+	}
+
+	botConfig, e := s.loadBotConfig(req.BotName)
+	if e != nil {
+		s.writeErrorJson(w, e.Error())
+		return
+	}
+	n := s.networkForHorizonURL(botConfig.HorizonURL)
+	if !n.isTestnet() {
+		s.writeErrorJson(w, fmt.Sprintf("bot '%s' does not run on the test network (%s), test assets can only be issued on the test network", req.BotName, n.horizonURL))
+		return
+	}
+
+	if botConfig.SourceSecretSeed != "" {
+		_, e = s.fundIfMissing(botConfig.SourceAccount(), req.BotName)
+		if e != nil {
+			s.writeErrorJson(w, e.Error())
+			return
+		}
+	}
+
+	assetCodes := []string{}
+	for _, a := range []hProtocol.Asset{botConfig.AssetBase(), botConfig.AssetQuote()} {
+		if a.Type != "native" {
+			assetCodes = append(assetCodes, a.Code)
+		}
+	}
+	if len(assetCodes) == 0 {
+		s.writeErrorJson(w, fmt.Sprintf("bot '%s' only trades the native asset, there are no assets to issue", req.BotName))
+		return
+	}
+	if len(assetCodes) == 2 && assetCodes[0] == assetCodes[1] {
+		// both assets would be the same asset once they are issued by the same issuer
+		s.writeErrorJson(w, fmt.Sprintf("bot '%s' trades two assets with the same code (%s), which cannot be issued by a single test issuer", req.BotName, assetCodes[0]))
+		return
+	}
+
+	resp, e := s.issueTestAssets(assetCodes, req.Amount, []string{botConfig.TradingSecretSeed})
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("unable to issue test assets for bot '%s': %s", req.BotName, e))
+		return
+	}
+
+	e = s.writeTraderIssuer(req.BotName, botConfig, resp.IssuerAddress)
+	if e != nil {
+		s.writeErrorJson(w, e.Error())
+		return
+	}
+	s.writeJson(w, resp)
+}
+
+// issueTestAssets generates and funds an issuer account that issues the amount of each asset code to each recipient, which are funded if needed
+func (s *APIServer) issueTestAssets(assetCodes []string, amount string, recipientSeeds []string) (*issueTestAssetResponse, error) {
+	if amount == "" {
+		amount = defaultTestAssetAmount
+	}
+	if a, e := strconv.ParseFloat(amount, 64); e != nil || a <= 0 {
+		return nil, fmt.Errorf("amount needs to be a positive number: %s", amount)
+	}
+	if len(recipientSeeds) == 0 {
+		return nil, fmt.Errorf("need at least one recipient to issue test assets to")
+	}
+
+	issuer, e := keypair.Random()
+	if e != nil {
+		return nil, fmt.Errorf("error generating issuer keypair: %s", e)
+	}
+	_, e = s.fundIfMissing(issuer.Address(), "issuer")
+	if e != nil {
+		return nil, e
+	}
+
+	recipients := []testAssetRecipient{}
+	for _, seed := range recipientSeeds {
+		kp, e := keypair.Parse(seed)
+		if e != nil {
+			return nil, fmt.Errorf("invalid recipient seed: %s", e)
+		}
+		_, e = s.fundIfMissing(kp.Address(), "recipient")
+		if e != nil {
+			return nil, e
+		}
+
+		ops := []build.TransactionMutator{}
+		for _, code := range assetCodes {
+			ops = append(ops, build.Trust(code, issuer.Address()))
+			ops = append(ops, build.Payment(
+				build.Destination{AddressOrSeed: kp.Address()},
+				build.CreditAmount{Code: code, Issuer: issuer.Address(), Amount: amount},
+				build.SourceAccount{AddressOrSeed: issuer.Address()},
+			))
+		}
+		txHash, e := s.submitTestnetTx(kp.Address(), []string{seed, issuer.Seed()}, ops)
+		if e != nil {
+			return nil, fmt.Errorf("error issuing test assets %v to account %s: %s", assetCodes, kp.Address(), e)
+		}
+		log.Printf("issued %s of test assets %v from issuer %s to account %s, tx hash: %s\n", amount, assetCodes, issuer.Address(), kp.Address(), txHash)
+		recipients = append(recipients, testAssetRecipient{
+			Address: kp.Address(),
+			TxHash:  txHash,
+		})
+	}
+
+	return &issueTestAssetResponse{
+		AssetCodes:    assetCodes,
+		IssuerAddress: issuer.Address(),
+		IssuerSeed:    issuer.Seed(),
+		Recipients:    recipients,
+	}, nil
+}
+
+// fundIfMissing funds the account using friendbot on the test network if it does not exist, returns true if it was funded
+func (s *APIServer) fundIfMissing(address string, label string) (bool, error) {
+	n := s.testnet()
+	_, e := n.api.AccountDetail(horizonclient.AccountRequest{AccountID: address})
+	if e == nil {
+		return false, nil
+	}
+
+	_, e = s.checkFundAccount(n, address, label)
+	if e != nil {
+		return false, fmt.Errorf("error funding account %s: %s", address, e)
+	}
+	return true, nil
+}
+
+// submitTestnetTx submits a transaction with the ops from the source account on the test network, signed by all the signers
+func (s *APIServer) submitTestnetTx(sourceAddress string, signers []string, ops []build.TransactionMutator) (string, error) {
+	n := s.testnet()
+	muts := []build.TransactionMutator{
+		build.SourceAccount{AddressOrSeed: sourceAddress},
+		build.AutoSequence{SequenceProvider: n.apiOld},
+		n.network,
+	}
+	txn, e := build.Transaction(append(muts, ops...)...)
+	if e != nil {
+		return "", fmt.Errorf("cannot create transaction: %s", e)
+	}
+
+	txnS, e := txn.Sign(signers...)
+	if e != nil {
+		return "", fmt.Errorf("cannot sign transaction: %s", e)
+	}
+
+	txn64, e := txnS.Base64()
+	if e != nil {
+		return "", fmt.Errorf("cannot convert transaction to base64: %s", e)
+	}
+
+	resp, e := n.apiOld.SubmitTransaction(txn64)
+	if e != nil {
+		return "", fmt.Errorf("error submitting transaction: %s", e)
+	}
+	return resp.Hash, nil
+}
+
+// writeTraderIssuer updates the trader config of the bot so its non-native assets are issued by the issuer
+func (s *APIServer) writeTraderIssuer(botName string, botConfig *trader.BotConfig, issuer string) error {
+	if botConfig.IssuerA != "" {
+		botConfig.IssuerA = issuer
+	}
+	if botConfig.IssuerB != "" {
+		botConfig.IssuerB = issuer
+	}
+
+	filenamePair := model2.GetBotFilenames(botName, buysell)
+	traderFilePath := fmt.Sprintf("%s/%s", s.configsDir, filenamePair.Trader)
+	log.Printf("updating issuer of test assets in trader config file: %s\n", traderFilePath)
+	e := toml.WriteFile(traderFilePath, botConfig)
+	if e != nil {
+		return fmt.Errorf("error writing trader botConfig toml file for bot '%s': %s", botName, e)
+	}
+	return nil
+}
+
+func readJsonRequest(r *http.Request, v interface{}) error {
+	bodyBytes, e := ioutil.ReadAll(r.Body)
+	if e != nil {
+		return fmt.Errorf("error reading request input: %s", e)
+	}
+	e = json.Unmarshal(bodyBytes, v)
+	if e != nil {
+		return fmt.Errorf("error unmarshaling json: %s; bodyString = %s", e, string(bodyBytes))
+	}
+	return nil
+}
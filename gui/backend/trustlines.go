@@ -0,0 +1,164 @@
+package backend
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/nikhilsaraf/go-tools/multithreading"
+	"github.com/stellar/go/build"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/plugins"
+	"github.com/stellar/kelp/trader"
+)
+
+// trustlinesResponse is the response of the trustline endpoints
+type trustlinesResponse struct {
+	TradingAccount string                    `json:"trading_account"`
+	TxHash         string                    `json:"tx_hash,omitempty"` // set when a transaction was submitted to change the trustlines
+	Trustlines     []plugins.TrustlineStatus `json:"trustlines"`
+	Ready          bool                      `json:"ready"` // true when all trustlines can be used for trading
+}
+
+func (s *APIServer) getTrustlines(w http.ResponseWriter, r *http.Request) {
+	botName, e := s.parseBotName(r)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error parsing bot name in getTrustlines: %s\n", e))
+		return
+	}
+
+	botConfig, e := s.loadBotConfig(botName)
+	if e != nil {
+		s.writeErrorJson(w, e.Error())
+		return
+	}
+	s.writeTrustlines(w, botName, botConfig, "")
+}
+
+func (s *APIServer) addTrustlines(w http.ResponseWriter, r *http.Request) {
+	s.changeTrustlines(w, r, "addTrustlines", func(statuses []plugins.TrustlineStatus, tradingAccount string) ([]build.TransactionMutator, error) {
+		return plugins.AddTrustlineOps(statuses, tradingAccount), nil
+	})
+}
+
+func (s *APIServer) removeTrustlines(w http.ResponseWriter, r *http.Request) {
+	s.changeTrustlines(w, r, "removeTrustlines", plugins.RemoveTrustlineOps)
+}
+
+// changeTrustlines submits the ops made by makeOps for the trustlines of the bot's trading account and responds with the resulting trustlines
+func (s *APIServer) changeTrustlines(
+	w http.ResponseWriter,
+	r *http.Request,
+	action string,
+	makeOps func(statuses []plugins.TrustlineStatus, tradingAccount string) ([]build.TransactionMutator, error),
+) {
+	botName, e := s.parseBotName(r)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error parsing bot name in %s: %s\n", action, e))
+		return
+	}
+
+	botConfig, e := s.loadBotConfig(botName)
+	if e != nil {
+		s.writeErrorJson(w, e.Error())
+		return
+	}
+	n := s.networkForHorizonURL(botConfig.HorizonURL)
+	if !isMainnetConfirmed(r, n) {
+		s.writeMainnetConfirmationRequired(w, botName, action, n)
+		return
+	}
+
+	statuses, e := plugins.LoadTrustlines(n.api, botConfig.TradingAccount(), []hProtocol.Asset{botConfig.AssetBase(), botConfig.AssetQuote()})
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("unable to load trustlines for bot '%s': %s\n", botName, e))
+		return
+	}
+	ops, e := makeOps(statuses, botConfig.TradingAccount())
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("unable to %s for bot '%s': %s\n", action, botName, e))
+		return
+	}
+	if len(ops) == 0 {
+		log.Printf("no trustline changes needed for %s for bot '%s'\n", action, botName)
+		s.writeTrustlines(w, botName, botConfig, "")
+		return
+	}
+
+	sdex := plugins.MakeSDEX(
+		n.api,
+		plugins.MakeIEIF(true),
+		nil,
+		botConfig.SourceSecretSeed,
+		botConfig.TradingSecretSeed,
+		botConfig.SourceAccount(),
+		botConfig.TradingAccount(),
+		n.network,
+		multithreading.MakeThreadTracker(),
+		-1, // not needed here
+		-1, // not needed here
+		false,
+		nil, // not needed here
+		map[model.Asset]hProtocol.Asset{},
+		plugins.SdexFixedFeeFn(0),
+	)
+	txHash, e := plugins.SubmitTrustlineOps(sdex, ops)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("unable to %s for bot '%s': %s\n", action, botName, e))
+		return
+	}
+	log.Printf("submitted %d trustline ops for %s for bot '%s', tx hash: %s\n", len(ops), action, botName, txHash)
+	s.writeTrustlines(w, botName, botConfig, txHash)
+}
+
+func (s *APIServer) writeTrustlines(w http.ResponseWriter, botName string, botConfig *trader.BotConfig, txHash string) {
+	n := s.networkForHorizonURL(botConfig.HorizonURL)
+	statuses, e := plugins.LoadTrustlines(n.api, botConfig.TradingAccount(), []hProtocol.Asset{botConfig.AssetBase(), botConfig.AssetQuote()})
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("unable to load trustlines for bot '%s': %s\n", botName, e))
+		return
+	}
+
+	ready := true
+	for _, t := range statuses {
+		if t.Problem != "" {
+			ready = false
+		}
+	}
+	s.writeJson(w, trustlinesResponse{
+		TradingAccount: botConfig.TradingAccount(),
+		TxHash:         txHash,
+		Trustlines:     statuses,
+		Ready:          ready,
+	})
+}
+
+// checkTrustlinesReady returns an error describing the problems with the trustlines of the bot's trading account, such as a trustline that
+// the issuer has not authorized, so the bot is not started only to exit when it validates its trustlines
+func (s *APIServer) checkTrustlinesReady(botName string) error {
+	botConfig, e := s.loadBotConfig(botName)
+	if e != nil {
+		return e
+	}
+	if !botConfig.IsTradingSdex() {
+		return nil
+	}
+
+	n := s.networkForHorizonURL(botConfig.HorizonURL)
+	statuses, e := plugins.LoadTrustlines(n.api, botConfig.TradingAccount(), []hProtocol.Asset{botConfig.AssetBase(), botConfig.AssetQuote()})
+	if e != nil {
+		return fmt.Errorf("unable to load trustlines for bot '%s': %s", botName, e)
+	}
+	problems := []string{}
+	for _, t := range statuses {
+		if t.Problem != "" {
+			problems = append(problems, t.Problem)
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("the trustlines of the trading account of bot '%s' cannot be used for trading: %s", botName, strings.Join(problems, "; "))
+	}
+	return nil
+}
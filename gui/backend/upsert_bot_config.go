@@ -6,7 +6,6 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
-	"strings"
 
 	"github.com/stellar/go/build"
 	"github.com/stellar/go/clients/horizon"
@@ -73,20 +72,37 @@ func (s *APIServer) upsertBotConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	e = req.TraderConfig.Init()
+	n := s.networkForHorizonURL(req.TraderConfig.HorizonURL)
+	if !isMainnetConfirmed(r, n) {
+		s.writeMainnetConfirmationRequired(w, req.Name, "upsertBotConfig", n)
+		return
+	}
+
+	e = s.writeBotConfigs(req)
 	if e != nil {
-		s.writeErrorJson(w, fmt.Sprintf("error running Init() for TraderConfig: %s", e))
+		s.writeErrorJson(w, e.Error())
 		return
 	}
 
+	// check if we need to create new funding accounts and new trustlines
+	s.reinitBotCheck(req, n)
+
+	s.writeJson(w, upsertBotConfigResponse{Success: true})
+}
+
+func (s *APIServer) writeBotConfigs(req upsertBotConfigRequest) error {
+	e := req.TraderConfig.Init()
+	if e != nil {
+		return fmt.Errorf("error running Init() for TraderConfig: %s", e)
+	}
+
 	filenamePair := model2.GetBotFilenames(req.Name, req.Strategy)
 	traderFilePath := fmt.Sprintf("%s/%s", s.configsDir, filenamePair.Trader)
 	botConfig := req.TraderConfig
 	log.Printf("upsert bot config to file: %s\n", traderFilePath)
 	e = toml.WriteFile(traderFilePath, &botConfig)
 	if e != nil {
-		s.writeErrorJson(w, fmt.Sprintf("error writing trader botConfig toml file for bot '%s': %s", req.Name, e))
-		return
+		return fmt.Errorf("error writing trader botConfig toml file for bot '%s': %s", req.Name, e)
 	}
 
 	strategyFilePath := fmt.Sprintf("%s/%s", s.configsDir, filenamePair.Strategy)
@@ -94,14 +110,9 @@ func (s *APIServer) upsertBotConfig(w http.ResponseWriter, r *http.Request) {
 	log.Printf("upsert strategy config to file: %s\n", strategyFilePath)
 	e = toml.WriteFile(strategyFilePath, &strategyConfig)
 	if e != nil {
-		s.writeErrorJson(w, fmt.Sprintf("error writing strategy toml file for bot '%s': %s", req.Name, e))
-		return
+		return fmt.Errorf("error writing strategy toml file for bot '%s': %s", req.Name, e)
 	}
-
-	// check if we need to create new funding accounts and new trustlines
-	s.reinitBotCheck(req)
-
-	s.writeJson(w, upsertBotConfigResponse{Success: true})
+	return nil
 }
 
 func (s *APIServer) validateConfigs(req upsertBotConfigRequest) *upsertBotConfigResponseErrors {
@@ -159,13 +170,13 @@ func hasNewLevel(levels []plugins.StaticLevel) bool {
 	return false
 }
 
-func (s *APIServer) reinitBotCheck(req upsertBotConfigRequest) {
-	isTestnet := strings.Contains(req.TraderConfig.HorizonURL, "test")
+func (s *APIServer) reinitBotCheck(req upsertBotConfigRequest, n *botNetwork) {
 	bot := &model2.Bot{
 		Name:     req.Name,
 		Strategy: req.Strategy,
 		Running:  false,
-		Test:     isTestnet,
+		Test:     n.isTestnet(),
+		Network:  n.name,
 	}
 
 	// set bot state to initializing so it handles the update
@@ -178,7 +189,7 @@ func (s *APIServer) reinitBotCheck(req upsertBotConfigRequest) {
 			log.Printf("error parsing trading secret seed for bot '%s': %s\n", bot.Name, e)
 			return
 		}
-		traderAccount, e := s.checkFundAccount(tradingKP.Address(), bot.Name)
+		traderAccount, e := s.checkFundAccount(n, tradingKP.Address(), bot.Name)
 		if e != nil {
 			log.Printf("error checking and funding trader account during upsert config: %s\n", e)
 			return
@@ -189,7 +200,7 @@ func (s *APIServer) reinitBotCheck(req upsertBotConfigRequest) {
 			req.TraderConfig.AssetBase(),
 			req.TraderConfig.AssetQuote(),
 		}
-		e = s.checkAddTrustline(*traderAccount, tradingKP, req.TraderConfig.TradingSecretSeed, bot.Name, isTestnet, assets)
+		e = s.checkAddTrustline(*traderAccount, tradingKP, req.TraderConfig.TradingSecretSeed, bot.Name, n, assets)
 		if e != nil {
 			log.Printf("error checking and adding trustline to trader account during upsert config: %s\n", e)
 			return
@@ -202,7 +213,7 @@ func (s *APIServer) reinitBotCheck(req upsertBotConfigRequest) {
 				log.Printf("error parsing source secret seed for bot '%s': %s\n", bot.Name, e)
 				return
 			}
-			_, e = s.checkFundAccount(sourceKP.Address(), bot.Name)
+			_, e = s.checkFundAccount(n, sourceKP.Address(), bot.Name)
 			if e != nil {
 				fmt.Printf("error checking and funding source account during upsert config: %s\n", e)
 				return
@@ -218,13 +229,9 @@ func (s *APIServer) reinitBotCheck(req upsertBotConfigRequest) {
 	}()
 }
 
-func (s *APIServer) checkAddTrustline(account hProtocol.Account, kp keypair.KP, traderSeed string, botName string, isTestnet bool, assets []hProtocol.Asset) error {
-	network := build.PublicNetwork
-	client := s.apiPubNetOld
-	if isTestnet {
-		network = build.TestNetwork
-		client = s.apiTestNetOld
-	}
+func (s *APIServer) checkAddTrustline(account hProtocol.Account, kp keypair.KP, traderSeed string, botName string, n *botNetwork, assets []hProtocol.Asset) error {
+	network := n.network
+	client := n.apiOld
 
 	// find trustlines to be added
 	trustlines := []hProtocol.Asset{}
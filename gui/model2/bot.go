@@ -6,12 +6,20 @@ import (
 	"strings"
 )
 
+// names of the networks a bot can run against
+const (
+	NetworkTestnet = "testnet"
+	NetworkPubnet  = "pubnet"
+	NetworkCustom  = "custom"
+)
+
 // Bot represents a kelp bot instance
 type Bot struct {
 	Name     string `json:"name"`
 	Strategy string `json:"strategy"`
 	Running  bool   `json:"running"`
 	Test     bool   `json:"test"`
+	Network  string `json:"network"`
 	Warnings uint16 `json:"warnings"`
 	Errors   uint16 `json:"errors"`
 }
@@ -23,6 +31,7 @@ func MakeAutogeneratedBot() *Bot {
 		Strategy: "buysell",
 		Running:  false,
 		Test:     true,
+		Network:  NetworkTestnet,
 		Warnings: 0,
 		Errors:   0,
 	}
@@ -71,6 +80,26 @@ func GetLogPrefix(botName string, strategy string) string {
 	return fmt.Sprintf("%s__%s_", converted, strategy)
 }
 
+// GetPnLFilename returns the filename of the profit and loss ledger of a bot
+func GetPnLFilename(botName string) string {
+	return fmt.Sprintf("%s__pnl.json", GetPrefix(botName))
+}
+
+// GetOffersJournalFilename returns the filename of the offer journal of a bot
+func GetOffersJournalFilename(botName string) string {
+	return fmt.Sprintf("%s__offers.json", GetPrefix(botName))
+}
+
+// GetScheduleFilename returns the filename of the trading hours schedule of a bot
+func GetScheduleFilename(botName string) string {
+	return fmt.Sprintf("%s__schedule.json", GetPrefix(botName))
+}
+
+// GetRunHistoryFilename returns the filename of the run history of a bot
+func GetRunHistoryFilename(botName string) string {
+	return fmt.Sprintf("%s__history.jsonl", GetPrefix(botName))
+}
+
 // GetPrefix returns the general prefix for filenames associated with a botName
 func GetPrefix(botName string) string {
 	return strings.ToLower(strings.Replace(botName, " ", "_", -1))
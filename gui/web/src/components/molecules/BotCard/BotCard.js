@@ -18,6 +18,7 @@ import stop from '../../../kelp-ops-api/stop';
 import deleteBot from '../../../kelp-ops-api/deleteBot';
 import getState from '../../../kelp-ops-api/getState';
 import getBotInfo from '../../../kelp-ops-api/getBotInfo';
+import getBotAnnotations from '../../../kelp-ops-api/getBotAnnotations';
 
 let defaultBotInfo = {
   "last_updated": "Never",
@@ -48,6 +49,7 @@ let defaultBotInfo = {
 const botStateIntervalMillis = 2000;
 const botInfoIntervalMillis = 5000;
 const botInfoTimeoutMillis = 3000;  // should be less than interval
+const maxAnnotationsShown = 3;
 
 class BotCard extends Component {
   constructor(props) {
@@ -58,11 +60,13 @@ class BotCard extends Component {
       popoverVisible: false,
       state: Constants.BotState.initializing,
       botInfo: defaultBotInfo,
+      annotations: [],
     };
 
     this.toggleBot = this.toggleBot.bind(this);
     this.checkState = this.checkState.bind(this);
     this.checkBotInfo = this.checkBotInfo.bind(this);
+    this.checkAnnotations = this.checkAnnotations.bind(this);
     this.startBot = this.startBot.bind(this);
     this.stopBot = this.stopBot.bind(this);
     this.tick = this.tick.bind(this);
@@ -143,11 +147,50 @@ class BotCard extends Component {
     }
   }
 
+  checkAnnotations() {
+    if (this.state.state !== Constants.BotState.running) {
+      if (this.state.annotations.length > 0) {
+        this.setState({
+          annotations: [],
+        });
+      }
+      return;
+    }
+
+    const controller = new AbortController();
+    if (!this._asyncRequests["annotations"]) {
+      var _this = this;
+      this._asyncRequests["annotations"] = getBotAnnotations(this.props.baseUrl, this.props.name, controller.signal).then(resp => {
+        if (!_this._asyncRequests["annotations"]) {
+          // if it has been deleted it means we don't want to process the result
+          return
+        }
+
+        delete _this._asyncRequests["annotations"];
+        if (!resp.error) {
+          // older bots do not emit annotations and respond with an empty object
+          _this.setState({
+            annotations: resp.annotations ? resp.annotations : [],
+          });
+        }
+      }).catch(function(error) {
+        delete _this._asyncRequests["annotations"];
+        console.error(error);
+      });
+
+      // set a timeout on the fetch request
+      if (this._asyncRequests["annotations"]) {
+        setTimeout(controller.abort.bind(controller), botInfoTimeoutMillis);
+      }
+    }
+  }
+
   componentDidMount() {
     this.checkState();
     this.checkBotInfo();
     this._stateTimer = setInterval(this.checkState, botStateIntervalMillis);
     this._infoTimer = setInterval(this.checkBotInfo, botInfoIntervalMillis);
+    this._annotationsTimer = setInterval(this.checkAnnotations, botInfoIntervalMillis);
   }
 
   componentWillUnmount() {
@@ -161,6 +204,11 @@ class BotCard extends Component {
       this._infoTimer = null;
     }
 
+    if (this._annotationsTimer) {
+      clearTimeout(this._annotationsTimer);
+      this._annotationsTimer = null;
+    }
+
     if (this._tickTimer) {
       clearTimeout(this._tickTimer);
       this._tickTimer = null;
@@ -185,6 +233,10 @@ class BotCard extends Component {
     if (this._asyncRequests["botInfo"]) {
       delete this._asyncRequests["botInfo"];
     }
+
+    if (this._asyncRequests["annotations"]) {
+      delete this._asyncRequests["annotations"];
+    }
   }
 
   toggleBot() {
@@ -198,8 +250,14 @@ class BotCard extends Component {
   }
 
   startBot() {
+    // bots on the main network trade with real funds so we ask the user to confirm before starting them
+    const confirmMainnet = !this.props.test;
+    if (confirmMainnet && !window.confirm("'" + this.props.name + "' runs on the main network and will trade with real funds, do you want to start it?")) {
+      return;
+    }
+
     var _this = this;
-    this._asyncRequests["start"] = start(this.props.baseUrl, this.props.name).then(resp => {
+    this._asyncRequests["start"] = start(this.props.baseUrl, this.props.name, confirmMainnet).then(resp => {
       if (!_this._asyncRequests["start"]) {
         // if it has been deleted it means we don't want to process the result
         return
@@ -284,7 +342,27 @@ class BotCard extends Component {
     window.open(link);
   }
 
+  renderAnnotations() {
+    if (this.state.annotations.length === 0) {
+      return null;
+    }
+
+    const shown = this.state.annotations.slice(0, maxAnnotationsShown);
+    return (
+      <ul className={styles.annotations}>
+        {shown.map((a, index) => (
+          <li key={index} className={a.level === "error" ? styles.annotationError : styles.annotation}>
+            {a.source}: {a.message}{a.count > 1 ? " (x" + a.count + ")" : ""}
+          </li>
+        ))}
+      </ul>
+    );
+  }
+
   render() {
+    const numWarnings = this.props.warnings + this.state.annotations.filter(a => a.level === "warning").length;
+    const numErrors = this.props.errors + this.state.annotations.filter(a => a.level === "error").length;
+
     let popover = "";
     if (this.state.popoverVisible) {
       let enableEdit = this.state.state === Constants.BotState.stopped || this.state.state === Constants.BotState.stopping;
@@ -352,13 +430,14 @@ class BotCard extends Component {
               lastUpdated={this.state.botInfo.last_updated}
             />
           </div>
+          {this.renderAnnotations()}
         </div>
 
         <div className={styles.secondColumn}>
           <div className={styles.notificationsLine}>
             <PillGroup>
-              <Pill number={this.props.warnings} type={'warning'}/>
-              <Pill number={this.props.errors} type={'error'}/>
+              <Pill number={numWarnings} type={'warning'}/>
+              <Pill number={numErrors} type={'error'}/>
             </PillGroup>
           </div>
           <BotBidAskInfo
@@ -11,7 +11,8 @@ class PriceFeedAsset extends Component {
     super(props);
     this.state = {
       isLoading: true,
-      price: null
+      price: null,
+      quote: null
     };
     this.queryPrice = this.queryPrice.bind(this);
 
@@ -64,6 +65,7 @@ class PriceFeedAsset extends Component {
       let updateStateObj = { isLoading: false };
       if (!resp.error) {
         updateStateObj.price = resp.price
+        updateStateObj.quote = resp
         this.props.onNewPrice(resp.price);
       }
 
@@ -82,6 +84,7 @@ class PriceFeedAsset extends Component {
       label={this.props.title}
       loading={false}
       price={this.state.price}
+      quote={this.state.quote}
       fetchPrice={this.queryPrice}
       />);
     if (this.state.isLoading || !this.props.optionsMetadata) {
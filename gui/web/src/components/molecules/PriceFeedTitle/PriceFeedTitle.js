@@ -11,9 +11,32 @@ class PriceFeedTitle extends Component {
     label: PropTypes.string,
     loading: PropTypes.bool,
     price: PropTypes.number,
+    quote: PropTypes.object,
     fetchPrice: PropTypes.func
   };
 
+  // describes the bid/ask and source of the price so users can judge the quality of the feed
+  renderQuoteDetails() {
+    const quote = this.props.quote;
+    if (this.props.loading || !quote) {
+      return null;
+    }
+
+    let parts = [];
+    if (quote.bid !== null && quote.ask !== null) {
+      parts.push("bid " + quote.bid + " / ask " + quote.ask + " / mid " + quote.mid);
+      if (quote.mid > 0) {
+        parts.push("spread " + ((quote.ask - quote.bid) / quote.mid * 100).toFixed(2) + "%");
+      }
+    }
+    if (quote.last_trade_time) {
+      parts.push("updated " + quote.last_trade_time);
+    }
+    parts.push("source: " + quote.source);
+
+    return (<div className={styles.details}>{parts.join(" · ")}</div>);
+  }
+
   render() {
     const isLoading = this.props.loading ? styles.isLoading : null;
     const valueClasses = classNames(
@@ -22,27 +45,30 @@ class PriceFeedTitle extends Component {
     );
 
     return (
-      <div className={styles.wrapper}>
-        <Label>{this.props.label}</Label>
-        <span className={styles.equals}>=</span>
-        <div className={styles.valueWrapper}>
-          <span className={valueClasses}>{this.props.price === null ? "<missing>" : this.props.price }</span>
-          { this.props.loading && (
-            <div className={styles.loaderWrapper}>
-              <LoadingAnimation/>
-            </div>
-          )}
-        </div>
+      <div>
+        <div className={styles.wrapper}>
+          <Label>{this.props.label}</Label>
+          <span className={styles.equals}>=</span>
+          <div className={styles.valueWrapper}>
+            <span className={valueClasses}>{this.props.price === null ? "<missing>" : this.props.price }</span>
+            { this.props.loading && (
+              <div className={styles.loaderWrapper}>
+                <LoadingAnimation/>
+              </div>
+            )}
+          </div>
         
-        <Button 
-          onClick={this.props.fetchPrice}
-          icon="refresh"
-          className={styles.button}
-          variant="transparent"
-          hsize="round"
-          disabled={this.props.loading}
-          >
-        </Button>
+          <Button 
+            onClick={this.props.fetchPrice}
+            icon="refresh"
+            className={styles.button}
+            variant="transparent"
+            hsize="round"
+            disabled={this.props.loading}
+            >
+          </Button>
+        </div>
+        {this.renderQuoteDetails()}
       </div>
     );
   }
@@ -0,0 +1,8 @@
+export default (baseUrl, cloneData) => {
+    return fetch(baseUrl + "/api/v1/cloneBot", {
+        method: "POST",
+        body: cloneData,
+    }).then(resp => {
+        return resp.json();
+    });
+};
\ No newline at end of file
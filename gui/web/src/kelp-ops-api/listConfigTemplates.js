@@ -0,0 +1,5 @@
+export default (baseUrl) => {
+    return fetch(baseUrl + "/api/v1/listConfigTemplates").then(resp => {
+        return resp.json();
+    });
+};
\ No newline at end of file
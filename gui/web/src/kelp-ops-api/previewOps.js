@@ -0,0 +1,9 @@
+export default (baseUrl, botName, signal) => {
+    return fetch(baseUrl + "/api/v1/previewOps", {
+        method: "POST",
+        body: botName,
+        signal: signal,
+    }).then(resp => {
+        return resp.json();
+    });
+};
\ No newline at end of file
@@ -1,5 +1,9 @@
-export default (baseUrl, botName) => {
-    return fetch(baseUrl + "/api/v1/start", {
+export default (baseUrl, botName, confirmMainnet) => {
+    let url = baseUrl + "/api/v1/start";
+    if (confirmMainnet) {
+        url += "?confirmMainnet=true";
+    }
+    return fetch(url, {
         method: "POST",
         body: botName,
     }).then(resp => {
@@ -7,6 +7,7 @@ import (
 )
 
 func main() {
+	cmd.ValidateBuild()
 	e := cmd.RootCmd.Execute()
 	if e != nil {
 		log.Fatal(e)
@@ -134,6 +134,9 @@ var Display = displayAssetConverter{}
 // CcxtAssetConverter is the asset converter for the CCXT exchange interface
 var CcxtAssetConverter = Display
 
+// BinanceAssetConverter is the asset converter for the Binance exchange, which uses the plain asset codes
+var BinanceAssetConverter = Display
+
 // KrakenAssetConverter is the asset converter for the Kraken exchange
 var KrakenAssetConverter = makeAssetConverter(map[Asset]string{
 	XLM:  "XXLM",
@@ -167,6 +170,26 @@ var KrakenAssetConverter = makeAssetConverter(map[Asset]string{
 	KRW:  "ZKRW",
 })
 
+// BitfinexAssetConverter is the asset converter for the Bitfinex exchange
+var BitfinexAssetConverter = makeAssetConverter(map[Asset]string{
+	XLM:  "XLM",
+	BTC:  "BTC",
+	USD:  "USD",
+	ETH:  "ETH",
+	LTC:  "LTC",
+	EOS:  "EOS",
+	XRP:  "XRP",
+	ZEC:  "ZEC",
+	XMR:  "XMR",
+	ETC:  "ETC",
+	OMG:  "OMG",
+	DASH: "DSH",
+	USDT: "UST",
+	EUR:  "EUR",
+	GBP:  "GBP",
+	JPY:  "JPY",
+})
+
 // KrakenAssetConverterOpenOrders is the asset converter for the Kraken exchange's GetOpenOrders API
 var KrakenAssetConverterOpenOrders = makeAssetConverter(map[Asset]string{
 	XLM: "XLM",
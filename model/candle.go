@@ -0,0 +1,61 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// Candle is an OHLCV candle of a trading pair, the volume is in units of the base asset
+type Candle struct {
+	Pair      *TradingPair
+	Timestamp *Timestamp // open time of the candle
+	Interval  time.Duration
+	Open      *Number
+	High      *Number
+	Low       *Number
+	Close     *Number
+	Volume    *Number
+}
+
+// String is the stringer method
+func (c Candle) String() string {
+	return fmt.Sprintf("Candle[pair=%s, timestamp=%s, interval=%s, open=%s, high=%s, low=%s, close=%s, volume=%s]",
+		c.Pair,
+		c.Timestamp,
+		c.Interval,
+		c.Open.AsString(),
+		c.High.AsString(),
+		c.Low.AsString(),
+		c.Close.AsString(),
+		c.Volume.AsString(),
+	)
+}
+
+// candleIntervalNames are the intervals that most exchanges support, with the names used by CCXT
+var candleIntervalNames = map[time.Duration]string{
+	time.Minute:         "1m",
+	3 * time.Minute:     "3m",
+	5 * time.Minute:     "5m",
+	15 * time.Minute:    "15m",
+	30 * time.Minute:    "30m",
+	time.Hour:           "1h",
+	2 * time.Hour:       "2h",
+	4 * time.Hour:       "4h",
+	6 * time.Hour:       "6h",
+	8 * time.Hour:       "8h",
+	12 * time.Hour:      "12h",
+	24 * time.Hour:      "1d",
+	3 * 24 * time.Hour:  "3d",
+	7 * 24 * time.Hour:  "1w",
+	15 * 24 * time.Hour: "15d",
+	30 * 24 * time.Hour: "1M",
+}
+
+// CandleIntervalName returns the name of the interval as used by CCXT, for example "5m" or "1d"
+func CandleIntervalName(interval time.Duration) (string, error) {
+	name, ok := candleIntervalNames[interval]
+	if !ok {
+		return "", fmt.Errorf("unsupported candle interval: %s", interval)
+	}
+	return name, nil
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCandleIntervalName(t *testing.T) {
+	testCases := []struct {
+		interval time.Duration
+		wantName string
+		wantErr  bool
+	}{
+		{interval: time.Minute, wantName: "1m"},
+		{interval: 15 * time.Minute, wantName: "15m"},
+		{interval: 4 * time.Hour, wantName: "4h"},
+		{interval: 24 * time.Hour, wantName: "1d"},
+		{interval: 7 * 24 * time.Hour, wantName: "1w"},
+		{interval: 30 * 24 * time.Hour, wantName: "1M"},
+		{interval: 7 * time.Minute, wantErr: true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.interval.String(), func(t *testing.T) {
+			name, e := CandleIntervalName(k.interval)
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantName, name)
+		})
+	}
+}
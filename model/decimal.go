@@ -0,0 +1,226 @@
+package model
+
+import (
+	"fmt"
+	"log"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// RoundingMode decides which way a Decimal is rounded when digits are dropped
+type RoundingMode int8
+
+// RoundingMode values
+const (
+	RoundHalfUp   RoundingMode = iota // round to the nearest value and ties away from zero, which is how a Number is rounded
+	RoundHalfEven                     // round to the nearest value and ties to the even neighbour
+	RoundDown                         // round toward zero, so the rounded amount is never more than the exact amount
+	RoundUp                           // round away from zero
+)
+
+// String is the Stringer interface impl.
+func (m RoundingMode) String() string {
+	switch m {
+	case RoundHalfUp:
+		return "halfUp"
+	case RoundHalfEven:
+		return "halfEven"
+	case RoundDown:
+		return "down"
+	case RoundUp:
+		return "up"
+	}
+	return fmt.Sprintf("unknown(%d)", int8(m))
+}
+
+// DecimalConstants holds some useful constants
+var DecimalConstants = struct {
+	Zero *Decimal
+}{
+	Zero: &Decimal{unscaled: big.NewInt(0), scale: 0},
+}
+
+// Decimal is an exact fixed-point number with the value unscaled * 10^-scale. Additions, subtractions, multiplications and comparisons are
+// exact so amounts that are accumulated over many operations do not drift the way a float-backed Number does. Digits are only dropped when
+// explicitly rounded.
+type Decimal struct {
+	unscaled *big.Int
+	scale    int32
+}
+
+// DecimalFromString parses a decimal string such as "-12.345" or "1e-7" exactly
+func DecimalFromString(s string) (*Decimal, error) {
+	mantissa := s
+	exponent := int64(0)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		var e error
+		exponent, e = strconv.ParseInt(s[i+1:], 10, 32)
+		if e != nil {
+			return nil, fmt.Errorf("invalid exponent in decimal '%s': %s", s, e)
+		}
+		mantissa = s[:i]
+	}
+
+	digits := mantissa
+	scale := int64(0)
+	if i := strings.Index(mantissa, "."); i >= 0 {
+		digits = mantissa[:i] + mantissa[i+1:]
+		scale = int64(len(mantissa) - i - 1)
+	}
+	unsigned := strings.TrimLeft(digits, "+-")
+	if unsigned == "" || strings.ContainsAny(unsigned, "+-") {
+		return nil, fmt.Errorf("invalid decimal '%s'", s)
+	}
+
+	unscaled, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid decimal '%s'", s)
+	}
+	return &Decimal{
+		unscaled: unscaled,
+		scale:    int32(scale - exponent),
+	}, nil
+}
+
+// MustDecimalFromString panics when there's an error
+func MustDecimalFromString(s string) *Decimal {
+	parsed, e := DecimalFromString(s)
+	if e != nil {
+		log.Fatal(e)
+	}
+	return parsed
+}
+
+// DecimalFromNumber makes a Decimal that is exactly the value of the Number at its precision
+func DecimalFromNumber(n Number) *Decimal {
+	return MustDecimalFromString(n.AsString())
+}
+
+// Add returns a new Decimal after adding the passed in Decimal
+func (d Decimal) Add(d2 Decimal) *Decimal {
+	a, b, scale := align(d, d2)
+	return &Decimal{unscaled: a.Add(a, b), scale: scale}
+}
+
+// Subtract returns a new Decimal after subtracting out the passed in Decimal
+func (d Decimal) Subtract(d2 Decimal) *Decimal {
+	a, b, scale := align(d, d2)
+	return &Decimal{unscaled: a.Sub(a, b), scale: scale}
+}
+
+// Multiply returns a new Decimal after multiplying with the passed in Decimal
+func (d Decimal) Multiply(d2 Decimal) *Decimal {
+	return &Decimal{
+		unscaled: new(big.Int).Mul(d.int(), d2.int()),
+		scale:    d.scale + d2.scale,
+	}
+}
+
+// Negate returns the negative value of the decimal
+func (d Decimal) Negate() *Decimal {
+	return &Decimal{unscaled: new(big.Int).Neg(d.int()), scale: d.scale}
+}
+
+// Abs returns the absolute of the decimal
+func (d Decimal) Abs() *Decimal {
+	return &Decimal{unscaled: new(big.Int).Abs(d.int()), scale: d.scale}
+}
+
+// Cmp returns -1, 0 or +1 when the decimal is less than, equal to or greater than the passed in Decimal
+func (d Decimal) Cmp(d2 Decimal) int {
+	a, b, _ := align(d, d2)
+	return a.Cmp(b)
+}
+
+// Sign returns -1, 0 or +1 when the decimal is negative, zero or positive
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+// IsZero returns true if the decimal is zero
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Round returns a new Decimal with at most precision digits after the decimal point, rounded with the mode
+func (d Decimal) Round(precision int8, mode RoundingMode) *Decimal {
+	if d.scale <= int32(precision) {
+		return &Decimal{unscaled: new(big.Int).Set(d.int()), scale: d.scale}
+	}
+
+	divisor := pow10(d.scale - int32(precision))
+	quotient, remainder := new(big.Int).QuoRem(d.int(), divisor, new(big.Int))
+	if remainder.Sign() != 0 {
+		// compare twice the dropped digits with the divisor to find out if they are below, at or above half
+		twice := new(big.Int).Abs(remainder)
+		half := twice.Mul(twice, big.NewInt(2)).Cmp(divisor)
+
+		roundAway := false
+		switch mode {
+		case RoundHalfUp:
+			roundAway = half >= 0
+		case RoundHalfEven:
+			roundAway = half > 0 || (half == 0 && quotient.Bit(0) == 1)
+		case RoundUp:
+			roundAway = true
+		}
+		if roundAway {
+			quotient = quotient.Add(quotient, big.NewInt(int64(d.Sign())))
+		}
+	}
+	return &Decimal{unscaled: quotient, scale: int32(precision)}
+}
+
+// AsNumber rounds the decimal to the precision with the mode and converts it to a Number
+func (d Decimal) AsNumber(precision int8, mode RoundingMode) *Number {
+	return MustNumberFromString(d.Round(precision, mode).String(), precision)
+}
+
+// AsFloat gives the float64 closest to the decimal
+func (d Decimal) AsFloat() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// String is the Stringer interface impl., the exact value with all digits of the scale
+func (d Decimal) String() string {
+	if d.scale <= 0 {
+		return new(big.Int).Mul(d.int(), pow10(-d.scale)).String()
+	}
+
+	digits := new(big.Int).Abs(d.int()).String()
+	if len(digits) <= int(d.scale) {
+		digits = strings.Repeat("0", int(d.scale)-len(digits)+1) + digits
+	}
+	point := len(digits) - int(d.scale)
+	s := digits[:point] + "." + digits[point:]
+	if d.Sign() < 0 {
+		return "-" + s
+	}
+	return s
+}
+
+// int returns the unscaled value, treating the zero value of Decimal as 0
+func (d Decimal) int() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+	return d.unscaled
+}
+
+// align returns copies of the unscaled values of both decimals at the larger of the two scales
+func align(d1 Decimal, d2 Decimal) (*big.Int, *big.Int, int32) {
+	a := new(big.Int).Set(d1.int())
+	b := new(big.Int).Set(d2.int())
+	if d1.scale < d2.scale {
+		a = a.Mul(a, pow10(d2.scale-d1.scale))
+		return a, b, d2.scale
+	}
+	b = b.Mul(b, pow10(d1.scale-d2.scale))
+	return a, b, d1.scale
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,117 @@
+package model
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecimalFromString(t *testing.T) {
+	testCases := []struct {
+		s          string
+		wantString string
+		wantErr    bool
+	}{
+		{s: "1.10", wantString: "1.10"},
+		{s: "-0.05", wantString: "-0.05"},
+		{s: "+7", wantString: "7"},
+		{s: ".5", wantString: "0.5"},
+		{s: "1e-7", wantString: "0.0000001"},
+		{s: "1.5E2", wantString: "150"},
+		{s: "", wantErr: true},
+		{s: "-", wantErr: true},
+		{s: "1.2.3", wantErr: true},
+		{s: "--1", wantErr: true},
+		{s: "NaN", wantErr: true},
+	}
+
+	for _, kase := range testCases {
+		t.Run(kase.s, func(t *testing.T) {
+			d, e := DecimalFromString(kase.s)
+			if kase.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, kase.wantString, d.String())
+		})
+	}
+}
+
+func TestDecimalAccumulatesExactly(t *testing.T) {
+	step := NumberFromFloat(0.1, 7)
+
+	d := DecimalConstants.Zero
+	for i := 0; i < 10; i++ {
+		d = d.Add(*DecimalFromNumber(*step))
+	}
+	for i := 0; i < 10; i++ {
+		d = d.Subtract(*DecimalFromNumber(*step))
+	}
+	assert.True(t, d.IsZero(), d.String())
+
+	for i := 0; i < 10; i++ {
+		d = d.Add(*DecimalFromNumber(*step))
+	}
+	assert.Equal(t, 0, d.Cmp(*MustDecimalFromString("1")))
+	assert.Equal(t, "1.0000000", d.String())
+	assert.Equal(t, 1.0, d.AsFloat())
+	// comparisons against volumes one unit of precision away are exact
+	assert.Equal(t, -1, DecimalFromNumber(*NumberFromFloat(0.9999999, 7)).Cmp(*d))
+}
+
+func TestDecimalMath(t *testing.T) {
+	a := MustDecimalFromString("1.25")
+	b := MustDecimalFromString("-0.005")
+
+	assert.Equal(t, "1.245", a.Add(*b).String())
+	assert.Equal(t, "1.255", a.Subtract(*b).String())
+	assert.Equal(t, "-0.00625", a.Multiply(*b).String())
+	assert.Equal(t, "0.005", b.Abs().String())
+	assert.Equal(t, "-1.25", a.Negate().String())
+	assert.Equal(t, 1, a.Cmp(*b))
+	assert.Equal(t, -1, b.Cmp(*a))
+	assert.Equal(t, 0, a.Cmp(*MustDecimalFromString("1.2500")))
+	assert.Equal(t, -1, b.Sign())
+	assert.True(t, Decimal{}.IsZero())
+}
+
+func TestDecimalRound(t *testing.T) {
+	testCases := []struct {
+		s         string
+		precision int8
+		mode      RoundingMode
+		want      string
+	}{
+		{s: "1.25", precision: 1, mode: RoundHalfUp, want: "1.3"},
+		{s: "-1.25", precision: 1, mode: RoundHalfUp, want: "-1.3"},
+		{s: "1.25", precision: 1, mode: RoundHalfEven, want: "1.2"},
+		{s: "1.35", precision: 1, mode: RoundHalfEven, want: "1.4"},
+		{s: "1.251", precision: 1, mode: RoundHalfEven, want: "1.3"},
+		{s: "1.29", precision: 1, mode: RoundDown, want: "1.2"},
+		{s: "-1.29", precision: 1, mode: RoundDown, want: "-1.2"},
+		{s: "1.21", precision: 1, mode: RoundUp, want: "1.3"},
+		{s: "-1.21", precision: 1, mode: RoundUp, want: "-1.3"},
+		{s: "1.20", precision: 1, mode: RoundUp, want: "1.2"},
+		{s: "0.004", precision: 2, mode: RoundHalfUp, want: "0.00"},
+		{s: "1.2", precision: 3, mode: RoundDown, want: "1.2"},
+		{s: "15", precision: -1, mode: RoundHalfUp, want: "20"},
+	}
+
+	for _, kase := range testCases {
+		t.Run(fmt.Sprintf("%s_%d_%s", kase.s, kase.precision, kase.mode), func(t *testing.T) {
+			d := MustDecimalFromString(kase.s).Round(kase.precision, kase.mode)
+			assert.Equal(t, kase.want, d.String())
+		})
+	}
+}
+
+func TestDecimalAsNumber(t *testing.T) {
+	d := MustDecimalFromString("0.12345678")
+	assert.Equal(t, "0.1234567", d.AsNumber(7, RoundDown).AsString())
+	assert.Equal(t, "0.1234568", d.AsNumber(7, RoundHalfUp).AsString())
+	assert.Equal(t, int8(7), d.AsNumber(7, RoundHalfUp).Precision())
+}
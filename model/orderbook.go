@@ -62,8 +62,10 @@ type OrderType int8
 
 // These are the available order types
 const (
-	OrderTypeMarket OrderType = 0
-	OrderTypeLimit  OrderType = 1
+	OrderTypeMarket    OrderType = 0
+	OrderTypeLimit     OrderType = 1
+	OrderTypeStopLoss  OrderType = 2 // market order that is placed once the market reaches the StopPrice
+	OrderTypeStopLimit OrderType = 3 // limit order at the Price that is placed once the market reaches the StopPrice
 )
 
 // IsMarket returns true for market orders
@@ -76,19 +78,30 @@ func (o OrderType) IsLimit() bool {
 	return o == OrderTypeLimit
 }
 
+// IsStop returns true for orders that are triggered by the StopPrice
+func (o OrderType) IsStop() bool {
+	return o == OrderTypeStopLoss || o == OrderTypeStopLimit
+}
+
 // String is the stringer function
 func (o OrderType) String() string {
 	if o == OrderTypeMarket {
 		return "market"
 	} else if o == OrderTypeLimit {
 		return "limit"
+	} else if o == OrderTypeStopLoss {
+		return "stop-loss"
+	} else if o == OrderTypeStopLimit {
+		return "stop-loss-limit"
 	}
 	return "error, unrecognized order type"
 }
 
 var orderTypeMap = map[string]OrderType{
-	"market": OrderTypeMarket,
-	"limit":  OrderTypeLimit,
+	"market":          OrderTypeMarket,
+	"limit":           OrderTypeLimit,
+	"stop-loss":       OrderTypeStopLoss,
+	"stop-loss-limit": OrderTypeStopLimit,
 }
 
 // OrderTypeFromString is a convenience to convert from common strings to the corresponding OrderType
@@ -104,6 +117,14 @@ type Order struct {
 	Price       *Number
 	Volume      *Number
 	Timestamp   *Timestamp
+	PostOnly    bool    // limit orders that would take liquidity are rejected by the exchange instead of being filled
+	StopPrice   *Number // trigger price of stop orders, nil for other order types
+	// venue-specific params of the order (such as "timeInForce" on ccxt or "oflags" on kraken) that the exchange sends along with the
+	// order, nil for none
+	Params map[string]string
+	// tag of the bot that placed the order, sent to the exchange as the client order ID (kraken needs a 32-bit integer) so the orders of
+	// the bot can be found among the open orders of the account, empty for untagged orders
+	ClientOrderID string
 }
 
 // String is the stringer function
@@ -113,6 +134,26 @@ func (o Order) String() string {
 		tsString = fmt.Sprintf("%d", o.Timestamp.AsInt64())
 	}
 
+	if o.OrderType.IsStop() {
+		// stop-loss orders do not have a limit price
+		priceString := nilString
+		if o.Price != nil {
+			priceString = o.Price.AsString()
+		}
+		stopPriceString := nilString
+		if o.StopPrice != nil {
+			stopPriceString = o.StopPrice.AsString()
+		}
+		return fmt.Sprintf("Order[pair=%s, action=%s, type=%s, price=%s, stopPrice=%s, vol=%s, ts=%s]",
+			o.Pair,
+			o.OrderAction,
+			o.OrderType,
+			priceString,
+			stopPriceString,
+			o.Volume.AsString(),
+			tsString,
+		)
+	}
 	return fmt.Sprintf("Order[pair=%s, action=%s, type=%s, price=%s, vol=%s, ts=%s]",
 		o.Pair,
 		o.OrderAction,
@@ -236,6 +277,7 @@ type Trade struct {
 	TransactionID *TransactionID
 	Cost          *Number
 	Fee           *Number
+	FeeAsset      Asset // asset the fee was charged in, empty when the exchange does not report it
 }
 
 // TradesByTsID implements sort.Interface for []Trade based on Timestamp and TransactionID
@@ -264,7 +306,7 @@ func (t TradesByTsID) Less(i int, j int) bool {
 }
 
 func (t Trade) String() string {
-	return fmt.Sprintf("Trade[txid: %s, ts: %s, pair: %s, action: %s, type: %s, counterPrice: %s, baseVolume: %s, counterCost: %s, fee: %s]",
+	return fmt.Sprintf("Trade[txid: %s, ts: %s, pair: %s, action: %s, type: %s, counterPrice: %s, baseVolume: %s, counterCost: %s, fee: %s, feeAsset: %s]",
 		utils.CheckedString(t.TransactionID),
 		utils.CheckedString(t.Timestamp),
 		*t.Pair,
@@ -274,6 +316,7 @@ func (t Trade) String() string {
 		utils.CheckedString(t.Volume),
 		utils.CheckedString(t.Cost),
 		utils.CheckedString(t.Fee),
+		t.FeeAsset,
 	)
 }
 
@@ -338,6 +381,22 @@ func (o *OrderConstraints) String() string {
 		o.PricePrecision, o.VolumePrecision, o.MinBaseVolume.AsString(), minQuoteVolumeStr)
 }
 
+// Equal returns true if both OrderConstraints have the same values
+func (o *OrderConstraints) Equal(other *OrderConstraints) bool {
+	if o == nil || other == nil {
+		return o == other
+	}
+	if (o.MinQuoteVolume == nil) != (other.MinQuoteVolume == nil) {
+		return false
+	}
+	if o.MinQuoteVolume != nil && o.MinQuoteVolume.AsString() != other.MinQuoteVolume.AsString() {
+		return false
+	}
+	return o.PricePrecision == other.PricePrecision &&
+		o.VolumePrecision == other.VolumePrecision &&
+		o.MinBaseVolume.AsString() == other.MinBaseVolume.AsString()
+}
+
 // OrderConstraintsOverride describes an override for an OrderConstraint
 type OrderConstraintsOverride struct {
 	PricePrecision  *int8
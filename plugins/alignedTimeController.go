@@ -0,0 +1,52 @@
+package plugins
+
+import (
+	"log"
+	"time"
+
+	"github.com/stellar/kelp/api"
+)
+
+// AlignedTimeController runs updates at multiples of the tick interval on the clock, shifted by an offset, so updates line up with candle
+// boundaries. For example a tick interval of 30 seconds runs updates at :00 and :30 of each minute.
+type AlignedTimeController struct {
+	tickInterval time.Duration
+	offset       time.Duration
+}
+
+// MakeAlignedTimeController is a factory method, the offset needs to be less than the tick interval
+func MakeAlignedTimeController(tickInterval time.Duration, offset time.Duration) api.TimeController {
+	return &AlignedTimeController{
+		tickInterval: tickInterval,
+		offset:       offset,
+	}
+}
+
+var _ api.TimeController = &AlignedTimeController{}
+
+// ShouldUpdate impl
+func (t *AlignedTimeController) ShouldUpdate(lastUpdateTime time.Time, currentUpdateTime time.Time) bool {
+	nextTick := t.nextTick(lastUpdateTime)
+	shouldUpdate := !currentUpdateTime.Before(nextTick)
+	log.Printf("alignedTimeController tickInterval=%s, offset=%s, shouldUpdate=%v, nextTick=%s\n", t.tickInterval, t.offset, shouldUpdate, nextTick.UTC().Format(time.RFC3339))
+	return shouldUpdate
+}
+
+// SleepTime impl
+func (t *AlignedTimeController) SleepTime(lastUpdateTime time.Time, currentUpdateTime time.Time) time.Duration {
+	// the first update runs at startup, after which we sleep until the next tick on the clock
+	sleepTime := time.Until(t.nextTick(lastUpdateTime))
+	if sleepTime < 0 {
+		return 0
+	}
+	return sleepTime
+}
+
+// nextTick returns the first tick strictly after the given time, ticks are at the offset plus multiples of the tick interval since the unix
+// epoch. Updates that run longer than the tick interval skip the ticks they missed.
+func (t *AlignedTimeController) nextTick(after time.Time) time.Time {
+	interval := t.tickInterval.Nanoseconds()
+	sinceFirstTick := after.UnixNano() - t.offset.Nanoseconds()
+	numTicks := sinceFirstTick / interval
+	return time.Unix(0, (numTicks+1)*interval+t.offset.Nanoseconds())
+}
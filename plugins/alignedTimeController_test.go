@@ -0,0 +1,55 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlignedTimeControllerNextTick(t *testing.T) {
+	base := time.Date(2020, 9, 13, 12, 26, 0, 0, time.UTC)
+	testCases := []struct {
+		name     string
+		offset   time.Duration
+		after    time.Time
+		wantTick time.Time
+	}{
+		{
+			name:     "between ticks",
+			after:    base.Add(10 * time.Second),
+			wantTick: base.Add(30 * time.Second),
+		}, {
+			name:     "on a tick",
+			after:    base.Add(30 * time.Second),
+			wantTick: base.Add(60 * time.Second),
+		}, {
+			name:     "with offset",
+			offset:   5 * time.Second,
+			after:    base.Add(10 * time.Second),
+			wantTick: base.Add(35 * time.Second),
+		}, {
+			name:     "with offset before the shifted tick",
+			offset:   5 * time.Second,
+			after:    base.Add(2 * time.Second),
+			wantTick: base.Add(5 * time.Second),
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			c := MakeAlignedTimeController(30*time.Second, k.offset).(*AlignedTimeController)
+			assert.Equal(t, k.wantTick.UnixNano(), c.nextTick(k.after).UnixNano())
+		})
+	}
+}
+
+func TestAlignedTimeControllerShouldUpdate(t *testing.T) {
+	c := MakeAlignedTimeController(30*time.Second, 0)
+	lastUpdate := time.Date(2020, 9, 13, 12, 26, 31, 0, time.UTC)
+
+	assert.False(t, c.ShouldUpdate(lastUpdate, lastUpdate.Add(28*time.Second)))
+	assert.True(t, c.ShouldUpdate(lastUpdate, lastUpdate.Add(29*time.Second)))
+	// an update that ran past the next tick is not made up for
+	assert.True(t, c.ShouldUpdate(lastUpdate, lastUpdate.Add(75*time.Second)))
+}
@@ -0,0 +1,109 @@
+package plugins
+
+import (
+	"log"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/stellar/go/build"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/xdr"
+	"github.com/stellar/kelp/api"
+)
+
+// amountJitterFilter randomizes the amounts of the offers that are created or modified so the sizes of the levels of the bot are not
+// deterministic, which makes it harder for other traders to identify the offers of the bot and trade around them
+type amountJitterFilter struct {
+	jitter  float64
+	randGen *rand.Rand
+}
+
+var _ SubmitFilter = &amountJitterFilter{}
+var _ api.StateSnapshotter = &amountJitterFilter{}
+
+// MakeFilterAmountJitter makes a submit filter that randomly changes the amount of each offer by up to the jitter fraction in either
+// direction, a seed of 0 seeds the randomness from the clock. Returns nil when disabled.
+func MakeFilterAmountJitter(jitter float64, seed int64) SubmitFilter {
+	if jitter == 0 {
+		return nil
+	}
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return makeAmountJitterFilter(jitter, rand.New(rand.NewSource(seed)))
+}
+
+func makeAmountJitterFilter(jitter float64, randGen *rand.Rand) *amountJitterFilter {
+	return &amountJitterFilter{
+		jitter:  jitter,
+		randGen: randGen,
+	}
+}
+
+// Snapshot impl, a source of randomness cannot be copied so the amounts are jittered with a separate source until the state is restored,
+// which keeps the amounts of a seeded filter the same whether or not the ops were previewed
+func (f *amountJitterFilter) Snapshot() func() {
+	randGen := f.randGen
+	f.randGen = rand.New(rand.NewSource(time.Now().UnixNano()))
+	return func() {
+		f.randGen = randGen
+	}
+}
+
+// jitterAmount returns the raw amount (in stroops) changed by a random fraction within the jitter, it never returns less than 1 stroop
+// so an offer is not turned into a delete operation
+func (f *amountJitterFilter) jitterAmount(amount xdr.Int64) xdr.Int64 {
+	factor := 1 + f.jitter*(2*f.randGen.Float64()-1)
+	jittered := xdr.Int64(math.Floor(float64(amount) * factor))
+	if jittered < 1 {
+		return 1
+	}
+	return jittered
+}
+
+// Apply impl.
+func (f *amountJitterFilter) Apply(
+	ops []build.TransactionMutator,
+	sellingOffers []hProtocol.Offer,
+	buyingOffers []hProtocol.Offer,
+) ([]build.TransactionMutator, error) {
+	numJittered := 0
+	filteredOps := []build.TransactionMutator{}
+	for _, op := range ops {
+		switch o := op.(type) {
+		case *build.ManageOfferBuilder:
+			// delete operations are kept as they are
+			if o.MO.Amount == 0 {
+				filteredOps = append(filteredOps, o)
+				continue
+			}
+			opCopy := *o
+			opCopy.MO.Amount = f.jitterAmount(o.MO.Amount)
+			filteredOps = append(filteredOps, &opCopy)
+			numJittered++
+		case build.ManageOfferBuilder:
+			if o.MO.Amount == 0 {
+				filteredOps = append(filteredOps, o)
+				continue
+			}
+			o.MO.Amount = f.jitterAmount(o.MO.Amount)
+			filteredOps = append(filteredOps, o)
+			numJittered++
+		case *ManageBuyOfferBuilder:
+			if o.MBO.BuyAmount == 0 {
+				filteredOps = append(filteredOps, o)
+				continue
+			}
+			opCopy := *o
+			opCopy.MBO.BuyAmount = f.jitterAmount(o.MBO.BuyAmount)
+			filteredOps = append(filteredOps, &opCopy)
+			numJittered++
+		default:
+			filteredOps = append(filteredOps, op)
+		}
+	}
+
+	log.Printf("amountJitterFilter: jittered the amounts of %d ops by up to %.2f%%\n", numJittered, f.jitter*100)
+	return filteredOps, nil
+}
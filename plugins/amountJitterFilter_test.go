@@ -0,0 +1,47 @@
+package plugins
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stellar/go/build"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAmountJitterFilterApply(t *testing.T) {
+	assert.Nil(t, MakeFilterAmountJitter(0, 0))
+
+	usd := build.CreditAsset("USD", testIssuer)
+	sellRate := build.Rate{Selling: build.NativeAsset(), Buying: usd, Price: build.Price("2")}
+	createOp := build.CreateOffer(sellRate, build.Amount("100"))
+	deleteOp := build.DeleteOffer(sellRate, build.OfferID(13))
+	nativeBuy := makeManageBuyOffer(usd, build.NativeAsset(), "0.5", "20", 0, "")
+	ops := []build.TransactionMutator{&createOp, &deleteOp, nativeBuy}
+
+	f := makeAmountJitterFilter(0.1, rand.New(rand.NewSource(42)))
+	filteredOps, e := f.Apply(ops, nil, nil)
+	if !assert.NoError(t, e) || !assert.Equal(t, 3, len(filteredOps)) {
+		return
+	}
+	jitteredSell := filteredOps[0].(*build.ManageOfferBuilder).MO.Amount
+	assert.True(t, jitteredSell >= 900000000 && jitteredSell <= 1100000000, "amount %d is not within the jitter", jitteredSell)
+	assert.NotEqual(t, xdr.Int64(1000000000), jitteredSell)
+	// delete operations are not changed
+	assert.Equal(t, &deleteOp, filteredOps[1])
+	jitteredBuy := filteredOps[2].(*ManageBuyOfferBuilder).MBO.BuyAmount
+	assert.True(t, jitteredBuy >= 180000000 && jitteredBuy <= 220000000, "amount %d is not within the jitter", jitteredBuy)
+	// the original ops are unchanged
+	assert.Equal(t, xdr.Int64(1000000000), createOp.MO.Amount)
+	assert.Equal(t, xdr.Int64(200000000), nativeBuy.MBO.BuyAmount)
+
+	// the same seed produces the same amounts
+	sameSeedOps, e := makeAmountJitterFilter(0.1, rand.New(rand.NewSource(42))).Apply(ops, nil, nil)
+	if assert.NoError(t, e) {
+		assert.Equal(t, jitteredSell, sameSeedOps[0].(*build.ManageOfferBuilder).MO.Amount)
+		assert.Equal(t, jitteredBuy, sameSeedOps[2].(*ManageBuyOfferBuilder).MBO.BuyAmount)
+	}
+
+	// amounts are never jittered into a delete operation
+	assert.Equal(t, xdr.Int64(1), makeAmountJitterFilter(0.9, rand.New(rand.NewSource(1))).jitterAmount(1))
+}
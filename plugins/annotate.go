@@ -0,0 +1,16 @@
+package plugins
+
+import (
+	"log"
+
+	"github.com/stellar/kelp/api"
+)
+
+// annotate emits the annotation if an annotator has been set on the strategy, annotations are always logged
+func annotate(annotator api.Annotator, level api.AnnotationLevel, source string, message string) {
+	log.Printf("annotation | %s | %s | %s\n", level, source, message)
+	if annotator == nil {
+		return
+	}
+	annotator.Annotate(level, source, message)
+}
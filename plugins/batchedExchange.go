@@ -33,6 +33,7 @@ type BatchedExchange struct {
 }
 
 var _ api.ExchangeShim = BatchedExchange{}
+var _ api.StreamingExchange = BatchedExchange{}
 
 // MakeBatchedExchange factory
 func MakeBatchedExchange(
@@ -168,6 +169,22 @@ func (b BatchedExchange) GetTradeHistory(pair model.TradingPair, maybeCursorStar
 	return b.inner.GetTradeHistory(pair, maybeCursorStart, maybeCursorEnd)
 }
 
+// StreamTrades impl
+func (b BatchedExchange) StreamTrades(pair *model.TradingPair, handler func(trade model.Trade)) error {
+	if tradeStream, ok := b.inner.(api.TradeStream); ok {
+		return tradeStream.StreamTrades(pair, handler)
+	}
+	return api.ErrTradeStreamUnsupported
+}
+
+// StreamOrderBook impl
+func (b BatchedExchange) StreamOrderBook(pair *model.TradingPair, maxCount int32, handler func(ob *model.OrderBook)) error {
+	if orderBookStream, ok := b.inner.(api.OrderBookStream); ok {
+		return orderBookStream.StreamOrderBook(pair, maxCount, handler)
+	}
+	return api.ErrOrderBookStreamUnsupported
+}
+
 // GetLatestTradeCursor impl
 func (b BatchedExchange) GetLatestTradeCursor() (interface{}, error) {
 	return b.inner.GetLatestTradeCursor()
@@ -453,6 +470,12 @@ func Ops2CommandsHack(
 				return nil, fmt.Errorf("unable to convert build.ManageOfferBuilder to a Command: %s", e)
 			}
 			commands = append(commands, c...)
+		case *ManageBuyOfferBuilder:
+			c, e := buyOp2CommandsHack(manageOffer, baseAsset, quoteAsset, offerID2OrderID, orderConstraints)
+			if e != nil {
+				return nil, fmt.Errorf("unable to convert *ManageBuyOfferBuilder to a Command: %s", e)
+			}
+			commands = append(commands, c...)
 		default:
 			return nil, fmt.Errorf("unable to recognize transaction mutator op (%s): %v", reflect.TypeOf(op), manageOffer)
 		}
@@ -468,22 +491,52 @@ func op2CommandsHack(
 	offerID2OrderID map[int64]string, // if map is nil then we ignore ID errors
 	orderConstraints *model.OrderConstraints,
 ) ([]Command, error) {
-	commands := []Command{}
 	order, e := manageOffer2Order(manageOffer, baseAsset, quoteAsset, orderConstraints)
 	if e != nil {
 		return nil, fmt.Errorf("error converting from manageOffer op to Order: %s", e)
 	}
+	return offer2Commands(order, int64(manageOffer.MO.OfferId), manageOffer.MO.Amount == 0, offerID2OrderID)
+}
+
+// buyOp2CommandsHack converts one native buy offer op to possibly many Commands
+func buyOp2CommandsHack(
+	manageBuyOffer *ManageBuyOfferBuilder,
+	baseAsset hProtocol.Asset,
+	quoteAsset hProtocol.Asset,
+	offerID2OrderID map[int64]string, // if map is nil then we ignore ID errors
+	orderConstraints *model.OrderConstraints,
+) ([]Command, error) {
+	order := &model.Order{
+		Pair: &model.TradingPair{
+			Base:  model.FromHorizonAsset(baseAsset),
+			Quote: model.FromHorizonAsset(quoteAsset),
+		},
+		OrderAction: model.OrderActionBuy,
+		OrderType:   model.OrderTypeLimit,
+		Price:       model.NumberFromFloat(manageBuyOffer.BuyPrice(), orderConstraints.PricePrecision),
+		Volume:      model.NumberFromFloat(manageBuyOffer.BuyAmount(), orderConstraints.VolumePrecision),
+		Timestamp:   model.MakeTimestamp(time.Now().UnixNano() / int64(time.Millisecond)),
+	}
+	return offer2Commands(order, int64(manageBuyOffer.MBO.OfferId), manageBuyOffer.MBO.BuyAmount == 0, offerID2OrderID)
+}
 
-	if manageOffer.MO.Amount == 0 {
+// offer2Commands converts the order of a manage offer op to the Commands that create, modify (cancel and create), or cancel it
+func offer2Commands(
+	order *model.Order,
+	offerID int64,
+	isDelete bool,
+	offerID2OrderID map[int64]string, // if map is nil then we ignore ID errors
+) ([]Command, error) {
+	commands := []Command{}
+	if isDelete {
 		// cancel
 		// fetch real orderID here (hoops we have to jump through because of the hacked approach to using centralized exchanges)
 		var orderID string
 		if offerID2OrderID != nil {
-			ID := int64(manageOffer.MO.OfferId)
 			var ok bool
-			orderID, ok = offerID2OrderID[ID]
+			orderID, ok = offerID2OrderID[offerID]
 			if !ok {
-				return nil, fmt.Errorf("there was an order that we have never seen before and did not have in the offerID2OrderID map, offerID (int): %d", ID)
+				return nil, fmt.Errorf("there was an order that we have never seen before and did not have in the offerID2OrderID map, offerID (int): %d", offerID)
 			}
 		} else {
 			orderID = ""
@@ -491,17 +544,16 @@ func op2CommandsHack(
 		txID := model.MakeTransactionID(orderID)
 		openOrder := order2OpenOrder(order, txID)
 		commands = append(commands, MakeCommandCancel(openOrder))
-	} else if manageOffer.MO.OfferId != 0 {
+	} else if offerID != 0 {
 		// modify is cancel followed by create
 		// -- cancel
 		// fetch real orderID here (hoops we have to jump through because of the hacked approach to using centralized exchanges)
 		var orderID string
 		if offerID2OrderID != nil {
-			ID := int64(manageOffer.MO.OfferId)
 			var ok bool
-			orderID, ok = offerID2OrderID[ID]
+			orderID, ok = offerID2OrderID[offerID]
 			if !ok {
-				return nil, fmt.Errorf("there was an order that we have never seen before and did not have in the offerID2OrderID map, offerID (int): %d", ID)
+				return nil, fmt.Errorf("there was an order that we have never seen before and did not have in the offerID2OrderID map, offerID (int): %d", offerID)
 			}
 		} else {
 			orderID = ""
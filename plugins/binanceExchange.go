@@ -0,0 +1,1229 @@
+package plugins
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/networking"
+)
+
+// ensure that binanceExchange conforms to the Exchange interface
+var _ api.Exchange = &binanceExchange{}
+
+// ensure that binanceExchange can refresh its order constraints and reports its time
+var _ api.ConstraintsRefresher = &binanceExchange{}
+var _ api.ServerClock = &binanceExchange{}
+
+const binanceRegionParam = "region"
+const binanceDefaultRegion = "binance.com"
+const binanceRecvWindowMillis = 5000
+const binanceTradesLimit = 1000
+
+// binanceTradeHistoryWindow is the longest time range that the myTrades endpoint returns trades for in one request
+const binanceTradeHistoryWindow = 24 * time.Hour
+
+// binanceOrderBookLimits are the only values accepted by the "limit" param of the depth endpoint, in increasing order
+var binanceOrderBookLimits = []int32{5, 10, 20, 50, 100, 500, 1000, 5000}
+
+// request weights of the endpoints, the weight of the depth endpoint depends on the limit (see binanceDepthWeight)
+const (
+	binanceWeightDefault      = 1
+	binanceWeightOpenOrders   = 3
+	binanceWeightAccount      = 10
+	binanceWeightMyTrades     = 10
+	binanceWeightExchangeInfo = 10
+)
+
+// binanceRegion is one of the regional exchanges of binance, which have their own endpoint, markets, precisions, minimums and rate limits.
+// The values here are the defaults that are used until they are refreshed from the exchangeInfo endpoint of the region.
+type binanceRegion struct {
+	baseURL         string
+	weightLimit     int
+	weightInterval  time.Duration
+	ordersLimit     int
+	ordersInterval  time.Duration
+	precisionMatrix map[model.TradingPair]model.OrderConstraints
+}
+
+// binanceRegions are the regions that can be selected with the "region" exchange param
+var binanceRegions = map[string]binanceRegion{
+	"binance.com": {
+		baseURL:        "https://api.binance.com",
+		weightLimit:    1200,
+		weightInterval: time.Minute,
+		ordersLimit:    50,
+		ordersInterval: 10 * time.Second,
+		precisionMatrix: map[model.TradingPair]model.OrderConstraints{
+			*model.MakeTradingPair(model.XLM, model.USDT): *model.MakeOrderConstraintsWithCost(5, 1, 0.1, 10.0),
+			*model.MakeTradingPair(model.XLM, model.BTC):  *model.MakeOrderConstraintsWithCost(8, 0, 1.0, 0.0001),
+			*model.MakeTradingPair(model.XLM, model.ETH):  *model.MakeOrderConstraintsWithCost(8, 0, 1.0, 0.005),
+			*model.MakeTradingPair(model.BTC, model.USDT): *model.MakeOrderConstraintsWithCost(2, 6, 0.000001, 10.0),
+			*model.MakeTradingPair(model.ETH, model.USDT): *model.MakeOrderConstraintsWithCost(2, 5, 0.00001, 10.0),
+			*model.MakeTradingPair(model.ETH, model.BTC):  *model.MakeOrderConstraintsWithCost(6, 3, 0.001, 0.0001),
+			*model.MakeTradingPair(model.XRP, model.USDT): *model.MakeOrderConstraintsWithCost(5, 1, 0.1, 10.0),
+			*model.MakeTradingPair(model.XRP, model.BTC):  *model.MakeOrderConstraintsWithCost(8, 0, 1.0, 0.0001),
+		},
+	},
+	"binance.us": {
+		baseURL:        "https://api.binance.us",
+		weightLimit:    1200,
+		weightInterval: time.Minute,
+		ordersLimit:    10,
+		ordersInterval: time.Second,
+		precisionMatrix: map[model.TradingPair]model.OrderConstraints{
+			*model.MakeTradingPair(model.XLM, model.USD):  *model.MakeOrderConstraintsWithCost(4, 1, 0.1, 10.0),
+			*model.MakeTradingPair(model.XLM, model.USDT): *model.MakeOrderConstraintsWithCost(4, 1, 0.1, 10.0),
+			*model.MakeTradingPair(model.XLM, model.BTC):  *model.MakeOrderConstraintsWithCost(8, 0, 1.0, 0.0001),
+			*model.MakeTradingPair(model.BTC, model.USD):  *model.MakeOrderConstraintsWithCost(2, 6, 0.000001, 10.0),
+			*model.MakeTradingPair(model.BTC, model.USDT): *model.MakeOrderConstraintsWithCost(2, 6, 0.000001, 10.0),
+			*model.MakeTradingPair(model.ETH, model.USD):  *model.MakeOrderConstraintsWithCost(2, 5, 0.00001, 10.0),
+			*model.MakeTradingPair(model.XRP, model.USD):  *model.MakeOrderConstraintsWithCost(4, 1, 0.1, 10.0),
+		},
+	},
+	"binance.je": {
+		baseURL:        "https://api.binance.je",
+		weightLimit:    1200,
+		weightInterval: time.Minute,
+		ordersLimit:    10,
+		ordersInterval: time.Second,
+		precisionMatrix: map[model.TradingPair]model.OrderConstraints{
+			*model.MakeTradingPair(model.BTC, model.EUR): *model.MakeOrderConstraintsWithCost(2, 6, 0.000001, 10.0),
+			*model.MakeTradingPair(model.BTC, model.GBP): *model.MakeOrderConstraintsWithCost(2, 6, 0.000001, 10.0),
+			*model.MakeTradingPair(model.ETH, model.EUR): *model.MakeOrderConstraintsWithCost(2, 5, 0.00001, 10.0),
+			*model.MakeTradingPair(model.ETH, model.GBP): *model.MakeOrderConstraintsWithCost(2, 5, 0.00001, 10.0),
+		},
+	},
+}
+
+// binanceExchange is the native implementation for the Binance Exchange and its regional exchanges, using the v3 REST API
+type binanceExchange struct {
+	assetConverter     model.AssetConverterInterface
+	apiKeys            []api.ExchangeAPIKey
+	apiNextIndex       uint8
+	ocOverridesHandler *OrderConstraintsOverridesHandler
+	httpClient         *http.Client
+	isSimulated        bool // will simulate add and cancel orders if this is true
+	regionName         string
+	region             binanceRegion
+	orderParams        map[string]string
+	weightLimiter      *binanceRateLimiter
+	ordersLimiter      *binanceRateLimiter
+	now                func() time.Time
+
+	// constraints holds the order constraints fetched from the exchange, which take precedence over the precision matrix of the region
+	constraintsMutex *sync.RWMutex
+	constraints      map[model.TradingPair]model.OrderConstraints
+}
+
+// makeBinanceExchange is a factory method to make the binance exchange, the region is read from the exchangeParams
+func makeBinanceExchange(apiKeys []api.ExchangeAPIKey, exchangeParams []api.ExchangeParam, isSimulated bool) (api.Exchange, error) {
+	if len(apiKeys) == 0 || len(apiKeys) > math.MaxUint8 {
+		return nil, fmt.Errorf("invalid number of apiKeys: %d", len(apiKeys))
+	}
+
+	orderParams, exchangeParams := extractOrderParams(exchangeParams)
+	regionName, e := parseBinanceRegion(exchangeParams)
+	if e != nil {
+		return nil, fmt.Errorf("unable to make binance exchange: %s", e)
+	}
+
+	b := newBinanceExchange(regionName, apiKeys, orderParams, isSimulated)
+	// the defaults of the region are used when the constraints cannot be loaded, they are refreshed again by the strategies that need them
+	if e = b.RefreshOrderConstraints(); e != nil {
+		log.Printf("unable to load order constraints and rate limits from %s, continuing with the defaults of the region: %s\n", regionName, e)
+	}
+	return b, nil
+}
+
+// newBinanceExchange makes the exchange for a valid region without loading anything from the exchange
+func newBinanceExchange(regionName string, apiKeys []api.ExchangeAPIKey, orderParams map[string]string, isSimulated bool) *binanceExchange {
+	region := binanceRegions[regionName]
+	return &binanceExchange{
+		assetConverter:     model.BinanceAssetConverter,
+		apiKeys:            apiKeys,
+		apiNextIndex:       0,
+		ocOverridesHandler: MakeEmptyOrderConstraintsOverridesHandler(),
+		httpClient:         http.DefaultClient,
+		isSimulated:        isSimulated,
+		regionName:         regionName,
+		region:             region,
+		orderParams:        orderParams,
+		weightLimiter:      makeBinanceRateLimiter("request weight", region.weightLimit, region.weightInterval),
+		ordersLimiter:      makeBinanceRateLimiter("orders", region.ordersLimit, region.ordersInterval),
+		now:                time.Now,
+		constraintsMutex:   &sync.RWMutex{},
+		constraints:        map[model.TradingPair]model.OrderConstraints{},
+	}
+}
+
+// parseBinanceRegion returns the region set in the exchange params, other params are ignored
+func parseBinanceRegion(exchangeParams []api.ExchangeParam) (string, error) {
+	regionName := binanceDefaultRegion
+	for _, p := range exchangeParams {
+		if p.Param != binanceRegionParam {
+			continue
+		}
+		if _, ok := binanceRegions[p.Value]; !ok {
+			names := []string{}
+			for name := range binanceRegions {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return "", fmt.Errorf("invalid binance region '%s', needs to be one of %v", p.Value, names)
+		}
+		regionName = p.Value
+	}
+	return regionName, nil
+}
+
+// nextAPIKey rotates the API key being used so we can overcome rate limit issues
+func (b *binanceExchange) nextAPIKey() api.ExchangeAPIKey {
+	log.Printf("returning binance API key at index %d", b.apiNextIndex)
+	apiKey := b.apiKeys[b.apiNextIndex]
+	// rotate key for the next call
+	b.apiNextIndex = (b.apiNextIndex + 1) % uint8(len(b.apiKeys))
+	return apiKey
+}
+
+// binanceSignature computes the signature expected in the signature param of signed requests
+func binanceSignature(secret string, query string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(query))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// binanceSymbol converts a trading pair to the symbol used by binance, i.e. XLMUSDT
+func binanceSymbol(c model.AssetConverterInterface, pair model.TradingPair) (string, error) {
+	return pair.ToString(c, "")
+}
+
+// binanceDepthWeight returns the request weight of the depth endpoint for the limit
+func binanceDepthWeight(limit int32) int {
+	if limit <= 100 {
+		return 1
+	} else if limit <= 500 {
+		return 5
+	} else if limit <= 1000 {
+		return 10
+	}
+	return 50
+}
+
+// binancePrecision returns the number of decimals of a step size such as the tickSize or the stepSize of a symbol, i.e. 0.00100000 has 3
+func binancePrecision(stepSize string) (int8, error) {
+	step, e := strconv.ParseFloat(stepSize, 64)
+	if e != nil || step <= 0 {
+		return 0, fmt.Errorf("invalid step size '%s'", stepSize)
+	}
+
+	i := strings.Index(stepSize, ".")
+	if i < 0 {
+		return 0, nil
+	}
+	return int8(len(strings.TrimRight(stepSize[i+1:], "0"))), nil
+}
+
+// request sends a request to the endpoint of the region, signed requests are authenticated with the next API key
+func (b *binanceExchange) request(method string, path string, params url.Values, weight int, signed bool, resp interface{}) error {
+	if params == nil {
+		params = url.Values{}
+	}
+	headers := map[string]string{}
+	var apiKey api.ExchangeAPIKey
+	if signed {
+		apiKey = b.nextAPIKey()
+		params.Set("timestamp", strconv.FormatInt(b.now().UnixNano()/int64(time.Millisecond), 10))
+		params.Set("recvWindow", strconv.Itoa(binanceRecvWindowMillis))
+		headers["X-MBX-APIKEY"] = apiKey.Key
+	}
+
+	// the signature needs to be the last param since binance verifies it against the query that precedes it
+	query := params.Encode()
+	if signed {
+		query = query + "&signature=" + binanceSignature(apiKey.Secret, query)
+	}
+	reqURL := b.region.baseURL + path
+	if query != "" {
+		reqURL = reqURL + "?" + query
+	}
+
+	b.weightLimiter.wait(weight)
+	e := networking.JSONRequest(b.httpClient, method, reqURL, "", headers, resp, "code")
+	if e != nil {
+		return fmt.Errorf("error in request to %s (method=%s, path=%s): %s", b.regionName, method, path, e)
+	}
+	return nil
+}
+
+// AddOrder impl.
+func (b *binanceExchange) AddOrder(order *model.Order) (*model.TransactionID, error) {
+	symbol, e := binanceSymbol(b.assetConverter, *order.Pair)
+	if e != nil {
+		return nil, e
+	}
+
+	if b.isSimulated {
+		log.Printf("not adding order to Binance in simulation mode, order=%s\n", *order)
+		return model.MakeTransactionID("simulated"), nil
+	}
+
+	params, e := binanceOrderParams(order, b.GetOrderConstraints(order.Pair), b.now())
+	if e != nil {
+		return nil, e
+	}
+	params.Set("symbol", symbol)
+	for k, v := range mergeOrderParams(b.orderParams, order) {
+		params.Set(k, v)
+	}
+
+	log.Printf("binance is submitting order: symbol=%s, orderAction=%s, orderType=%s, volume=%s, params=%v\n",
+		symbol, order.OrderAction.String(), order.OrderType.String(), order.Volume.AsString(), params)
+	b.ordersLimiter.wait(1)
+	var resp struct {
+		OrderID int64 `json:"orderId"`
+	}
+	e = b.request("POST", "/api/v3/order", params, binanceWeightDefault, true, &resp)
+	if e != nil {
+		return nil, e
+	}
+	return model.MakeTransactionID(strconv.FormatInt(resp.OrderID, 10)), nil
+}
+
+// binanceOrderParams returns the params of the order to submit, post-only orders are submitted as LIMIT_MAKER orders which binance rejects
+// instead of matching them
+func binanceOrderParams(order *model.Order, orderConstraints *model.OrderConstraints, now time.Time) (url.Values, error) {
+	if order.Volume.Precision() > orderConstraints.VolumePrecision {
+		return nil, fmt.Errorf("binance volume precision can be a maximum of %d, got %d, value = %.12f", orderConstraints.VolumePrecision, order.Volume.Precision(), order.Volume.AsFloat())
+	}
+
+	params := url.Values{}
+	params.Set("side", strings.ToUpper(order.OrderAction.String()))
+	params.Set("quantity", order.Volume.AsString())
+	if order.ClientOrderID != "" {
+		params.Set("newClientOrderId", uniqueClientOrderID(order.ClientOrderID, now))
+	}
+
+	switch order.OrderType {
+	case model.OrderTypeMarket:
+		if order.PostOnly {
+			return nil, fmt.Errorf("binance market orders cannot be post-only")
+		}
+		params.Set("type", "MARKET")
+	case model.OrderTypeLimit:
+		if order.Price.Precision() > orderConstraints.PricePrecision {
+			return nil, fmt.Errorf("binance price precision can be a maximum of %d, got %d, value = %.12f", orderConstraints.PricePrecision, order.Price.Precision(), order.Price.AsFloat())
+		}
+		params.Set("price", order.Price.AsString())
+		if order.PostOnly {
+			params.Set("type", "LIMIT_MAKER")
+		} else {
+			params.Set("type", "LIMIT")
+			params.Set("timeInForce", "GTC")
+		}
+	default:
+		return nil, fmt.Errorf("binance integration only supports limit and market orders, got orderType=%s", order.OrderType.String())
+	}
+	return params, nil
+}
+
+// CancelOrder impl.
+func (b *binanceExchange) CancelOrder(txID *model.TransactionID, pair model.TradingPair) (model.CancelOrderResult, error) {
+	if b.isSimulated {
+		return model.CancelResultCancelSuccessful, nil
+	}
+	log.Printf("binance is canceling order: ID=%s, tradingPair=%s\n", txID.String(), pair.String())
+
+	symbol, e := binanceSymbol(b.assetConverter, pair)
+	if e != nil {
+		return model.CancelResultFailed, e
+	}
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("orderId", txID.String())
+
+	var resp struct {
+		Status string `json:"status"`
+	}
+	e = b.request("DELETE", "/api/v3/order", params, binanceWeightDefault, true, &resp)
+	if e != nil {
+		return model.CancelResultFailed, e
+	}
+	if resp.Status != "CANCELED" {
+		log.Printf("binance could not cancel order (ID=%s), status=%s\n", txID.String(), resp.Status)
+		return model.CancelResultFailed, nil
+	}
+	return model.CancelResultCancelSuccessful, nil
+}
+
+// GetAccountBalances impl.
+func (b *binanceExchange) GetAccountBalances(assetList []interface{}) (map[interface{}]model.Number, error) {
+	var resp struct {
+		Balances []struct {
+			Asset  string `json:"asset"`
+			Free   string `json:"free"`
+			Locked string `json:"locked"`
+		} `json:"balances"`
+	}
+	e := b.request("GET", "/api/v3/account", nil, binanceWeightAccount, true, &resp)
+	if e != nil {
+		return nil, e
+	}
+
+	// the balance includes the amounts locked in open orders
+	balances := map[string]float64{}
+	for _, bal := range resp.Balances {
+		free, e := strconv.ParseFloat(bal.Free, 64)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse free balance '%s' of asset '%s': %s", bal.Free, bal.Asset, e)
+		}
+		locked, e := strconv.ParseFloat(bal.Locked, 64)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse locked balance '%s' of asset '%s': %s", bal.Locked, bal.Asset, e)
+		}
+		balances[bal.Asset] = free + locked
+	}
+
+	m := map[interface{}]model.Number{}
+	for _, elem := range assetList {
+		var asset model.Asset
+		if v, ok := elem.(model.Asset); ok {
+			asset = v
+		} else {
+			return nil, fmt.Errorf("invalid type of asset passed in, only model.Asset accepted")
+		}
+
+		binanceAssetString, e := b.assetConverter.ToString(asset)
+		if e != nil {
+			// discard partially built map for now
+			return nil, e
+		}
+		// assets that were never held have a zero balance
+		m[asset] = *model.NumberFromFloat(balances[binanceAssetString], precisionBalances)
+	}
+	return m, nil
+}
+
+// GetOrderConstraints impl
+func (b *binanceExchange) GetOrderConstraints(pair *model.TradingPair) *model.OrderConstraints {
+	b.constraintsMutex.RLock()
+	oc, ok := b.constraints[*pair]
+	b.constraintsMutex.RUnlock()
+	if !ok {
+		oc, ok = b.region.precisionMatrix[*pair]
+	}
+	if ok {
+		return b.ocOverridesHandler.Apply(pair, &oc)
+	}
+
+	if b.ocOverridesHandler.IsCompletelyOverriden(pair) {
+		override := b.ocOverridesHandler.Get(pair)
+		return model.MakeOrderConstraintsFromOverride(override)
+	}
+	panic(fmt.Sprintf("binanceExchange could not find orderConstraints for trading pair %v on %s", pair, b.regionName))
+}
+
+// binanceExchangeInfo is the response of the exchangeInfo endpoint
+type binanceExchangeInfo struct {
+	RateLimits []binanceRateLimit `json:"rateLimits"`
+	Symbols    []struct {
+		Symbol     string `json:"symbol"`
+		Status     string `json:"status"`
+		BaseAsset  string `json:"baseAsset"`
+		QuoteAsset string `json:"quoteAsset"`
+		Filters    []struct {
+			FilterType  string `json:"filterType"`
+			TickSize    string `json:"tickSize"`
+			StepSize    string `json:"stepSize"`
+			MinQty      string `json:"minQty"`
+			MinNotional string `json:"minNotional"`
+		} `json:"filters"`
+	} `json:"symbols"`
+}
+
+// binanceRateLimit is a rate limit of the exchange, i.e. a limit of 1200 REQUEST_WEIGHT per 1 MINUTE
+type binanceRateLimit struct {
+	RateLimitType string `json:"rateLimitType"`
+	Interval      string `json:"interval"`
+	IntervalNum   int    `json:"intervalNum"`
+	Limit         int    `json:"limit"`
+}
+
+// binanceRateLimitIntervals are the units of the intervals of the rate limits
+var binanceRateLimitIntervals = map[string]time.Duration{
+	"SECOND": time.Second,
+	"MINUTE": time.Minute,
+	"HOUR":   time.Hour,
+	"DAY":    24 * time.Hour,
+}
+
+// duration returns the length of the interval of the rate limit
+func (r binanceRateLimit) duration() (time.Duration, error) {
+	unit, ok := binanceRateLimitIntervals[r.Interval]
+	if !ok || r.IntervalNum <= 0 || r.Limit <= 0 {
+		return 0, fmt.Errorf("invalid binance rate limit: %+v", r)
+	}
+	return time.Duration(r.IntervalNum) * unit, nil
+}
+
+// readBinanceOrderConstraints converts the filters of the trading symbols in the exchangeInfo into order constraints
+func readBinanceOrderConstraints(c model.AssetConverterInterface, info *binanceExchangeInfo) (map[model.TradingPair]model.OrderConstraints, error) {
+	constraints := map[model.TradingPair]model.OrderConstraints{}
+	for _, s := range info.Symbols {
+		if s.Status != "TRADING" {
+			continue
+		}
+		base, e := c.FromString(s.BaseAsset)
+		if e != nil {
+			return nil, e
+		}
+		quote, e := c.FromString(s.QuoteAsset)
+		if e != nil {
+			return nil, e
+		}
+
+		var pricePrecision, volumePrecision int8
+		var minBaseVolume, minQuoteVolume float64
+		hasMinQuoteVolume := false
+		for _, f := range s.Filters {
+			switch f.FilterType {
+			case "PRICE_FILTER":
+				if pricePrecision, e = binancePrecision(f.TickSize); e != nil {
+					return nil, fmt.Errorf("could not read the tickSize of binance symbol '%s': %s", s.Symbol, e)
+				}
+			case "LOT_SIZE":
+				if volumePrecision, e = binancePrecision(f.StepSize); e != nil {
+					return nil, fmt.Errorf("could not read the stepSize of binance symbol '%s': %s", s.Symbol, e)
+				}
+				if minBaseVolume, e = strconv.ParseFloat(f.MinQty, 64); e != nil {
+					return nil, fmt.Errorf("could not parse minQty '%s' of binance symbol '%s': %s", f.MinQty, s.Symbol, e)
+				}
+			case "MIN_NOTIONAL", "NOTIONAL":
+				if minQuoteVolume, e = strconv.ParseFloat(f.MinNotional, 64); e != nil {
+					return nil, fmt.Errorf("could not parse minNotional '%s' of binance symbol '%s': %s", f.MinNotional, s.Symbol, e)
+				}
+				hasMinQuoteVolume = true
+			}
+		}
+
+		oc := model.MakeOrderConstraints(pricePrecision, volumePrecision, minBaseVolume)
+		if hasMinQuoteVolume {
+			oc = model.MakeOrderConstraintsWithCost(pricePrecision, volumePrecision, minBaseVolume, minQuoteVolume)
+		}
+		constraints[*model.MakeTradingPair(base, quote)] = *oc
+	}
+	return constraints, nil
+}
+
+// RefreshOrderConstraints impl, fetches the precisions, minimums and rate limits of the region
+func (b *binanceExchange) RefreshOrderConstraints() error {
+	info := binanceExchangeInfo{}
+	e := b.request("GET", "/api/v3/exchangeInfo", nil, binanceWeightExchangeInfo, false, &info)
+	if e != nil {
+		return fmt.Errorf("could not fetch exchange info: %s", e)
+	}
+
+	constraints, e := readBinanceOrderConstraints(b.assetConverter, &info)
+	if e != nil {
+		return e
+	}
+	e = b.applyRateLimits(info.RateLimits)
+	if e != nil {
+		return e
+	}
+
+	b.constraintsMutex.Lock()
+	b.constraints = constraints
+	b.constraintsMutex.Unlock()
+	log.Printf("refreshed order constraints for %d trading pairs and the rate limits (%s, %s) of %s\n", len(constraints), b.weightLimiter, b.ordersLimiter, b.regionName)
+	return nil
+}
+
+// applyRateLimits limits the requests to the request weight limit and the orders to the orders limit with the shortest interval
+func (b *binanceExchange) applyRateLimits(rateLimits []binanceRateLimit) error {
+	limiters := map[string]*binanceRateLimiter{
+		"REQUEST_WEIGHT": b.weightLimiter,
+		"ORDERS":         b.ordersLimiter,
+	}
+	shortest := map[string]time.Duration{}
+	for _, r := range rateLimits {
+		limiter, ok := limiters[r.RateLimitType]
+		if !ok {
+			continue
+		}
+		interval, e := r.duration()
+		if e != nil {
+			return e
+		}
+		if s, ok := shortest[r.RateLimitType]; ok && s <= interval {
+			continue
+		}
+		shortest[r.RateLimitType] = interval
+		limiter.setLimit(r.Limit, interval)
+	}
+	return nil
+}
+
+// OverrideOrderConstraints impl, can partially override values for specific pairs
+func (b *binanceExchange) OverrideOrderConstraints(pair *model.TradingPair, override *model.OrderConstraintsOverride) {
+	b.ocOverridesHandler.Upsert(pair, override)
+}
+
+// GetAssetConverter impl.
+func (b *binanceExchange) GetAssetConverter() model.AssetConverterInterface {
+	return b.assetConverter
+}
+
+// GetServerTime impl.
+func (b *binanceExchange) GetServerTime() (time.Time, error) {
+	var resp struct {
+		ServerTime int64 `json:"serverTime"`
+	}
+	e := b.request("GET", "/api/v3/time", nil, binanceWeightDefault, false, &resp)
+	if e != nil {
+		return time.Time{}, fmt.Errorf("error fetching the server time: %s", e)
+	}
+	return time.Unix(0, resp.ServerTime*int64(time.Millisecond)), nil
+}
+
+// binanceOrder is an order in the response of the openOrders endpoint
+type binanceOrder struct {
+	Symbol        string `json:"symbol"`
+	OrderID       int64  `json:"orderId"`
+	ClientOrderID string `json:"clientOrderId"`
+	Price         string `json:"price"`
+	OrigQty       string `json:"origQty"`
+	ExecutedQty   string `json:"executedQty"`
+	Type          string `json:"type"`
+	Side          string `json:"side"`
+	Time          int64  `json:"time"`
+}
+
+// GetOpenOrders impl.
+func (b *binanceExchange) GetOpenOrders(pairs []*model.TradingPair) (map[model.TradingPair][]model.OpenOrder, error) {
+	m := map[model.TradingPair][]model.OpenOrder{}
+	for _, p := range pairs {
+		symbol, e := binanceSymbol(b.assetConverter, *p)
+		if e != nil {
+			return nil, e
+		}
+		params := url.Values{}
+		params.Set("symbol", symbol)
+
+		orders := []binanceOrder{}
+		e = b.request("GET", "/api/v3/openOrders", params, binanceWeightOpenOrders, true, &orders)
+		if e != nil {
+			return nil, fmt.Errorf("cannot load open orders for Binance: %s", e)
+		}
+
+		m[*p] = []model.OpenOrder{}
+		for _, o := range orders {
+			openOrder, e := readBinanceOpenOrder(p, o, b.GetOrderConstraints(p))
+			if e != nil {
+				return nil, e
+			}
+			m[*p] = append(m[*p], *openOrder)
+		}
+	}
+	return m, nil
+}
+
+// readBinanceOpenOrder converts an order of the openOrders endpoint
+func readBinanceOpenOrder(pair *model.TradingPair, o binanceOrder, orderConstraints *model.OrderConstraints) (*model.OpenOrder, error) {
+	var orderAction model.OrderAction
+	switch o.Side {
+	case "BUY":
+		orderAction = model.OrderActionBuy
+	case "SELL":
+		orderAction = model.OrderActionSell
+	default:
+		return nil, fmt.Errorf("invalid side '%s' of binance order %d", o.Side, o.OrderID)
+	}
+	price, e := model.NumberFromString(o.Price, orderConstraints.PricePrecision)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse price '%s' of binance order %d: %s", o.Price, o.OrderID, e)
+	}
+	volume, e := model.NumberFromString(o.OrigQty, orderConstraints.VolumePrecision)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse origQty '%s' of binance order %d: %s", o.OrigQty, o.OrderID, e)
+	}
+	executed, e := model.NumberFromString(o.ExecutedQty, orderConstraints.VolumePrecision)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse executedQty '%s' of binance order %d: %s", o.ExecutedQty, o.OrderID, e)
+	}
+
+	orderType := model.OrderTypeLimit
+	if o.Type == "MARKET" {
+		orderType = model.OrderTypeMarket
+	}
+	ts := model.MakeTimestamp(o.Time)
+	return &model.OpenOrder{
+		Order: model.Order{
+			Pair:          pair,
+			OrderAction:   orderAction,
+			OrderType:     orderType,
+			Price:         price,
+			Volume:        volume,
+			Timestamp:     ts,
+			PostOnly:      o.Type == "LIMIT_MAKER",
+			ClientOrderID: clientOrderIDTag(o.ClientOrderID),
+		},
+		ID:             strconv.FormatInt(o.OrderID, 10),
+		StartTime:      ts,
+		ExpireTime:     nil,
+		VolumeExecuted: executed,
+	}, nil
+}
+
+// GetOrderBook impl.
+func (b *binanceExchange) GetOrderBook(pair *model.TradingPair, maxCount int32) (*model.OrderBook, error) {
+	symbol, e := binanceSymbol(b.assetConverter, *pair)
+	if e != nil {
+		return nil, e
+	}
+
+	// use the smallest allowed limit that can satisfy maxCount
+	limit := binanceOrderBookLimits[len(binanceOrderBookLimits)-1]
+	for _, l := range binanceOrderBookLimits {
+		if l >= maxCount {
+			limit = l
+			break
+		}
+	}
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("limit", strconv.Itoa(int(limit)))
+
+	var resp struct {
+		Bids [][]string `json:"bids"`
+		Asks [][]string `json:"asks"`
+	}
+	e = b.request("GET", "/api/v3/depth", params, binanceDepthWeight(limit), false, &resp)
+	if e != nil {
+		return nil, e
+	}
+
+	orderConstraints := b.GetOrderConstraints(pair)
+	ts := model.MakeTimestamp(b.now().UnixNano() / int64(time.Millisecond))
+	asks, e := readBinanceOrderBookSide(pair, model.OrderActionSell, resp.Asks, maxCount, orderConstraints, ts)
+	if e != nil {
+		return nil, e
+	}
+	bids, e := readBinanceOrderBookSide(pair, model.OrderActionBuy, resp.Bids, maxCount, orderConstraints, ts)
+	if e != nil {
+		return nil, e
+	}
+	return model.MakeOrderBook(pair, asks, bids), nil
+}
+
+// readBinanceOrderBookSide converts the levels of one side of the depth endpoint, each level is [PRICE, QTY]
+func readBinanceOrderBookSide(
+	pair *model.TradingPair,
+	orderAction model.OrderAction,
+	levels [][]string,
+	maxCount int32,
+	orderConstraints *model.OrderConstraints,
+	ts *model.Timestamp,
+) ([]model.Order, error) {
+	orders := []model.Order{}
+	for _, level := range levels {
+		if int32(len(orders)) >= maxCount {
+			break
+		}
+		if len(level) < 2 {
+			return nil, fmt.Errorf("unexpected orderbook level in binance response: %v", level)
+		}
+		price, e := model.NumberFromString(level[0], orderConstraints.PricePrecision)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse price of orderbook level %v: %s", level, e)
+		}
+		volume, e := model.NumberFromString(level[1], orderConstraints.VolumePrecision)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse volume of orderbook level %v: %s", level, e)
+		}
+		orders = append(orders, model.Order{
+			Pair:        pair,
+			OrderAction: orderAction,
+			OrderType:   model.OrderTypeLimit,
+			Price:       price,
+			Volume:      volume,
+			Timestamp:   ts,
+		})
+	}
+	return orders, nil
+}
+
+// GetTickerPrice impl.
+func (b *binanceExchange) GetTickerPrice(pairs []model.TradingPair) (map[model.TradingPair]api.Ticker, error) {
+	priceResult := map[model.TradingPair]api.Ticker{}
+	for _, p := range pairs {
+		symbol, e := binanceSymbol(b.assetConverter, p)
+		if e != nil {
+			return nil, e
+		}
+		params := url.Values{}
+		params.Set("symbol", symbol)
+
+		var resp struct {
+			BidPrice string `json:"bidPrice"`
+			AskPrice string `json:"askPrice"`
+		}
+		e = b.request("GET", "/api/v3/ticker/bookTicker", params, binanceWeightDefault, false, &resp)
+		if e != nil {
+			return nil, e
+		}
+
+		orderConstraints := b.GetOrderConstraints(&p)
+		bid, e := model.NumberFromString(resp.BidPrice, orderConstraints.PricePrecision)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse bidPrice '%s' of binance symbol '%s': %s", resp.BidPrice, symbol, e)
+		}
+		ask, e := model.NumberFromString(resp.AskPrice, orderConstraints.PricePrecision)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse askPrice '%s' of binance symbol '%s': %s", resp.AskPrice, symbol, e)
+		}
+		priceResult[p] = api.Ticker{
+			AskPrice: ask,
+			BidPrice: bid,
+		}
+	}
+	return priceResult, nil
+}
+
+// binanceCandleIntervals are the intervals supported by the klines endpoint of binance
+var binanceCandleIntervals = map[time.Duration]string{
+	time.Minute:         "1m",
+	3 * time.Minute:     "3m",
+	5 * time.Minute:     "5m",
+	15 * time.Minute:    "15m",
+	30 * time.Minute:    "30m",
+	time.Hour:           "1h",
+	2 * time.Hour:       "2h",
+	4 * time.Hour:       "4h",
+	6 * time.Hour:       "6h",
+	8 * time.Hour:       "8h",
+	12 * time.Hour:      "12h",
+	24 * time.Hour:      "1d",
+	3 * 24 * time.Hour:  "3d",
+	7 * 24 * time.Hour:  "1w",
+	30 * 24 * time.Hour: "1M",
+}
+
+// GetCandles impl
+func (b *binanceExchange) GetCandles(pair *model.TradingPair, interval time.Duration, since *model.Timestamp) ([]model.Candle, error) {
+	klineInterval, ok := binanceCandleIntervals[interval]
+	if !ok {
+		return nil, fmt.Errorf("unsupported candle interval for binance: %s", interval)
+	}
+	symbol, e := binanceSymbol(b.assetConverter, *pair)
+	if e != nil {
+		return nil, e
+	}
+
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("interval", klineInterval)
+	if since != nil {
+		params.Set("startTime", strconv.FormatInt(since.AsInt64(), 10))
+	}
+	rows := [][]interface{}{}
+	e = b.request("GET", "/api/v3/klines", params, binanceWeightDefault, false, &rows)
+	if e != nil {
+		return nil, e
+	}
+	return readBinanceCandles(pair, interval, rows, b.GetOrderConstraints(pair))
+}
+
+// readBinanceCandles converts the rows of the klines endpoint of binance, each row starts with [OPEN_TIME, OPEN, HIGH, LOW, CLOSE, VOLUME]
+func readBinanceCandles(pair *model.TradingPair, interval time.Duration, rows [][]interface{}, orderConstraints *model.OrderConstraints) ([]model.Candle, error) {
+	candles := []model.Candle{}
+	for _, row := range rows {
+		if len(row) < 6 {
+			return nil, fmt.Errorf("unexpected candle in binance response: %v", row)
+		}
+		openTime, ok := row[0].(float64)
+		if !ok {
+			return nil, fmt.Errorf("could not parse open time of binance candle: %v", row)
+		}
+		values := []*model.Number{}
+		for i := 1; i <= 5; i++ {
+			precision := orderConstraints.PricePrecision
+			if i == 5 {
+				precision = orderConstraints.VolumePrecision
+			}
+			s, ok := row[i].(string)
+			if !ok {
+				return nil, fmt.Errorf("could not parse field at index %d of binance candle: %v", i, row)
+			}
+			n, e := model.NumberFromString(s, precision)
+			if e != nil {
+				return nil, fmt.Errorf("could not parse field at index %d of binance candle %v: %s", i, row, e)
+			}
+			values = append(values, n)
+		}
+
+		candles = append(candles, model.Candle{
+			Pair:      pair,
+			Timestamp: model.MakeTimestamp(int64(openTime)),
+			Interval:  interval,
+			Open:      values[0],
+			High:      values[1],
+			Low:       values[2],
+			Close:     values[3],
+			Volume:    values[4],
+		})
+	}
+	return candles, nil
+}
+
+// binanceTrade is a trade of the account in the response of the myTrades endpoint
+type binanceTrade struct {
+	ID              int64  `json:"id"`
+	OrderID         int64  `json:"orderId"`
+	Price           string `json:"price"`
+	Qty             string `json:"qty"`
+	QuoteQty        string `json:"quoteQty"`
+	Commission      string `json:"commission"`
+	CommissionAsset string `json:"commissionAsset"`
+	Time            int64  `json:"time"`
+	IsBuyer         bool   `json:"isBuyer"`
+}
+
+// GetTradeHistory impl, cursors are timestamps in milliseconds represented as strings. binance returns the trades of at most 24 hours
+// in one request so the cursor moves past a range without trades once the range is over.
+func (b *binanceExchange) GetTradeHistory(pair model.TradingPair, maybeCursorStart interface{}, maybeCursorEnd interface{}) (*api.TradeHistoryResult, error) {
+	symbol, e := binanceSymbol(b.assetConverter, pair)
+	if e != nil {
+		return nil, e
+	}
+
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("limit", strconv.Itoa(binanceTradesLimit))
+	var cursorStart *string
+	var windowEnd int64
+	if maybeCursorStart != nil {
+		s := maybeCursorStart.(string)
+		start, e := strconv.ParseInt(s, 10, 64)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse start cursor '%s' for binance: %s", s, e)
+		}
+		params.Set("startTime", s)
+		cursorStart = &s
+		windowEnd = start + int64(binanceTradeHistoryWindow/time.Millisecond) - 1
+	}
+	if maybeCursorEnd != nil {
+		s := maybeCursorEnd.(string)
+		end, e := strconv.ParseInt(s, 10, 64)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse end cursor '%s' for binance: %s", s, e)
+		}
+		if cursorStart == nil || end < windowEnd {
+			windowEnd = end
+		}
+	}
+	if windowEnd > 0 {
+		params.Set("endTime", strconv.FormatInt(windowEnd, 10))
+	}
+
+	trades := []binanceTrade{}
+	e = b.request("GET", "/api/v3/myTrades", params, binanceWeightMyTrades, true, &trades)
+	if e != nil {
+		return nil, e
+	}
+	res := api.TradeHistoryResult{}
+	res.Trades, e = b.readTrades(pair, trades)
+	if e != nil {
+		return nil, e
+	}
+
+	// sort to be in ascending order
+	sort.Sort(model.TradesByTsID(res.Trades))
+
+	// set correct value for cursor
+	nowMillis := b.now().UnixNano() / int64(time.Millisecond)
+	if len(res.Trades) > 0 {
+		lastCursor := res.Trades[len(res.Trades)-1].Order.Timestamp.AsInt64()
+		// add 1 to lastCursor so we don't repeat the same cursor on the next run
+		res.Cursor = strconv.FormatInt(lastCursor+1, 10)
+	} else if cursorStart != nil && windowEnd < nowMillis {
+		// there were no trades in a range that is over
+		res.Cursor = strconv.FormatInt(windowEnd+1, 10)
+	} else if cursorStart != nil {
+		res.Cursor = *cursorStart
+	} else {
+		res.Cursor = nil
+	}
+
+	return &res, nil
+}
+
+// readTrades converts the trades of the account in a response of the myTrades endpoint
+func (b *binanceExchange) readTrades(pair model.TradingPair, trades []binanceTrade) ([]model.Trade, error) {
+	orderConstraints := b.GetOrderConstraints(&pair)
+	// for now use the max precision between price and volume for fee and cost
+	feeCostPrecision := orderConstraints.PricePrecision
+	if orderConstraints.VolumePrecision > feeCostPrecision {
+		feeCostPrecision = orderConstraints.VolumePrecision
+	}
+
+	result := []model.Trade{}
+	for _, t := range trades {
+		price, e := model.NumberFromString(t.Price, orderConstraints.PricePrecision)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse price '%s' of binance trade %d: %s", t.Price, t.ID, e)
+		}
+		volume, e := model.NumberFromString(t.Qty, orderConstraints.VolumePrecision)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse qty '%s' of binance trade %d: %s", t.Qty, t.ID, e)
+		}
+		cost, e := model.NumberFromString(t.QuoteQty, feeCostPrecision)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse quoteQty '%s' of binance trade %d: %s", t.QuoteQty, t.ID, e)
+		}
+		fee, e := model.NumberFromString(t.Commission, feeCostPrecision)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse commission '%s' of binance trade %d: %s", t.Commission, t.ID, e)
+		}
+		feeAsset, e := b.assetConverter.FromString(t.CommissionAsset)
+		if e != nil {
+			return nil, e
+		}
+
+		orderAction := model.OrderActionSell
+		if t.IsBuyer {
+			orderAction = model.OrderActionBuy
+		}
+		result = append(result, model.Trade{
+			Order: model.Order{
+				Pair:        &pair,
+				OrderAction: orderAction,
+				OrderType:   model.OrderTypeLimit,
+				Price:       price,
+				Volume:      volume,
+				Timestamp:   model.MakeTimestamp(t.Time),
+			},
+			TransactionID: model.MakeTransactionID(strconv.FormatInt(t.ID, 10)),
+			Cost:          cost,
+			Fee:           fee,
+			FeeAsset:      feeAsset,
+		})
+	}
+	return result, nil
+}
+
+// GetTradesForOrder impl
+func (b *binanceExchange) GetTradesForOrder(txID *model.TransactionID, pair model.TradingPair) ([]model.Trade, error) {
+	if b.isSimulated {
+		return []model.Trade{}, nil
+	}
+	symbol, e := binanceSymbol(b.assetConverter, pair)
+	if e != nil {
+		return nil, e
+	}
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("orderId", txID.String())
+
+	trades := []binanceTrade{}
+	e = b.request("GET", "/api/v3/myTrades", params, binanceWeightMyTrades, true, &trades)
+	if e != nil {
+		return nil, fmt.Errorf("error fetching trades of order '%s': %s", txID.String(), e)
+	}
+	result, e := b.readTrades(pair, trades)
+	if e != nil {
+		return nil, e
+	}
+
+	// sort to be in ascending order
+	sort.Sort(model.TradesByTsID(result))
+	return result, nil
+}
+
+// GetLatestTradeCursor impl.
+func (b *binanceExchange) GetLatestTradeCursor() (interface{}, error) {
+	timeNowMillis := b.now().UnixNano() / int64(time.Millisecond)
+	latestTradeCursor := fmt.Sprintf("%d", timeNowMillis)
+	return latestTradeCursor, nil
+}
+
+// GetTrades impl, cursors are aggregate trade IDs represented as int64
+func (b *binanceExchange) GetTrades(pair *model.TradingPair, maybeCursor interface{}) (*api.TradesResult, error) {
+	symbol, e := binanceSymbol(b.assetConverter, *pair)
+	if e != nil {
+		return nil, e
+	}
+
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("limit", strconv.Itoa(binanceTradesLimit))
+	var cursor interface{}
+	if maybeCursor != nil {
+		mc := maybeCursor.(int64)
+		params.Set("fromId", strconv.FormatInt(mc, 10))
+		cursor = mc
+	}
+
+	var aggTrades []struct {
+		ID           int64  `json:"a"`
+		Price        string `json:"p"`
+		Qty          string `json:"q"`
+		Time         int64  `json:"T"`
+		IsBuyerMaker bool   `json:"m"`
+	}
+	e = b.request("GET", "/api/v3/aggTrades", params, binanceWeightDefault, false, &aggTrades)
+	if e != nil {
+		return nil, e
+	}
+
+	orderConstraints := b.GetOrderConstraints(pair)
+	tradesResult := &api.TradesResult{Trades: []model.Trade{}}
+	for _, t := range aggTrades {
+		price, e := model.NumberFromString(t.Price, orderConstraints.PricePrecision)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse price '%s' of binance aggregate trade %d: %s", t.Price, t.ID, e)
+		}
+		volume, e := model.NumberFromString(t.Qty, orderConstraints.VolumePrecision)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse qty '%s' of binance aggregate trade %d: %s", t.Qty, t.ID, e)
+		}
+
+		// the taker was selling when the buyer was the maker
+		orderAction := model.OrderActionBuy
+		if t.IsBuyerMaker {
+			orderAction = model.OrderActionSell
+		}
+		tradesResult.Trades = append(tradesResult.Trades, model.Trade{
+			Order: model.Order{
+				Pair:        pair,
+				OrderAction: orderAction,
+				OrderType:   model.OrderTypeLimit,
+				Price:       price,
+				Volume:      volume,
+				Timestamp:   model.MakeTimestamp(t.Time),
+			},
+			TransactionID: model.MakeTransactionID(strconv.FormatInt(t.ID, 10)),
+			// Cost unavailable
+			// Fee unavailable
+		})
+
+		// add 1 to the last ID so we don't repeat the same trade on the next run
+		cursor = t.ID + 1
+	}
+
+	// sort to be in ascending order
+	sort.Sort(model.TradesByTsID(tradesResult.Trades))
+	tradesResult.Cursor = cursor
+
+	return tradesResult, nil
+}
+
+// GetWithdrawInfo impl.
+func (b *binanceExchange) GetWithdrawInfo(
+	asset model.Asset,
+	amountToWithdraw *model.Number,
+	address string,
+) (*api.WithdrawInfo, error) {
+	return nil, fmt.Errorf("withdrawals are not supported by the binance integration")
+}
+
+// PrepareDeposit impl.
+func (b *binanceExchange) PrepareDeposit(asset model.Asset, amount *model.Number) (*api.PrepareDepositResult, error) {
+	return nil, fmt.Errorf("deposits are not supported by the binance integration")
+}
+
+// WithdrawFunds impl.
+func (b *binanceExchange) WithdrawFunds(
+	asset model.Asset,
+	amountToWithdraw *model.Number,
+	address string,
+) (*api.WithdrawFunds, error) {
+	return nil, fmt.Errorf("withdrawals are not supported by the binance integration")
+}
+
+// binanceRateLimiter is a token bucket that keeps the requests within one of the rate limits of binance, which are counted in request
+// weight or in orders per interval. A request that needs more tokens than are available waits until the bucket has refilled.
+type binanceRateLimiter struct {
+	name       string
+	mutex      *sync.Mutex
+	limit      int
+	interval   time.Duration
+	tokens     float64
+	lastRefill time.Time
+	now        func() time.Time
+}
+
+// makeBinanceRateLimiter is a factory method
+func makeBinanceRateLimiter(name string, limit int, interval time.Duration) *binanceRateLimiter {
+	return &binanceRateLimiter{
+		name:       name,
+		mutex:      &sync.Mutex{},
+		limit:      limit,
+		interval:   interval,
+		tokens:     float64(limit),
+		lastRefill: time.Now(),
+		now:        time.Now,
+	}
+}
+
+// String is the stringer function
+func (l *binanceRateLimiter) String() string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return fmt.Sprintf("%s: %d per %s", l.name, l.limit, l.interval)
+}
+
+// setLimit changes the limit, the tokens that are available are capped at the new limit
+func (l *binanceRateLimiter) setLimit(limit int, interval time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.limit = limit
+	l.interval = interval
+	if l.tokens > float64(limit) {
+		l.tokens = float64(limit)
+	}
+}
+
+// reserve takes n tokens from the bucket and returns how long the caller needs to wait before sending the request
+func (l *binanceRateLimiter) reserve(n int) time.Duration {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	ratePerSecond := float64(l.limit) / l.interval.Seconds()
+	l.tokens += now.Sub(l.lastRefill).Seconds() * ratePerSecond
+	if l.tokens > float64(l.limit) {
+		l.tokens = float64(l.limit)
+	}
+	l.lastRefill = now
+
+	l.tokens -= float64(n)
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / ratePerSecond * float64(time.Second))
+}
+
+// wait blocks until a request that counts n towards the limit can be sent
+func (l *binanceRateLimiter) wait(n int) {
+	delay := l.reserve(n)
+	if delay <= 0 {
+		return
+	}
+
+	log.Printf("binance rate limit reached, waiting %s before sending a request that counts %d (%s)\n", delay, n, l)
+	time.Sleep(delay)
+}
@@ -0,0 +1,361 @@
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBinanceRegion(t *testing.T) {
+	testCases := []struct {
+		params     []api.ExchangeParam
+		wantRegion string
+		wantErr    bool
+	}{
+		{
+			params:     nil,
+			wantRegion: "binance.com",
+		}, {
+			params:     []api.ExchangeParam{{Param: "region", Value: "binance.us"}},
+			wantRegion: "binance.us",
+		}, {
+			params:     []api.ExchangeParam{{Param: "orderbook_tick_size", Value: "0.0001"}, {Param: "region", Value: "binance.je"}},
+			wantRegion: "binance.je",
+		}, {
+			params:  []api.ExchangeParam{{Param: "region", Value: "binance.xyz"}},
+			wantErr: true,
+		},
+	}
+
+	for i, kase := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			region, e := parseBinanceRegion(kase.params)
+			if kase.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, kase.wantRegion, region)
+		})
+	}
+}
+
+func TestBinancePrecision(t *testing.T) {
+	testCases := []struct {
+		stepSize      string
+		wantPrecision int8
+		wantErr       bool
+	}{
+		{stepSize: "0.00001000", wantPrecision: 5},
+		{stepSize: "0.10000000", wantPrecision: 1},
+		{stepSize: "1.00000000", wantPrecision: 0},
+		{stepSize: "10", wantPrecision: 0},
+		{stepSize: "0.00000000", wantErr: true},
+		{stepSize: "abc", wantErr: true},
+	}
+
+	for _, kase := range testCases {
+		t.Run(kase.stepSize, func(t *testing.T) {
+			precision, e := binancePrecision(kase.stepSize)
+			if kase.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, kase.wantPrecision, precision)
+		})
+	}
+}
+
+func TestBinanceSignature(t *testing.T) {
+	// example from the API documentation of binance
+	query := "symbol=LTCBTC&side=BUY&type=LIMIT&timeInForce=GTC&quantity=1&price=0.1&recvWindow=5000&timestamp=1499827319559"
+	secret := "NhqPtmdSJYdKjVHjA7PZj4Mge3R5YNiP1e3UZjInClVN65XAbvqqM6A7H5fATj0j"
+	assert.Equal(t, "c8db56825ae71d6d79447849e617115f4a920fa2acdcab2b053c4b2838bd6b71", binanceSignature(secret, query))
+}
+
+func TestBinanceOrderParams(t *testing.T) {
+	pair := model.MakeTradingPair(model.XLM, model.USDT)
+	oc := model.MakeOrderConstraints(5, 1, 0.1)
+	now := time.Unix(0, 1000)
+	testCases := []struct {
+		name       string
+		order      model.Order
+		wantParams url.Values
+		wantErr    bool
+	}{
+		{
+			name: "limit",
+			order: model.Order{
+				Pair:        pair,
+				OrderAction: model.OrderActionBuy,
+				OrderType:   model.OrderTypeLimit,
+				Price:       model.NumberFromFloat(0.12345, 5),
+				Volume:      model.NumberFromFloat(100.5, 1),
+			},
+			wantParams: url.Values{
+				"side":        {"BUY"},
+				"quantity":    {"100.5"},
+				"price":       {"0.12345"},
+				"type":        {"LIMIT"},
+				"timeInForce": {"GTC"},
+			},
+		}, {
+			name: "post-only",
+			order: model.Order{
+				Pair:          pair,
+				OrderAction:   model.OrderActionSell,
+				OrderType:     model.OrderTypeLimit,
+				Price:         model.NumberFromFloat(0.12345, 5),
+				Volume:        model.NumberFromFloat(100.5, 1),
+				PostOnly:      true,
+				ClientOrderID: "123",
+			},
+			wantParams: url.Values{
+				"side":             {"SELL"},
+				"quantity":         {"100.5"},
+				"price":            {"0.12345"},
+				"type":             {"LIMIT_MAKER"},
+				"newClientOrderId": {"123-rs"},
+			},
+		}, {
+			name: "market",
+			order: model.Order{
+				Pair:        pair,
+				OrderAction: model.OrderActionSell,
+				OrderType:   model.OrderTypeMarket,
+				Volume:      model.NumberFromFloat(100.5, 1),
+			},
+			wantParams: url.Values{
+				"side":     {"SELL"},
+				"quantity": {"100.5"},
+				"type":     {"MARKET"},
+			},
+		}, {
+			name: "price precision",
+			order: model.Order{
+				Pair:        pair,
+				OrderAction: model.OrderActionBuy,
+				OrderType:   model.OrderTypeLimit,
+				Price:       model.NumberFromFloat(0.123456, 6),
+				Volume:      model.NumberFromFloat(100.5, 1),
+			},
+			wantErr: true,
+		}, {
+			name: "stop loss",
+			order: model.Order{
+				Pair:        pair,
+				OrderAction: model.OrderActionBuy,
+				OrderType:   model.OrderTypeStopLoss,
+				Volume:      model.NumberFromFloat(100.5, 1),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, kase := range testCases {
+		t.Run(kase.name, func(t *testing.T) {
+			params, e := binanceOrderParams(&kase.order, oc, now)
+			if kase.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, kase.wantParams, params)
+		})
+	}
+}
+
+func TestReadBinanceOrderConstraints(t *testing.T) {
+	info := binanceExchangeInfo{}
+	e := json.Unmarshal([]byte(`{
+		"rateLimits": [],
+		"symbols": [{
+			"symbol": "XLMUSD",
+			"status": "TRADING",
+			"baseAsset": "XLM",
+			"quoteAsset": "USD",
+			"filters": [
+				{"filterType": "PRICE_FILTER", "minPrice": "0.00010000", "maxPrice": "1000.00000000", "tickSize": "0.00010000"},
+				{"filterType": "LOT_SIZE", "minQty": "0.10000000", "maxQty": "9000000.00000000", "stepSize": "0.10000000"},
+				{"filterType": "MIN_NOTIONAL", "minNotional": "10.00000000"}
+			]
+		}, {
+			"symbol": "XLMBTC",
+			"status": "BREAK",
+			"baseAsset": "XLM",
+			"quoteAsset": "BTC",
+			"filters": []
+		}]
+	}`), &info)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	constraints, e := readBinanceOrderConstraints(model.BinanceAssetConverter, &info)
+	if !assert.NoError(t, e) {
+		return
+	}
+	if !assert.Equal(t, 1, len(constraints)) {
+		return
+	}
+	oc, ok := constraints[*model.MakeTradingPair(model.XLM, model.USD)]
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.Equal(t, int8(4), oc.PricePrecision)
+	assert.Equal(t, int8(1), oc.VolumePrecision)
+	assert.Equal(t, 0.1, oc.MinBaseVolume.AsFloat())
+	if assert.NotNil(t, oc.MinQuoteVolume) {
+		assert.Equal(t, 10.0, oc.MinQuoteVolume.AsFloat())
+	}
+}
+
+func TestBinanceApplyRateLimits(t *testing.T) {
+	b := newBinanceExchange("binance.us", []api.ExchangeAPIKey{{}}, map[string]string{}, true)
+	e := b.applyRateLimits([]binanceRateLimit{
+		{RateLimitType: "REQUEST_WEIGHT", Interval: "MINUTE", IntervalNum: 1, Limit: 600},
+		{RateLimitType: "ORDERS", Interval: "DAY", IntervalNum: 1, Limit: 200000},
+		{RateLimitType: "ORDERS", Interval: "SECOND", IntervalNum: 10, Limit: 100},
+		{RateLimitType: "RAW_REQUESTS", Interval: "MINUTE", IntervalNum: 5, Limit: 5000},
+	})
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 600, b.weightLimiter.limit)
+	assert.Equal(t, time.Minute, b.weightLimiter.interval)
+	assert.Equal(t, 100, b.ordersLimiter.limit)
+	assert.Equal(t, 10*time.Second, b.ordersLimiter.interval)
+
+	e = b.applyRateLimits([]binanceRateLimit{{RateLimitType: "ORDERS", Interval: "WEEK", IntervalNum: 1, Limit: 10}})
+	assert.Error(t, e)
+}
+
+func TestBinanceRateLimiter(t *testing.T) {
+	start := time.Unix(1000, 0)
+	now := start
+	l := makeBinanceRateLimiter("request weight", 60, time.Minute)
+	l.lastRefill = start
+	l.now = func() time.Time { return now }
+
+	// the bucket starts out full
+	assert.Equal(t, time.Duration(0), l.reserve(50))
+	assert.Equal(t, time.Duration(0), l.reserve(10))
+	// the next request waits until 5 tokens were refilled at 1 per second
+	assert.Equal(t, 5*time.Second, l.reserve(5))
+
+	// the tokens that were taken on credit are repaid first
+	now = start.Add(10 * time.Second)
+	assert.Equal(t, time.Duration(0), l.reserve(5))
+	assert.Equal(t, time.Second, l.reserve(1))
+
+	// a lower limit caps the tokens that are available
+	now = start.Add(time.Hour)
+	l.setLimit(10, time.Second)
+	assert.Equal(t, time.Duration(0), l.reserve(10))
+	assert.Equal(t, 100*time.Millisecond, l.reserve(1))
+}
+
+func TestReadBinanceCandles(t *testing.T) {
+	pair := model.MakeTradingPair(model.XLM, model.USDT)
+	rows := [][]interface{}{}
+	e := json.Unmarshal([]byte(`[
+		[1546300800000, "0.11720", "0.11910", "0.11650", "0.11850", "125000.5", 1546304399999, "14812.5", 120, "60000.0", "7100.0", "0"],
+		[1546304400000, "0.11850", "0.11880", "0.11760", "0.11790", "98000.2", 1546307999999, "11600.1", 98, "50000.0", "5900.0", "0"]
+	]`), &rows)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	candles, e := readBinanceCandles(pair, time.Hour, rows, model.MakeOrderConstraints(5, 1, 0.1))
+	if !assert.NoError(t, e) {
+		return
+	}
+	if !assert.Equal(t, 2, len(candles)) {
+		return
+	}
+	assert.Equal(t, int64(1546300800000), candles[0].Timestamp.AsInt64())
+	assert.Equal(t, time.Hour, candles[0].Interval)
+	assert.Equal(t, 0.1172, candles[0].Open.AsFloat())
+	assert.Equal(t, 0.1191, candles[0].High.AsFloat())
+	assert.Equal(t, 0.1165, candles[0].Low.AsFloat())
+	assert.Equal(t, 0.1185, candles[0].Close.AsFloat())
+	assert.Equal(t, 125000.5, candles[0].Volume.AsFloat())
+
+	_, e = readBinanceCandles(pair, time.Hour, [][]interface{}{{1546300800000.0, "0.1172"}}, model.MakeOrderConstraints(5, 1, 0.1))
+	assert.Error(t, e)
+}
+
+func TestBinanceGetTradeHistory(t *testing.T) {
+	var gotQuery url.Values
+	var gotAPIKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query()
+		gotAPIKey = r.Header.Get("X-MBX-APIKEY")
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("startTime") == "1000" {
+			w.Write([]byte(`[{"symbol": "XLMUSD", "id": 28457, "orderId": 100234, "price": "0.1000", "qty": "12.0", "quoteQty": "1.2",
+				"commission": "0.0012", "commissionAsset": "USD", "time": 5000, "isBuyer": true, "isMaker": false}]`))
+			return
+		}
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	b := newBinanceExchange("binance.us", []api.ExchangeAPIKey{{Key: "key", Secret: "secret"}}, map[string]string{}, false)
+	b.region.baseURL = server.URL
+	now := time.Unix(0, 2*int64(binanceTradeHistoryWindow))
+	b.now = func() time.Time { return now }
+	pair := *model.MakeTradingPair(model.XLM, model.USD)
+
+	res, e := b.GetTradeHistory(pair, "1000", nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, "key", gotAPIKey)
+	assert.Equal(t, "XLMUSD", gotQuery.Get("symbol"))
+	assert.Equal(t, fmt.Sprintf("%d", 1000+int64(binanceTradeHistoryWindow/time.Millisecond)-1), gotQuery.Get("endTime"))
+	assert.NotEqual(t, "", gotQuery.Get("signature"))
+	if !assert.Equal(t, 1, len(res.Trades)) {
+		return
+	}
+	trade := res.Trades[0]
+	assert.Equal(t, model.OrderActionBuy, trade.OrderAction)
+	assert.Equal(t, 0.1, trade.Price.AsFloat())
+	assert.Equal(t, 12.0, trade.Volume.AsFloat())
+	assert.Equal(t, 1.2, trade.Cost.AsFloat())
+	assert.Equal(t, 0.0012, trade.Fee.AsFloat())
+	assert.Equal(t, model.USD, trade.FeeAsset)
+	assert.Equal(t, "28457", trade.TransactionID.String())
+	assert.Equal(t, "5001", res.Cursor)
+
+	// the cursor moves past a range without trades once the range is over
+	res, e = b.GetTradeHistory(pair, "5001", nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 0, len(res.Trades))
+	assert.Equal(t, fmt.Sprintf("%d", 5001+int64(binanceTradeHistoryWindow/time.Millisecond)), res.Cursor)
+
+	// the cursor stays when the range is not over yet
+	latest := fmt.Sprintf("%d", now.UnixNano()/int64(time.Millisecond)-1000)
+	res, e = b.GetTradeHistory(pair, latest, nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, latest, res.Cursor)
+}
@@ -0,0 +1,982 @@
+package plugins
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/networking"
+)
+
+// ensure that bitfinexExchange conforms to the Exchange interface
+var _ api.Exchange = &bitfinexExchange{}
+
+// ensure that bitfinexExchange can refresh its order constraints
+var _ api.ConstraintsRefresher = &bitfinexExchange{}
+
+const bitfinexPublicBaseURL = "https://api-pub.bitfinex.com"
+const bitfinexAuthBaseURL = "https://api.bitfinex.com"
+const bitfinexExchangeWallet = "exchange"
+const bitfinexOrderTypeLimit = "EXCHANGE LIMIT"
+const bitfinexTradeHistoryLimit = 2500
+const bitfinexTradesLimit = 1000
+
+// bitfinexOrderBookLengths are the only values accepted by the "len" param of the book endpoint, in increasing order
+var bitfinexOrderBookLengths = []int32{1, 25, 100}
+
+// bitfinexExchange is the native implementation for the Bitfinex Exchange, using the v2 REST API
+type bitfinexExchange struct {
+	assetConverter     *model.AssetConverter
+	apiKeys            []api.ExchangeAPIKey
+	apiNextIndex       uint8
+	ocOverridesHandler *OrderConstraintsOverridesHandler
+	httpClient         *http.Client
+	isSimulated        bool // will simulate add and cancel orders if this is true
+
+	// bitfinex requires a strictly increasing nonce across all authenticated requests
+	nonceMutex *sync.Mutex
+	lastNonce  int64
+
+	// minBaseVolumes holds the min order sizes fetched from the exchange, which take precedence over bitfinexPrecisionMatrix
+	minBaseVolumesMutex *sync.RWMutex
+	minBaseVolumes      map[model.TradingPair]model.Number
+}
+
+// makeBitfinexExchange is a factory method to make the bitfinex exchange
+func makeBitfinexExchange(apiKeys []api.ExchangeAPIKey, isSimulated bool) (api.Exchange, error) {
+	if len(apiKeys) == 0 || len(apiKeys) > math.MaxUint8 {
+		return nil, fmt.Errorf("invalid number of apiKeys: %d", len(apiKeys))
+	}
+
+	return &bitfinexExchange{
+		assetConverter:      model.BitfinexAssetConverter,
+		apiKeys:             apiKeys,
+		apiNextIndex:        0,
+		ocOverridesHandler:  MakeEmptyOrderConstraintsOverridesHandler(),
+		httpClient:          http.DefaultClient,
+		isSimulated:         isSimulated,
+		nonceMutex:          &sync.Mutex{},
+		lastNonce:           0,
+		minBaseVolumesMutex: &sync.RWMutex{},
+		minBaseVolumes:      map[model.TradingPair]model.Number{},
+	}, nil
+}
+
+// nextAPIKey rotates the API key being used so we can overcome rate limit issues
+func (b *bitfinexExchange) nextAPIKey() api.ExchangeAPIKey {
+	log.Printf("returning bitfinex API key at index %d", b.apiNextIndex)
+	apiKey := b.apiKeys[b.apiNextIndex]
+	// rotate key for the next call
+	b.apiNextIndex = (b.apiNextIndex + 1) % uint8(len(b.apiKeys))
+	return apiKey
+}
+
+// nextNonce returns a strictly increasing nonce based on the current time in microseconds
+func (b *bitfinexExchange) nextNonce() string {
+	b.nonceMutex.Lock()
+	defer b.nonceMutex.Unlock()
+
+	nonce := time.Now().UnixNano() / int64(time.Microsecond)
+	if nonce <= b.lastNonce {
+		nonce = b.lastNonce + 1
+	}
+	b.lastNonce = nonce
+	return strconv.FormatInt(nonce, 10)
+}
+
+// bitfinexSignature computes the signature expected in the bfx-signature header of authenticated requests
+func bitfinexSignature(secret string, path string, nonce string, body string) string {
+	mac := hmac.New(sha512.New384, []byte(secret))
+	mac.Write([]byte("/api/" + path + nonce + body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// bitfinexSymbol converts a trading pair to the symbol used by bitfinex, i.e. tXLMUSD or tBASE:QUOTE when either code is longer than 3 characters
+func bitfinexSymbol(c model.AssetConverterInterface, pair model.TradingPair) (string, error) {
+	base, e := c.ToString(pair.Base)
+	if e != nil {
+		return "", e
+	}
+	quote, e := c.ToString(pair.Quote)
+	if e != nil {
+		return "", e
+	}
+
+	if len(base) > 3 || len(quote) > 3 {
+		return "t" + base + ":" + quote, nil
+	}
+	return "t" + base + quote, nil
+}
+
+// checkBitfinexError returns an error when the response is an error response of the form ["error", code, "message"]
+func checkBitfinexError(resp interface{}) error {
+	arr, ok := resp.([]interface{})
+	if !ok || len(arr) == 0 {
+		return nil
+	}
+
+	if s, ok := arr[0].(string); ok && s == "error" {
+		return fmt.Errorf("bitfinex returned an error response: %v", arr[1:])
+	}
+	return nil
+}
+
+func (b *bitfinexExchange) publicRequest(path string) (interface{}, error) {
+	var resp interface{}
+	e := networking.JSONRequest(b.httpClient, "GET", bitfinexPublicBaseURL+"/"+path, "", map[string]string{}, &resp, "")
+	if e != nil {
+		return nil, fmt.Errorf("error in public request to bitfinex (path=%s): %s", path, e)
+	}
+
+	if e = checkBitfinexError(resp); e != nil {
+		return nil, e
+	}
+	return resp, nil
+}
+
+func (b *bitfinexExchange) authRequest(path string, body map[string]interface{}) (interface{}, error) {
+	bodyBytes, e := json.Marshal(body)
+	if e != nil {
+		return nil, fmt.Errorf("could not marshal body for bitfinex request (path=%s): %s", path, e)
+	}
+
+	apiKey := b.nextAPIKey()
+	nonce := b.nextNonce()
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"bfx-nonce":     nonce,
+		"bfx-apikey":    apiKey.Key,
+		"bfx-signature": bitfinexSignature(apiKey.Secret, path, nonce, string(bodyBytes)),
+	}
+
+	var resp interface{}
+	e = networking.JSONRequest(b.httpClient, "POST", bitfinexAuthBaseURL+"/"+path, string(bodyBytes), headers, &resp, "")
+	if e != nil {
+		return nil, fmt.Errorf("error in authenticated request to bitfinex (path=%s): %s", path, e)
+	}
+
+	if e = checkBitfinexError(resp); e != nil {
+		return nil, e
+	}
+	return resp, nil
+}
+
+func bitfinexArray(v interface{}, field string) ([]interface{}, error) {
+	arr, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("could not parse '%s' as an array in bitfinex response: %v", field, v)
+	}
+	return arr, nil
+}
+
+func bitfinexFloat(arr []interface{}, index int, field string) (float64, error) {
+	if index >= len(arr) {
+		return 0, fmt.Errorf("could not find field '%s' at index %d in bitfinex response: %v", field, index, arr)
+	}
+	f, ok := arr[index].(float64)
+	if !ok {
+		return 0, fmt.Errorf("could not parse field '%s' at index %d as a number in bitfinex response: %v", field, index, arr)
+	}
+	return f, nil
+}
+
+func bitfinexString(arr []interface{}, index int, field string) (string, error) {
+	if index >= len(arr) {
+		return "", fmt.Errorf("could not find field '%s' at index %d in bitfinex response: %v", field, index, arr)
+	}
+	s, ok := arr[index].(string)
+	if !ok {
+		return "", fmt.Errorf("could not parse field '%s' at index %d as a string in bitfinex response: %v", field, index, arr)
+	}
+	return s, nil
+}
+
+// AddOrder impl.
+func (b *bitfinexExchange) AddOrder(order *model.Order) (*model.TransactionID, error) {
+	symbol, e := bitfinexSymbol(b.assetConverter, *order.Pair)
+	if e != nil {
+		return nil, e
+	}
+
+	if b.isSimulated {
+		log.Printf("not adding order to Bitfinex in simulation mode, order=%s\n", *order)
+		return model.MakeTransactionID("simulated"), nil
+	}
+
+	orderConstraints := b.GetOrderConstraints(order.Pair)
+	if order.Price.Precision() > orderConstraints.PricePrecision {
+		return nil, fmt.Errorf("bitfinex price precision can be a maximum of %d, got %d, value = %.12f", orderConstraints.PricePrecision, order.Price.Precision(), order.Price.AsFloat())
+	}
+	if order.Volume.Precision() > orderConstraints.VolumePrecision {
+		return nil, fmt.Errorf("bitfinex volume precision can be a maximum of %d, got %d, value = %.12f", orderConstraints.VolumePrecision, order.Volume.Precision(), order.Volume.AsFloat())
+	}
+	if order.OrderType != model.OrderTypeLimit {
+		return nil, fmt.Errorf("bitfinex integration only supports limit orders, got orderType=%s", order.OrderType.String())
+	}
+	if order.PostOnly {
+		return nil, fmt.Errorf("bitfinex integration does not support post-only orders")
+	}
+
+	// bitfinex uses a negative amount for sell orders
+	amount := order.Volume
+	if order.OrderAction.IsSell() {
+		amount = order.Volume.Negate()
+	}
+
+	log.Printf("bitfinex is submitting order: symbol=%s, orderAction=%s, orderType=%s, volume=%s, price=%s\n",
+		symbol, order.OrderAction.String(), order.OrderType.String(), order.Volume.AsString(), order.Price.AsString())
+	resp, e := b.authRequest("v2/auth/w/order/submit", map[string]interface{}{
+		"type":   bitfinexOrderTypeLimit,
+		"symbol": symbol,
+		"amount": amount.AsString(),
+		"price":  order.Price.AsString(),
+	})
+	if e != nil {
+		return nil, e
+	}
+
+	// response is a notification of the form [MTS, TYPE, MESSAGE_ID, null, [ORDER, ...], CODE, STATUS, TEXT]
+	notification, e := bitfinexArray(resp, "notification")
+	if e != nil {
+		return nil, e
+	}
+	if len(notification) < 5 {
+		return nil, fmt.Errorf("unexpected notification from bitfinex when submitting order: %v", notification)
+	}
+	orders, e := bitfinexArray(notification[4], "orders")
+	if e != nil {
+		return nil, e
+	}
+	if len(orders) != 1 {
+		return nil, fmt.Errorf("expected exactly 1 order to be created by bitfinex, got %d: %v", len(orders), notification)
+	}
+	createdOrder, e := bitfinexArray(orders[0], "order")
+	if e != nil {
+		return nil, e
+	}
+	orderID, e := bitfinexFloat(createdOrder, 0, "ID")
+	if e != nil {
+		return nil, e
+	}
+	return model.MakeTransactionID(strconv.FormatInt(int64(orderID), 10)), nil
+}
+
+// CancelOrder impl.
+func (b *bitfinexExchange) CancelOrder(txID *model.TransactionID, pair model.TradingPair) (model.CancelOrderResult, error) {
+	if b.isSimulated {
+		return model.CancelResultCancelSuccessful, nil
+	}
+	log.Printf("bitfinex is canceling order: ID=%s, tradingPair=%s\n", txID.String(), pair.String())
+
+	// we don't actually use the pair for bitfinex
+	orderID, e := strconv.ParseInt(txID.String(), 10, 64)
+	if e != nil {
+		return model.CancelResultFailed, fmt.Errorf("could not parse bitfinex order ID '%s': %s", txID.String(), e)
+	}
+
+	resp, e := b.authRequest("v2/auth/w/order/cancel", map[string]interface{}{"id": orderID})
+	if e != nil {
+		return model.CancelResultFailed, e
+	}
+
+	// response is a notification of the form [MTS, TYPE, MESSAGE_ID, null, ORDER, CODE, STATUS, TEXT]
+	notification, e := bitfinexArray(resp, "notification")
+	if e != nil {
+		return model.CancelResultFailed, e
+	}
+	status, e := bitfinexString(notification, 6, "STATUS")
+	if e != nil {
+		return model.CancelResultFailed, e
+	}
+	if status != "SUCCESS" {
+		log.Printf("bitfinex could not cancel order (ID=%s): %v\n", txID.String(), notification)
+		return model.CancelResultFailed, nil
+	}
+	return model.CancelResultCancelSuccessful, nil
+}
+
+// GetAccountBalances impl.
+func (b *bitfinexExchange) GetAccountBalances(assetList []interface{}) (map[interface{}]model.Number, error) {
+	resp, e := b.authRequest("v2/auth/r/wallets", map[string]interface{}{})
+	if e != nil {
+		return nil, e
+	}
+	wallets, e := bitfinexArray(resp, "wallets")
+	if e != nil {
+		return nil, e
+	}
+
+	// only the exchange wallet can be used for trading
+	balances := map[string]float64{}
+	for _, w := range wallets {
+		wallet, e := bitfinexArray(w, "wallet")
+		if e != nil {
+			return nil, e
+		}
+		walletType, e := bitfinexString(wallet, 0, "WALLET_TYPE")
+		if e != nil {
+			return nil, e
+		}
+		if walletType != bitfinexExchangeWallet {
+			continue
+		}
+
+		currency, e := bitfinexString(wallet, 1, "CURRENCY")
+		if e != nil {
+			return nil, e
+		}
+		balance, e := bitfinexFloat(wallet, 2, "BALANCE")
+		if e != nil {
+			return nil, e
+		}
+		balances[currency] = balance
+	}
+
+	m := map[interface{}]model.Number{}
+	for _, elem := range assetList {
+		var asset model.Asset
+		if v, ok := elem.(model.Asset); ok {
+			asset = v
+		} else {
+			return nil, fmt.Errorf("invalid type of asset passed in, only model.Asset accepted")
+		}
+
+		bitfinexAssetString, e := b.assetConverter.ToString(asset)
+		if e != nil {
+			// discard partially built map for now
+			return nil, e
+		}
+		// assets without a wallet have a zero balance
+		m[asset] = *model.NumberFromFloat(balances[bitfinexAssetString], precisionBalances)
+	}
+	return m, nil
+}
+
+// GetOrderConstraints impl
+func (b *bitfinexExchange) GetOrderConstraints(pair *model.TradingPair) *model.OrderConstraints {
+	oc, ok := bitfinexPrecisionMatrix[*pair]
+	if ok {
+		b.minBaseVolumesMutex.RLock()
+		if minBaseVolume, has := b.minBaseVolumes[*pair]; has {
+			oc.MinBaseVolume = minBaseVolume
+		}
+		b.minBaseVolumesMutex.RUnlock()
+		return b.ocOverridesHandler.Apply(pair, &oc)
+	}
+
+	if b.ocOverridesHandler.IsCompletelyOverriden(pair) {
+		override := b.ocOverridesHandler.Get(pair)
+		return model.MakeOrderConstraintsFromOverride(override)
+	}
+	panic(fmt.Sprintf("bitfinexExchange could not find orderConstraints for trading pair %v. Try using the \"ccxt-bitfinex\" integration instead.", pair))
+}
+
+// RefreshOrderConstraints impl, fetches the latest min order sizes for the pairs in bitfinexPrecisionMatrix
+func (b *bitfinexExchange) RefreshOrderConstraints() error {
+	var details []struct {
+		Pair             string `json:"pair"`
+		MinimumOrderSize string `json:"minimum_order_size"`
+	}
+	e := networking.JSONRequest(b.httpClient, "GET", bitfinexAuthBaseURL+"/v1/symbols_details", "", map[string]string{}, &details, "message")
+	if e != nil {
+		return fmt.Errorf("could not fetch symbol details from bitfinex: %s", e)
+	}
+
+	// the v1 API uses lowercase symbols without the "t" prefix
+	symbol2Pair := map[string]model.TradingPair{}
+	for pair := range bitfinexPrecisionMatrix {
+		symbol, e := bitfinexSymbol(b.assetConverter, pair)
+		if e != nil {
+			return e
+		}
+		symbol2Pair[strings.ToLower(strings.TrimPrefix(symbol, "t"))] = pair
+	}
+
+	minBaseVolumes := map[model.TradingPair]model.Number{}
+	for _, d := range details {
+		pair, ok := symbol2Pair[d.Pair]
+		if !ok {
+			continue
+		}
+
+		oc := bitfinexPrecisionMatrix[pair]
+		minBaseVolume, e := model.NumberFromString(d.MinimumOrderSize, oc.VolumePrecision)
+		if e != nil {
+			return fmt.Errorf("could not parse minimum_order_size '%s' for bitfinex pair '%s': %s", d.MinimumOrderSize, d.Pair, e)
+		}
+		minBaseVolumes[pair] = *minBaseVolume
+	}
+
+	b.minBaseVolumesMutex.Lock()
+	b.minBaseVolumes = minBaseVolumes
+	b.minBaseVolumesMutex.Unlock()
+	log.Printf("refreshed min base volumes for %d bitfinex trading pairs\n", len(minBaseVolumes))
+	return nil
+}
+
+// OverrideOrderConstraints impl, can partially override values for specific pairs
+func (b *bitfinexExchange) OverrideOrderConstraints(pair *model.TradingPair, override *model.OrderConstraintsOverride) {
+	b.ocOverridesHandler.Upsert(pair, override)
+}
+
+// GetAssetConverter impl.
+func (b *bitfinexExchange) GetAssetConverter() model.AssetConverterInterface {
+	return b.assetConverter
+}
+
+// GetOpenOrders impl.
+func (b *bitfinexExchange) GetOpenOrders(pairs []*model.TradingPair) (map[model.TradingPair][]model.OpenOrder, error) {
+	// convert to a map so we can easily search for the trading pair of a symbol
+	symbol2Pair := map[string]*model.TradingPair{}
+	for _, p := range pairs {
+		symbol, e := bitfinexSymbol(b.assetConverter, *p)
+		if e != nil {
+			return nil, e
+		}
+		symbol2Pair[symbol] = p
+	}
+
+	resp, e := b.authRequest("v2/auth/r/orders", map[string]interface{}{})
+	if e != nil {
+		return nil, fmt.Errorf("cannot load open orders for Bitfinex: %s", e)
+	}
+	orders, e := bitfinexArray(resp, "orders")
+	if e != nil {
+		return nil, e
+	}
+
+	m := map[model.TradingPair][]model.OpenOrder{}
+	for _, p := range pairs {
+		m[*p] = []model.OpenOrder{}
+	}
+	for _, o := range orders {
+		order, e := bitfinexArray(o, "order")
+		if e != nil {
+			return nil, e
+		}
+		symbol, e := bitfinexString(order, 3, "SYMBOL")
+		if e != nil {
+			return nil, e
+		}
+		pair, ok := symbol2Pair[symbol]
+		if !ok {
+			// skip open orders for pairs that were not requested
+			continue
+		}
+
+		id, e := bitfinexFloat(order, 0, "ID")
+		if e != nil {
+			return nil, e
+		}
+		mtsCreate, e := bitfinexFloat(order, 4, "MTS_CREATE")
+		if e != nil {
+			return nil, e
+		}
+		amount, e := bitfinexFloat(order, 6, "AMOUNT")
+		if e != nil {
+			return nil, e
+		}
+		amountOrig, e := bitfinexFloat(order, 7, "AMOUNT_ORIG")
+		if e != nil {
+			return nil, e
+		}
+		price, e := bitfinexFloat(order, 16, "PRICE")
+		if e != nil {
+			return nil, e
+		}
+
+		// amounts are positive for buy orders and negative for sell orders
+		orderAction := model.OrderActionBuy
+		if amountOrig < 0 {
+			orderAction = model.OrderActionSell
+		}
+
+		orderConstraints := b.GetOrderConstraints(pair)
+		ts := model.MakeTimestamp(int64(mtsCreate))
+		m[*pair] = append(m[*pair], model.OpenOrder{
+			Order: model.Order{
+				Pair:        pair,
+				OrderAction: orderAction,
+				OrderType:   model.OrderTypeLimit,
+				Price:       model.NumberFromFloat(price, orderConstraints.PricePrecision),
+				Volume:      model.NumberFromFloat(math.Abs(amountOrig), orderConstraints.VolumePrecision),
+				Timestamp:   ts,
+			},
+			ID:             strconv.FormatInt(int64(id), 10),
+			StartTime:      ts,
+			ExpireTime:     nil,
+			VolumeExecuted: model.NumberFromFloat(math.Abs(amountOrig)-math.Abs(amount), orderConstraints.VolumePrecision),
+		})
+	}
+	return m, nil
+}
+
+// GetOrderBook impl.
+func (b *bitfinexExchange) GetOrderBook(pair *model.TradingPair, maxCount int32) (*model.OrderBook, error) {
+	symbol, e := bitfinexSymbol(b.assetConverter, *pair)
+	if e != nil {
+		return nil, e
+	}
+
+	// use the smallest allowed length that can satisfy maxCount
+	length := bitfinexOrderBookLengths[len(bitfinexOrderBookLengths)-1]
+	for _, l := range bitfinexOrderBookLengths {
+		if l >= maxCount {
+			length = l
+			break
+		}
+	}
+
+	resp, e := b.publicRequest(fmt.Sprintf("v2/book/%s/P0?len=%d", symbol, length))
+	if e != nil {
+		return nil, e
+	}
+	entries, e := bitfinexArray(resp, "book")
+	if e != nil {
+		return nil, e
+	}
+
+	orderConstraints := b.GetOrderConstraints(pair)
+	ts := model.MakeTimestamp(time.Now().UnixNano() / int64(time.Millisecond))
+	asks := []model.Order{}
+	bids := []model.Order{}
+	for _, elem := range entries {
+		entry, e := bitfinexArray(elem, "book entry")
+		if e != nil {
+			return nil, e
+		}
+		price, e := bitfinexFloat(entry, 0, "PRICE")
+		if e != nil {
+			return nil, e
+		}
+		amount, e := bitfinexFloat(entry, 2, "AMOUNT")
+		if e != nil {
+			return nil, e
+		}
+
+		// bids have a positive amount and asks have a negative amount
+		orderAction := model.OrderActionBuy
+		if amount < 0 {
+			orderAction = model.OrderActionSell
+		}
+		order := model.Order{
+			Pair:        pair,
+			OrderAction: orderAction,
+			OrderType:   model.OrderTypeLimit,
+			Price:       model.NumberFromFloat(price, orderConstraints.PricePrecision),
+			Volume:      model.NumberFromFloat(math.Abs(amount), orderConstraints.VolumePrecision),
+			Timestamp:   ts,
+		}
+
+		if orderAction.IsSell() {
+			if int32(len(asks)) < maxCount {
+				asks = append(asks, order)
+			}
+		} else if int32(len(bids)) < maxCount {
+			bids = append(bids, order)
+		}
+	}
+	return model.MakeOrderBook(pair, asks, bids), nil
+}
+
+// GetTickerPrice impl.
+func (b *bitfinexExchange) GetTickerPrice(pairs []model.TradingPair) (map[model.TradingPair]api.Ticker, error) {
+	priceResult := map[model.TradingPair]api.Ticker{}
+	for _, p := range pairs {
+		symbol, e := bitfinexSymbol(b.assetConverter, p)
+		if e != nil {
+			return nil, e
+		}
+
+		resp, e := b.publicRequest("v2/ticker/" + symbol)
+		if e != nil {
+			return nil, e
+		}
+		ticker, e := bitfinexArray(resp, "ticker")
+		if e != nil {
+			return nil, e
+		}
+		bid, e := bitfinexFloat(ticker, 0, "BID")
+		if e != nil {
+			return nil, e
+		}
+		ask, e := bitfinexFloat(ticker, 2, "ASK")
+		if e != nil {
+			return nil, e
+		}
+
+		orderConstraints := b.GetOrderConstraints(&p)
+		priceResult[p] = api.Ticker{
+			AskPrice: model.NumberFromFloat(ask, orderConstraints.PricePrecision),
+			BidPrice: model.NumberFromFloat(bid, orderConstraints.PricePrecision),
+		}
+	}
+	return priceResult, nil
+}
+
+// bitfinexCandleTimeframes are the timeframes supported by the candles endpoint of bitfinex
+var bitfinexCandleTimeframes = map[time.Duration]string{
+	time.Minute:         "1m",
+	5 * time.Minute:     "5m",
+	15 * time.Minute:    "15m",
+	30 * time.Minute:    "30m",
+	time.Hour:           "1h",
+	3 * time.Hour:       "3h",
+	6 * time.Hour:       "6h",
+	12 * time.Hour:      "12h",
+	24 * time.Hour:      "1D",
+	7 * 24 * time.Hour:  "1W",
+	14 * 24 * time.Hour: "14D",
+	30 * 24 * time.Hour: "1M",
+}
+
+// GetCandles impl
+func (b *bitfinexExchange) GetCandles(pair *model.TradingPair, interval time.Duration, since *model.Timestamp) ([]model.Candle, error) {
+	timeframe, ok := bitfinexCandleTimeframes[interval]
+	if !ok {
+		return nil, fmt.Errorf("unsupported candle interval for bitfinex: %s", interval)
+	}
+	symbol, e := bitfinexSymbol(b.assetConverter, *pair)
+	if e != nil {
+		return nil, e
+	}
+
+	path := fmt.Sprintf("v2/candles/trade:%s:%s/hist?sort=1", timeframe, symbol)
+	if since != nil {
+		path = fmt.Sprintf("%s&start=%d", path, since.AsInt64())
+	}
+	resp, e := b.publicRequest(path)
+	if e != nil {
+		return nil, e
+	}
+	rows, e := bitfinexArray(resp, "candles")
+	if e != nil {
+		return nil, e
+	}
+	return readBitfinexCandles(pair, interval, rows, b.GetOrderConstraints(pair))
+}
+
+// readBitfinexCandles converts the rows of the candles endpoint of bitfinex, each row is [MTS, OPEN, CLOSE, HIGH, LOW, VOLUME]
+func readBitfinexCandles(pair *model.TradingPair, interval time.Duration, rows []interface{}, orderConstraints *model.OrderConstraints) ([]model.Candle, error) {
+	candles := []model.Candle{}
+	for _, r := range rows {
+		row, e := bitfinexArray(r, "candle")
+		if e != nil {
+			return nil, e
+		}
+		mts, e := bitfinexFloat(row, 0, "MTS")
+		if e != nil {
+			return nil, e
+		}
+		openPrice, e := bitfinexFloat(row, 1, "OPEN")
+		if e != nil {
+			return nil, e
+		}
+		closePrice, e := bitfinexFloat(row, 2, "CLOSE")
+		if e != nil {
+			return nil, e
+		}
+		highPrice, e := bitfinexFloat(row, 3, "HIGH")
+		if e != nil {
+			return nil, e
+		}
+		lowPrice, e := bitfinexFloat(row, 4, "LOW")
+		if e != nil {
+			return nil, e
+		}
+		volume, e := bitfinexFloat(row, 5, "VOLUME")
+		if e != nil {
+			return nil, e
+		}
+
+		candles = append(candles, model.Candle{
+			Pair:      pair,
+			Timestamp: model.MakeTimestamp(int64(mts)),
+			Interval:  interval,
+			Open:      model.NumberFromFloat(openPrice, orderConstraints.PricePrecision),
+			High:      model.NumberFromFloat(highPrice, orderConstraints.PricePrecision),
+			Low:       model.NumberFromFloat(lowPrice, orderConstraints.PricePrecision),
+			Close:     model.NumberFromFloat(closePrice, orderConstraints.PricePrecision),
+			Volume:    model.NumberFromFloat(volume, orderConstraints.VolumePrecision),
+		})
+	}
+	return candles, nil
+}
+
+// GetTradeHistory impl, cursors are timestamps in milliseconds represented as strings
+func (b *bitfinexExchange) GetTradeHistory(pair model.TradingPair, maybeCursorStart interface{}, maybeCursorEnd interface{}) (*api.TradeHistoryResult, error) {
+	symbol, e := bitfinexSymbol(b.assetConverter, pair)
+	if e != nil {
+		return nil, e
+	}
+
+	body := map[string]interface{}{
+		"limit": bitfinexTradeHistoryLimit,
+		"sort":  1,
+	}
+	var cursorStart *string
+	if maybeCursorStart != nil {
+		s := maybeCursorStart.(string)
+		start, e := strconv.ParseInt(s, 10, 64)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse start cursor '%s' for bitfinex: %s", s, e)
+		}
+		body["start"] = start
+		cursorStart = &s
+	}
+	if maybeCursorEnd != nil {
+		s := maybeCursorEnd.(string)
+		end, e := strconv.ParseInt(s, 10, 64)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse end cursor '%s' for bitfinex: %s", s, e)
+		}
+		body["end"] = end
+	}
+
+	resp, e := b.authRequest(fmt.Sprintf("v2/auth/r/trades/%s/hist", symbol), body)
+	if e != nil {
+		return nil, e
+	}
+	trades, e := b.readTrades(resp, pair)
+	if e != nil {
+		return nil, e
+	}
+	res := api.TradeHistoryResult{Trades: trades}
+
+	// sort to be in ascending order
+	sort.Sort(model.TradesByTsID(res.Trades))
+
+	// set correct value for cursor
+	if len(res.Trades) > 0 {
+		lastCursor := res.Trades[len(res.Trades)-1].Order.Timestamp.AsInt64()
+		// add 1 to lastCursor so we don't repeat the same cursor on the next run
+		res.Cursor = strconv.FormatInt(lastCursor+1, 10)
+	} else if cursorStart != nil {
+		res.Cursor = *cursorStart
+	} else {
+		res.Cursor = nil
+	}
+
+	return &res, nil
+}
+
+// readTrades converts the trades of the account in a response of the trades endpoints of the authenticated API
+func (b *bitfinexExchange) readTrades(resp interface{}, pair model.TradingPair) ([]model.Trade, error) {
+	trades, e := bitfinexArray(resp, "trades")
+	if e != nil {
+		return nil, e
+	}
+
+	orderConstraints := b.GetOrderConstraints(&pair)
+	// for now use the max precision between price and volume for fee and cost
+	feeCostPrecision := orderConstraints.PricePrecision
+	if orderConstraints.VolumePrecision > feeCostPrecision {
+		feeCostPrecision = orderConstraints.VolumePrecision
+	}
+
+	result := []model.Trade{}
+	for _, t := range trades {
+		trade, e := bitfinexArray(t, "trade")
+		if e != nil {
+			return nil, e
+		}
+		id, e := bitfinexFloat(trade, 0, "ID")
+		if e != nil {
+			return nil, e
+		}
+		mts, e := bitfinexFloat(trade, 2, "MTS")
+		if e != nil {
+			return nil, e
+		}
+		execAmount, e := bitfinexFloat(trade, 4, "EXEC_AMOUNT")
+		if e != nil {
+			return nil, e
+		}
+		execPrice, e := bitfinexFloat(trade, 5, "EXEC_PRICE")
+		if e != nil {
+			return nil, e
+		}
+		fee, e := bitfinexFloat(trade, 9, "FEE")
+		if e != nil {
+			return nil, e
+		}
+
+		orderAction := model.OrderActionBuy
+		if execAmount < 0 {
+			orderAction = model.OrderActionSell
+		}
+		volume := math.Abs(execAmount)
+		result = append(result, model.Trade{
+			Order: model.Order{
+				Pair:        &pair,
+				OrderAction: orderAction,
+				OrderType:   model.OrderTypeLimit,
+				Price:       model.NumberFromFloat(execPrice, orderConstraints.PricePrecision),
+				Volume:      model.NumberFromFloat(volume, orderConstraints.VolumePrecision),
+				Timestamp:   model.MakeTimestamp(int64(mts)),
+			},
+			TransactionID: model.MakeTransactionID(strconv.FormatInt(int64(id), 10)),
+			Cost:          model.NumberFromFloat(volume*execPrice, feeCostPrecision),
+			// bitfinex reports fees as negative values
+			Fee: model.NumberFromFloat(math.Abs(fee), feeCostPrecision),
+		})
+	}
+	return result, nil
+}
+
+// GetTradesForOrder impl
+func (b *bitfinexExchange) GetTradesForOrder(txID *model.TransactionID, pair model.TradingPair) ([]model.Trade, error) {
+	if b.isSimulated {
+		return []model.Trade{}, nil
+	}
+	symbol, e := bitfinexSymbol(b.assetConverter, pair)
+	if e != nil {
+		return nil, e
+	}
+
+	resp, e := b.authRequest(fmt.Sprintf("v2/auth/r/order/%s:%s/trades", symbol, txID.String()), map[string]interface{}{})
+	if e != nil {
+		return nil, fmt.Errorf("error fetching trades of order '%s': %s", txID.String(), e)
+	}
+	trades, e := b.readTrades(resp, pair)
+	if e != nil {
+		return nil, e
+	}
+
+	// sort to be in ascending order
+	sort.Sort(model.TradesByTsID(trades))
+	return trades, nil
+}
+
+// GetLatestTradeCursor impl.
+func (b *bitfinexExchange) GetLatestTradeCursor() (interface{}, error) {
+	timeNowMillis := time.Now().UnixNano() / int64(time.Millisecond)
+	latestTradeCursor := fmt.Sprintf("%d", timeNowMillis)
+	return latestTradeCursor, nil
+}
+
+// GetTrades impl, cursors are timestamps in milliseconds represented as int64
+func (b *bitfinexExchange) GetTrades(pair *model.TradingPair, maybeCursor interface{}) (*api.TradesResult, error) {
+	symbol, e := bitfinexSymbol(b.assetConverter, *pair)
+	if e != nil {
+		return nil, e
+	}
+
+	path := fmt.Sprintf("v2/trades/%s/hist?limit=%d&sort=1", symbol, bitfinexTradesLimit)
+	var cursor interface{}
+	if maybeCursor != nil {
+		mc := maybeCursor.(int64)
+		path = fmt.Sprintf("%s&start=%d", path, mc)
+		cursor = mc
+	}
+
+	resp, e := b.publicRequest(path)
+	if e != nil {
+		return nil, e
+	}
+	trades, e := bitfinexArray(resp, "trades")
+	if e != nil {
+		return nil, e
+	}
+
+	orderConstraints := b.GetOrderConstraints(pair)
+	tradesResult := &api.TradesResult{Trades: []model.Trade{}}
+	for _, t := range trades {
+		trade, e := bitfinexArray(t, "trade")
+		if e != nil {
+			return nil, e
+		}
+		mts, e := bitfinexFloat(trade, 1, "MTS")
+		if e != nil {
+			return nil, e
+		}
+		amount, e := bitfinexFloat(trade, 2, "AMOUNT")
+		if e != nil {
+			return nil, e
+		}
+		price, e := bitfinexFloat(trade, 3, "PRICE")
+		if e != nil {
+			return nil, e
+		}
+
+		// the amount is positive when the taker was buying and negative when the taker was selling
+		orderAction := model.OrderActionBuy
+		if amount < 0 {
+			orderAction = model.OrderActionSell
+		}
+		tradesResult.Trades = append(tradesResult.Trades, model.Trade{
+			Order: model.Order{
+				Pair:        pair,
+				OrderAction: orderAction,
+				OrderType:   model.OrderTypeLimit,
+				Price:       model.NumberFromFloat(price, orderConstraints.PricePrecision),
+				Volume:      model.NumberFromFloat(math.Abs(amount), orderConstraints.VolumePrecision),
+				Timestamp:   model.MakeTimestamp(int64(mts)),
+			},
+			// TransactionID unavailable
+			// Cost unavailable
+			// Fee unavailable
+		})
+	}
+
+	// sort to be in ascending order
+	sort.Sort(model.TradesByTsID(tradesResult.Trades))
+
+	// set correct value for cursor
+	if len(tradesResult.Trades) > 0 {
+		lastCursor := tradesResult.Trades[len(tradesResult.Trades)-1].Order.Timestamp.AsInt64()
+		// add 1 to lastCursor so we don't repeat the same cursor on the next run
+		cursor = lastCursor + 1
+	}
+	tradesResult.Cursor = cursor
+
+	return tradesResult, nil
+}
+
+// GetWithdrawInfo impl.
+func (b *bitfinexExchange) GetWithdrawInfo(
+	asset model.Asset,
+	amountToWithdraw *model.Number,
+	address string,
+) (*api.WithdrawInfo, error) {
+	return nil, fmt.Errorf("withdrawals are not supported by the bitfinex integration")
+}
+
+// PrepareDeposit impl.
+func (b *bitfinexExchange) PrepareDeposit(asset model.Asset, amount *model.Number) (*api.PrepareDepositResult, error) {
+	return nil, fmt.Errorf("deposits are not supported by the bitfinex integration")
+}
+
+// WithdrawFunds impl.
+func (b *bitfinexExchange) WithdrawFunds(
+	asset model.Asset,
+	amountToWithdraw *model.Number,
+	address string,
+) (*api.WithdrawFunds, error) {
+	return nil, fmt.Errorf("withdrawals are not supported by the bitfinex integration")
+}
+
+// bitfinexPrecisionMatrix describes the price and volume precision and default min base volume for each trading pair
+// the min base volumes are refreshed from the exchange via RefreshOrderConstraints
+var bitfinexPrecisionMatrix = map[model.TradingPair]model.OrderConstraints{
+	*model.MakeTradingPair(model.XLM, model.USD): *model.MakeOrderConstraints(5, 8, 20.0),
+	*model.MakeTradingPair(model.XLM, model.BTC): *model.MakeOrderConstraints(8, 8, 20.0),
+	*model.MakeTradingPair(model.XLM, model.ETH): *model.MakeOrderConstraints(8, 8, 20.0),
+	*model.MakeTradingPair(model.BTC, model.USD): *model.MakeOrderConstraints(1, 8, 0.0006),
+	*model.MakeTradingPair(model.ETH, model.USD): *model.MakeOrderConstraints(2, 8, 0.04),
+	*model.MakeTradingPair(model.ETH, model.BTC): *model.MakeOrderConstraints(6, 8, 0.04),
+	*model.MakeTradingPair(model.XRP, model.USD): *model.MakeOrderConstraints(5, 8, 20.0),
+	*model.MakeTradingPair(model.XRP, model.BTC): *model.MakeOrderConstraints(8, 8, 20.0),
+}
@@ -0,0 +1,92 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBitfinexSymbol(t *testing.T) {
+	testCases := []struct {
+		pair       model.TradingPair
+		wantSymbol string
+	}{
+		{
+			pair:       *model.MakeTradingPair(model.XLM, model.USD),
+			wantSymbol: "tXLMUSD",
+		}, {
+			pair:       *model.MakeTradingPair(model.BTC, model.USDT),
+			wantSymbol: "tBTCUST",
+		}, {
+			pair:       *model.MakeTradingPair(model.DASH, model.BTC),
+			wantSymbol: "tDSHBTC",
+		},
+	}
+
+	for _, kase := range testCases {
+		t.Run(kase.pair.String(), func(t *testing.T) {
+			symbol, e := bitfinexSymbol(model.BitfinexAssetConverter, kase.pair)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, kase.wantSymbol, symbol)
+		})
+	}
+}
+
+func TestCheckBitfinexError(t *testing.T) {
+	testCases := []struct {
+		resp    interface{}
+		wantErr bool
+	}{
+		{
+			resp:    []interface{}{"error", 10100.0, "apikey: invalid"},
+			wantErr: true,
+		}, {
+			resp:    []interface{}{[]interface{}{"exchange", "XLM", 100.0}},
+			wantErr: false,
+		}, {
+			resp:    []interface{}{},
+			wantErr: false,
+		}, {
+			resp:    map[string]interface{}{"pair": "xlmusd"},
+			wantErr: false,
+		},
+	}
+
+	for i, kase := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			e := checkBitfinexError(kase.resp)
+			assert.Equal(t, kase.wantErr, e != nil)
+		})
+	}
+}
+
+func TestReadBitfinexCandles(t *testing.T) {
+	pair := model.MakeTradingPair(model.XLM, model.USD)
+	rows := []interface{}{
+		[]interface{}{1546300800000.0, 0.1172, 0.1185, 0.1191, 0.1165, 125000.5},
+		[]interface{}{1546304400000.0, 0.1185, 0.1179, 0.1188, 0.1176, 98000.25},
+	}
+
+	candles, e := readBitfinexCandles(pair, time.Hour, rows, model.MakeOrderConstraints(4, 2, 1))
+	if !assert.NoError(t, e) {
+		return
+	}
+	if !assert.Equal(t, 2, len(candles)) {
+		return
+	}
+	assert.Equal(t, int64(1546300800000), candles[0].Timestamp.AsInt64())
+	assert.Equal(t, time.Hour, candles[0].Interval)
+	assert.Equal(t, 0.1172, candles[0].Open.AsFloat())
+	assert.Equal(t, 0.1191, candles[0].High.AsFloat())
+	assert.Equal(t, 0.1165, candles[0].Low.AsFloat())
+	assert.Equal(t, 0.1185, candles[0].Close.AsFloat())
+	assert.Equal(t, 125000.5, candles[0].Volume.AsFloat())
+
+	_, e = readBitfinexCandles(pair, time.Hour, []interface{}{[]interface{}{1546300800000.0, 0.1172}}, model.MakeOrderConstraints(4, 2, 1))
+	assert.Error(t, e)
+}
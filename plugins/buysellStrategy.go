@@ -16,12 +16,18 @@ type BuySellConfig struct {
 	RateOffsetPercent      float64       `valid:"-" toml:"RATE_OFFSET_PERCENT" json:"rate_offset_percent"`
 	RateOffset             float64       `valid:"-" toml:"RATE_OFFSET" json:"rate_offset"`
 	RateOffsetPercentFirst bool          `valid:"-" toml:"RATE_OFFSET_PERCENT_FIRST" json:"rate_offset_percent_first"`
-	AmountOfABase          float64       `valid:"-" toml:"AMOUNT_OF_A_BASE" json:"amount_of_a_base"` // the size of order to keep on either side
+	AmountOfABase          float64       `valid:"-" toml:"AMOUNT_OF_A_BASE" json:"amount_of_a_base"`   // the size of order to keep on either side
+	AmountOfAQuote         float64       `valid:"-" toml:"AMOUNT_OF_A_QUOTE" json:"amount_of_a_quote"` // the size of order in units of the quote asset, used instead of AMOUNT_OF_A_BASE
 	DataTypeA              string        `valid:"-" toml:"DATA_TYPE_A" json:"data_type_a"`
 	DataFeedAURL           string        `valid:"-" toml:"DATA_FEED_A_URL" json:"data_feed_a_url"`
 	DataTypeB              string        `valid:"-" toml:"DATA_TYPE_B" json:"data_type_b"`
 	DataFeedBURL           string        `valid:"-" toml:"DATA_FEED_B_URL" json:"data_feed_b_url"`
 	Levels                 []StaticLevel `valid:"-" toml:"LEVELS" json:"levels"`
+	// optionally scale the spread of all levels by the volatility of the center price over this window, 0 disables it
+	VolatilityWindowSeconds    uint32  `valid:"-" toml:"VOLATILITY_WINDOW_SECONDS" json:"volatility_window_seconds"`
+	VolatilitySpreadMultiplier float64 `valid:"-" toml:"VOLATILITY_SPREAD_MULTIPLIER" json:"volatility_spread_multiplier"`
+	VolatilityMaxSpreadFactor  float64 `valid:"-" toml:"VOLATILITY_MAX_SPREAD_FACTOR" json:"volatility_max_spread_factor"`
+	PriceFeedCacheConfig       `valid:"-"`
 }
 
 // MakeBuysellConfig factory method
@@ -67,20 +73,23 @@ func makeBuySellStrategy(
 	assetQuote *hProtocol.Asset,
 	config *BuySellConfig,
 ) (api.Strategy, error) {
+	e := validateAmountUnits(config.AmountOfABase, config.AmountOfAQuote)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the buysell strategy because of an invalid amount config: %s", e)
+	}
 	offsetSell := rateOffset{
 		percent:      config.RateOffsetPercent,
 		absolute:     config.RateOffset,
 		percentFirst: config.RateOffsetPercentFirst,
 	}
-	sellSideFeedPair, e := MakeFeedPair(
-		config.DataTypeA,
-		config.DataFeedAURL,
-		config.DataTypeB,
-		config.DataFeedBURL,
-	)
+	sellSideFeedPair, e := config.makeFeedPair(config.DataTypeA, config.DataFeedAURL, config.DataTypeB, config.DataFeedBURL)
 	if e != nil {
 		return nil, fmt.Errorf("cannot make the buysell strategy because we could not make the sell side feed pair: %s", e)
 	}
+	sellSideVolatility, e := makeVolatilitySpread(sellSideFeedPair, config.VolatilityWindowSeconds, config.VolatilitySpreadMultiplier, config.VolatilityMaxSpreadFactor)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the buysell strategy because of an invalid volatility config: %s", e)
+	}
 	orderConstraints := sdex.GetOrderConstraints(pair)
 	sellSideStrategy := makeSellSideStrategy(
 		sdex,
@@ -91,9 +100,11 @@ func makeBuySellStrategy(
 		makeStaticSpreadLevelProvider(
 			config.Levels,
 			config.AmountOfABase,
+			config.AmountOfAQuote,
 			offsetSell,
 			sellSideFeedPair,
 			orderConstraints,
+			sellSideVolatility,
 		),
 		config.PriceTolerance,
 		config.AmountTolerance,
@@ -106,15 +117,14 @@ func makeBuySellStrategy(
 		percentFirst: config.RateOffsetPercentFirst,
 		invert:       true,
 	}
-	buySideFeedPair, e := MakeFeedPair(
-		config.DataTypeB,
-		config.DataFeedBURL,
-		config.DataTypeA,
-		config.DataFeedAURL,
-	)
+	buySideFeedPair, e := config.makeFeedPair(config.DataTypeB, config.DataFeedBURL, config.DataTypeA, config.DataFeedAURL)
 	if e != nil {
 		return nil, fmt.Errorf("cannot make the buysell strategy because we could not make the buy side feed pair: %s", e)
 	}
+	buySideVolatility, e := makeVolatilitySpread(buySideFeedPair, config.VolatilityWindowSeconds, config.VolatilitySpreadMultiplier, config.VolatilityMaxSpreadFactor)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the buysell strategy because of an invalid volatility config: %s", e)
+	}
 	// switch sides of base/quote here for buy side
 	buySideStrategy := makeSellSideStrategy(
 		sdex,
@@ -125,9 +135,11 @@ func makeBuySellStrategy(
 		makeStaticSpreadLevelProvider(
 			config.Levels,
 			config.AmountOfABase,
+			config.AmountOfAQuote,
 			offsetBuy,
 			buySideFeedPair,
 			orderConstraints,
+			buySideVolatility,
 		),
 		config.PriceTolerance,
 		config.AmountTolerance,
@@ -0,0 +1,185 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/stellar/kelp/api"
+)
+
+// PriceFeedCacheStats are the counters of a cached price feed since the bot started
+type PriceFeedCacheStats struct {
+	Hits      int     `json:"hits"`       // prices returned from the cache within the TTL
+	Misses    int     `json:"misses"`     // prices fetched from the feed
+	Stale     int     `json:"stale"`      // last prices returned because the feed failed within the stale tolerance
+	Errors    int     `json:"errors"`     // feed failures that were returned as errors because the last price was too old
+	AgeMillis float64 `json:"age_millis"` // age of the last price that was fetched, as of the last call
+}
+
+// cachedPriceFeed wraps a child price feed and returns the last price for the TTL after it was fetched. When fetching the price fails
+// the last price is returned until the stale tolerance has passed after the TTL, so transient failures of a feed do not abort an update
+// cycle. Once the last price is older than the TTL and the stale tolerance the error of the child feed is returned.
+type cachedPriceFeed struct {
+	name           string
+	child          api.PriceFeed
+	ttl            time.Duration
+	staleTolerance time.Duration
+	now            func() time.Time
+
+	// uninitialized
+	mutex     *sync.Mutex
+	lastPrice float64
+	fetchedAt time.Time
+	updatedAt time.Time
+	stats     PriceFeedCacheStats
+}
+
+// ensure that it implements PriceFeed and TimestampedPriceFeed
+var _ api.PriceFeed = &cachedPriceFeed{}
+var _ api.TimestampedPriceFeed = &cachedPriceFeed{}
+
+// makeCachedPriceFeed is a factory method
+func makeCachedPriceFeed(name string, child api.PriceFeed, ttl time.Duration, staleTolerance time.Duration) *cachedPriceFeed {
+	return &cachedPriceFeed{
+		name:           name,
+		child:          child,
+		ttl:            ttl,
+		staleTolerance: staleTolerance,
+		now:            time.Now,
+		mutex:          &sync.Mutex{},
+	}
+}
+
+// GetPrice impl
+func (f *cachedPriceFeed) GetPrice() (float64, error) {
+	tp, e := f.GetTimestampedPrice()
+	if e != nil {
+		return 0, e
+	}
+	return tp.Price, nil
+}
+
+// GetTimestampedPrice impl, the timestamps are the ones of the last price that was fetched from the child feed
+func (f *cachedPriceFeed) GetTimestampedPrice() (*api.TimestampedPrice, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	now := f.now()
+	hasPrice := !f.fetchedAt.IsZero()
+	age := now.Sub(f.fetchedAt)
+	if hasPrice {
+		f.stats.AgeMillis = float64(age) / float64(time.Millisecond)
+	}
+	if hasPrice && age < f.ttl {
+		f.stats.Hits++
+		return f.last(), nil
+	}
+
+	tp, e := api.GetTimestampedPrice(f.child)
+	if e != nil {
+		if hasPrice && age < f.ttl+f.staleTolerance {
+			f.stats.Stale++
+			log.Printf("price feed '%s' failed, using the last price %.7f which is %s old: %s\n", f.name, f.lastPrice, age, e)
+			return f.last(), nil
+		}
+		f.stats.Errors++
+		return nil, e
+	}
+
+	f.stats.Misses++
+	f.stats.AgeMillis = 0
+	f.lastPrice = tp.Price
+	f.fetchedAt = now
+	f.updatedAt = tp.UpdatedAt
+	return f.last(), nil
+}
+
+// last returns the last price that was fetched, needs to be called while holding the mutex
+func (f *cachedPriceFeed) last() *api.TimestampedPrice {
+	return &api.TimestampedPrice{
+		Price:     f.lastPrice,
+		UpdatedAt: f.updatedAt,
+		FetchedAt: f.fetchedAt,
+	}
+}
+
+// getStats returns a copy of the counters
+func (f *cachedPriceFeed) getStats() PriceFeedCacheStats {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.stats
+}
+
+// cachedPriceFeeds are the cached price feeds made by this process keyed by the type and URL of the feed, they are shared between the feed
+// pairs of a strategy so a feed that is used by both sides of the buysell strategy is fetched once per TTL
+var cachedPriceFeeds = map[string]*cachedPriceFeed{}
+var cachedPriceFeedsMutex = &sync.Mutex{}
+
+// PriceFeedCacheConfig is embedded in the configs of the strategies that read from price feeds. It optionally reuses the prices of the
+// feeds for the TTL and falls back to the last price when a feed fails within the stale tolerance, both are 0 by default to disable the cache.
+type PriceFeedCacheConfig struct {
+	PriceFeedCacheTTLSeconds       uint32 `valid:"-" toml:"PRICE_FEED_CACHE_TTL_SECONDS" json:"price_feed_cache_ttl_seconds"`
+	PriceFeedStaleToleranceSeconds uint32 `valid:"-" toml:"PRICE_FEED_STALE_TOLERANCE_SECONDS" json:"price_feed_stale_tolerance_seconds"`
+}
+
+// makeFeedPair makes a feed pair whose feeds are cached as configured
+func (c PriceFeedCacheConfig) makeFeedPair(dataTypeA, dataFeedAUrl, dataTypeB, dataFeedBUrl string) (*api.FeedPair, error) {
+	return makeCachedFeedPair(dataTypeA, dataFeedAUrl, dataTypeB, dataFeedBUrl, c.PriceFeedCacheTTLSeconds, c.PriceFeedStaleToleranceSeconds)
+}
+
+// makeCachedFeedPair makes a feed pair whose feeds are cached, it makes an uncached feed pair when both the TTL and the stale tolerance are 0
+func makeCachedFeedPair(dataTypeA, dataFeedAUrl, dataTypeB, dataFeedBUrl string, ttlSeconds uint32, staleToleranceSeconds uint32) (*api.FeedPair, error) {
+	if ttlSeconds == 0 && staleToleranceSeconds == 0 {
+		return MakeFeedPair(dataTypeA, dataFeedAUrl, dataTypeB, dataFeedBUrl)
+	}
+
+	ttl := time.Duration(ttlSeconds) * time.Second
+	staleTolerance := time.Duration(staleToleranceSeconds) * time.Second
+	feedA, e := getCachedPriceFeed(dataTypeA, dataFeedAUrl, ttl, staleTolerance)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make a feed pair because of an error when making priceFeed A: %s", e)
+	}
+
+	feedB, e := getCachedPriceFeed(dataTypeB, dataFeedBUrl, ttl, staleTolerance)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make a feed pair because of an error when making priceFeed B: %s", e)
+	}
+
+	return &api.FeedPair{
+		FeedA: feedA,
+		FeedB: feedB,
+	}, nil
+}
+
+func getCachedPriceFeed(feedType string, url string, ttl time.Duration, staleTolerance time.Duration) (*cachedPriceFeed, error) {
+	cachedPriceFeedsMutex.Lock()
+	defer cachedPriceFeedsMutex.Unlock()
+
+	name := fmt.Sprintf("%s/%s", feedType, url)
+	if f, ok := cachedPriceFeeds[name]; ok && f.ttl == ttl && f.staleTolerance == staleTolerance {
+		return f, nil
+	}
+
+	child, e := MakePriceFeed(feedType, url)
+	if e != nil {
+		return nil, e
+	}
+	// only the fetches that miss the cache are timed
+	f := makeCachedPriceFeed(name, makeTimedPriceFeed(child), ttl, staleTolerance)
+	cachedPriceFeeds[name] = f
+	return f, nil
+}
+
+// GetPriceFeedCacheStats returns the counters of the cached price feeds made by this process, keyed by the type and URL of the feed
+func GetPriceFeedCacheStats() map[string]PriceFeedCacheStats {
+	cachedPriceFeedsMutex.Lock()
+	defer cachedPriceFeedsMutex.Unlock()
+
+	m := map[string]PriceFeedCacheStats{}
+	for name, f := range cachedPriceFeeds {
+		m[name] = f.getStats()
+	}
+	return m
+}
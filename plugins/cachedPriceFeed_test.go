@@ -0,0 +1,118 @@
+package plugins
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stellar/go/support/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyFeed returns an incrementing price, or an error when failing is set
+type flakyFeed struct {
+	price   float64
+	failing bool
+}
+
+func (f *flakyFeed) GetPrice() (float64, error) {
+	if f.failing {
+		return 0, fmt.Errorf("feed is down")
+	}
+	f.price++
+	return f.price, nil
+}
+
+func TestCachedPriceFeed(t *testing.T) {
+	child := &flakyFeed{}
+	f := makeCachedPriceFeed("test", child, 10*time.Second, 30*time.Second)
+	start := time.Unix(1000, 0)
+	now := start
+	f.now = func() time.Time { return now }
+
+	testCases := []struct {
+		name      string
+		offset    time.Duration
+		failing   bool
+		wantPrice float64
+		wantError bool
+	}{
+		{name: "first fetch", offset: 0, wantPrice: 1},
+		{name: "within the TTL", offset: 9 * time.Second, wantPrice: 1},
+		{name: "after the TTL", offset: 10 * time.Second, wantPrice: 2},
+		{name: "failure within the stale tolerance", offset: 49 * time.Second, failing: true, wantPrice: 2},
+		{name: "failure after the stale tolerance", offset: 50 * time.Second, failing: true, wantError: true},
+		{name: "recovered", offset: 51 * time.Second, wantPrice: 3},
+	}
+
+	for _, k := range testCases {
+		now = start.Add(k.offset)
+		child.failing = k.failing
+		price, e := f.GetPrice()
+		if k.wantError {
+			assert.Error(t, e, k.name)
+			continue
+		}
+		if !assert.NoError(t, e, k.name) {
+			return
+		}
+		assert.Equal(t, k.wantPrice, price, k.name)
+	}
+
+	assert.Equal(t, PriceFeedCacheStats{Hits: 1, Misses: 3, Stale: 1, Errors: 1, AgeMillis: 0}, f.getStats())
+}
+
+func TestCachedPriceFeedWithoutTTL(t *testing.T) {
+	child := &flakyFeed{}
+	f := makeCachedPriceFeed("test", child, 0, 5*time.Second)
+	now := time.Unix(1000, 0)
+	f.now = func() time.Time { return now }
+
+	for _, want := range []float64{1, 2} {
+		price, e := f.GetPrice()
+		if !assert.NoError(t, e) {
+			return
+		}
+		assert.Equal(t, want, price)
+	}
+
+	// the feed is fetched every time and only falls back to the last price when it fails
+	child.failing = true
+	now = now.Add(4 * time.Second)
+	price, e := f.GetPrice()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 2.0, price)
+	assert.Equal(t, PriceFeedCacheStats{Hits: 0, Misses: 2, Stale: 1, Errors: 0, AgeMillis: 4000}, f.getStats())
+}
+
+func TestPriceFeedCacheConfigRead(t *testing.T) {
+	dir, e := ioutil.TempDir("", "kelp_price_feed_cache")
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "buysell.cfg")
+	contents := `DATA_TYPE_A = "fixed"
+DATA_FEED_A_URL = "1.0"
+PRICE_FEED_CACHE_TTL_SECONDS = 5
+PRICE_FEED_STALE_TOLERANCE_SECONDS = 60
+`
+	if !assert.NoError(t, ioutil.WriteFile(path, []byte(contents), 0644)) {
+		return
+	}
+
+	var cfg BuySellConfig
+	if !assert.NoError(t, config.Read(path, &cfg)) {
+		return
+	}
+	assert.Equal(t, uint32(5), cfg.PriceFeedCacheTTLSeconds)
+	assert.Equal(t, uint32(60), cfg.PriceFeedStaleToleranceSeconds)
+	assert.True(t, strings.Contains(cfg.String(), "PRICE_FEED_CACHE_TTL_SECONDS: 5\n"), cfg.String())
+	assert.True(t, strings.Contains(cfg.String(), "PRICE_FEED_STALE_TOLERANCE_SECONDS: 60\n"), cfg.String())
+}
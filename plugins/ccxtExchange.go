@@ -3,6 +3,7 @@ package plugins
 import (
 	"fmt"
 	"log"
+	"math"
 	"sort"
 	"strconv"
 	"time"
@@ -15,18 +16,55 @@ import (
 
 const ccxtBalancePrecision = 10
 
+// ccxtProBridgeURLParam is the EXCHANGE_PARAMS key for the websocket URL of a ccxt-pro bridge, it is not passed on to the exchange
+const ccxtProBridgeURLParam = "ccxt_pro_bridge_url"
+
+// EXCHANGE_PARAMS keys of the orderbook options, they are not passed on to the exchange
+const (
+	// ccxtOrderBookFetchLimitParam is the number of levels fetched from the exchange on each side, which can be more than the number of
+	// levels requested so enough levels remain after they are aggregated
+	ccxtOrderBookFetchLimitParam = "orderbook_fetch_limit"
+	// ccxtOrderBookTickSizeParam is the size of the price buckets that the levels are aggregated into, in units of the quote asset
+	ccxtOrderBookTickSizeParam = "orderbook_tick_size"
+)
+
+// ccxtOrderBookParams are the params used for market data only, which are kept when the exchange is not used for trading
+var ccxtOrderBookParams = []string{ccxtProBridgeURLParam, ccxtOrderBookFetchLimitParam, ccxtOrderBookTickSizeParam}
+
 // ensure that ccxtExchange conforms to the Exchange interface
 var _ api.Exchange = ccxtExchange{}
 
+// ensure that ccxtExchange can refresh its order constraints
+var _ api.ConstraintsRefresher = ccxtExchange{}
+
+// ensure that ccxtExchange can stream orderbooks and trades
+var _ api.StreamingExchange = ccxtExchange{}
+
+// ensure that ccxtExchange can modify orders
+var _ api.OrderModifier = ccxtExchange{}
+
+// ensure that ccxtExchange can report the time of the exchange
+var _ api.ServerClock = ccxtExchange{}
+
 // ccxtExchange is the implementation for the CCXT REST library that supports many exchanges (https://github.com/franz-see/ccxt-rest, https://github.com/ccxt/ccxt/)
 type ccxtExchange struct {
+	exchangeName       string
 	assetConverter     model.AssetConverterInterface
 	delimiter          string
 	ocOverridesHandler *OrderConstraintsOverridesHandler
 	api                *sdk.Ccxt
+	proBridge          *sdk.CcxtProBridge // nil when orderbooks and trades are not streamed from a ccxt-pro bridge
+	orderBookOptions   ccxtOrderBookOptions
+	orderParams        map[string]string // sent with every order, from the EXCHANGE_PARAMS with the order param prefix
 	simMode            bool
 }
 
+// ccxtOrderBookOptions control how many levels are fetched from the exchange and how they are aggregated
+type ccxtOrderBookOptions struct {
+	fetchLimit int     // 0 fetches the number of levels that were requested
+	tickSize   float64 // 0 does not aggregate the levels
+}
+
 // makeCcxtExchange is a factory method to make an exchange using the CCXT interface
 func makeCcxtExchange(
 	exchangeName string,
@@ -44,25 +82,95 @@ func makeCcxtExchange(
 		return nil, fmt.Errorf("need exactly 1 ExchangeAPIKey")
 	}
 
-	c, e := sdk.MakeInitializedCcxtExchange(exchangeName, apiKeys[0], exchangeParams, headers)
+	orderParams, innerParams := extractOrderParams(exchangeParams)
+	bridgeURL, innerParams := extractCcxtProBridgeURL(innerParams)
+	orderBookOptions, innerParams, e := extractCcxtOrderBookOptions(innerParams)
+	if e != nil {
+		return nil, fmt.Errorf("invalid orderbook params for ccxt exchange: %s", e)
+	}
+	c, e := sdk.MakeInitializedCcxtExchange(exchangeName, apiKeys[0], innerParams, headers)
 	if e != nil {
 		return nil, fmt.Errorf("error making a ccxt exchange: %s", e)
 	}
 
+	var proBridge *sdk.CcxtProBridge
+	if bridgeURL != "" {
+		proBridge, e = sdk.MakeCcxtProBridge(bridgeURL, exchangeName, apiKeys[0], innerParams)
+		if e != nil {
+			return nil, fmt.Errorf("error making a ccxt-pro bridge: %s", e)
+		}
+	}
+
 	ocOverridesHandler := MakeEmptyOrderConstraintsOverridesHandler()
 	if orderConstraintOverrides != nil {
 		ocOverridesHandler = MakeOrderConstraintsOverridesHandler(orderConstraintOverrides)
 	}
 
 	return ccxtExchange{
+		exchangeName:       exchangeName,
 		assetConverter:     model.CcxtAssetConverter,
 		delimiter:          "/",
 		ocOverridesHandler: ocOverridesHandler,
 		api:                c,
+		proBridge:          proBridge,
+		orderBookOptions:   orderBookOptions,
+		orderParams:        orderParams,
 		simMode:            simMode,
 	}, nil
 }
 
+// extractCcxtProBridgeURL returns the URL of the ccxt-pro bridge (empty if not set) and the remaining params for the exchange
+func extractCcxtProBridgeURL(exchangeParams []api.ExchangeParam) (string, []api.ExchangeParam) {
+	bridgeURL := ""
+	innerParams := []api.ExchangeParam{}
+	for _, p := range exchangeParams {
+		if p.Param == ccxtProBridgeURLParam {
+			bridgeURL = p.Value
+			continue
+		}
+		innerParams = append(innerParams, p)
+	}
+	return bridgeURL, innerParams
+}
+
+// extractCcxtOrderBookOptions returns the orderbook options and the remaining params for the exchange
+func extractCcxtOrderBookOptions(exchangeParams []api.ExchangeParam) (ccxtOrderBookOptions, []api.ExchangeParam, error) {
+	options := ccxtOrderBookOptions{}
+	innerParams := []api.ExchangeParam{}
+	for _, p := range exchangeParams {
+		switch p.Param {
+		case ccxtOrderBookFetchLimitParam:
+			fetchLimit, e := strconv.Atoi(p.Value)
+			if e != nil || fetchLimit <= 0 {
+				return options, nil, fmt.Errorf("'%s' needs to be a positive integer: %s", ccxtOrderBookFetchLimitParam, p.Value)
+			}
+			options.fetchLimit = fetchLimit
+		case ccxtOrderBookTickSizeParam:
+			tickSize, e := strconv.ParseFloat(p.Value, 64)
+			if e != nil || tickSize <= 0 {
+				return options, nil, fmt.Errorf("'%s' needs to be a positive number: %s", ccxtOrderBookTickSizeParam, p.Value)
+			}
+			options.tickSize = tickSize
+		default:
+			innerParams = append(innerParams, p)
+		}
+	}
+	return options, innerParams, nil
+}
+
+// ccxtMarketDataParams returns the params that are used for the market data of ccxt exchanges, dropping the params used for trading
+func ccxtMarketDataParams(exchangeParams []api.ExchangeParam) []api.ExchangeParam {
+	params := []api.ExchangeParam{}
+	for _, p := range exchangeParams {
+		for _, name := range ccxtOrderBookParams {
+			if p.Param == name {
+				params = append(params, p)
+			}
+		}
+	}
+	return params
+}
+
 // GetTickerPrice impl.
 func (c ccxtExchange) GetTickerPrice(pairs []model.TradingPair) (map[model.TradingPair]api.Ticker, error) {
 	pairsMap, e := model.TradingPairs2Strings(c.assetConverter, c.delimiter, pairs)
@@ -118,6 +226,11 @@ func (c ccxtExchange) GetOrderConstraints(pair *model.TradingPair) *model.OrderC
 	return c.ocOverridesHandler.Apply(pair, oc)
 }
 
+// RefreshOrderConstraints impl, reloads the markets from the exchange since that is where CCXT gets the precision and limits from
+func (c ccxtExchange) RefreshOrderConstraints() error {
+	return c.api.ReloadMarkets()
+}
+
 // OverrideOrderConstraints impl, can partially override values for specific pairs
 func (c ccxtExchange) OverrideOrderConstraints(pair *model.TradingPair, override *model.OrderConstraintsOverride) {
 	c.ocOverridesHandler.Upsert(pair, override)
@@ -160,12 +273,45 @@ func (c ccxtExchange) GetOrderBook(pair *model.TradingPair, maxCount int32) (*mo
 		return nil, fmt.Errorf("error converting pair to string: %s", e)
 	}
 
-	limit := int(maxCount)
+	limit := c.fetchLimit(maxCount)
 	ob, e := c.api.FetchOrderBook(pairString, &limit)
 	if e != nil {
 		return nil, fmt.Errorf("error while fetching orderbook for trading pair '%s': %s", pairString, e)
 	}
+	return c.readOrderBook(ob, pair, maxCount)
+}
+
+// fetchLimit returns the number of levels to fetch on each side for the requested number of levels
+func (c ccxtExchange) fetchLimit(maxCount int32) int {
+	if c.orderBookOptions.fetchLimit > int(maxCount) {
+		return c.orderBookOptions.fetchLimit
+	}
+	return int(maxCount)
+}
+
+// StreamOrderBook impl, it watches the orderbook on the ccxt-pro bridge
+func (c ccxtExchange) StreamOrderBook(pair *model.TradingPair, maxCount int32, handler func(ob *model.OrderBook)) error {
+	if c.proBridge == nil {
+		return api.ErrOrderBookStreamUnsupported
+	}
 
+	pairString, e := pair.ToString(c.assetConverter, c.delimiter)
+	if e != nil {
+		return fmt.Errorf("error converting pair to string: %s", e)
+	}
+
+	return c.proBridge.WatchOrderBook(pairString, c.fetchLimit(maxCount), func(ob map[string][]sdk.CcxtOrder) error {
+		orderBook, e := c.readOrderBook(ob, pair, maxCount)
+		if e != nil {
+			return fmt.Errorf("error while reading streamed orderbook for trading pair '%s': %s", pairString, e)
+		}
+		handler(orderBook)
+		return nil
+	})
+}
+
+// readOrderBook converts the orderbook, aggregating the levels when a tick size is set and keeping at most maxCount levels on each side
+func (c ccxtExchange) readOrderBook(ob map[string][]sdk.CcxtOrder, pair *model.TradingPair, maxCount int32) (*model.OrderBook, error) {
 	if _, ok := ob["asks"]; !ok {
 		return nil, fmt.Errorf("orderbook did not contain the 'asks' field: %v", ob)
 	}
@@ -173,11 +319,50 @@ func (c ccxtExchange) GetOrderBook(pair *model.TradingPair, maxCount int32) (*mo
 		return nil, fmt.Errorf("orderbook did not contain the 'bids' field: %v", ob)
 	}
 
-	asks := c.readOrders(ob["asks"], pair, model.OrderActionSell)
-	bids := c.readOrders(ob["bids"], pair, model.OrderActionBuy)
+	rawAsks := ob["asks"]
+	rawBids := ob["bids"]
+	if c.orderBookOptions.tickSize > 0 {
+		rawAsks = aggregateCcxtOrders(rawAsks, c.orderBookOptions.tickSize, false)
+		rawBids = aggregateCcxtOrders(rawBids, c.orderBookOptions.tickSize, true)
+	}
+	if maxCount > 0 && len(rawAsks) > int(maxCount) {
+		rawAsks = rawAsks[:maxCount]
+	}
+	if maxCount > 0 && len(rawBids) > int(maxCount) {
+		rawBids = rawBids[:maxCount]
+	}
+
+	asks := c.readOrders(rawAsks, pair, model.OrderActionSell)
+	bids := c.readOrders(rawBids, pair, model.OrderActionBuy)
 	return model.MakeOrderBook(pair, asks, bids), nil
 }
 
+// aggregateCcxtOrders sums the amounts of the levels in each price bucket of tickSize, which are expected to be sorted from the top of the
+// orderbook. Bids are rounded down and asks are rounded up to their bucket so the aggregated levels are never better than the original ones.
+func aggregateCcxtOrders(orders []sdk.CcxtOrder, tickSize float64, isBid bool) []sdk.CcxtOrder {
+	// the epsilon keeps prices that are already on a bucket boundary in their bucket despite floating point errors
+	const epsilon = 1e-9
+
+	aggregated := []sdk.CcxtOrder{}
+	for _, o := range orders {
+		var bucket float64
+		if isBid {
+			bucket = math.Floor(o.Price/tickSize + epsilon)
+		} else {
+			bucket = math.Ceil(o.Price/tickSize - epsilon)
+		}
+		price := bucket * tickSize
+
+		last := len(aggregated) - 1
+		if last >= 0 && math.Abs(aggregated[last].Price-price) < tickSize/2 {
+			aggregated[last].Amount += o.Amount
+			continue
+		}
+		aggregated = append(aggregated, sdk.CcxtOrder{Price: price, Amount: o.Amount})
+	}
+	return aggregated
+}
+
 func (c ccxtExchange) readOrders(orders []sdk.CcxtOrder, pair *model.TradingPair, orderAction model.OrderAction) []model.Order {
 	pricePrecision := c.GetOrderConstraints(pair).PricePrecision
 	volumePrecision := c.GetOrderConstraints(pair).VolumePrecision
@@ -234,6 +419,35 @@ func (c ccxtExchange) GetTradeHistory(pair model.TradingPair, maybeCursorStart i
 	}, nil
 }
 
+// StreamTrades impl, it watches the trades of the account on the ccxt-pro bridge
+func (c ccxtExchange) StreamTrades(pair *model.TradingPair, handler func(trade model.Trade)) error {
+	if c.proBridge == nil {
+		return api.ErrTradeStreamUnsupported
+	}
+
+	pairString, e := pair.ToString(c.assetConverter, c.delimiter)
+	if e != nil {
+		return fmt.Errorf("error converting pair to string: %s", e)
+	}
+
+	return c.proBridge.WatchMyTrades(pairString, func(tradesRaw []sdk.CcxtTrade) error {
+		trades := []model.Trade{}
+		for _, raw := range tradesRaw {
+			t, e := c.readTrade(pair, pairString, raw)
+			if e != nil {
+				return fmt.Errorf("error while reading streamed trade: %s", e)
+			}
+			trades = append(trades, *t)
+		}
+
+		sort.Sort(model.TradesByTsID(trades))
+		for _, t := range trades {
+			handler(t)
+		}
+		return nil
+	})
+}
+
 // GetLatestTradeCursor impl.
 func (c ccxtExchange) GetLatestTradeCursor() (interface{}, error) {
 	timeNowMillis := time.Now().UnixNano() / int64(time.Millisecond)
@@ -278,6 +492,56 @@ func (c ccxtExchange) GetTrades(pair *model.TradingPair, maybeCursor interface{}
 	}, nil
 }
 
+// GetCandles impl
+func (c ccxtExchange) GetCandles(pair *model.TradingPair, interval time.Duration, since *model.Timestamp) ([]model.Candle, error) {
+	pairString, e := pair.ToString(c.assetConverter, c.delimiter)
+	if e != nil {
+		return nil, fmt.Errorf("error converting pair to string: %s", e)
+	}
+	timeframe, e := model.CandleIntervalName(interval)
+	if e != nil {
+		return nil, e
+	}
+
+	var maybeSince *int64
+	if since != nil {
+		s := since.AsInt64()
+		maybeSince = &s
+	}
+	candlesRaw, e := c.api.FetchOHLCV(pairString, timeframe, maybeSince)
+	if e != nil {
+		return nil, fmt.Errorf("error while fetching candles for trading pair '%s': %s", pairString, e)
+	}
+
+	orderConstraints := c.GetOrderConstraints(pair)
+	candles := []model.Candle{}
+	for _, raw := range candlesRaw {
+		candles = append(candles, model.Candle{
+			Pair:      pair,
+			Timestamp: model.MakeTimestamp(raw.Timestamp),
+			Interval:  interval,
+			Open:      model.NumberFromFloat(raw.Open, orderConstraints.PricePrecision),
+			High:      model.NumberFromFloat(raw.High, orderConstraints.PricePrecision),
+			Low:       model.NumberFromFloat(raw.Low, orderConstraints.PricePrecision),
+			Close:     model.NumberFromFloat(raw.Close, orderConstraints.PricePrecision),
+			Volume:    model.NumberFromFloat(raw.Volume, orderConstraints.VolumePrecision),
+		})
+	}
+	return candles, nil
+}
+
+// feeAsset converts the currency of a fee reported by ccxt to an asset, currencies that the asset converter does not know are kept as they are
+func (c ccxtExchange) feeAsset(currency string) model.Asset {
+	if currency == "" {
+		return ""
+	}
+	asset, e := c.assetConverter.FromString(currency)
+	if e != nil {
+		return model.Asset(currency)
+	}
+	return asset
+}
+
 func (c ccxtExchange) readTrade(pair *model.TradingPair, pairString string, rawTrade sdk.CcxtTrade) (*model.Trade, error) {
 	if rawTrade.Symbol != pairString {
 		return nil, fmt.Errorf("expected '%s' for 'symbol' field, got: %s", pairString, rawTrade.Symbol)
@@ -301,6 +565,7 @@ func (c ccxtExchange) readTrade(pair *model.TradingPair, pairString string, rawT
 		},
 		TransactionID: model.MakeTransactionID(rawTrade.ID),
 		Fee:           model.NumberFromFloat(rawTrade.Fee.Cost, feecCostPrecision),
+		FeeAsset:      c.feeAsset(rawTrade.Fee.Currency),
 	}
 
 	if rawTrade.Side == "sell" {
@@ -369,12 +634,13 @@ func (c ccxtExchange) convertOpenOrderFromCcxt(pair *model.TradingPair, o sdk.Cc
 
 	return &model.OpenOrder{
 		Order: model.Order{
-			Pair:        pair,
-			OrderAction: orderAction,
-			OrderType:   model.OrderTypeLimit,
-			Price:       model.NumberFromFloat(o.Price, c.GetOrderConstraints(pair).PricePrecision),
-			Volume:      model.NumberFromFloat(o.Amount, c.GetOrderConstraints(pair).VolumePrecision),
-			Timestamp:   ts,
+			Pair:          pair,
+			OrderAction:   orderAction,
+			OrderType:     model.OrderTypeLimit,
+			Price:         model.NumberFromFloat(o.Price, c.GetOrderConstraints(pair).PricePrecision),
+			Volume:        model.NumberFromFloat(o.Amount, c.GetOrderConstraints(pair).VolumePrecision),
+			Timestamp:     ts,
+			ClientOrderID: clientOrderIDTag(o.ClientOrderID),
 		},
 		ID:             o.ID,
 		StartTime:      ts,
@@ -395,13 +661,134 @@ func (c ccxtExchange) AddOrder(order *model.Order) (*model.TransactionID, error)
 		side = "buy"
 	}
 
-	log.Printf("ccxt is submitting order: pair=%s, orderAction=%s, orderType=%s, volume=%s, price=%s\n",
-		pairString, order.OrderAction.String(), order.OrderType.String(), order.Volume.AsString(), order.Price.AsString())
-	ccxtOpenOrder, e := c.api.CreateLimitOrder(pairString, side, order.Volume.AsFloat(), order.Price.AsFloat())
+	if order.OrderType.IsStop() {
+		return c.addStopOrder(order, pairString, side)
+	}
+
+	params := ccxtOrderParams(mergeOrderParams(c.orderParams, order), order.PostOnly)
+	if order.ClientOrderID != "" {
+		if params == nil {
+			params = map[string]interface{}{}
+		}
+		// exchanges need the client order IDs of open orders to be unique so a suffix is added to the tag of the order
+		params["clientOrderId"] = uniqueClientOrderID(order.ClientOrderID, time.Now())
+	}
+	log.Printf("ccxt is submitting order: pair=%s, orderAction=%s, orderType=%s, volume=%s, price=%s, postOnly=%v, params=%v\n",
+		pairString, order.OrderAction.String(), order.OrderType.String(), order.Volume.AsString(), order.Price.AsString(), order.PostOnly, params)
+	ccxtOpenOrder, e := c.api.CreateLimitOrderWithParams(pairString, side, order.Volume.AsFloat(), order.Price.AsFloat(), params)
 	if e != nil {
 		return nil, fmt.Errorf("error while creating limit order %s: %s", *order, e)
 	}
+	// some exchanges accept a post-only order that would take liquidity and cancel it immediately
+	if order.PostOnly && (ccxtOpenOrder.Status == "canceled" || ccxtOpenOrder.Status == "expired" || ccxtOpenOrder.Status == "rejected") {
+		return nil, fmt.Errorf("post-only order %s was not accepted as a maker order, status=%s", *order, ccxtOpenOrder.Status)
+	}
+
+	return model.MakeTransactionID(ccxtOpenOrder.ID), nil
+}
+
+// ccxtOrderParams converts the order params to the params of the createOrder endpoint, adding the unified postOnly param for post-only orders.
+// The values "true" and "false" are sent as booleans and all other values as strings. Returns nil when there are no params.
+func ccxtOrderParams(orderParams map[string]string, postOnly bool) map[string]interface{} {
+	if len(orderParams) == 0 && !postOnly {
+		return nil
+	}
+
+	params := map[string]interface{}{}
+	for k, v := range orderParams {
+		if v == "true" || v == "false" {
+			params[k] = v == "true"
+			continue
+		}
+		params[k] = v
+	}
+	if postOnly {
+		params["postOnly"] = true
+	}
+	return params
+}
+
+// ModifyOrder impl, only exchanges that implement editOrder natively are supported because CCXT emulates it with a cancel and a create
+// otherwise, which is what the caller does when this returns api.ErrModifyOrderUnsupported
+func (c ccxtExchange) ModifyOrder(txID *model.TransactionID, order *model.Order) (*model.TransactionID, error) {
+	if order.OrderType != model.OrderTypeLimit {
+		return nil, fmt.Errorf("only limit orders can be modified, orderType=%s", order.OrderType.String())
+	}
+	isNative, e := c.api.HasNative("editOrder")
+	if e != nil {
+		return nil, fmt.Errorf("unable to check whether the exchange supports editOrder: %s", e)
+	}
+	if !isNative {
+		return nil, api.ErrModifyOrderUnsupported
+	}
+
+	pairString, e := order.Pair.ToString(c.assetConverter, c.delimiter)
+	if e != nil {
+		return nil, fmt.Errorf("error converting pair to string: %s", e)
+	}
+	side := "sell"
+	if order.OrderAction.IsBuy() {
+		side = "buy"
+	}
 
+	log.Printf("ccxt is modifying order: ID=%s, pair=%s, orderAction=%s, volume=%s, price=%s, postOnly=%v\n",
+		txID.String(), pairString, order.OrderAction.String(), order.Volume.AsString(), order.Price.AsString(), order.PostOnly)
+	ccxtOpenOrder, e := c.api.EditLimitOrder(txID.String(), pairString, side, order.Volume.AsFloat(), order.Price.AsFloat(), order.PostOnly)
+	if e != nil {
+		return nil, fmt.Errorf("error while modifying order (ID=%s) to %s: %s", txID.String(), *order, e)
+	}
+	if order.PostOnly && (ccxtOpenOrder.Status == "canceled" || ccxtOpenOrder.Status == "expired" || ccxtOpenOrder.Status == "rejected") {
+		return nil, fmt.Errorf("post-only order %s was not accepted as a maker order after modifying it, status=%s", *order, ccxtOpenOrder.Status)
+	}
+	if ccxtOpenOrder.ID == "" {
+		return txID, nil
+	}
+	return model.MakeTransactionID(ccxtOpenOrder.ID), nil
+}
+
+// ccxtStopOrderTypes are the names of the stop order types on the ccxt exchanges that support them, ccxt does not unify stop orders
+var ccxtStopOrderTypes = map[string]map[model.OrderType]string{
+	"binance": {
+		model.OrderTypeStopLoss:  "STOP_LOSS",
+		model.OrderTypeStopLimit: "STOP_LOSS_LIMIT",
+	},
+	"binanceus": {
+		model.OrderTypeStopLoss:  "STOP_LOSS",
+		model.OrderTypeStopLimit: "STOP_LOSS_LIMIT",
+	},
+}
+
+// ccxtStopOrderType returns the name of the stop order type on the exchange
+func ccxtStopOrderType(exchangeName string, orderType model.OrderType) (string, error) {
+	stopOrderType, ok := ccxtStopOrderTypes[exchangeName][orderType]
+	if !ok {
+		return "", fmt.Errorf("%s orders are not supported on ccxt exchange '%s'", orderType, exchangeName)
+	}
+	return stopOrderType, nil
+}
+
+// addStopOrder places a stop order which rests on the exchange until the market reaches its stop price
+func (c ccxtExchange) addStopOrder(order *model.Order, pairString string, side string) (*model.TransactionID, error) {
+	e := validateStopOrder(order)
+	if e != nil {
+		return nil, fmt.Errorf("invalid ccxt order: %s", e)
+	}
+	stopOrderType, e := ccxtStopOrderType(c.exchangeName, order.OrderType)
+	if e != nil {
+		return nil, e
+	}
+
+	var price *float64
+	if order.Price != nil {
+		p := order.Price.AsFloat()
+		price = &p
+	}
+	log.Printf("ccxt is submitting stop order: pair=%s, orderAction=%s, orderType=%s, volume=%s, order=%s\n",
+		pairString, order.OrderAction.String(), stopOrderType, order.Volume.AsString(), *order)
+	ccxtOpenOrder, e := c.api.CreateStopOrder(pairString, stopOrderType, side, order.Volume.AsFloat(), price, order.StopPrice.AsFloat())
+	if e != nil {
+		return nil, fmt.Errorf("error while creating stop order %s: %s", *order, e)
+	}
 	return model.MakeTransactionID(ccxtOpenOrder.ID), nil
 }
 
@@ -420,6 +807,47 @@ func (c ccxtExchange) CancelOrder(txID *model.TransactionID, pair model.TradingP
 	return model.CancelResultCancelSuccessful, nil
 }
 
+// GetTradesForOrder impl
+func (c ccxtExchange) GetTradesForOrder(txID *model.TransactionID, pair model.TradingPair) ([]model.Trade, error) {
+	pairString, e := pair.ToString(c.assetConverter, c.delimiter)
+	if e != nil {
+		return nil, fmt.Errorf("error converting pair to string: %s", e)
+	}
+
+	tradesRaw, e := c.api.FetchOrderTrades(txID.String(), pairString)
+	if e != nil {
+		return nil, fmt.Errorf("error while fetching trades of order '%s' for trading pair '%s': %s", txID.String(), pairString, e)
+	}
+
+	trades := []model.Trade{}
+	for _, raw := range tradesRaw {
+		t, e := c.readTrade(&pair, pairString, raw)
+		if e != nil {
+			return nil, fmt.Errorf("error while reading trade: %s", e)
+		}
+		trades = append(trades, *t)
+	}
+	sort.Sort(model.TradesByTsID(trades))
+	return trades, nil
+}
+
+// GetServerTime impl, only exchanges that implement fetchTime are supported
+func (c ccxtExchange) GetServerTime() (time.Time, error) {
+	hasFetchTime, e := c.api.HasFetchTime()
+	if e != nil {
+		return time.Time{}, fmt.Errorf("unable to check whether the exchange supports fetchTime: %s", e)
+	}
+	if !hasFetchTime {
+		return time.Time{}, api.ErrServerTimeUnsupported
+	}
+
+	millis, e := c.api.FetchTime()
+	if e != nil {
+		return time.Time{}, fmt.Errorf("error while fetching the server time: %s", e)
+	}
+	return time.Unix(0, millis*int64(time.Millisecond)), nil
+}
+
 // PrepareDeposit impl
 func (c ccxtExchange) PrepareDeposit(asset model.Asset, amount *model.Number) (*api.PrepareDepositResult, error) {
 	// TODO implement
@@ -3,12 +3,14 @@ package plugins
 import (
 	"fmt"
 	"log"
+	"math"
 	"strconv"
 	"testing"
 	"time"
 
 	"github.com/stellar/kelp/api"
 	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/sdk"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -490,3 +492,104 @@ func TestGetOrderConstraints_Ccxt_Precision(t *testing.T) {
 		})
 	}
 }
+
+func TestExtractCcxtProBridgeURL(t *testing.T) {
+	bridgeURL, innerParams := extractCcxtProBridgeURL([]api.ExchangeParam{
+		{Param: "password", Value: "pass"},
+		{Param: "ccxt_pro_bridge_url", Value: "ws://localhost:3001"},
+	})
+	assert.Equal(t, "ws://localhost:3001", bridgeURL)
+	assert.Equal(t, []api.ExchangeParam{{Param: "password", Value: "pass"}}, innerParams)
+
+	bridgeURL, innerParams = extractCcxtProBridgeURL([]api.ExchangeParam{})
+	assert.Equal(t, "", bridgeURL)
+	assert.Equal(t, []api.ExchangeParam{}, innerParams)
+}
+
+func TestExtractCcxtOrderBookOptions(t *testing.T) {
+	options, innerParams, e := extractCcxtOrderBookOptions([]api.ExchangeParam{
+		{Param: "password", Value: "pass"},
+		{Param: "orderbook_fetch_limit", Value: "500"},
+		{Param: "orderbook_tick_size", Value: "0.01"},
+	})
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, ccxtOrderBookOptions{fetchLimit: 500, tickSize: 0.01}, options)
+	assert.Equal(t, []api.ExchangeParam{{Param: "password", Value: "pass"}}, innerParams)
+
+	_, _, e = extractCcxtOrderBookOptions([]api.ExchangeParam{{Param: "orderbook_fetch_limit", Value: "0"}})
+	assert.Error(t, e)
+	_, _, e = extractCcxtOrderBookOptions([]api.ExchangeParam{{Param: "orderbook_tick_size", Value: "abc"}})
+	assert.Error(t, e)
+
+	marketDataParams := ccxtMarketDataParams([]api.ExchangeParam{
+		{Param: "password", Value: "pass"},
+		{Param: "ccxt_pro_bridge_url", Value: "ws://localhost:3001"},
+		{Param: "orderbook_tick_size", Value: "0.01"},
+	})
+	assert.Equal(t, []api.ExchangeParam{
+		{Param: "ccxt_pro_bridge_url", Value: "ws://localhost:3001"},
+		{Param: "orderbook_tick_size", Value: "0.01"},
+	}, marketDataParams)
+}
+
+func TestAggregateCcxtOrders(t *testing.T) {
+	bids := []sdk.CcxtOrder{
+		{Price: 0.2059, Amount: 1},
+		{Price: 0.2051, Amount: 2},
+		{Price: 0.2050, Amount: 3},
+		{Price: 0.2049, Amount: 4},
+		{Price: 0.2031, Amount: 5},
+	}
+	assert.Equal(t, []sdk.CcxtOrder{
+		{Price: 0.205, Amount: 6},
+		{Price: 0.204, Amount: 4},
+		{Price: 0.203, Amount: 5},
+	}, roundCcxtOrders(aggregateCcxtOrders(bids, 0.001, true)))
+
+	asks := []sdk.CcxtOrder{
+		{Price: 0.2060, Amount: 1},
+		{Price: 0.2061, Amount: 2},
+		{Price: 0.2069, Amount: 3},
+		{Price: 0.2075, Amount: 4},
+	}
+	assert.Equal(t, []sdk.CcxtOrder{
+		{Price: 0.206, Amount: 1},
+		{Price: 0.207, Amount: 5},
+		{Price: 0.208, Amount: 4},
+	}, roundCcxtOrders(aggregateCcxtOrders(asks, 0.001, false)))
+
+	assert.Equal(t, []sdk.CcxtOrder{}, aggregateCcxtOrders([]sdk.CcxtOrder{}, 0.001, true))
+}
+
+// roundCcxtOrders removes the floating point errors of the bucket prices so they can be compared
+func roundCcxtOrders(orders []sdk.CcxtOrder) []sdk.CcxtOrder {
+	rounded := []sdk.CcxtOrder{}
+	for _, o := range orders {
+		rounded = append(rounded, sdk.CcxtOrder{Price: math.Round(o.Price*1e9) / 1e9, Amount: o.Amount})
+	}
+	return rounded
+}
+
+func TestCcxtStopOrderType(t *testing.T) {
+	stopOrderType, e := ccxtStopOrderType("binance", model.OrderTypeStopLimit)
+	if assert.NoError(t, e) {
+		assert.Equal(t, "STOP_LOSS_LIMIT", stopOrderType)
+	}
+	stopOrderType, e = ccxtStopOrderType("binanceus", model.OrderTypeStopLoss)
+	if assert.NoError(t, e) {
+		assert.Equal(t, "STOP_LOSS", stopOrderType)
+	}
+	_, e = ccxtStopOrderType("poloniex", model.OrderTypeStopLoss)
+	assert.Error(t, e)
+}
+
+func TestCcxtOrderParams(t *testing.T) {
+	assert.Nil(t, ccxtOrderParams(map[string]string{}, false))
+	assert.Equal(t, map[string]interface{}{"postOnly": true}, ccxtOrderParams(nil, true))
+	assert.Equal(t,
+		map[string]interface{}{"timeInForce": "GTC", "reduceOnly": false, "postOnly": true},
+		ccxtOrderParams(map[string]string{"timeInForce": "GTC", "reduceOnly": "false"}, true),
+	)
+}
@@ -0,0 +1,82 @@
+package plugins
+
+import (
+	"fmt"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/sdk"
+)
+
+// ensure that ccxtExchange can withdraw to an address with a memo
+var _ api.WithdrawalExchange = ccxtExchange{}
+
+// Withdraw impl.
+func (c ccxtExchange) Withdraw(asset model.Asset, amount *model.Number, address string, memo string) (*api.Withdrawal, error) {
+	code, e := c.assetConverter.ToString(asset)
+	if e != nil {
+		return nil, fmt.Errorf("unable to convert asset '%s' to ccxt code: %s", asset, e)
+	}
+
+	tx, e := c.api.Withdraw(code, amount.AsFloat(), address, memo)
+	if e != nil {
+		return nil, fmt.Errorf("unable to withdraw %s %s to address '%s' (memo='%s'): %s", amount.AsString(), asset, address, memo, e)
+	}
+	if tx.ID == "" {
+		return nil, fmt.Errorf("exchange '%s' did not return the ID of the withdrawal of %s %s to address '%s'", c.exchangeName, amount.AsString(), asset, address)
+	}
+
+	w := makeCcxtWithdrawal(asset, tx)
+	// not every exchange echoes the request, keep what was requested
+	w.Amount = amount
+	w.Address = address
+	w.Memo = memo
+	return w, nil
+}
+
+// GetWithdrawal impl.
+func (c ccxtExchange) GetWithdrawal(asset model.Asset, withdrawalID string) (*api.Withdrawal, error) {
+	code, e := c.assetConverter.ToString(asset)
+	if e != nil {
+		return nil, fmt.Errorf("unable to convert asset '%s' to ccxt code: %s", asset, e)
+	}
+
+	txs, e := c.api.FetchWithdrawals(code)
+	if e != nil {
+		return nil, fmt.Errorf("unable to fetch withdrawals of %s: %s", asset, e)
+	}
+	for _, tx := range txs {
+		if tx.ID == withdrawalID {
+			return makeCcxtWithdrawal(asset, &tx), nil
+		}
+	}
+	return nil, fmt.Errorf("withdrawal '%s' of %s is not in the recent withdrawals returned by exchange '%s'", withdrawalID, asset, c.exchangeName)
+}
+
+func makeCcxtWithdrawal(asset model.Asset, tx *sdk.CcxtTransaction) *api.Withdrawal {
+	var amount *model.Number
+	if tx.Amount != 0 {
+		amount = model.NumberFromFloat(tx.Amount, ccxtBalancePrecision)
+	}
+	return &api.Withdrawal{
+		ID:      tx.ID,
+		Asset:   asset,
+		Amount:  amount,
+		Address: tx.Address,
+		Memo:    tx.Tag,
+		Status:  ccxtWithdrawalStatus(tx.Status),
+		TxID:    tx.TxID,
+	}
+}
+
+// ccxtWithdrawalStatus maps the unified status of a ccxt transaction
+func ccxtWithdrawalStatus(status string) api.WithdrawalStatus {
+	switch status {
+	case "ok":
+		return api.WithdrawalStatusComplete
+	case "failed", "canceled":
+		return api.WithdrawalStatusFailed
+	default:
+		return api.WithdrawalStatusPending
+	}
+}
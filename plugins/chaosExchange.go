@@ -0,0 +1,378 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// chaosParamPrefix is the prefix used in EXCHANGE_PARAMS to configure the chaosExchange, these params are never forwarded to the inner exchange
+const chaosParamPrefix = "chaos_"
+
+// ensure that chaosExchange conforms to the Exchange interface
+var _ api.Exchange = &chaosExchange{}
+
+// ensure that chaosExchange can refresh its order constraints
+var _ api.ConstraintsRefresher = &chaosExchange{}
+
+// ensure that chaosExchange can stream orderbooks and trades
+var _ api.StreamingExchange = &chaosExchange{}
+
+// ensure that chaosExchange can modify orders when the inner exchange can
+var _ api.OrderModifier = &chaosExchange{}
+
+// ensure that chaosExchange can report the server time and validate orders when the inner exchange can
+var _ api.ServerClock = &chaosExchange{}
+var _ api.OrderValidator = &chaosExchange{}
+
+// chaosConfig holds the knobs for the failures injected by the chaosExchange
+type chaosConfig struct {
+	latencyMillis       int64   // fixed latency added to every call
+	latencyJitterMillis int64   // random additional latency in the range [0, latencyJitterMillis) added to every call
+	errorRate           float64 // probability in [0, 1] that a call fails with an injected error
+	partialDataRate     float64 // probability in [0, 1] that a call returning a list drops a random suffix of that list
+}
+
+// String is the stringer function
+func (c *chaosConfig) String() string {
+	return fmt.Sprintf("chaosConfig[latencyMillis=%d, latencyJitterMillis=%d, errorRate=%.4f, partialDataRate=%.4f]",
+		c.latencyMillis, c.latencyJitterMillis, c.errorRate, c.partialDataRate)
+}
+
+// extractChaosConfig separates the chaos params from the params meant for the inner exchange, returns a nil config if chaos is not enabled
+func extractChaosConfig(exchangeParams []api.ExchangeParam) (*chaosConfig, []api.ExchangeParam, error) {
+	innerParams := []api.ExchangeParam{}
+	var config *chaosConfig
+	for _, p := range exchangeParams {
+		if !strings.HasPrefix(p.Param, chaosParamPrefix) {
+			innerParams = append(innerParams, p)
+			continue
+		}
+
+		if config == nil {
+			config = &chaosConfig{}
+		}
+		var e error
+		switch strings.TrimPrefix(p.Param, chaosParamPrefix) {
+		case "latency_millis":
+			config.latencyMillis, e = strconv.ParseInt(p.Value, 10, 64)
+		case "latency_jitter_millis":
+			config.latencyJitterMillis, e = strconv.ParseInt(p.Value, 10, 64)
+		case "error_rate":
+			config.errorRate, e = parseProbability(p.Value)
+		case "partial_data_rate":
+			config.partialDataRate, e = parseProbability(p.Value)
+		default:
+			return nil, nil, fmt.Errorf("unrecognized chaos exchange param: %s", p.Param)
+		}
+		if e != nil {
+			return nil, nil, fmt.Errorf("unable to parse value for chaos exchange param '%s': %s", p.Param, e)
+		}
+	}
+
+	if config != nil && (config.latencyMillis < 0 || config.latencyJitterMillis < 0) {
+		return nil, nil, fmt.Errorf("chaos exchange latency params need to be non-negative: %s", config)
+	}
+	return config, innerParams, nil
+}
+
+func parseProbability(s string) (float64, error) {
+	v, e := strconv.ParseFloat(s, 64)
+	if e != nil {
+		return 0, e
+	}
+	if v < 0 || v > 1 {
+		return 0, fmt.Errorf("probability needs to be between 0 and 1 inclusive: %f", v)
+	}
+	return v, nil
+}
+
+// chaosExchange wraps any api.Exchange and injects latency, random errors, and partial data so we can test strategy robustness
+type chaosExchange struct {
+	inner    api.Exchange
+	config   *chaosConfig
+	rng      *rand.Rand
+	rngMutex *sync.Mutex // rand.Rand is not safe for concurrent use and the exchange is called from the update loop and background threads
+}
+
+// makeChaosExchange is a factory method
+func makeChaosExchange(inner api.Exchange, config *chaosConfig) *chaosExchange {
+	log.Printf("wrapping exchange in a chaosExchange: %s\n", config)
+	return &chaosExchange{
+		inner:    inner,
+		config:   config,
+		rng:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		rngMutex: &sync.Mutex{},
+	}
+}
+
+// float64 returns a random number in [0, 1)
+func (c *chaosExchange) float64() float64 {
+	c.rngMutex.Lock()
+	defer c.rngMutex.Unlock()
+	return c.rng.Float64()
+}
+
+// int63n returns a random number in [0, n)
+func (c *chaosExchange) int63n(n int64) int64 {
+	c.rngMutex.Lock()
+	defer c.rngMutex.Unlock()
+	return c.rng.Int63n(n)
+}
+
+// inject adds latency and returns an error based on the configured errorRate
+func (c *chaosExchange) inject(methodName string) error {
+	latency := c.config.latencyMillis
+	if c.config.latencyJitterMillis > 0 {
+		latency += c.int63n(c.config.latencyJitterMillis)
+	}
+	if latency > 0 {
+		time.Sleep(time.Duration(latency) * time.Millisecond)
+	}
+
+	if c.float64() < c.config.errorRate {
+		log.Printf("chaosExchange is injecting an error in %s\n", methodName)
+		return fmt.Errorf("chaosExchange: injected error in %s", methodName)
+	}
+	return nil
+}
+
+// truncatedLength returns the number of elements of a list of size n to keep based on the configured partialDataRate
+func (c *chaosExchange) truncatedLength(methodName string, n int) int {
+	if n == 0 || c.float64() >= c.config.partialDataRate {
+		return n
+	}
+
+	keep := int(c.int63n(int64(n)))
+	log.Printf("chaosExchange is returning partial data in %s, keeping %d of %d elements\n", methodName, keep, n)
+	return keep
+}
+
+// GetAccountBalances impl
+func (c *chaosExchange) GetAccountBalances(assetList []interface{}) (map[interface{}]model.Number, error) {
+	if e := c.inject("GetAccountBalances"); e != nil {
+		return nil, e
+	}
+	return c.inner.GetAccountBalances(assetList)
+}
+
+// GetTickerPrice impl
+func (c *chaosExchange) GetTickerPrice(pairs []model.TradingPair) (map[model.TradingPair]api.Ticker, error) {
+	if e := c.inject("GetTickerPrice"); e != nil {
+		return nil, e
+	}
+	return c.inner.GetTickerPrice(pairs)
+}
+
+// GetAssetConverter impl
+func (c *chaosExchange) GetAssetConverter() model.AssetConverterInterface {
+	return c.inner.GetAssetConverter()
+}
+
+// GetOrderConstraints impl
+func (c *chaosExchange) GetOrderConstraints(pair *model.TradingPair) *model.OrderConstraints {
+	return c.inner.GetOrderConstraints(pair)
+}
+
+// RefreshOrderConstraints impl, is a no-op when the inner exchange cannot refresh its order constraints
+func (c *chaosExchange) RefreshOrderConstraints() error {
+	if refresher, ok := c.inner.(api.ConstraintsRefresher); ok {
+		return refresher.RefreshOrderConstraints()
+	}
+	return nil
+}
+
+// OverrideOrderConstraints impl
+func (c *chaosExchange) OverrideOrderConstraints(pair *model.TradingPair, override *model.OrderConstraintsOverride) {
+	c.inner.OverrideOrderConstraints(pair, override)
+}
+
+// GetOrderBook impl, can drop the deeper levels of either side of the book
+func (c *chaosExchange) GetOrderBook(pair *model.TradingPair, maxCount int32) (*model.OrderBook, error) {
+	if e := c.inject("GetOrderBook"); e != nil {
+		return nil, e
+	}
+
+	ob, e := c.inner.GetOrderBook(pair, maxCount)
+	if e != nil {
+		return nil, e
+	}
+	asks := ob.Asks()
+	bids := ob.Bids()
+	return model.MakeOrderBook(
+		ob.Pair(),
+		asks[:c.truncatedLength("GetOrderBook.asks", len(asks))],
+		bids[:c.truncatedLength("GetOrderBook.bids", len(bids))],
+	), nil
+}
+
+// GetTrades impl
+func (c *chaosExchange) GetTrades(pair *model.TradingPair, maybeCursor interface{}) (*api.TradesResult, error) {
+	if e := c.inject("GetTrades"); e != nil {
+		return nil, e
+	}
+	return c.inner.GetTrades(pair, maybeCursor)
+}
+
+// GetCandles impl
+func (c *chaosExchange) GetCandles(pair *model.TradingPair, interval time.Duration, since *model.Timestamp) ([]model.Candle, error) {
+	if e := c.inject("GetCandles"); e != nil {
+		return nil, e
+	}
+	candles, e := c.inner.GetCandles(pair, interval, since)
+	if e != nil {
+		return nil, e
+	}
+	return candles[:c.truncatedLength("GetCandles", len(candles))], nil
+}
+
+// GetTradeHistory impl
+func (c *chaosExchange) GetTradeHistory(pair model.TradingPair, maybeCursorStart interface{}, maybeCursorEnd interface{}) (*api.TradeHistoryResult, error) {
+	if e := c.inject("GetTradeHistory"); e != nil {
+		return nil, e
+	}
+	return c.inner.GetTradeHistory(pair, maybeCursorStart, maybeCursorEnd)
+}
+
+// GetLatestTradeCursor impl
+func (c *chaosExchange) GetLatestTradeCursor() (interface{}, error) {
+	if e := c.inject("GetLatestTradeCursor"); e != nil {
+		return nil, e
+	}
+	return c.inner.GetLatestTradeCursor()
+}
+
+// StreamTrades impl, failures are only injected when the stream is started
+func (c *chaosExchange) StreamTrades(pair *model.TradingPair, handler func(trade model.Trade)) error {
+	tradeStream, ok := c.inner.(api.TradeStream)
+	if !ok {
+		return api.ErrTradeStreamUnsupported
+	}
+	if e := c.inject("StreamTrades"); e != nil {
+		return e
+	}
+	return tradeStream.StreamTrades(pair, handler)
+}
+
+// StreamOrderBook impl, failures are only injected when the stream is started
+func (c *chaosExchange) StreamOrderBook(pair *model.TradingPair, maxCount int32, handler func(ob *model.OrderBook)) error {
+	orderBookStream, ok := c.inner.(api.OrderBookStream)
+	if !ok {
+		return api.ErrOrderBookStreamUnsupported
+	}
+	if e := c.inject("StreamOrderBook"); e != nil {
+		return e
+	}
+	return orderBookStream.StreamOrderBook(pair, maxCount, handler)
+}
+
+// GetOpenOrders impl, can drop some of the open orders for each pair
+func (c *chaosExchange) GetOpenOrders(pairs []*model.TradingPair) (map[model.TradingPair][]model.OpenOrder, error) {
+	if e := c.inject("GetOpenOrders"); e != nil {
+		return nil, e
+	}
+
+	openOrders, e := c.inner.GetOpenOrders(pairs)
+	if e != nil {
+		return nil, e
+	}
+	for pair, orders := range openOrders {
+		openOrders[pair] = orders[:c.truncatedLength("GetOpenOrders", len(orders))]
+	}
+	return openOrders, nil
+}
+
+// AddOrder impl
+func (c *chaosExchange) AddOrder(order *model.Order) (*model.TransactionID, error) {
+	if e := c.inject("AddOrder"); e != nil {
+		return nil, e
+	}
+	return c.inner.AddOrder(order)
+}
+
+// CancelOrder impl
+func (c *chaosExchange) CancelOrder(txID *model.TransactionID, pair model.TradingPair) (model.CancelOrderResult, error) {
+	if e := c.inject("CancelOrder"); e != nil {
+		return model.CancelResultFailed, e
+	}
+	return c.inner.CancelOrder(txID, pair)
+}
+
+// ModifyOrder impl
+func (c *chaosExchange) ModifyOrder(txID *model.TransactionID, order *model.Order) (*model.TransactionID, error) {
+	orderModifier, ok := c.inner.(api.OrderModifier)
+	if !ok {
+		return nil, api.ErrModifyOrderUnsupported
+	}
+	if e := c.inject("ModifyOrder"); e != nil {
+		return nil, e
+	}
+	return orderModifier.ModifyOrder(txID, order)
+}
+
+// GetServerTime impl
+func (c *chaosExchange) GetServerTime() (time.Time, error) {
+	serverClock, ok := c.inner.(api.ServerClock)
+	if !ok {
+		return time.Time{}, api.ErrServerTimeUnsupported
+	}
+	if e := c.inject("GetServerTime"); e != nil {
+		return time.Time{}, e
+	}
+	return serverClock.GetServerTime()
+}
+
+// ValidateOrder impl
+func (c *chaosExchange) ValidateOrder(order *model.Order) error {
+	orderValidator, ok := c.inner.(api.OrderValidator)
+	if !ok {
+		return api.ErrValidateOrderUnsupported
+	}
+	if e := c.inject("ValidateOrder"); e != nil {
+		return e
+	}
+	return orderValidator.ValidateOrder(order)
+}
+
+// GetTradesForOrder impl
+func (c *chaosExchange) GetTradesForOrder(txID *model.TransactionID, pair model.TradingPair) ([]model.Trade, error) {
+	if e := c.inject("GetTradesForOrder"); e != nil {
+		return nil, e
+	}
+	return c.inner.GetTradesForOrder(txID, pair)
+}
+
+// PrepareDeposit impl
+func (c *chaosExchange) PrepareDeposit(asset model.Asset, amount *model.Number) (*api.PrepareDepositResult, error) {
+	if e := c.inject("PrepareDeposit"); e != nil {
+		return nil, e
+	}
+	return c.inner.PrepareDeposit(asset, amount)
+}
+
+// GetWithdrawInfo impl
+func (c *chaosExchange) GetWithdrawInfo(asset model.Asset, amountToWithdraw *model.Number, address string) (*api.WithdrawInfo, error) {
+	if e := c.inject("GetWithdrawInfo"); e != nil {
+		return nil, e
+	}
+	return c.inner.GetWithdrawInfo(asset, amountToWithdraw, address)
+}
+
+// WithdrawFunds impl
+func (c *chaosExchange) WithdrawFunds(
+	asset model.Asset,
+	amountToWithdraw *model.Number,
+	address string,
+) (*api.WithdrawFunds, error) {
+	if e := c.inject("WithdrawFunds"); e != nil {
+		return nil, e
+	}
+	return c.inner.WithdrawFunds(asset, amountToWithdraw, address)
+}
@@ -0,0 +1,68 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractChaosConfig(t *testing.T) {
+	testCases := []struct {
+		params          []api.ExchangeParam
+		wantConfig      *chaosConfig
+		wantInnerParams []api.ExchangeParam
+		wantErr         bool
+	}{
+		{
+			params:          []api.ExchangeParam{},
+			wantConfig:      nil,
+			wantInnerParams: []api.ExchangeParam{},
+		}, {
+			params:          []api.ExchangeParam{{Param: "password", Value: "abc"}},
+			wantConfig:      nil,
+			wantInnerParams: []api.ExchangeParam{{Param: "password", Value: "abc"}},
+		}, {
+			params: []api.ExchangeParam{
+				{Param: "password", Value: "abc"},
+				{Param: "chaos_latency_millis", Value: "200"},
+				{Param: "chaos_latency_jitter_millis", Value: "50"},
+				{Param: "chaos_error_rate", Value: "0.25"},
+				{Param: "chaos_partial_data_rate", Value: "1"},
+			},
+			wantConfig: &chaosConfig{
+				latencyMillis:       200,
+				latencyJitterMillis: 50,
+				errorRate:           0.25,
+				partialDataRate:     1.0,
+			},
+			wantInnerParams: []api.ExchangeParam{{Param: "password", Value: "abc"}},
+		}, {
+			params:  []api.ExchangeParam{{Param: "chaos_error_rate", Value: "1.5"}},
+			wantErr: true,
+		}, {
+			params:  []api.ExchangeParam{{Param: "chaos_latency_millis", Value: "-1"}},
+			wantErr: true,
+		}, {
+			params:  []api.ExchangeParam{{Param: "chaos_unknown", Value: "1"}},
+			wantErr: true,
+		},
+	}
+
+	for i, kase := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			config, innerParams, e := extractChaosConfig(kase.params)
+			if kase.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			assert.Equal(t, kase.wantConfig, config)
+			assert.Equal(t, kase.wantInnerParams, innerParams)
+		})
+	}
+}
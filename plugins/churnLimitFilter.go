@@ -0,0 +1,187 @@
+package plugins
+
+import (
+	"log"
+
+	"github.com/stellar/go/build"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/xdr"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// churnLimitFilter migrates existing offers gradually over several cycles after the order constraints change so we never
+// end up with a momentarily empty book because every offer was deleted or recreated in the same cycle
+type churnLimitFilter struct {
+	tradingPair      *model.TradingPair
+	exchangeShim     api.ExchangeShim
+	maxChurnPerCycle int
+
+	// uninitialized
+	lastConstraints string
+	migrating       bool
+	numPruneOps     int // ops submitted to prune excess offers in the current update cycle, which count toward maxChurnPerCycle
+}
+
+var _ SubmitFilter = &churnLimitFilter{}
+var _ api.StateSnapshotter = &churnLimitFilter{}
+var _ pruneOpsCounter = &churnLimitFilter{}
+
+// MakeFilterChurnLimit makes a submit filter that limits the number of manage offer ops per cycle while migrating offers, returns nil when disabled
+func MakeFilterChurnLimit(maxChurnPerCycle uint32, exchangeShim api.ExchangeShim, tradingPair *model.TradingPair) SubmitFilter {
+	if maxChurnPerCycle == 0 {
+		return nil
+	}
+
+	return &churnLimitFilter{
+		tradingPair:      tradingPair,
+		exchangeShim:     exchangeShim,
+		maxChurnPerCycle: int(maxChurnPerCycle),
+	}
+}
+
+// Snapshot impl, Apply starts and finishes the migration of the offers
+func (f *churnLimitFilter) Snapshot() func() {
+	lastConstraints := f.lastConstraints
+	migrating := f.migrating
+	numPruneOps := f.numPruneOps
+	return func() {
+		f.lastConstraints = lastConstraints
+		f.migrating = migrating
+		f.numPruneOps = numPruneOps
+	}
+}
+
+// setNumPruneOps impl.
+func (f *churnLimitFilter) setNumPruneOps(numOps int) {
+	f.numPruneOps = numOps
+}
+
+// churnUnit is a group of manage offer ops that are kept or deferred together, which is a single op or a delete along with the create that
+// replaces it on the same side of the book so an offer is never deleted in one cycle and only replaced in a later cycle
+type churnUnit struct {
+	indices  []int
+	isModify bool
+}
+
+// Apply impl. While migrating, modifies are kept before the deletes and creates since they move an offer without taking it off the book,
+// and the ops that were submitted to prune excess offers in the same cycle count toward the maximum.
+func (f *churnLimitFilter) Apply(
+	ops []build.TransactionMutator,
+	sellingOffers []hProtocol.Offer,
+	buyingOffers []hProtocol.Offer,
+) ([]build.TransactionMutator, error) {
+	numPruneOps := f.numPruneOps
+	f.numPruneOps = 0
+
+	oc := f.exchangeShim.GetOrderConstraints(f.tradingPair).String()
+	if f.lastConstraints != "" && oc != f.lastConstraints {
+		log.Printf("churnLimitFilter: order constraints changed from %s to %s, migrating offers with a maximum of %d ops per cycle\n", f.lastConstraints, oc, f.maxChurnPerCycle)
+		f.migrating = true
+	}
+	f.lastConstraints = oc
+
+	if !f.migrating {
+		return ops, nil
+	}
+
+	budget := f.maxChurnPerCycle - numPruneOps
+	units := makeChurnUnits(ops)
+	keep := map[int]bool{}
+	numChurn := 0
+	// modifies are kept first, followed by the other units in the order of the strategy
+	for _, modifiesOnly := range []bool{true, false} {
+		for _, u := range units {
+			if u.isModify != modifiesOnly || numChurn+len(u.indices) > budget {
+				continue
+			}
+			for _, i := range u.indices {
+				keep[i] = true
+			}
+			numChurn += len(u.indices)
+		}
+	}
+
+	numDeferred := 0
+	filteredOps := []build.TransactionMutator{}
+	for i, op := range ops {
+		if _, _, isOfferOp := churnOpAction(op); isOfferOp && !keep[i] {
+			// the strategy will recompute this op in a later cycle
+			numDeferred++
+			continue
+		}
+		filteredOps = append(filteredOps, op)
+	}
+
+	if numDeferred == 0 {
+		log.Printf("churnLimitFilter: finished migrating offers, submitting the remaining %d ops (numPruneOps=%d)\n", numChurn, numPruneOps)
+		f.migrating = false
+	} else {
+		log.Printf("churnLimitFilter: migrating offers, kept %d ops and deferred %d ops to later cycles (numPruneOps=%d)\n", numChurn, numDeferred, numPruneOps)
+	}
+	return filteredOps, nil
+}
+
+// makeChurnUnits groups the manage offer ops into churnUnits in the order of the ops, pairing each delete with the next unpaired create on
+// the same side of the book and each create with the next unpaired delete
+func makeChurnUnits(ops []build.TransactionMutator) []*churnUnit {
+	units := []*churnUnit{}
+	unpairedDeletes := map[string][]*churnUnit{}
+	unpairedCreates := map[string][]*churnUnit{}
+	for i, op := range ops {
+		action, side, isOfferOp := churnOpAction(op)
+		if !isOfferOp {
+			continue
+		}
+
+		var pending map[string][]*churnUnit
+		var unpaired map[string][]*churnUnit
+		switch action {
+		case "modify":
+			units = append(units, &churnUnit{indices: []int{i}, isModify: true})
+			continue
+		case "delete":
+			pending, unpaired = unpairedCreates, unpairedDeletes
+		default:
+			pending, unpaired = unpairedDeletes, unpairedCreates
+		}
+
+		if len(pending[side]) > 0 {
+			pending[side][0].indices = append(pending[side][0].indices, i)
+			pending[side] = pending[side][1:]
+			continue
+		}
+		u := &churnUnit{indices: []int{i}}
+		units = append(units, u)
+		unpaired[side] = append(unpaired[side], u)
+	}
+	return units
+}
+
+// churnOpAction returns the action of a manage offer op (create, modify or delete) and the side of the book it is on, identified by the
+// assets that are sold and bought. It returns false for ops that do not manage offers.
+func churnOpAction(op build.TransactionMutator) (string, string, bool) {
+	var offerID xdr.Int64
+	var amount xdr.Int64
+	var selling xdr.Asset
+	var buying xdr.Asset
+	switch o := op.(type) {
+	case *build.ManageOfferBuilder:
+		offerID, amount, selling, buying = o.MO.OfferId, o.MO.Amount, o.MO.Selling, o.MO.Buying
+	case build.ManageOfferBuilder:
+		offerID, amount, selling, buying = o.MO.OfferId, o.MO.Amount, o.MO.Selling, o.MO.Buying
+	case *ManageBuyOfferBuilder:
+		offerID, amount, selling, buying = o.MBO.OfferId, o.MBO.BuyAmount, o.MBO.Selling, o.MBO.Buying
+	default:
+		return "", "", false
+	}
+
+	side := selling.String() + "/" + buying.String()
+	if offerID == 0 {
+		return "create", side, true
+	}
+	if amount == 0 {
+		return "delete", side, true
+	}
+	return "modify", side, true
+}
@@ -0,0 +1,127 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stellar/go/build"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// constraintsShim returns the order constraints that are set on it
+type constraintsShim struct {
+	api.ExchangeShim
+	constraints *model.OrderConstraints
+}
+
+func (s *constraintsShim) GetOrderConstraints(pair *model.TradingPair) *model.OrderConstraints {
+	return s.constraints
+}
+
+func TestChurnLimitFilterApply(t *testing.T) {
+	usd := build.CreditAsset("USD", testIssuer)
+	sellRate := build.Rate{Selling: build.NativeAsset(), Buying: usd, Price: build.Price("2")}
+	createSell := build.CreateOffer(sellRate, build.Amount("100"))
+	deleteSell := build.DeleteOffer(sellRate, build.OfferID(13))
+	modifySell := build.UpdateOffer(sellRate, build.Amount("50"), build.OfferID(14))
+	payment := build.Payment(build.Destination{AddressOrSeed: testIssuer}, build.NativeAmount{Amount: "1"})
+	ops := []build.TransactionMutator{
+		&createSell,
+		&deleteSell,
+		&modifySell,
+		&payment,
+		makeManageBuyOffer(usd, build.NativeAsset(), "0.5", "20", 0, ""),
+		makeManageBuyOffer(usd, build.NativeAsset(), "0.4", "20", 15, ""),
+		makeManageBuyOffer(usd, build.NativeAsset(), "0.3", "0", 16, ""),
+	}
+
+	testCases := []struct {
+		name          string
+		maxChurn      uint32
+		numPruneOps   int
+		wantIndices   []int
+		wantMigrating bool
+	}{
+		{
+			name:          "modifies first",
+			maxChurn:      2,
+			wantIndices:   []int{2, 3, 5},
+			wantMigrating: true,
+		}, {
+			name:          "delete is kept with its replacement",
+			maxChurn:      3,
+			wantIndices:   []int{2, 3, 5},
+			wantMigrating: true,
+		}, {
+			name:          "pairs after modifies",
+			maxChurn:      4,
+			wantIndices:   []int{0, 1, 2, 3, 5},
+			wantMigrating: true,
+		}, {
+			name:          "all ops",
+			maxChurn:      6,
+			wantIndices:   []int{0, 1, 2, 3, 4, 5, 6},
+			wantMigrating: false,
+		}, {
+			name:          "prune ops count toward the maximum",
+			maxChurn:      4,
+			numPruneOps:   2,
+			wantIndices:   []int{2, 3, 5},
+			wantMigrating: true,
+		}, {
+			name:          "prune ops exceed the maximum",
+			maxChurn:      2,
+			numPruneOps:   3,
+			wantIndices:   []int{3},
+			wantMigrating: true,
+		},
+	}
+
+	for _, kase := range testCases {
+		t.Run(kase.name, func(t *testing.T) {
+			shim := &constraintsShim{constraints: model.MakeOrderConstraints(7, 7, 1)}
+			f := MakeFilterChurnLimit(kase.maxChurn, shim, &model.TradingPair{Base: model.XLM, Quote: model.USD}).(*churnLimitFilter)
+
+			// ops are not limited until the order constraints change
+			filteredOps, e := f.Apply(ops, nil, nil)
+			if !assert.NoError(t, e) || !assert.Equal(t, ops, filteredOps) {
+				return
+			}
+
+			shim.constraints = model.MakeOrderConstraints(6, 7, 1)
+			f.setNumPruneOps(kase.numPruneOps)
+			filteredOps, e = f.Apply(ops, nil, nil)
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			wantOps := []build.TransactionMutator{}
+			for _, i := range kase.wantIndices {
+				wantOps = append(wantOps, ops[i])
+			}
+			assert.Equal(t, wantOps, filteredOps)
+			assert.Equal(t, kase.wantMigrating, f.migrating)
+			// the prune ops are only counted in the cycle they were submitted in
+			assert.Equal(t, 0, f.numPruneOps)
+		})
+	}
+}
+
+func TestMakeChurnUnits(t *testing.T) {
+	usd := build.CreditAsset("USD", testIssuer)
+	sellRate := build.Rate{Selling: build.NativeAsset(), Buying: usd, Price: build.Price("2")}
+	deleteSell1 := build.DeleteOffer(sellRate, build.OfferID(1))
+	deleteSell2 := build.DeleteOffer(sellRate, build.OfferID(2))
+	createSell := build.CreateOffer(sellRate, build.Amount("100"))
+	createBuy := makeManageBuyOffer(usd, build.NativeAsset(), "0.5", "20", 0, "")
+
+	units := makeChurnUnits([]build.TransactionMutator{&deleteSell1, &deleteSell2, createBuy, &createSell})
+	if !assert.Equal(t, 3, len(units)) {
+		return
+	}
+	// the create on the sell side replaces the first delete on the sell side, the create on the buy side has no delete to pair with
+	assert.Equal(t, []int{0, 3}, units[0].indices)
+	assert.Equal(t, []int{1}, units[1].indices)
+	assert.Equal(t, []int{2}, units[2].indices)
+}
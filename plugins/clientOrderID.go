@@ -0,0 +1,39 @@
+package plugins
+
+import (
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stellar/kelp/model"
+)
+
+// clientOrderIDSeparator separates the tag of a bot from the suffix that makes the client order IDs sent to ccxt exchanges unique
+const clientOrderIDSeparator = "-"
+
+// makeClientOrderIDTag returns the tag of the orders a bot places on the pair, which is a positive 32-bit integer so it can also be used as
+// the userref of kraken orders. The tag is derived from the account of the bot so it stays the same when the bot is restarted.
+func makeClientOrderIDTag(account string, pair *model.TradingPair) string {
+	h := fnv.New32a()
+	h.Write([]byte(account + "/" + pair.String()))
+	tag := h.Sum32() & 0x7fffffff
+	if tag == 0 {
+		// kraken treats a userref of 0 as not set
+		tag = 1
+	}
+	return strconv.FormatUint(uint64(tag), 10)
+}
+
+// uniqueClientOrderID appends a suffix to the tag that makes it unique among the orders placed with the tag
+func uniqueClientOrderID(tag string, now time.Time) string {
+	return tag + clientOrderIDSeparator + strconv.FormatInt(now.UnixNano(), 36)
+}
+
+// clientOrderIDTag returns the tag of a client order ID made by uniqueClientOrderID
+func clientOrderIDTag(clientOrderID string) string {
+	if i := strings.LastIndex(clientOrderID, clientOrderIDSeparator); i >= 0 {
+		return clientOrderID[:i]
+	}
+	return clientOrderID
+}
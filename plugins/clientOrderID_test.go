@@ -0,0 +1,35 @@
+package plugins
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeClientOrderIDTag(t *testing.T) {
+	pair := &model.TradingPair{Base: model.XLM, Quote: model.USD}
+	tag := makeClientOrderIDTag("GABC", pair)
+	assert.Equal(t, tag, makeClientOrderIDTag("GABC", pair))
+	assert.NotEqual(t, tag, makeClientOrderIDTag("GXYZ", pair))
+	assert.NotEqual(t, tag, makeClientOrderIDTag("GABC", &model.TradingPair{Base: model.XLM, Quote: model.BTC}))
+
+	// the tag needs to be usable as the userref of kraken orders
+	userref, e := strconv.ParseInt(tag, 10, 32)
+	if assert.NoError(t, e) {
+		assert.True(t, userref > 0)
+	}
+}
+
+func TestClientOrderIDTag(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	id := uniqueClientOrderID("12345", now)
+	assert.NotEqual(t, id, uniqueClientOrderID("12345", now.Add(time.Nanosecond)))
+	assert.Equal(t, "12345", clientOrderIDTag(id))
+
+	// IDs not made by uniqueClientOrderID are returned as they are
+	assert.Equal(t, "12345", clientOrderIDTag("12345"))
+	assert.Equal(t, "", clientOrderIDTag(""))
+}
@@ -23,6 +23,12 @@ type composeStrategy struct {
 // ensure it implements Strategy
 var _ api.Strategy = &composeStrategy{}
 
+// ensure it implements Annotatable
+var _ api.Annotatable = &composeStrategy{}
+
+// ensure it implements StateSnapshotter
+var _ api.StateSnapshotter = &composeStrategy{}
+
 // makeComposeStrategy is a factory method for composeStrategy
 func makeComposeStrategy(
 	assetBase *hProtocol.Asset,
@@ -38,6 +44,32 @@ func makeComposeStrategy(
 	}
 }
 
+// SetAnnotator impl, passes the annotator to the sub-strategies that emit annotations
+func (s *composeStrategy) SetAnnotator(annotator api.Annotator) {
+	if a, ok := s.buyStrat.(api.Annotatable); ok {
+		a.SetAnnotator(annotator)
+	}
+	if a, ok := s.sellStrat.(api.Annotatable); ok {
+		a.SetAnnotator(annotator)
+	}
+}
+
+// Snapshot impl, snapshots the sub-strategies that change their state when they compute ops
+func (s *composeStrategy) Snapshot() func() {
+	restores := []func(){}
+	if ss, ok := s.buyStrat.(api.StateSnapshotter); ok {
+		restores = append(restores, ss.Snapshot())
+	}
+	if ss, ok := s.sellStrat.(api.StateSnapshotter); ok {
+		restores = append(restores, ss.Snapshot())
+	}
+	return func() {
+		for _, restore := range restores {
+			restore()
+		}
+	}
+}
+
 // PruneExistingOffers impl
 func (s *composeStrategy) PruneExistingOffers(buyingAOffers []hProtocol.Offer, sellingAOffers []hProtocol.Offer) ([]build.TransactionMutator, []hProtocol.Offer, []hProtocol.Offer) {
 	pruneOps1, newBuyingAOffers := s.buyStrat.PruneExistingOffers(buyingAOffers)
@@ -0,0 +1,121 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/stellar/kelp/api"
+)
+
+// crossRateLegSeparator separates the two legs in the URL of a cross rate feed
+const crossRateLegSeparator = "|"
+
+// crossRateInvertPrefix marks a leg whose price is inverted before it is used
+const crossRateInvertPrefix = "invert:"
+
+// crossRateLeg is one of the two feeds composed by a crossRatePriceFeed
+type crossRateLeg struct {
+	feedType string
+	url      string
+	feed     api.PriceFeed
+	invert   bool
+}
+
+// String is the stringer function
+func (l crossRateLeg) String() string {
+	if l.invert {
+		return fmt.Sprintf("1/(%s:%s)", l.feedType, l.url)
+	}
+	return fmt.Sprintf("(%s:%s)", l.feedType, l.url)
+}
+
+// crossRatePriceFeed composes two feeds through a third reference currency by multiplying their prices, inverting either leg when needed.
+// For example XLM/BTC can be priced from XLM/USD and BTC/USD with the URL "exchange:kraken/XXLM/ZUSD|invert:exchange:kraken/XXBT/ZUSD"
+// which computes XLM/USD * 1/(BTC/USD).
+type crossRatePriceFeed struct {
+	legA crossRateLeg
+	legB crossRateLeg
+}
+
+// ensure that it implements PriceFeed
+var _ api.PriceFeed = &crossRatePriceFeed{}
+
+// makeCrossRatePriceFeed is a factory method, the URL is "[invert:]<typeA>:<urlA>|[invert:]<typeB>:<urlB>"
+func makeCrossRatePriceFeed(url string) (*crossRatePriceFeed, error) {
+	legURLs := strings.Split(url, crossRateLegSeparator)
+	if len(legURLs) != 2 {
+		return nil, fmt.Errorf("invalid format of cross type URL, needs exactly 2 legs after splitting URL by '%s', has %d: %s", crossRateLegSeparator, len(legURLs), url)
+	}
+
+	legA, e := makeCrossRateLeg(legURLs[0])
+	if e != nil {
+		return nil, fmt.Errorf("unable to make the first leg of the cross rate feed: %s", e)
+	}
+	legB, e := makeCrossRateLeg(legURLs[1])
+	if e != nil {
+		return nil, fmt.Errorf("unable to make the second leg of the cross rate feed: %s", e)
+	}
+
+	return &crossRatePriceFeed{
+		legA: *legA,
+		legB: *legB,
+	}, nil
+}
+
+func makeCrossRateLeg(legURL string) (*crossRateLeg, error) {
+	invert := strings.HasPrefix(legURL, crossRateInvertPrefix)
+	legURL = strings.TrimPrefix(legURL, crossRateInvertPrefix)
+
+	// [0] = feedType, [1] = url of the feed which can itself contain ':'
+	parts := strings.SplitN(legURL, ":", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return nil, fmt.Errorf("invalid format of leg, needs to be '[%s]<type>:<url>': %s", crossRateInvertPrefix, legURL)
+	}
+	if parts[0] == "cross" {
+		return nil, fmt.Errorf("the leg of a cross rate feed cannot itself be a cross rate feed: %s", legURL)
+	}
+
+	feed, e := MakePriceFeed(parts[0], parts[1])
+	if e != nil {
+		return nil, fmt.Errorf("unable to make price feed for leg '%s': %s", legURL, e)
+	}
+	return &crossRateLeg{
+		feedType: parts[0],
+		url:      parts[1],
+		feed:     feed,
+		invert:   invert,
+	}, nil
+}
+
+// price fetches the price of the leg, inverting it if needed
+func (l crossRateLeg) price() (float64, error) {
+	p, e := l.feed.GetPrice()
+	if e != nil {
+		return 0, fmt.Errorf("error fetching price of leg %s: %s", l, e)
+	}
+	if p <= 0 {
+		return 0, fmt.Errorf("price of leg %s needs to be positive, was %f", l, p)
+	}
+
+	if l.invert {
+		return 1 / p, nil
+	}
+	return p, nil
+}
+
+// GetPrice impl
+func (f *crossRatePriceFeed) GetPrice() (float64, error) {
+	pA, e := f.legA.price()
+	if e != nil {
+		return 0, e
+	}
+	pB, e := f.legB.price()
+	if e != nil {
+		return 0, e
+	}
+
+	crossRate := pA * pB
+	log.Printf("price from cross rate feed: %s=%.7f, %s=%.7f, crossRate=%.7f\n", f.legA, pA, f.legB, pB, crossRate)
+	return crossRate, nil
+}
@@ -0,0 +1,54 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCrossRatePriceFeed(t *testing.T) {
+	testCases := []struct {
+		url      string
+		wantRate float64
+	}{
+		{
+			url:      "fixed:0.10|fixed:2.0",
+			wantRate: 0.2,
+		}, {
+			// XLM/BTC = XLM/USD * 1/(BTC/USD)
+			url:      "fixed:0.10|invert:fixed:10000",
+			wantRate: 0.00001,
+		}, {
+			url:      "invert:fixed:4|invert:fixed:0.5",
+			wantRate: 0.5,
+		},
+	}
+
+	for _, kase := range testCases {
+		t.Run(kase.url, func(t *testing.T) {
+			f, e := makeCrossRatePriceFeed(kase.url)
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			rate, e := f.GetPrice()
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.InDelta(t, kase.wantRate, rate, 1e-12)
+		})
+	}
+}
+
+func TestMakeCrossRatePriceFeedInvalid(t *testing.T) {
+	for _, url := range []string{
+		"fixed:1.0",
+		"fixed:1.0|fixed:1.0|fixed:1.0",
+		"fixed|fixed:1.0",
+		"fixed:1.0|cross:fixed:1.0",
+		"fixed:1.0|unknown:1.0",
+	} {
+		_, e := makeCrossRatePriceFeed(url)
+		assert.Error(t, e, url)
+	}
+}
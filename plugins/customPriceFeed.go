@@ -0,0 +1,188 @@
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/stellar/kelp/api"
+)
+
+// CustomPriceFeed is a named price feed defined by the user, bots use it with the "custom" feed type and its name as the URL
+type CustomPriceFeed struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`                // "json" or any other feed type except "custom"
+	URL      string `json:"url"`                 // URL of the feed type, for the "json" type this is the URL of the HTTP API
+	JSONPath string `json:"json_path,omitempty"` // path to the price in the response of a "json" feed, such as "$.data[0].last"
+	// headers and auth of the requests of a "json" feed, the values can reference environment variables as ${NAME}
+	Headers map[string]string `json:"headers,omitempty"`
+	Auth    string            `json:"auth,omitempty"` // "bearer:<token>" or "basic:<user>:<password>"
+}
+
+// customPriceFeedNameRegex limits names to characters that are safe in config files and metric names
+var customPriceFeedNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// customPriceFeeds are the registered custom price feeds of this process keyed by name
+var customPriceFeeds = map[string]CustomPriceFeed{}
+var customPriceFeedsMutex = &sync.Mutex{}
+
+// feedURL returns the URL passed to the feed type of the custom feed
+func (f CustomPriceFeed) feedURL() string {
+	if f.Type != "json" {
+		return f.URL
+	}
+
+	url := f.URL + jsonPathSeparator + f.JSONPath
+	names := []string{}
+	for name := range f.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		url += fmt.Sprintf("%s%s: %s", jsonPathSeparator, name, f.Headers[name])
+	}
+	if f.Auth != "" {
+		url += jsonPathSeparator + f.Auth
+	}
+	return url
+}
+
+// MakeCustomPriceFeed makes the price feed of a definition, which also validates the definition
+func MakeCustomPriceFeed(f CustomPriceFeed) (api.PriceFeed, error) {
+	if !customPriceFeedNameRegex.MatchString(f.Name) {
+		return nil, fmt.Errorf("invalid name '%s' of custom price feed, can only use letters, digits, '_' and '-'", f.Name)
+	}
+	if f.Type == "custom" {
+		return nil, fmt.Errorf("custom price feed '%s' cannot have the type 'custom'", f.Name)
+	}
+	if f.Type == "cross" && strings.Contains(f.URL, "custom:") {
+		// a custom feed could otherwise reference itself through its legs
+		return nil, fmt.Errorf("custom price feed '%s' cannot use custom price feeds as the legs of a cross rate", f.Name)
+	}
+	if f.Type != "json" && (f.JSONPath != "" || len(f.Headers) > 0 || f.Auth != "") {
+		return nil, fmt.Errorf("custom price feed '%s' can only have a JSON path, headers or auth with the type 'json'", f.Name)
+	}
+	if f.Type == "json" && (strings.Contains(f.JSONPath, jsonPathSeparator) || strings.Contains(f.Auth, jsonPathSeparator)) {
+		return nil, fmt.Errorf("the JSON path and auth of custom price feed '%s' cannot contain '%s'", f.Name, jsonPathSeparator)
+	}
+	for name, value := range f.Headers {
+		if strings.Contains(name, jsonPathSeparator) || strings.Contains(value, jsonPathSeparator) {
+			return nil, fmt.Errorf("the headers of custom price feed '%s' cannot contain '%s'", f.Name, jsonPathSeparator)
+		}
+	}
+
+	feed, e := makePriceFeed(f.Type, f.feedURL())
+	if e != nil {
+		return nil, fmt.Errorf("invalid custom price feed '%s': %s", f.Name, e)
+	}
+	return feed, nil
+}
+
+// makeCustomPriceFeed resolves the registered custom price feed with the name
+func makeCustomPriceFeed(name string) (api.PriceFeed, error) {
+	customPriceFeedsMutex.Lock()
+	f, ok := customPriceFeeds[name]
+	customPriceFeedsMutex.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("no custom price feed is registered with the name '%s'", name)
+	}
+	return MakeCustomPriceFeed(f)
+}
+
+// SetCustomPriceFeeds replaces the registered custom price feeds after validating them
+func SetCustomPriceFeeds(feeds []CustomPriceFeed) error {
+	m := map[string]CustomPriceFeed{}
+	for _, f := range feeds {
+		if _, ok := m[f.Name]; ok {
+			return fmt.Errorf("custom price feed '%s' is defined more than once", f.Name)
+		}
+		_, e := MakeCustomPriceFeed(f)
+		if e != nil {
+			return e
+		}
+		m[f.Name] = f
+	}
+
+	customPriceFeedsMutex.Lock()
+	defer customPriceFeedsMutex.Unlock()
+	customPriceFeeds = m
+	return nil
+}
+
+// GetCustomPriceFeeds returns the registered custom price feeds sorted by name
+func GetCustomPriceFeeds() []CustomPriceFeed {
+	customPriceFeedsMutex.Lock()
+	defer customPriceFeedsMutex.Unlock()
+
+	feeds := []CustomPriceFeed{}
+	for _, f := range customPriceFeeds {
+		feeds = append(feeds, f)
+	}
+	sort.Slice(feeds, func(i int, j int) bool {
+		return feeds[i].Name < feeds[j].Name
+	})
+	return feeds
+}
+
+// CustomPriceFeedsVersion is the format version of the custom price feeds file written by this version of Kelp
+const CustomPriceFeedsVersion = 1
+
+// customPriceFeedsFile is the persisted form of the custom price feeds
+type customPriceFeedsFile struct {
+	Version int               `json:"version"`
+	Feeds   []CustomPriceFeed `json:"feeds"`
+}
+
+// LoadCustomPriceFeeds registers the custom price feeds saved in the file, a missing file has no custom price feeds
+func LoadCustomPriceFeeds(filePath string) error {
+	b, e := ioutil.ReadFile(filePath)
+	if os.IsNotExist(e) {
+		return SetCustomPriceFeeds([]CustomPriceFeed{})
+	}
+	if e != nil {
+		return fmt.Errorf("unable to read custom price feeds file '%s': %s", filePath, e)
+	}
+
+	var f customPriceFeedsFile
+	e = json.Unmarshal(b, &f)
+	if e != nil {
+		return fmt.Errorf("unable to unmarshal custom price feeds file '%s': %s", filePath, e)
+	}
+	if f.Version > CustomPriceFeedsVersion {
+		return fmt.Errorf("custom price feeds file '%s' was written by a newer version of Kelp (version=%d, max supported version=%d), upgrade Kelp or move the file", filePath, f.Version, CustomPriceFeedsVersion)
+	}
+	e = SetCustomPriceFeeds(f.Feeds)
+	if e != nil {
+		return fmt.Errorf("invalid custom price feeds file '%s': %s", filePath, e)
+	}
+	log.Printf("loaded %d custom price feeds from '%s'\n", len(f.Feeds), filePath)
+	return nil
+}
+
+// SaveCustomPriceFeeds writes the registered custom price feeds to the file so they can be loaded with LoadCustomPriceFeeds, the file is
+// written to a temporary file first and then moved into place so bots starting at the same time never read a partial file
+func SaveCustomPriceFeeds(filePath string) error {
+	b, e := json.MarshalIndent(customPriceFeedsFile{
+		Version: CustomPriceFeedsVersion,
+		Feeds:   GetCustomPriceFeeds(),
+	}, "", "  ")
+	if e != nil {
+		return fmt.Errorf("unable to marshal custom price feeds: %s", e)
+	}
+	tmpFilePath := filePath + ".tmp"
+	e = ioutil.WriteFile(tmpFilePath, b, 0644)
+	if e != nil {
+		return fmt.Errorf("unable to write custom price feeds file '%s': %s", tmpFilePath, e)
+	}
+	e = os.Rename(tmpFilePath, filePath)
+	if e != nil {
+		return fmt.Errorf("unable to move custom price feeds file '%s' to '%s': %s", tmpFilePath, filePath, e)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package plugins
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomPriceFeeds(t *testing.T) {
+	defer SetCustomPriceFeeds([]CustomPriceFeed{})
+
+	e := SetCustomPriceFeeds([]CustomPriceFeed{
+		{Name: "peg", Type: "fixed", URL: "1.25"},
+		{Name: "ticker", Type: "json", URL: "https://api.example.com/ticker", JSONPath: "data.last"},
+	})
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, []string{"peg", "ticker"}, []string{GetCustomPriceFeeds()[0].Name, GetCustomPriceFeeds()[1].Name})
+
+	feed, e := MakePriceFeed("custom", "peg")
+	if assert.NoError(t, e) {
+		price, e := feed.GetPrice()
+		assert.NoError(t, e)
+		assert.Equal(t, 1.25, price)
+	}
+	_, e = MakePriceFeed("custom", "missing")
+	assert.Error(t, e)
+
+	invalid := []CustomPriceFeed{
+		{Name: "bad name", Type: "fixed", URL: "1.0"},
+		{Name: "self", Type: "custom", URL: "self"},
+		{Name: "loop", Type: "cross", URL: "custom:loop|fixed:1.0"},
+		{Name: "path", Type: "fixed", URL: "1.0", JSONPath: "price"},
+		{Name: "nopath", Type: "json", URL: "https://api.example.com/ticker"},
+		{Name: "headers", Type: "fixed", URL: "1.0", Headers: map[string]string{"X-Key": "1"}},
+		{Name: "separator", Type: "json", URL: "https://api.example.com/ticker", JSONPath: "price", Auth: "bearer:a#b"},
+	}
+	for _, f := range invalid {
+		_, e = MakeCustomPriceFeed(f)
+		assert.Error(t, e, f.Name)
+	}
+
+	f := CustomPriceFeed{
+		Name:     "auth",
+		Type:     "json",
+		URL:      "https://api.example.com/ticker",
+		JSONPath: "$.price",
+		Headers:  map[string]string{"X-B": "2", "X-A": "${KEY}"},
+		Auth:     "bearer:${TOKEN}",
+	}
+	assert.Equal(t, "https://api.example.com/ticker#$.price#X-A: ${KEY}#X-B: 2#bearer:${TOKEN}", f.feedURL())
+
+	assert.Error(t, SetCustomPriceFeeds([]CustomPriceFeed{{Name: "a", Type: "fixed", URL: "1"}, {Name: "a", Type: "fixed", URL: "2"}}))
+	// a failed update keeps the registered feeds
+	assert.Len(t, GetCustomPriceFeeds(), 2)
+}
+
+func TestSaveLoadCustomPriceFeeds(t *testing.T) {
+	defer SetCustomPriceFeeds([]CustomPriceFeed{})
+	dir, e := ioutil.TempDir("", "customPriceFeeds")
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	filePath := filepath.Join(dir, "custom_price_feeds.json")
+
+	if !assert.NoError(t, SetCustomPriceFeeds([]CustomPriceFeed{{Name: "peg", Type: "fixed", URL: "1.25"}})) {
+		return
+	}
+	if !assert.NoError(t, SaveCustomPriceFeeds(filePath)) {
+		return
+	}
+	assert.NoError(t, SetCustomPriceFeeds([]CustomPriceFeed{}))
+	if assert.NoError(t, LoadCustomPriceFeeds(filePath)) {
+		assert.Equal(t, []CustomPriceFeed{{Name: "peg", Type: "fixed", URL: "1.25"}}, GetCustomPriceFeeds())
+	}
+
+	// files written by a newer version of Kelp are rejected
+	if !assert.NoError(t, ioutil.WriteFile(filePath, []byte(`{"version": 2, "feeds": []}`), 0644)) {
+		return
+	}
+	assert.Error(t, LoadCustomPriceFeeds(filePath))
+	assert.Len(t, GetCustomPriceFeeds(), 1)
+}
@@ -0,0 +1,185 @@
+package plugins
+
+import (
+	"log"
+	"sync"
+
+	"github.com/stellar/go/build"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// accountBalancesLoader is implemented by exchange shims that can load the balances of all assets of the account with a single request
+type accountBalancesLoader interface {
+	loadAccountBalances() (func(asset hProtocol.Asset) (*api.Balance, error), error)
+}
+
+// CycleContextStats counts the loads of the account state in the current update cycle
+type CycleContextStats struct {
+	BalanceLoads int `json:"balance_loads"` // requests made to load balances
+	OfferLoads   int `json:"offer_loads"`   // requests made to load offers
+	Reused       int `json:"reused"`        // balances and offers that were served without a request
+}
+
+// CycleContext is the exchange shim shared by the trader, the strategy and the submit filters. During an update cycle the balances and
+// offers of the account are loaded once and shared, instead of each component loading them from Horizon or the exchange, which shortens
+// the update cycle and leaves headroom under the rate limits. The loaded state is dropped after ops are submitted since they change the
+// balances and offers. Outside of an update cycle every call is passed through to the inner exchange shim.
+type CycleContext struct {
+	api.ExchangeShim
+
+	// uninitialized
+	mutex         *sync.Mutex
+	active        bool
+	lookupBalance func(asset hProtocol.Asset) (*api.Balance, error) // set once the balances of all assets are loaded
+	balances      map[hProtocol.Asset]api.Balance                   // used when the inner shim loads balances one asset at a time
+	offers        []hProtocol.Offer
+	offersLoaded  bool
+	stats         CycleContextStats
+}
+
+// ensure it implements ExchangeShim and passes through the streams of the inner exchange shim
+var _ api.ExchangeShim = &CycleContext{}
+var _ api.TradeStream = &CycleContext{}
+var _ api.OrderBookStream = &CycleContext{}
+
+// MakeCycleContext is a factory method
+func MakeCycleContext(inner api.ExchangeShim) *CycleContext {
+	return &CycleContext{
+		ExchangeShim: inner,
+		mutex:        &sync.Mutex{},
+		balances:     map[hProtocol.Asset]api.Balance{},
+	}
+}
+
+// BeginCycle starts sharing the state of the account until EndCycle is called, the state is loaded when it is first needed
+func (c *CycleContext) BeginCycle() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.active = true
+	c.stats = CycleContextStats{}
+	c.reset()
+}
+
+// EndCycle stops sharing the state of the account
+func (c *CycleContext) EndCycle() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.active {
+		log.Printf("cycle context: loaded balances %d times and offers %d times, reused them %d times\n", c.stats.BalanceLoads, c.stats.OfferLoads, c.stats.Reused)
+	}
+	c.active = false
+	c.reset()
+}
+
+// Stats returns the loads of the account state in the current update cycle
+func (c *CycleContext) Stats() CycleContextStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.stats
+}
+
+// reset drops the loaded state. Needs to be called while holding the mutex.
+func (c *CycleContext) reset() {
+	c.lookupBalance = nil
+	c.balances = map[hProtocol.Asset]api.Balance{}
+	c.offers = nil
+	c.offersLoaded = false
+}
+
+// GetBalanceHack impl
+func (c *CycleContext) GetBalanceHack(asset hProtocol.Asset) (*api.Balance, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if !c.active {
+		return c.ExchangeShim.GetBalanceHack(asset)
+	}
+
+	if loader, ok := c.ExchangeShim.(accountBalancesLoader); ok {
+		if c.lookupBalance != nil {
+			c.stats.Reused++
+			return c.lookupBalance(asset)
+		}
+
+		lookup, e := loader.loadAccountBalances()
+		c.stats.BalanceLoads++
+		if e != nil {
+			return nil, e
+		}
+		c.lookupBalance = lookup
+		return lookup(asset)
+	}
+
+	if b, ok := c.balances[asset]; ok {
+		c.stats.Reused++
+		return &b, nil
+	}
+	b, e := c.ExchangeShim.GetBalanceHack(asset)
+	c.stats.BalanceLoads++
+	if e != nil {
+		return nil, e
+	}
+	c.balances[asset] = *b
+	return b, nil
+}
+
+// LoadOffersHack impl
+func (c *CycleContext) LoadOffersHack() ([]hProtocol.Offer, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if !c.active {
+		return c.ExchangeShim.LoadOffersHack()
+	}
+
+	if !c.offersLoaded {
+		offers, e := c.ExchangeShim.LoadOffersHack()
+		c.stats.OfferLoads++
+		if e != nil {
+			return nil, e
+		}
+		c.offers = offers
+		c.offersLoaded = true
+	} else {
+		c.stats.Reused++
+	}
+	// callers can sort and filter the offers so each of them gets its own copy
+	return append([]hProtocol.Offer{}, c.offers...), nil
+}
+
+// SubmitOps impl
+func (c *CycleContext) SubmitOps(ops []build.TransactionMutator, asyncCallback func(hash string, e error)) error {
+	defer c.dropState()
+	return c.ExchangeShim.SubmitOps(ops, asyncCallback)
+}
+
+// SubmitOpsSynch impl
+func (c *CycleContext) SubmitOpsSynch(ops []build.TransactionMutator, asyncCallback func(hash string, e error)) error {
+	defer c.dropState()
+	return c.ExchangeShim.SubmitOpsSynch(ops, asyncCallback)
+}
+
+// StreamTrades impl
+func (c *CycleContext) StreamTrades(pair *model.TradingPair, handler func(trade model.Trade)) error {
+	if tradeStream, ok := c.ExchangeShim.(api.TradeStream); ok {
+		return tradeStream.StreamTrades(pair, handler)
+	}
+	return api.ErrTradeStreamUnsupported
+}
+
+// StreamOrderBook impl
+func (c *CycleContext) StreamOrderBook(pair *model.TradingPair, maxCount int32, handler func(ob *model.OrderBook)) error {
+	if orderBookStream, ok := c.ExchangeShim.(api.OrderBookStream); ok {
+		return orderBookStream.StreamOrderBook(pair, maxCount, handler)
+	}
+	return api.ErrOrderBookStreamUnsupported
+}
+
+// dropState drops the loaded state after submitting ops so it is loaded again when it is next needed
+func (c *CycleContext) dropState() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.reset()
+}
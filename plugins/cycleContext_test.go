@@ -0,0 +1,105 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stellar/go/build"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingShim counts the balances and offers loaded from it
+type countingShim struct {
+	api.ExchangeShim
+	balanceLoads int
+	offerLoads   int
+}
+
+func (s *countingShim) GetBalanceHack(asset hProtocol.Asset) (*api.Balance, error) {
+	s.balanceLoads++
+	return &api.Balance{Balance: 100}, nil
+}
+
+func (s *countingShim) LoadOffersHack() ([]hProtocol.Offer, error) {
+	s.offerLoads++
+	return []hProtocol.Offer{{ID: 1}, {ID: 2}}, nil
+}
+
+func (s *countingShim) SubmitOps(ops []build.TransactionMutator, asyncCallback func(hash string, e error)) error {
+	return nil
+}
+
+// countingBalancesShim loads the balances of all assets with a single request
+type countingBalancesShim struct {
+	countingShim
+}
+
+func (s *countingBalancesShim) loadAccountBalances() (func(asset hProtocol.Asset) (*api.Balance, error), error) {
+	s.balanceLoads++
+	return func(asset hProtocol.Asset) (*api.Balance, error) {
+		return &api.Balance{Balance: 50}, nil
+	}, nil
+}
+
+func TestCycleContext(t *testing.T) {
+	xlm := utils.NativeAsset
+	usd := hProtocol.Asset{Type: "credit_alphanum4", Code: "USD", Issuer: "GBMMZMK2DC4FFP4CAI6KCVNCQ7WLO5A7DQU7EC7WGHRDQBZB763X4OQI"}
+	inner := &countingShim{}
+	c := MakeCycleContext(inner)
+
+	// calls are passed through outside of an update cycle
+	c.GetBalanceHack(xlm)
+	c.GetBalanceHack(xlm)
+	c.LoadOffersHack()
+	assert.Equal(t, 2, inner.balanceLoads)
+	assert.Equal(t, 1, inner.offerLoads)
+
+	c.BeginCycle()
+	for i := 0; i < 3; i++ {
+		b, e := c.GetBalanceHack(xlm)
+		if assert.NoError(t, e) {
+			assert.Equal(t, 100.0, b.Balance)
+		}
+		c.GetBalanceHack(usd)
+		offers, e := c.LoadOffersHack()
+		if assert.NoError(t, e) {
+			assert.Len(t, offers, 2)
+			// callers get their own copy of the offers
+			offers[0].ID = 5
+		}
+	}
+	assert.Equal(t, 4, inner.balanceLoads)
+	assert.Equal(t, 2, inner.offerLoads)
+	assert.Equal(t, CycleContextStats{BalanceLoads: 2, OfferLoads: 1, Reused: 6}, c.Stats())
+	offers, _ := c.LoadOffersHack()
+	assert.Equal(t, int64(1), offers[0].ID)
+
+	// submitting ops drops the loaded state
+	assert.NoError(t, c.SubmitOps(nil, nil))
+	c.GetBalanceHack(xlm)
+	c.LoadOffersHack()
+	assert.Equal(t, 5, inner.balanceLoads)
+	assert.Equal(t, 3, inner.offerLoads)
+
+	c.EndCycle()
+	c.LoadOffersHack()
+	assert.Equal(t, 4, inner.offerLoads)
+}
+
+func TestCycleContextLoadsAllBalances(t *testing.T) {
+	usd := hProtocol.Asset{Type: "credit_alphanum4", Code: "USD", Issuer: "GBMMZMK2DC4FFP4CAI6KCVNCQ7WLO5A7DQU7EC7WGHRDQBZB763X4OQI"}
+	inner := &countingBalancesShim{}
+	c := MakeCycleContext(inner)
+
+	c.BeginCycle()
+	defer c.EndCycle()
+	b, e := c.GetBalanceHack(utils.NativeAsset)
+	if assert.NoError(t, e) {
+		assert.Equal(t, 50.0, b.Balance)
+	}
+	c.GetBalanceHack(usd)
+	c.GetBalanceHack(utils.NativeAsset)
+	assert.Equal(t, 1, inner.balanceLoads)
+}
@@ -3,43 +3,197 @@ package plugins
 import (
 	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/stellar/kelp/api"
 	"github.com/stellar/kelp/model"
 )
 
+// exchangePricePoint selects which price of the exchange is used as the price of the feed
+type exchangePricePoint string
+
+// exchangePricePoint values
+const (
+	exchangePricePointBid    exchangePricePoint = "bid"
+	exchangePricePointAsk    exchangePricePoint = "ask"
+	exchangePricePointMid    exchangePricePoint = "mid"
+	exchangePricePointLast   exchangePricePoint = "last"
+	exchangePricePointVwap1h exchangePricePoint = "vwap_1h"
+)
+
+// exchangeFeedVwapWindow is the window of trades used by the vwap_1h price point
+const exchangeFeedVwapWindow = time.Hour
+
 // encapsulates a priceFeed from a tickerAPI
 type exchangeFeed struct {
-	name      string
-	tickerAPI *api.TickerAPI
-	pairs     []model.TradingPair
+	name       string
+	tickerAPI  *api.TickerAPI
+	tradeAPI   api.TradeAPI // used by the price points that are computed from the public trades on the exchange
+	pairs      []model.TradingPair
+	pricePoint exchangePricePoint
+}
+
+// ensure that it implements PriceQuoteFeed
+var _ api.PriceQuoteFeed = &exchangeFeed{}
+
+// makeExchangeFeed creates a price feed from the URL "<exchangeType>/<base>/<quote>[/<pricePoint>]", the price point is one of bid, ask,
+// mid (default), last or vwap_1h
+func makeExchangeFeed(url string) (*exchangeFeed, error) {
+	// [0] = exchangeType, [1] = base, [2] = quote, [3] = pricePoint (optional)
+	urlParts := strings.Split(url, "/")
+	if len(urlParts) != 3 && len(urlParts) != 4 {
+		return nil, fmt.Errorf("invalid format of exchange type URL, needs 3 or 4 parts after splitting URL by '/', has %d: %s", len(urlParts), url)
+	}
+	pricePoint := exchangePricePointMid
+	if len(urlParts) == 4 {
+		var e error
+		pricePoint, e = parseExchangePricePoint(urlParts[3])
+		if e != nil {
+			return nil, e
+		}
+	}
+
+	exchange, e := MakeExchange(urlParts[0], true)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make priceFeed because of an error when making the '%s' exchange: %s", urlParts[0], e)
+	}
+	baseAsset, e := exchange.GetAssetConverter().FromString(urlParts[1])
+	if e != nil {
+		return nil, fmt.Errorf("cannot make priceFeed because of an error when converting the base asset: %s", e)
+	}
+	quoteAsset, e := exchange.GetAssetConverter().FromString(urlParts[2])
+	if e != nil {
+		return nil, fmt.Errorf("cannot make priceFeed because of an error when converting the quote asset: %s", e)
+	}
+	tradingPair := model.TradingPair{
+		Base:  baseAsset,
+		Quote: quoteAsset,
+	}
+	tickerAPI := api.TickerAPI(exchange)
+	return newExchangeFeed(url, &tickerAPI, exchange, &tradingPair, pricePoint), nil
 }
 
-// ensure that it implements PriceFeed
-var _ api.PriceFeed = &exchangeFeed{}
+func parseExchangePricePoint(s string) (exchangePricePoint, error) {
+	switch exchangePricePoint(s) {
+	case exchangePricePointBid, exchangePricePointAsk, exchangePricePointMid, exchangePricePointLast, exchangePricePointVwap1h:
+		return exchangePricePoint(s), nil
+	}
+	return "", fmt.Errorf("invalid price point '%s' in exchange type URL, needs to be one of '%s', '%s', '%s', '%s' or '%s'", s,
+		exchangePricePointBid, exchangePricePointAsk, exchangePricePointMid, exchangePricePointLast, exchangePricePointVwap1h)
+}
 
-func newExchangeFeed(name string, tickerAPI *api.TickerAPI, pair *model.TradingPair) *exchangeFeed {
+func newExchangeFeed(name string, tickerAPI *api.TickerAPI, tradeAPI api.TradeAPI, pair *model.TradingPair, pricePoint exchangePricePoint) *exchangeFeed {
 	return &exchangeFeed{
-		name:      name,
-		tickerAPI: tickerAPI,
-		pairs:     []model.TradingPair{*pair},
+		name:       name,
+		tickerAPI:  tickerAPI,
+		tradeAPI:   tradeAPI,
+		pairs:      []model.TradingPair{*pair},
+		pricePoint: pricePoint,
 	}
 }
 
 // GetPrice impl
 func (f *exchangeFeed) GetPrice() (float64, error) {
+	q, e := f.GetPriceQuote()
+	if e != nil {
+		return 0, e
+	}
+	return q.Mid, nil
+}
+
+// GetPriceQuote impl, Mid is the selected price point. The ticker does not include the time of the last trade so it is only set for the
+// last price point.
+func (f *exchangeFeed) GetPriceQuote() (*api.PriceQuote, error) {
 	tickerAPI := *f.tickerAPI
 	m, e := tickerAPI.GetTickerPrice(f.pairs)
 	if e != nil {
-		return 0, fmt.Errorf("error while getting price from exchange feed: %s", e)
+		return nil, fmt.Errorf("error while getting price from exchange feed: %s", e)
 	}
 
 	p, ok := m[f.pairs[0]]
 	if !ok {
-		return 0, fmt.Errorf("could not get price for trading pair: %s", f.pairs[0].String())
+		return nil, fmt.Errorf("could not get price for trading pair: %s", f.pairs[0].String())
+	}
+
+	bidPrice := p.BidPrice.AsFloat()
+	askPrice := p.AskPrice.AsFloat()
+	centerPrice := (bidPrice + askPrice) / 2
+	quote := &api.PriceQuote{
+		Bid:    &bidPrice,
+		Ask:    &askPrice,
+		Mid:    centerPrice,
+		Source: fmt.Sprintf("exchange ticker (%s)", f.name),
 	}
 
-	centerPrice := (p.BidPrice.AsFloat() + p.AskPrice.AsFloat()) / 2
-	log.Printf("price from exchange feed (%s): bidPrice=%.7f, askPrice=%.7f, centerPrice=%.7f", f.name, p.BidPrice.AsFloat(), p.AskPrice.AsFloat(), centerPrice)
-	return centerPrice, nil
+	switch f.pricePoint {
+	case exchangePricePointBid:
+		quote.Mid = bidPrice
+	case exchangePricePointAsk:
+		quote.Mid = askPrice
+	case exchangePricePointLast, exchangePricePointVwap1h:
+		e = f.priceFromTrades(quote)
+		if e != nil {
+			return nil, e
+		}
+	}
+	log.Printf("price from exchange feed (%s): bidPrice=%.7f, askPrice=%.7f, centerPrice=%.7f, %s=%.7f", f.name, bidPrice, askPrice, centerPrice, f.pricePoint, quote.Mid)
+	return quote, nil
+}
+
+// priceFromTrades sets the price of the quote from the recent public trades on the exchange
+func (f *exchangeFeed) priceFromTrades(quote *api.PriceQuote) error {
+	result, e := f.tradeAPI.GetTrades(&f.pairs[0], nil)
+	if e != nil {
+		return fmt.Errorf("error while getting trades from exchange feed: %s", e)
+	}
+	if len(result.Trades) == 0 {
+		return fmt.Errorf("no recent trades for trading pair %s to compute the '%s' price of the exchange feed", f.pairs[0].String(), f.pricePoint)
+	}
+
+	if f.pricePoint == exchangePricePointLast {
+		last := result.Trades[len(result.Trades)-1]
+		quote.Mid = last.Price.AsFloat()
+		if last.Timestamp != nil {
+			lastTradeTime := tradeTime(last.Timestamp)
+			quote.LastTradeTime = &lastTradeTime
+		}
+		quote.Source = fmt.Sprintf("last trade on exchange (%s)", f.name)
+		return nil
+	}
+
+	vwap, e := vwapSince(result.Trades, time.Now().Add(-exchangeFeedVwapWindow))
+	if e != nil {
+		return fmt.Errorf("unable to compute the '%s' price of the exchange feed: %s", f.pricePoint, e)
+	}
+	quote.Mid = vwap
+	quote.Source = fmt.Sprintf("volume-weighted average price of trades in the last %s on exchange (%s)", exchangeFeedVwapWindow, f.name)
+	return nil
+}
+
+// vwapSince is the volume-weighted average price of the trades at or after since
+func vwapSince(trades []model.Trade, since time.Time) (float64, error) {
+	notional := 0.0
+	volume := 0.0
+	for _, t := range trades {
+		if t.Timestamp == nil || tradeTime(t.Timestamp).Before(since) {
+			continue
+		}
+		notional += t.Price.AsFloat() * t.Volume.AsFloat()
+		volume += t.Volume.AsFloat()
+	}
+	if volume == 0 {
+		return 0, fmt.Errorf("no trades since %s", since.UTC().Format(time.RFC3339))
+	}
+	return notional / volume, nil
+}
+
+// tradeTime converts the timestamp of a trade to a time, some exchanges (kraken) report the timestamps of trades in seconds instead of millis
+// so timestamps that are too small to be millis of a recent date are treated as seconds
+func tradeTime(ts *model.Timestamp) time.Time {
+	v := ts.AsInt64()
+	if v < 1e11 {
+		return time.Unix(v, 0)
+	}
+	return time.Unix(0, v*int64(time.Millisecond))
 }
@@ -0,0 +1,49 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseExchangePricePoint(t *testing.T) {
+	for _, s := range []string{"bid", "ask", "mid", "last", "vwap_1h"} {
+		p, e := parseExchangePricePoint(s)
+		if assert.NoError(t, e) {
+			assert.Equal(t, exchangePricePoint(s), p)
+		}
+	}
+
+	_, e := parseExchangePricePoint("vwap")
+	assert.Error(t, e)
+}
+
+func TestVwapSince(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	makeTrade := func(price float64, volume float64, ts *model.Timestamp) model.Trade {
+		return model.Trade{Order: model.Order{
+			Price:     model.NumberFromFloat(price, 7),
+			Volume:    model.NumberFromFloat(volume, 7),
+			Timestamp: ts,
+		}}
+	}
+	trades := []model.Trade{
+		// outside the window
+		makeTrade(5.0, 100, model.MakeTimestampFromTime(now.Add(-2*time.Hour))),
+		makeTrade(1.0, 10, model.MakeTimestampFromTime(now.Add(-30*time.Minute))),
+		// timestamp in seconds
+		makeTrade(2.0, 30, model.MakeTimestamp(now.Add(-10*time.Minute).Unix())),
+		makeTrade(3.0, 100, nil),
+	}
+
+	vwap, e := vwapSince(trades, now.Add(-time.Hour))
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.InDelta(t, 1.75, vwap, 0.0000001)
+
+	_, e = vwapSince(trades, now)
+	assert.Error(t, e)
+}
@@ -98,6 +98,40 @@ var strategies = map[string]StrategyContainer{
 			return makeBalancedStrategy(strategyFactoryData.sdex, strategyFactoryData.tradingPair, strategyFactoryData.ieif, strategyFactoryData.assetBase, strategyFactoryData.assetQuote, &cfg), nil
 		},
 	},
+	"pendulum": {
+		SortOrder:   5,
+		Description: "Places one buy and one sell offer around the last fill price and moves the price in the direction of every fill",
+		NeedsConfig: true,
+		Complexity:  "Beginner",
+		makeFn: func(strategyFactoryData strategyFactoryData) (api.Strategy, error) {
+			var cfg pendulumConfig
+			err := config.Read(strategyFactoryData.stratConfigPath, &cfg)
+			utils.CheckConfigError(cfg, err, strategyFactoryData.stratConfigPath)
+			utils.LogConfig(cfg)
+			s, e := makePendulumStrategy(strategyFactoryData.sdex, strategyFactoryData.tradingPair, strategyFactoryData.ieif, strategyFactoryData.assetBase, strategyFactoryData.assetQuote, &cfg)
+			if e != nil {
+				return nil, fmt.Errorf("makeFn failed: %s", e)
+			}
+			return s, nil
+		},
+	},
+	"indexband": {
+		SortOrder:   6,
+		Description: "Creates buy and sell offers in price bands around the price of an index feed with a pre-specified liquidity depth in each band",
+		NeedsConfig: true,
+		Complexity:  "Beginner",
+		makeFn: func(strategyFactoryData strategyFactoryData) (api.Strategy, error) {
+			var cfg indexBandConfig
+			err := config.Read(strategyFactoryData.stratConfigPath, &cfg)
+			utils.CheckConfigError(cfg, err, strategyFactoryData.stratConfigPath)
+			utils.LogConfig(cfg)
+			s, e := makeIndexBandStrategy(strategyFactoryData.sdex, strategyFactoryData.tradingPair, strategyFactoryData.ieif, strategyFactoryData.assetBase, strategyFactoryData.assetQuote, &cfg)
+			if e != nil {
+				return nil, fmt.Errorf("makeFn failed: %s", e)
+			}
+			return s, nil
+		},
+	},
 	"delete": {
 		SortOrder:   2,
 		Description: "Deletes all orders for the configured orderbook",
@@ -190,7 +224,25 @@ func loadExchanges() {
 			TradeEnabled: true,
 			Tested:       true,
 			makeFn: func(exchangeFactoryData exchangeFactoryData) (api.Exchange, error) {
-				return makeKrakenExchange(exchangeFactoryData.apiKeys, exchangeFactoryData.simMode)
+				return makeKrakenExchange(exchangeFactoryData.apiKeys, exchangeFactoryData.exchangeParams, exchangeFactoryData.simMode)
+			},
+		},
+		"bitfinex": {
+			SortOrder:    1,
+			Description:  "Bitfinex is a popular centralized cryptocurrency exchange",
+			TradeEnabled: true,
+			Tested:       false,
+			makeFn: func(exchangeFactoryData exchangeFactoryData) (api.Exchange, error) {
+				return makeBitfinexExchange(exchangeFactoryData.apiKeys, exchangeFactoryData.simMode)
+			},
+		},
+		"binance": {
+			SortOrder:    2,
+			Description:  "Binance is a popular centralized cryptocurrency exchange, the regional exchanges are selected with the \"region\" param",
+			TradeEnabled: true,
+			Tested:       false,
+			makeFn: func(exchangeFactoryData exchangeFactoryData) (api.Exchange, error) {
+				return makeBinanceExchange(exchangeFactoryData.apiKeys, exchangeFactoryData.exchangeParams, exchangeFactoryData.simMode)
 			},
 		},
 	}
@@ -229,11 +281,17 @@ func loadExchanges() {
 
 // MakeExchange is a factory method to make an exchange based on a given type
 func MakeExchange(exchangeType string, simMode bool) (api.Exchange, error) {
+	return makeExchangeWithParams(exchangeType, nil, simMode)
+}
+
+// makeExchangeWithParams makes an exchange without API keys, such as for reading market data, that is configured with the exchangeParams
+func makeExchangeWithParams(exchangeType string, exchangeParams []api.ExchangeParam, simMode bool) (api.Exchange, error) {
 	if exchange, ok := getExchanges()[exchangeType]; ok {
 		exchangeAPIKey := api.ExchangeAPIKey{Key: "", Secret: ""}
 		x, e := exchange.makeFn(exchangeFactoryData{
-			simMode: simMode,
-			apiKeys: []api.ExchangeAPIKey{exchangeAPIKey},
+			simMode:        simMode,
+			apiKeys:        []api.ExchangeAPIKey{exchangeAPIKey},
+			exchangeParams: exchangeParams,
 		})
 		if e != nil {
 			return nil, fmt.Errorf("error when making the '%s' exchange: %s", exchangeType, e)
@@ -255,15 +313,24 @@ func MakeTradingExchange(exchangeType string, apiKeys []api.ExchangeAPIKey, exch
 			return nil, fmt.Errorf("cannot make trading exchange, apiKeys mising")
 		}
 
+		chaos, innerParams, e := extractChaosConfig(exchangeParams)
+		if e != nil {
+			return nil, fmt.Errorf("error when making the '%s' exchange: %s", exchangeType, e)
+		}
+
 		x, e := exchange.makeFn(exchangeFactoryData{
 			simMode:        simMode,
 			apiKeys:        apiKeys,
-			exchangeParams: exchangeParams,
+			exchangeParams: innerParams,
 			headers:        headers,
 		})
 		if e != nil {
 			return nil, fmt.Errorf("error when making the '%s' exchange: %s", exchangeType, e)
 		}
+
+		if chaos != nil {
+			x = makeChaosExchange(x, chaos)
+		}
 		return x, nil
 	}
 
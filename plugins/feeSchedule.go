@@ -0,0 +1,54 @@
+package plugins
+
+import (
+	"fmt"
+)
+
+// FeeSchedule describes the trading fees charged by a venue as fractions of the quote value of a trade (0.001 = 0.1%).
+// A negative MakerFee is a rebate paid to makers, MakerIncentive is any additional rate paid to makers by an incentive program (such as on SDEX).
+type FeeSchedule struct {
+	MakerFee       float64 `valid:"-" toml:"MAKER_FEE" json:"maker_fee"`
+	TakerFee       float64 `valid:"-" toml:"TAKER_FEE" json:"taker_fee"`
+	MakerIncentive float64 `valid:"-" toml:"MAKER_INCENTIVE" json:"maker_incentive"`
+}
+
+// String is the stringer function
+func (f *FeeSchedule) String() string {
+	if f == nil {
+		return "FeeSchedule[<nil>]"
+	}
+	return fmt.Sprintf("FeeSchedule[MakerFee=%.6f, TakerFee=%.6f, MakerIncentive=%.6f]", f.MakerFee, f.TakerFee, f.MakerIncentive)
+}
+
+// validate checks that the rates are sane, rebates and incentives can never exceed the value of the trade
+func (f *FeeSchedule) validate() error {
+	if f == nil {
+		return nil
+	}
+	if f.MakerFee <= -1 || f.MakerFee >= 1 {
+		return fmt.Errorf("MAKER_FEE needs to be in the range (-1, 1): %f", f.MakerFee)
+	}
+	if f.TakerFee <= -1 || f.TakerFee >= 1 {
+		return fmt.Errorf("TAKER_FEE needs to be in the range (-1, 1): %f", f.TakerFee)
+	}
+	if f.MakerIncentive < 0 || f.MakerIncentive >= 1 {
+		return fmt.Errorf("MAKER_INCENTIVE needs to be in the range [0, 1): %f", f.MakerIncentive)
+	}
+	return nil
+}
+
+// EffectiveMakerFee is the net rate paid when making on this venue, negative values mean we are paid to make
+func (f *FeeSchedule) EffectiveMakerFee() float64 {
+	if f == nil {
+		return 0
+	}
+	return f.MakerFee - f.MakerIncentive
+}
+
+// EffectiveTakerFee is the net rate paid when taking on this venue
+func (f *FeeSchedule) EffectiveTakerFee() float64 {
+	if f == nil {
+		return 0
+	}
+	return f.TakerFee
+}
@@ -0,0 +1,133 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/build"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// FillAnomalyLimits are the limits beyond which a fill is considered anomalous, which usually indicates a config error or a broken price feed
+type FillAnomalyLimits struct {
+	LevelAmount          float64       // expected size of a level in units of the base asset
+	MaxSizeMultiple      float64       // fills larger than MaxSizeMultiple * LevelAmount are anomalous, 0 does not check the size
+	MaxPriceDeviationPct float64       // fills priced further than this percentage from the reference price are anomalous, 0 does not check the price
+	ReferenceFeed        *api.FeedPair // reference price in units of the quote asset per unit of the base asset, only used to check the price
+}
+
+// fillAnomalyFilter watches the fills of the bot and stops quoting once a fill is anomalous: every later update cycle deletes the offers
+// of the bot instead of submitting the ops of the strategy, until the bot is restarted after the cause has been investigated
+type fillAnomalyFilter struct {
+	limits    FillAnomalyLimits
+	alert     api.Alert
+	sdex      *SDEX
+	startedAt time.Time
+
+	// uninitialized
+	mutex   *sync.Mutex
+	tripped string // description of the anomalous fill, empty while quoting
+}
+
+var _ SubmitFilter = &fillAnomalyFilter{}
+var _ api.FillHandler = &fillAnomalyFilter{}
+
+// MakeFilterFillAnomaly makes a submit filter that stops quoting after an anomalous fill, returns nil when the limits are not set
+func MakeFilterFillAnomaly(limits *FillAnomalyLimits, alert api.Alert, sdex *SDEX) SubmitFilter {
+	if limits == nil || (limits.MaxSizeMultiple <= 0 && limits.MaxPriceDeviationPct <= 0) {
+		return nil
+	}
+
+	return &fillAnomalyFilter{
+		limits:    *limits,
+		alert:     alert,
+		sdex:      sdex,
+		startedAt: time.Now(),
+		mutex:     &sync.Mutex{},
+	}
+}
+
+// HandleFill impl, fills from before the bot started are ignored since they were already there when the bot was (re)started
+func (f *fillAnomalyFilter) HandleFill(trade model.Trade) error {
+	if trade.Timestamp != nil && time.Unix(0, trade.Timestamp.AsInt64()*int64(time.Millisecond)).Before(f.startedAt) {
+		return nil
+	}
+
+	anomaly, e := f.checkFill(trade)
+	if e != nil {
+		return fmt.Errorf("unable to check fill for anomalies: %s", e)
+	}
+	if anomaly == "" {
+		return nil
+	}
+
+	f.mutex.Lock()
+	alreadyTripped := f.tripped != ""
+	if !alreadyTripped {
+		f.tripped = anomaly
+	}
+	f.mutex.Unlock()
+
+	log.Printf("fillAnomalyFilter: anomalous fill (txID=%v): %s\n", trade.TransactionID, anomaly)
+	if alreadyTripped {
+		return nil
+	}
+	log.Printf("fillAnomalyFilter: stopped quoting, all offers of the bot will be deleted until the bot is restarted\n")
+	e = f.alert.Trigger(fmt.Sprintf("stopped quoting on pair %s after an anomalous fill: %s", trade.Pair, anomaly), trade)
+	if e != nil {
+		return fmt.Errorf("unable to trigger alert for anomalous fill: %s", e)
+	}
+	return nil
+}
+
+// checkFill returns a description of the anomaly when the fill is anomalous
+func (f *fillAnomalyFilter) checkFill(trade model.Trade) (string, error) {
+	if f.limits.MaxSizeMultiple > 0 && trade.Volume != nil {
+		maxAmount := f.limits.MaxSizeMultiple * f.limits.LevelAmount
+		if trade.Volume.AsFloat() > maxAmount {
+			return fmt.Sprintf("%s fill of %.7f units of the base asset is larger than %.2f times the level amount (%.7f)",
+				trade.OrderAction, trade.Volume.AsFloat(), f.limits.MaxSizeMultiple, f.limits.LevelAmount), nil
+		}
+	}
+
+	if f.limits.MaxPriceDeviationPct > 0 && trade.Price != nil {
+		referencePrice, e := f.limits.ReferenceFeed.GetCenterPrice()
+		if e != nil {
+			return "", fmt.Errorf("unable to fetch price from reference price feed: %s", e)
+		}
+		if referencePrice <= 0 {
+			return "", fmt.Errorf("invalid price from reference price feed: %f", referencePrice)
+		}
+		deviationPct := math.Abs(trade.Price.AsFloat()/referencePrice-1) * 100
+		if deviationPct > f.limits.MaxPriceDeviationPct {
+			return fmt.Sprintf("%s fill at price %.7f deviates %.2f%% from the reference price %.7f, more than %.2f%%",
+				trade.OrderAction, trade.Price.AsFloat(), deviationPct, referencePrice, f.limits.MaxPriceDeviationPct), nil
+		}
+	}
+	return "", nil
+}
+
+// Apply impl.
+func (f *fillAnomalyFilter) Apply(
+	ops []build.TransactionMutator,
+	sellingOffers []hProtocol.Offer,
+	buyingOffers []hProtocol.Offer,
+) ([]build.TransactionMutator, error) {
+	f.mutex.Lock()
+	tripped := f.tripped
+	f.mutex.Unlock()
+	if tripped == "" {
+		return ops, nil
+	}
+
+	// the ops of the strategy are replaced with deletes of the existing offers, which includes any deletes that the strategy wanted
+	filteredOps := f.sdex.DeleteAllOffers(sellingOffers)
+	filteredOps = append(filteredOps, f.sdex.DeleteAllOffers(buyingOffers)...)
+	log.Printf("fillAnomalyFilter: not quoting because of an anomalous fill (%s), replaced %d ops with %d deletes\n", tripped, len(ops), len(filteredOps))
+	return filteredOps, nil
+}
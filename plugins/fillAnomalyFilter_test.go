@@ -0,0 +1,116 @@
+package plugins
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stellar/go/build"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/xdr"
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type testFillAlert struct {
+	descriptions []string
+}
+
+func (a *testFillAlert) Trigger(description string, details interface{}) error {
+	a.descriptions = append(a.descriptions, description)
+	return nil
+}
+
+func TestMakeFilterFillAnomaly(t *testing.T) {
+	assert.Nil(t, MakeFilterFillAnomaly(nil, &testFillAlert{}, nil))
+	assert.Nil(t, MakeFilterFillAnomaly(&FillAnomalyLimits{LevelAmount: 100}, &testFillAlert{}, nil))
+	assert.NotNil(t, MakeFilterFillAnomaly(&FillAnomalyLimits{LevelAmount: 100, MaxSizeMultiple: 3}, &testFillAlert{}, nil))
+}
+
+func TestFillAnomalyFilterCheckFill(t *testing.T) {
+	feedPair, e := MakeFeedPair("fixed", "0.2", "fixed", "1.0")
+	if !assert.NoError(t, e) {
+		return
+	}
+	f := MakeFilterFillAnomaly(&FillAnomalyLimits{
+		LevelAmount:          100,
+		MaxSizeMultiple:      3,
+		MaxPriceDeviationPct: 10,
+		ReferenceFeed:        feedPair,
+	}, &testFillAlert{}, nil).(*fillAnomalyFilter)
+
+	testCases := []struct {
+		name        string
+		volume      float64
+		price       float64
+		wantAnomaly bool
+	}{
+		{name: "normal fill", volume: 100, price: 0.21, wantAnomaly: false},
+		{name: "fill at the size limit", volume: 300, price: 0.2, wantAnomaly: false},
+		{name: "fill above the size limit", volume: 301, price: 0.2, wantAnomaly: true},
+		{name: "fill far below the reference price", volume: 100, price: 0.1, wantAnomaly: true},
+		{name: "fill far above the reference price", volume: 100, price: 0.23, wantAnomaly: true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			anomaly, e := f.checkFill(model.Trade{Order: model.Order{
+				OrderAction: model.OrderActionSell,
+				Price:       model.NumberFromFloat(k.price, 7),
+				Volume:      model.NumberFromFloat(k.volume, 7),
+			}})
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantAnomaly, anomaly != "", anomaly)
+		})
+	}
+}
+
+func TestFillAnomalyFilterStopsQuoting(t *testing.T) {
+	alert := &testFillAlert{}
+	sdex := &SDEX{sourceMutex: &sync.Mutex{}}
+	f := MakeFilterFillAnomaly(&FillAnomalyLimits{LevelAmount: 100, MaxSizeMultiple: 3}, alert, sdex).(*fillAnomalyFilter)
+
+	usd := hProtocol.Asset{Type: "credit_alphanum4", Code: "USD", Issuer: testIssuer}
+	native := hProtocol.Asset{Type: "native"}
+	sellingOffers := []hProtocol.Offer{{ID: 1, Selling: native, Buying: usd, Price: "0.2"}}
+	buyingOffers := []hProtocol.Offer{{ID: 2, Selling: usd, Buying: native, Price: "5"}}
+	op := build.CreateOffer(build.Rate{Selling: build.NativeAsset(), Buying: build.CreditAsset("USD", testIssuer), Price: build.Price("0.2")}, build.Amount("10"))
+
+	// ops pass through while quoting
+	ops, e := f.Apply([]build.TransactionMutator{&op}, sellingOffers, buyingOffers)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, []build.TransactionMutator{&op}, ops)
+
+	makeTrade := func(ts time.Time, volume float64) model.Trade {
+		return model.Trade{Order: model.Order{
+			OrderAction: model.OrderActionBuy,
+			Price:       model.NumberFromFloat(0.2, 7),
+			Volume:      model.NumberFromFloat(volume, 7),
+			Timestamp:   model.MakeTimestampFromTime(ts),
+		}}
+	}
+
+	// anomalous fills from before the bot started are ignored
+	assert.NoError(t, f.HandleFill(makeTrade(f.startedAt.Add(-time.Hour), 1000)))
+	assert.NoError(t, f.HandleFill(makeTrade(f.startedAt.Add(time.Second), 100)))
+	assert.Equal(t, 0, len(alert.descriptions))
+
+	// an anomalous fill triggers the alert once and replaces all ops with deletes of the existing offers
+	assert.NoError(t, f.HandleFill(makeTrade(f.startedAt.Add(time.Second), 1000)))
+	assert.NoError(t, f.HandleFill(makeTrade(f.startedAt.Add(time.Second), 2000)))
+	assert.Equal(t, 1, len(alert.descriptions))
+
+	ops, e = f.Apply([]build.TransactionMutator{&op}, sellingOffers, buyingOffers)
+	if !assert.NoError(t, e) || !assert.Equal(t, 2, len(ops)) {
+		return
+	}
+	for i, id := range []xdr.Int64{1, 2} {
+		deleteOp := ops[i].(*build.ManageOfferBuilder)
+		assert.Equal(t, id, deleteOp.MO.OfferId)
+		assert.Equal(t, xdr.Int64(0), deleteOp.MO.Amount)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"runtime/debug"
+	"sync"
 	"time"
 
 	"github.com/nikhilsaraf/go-tools/multithreading"
@@ -11,6 +12,12 @@ import (
 	"github.com/stellar/kelp/model"
 )
 
+// tradeStreamRetryDelay is how long the FillTracker waits before reconnecting a TradeStream that failed
+const tradeStreamRetryDelay = 5 * time.Second
+
+// maxHandledTrades bounds the number of trade IDs remembered to avoid handling a trade both from the TradeStream and from polling
+const maxHandledTrades = 1000
+
 // FillTracker tracks fills
 type FillTracker struct {
 	pair                             *model.TradingPair
@@ -24,6 +31,7 @@ type FillTracker struct {
 
 	// uninitialized
 	handlers []api.FillHandler
+	handled  *handledTrades // nil unless trades are streamed, in which case polling only catches the trades missed by the stream
 }
 
 // enforce FillTracker implementing api.FillTracker
@@ -80,6 +88,11 @@ func (f *FillTracker) TrackFills() error {
 	log.Printf("got latest trade cursor from where to start tracking fills: %v\n", lastCursor)
 
 	ech := make(chan error, len(f.handlers))
+	if tradeStream, ok := f.fillTrackable.(api.TradeStream); ok {
+		f.handled = makeHandledTrades(maxHandledTrades)
+		go f.streamTrades(tradeStream, ech)
+	}
+
 	for {
 		select {
 		case e := <-ech:
@@ -100,26 +113,12 @@ func (f *FillTracker) TrackFills() error {
 			continue
 		}
 
-		if len(tradeHistoryResult.Trades) > 0 {
-			// use a single goroutine so we handle trades sequentially and also respect the handler sequence
-			e = f.threadTracker.TriggerGoroutine(func(inputs []interface{}) {
-				ech := inputs[0].(chan error)
-				defer handlePanic(ech)
-
-				handlers := inputs[1].([]api.FillHandler)
-				trades := inputs[2].([]model.Trade)
-				for _, t := range trades {
-					for _, h := range handlers {
-						e := h.HandleFill(t)
-						if e != nil {
-							ech <- fmt.Errorf("error in a fill handler: %s", e)
-							// we do NOT want to exit from the goroutine immediately after encountering an error
-							// because we want to give all handlers a chance to get called for each trade
-						}
-					}
-				}
-			}, []interface{}{ech, f.handlers, tradeHistoryResult.Trades})
+		trades := f.unhandledTrades(tradeHistoryResult.Trades)
+		if len(trades) > 0 {
+			e = f.dispatch(trades, ech)
 			if e != nil {
+				// the trades were not handled so they need to be handled on the next cycle
+				f.forget(trades)
 				eMsg := fmt.Sprintf("error spawning fill handler: %s", e)
 				if f.countError() {
 					return fmt.Errorf(eMsg)
@@ -136,6 +135,130 @@ func (f *FillTracker) TrackFills() error {
 	}
 }
 
+// dispatch handles the trades on a single goroutine so we handle trades sequentially and also respect the handler sequence
+func (f *FillTracker) dispatch(trades []model.Trade, ech chan error) error {
+	return f.threadTracker.TriggerGoroutine(func(inputs []interface{}) {
+		ech := inputs[0].(chan error)
+		defer handlePanic(ech)
+
+		handlers := inputs[1].([]api.FillHandler)
+		trades := inputs[2].([]model.Trade)
+		for _, t := range trades {
+			for _, h := range handlers {
+				e := h.HandleFill(t)
+				if e != nil {
+					ech <- fmt.Errorf("error in a fill handler: %s", e)
+					// we do NOT want to exit from the goroutine immediately after encountering an error
+					// because we want to give all handlers a chance to get called for each trade
+				}
+			}
+		}
+	}, []interface{}{ech, f.handlers, trades})
+}
+
+// streamTrades runs forever, reconnecting the trade stream whenever it fails. Polling the trade history continues in the meantime so
+// no fills are missed while the stream is down.
+func (f *FillTracker) streamTrades(tradeStream api.TradeStream, ech chan error) {
+	for {
+		log.Printf("starting trade stream to track fills\n")
+		e := tradeStream.StreamTrades(f.GetPair(), func(trade model.Trade) {
+			trades := f.unhandledTrades([]model.Trade{trade})
+			if len(trades) == 0 {
+				return
+			}
+
+			e := f.dispatch(trades, ech)
+			if e != nil {
+				// polling picks up the trade on its next cycle since it was not handled
+				f.forget(trades)
+				log.Printf("error spawning fill handler for streamed trade: %s\n", e)
+			}
+		})
+		if e == api.ErrTradeStreamUnsupported {
+			log.Printf("exchange does not support streaming trades, tracking fills by polling only\n")
+			return
+		}
+		log.Printf("trade stream ended, reconnecting in %s: %s\n", tradeStreamRetryDelay, e)
+		time.Sleep(tradeStreamRetryDelay)
+	}
+}
+
+// unhandledTrades filters out trades that were already handled when trades are streamed, marking the returned trades as handled
+func (f *FillTracker) unhandledTrades(trades []model.Trade) []model.Trade {
+	if f.handled == nil {
+		return trades
+	}
+
+	unhandled := []model.Trade{}
+	for _, t := range trades {
+		if f.handled.markHandled(t) {
+			unhandled = append(unhandled, t)
+		}
+	}
+	return unhandled
+}
+
+// forget allows the trades to be handled again
+func (f *FillTracker) forget(trades []model.Trade) {
+	if f.handled == nil {
+		return
+	}
+
+	for _, t := range trades {
+		f.handled.forget(t)
+	}
+}
+
+// handledTrades remembers the IDs of the most recently handled trades
+type handledTrades struct {
+	mutex    *sync.Mutex
+	maxSize  int
+	ids      map[string]bool
+	idsOrder []string
+}
+
+func makeHandledTrades(maxSize int) *handledTrades {
+	return &handledTrades{
+		mutex:    &sync.Mutex{},
+		maxSize:  maxSize,
+		ids:      map[string]bool{},
+		idsOrder: []string{},
+	}
+}
+
+// markHandled returns false if the trade was already handled, trades without an ID are always handled
+func (h *handledTrades) markHandled(trade model.Trade) bool {
+	if trade.TransactionID == nil {
+		return true
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	id := trade.TransactionID.String()
+	if h.ids[id] {
+		return false
+	}
+	h.ids[id] = true
+	h.idsOrder = append(h.idsOrder, id)
+	if len(h.idsOrder) > h.maxSize {
+		delete(h.ids, h.idsOrder[0])
+		h.idsOrder = h.idsOrder[1:]
+	}
+	return true
+}
+
+// forget allows the trade to be handled again
+func (h *handledTrades) forget(trade model.Trade) {
+	if trade.TransactionID == nil {
+		return
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	delete(h.ids, trade.TransactionID.String())
+}
+
 func (f *FillTracker) sleep() {
 	time.Sleep(time.Duration(f.fillTrackerSleepMillis) * time.Millisecond)
 }
@@ -0,0 +1,29 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandledTrades(t *testing.T) {
+	makeTrade := func(id string) model.Trade {
+		return model.Trade{TransactionID: model.MakeTransactionID(id)}
+	}
+	h := makeHandledTrades(2)
+
+	assert.True(t, h.markHandled(makeTrade("a")))
+	assert.False(t, h.markHandled(makeTrade("a")))
+	assert.True(t, h.markHandled(makeTrade("b")))
+	// evicts "a"
+	assert.True(t, h.markHandled(makeTrade("c")))
+	assert.True(t, h.markHandled(makeTrade("a")))
+
+	h.forget(makeTrade("c"))
+	assert.True(t, h.markHandled(makeTrade("c")))
+
+	// trades without an ID cannot be deduplicated
+	assert.True(t, h.markHandled(model.Trade{}))
+	assert.True(t, h.markHandled(model.Trade{}))
+}
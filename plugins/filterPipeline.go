@@ -0,0 +1,260 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/stellar/go/build"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// names of the submit filters, used to configure them in the trader config and to report their decisions
+const (
+	FilterNameAmountJitter     = "amount_jitter"
+	FilterNameVolumeLimit      = "volume_limit"
+	FilterNameOrderConstraints = "order_constraints"
+	FilterNameMakerMode        = "maker_mode"
+	FilterNameChurnLimit       = "churn_limit"
+	FilterNamePriceGuard       = "price_guard"
+	FilterNameFillAnomaly      = "fill_anomaly"
+)
+
+// submitFilterDefaults lists every submit filter with its default priority and how it is made available, filters run in ascending order
+// of priority. The amount jitter filter is first by default so the volume limits and the order constraints are checked against the
+// randomized amounts, the volume limit filter is next so the order constraints are checked against the capped amounts, and the churn
+// limit filter limits the final set of ops that will be submitted. The price guard filter is next so it checks the prices of every op that
+// remains, and the fill anomaly filter is last so it replaces every op with deletes once quoting was stopped. Both of them can be reordered
+// but not disabled.
+var submitFilterDefaults = map[string]struct {
+	priority     int
+	availability string
+}{
+	FilterNameAmountJitter:     {priority: 25, availability: "available when AMOUNT_JITTER is set"},
+	FilterNameVolumeLimit:      {priority: 50, availability: "available when VOLUME_LIMITS are set"},
+	FilterNameOrderConstraints: {priority: 100, availability: "always available"},
+	FilterNameMakerMode:        {priority: 200, availability: "available when SUBMIT_MODE is 'maker_only'"},
+	FilterNameChurnLimit:       {priority: 300, availability: "available when MAX_CHURN_PER_CYCLE is set"},
+	FilterNamePriceGuard:       {priority: 400, availability: "available when MIN_SELL_PRICE or MAX_BUY_PRICE is set"},
+	FilterNameFillAnomaly:      {priority: 500, availability: "available when FILL_ANOMALY_MAX_SIZE_MULTIPLE or FILL_ANOMALY_MAX_PRICE_DEVIATION_PCT is set"},
+}
+
+// SubmitFilterNames returns the names of all the submit filters
+func SubmitFilterNames() []string {
+	names := []string{}
+	for name := range submitFilterDefaults {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i int, j int) bool {
+		return submitFilterDefaults[names[i]].priority < submitFilterDefaults[names[j]].priority
+	})
+	return names
+}
+
+// FilterStats are the running totals of the decisions made by a submit filter
+type FilterStats struct {
+	Cycles     uint64 `json:"cycles"`
+	OpsIn      uint64 `json:"ops_in"`
+	OpsDropped uint64 `json:"ops_dropped"`
+	OpsAdded   uint64 `json:"ops_added"`
+	Errors     uint64 `json:"errors"`
+}
+
+type pipelineFilter struct {
+	name     string
+	priority int
+	enabled  bool
+	filter   SubmitFilter
+	stats    FilterStats
+}
+
+// FilterPipeline runs named submit filters in ascending order of priority and keeps track of the ops dropped by each of them
+type FilterPipeline struct {
+	filters []*pipelineFilter
+}
+
+// MakeFilterPipeline is a factory method, it makes the pipeline with the default submit filters that are available for the settings
+func MakeFilterPipeline(
+	submitMode api.SubmitMode,
+	maxChurnPerCycle uint32,
+	amountJitter float64,
+	amountJitterSeed int64,
+	volumeLimits []VolumeLimit,
+	minSellPrice float64,
+	maxBuyPrice float64,
+	fillAnomalyLimits *FillAnomalyLimits,
+	alert api.Alert,
+	exchangeShim api.ExchangeShim,
+	sdex *SDEX,
+	tradingPair *model.TradingPair,
+	assetBase hProtocol.Asset,
+	assetQuote hProtocol.Asset,
+) *FilterPipeline {
+	p := &FilterPipeline{filters: []*pipelineFilter{}}
+	p.add(FilterNameAmountJitter, MakeFilterAmountJitter(amountJitter, amountJitterSeed))
+	p.add(FilterNameVolumeLimit, MakeFilterVolumeLimit(volumeLimits, assetBase, assetQuote))
+	p.add(FilterNameOrderConstraints, MakeFilterOrderConstraints(exchangeShim.GetOrderConstraints(tradingPair), assetBase, assetQuote))
+	p.add(FilterNameMakerMode, MakeFilterMakerMode(submitMode, exchangeShim, sdex, tradingPair))
+	p.add(FilterNameChurnLimit, MakeFilterChurnLimit(maxChurnPerCycle, exchangeShim, tradingPair))
+	p.add(FilterNamePriceGuard, MakeFilterPriceGuard(minSellPrice, maxBuyPrice, assetBase, assetQuote))
+	p.add(FilterNameFillAnomaly, MakeFilterFillAnomaly(fillAnomalyLimits, alert, sdex))
+	return p
+}
+
+// add adds the filter with its default priority, nil filters are skipped since the factories return nil when a filter is not needed
+func (p *FilterPipeline) add(name string, filter SubmitFilter) {
+	if filter == nil {
+		return
+	}
+	p.filters = append(p.filters, &pipelineFilter{
+		name:     name,
+		priority: submitFilterDefaults[name].priority,
+		enabled:  true,
+		filter:   filter,
+	})
+	p.sort()
+}
+
+func (p *FilterPipeline) sort() {
+	sort.SliceStable(p.filters, func(i int, j int) bool {
+		return p.filters[i].priority < p.filters[j].priority
+	})
+}
+
+// Configure enables or disables the named filter and changes its priority, nil values keep the current setting
+func (p *FilterPipeline) Configure(name string, enabled *bool, priority *int) error {
+	defaults, ok := submitFilterDefaults[name]
+	if !ok {
+		return fmt.Errorf("unknown submit filter '%s', needs to be one of %v", name, SubmitFilterNames())
+	}
+
+	var f *pipelineFilter
+	for _, pf := range p.filters {
+		if pf.name == name {
+			f = pf
+		}
+	}
+	if f == nil {
+		if enabled != nil && *enabled {
+			return fmt.Errorf("submit filter '%s' cannot be enabled because it is not available for this bot, it is %s", name, defaults.availability)
+		}
+		// disabling or reordering a filter that is not available has no effect
+		return nil
+	}
+
+	if enabled != nil {
+		if !*enabled && name == FilterNamePriceGuard {
+			return fmt.Errorf("submit filter '%s' cannot be disabled, unset MIN_SELL_PRICE and MAX_BUY_PRICE instead", name)
+		}
+		if !*enabled && name == FilterNameFillAnomaly {
+			return fmt.Errorf("submit filter '%s' cannot be disabled, unset FILL_ANOMALY_MAX_SIZE_MULTIPLE and FILL_ANOMALY_MAX_PRICE_DEVIATION_PCT instead", name)
+		}
+		f.enabled = *enabled
+	}
+	if priority != nil {
+		f.priority = *priority
+		p.sort()
+	}
+	return nil
+}
+
+// Apply runs the ops through the enabled filters and logs the decision of each filter
+func (p *FilterPipeline) Apply(ops []build.TransactionMutator, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]build.TransactionMutator, error) {
+	return p.apply(ops, sellingOffers, buyingOffers, true)
+}
+
+// Preview runs the ops through the enabled filters without logging or counting their decisions, the state of the filters is restored
+// afterwards so the preview does not change the ops of the next update
+func (p *FilterPipeline) Preview(ops []build.TransactionMutator, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]build.TransactionMutator, error) {
+	for _, f := range p.filters {
+		if ss, ok := f.filter.(api.StateSnapshotter); ok {
+			defer ss.Snapshot()()
+		}
+	}
+	return p.apply(ops, sellingOffers, buyingOffers, false)
+}
+
+func (p *FilterPipeline) apply(ops []build.TransactionMutator, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer, record bool) ([]build.TransactionMutator, error) {
+	for _, f := range p.filters {
+		if !f.enabled {
+			continue
+		}
+
+		numIn := len(ops)
+		filteredOps, e := f.filter.Apply(ops, sellingOffers, buyingOffers)
+		if e != nil {
+			if record {
+				f.stats.Errors++
+			}
+			return nil, fmt.Errorf("error in submit filter '%s': %s", f.name, e)
+		}
+		ops = filteredOps
+		if !record {
+			continue
+		}
+
+		numOut := len(ops)
+		f.stats.Cycles++
+		f.stats.OpsIn += uint64(numIn)
+		if numOut < numIn {
+			f.stats.OpsDropped += uint64(numIn - numOut)
+		} else {
+			f.stats.OpsAdded += uint64(numOut - numIn)
+		}
+		log.Printf("submitFilter=%s | opsIn=%d | opsOut=%d\n", f.name, numIn, numOut)
+	}
+	return ops, nil
+}
+
+// pruneOpsCounter is implemented by submit filters that need to know how many ops were submitted to prune excess offers, which are submitted
+// before the filters are applied without going through them
+type pruneOpsCounter interface {
+	setNumPruneOps(numOps int)
+}
+
+// SetNumPruneOps tells the filters how many ops were submitted to prune excess offers in the current update cycle, it needs to be called before
+// the filters are applied in every update cycle
+func (p *FilterPipeline) SetNumPruneOps(numOps int) {
+	for _, f := range p.filters {
+		if c, ok := f.filter.(pruneOpsCounter); ok {
+			c.setNumPruneOps(numOps)
+		}
+	}
+}
+
+// GetFillHandlers returns the filters that need to be registered with the fill tracker, including disabled filters so they are up to date
+// if they are enabled later
+func (p *FilterPipeline) GetFillHandlers() []api.FillHandler {
+	handlers := []api.FillHandler{}
+	for _, f := range p.filters {
+		if h, ok := f.filter.(api.FillHandler); ok {
+			handlers = append(handlers, h)
+		}
+	}
+	return handlers
+}
+
+// Stats returns the decisions made by each filter in the pipeline, keyed by the name of the filter
+func (p *FilterPipeline) Stats() map[string]FilterStats {
+	stats := map[string]FilterStats{}
+	for _, f := range p.filters {
+		stats[f.name] = f.stats
+	}
+	return stats
+}
+
+// String is the stringer function, it lists the filters in the order they run
+func (p *FilterPipeline) String() string {
+	names := []string{}
+	for _, f := range p.filters {
+		state := "enabled"
+		if !f.enabled {
+			state = "disabled"
+		}
+		names = append(names, fmt.Sprintf("%s(priority=%d, %s)", f.name, f.priority, state))
+	}
+	return fmt.Sprintf("FilterPipeline[%s]", strings.Join(names, ", "))
+}
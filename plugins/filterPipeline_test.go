@@ -0,0 +1,193 @@
+package plugins
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stellar/go/build"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stretchr/testify/assert"
+)
+
+type testPipelineOp string
+
+func (op testPipelineOp) MutateTransaction(*build.TransactionBuilder) error {
+	return nil
+}
+
+// testPipelineFilter drops the first numDrop ops and then appends an op with its name
+type testPipelineFilter struct {
+	name    string
+	numDrop int
+	fail    bool
+}
+
+func (f *testPipelineFilter) Apply(ops []build.TransactionMutator, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]build.TransactionMutator, error) {
+	if f.fail {
+		return nil, fmt.Errorf("failed")
+	}
+	numDrop := f.numDrop
+	if numDrop > len(ops) {
+		numDrop = len(ops)
+	}
+	return append(ops[numDrop:], testPipelineOp(f.name)), nil
+}
+
+func makeTestPipeline() *FilterPipeline {
+	p := &FilterPipeline{filters: []*pipelineFilter{}}
+	p.add(FilterNameChurnLimit, &testPipelineFilter{name: "churn", numDrop: 3})
+	p.add(FilterNameOrderConstraints, &testPipelineFilter{name: "constraints", numDrop: 1})
+	p.add(FilterNameMakerMode, nil)
+	return p
+}
+
+func testPipelineOps(n int) []build.TransactionMutator {
+	ops := []build.TransactionMutator{}
+	for i := 0; i < n; i++ {
+		ops = append(ops, testPipelineOp(fmt.Sprintf("op%d", i)))
+	}
+	return ops
+}
+
+func TestFilterPipelineApply(t *testing.T) {
+	p := makeTestPipeline()
+	assert.Equal(t, "FilterPipeline[order_constraints(priority=100, enabled), churn_limit(priority=300, enabled)]", p.String())
+
+	ops, e := p.Apply(testPipelineOps(4), nil, nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+	// constraints drops op0 and appends its op, then churn drops 3 ops and appends its op
+	assert.Equal(t, []build.TransactionMutator{testPipelineOp("constraints"), testPipelineOp("churn")}, ops)
+
+	ops, e = p.Apply(testPipelineOps(0), nil, nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+	// constraints adds an op, which churn drops before adding its own
+	assert.Equal(t, []build.TransactionMutator{testPipelineOp("churn")}, ops)
+
+	stats := p.Stats()
+	assert.Equal(t, 2, len(stats))
+	assert.Equal(t, FilterStats{Cycles: 2, OpsIn: 4, OpsDropped: 0, OpsAdded: 1}, stats[FilterNameOrderConstraints])
+	assert.Equal(t, FilterStats{Cycles: 2, OpsIn: 5, OpsDropped: 2, OpsAdded: 0}, stats[FilterNameChurnLimit])
+}
+
+func TestFilterPipelinePreview(t *testing.T) {
+	p := makeTestPipeline()
+	ops, e := p.Preview(testPipelineOps(4), nil, nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 2, len(ops))
+	assert.Equal(t, FilterStats{}, p.Stats()[FilterNameOrderConstraints])
+	assert.Equal(t, FilterStats{}, p.Stats()[FilterNameChurnLimit])
+}
+
+func TestFilterPipelinePreviewRestoresState(t *testing.T) {
+	usd := build.CreditAsset("USD", testIssuer)
+	createOp := build.CreateOffer(build.Rate{Selling: build.NativeAsset(), Buying: usd, Price: build.Price("2")}, build.Amount("100"))
+	ops := []build.TransactionMutator{&createOp}
+
+	expected, e := makeAmountJitterFilter(0.1, rand.New(rand.NewSource(42))).Apply(ops, nil, nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	p := &FilterPipeline{filters: []*pipelineFilter{}}
+	p.add(FilterNameAmountJitter, makeAmountJitterFilter(0.1, rand.New(rand.NewSource(42))))
+	_, e = p.Preview(ops, nil, nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+	// the preview does not advance the randomness of the seeded filter
+	applied, e := p.Apply(ops, nil, nil)
+	if assert.NoError(t, e) {
+		assert.Equal(t, expected, applied)
+	}
+}
+
+func TestFilterPipelineError(t *testing.T) {
+	p := &FilterPipeline{filters: []*pipelineFilter{}}
+	p.add(FilterNameOrderConstraints, &testPipelineFilter{name: "constraints", fail: true})
+	p.add(FilterNameChurnLimit, &testPipelineFilter{name: "churn"})
+
+	_, e := p.Apply(testPipelineOps(2), nil, nil)
+	assert.EqualError(t, e, "error in submit filter 'order_constraints': failed")
+	assert.Equal(t, FilterStats{Errors: 1}, p.Stats()[FilterNameOrderConstraints])
+	assert.Equal(t, FilterStats{}, p.Stats()[FilterNameChurnLimit])
+}
+
+func TestFilterPipelineConfigure(t *testing.T) {
+	yes := true
+	no := false
+	priority := 50
+
+	testCases := []struct {
+		name       string
+		filterName string
+		enabled    *bool
+		priority   *int
+		wantErr    bool
+		wantString string
+	}{
+		{
+			name:       "reorder",
+			filterName: FilterNameChurnLimit,
+			priority:   &priority,
+			wantString: "FilterPipeline[churn_limit(priority=50, enabled), order_constraints(priority=100, enabled)]",
+		}, {
+			name:       "disable",
+			filterName: FilterNameOrderConstraints,
+			enabled:    &no,
+			wantString: "FilterPipeline[order_constraints(priority=100, disabled), churn_limit(priority=300, enabled)]",
+		}, {
+			name:       "disable unavailable",
+			filterName: FilterNameMakerMode,
+			enabled:    &no,
+			wantString: "FilterPipeline[order_constraints(priority=100, enabled), churn_limit(priority=300, enabled)]",
+		}, {
+			name:       "enable unavailable",
+			filterName: FilterNameMakerMode,
+			enabled:    &yes,
+			wantErr:    true,
+		}, {
+			name:       "unknown",
+			filterName: "unknown_filter",
+			enabled:    &no,
+			wantErr:    true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			p := makeTestPipeline()
+			e := p.Configure(k.filterName, k.enabled, k.priority)
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantString, p.String())
+		})
+	}
+}
+
+func TestFilterPipelineSkipsDisabled(t *testing.T) {
+	p := makeTestPipeline()
+	no := false
+	e := p.Configure(FilterNameChurnLimit, &no, nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	ops, e := p.Apply(testPipelineOps(2), nil, nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, []build.TransactionMutator{testPipelineOp("op1"), testPipelineOp("constraints")}, ops)
+	assert.Equal(t, FilterStats{}, p.Stats()[FilterNameChurnLimit])
+}
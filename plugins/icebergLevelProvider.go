@@ -0,0 +1,136 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// icebergLevel is the state of one level of the wrapped provider, amounts are in units of the base asset
+type icebergLevel struct {
+	price       float64 // price of the level in the last update, used to attribute fills to the level
+	filled      float64 // amount of the level that was filled since the bot started
+	released    float64 // amount of the level that was made visible so far, the visible amount is released - filled
+	lastRelease time.Time
+}
+
+// icebergLevelProvider wraps a level provider so only a visible slice of the amount of each level is posted. The amount of a level is the
+// total amount to sell at that level, the hidden remainder is released in slices as the visible slice fills so the orderbook does not
+// show the full size of the position. With a slice interval at most one slice of a level is released per interval, which spreads the
+// selling out over time.
+type icebergLevelProvider struct {
+	inner            api.LevelProvider
+	visibleFraction  float64
+	sliceInterval    time.Duration
+	orderConstraints *model.OrderConstraints
+	now              func() time.Time
+
+	mutex  *sync.Mutex
+	levels []*icebergLevel
+}
+
+// ensure it implements the LevelProvider and FillHandler interfaces
+var _ api.LevelProvider = &icebergLevelProvider{}
+var _ api.FillHandler = &icebergLevelProvider{}
+
+// makeIcebergLevelProvider is a factory method, a sliceInterval of 0 releases the next slice of a level as soon as the previous slice fills
+func makeIcebergLevelProvider(inner api.LevelProvider, visibleFraction float64, sliceInterval time.Duration, orderConstraints *model.OrderConstraints) (api.LevelProvider, error) {
+	if visibleFraction <= 0 || visibleFraction > 1 {
+		return nil, fmt.Errorf("the visible fraction needs to be greater than 0 and at most 1: %f", visibleFraction)
+	}
+	return &icebergLevelProvider{
+		inner:            inner,
+		visibleFraction:  visibleFraction,
+		sliceInterval:    sliceInterval,
+		orderConstraints: orderConstraints,
+		now:              time.Now,
+		mutex:            &sync.Mutex{},
+		levels:           []*icebergLevel{},
+	}, nil
+}
+
+// GetLevels impl.
+func (p *icebergLevelProvider) GetLevels(maxAssetBase float64, maxAssetQuote float64) ([]api.Level, error) {
+	levels, e := p.inner.GetLevels(maxAssetBase, maxAssetQuote)
+	if e != nil {
+		return nil, e
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if len(levels) != len(p.levels) {
+		if len(p.levels) > 0 {
+			log.Printf("iceberg | number of levels changed from %d to %d, resetting the filled amounts of the levels\n", len(p.levels), len(levels))
+		}
+		p.levels = []*icebergLevel{}
+		for range levels {
+			p.levels = append(p.levels, &icebergLevel{})
+		}
+	}
+
+	now := p.now()
+	visibleLevels := []api.Level{}
+	for i, l := range levels {
+		state := p.levels[i]
+		state.price = l.Price.AsFloat()
+		total := l.Amount.AsFloat()
+		if state.released < state.filled {
+			// the fills of a level can exceed the released amount when offers of the level were not updated to the smaller slice yet
+			state.released = state.filled
+		}
+		if state.lastRelease.IsZero() || now.Sub(state.lastRelease) >= p.sliceInterval {
+			released := math.Min(total, state.filled+total*p.visibleFraction)
+			if released > state.released {
+				state.released = released
+				state.lastRelease = now
+			}
+		}
+
+		visible := math.Min(state.released, total) - state.filled
+		if visible <= 0 {
+			continue
+		}
+		visibleLevels = append(visibleLevels, api.Level{
+			Price:   l.Price,
+			Amount:  *model.NumberFromFloat(visible, p.orderConstraints.VolumePrecision),
+			Passive: l.Passive,
+		})
+		log.Printf("iceberg | level=%d | price=%.7f | totalAmount=%.7f | filledAmount=%.7f | visibleAmount=%.7f\n", i, state.price, total, state.filled, visible)
+	}
+	return visibleLevels, nil
+}
+
+// HandleFill impl, attributes a sell to the level with the closest price
+func (p *icebergLevelProvider) HandleFill(trade model.Trade) error {
+	if !trade.OrderAction.IsSell() {
+		return nil
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	var closest *icebergLevel
+	for _, l := range p.levels {
+		if closest == nil || math.Abs(l.price-trade.Price.AsFloat()) < math.Abs(closest.price-trade.Price.AsFloat()) {
+			closest = l
+		}
+	}
+	if closest == nil {
+		return nil
+	}
+	closest.filled += trade.Volume.AsFloat()
+	return nil
+}
+
+// GetFillHandlers impl
+func (p *icebergLevelProvider) GetFillHandlers() ([]api.FillHandler, error) {
+	handlers, e := p.inner.GetFillHandlers()
+	if e != nil {
+		return nil, e
+	}
+	return append(handlers, p), nil
+}
@@ -0,0 +1,107 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// fixedLevelProvider always returns the same levels
+type fixedLevelProvider struct {
+	levels []api.Level
+}
+
+func (p *fixedLevelProvider) GetLevels(maxAssetBase float64, maxAssetQuote float64) ([]api.Level, error) {
+	return p.levels, nil
+}
+
+func (p *fixedLevelProvider) GetFillHandlers() ([]api.FillHandler, error) {
+	return nil, nil
+}
+
+func makeTestSell(price float64, volume float64) model.Trade {
+	return model.Trade{Order: model.Order{
+		OrderAction: model.OrderActionSell,
+		Price:       model.NumberFromFloat(price, 7),
+		Volume:      model.NumberFromFloat(volume, 7),
+	}}
+}
+
+func TestIcebergLevelProvider(t *testing.T) {
+	inner := &fixedLevelProvider{levels: []api.Level{
+		{Price: *model.NumberFromFloat(1.0, 7), Amount: *model.NumberFromFloat(100, 7)},
+		{Price: *model.NumberFromFloat(1.1, 7), Amount: *model.NumberFromFloat(50, 7)},
+	}}
+	now := time.Unix(1000, 0)
+	lp, e := makeIcebergLevelProvider(inner, 0.1, 0, &model.OrderConstraints{PricePrecision: 7, VolumePrecision: 7})
+	if !assert.NoError(t, e) {
+		return
+	}
+	p := lp.(*icebergLevelProvider)
+	p.now = func() time.Time { return now }
+
+	amounts := func() []float64 {
+		levels, e := p.GetLevels(0, 0)
+		assert.NoError(t, e)
+		result := []float64{}
+		for _, l := range levels {
+			result = append(result, l.Amount.AsFloat())
+		}
+		return result
+	}
+
+	assert.Equal(t, []float64{10, 5}, amounts())
+
+	// a partial fill of the first level is re-posted from the hidden remainder
+	assert.NoError(t, p.HandleFill(makeTestSell(1.0, 4)))
+	assert.Equal(t, []float64{10, 5}, amounts())
+	assert.Equal(t, 4.0, p.levels[0].filled)
+
+	// the last slice of a level is only the remainder and the level is no longer posted once it is sold
+	assert.NoError(t, p.HandleFill(makeTestSell(1.1, 48)))
+	assert.Equal(t, []float64{10, 2}, amounts())
+	assert.NoError(t, p.HandleFill(makeTestSell(1.1, 2)))
+	assert.Equal(t, []float64{10}, amounts())
+
+	// buys are not counted
+	assert.NoError(t, p.HandleFill(model.Trade{Order: model.Order{OrderAction: model.OrderActionBuy, Price: model.NumberFromFloat(1.0, 7), Volume: model.NumberFromFloat(10, 7)}}))
+	assert.Equal(t, 4.0, p.levels[0].filled)
+}
+
+func TestIcebergLevelProviderSliceInterval(t *testing.T) {
+	inner := &fixedLevelProvider{levels: []api.Level{
+		{Price: *model.NumberFromFloat(1.0, 7), Amount: *model.NumberFromFloat(100, 7)},
+	}}
+	now := time.Unix(1000, 0)
+	lp, e := makeIcebergLevelProvider(inner, 0.1, time.Minute, &model.OrderConstraints{PricePrecision: 7, VolumePrecision: 7})
+	if !assert.NoError(t, e) {
+		return
+	}
+	p := lp.(*icebergLevelProvider)
+	p.now = func() time.Time { return now }
+
+	levels, _ := p.GetLevels(0, 0)
+	assert.Equal(t, 10.0, levels[0].Amount.AsFloat())
+
+	// the filled slice is not replenished until the interval passed
+	assert.NoError(t, p.HandleFill(makeTestSell(1.0, 10)))
+	now = now.Add(30 * time.Second)
+	levels, _ = p.GetLevels(0, 0)
+	assert.Equal(t, 0, len(levels))
+
+	now = now.Add(30 * time.Second)
+	levels, _ = p.GetLevels(0, 0)
+	if assert.Equal(t, 1, len(levels)) {
+		assert.Equal(t, 10.0, levels[0].Amount.AsFloat())
+	}
+}
+
+func TestMakeIcebergLevelProviderInvalidFraction(t *testing.T) {
+	for _, fraction := range []float64{-0.1, 1.5} {
+		_, e := makeIcebergLevelProvider(&fixedLevelProvider{}, fraction, 0, &model.OrderConstraints{})
+		assert.Error(t, e)
+	}
+}
@@ -111,7 +111,7 @@ func (ieif *IEIF) willOversellNative(incrementalNativeAmount float64) (bool, err
 
 	willOversellNative := incrementalNativeAmount > (nativeBal - minAccountBal - nativeLiabilities.Selling)
 	if willOversellNative {
-		log.Printf("we will oversell the native asset after considering fee and min reserves, incrementalNativeAmount = %.8f, nativeBal = %.8f, minAccountBal = %.8f, nativeLiabilities.Selling = %.8f\n",
+		log.Printf("warning: skipping op that would fail with op_low_reserve, we will oversell the native asset after considering fee and min reserves, incrementalNativeAmount = %.8f, nativeBal = %.8f, minAccountBal = %.8f, nativeLiabilities.Selling = %.8f\n",
 			incrementalNativeAmount, nativeBal, minAccountBal, nativeLiabilities.Selling)
 	}
 	return willOversellNative, nil
@@ -132,7 +132,7 @@ func (ieif *IEIF) willOversell(asset hProtocol.Asset, amountSelling float64) (bo
 
 	willOversell := amountSelling > (bal - minAccountBal - liabilities.Selling)
 	if willOversell {
-		log.Printf("we will oversell the asset '%s', amountSelling = %.8f, bal = %.8f, minAccountBal = %.8f, liabilities.Selling = %.8f\n",
+		log.Printf("warning: skipping op that would fail with op_underfunded, we will oversell the asset '%s', amountSelling = %.8f, bal = %.8f, minAccountBal = %.8f, liabilities.Selling = %.8f\n",
 			utils.Asset2String(asset), amountSelling, bal, minAccountBal, liabilities.Selling)
 	}
 	return willOversell, nil
@@ -155,6 +155,10 @@ func (ieif *IEIF) willOverbuy(asset hProtocol.Asset, amountBuying float64) (bool
 	}
 
 	willOverbuy := amountBuying > (balance.Trust - liabilities.Buying)
+	if willOverbuy {
+		log.Printf("warning: skipping op that would fail with op_line_full, we will overbuy the asset '%s', amountBuying = %.8f, trust = %.8f, liabilities.Buying = %.8f\n",
+			utils.Asset2String(asset), amountBuying, balance.Trust, liabilities.Buying)
+	}
 	return willOverbuy, nil
 }
 
@@ -244,6 +248,33 @@ func (ieif *IEIF) _liabilities(asset hProtocol.Asset, otherAsset hProtocol.Asset
 		return nil, nil, err
 	}
 
+	liabilities, pairLiabilities, e := offerLiabilities(offers, asset, otherAsset)
+	if e != nil {
+		return nil, nil, e
+	}
+
+	// prefer the liabilities reported by the network over the ones computed from offers since they are exact and include all offers
+	balance, e := ieif.assetBalance(asset)
+	if e != nil {
+		return nil, nil, e
+	}
+	if balance.Liabilities != nil {
+		if liabilities.Buying != balance.Liabilities.Buying || liabilities.Selling != balance.Liabilities.Selling {
+			log.Printf("using reported liabilities for asset %s (buying=%.8f, selling=%.8f) instead of computed liabilities (buying=%.8f, selling=%.8f)\n",
+				utils.Asset2String(asset), balance.Liabilities.Buying, balance.Liabilities.Selling, liabilities.Buying, liabilities.Selling)
+		}
+		liabilities = Liabilities{
+			Buying:  balance.Liabilities.Buying,
+			Selling: balance.Liabilities.Selling,
+		}
+	}
+
+	ieif.cachedLiabilities[asset] = liabilities
+	return &liabilities, &pairLiabilities, nil
+}
+
+// offerLiabilities returns the liabilities for the asset from the offers passed in along with the pairLiabilities w.r.t. the other asset
+func offerLiabilities(offers []hProtocol.Offer, asset hProtocol.Asset, otherAsset hProtocol.Asset) (Liabilities, Liabilities, error) {
 	// liabilities for the asset
 	liabilities := Liabilities{}
 	// liabilities for the asset w.r.t. the trading pair
@@ -252,7 +283,7 @@ func (ieif *IEIF) _liabilities(asset hProtocol.Asset, otherAsset hProtocol.Asset
 		if offer.Selling == asset {
 			offerAmt, err := utils.ParseOfferAmount(offer.Amount)
 			if err != nil {
-				return nil, nil, err
+				return Liabilities{}, Liabilities{}, err
 			}
 			liabilities.Selling += offerAmt
 
@@ -262,11 +293,11 @@ func (ieif *IEIF) _liabilities(asset hProtocol.Asset, otherAsset hProtocol.Asset
 		} else if offer.Buying == asset {
 			offerAmt, err := utils.ParseOfferAmount(offer.Amount)
 			if err != nil {
-				return nil, nil, err
+				return Liabilities{}, Liabilities{}, err
 			}
 			offerPrice, err := utils.ParseOfferAmount(offer.Price)
 			if err != nil {
-				return nil, nil, err
+				return Liabilities{}, Liabilities{}, err
 			}
 			buyingAmount := offerAmt * offerPrice
 			liabilities.Buying += buyingAmount
@@ -276,9 +307,7 @@ func (ieif *IEIF) _liabilities(asset hProtocol.Asset, otherAsset hProtocol.Asset
 			}
 		}
 	}
-
-	ieif.cachedLiabilities[asset] = liabilities
-	return &liabilities, &pairLiabilities, nil
+	return liabilities, pairLiabilities, nil
 }
 
 // ResetCachedBalances resets the cached balances map
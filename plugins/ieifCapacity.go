@@ -0,0 +1,173 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// AssetCapacity breaks down the balance of an asset into the amount that is free to be committed to new offers and the amounts that are reserved
+type AssetCapacity struct {
+	Asset                  string   `json:"asset"`
+	Balance                float64  `json:"balance"`
+	Free                   float64  `json:"free"`                     // amount that can still be sold, negative when the account is underfunded
+	ReservedForLiabilities float64  `json:"reserved_for_liabilities"` // amount committed to existing sell offers (selling liabilities)
+	ReservedForAccount     float64  `json:"reserved_for_account"`     // min reserve of the account and its subentries (trustlines, offers) plus the operational buffer for the native asset, the operational buffer for other assets
+	BuyingLiabilities      float64  `json:"buying_liabilities"`       // amount committed to be bought by existing offers
+	TrustLimit             *float64 `json:"trust_limit,omitempty"`    // nil for the native asset which has no trust limit
+	FreeToBuy              *float64 `json:"free_to_buy,omitempty"`    // amount that can still be bought before the trust limit is reached, nil for the native asset
+}
+
+// CapacityReport is the capacity of the assets of the trading pair along with warnings for ops that would fail because of the capacity
+type CapacityReport struct {
+	Base     *AssetCapacity `json:"base"`
+	Quote    *AssetCapacity `json:"quote"`
+	Native   *AssetCapacity `json:"native,omitempty"` // only set when trading on SDEX and neither asset of the pair is the native asset
+	Warnings []string       `json:"warnings"`
+}
+
+// capacityLookup returns the balance and the liabilities of an asset
+type capacityLookup func(asset hProtocol.Asset) (*api.Balance, *Liabilities, error)
+
+// CapacityReport returns the capacity of the assets of the trading pair, newOfferNativeAmount is the native amount needed for the fee and the
+// base reserve of a new offer. Fresh balances and offers are fetched instead of using the cached values since this is called outside of the
+// update cycle of the bot.
+func (ieif *IEIF) CapacityReport(assetBase hProtocol.Asset, assetQuote hProtocol.Asset, newOfferNativeAmount float64) (*CapacityReport, error) {
+	var offers []hProtocol.Offer
+	lookup := func(asset hProtocol.Asset) (*api.Balance, *Liabilities, error) {
+		balance, e := ieif.exchangeShim.GetBalanceHack(asset)
+		if e != nil {
+			return nil, nil, fmt.Errorf("unable to fetch balance: %s", e)
+		}
+		if balance.Liabilities != nil {
+			return balance, &Liabilities{Buying: balance.Liabilities.Buying, Selling: balance.Liabilities.Selling}, nil
+		}
+
+		// compute the liabilities from the offers when they are not reported by the network, offers are loaded once for all assets
+		if offers == nil {
+			offers, e = ieif.exchangeShim.LoadOffersHack()
+			if e != nil {
+				return nil, nil, fmt.Errorf("unable to load offers to compute liabilities: %s", e)
+			}
+		}
+		liabilities, _, e := offerLiabilities(offers, asset, asset)
+		if e != nil {
+			return nil, nil, fmt.Errorf("unable to compute liabilities: %s", e)
+		}
+		return balance, &liabilities, nil
+	}
+	return ieif.makeCapacityReport(assetBase, assetQuote, newOfferNativeAmount, lookup)
+}
+
+// LogCapacityWarnings logs the warnings of the capacity report using the cached balances and liabilities of the current update cycle, so the
+// reason is logged before the ops that would fail with op_underfunded or op_low_reserve are skipped
+func (ieif *IEIF) LogCapacityWarnings(assetBase hProtocol.Asset, assetQuote hProtocol.Asset, newOfferNativeAmount float64) {
+	lookup := func(asset hProtocol.Asset) (*api.Balance, *Liabilities, error) {
+		balance, e := ieif.assetBalance(asset)
+		if e != nil {
+			return nil, nil, e
+		}
+		liabilities, e := ieif.assetLiabilities(asset)
+		if e != nil {
+			return nil, nil, e
+		}
+		return balance, liabilities, nil
+	}
+
+	report, e := ieif.makeCapacityReport(assetBase, assetQuote, newOfferNativeAmount, lookup)
+	if e != nil {
+		log.Printf("could not compute capacity report, error = %s\n", e)
+		return
+	}
+	for _, w := range report.Warnings {
+		log.Printf("capacity warning: %s\n", w)
+	}
+}
+
+func (ieif *IEIF) makeCapacityReport(
+	assetBase hProtocol.Asset,
+	assetQuote hProtocol.Asset,
+	newOfferNativeAmount float64,
+	lookup capacityLookup,
+) (*CapacityReport, error) {
+	report := &CapacityReport{Warnings: []string{}}
+
+	var e error
+	report.Base, e = lookupAssetCapacity(assetBase, lookup)
+	if e != nil {
+		return nil, fmt.Errorf("unable to compute capacity of base asset: %s", e)
+	}
+	report.Quote, e = lookupAssetCapacity(assetQuote, lookup)
+	if e != nil {
+		return nil, fmt.Errorf("unable to compute capacity of quote asset: %s", e)
+	}
+
+	var nativeCapacity *AssetCapacity
+	if ieif.isTradingSdex {
+		if assetBase == utils.NativeAsset {
+			nativeCapacity = report.Base
+		} else if assetQuote == utils.NativeAsset {
+			nativeCapacity = report.Quote
+		} else {
+			report.Native, e = lookupAssetCapacity(utils.NativeAsset, lookup)
+			if e != nil {
+				return nil, fmt.Errorf("unable to compute capacity of native asset: %s", e)
+			}
+			nativeCapacity = report.Native
+		}
+	}
+
+	report.Warnings = capacityWarnings(report.Base, report.Quote, nativeCapacity, newOfferNativeAmount)
+	return report, nil
+}
+
+func lookupAssetCapacity(asset hProtocol.Asset, lookup capacityLookup) (*AssetCapacity, error) {
+	balance, liabilities, e := lookup(asset)
+	if e != nil {
+		return nil, e
+	}
+	return makeAssetCapacity(asset, balance, liabilities), nil
+}
+
+// makeAssetCapacity computes the capacity of the asset from its balance and liabilities
+func makeAssetCapacity(asset hProtocol.Asset, balance *api.Balance, liabilities *Liabilities) *AssetCapacity {
+	c := &AssetCapacity{
+		Asset:                  utils.Asset2String(asset),
+		Balance:                balance.Balance,
+		Free:                   balance.Balance - balance.Reserve - liabilities.Selling,
+		ReservedForLiabilities: liabilities.Selling,
+		ReservedForAccount:     balance.Reserve,
+		BuyingLiabilities:      liabilities.Buying,
+	}
+	if asset.Type != utils.Native {
+		trust := balance.Trust
+		freeToBuy := balance.Trust - liabilities.Buying
+		c.TrustLimit = &trust
+		c.FreeToBuy = &freeToBuy
+	}
+	return c
+}
+
+// capacityWarnings returns the warnings for ops that would fail because of the capacity, nativeCapacity is nil when not trading on SDEX
+func capacityWarnings(base *AssetCapacity, quote *AssetCapacity, nativeCapacity *AssetCapacity, newOfferNativeAmount float64) []string {
+	warnings := []string{}
+	for _, c := range []*AssetCapacity{base, quote} {
+		if c.Free <= 0 {
+			warnings = append(warnings, fmt.Sprintf("no free %s to sell (free=%.7f), offers selling more of it would fail with op_underfunded so they are not placed",
+				c.Asset, c.Free))
+		}
+		if c.FreeToBuy != nil && *c.FreeToBuy <= 0 {
+			warnings = append(warnings, fmt.Sprintf("trust limit of %s is used up (free_to_buy=%.7f), offers buying more of it would fail with op_line_full so they are not placed",
+				c.Asset, *c.FreeToBuy))
+		}
+	}
+
+	if nativeCapacity != nil && nativeCapacity.Free < newOfferNativeAmount {
+		warnings = append(warnings, fmt.Sprintf("free %s (%.7f) is below the %.7f needed for the fee and base reserve of a new offer, new offers would fail with op_low_reserve so they are not placed",
+			nativeCapacity.Asset, nativeCapacity.Free, newOfferNativeAmount))
+	}
+	return warnings
+}
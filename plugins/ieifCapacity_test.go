@@ -0,0 +1,94 @@
+package plugins
+
+import (
+	"strings"
+	"testing"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeAssetCapacity(t *testing.T) {
+	usd := hProtocol.Asset{Type: "credit_alphanum4", Code: "USD", Issuer: "GCNMOFLMHQKBQMVACQBR6EXXRLKX5QDB3DZHVIHB5MDYCAA5YDXNMKIJ"}
+
+	native := makeAssetCapacity(utils.NativeAsset, &api.Balance{Balance: 100, Trust: maxLumenTrust, Reserve: 22.5}, &Liabilities{Buying: 10, Selling: 30})
+	assert.Equal(t, 100.0, native.Balance)
+	assert.Equal(t, 47.5, native.Free)
+	assert.Equal(t, 30.0, native.ReservedForLiabilities)
+	assert.Equal(t, 22.5, native.ReservedForAccount)
+	assert.Equal(t, 10.0, native.BuyingLiabilities)
+	assert.Nil(t, native.TrustLimit)
+	assert.Nil(t, native.FreeToBuy)
+
+	credit := makeAssetCapacity(usd, &api.Balance{Balance: 50, Trust: 1000, Reserve: 0}, &Liabilities{Buying: 400, Selling: 50})
+	assert.Equal(t, 0.0, credit.Free)
+	if assert.NotNil(t, credit.TrustLimit) && assert.NotNil(t, credit.FreeToBuy) {
+		assert.Equal(t, 1000.0, *credit.TrustLimit)
+		assert.Equal(t, 600.0, *credit.FreeToBuy)
+	}
+}
+
+func TestCapacityWarnings(t *testing.T) {
+	freeToBuy := func(v float64) *float64 { return &v }
+
+	testCases := []struct {
+		name         string
+		base         AssetCapacity
+		quote        AssetCapacity
+		native       *AssetCapacity
+		wantOpCodes  []string
+		wantWarnings int
+	}{
+		{
+			name:         "enough capacity",
+			base:         AssetCapacity{Asset: "native", Free: 10},
+			quote:        AssetCapacity{Asset: "USD", Free: 10, FreeToBuy: freeToBuy(10)},
+			wantWarnings: 0,
+		}, {
+			name:         "underfunded quote",
+			base:         AssetCapacity{Asset: "native", Free: 10},
+			quote:        AssetCapacity{Asset: "USD", Free: 0, FreeToBuy: freeToBuy(10)},
+			wantOpCodes:  []string{"op_underfunded"},
+			wantWarnings: 1,
+		}, {
+			name:         "trust limit used up",
+			base:         AssetCapacity{Asset: "native", Free: 10},
+			quote:        AssetCapacity{Asset: "USD", Free: 10, FreeToBuy: freeToBuy(0)},
+			wantOpCodes:  []string{"op_line_full"},
+			wantWarnings: 1,
+		}, {
+			name:         "low reserve for native base",
+			base:         AssetCapacity{Asset: "native", Free: 0.3},
+			quote:        AssetCapacity{Asset: "USD", Free: 10, FreeToBuy: freeToBuy(10)},
+			wantOpCodes:  []string{"op_low_reserve"},
+			wantWarnings: 1,
+		}, {
+			name:         "low reserve for separate native",
+			base:         AssetCapacity{Asset: "EUR", Free: 10, FreeToBuy: freeToBuy(10)},
+			quote:        AssetCapacity{Asset: "USD", Free: 10, FreeToBuy: freeToBuy(10)},
+			native:       &AssetCapacity{Asset: "native", Free: 0.1},
+			wantOpCodes:  []string{"op_low_reserve"},
+			wantWarnings: 1,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			base, quote := k.base, k.quote
+			nativeCapacity := k.native
+			if nativeCapacity == nil && base.Asset == "native" {
+				nativeCapacity = &base
+			}
+
+			warnings := capacityWarnings(&base, &quote, nativeCapacity, 0.50001)
+			if !assert.Equal(t, k.wantWarnings, len(warnings), strings.Join(warnings, "; ")) {
+				return
+			}
+			for i, opCode := range k.wantOpCodes {
+				assert.Contains(t, warnings[i], opCode)
+			}
+		})
+	}
+}
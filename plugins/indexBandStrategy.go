@@ -0,0 +1,125 @@
+package plugins
+
+import (
+	"fmt"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// IndexBand is a price band around the index price in which a number of levels are quoted on both sides, bands are contiguous so each band
+// starts where the previous band ends
+type IndexBand struct {
+	SPREAD float64 `valid:"-" json:"spread"` // outer edge of the band as a distance from the index price, specified as a decimal
+	AMOUNT float64 `valid:"-" json:"amount"` // total amount of the band on each side, in units of the base asset
+	LEVELS uint16  `valid:"-" json:"levels"` // number of levels that the amount is split into
+}
+
+// indexBandConfig contains the configuration params for this strategy
+type indexBandConfig struct {
+	PriceTolerance       float64     `valid:"-" toml:"PRICE_TOLERANCE"`
+	AmountTolerance      float64     `valid:"-" toml:"AMOUNT_TOLERANCE"`
+	DataTypeA            string      `valid:"-" toml:"DATA_TYPE_A"`
+	DataFeedAURL         string      `valid:"-" toml:"DATA_FEED_A_URL"`
+	DataTypeB            string      `valid:"-" toml:"DATA_TYPE_B"`
+	DataFeedBURL         string      `valid:"-" toml:"DATA_FEED_B_URL"`
+	Bands                []IndexBand `valid:"-" toml:"BANDS"`
+	PriceFeedCacheConfig `valid:"-"`
+}
+
+// String impl.
+func (c indexBandConfig) String() string {
+	return utils.StructString(c, nil)
+}
+
+// indexBandLevels converts the bands into levels, the levels of a band are evenly spaced inside the band so that no level is placed on the
+// edge of a band. The amounts of the levels are in units of the base asset.
+func indexBandLevels(bands []IndexBand) ([]StaticLevel, error) {
+	if len(bands) == 0 {
+		return nil, fmt.Errorf("need to specify at least one band in BANDS")
+	}
+
+	levels := []StaticLevel{}
+	innerSpread := 0.0
+	for i, b := range bands {
+		if b.SPREAD <= innerSpread || b.SPREAD >= 1 {
+			return nil, fmt.Errorf("SPREAD of band %d needs to be between the SPREAD of the previous band (%f) and 1: %f", i+1, innerSpread, b.SPREAD)
+		}
+		if b.AMOUNT <= 0 {
+			return nil, fmt.Errorf("AMOUNT of band %d needs to be positive: %f", i+1, b.AMOUNT)
+		}
+		if b.LEVELS == 0 {
+			return nil, fmt.Errorf("LEVELS of band %d needs to be positive", i+1)
+		}
+
+		width := (b.SPREAD - innerSpread) / float64(b.LEVELS)
+		for l := 0; l < int(b.LEVELS); l++ {
+			levels = append(levels, StaticLevel{
+				SPREAD: innerSpread + width*(float64(l)+0.5),
+				AMOUNT: b.AMOUNT / float64(b.LEVELS),
+			})
+		}
+		innerSpread = b.SPREAD
+	}
+	return levels, nil
+}
+
+// makeIndexBandStrategy is a factory method for the indexband strategy, which quotes the levels of each band around the price of an index
+// feed on both sides without depending on the orderbook of another exchange
+func makeIndexBandStrategy(
+	sdex *SDEX,
+	pair *model.TradingPair,
+	ieif *IEIF,
+	assetBase *hProtocol.Asset,
+	assetQuote *hProtocol.Asset,
+	config *indexBandConfig,
+) (api.Strategy, error) {
+	levels, e := indexBandLevels(config.Bands)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the indexband strategy because of an invalid band config: %s", e)
+	}
+
+	sellSideFeedPair, e := config.makeFeedPair(config.DataTypeA, config.DataFeedAURL, config.DataTypeB, config.DataFeedBURL)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the indexband strategy because we could not make the sell side feed pair: %s", e)
+	}
+	orderConstraints := sdex.GetOrderConstraints(pair)
+	sellSideStrategy := makeSellSideStrategy(
+		sdex,
+		orderConstraints,
+		ieif,
+		assetBase,
+		assetQuote,
+		// the amounts of the levels are already in units of the base asset so they are not scaled
+		makeStaticSpreadLevelProvider(levels, 1.0, 0, rateOffset{}, sellSideFeedPair, orderConstraints, nil),
+		config.PriceTolerance,
+		config.AmountTolerance,
+		false,
+	)
+
+	buySideFeedPair, e := config.makeFeedPair(config.DataTypeB, config.DataFeedBURL, config.DataTypeA, config.DataFeedAURL)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the indexband strategy because we could not make the buy side feed pair: %s", e)
+	}
+	// switch sides of base/quote here for buy side
+	buySideStrategy := makeSellSideStrategy(
+		sdex,
+		orderConstraints,
+		ieif,
+		assetQuote,
+		assetBase,
+		makeStaticSpreadLevelProvider(levels, 1.0, 0, rateOffset{invert: true}, buySideFeedPair, orderConstraints, nil),
+		config.PriceTolerance,
+		config.AmountTolerance,
+		true,
+	)
+
+	return makeComposeStrategy(
+		assetBase,
+		assetQuote,
+		buySideStrategy,
+		sellSideStrategy,
+	), nil
+}
@@ -0,0 +1,65 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndexBandLevels(t *testing.T) {
+	levels, e := indexBandLevels([]IndexBand{
+		{SPREAD: 0.005, AMOUNT: 1000, LEVELS: 2},
+		{SPREAD: 0.01, AMOUNT: 300, LEVELS: 1},
+	})
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	wantLevels := []StaticLevel{
+		{SPREAD: 0.00125, AMOUNT: 500},
+		{SPREAD: 0.00375, AMOUNT: 500},
+		{SPREAD: 0.0075, AMOUNT: 300},
+	}
+	if !assert.Equal(t, len(wantLevels), len(levels)) {
+		return
+	}
+	for i, want := range wantLevels {
+		assert.InDelta(t, want.SPREAD, levels[i].SPREAD, 1e-12, "level %d", i)
+		assert.InDelta(t, want.AMOUNT, levels[i].AMOUNT, 1e-12, "level %d", i)
+		assert.False(t, levels[i].PASSIVE)
+	}
+}
+
+func TestIndexBandLevelsInvalid(t *testing.T) {
+	testCases := []struct {
+		name  string
+		bands []IndexBand
+	}{
+		{
+			name:  "no bands",
+			bands: []IndexBand{},
+		}, {
+			name:  "zero spread",
+			bands: []IndexBand{{SPREAD: 0, AMOUNT: 1, LEVELS: 1}},
+		}, {
+			name:  "spread of 1",
+			bands: []IndexBand{{SPREAD: 1, AMOUNT: 1, LEVELS: 1}},
+		}, {
+			name:  "decreasing spread",
+			bands: []IndexBand{{SPREAD: 0.02, AMOUNT: 1, LEVELS: 1}, {SPREAD: 0.01, AMOUNT: 1, LEVELS: 1}},
+		}, {
+			name:  "zero amount",
+			bands: []IndexBand{{SPREAD: 0.01, AMOUNT: 0, LEVELS: 1}},
+		}, {
+			name:  "zero levels",
+			bands: []IndexBand{{SPREAD: 0.01, AMOUNT: 1, LEVELS: 0}},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			_, e := indexBandLevels(k.bands)
+			assert.Error(t, e)
+		})
+	}
+}
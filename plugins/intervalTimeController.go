@@ -12,17 +12,24 @@ import (
 type IntervalTimeController struct {
 	tickInterval       time.Duration
 	maxTickDelayMillis int64
+	jitter             float64 // fraction of the tickInterval by which each interval is randomly shortened or lengthened
 	randGen            *rand.Rand
+
+	// uninitialized
+	nextInterval time.Duration // interval until the next update, drawn after each update when jitter is set
 }
 
 // MakeIntervalTimeController is a factory method
-func MakeIntervalTimeController(tickInterval time.Duration, maxTickDelayMillis int64) api.TimeController {
+func MakeIntervalTimeController(tickInterval time.Duration, maxTickDelayMillis int64, jitter float64) api.TimeController {
 	randGen := rand.New(rand.NewSource(time.Now().UnixNano()))
-	return &IntervalTimeController{
+	t := &IntervalTimeController{
 		tickInterval:       tickInterval,
 		maxTickDelayMillis: maxTickDelayMillis,
+		jitter:             jitter,
 		randGen:            randGen,
 	}
+	t.nextInterval = t.makeInterval()
+	return t
 }
 
 var _ api.TimeController = &IntervalTimeController{}
@@ -30,8 +37,11 @@ var _ api.TimeController = &IntervalTimeController{}
 // ShouldUpdate impl
 func (t *IntervalTimeController) ShouldUpdate(lastUpdateTime time.Time, currentUpdateTime time.Time) bool {
 	elapsedSinceUpdate := currentUpdateTime.Sub(lastUpdateTime)
-	shouldUpdate := elapsedSinceUpdate >= t.tickInterval
-	log.Printf("intervalTimeController tickInterval=%s, shouldUpdate=%v, elapsedSinceUpdate=%s\n", t.tickInterval, shouldUpdate, elapsedSinceUpdate)
+	shouldUpdate := elapsedSinceUpdate >= t.nextInterval
+	log.Printf("intervalTimeController tickInterval=%s, nextInterval=%s, shouldUpdate=%v, elapsedSinceUpdate=%s\n", t.tickInterval, t.nextInterval, shouldUpdate, elapsedSinceUpdate)
+	if shouldUpdate {
+		t.nextInterval = t.makeInterval()
+	}
 	return shouldUpdate
 }
 
@@ -39,7 +49,7 @@ func (t *IntervalTimeController) ShouldUpdate(lastUpdateTime time.Time, currentU
 func (t *IntervalTimeController) SleepTime(lastUpdateTime time.Time, currentUpdateTime time.Time) time.Duration {
 	// use time till now as opposed to currentUpdateTime because we want the start of the clock cycle to be synchronized
 	elapsedSinceUpdate := time.Since(lastUpdateTime)
-	fixedDurationCatchup := time.Duration(t.tickInterval.Nanoseconds() - elapsedSinceUpdate.Nanoseconds())
+	fixedDurationCatchup := time.Duration(t.nextInterval.Nanoseconds() - elapsedSinceUpdate.Nanoseconds())
 	randomizedDelayMillis := t.makeRandomDelay()
 
 	// if fixedDurationCatchup < 0 then we already have a built-in randomized delay because of the variable processing time consumed
@@ -52,3 +62,13 @@ func (t *IntervalTimeController) makeRandomDelay() time.Duration {
 	}
 	return time.Duration(0) * time.Millisecond
 }
+
+// makeInterval draws the interval until the next update uniformly from [tickInterval * (1 - jitter), tickInterval * (1 + jitter)] so the
+// average interval stays at the tickInterval, unlike the delay from maxTickDelayMillis which only lengthens it
+func (t *IntervalTimeController) makeInterval() time.Duration {
+	if t.jitter <= 0 {
+		return t.tickInterval
+	}
+	factor := 1 + t.jitter*(2*t.randGen.Float64()-1)
+	return time.Duration(float64(t.tickInterval) * factor)
+}
@@ -0,0 +1,19 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntervalTimeControllerJitter(t *testing.T) {
+	c := MakeIntervalTimeController(100*time.Second, 0, 0.2).(*IntervalTimeController)
+	for i := 0; i < 100; i++ {
+		interval := c.makeInterval()
+		assert.True(t, interval >= 80*time.Second && interval <= 120*time.Second, "interval out of range: %s", interval)
+	}
+
+	c = MakeIntervalTimeController(100*time.Second, 0, 0).(*IntervalTimeController)
+	assert.Equal(t, 100*time.Second, c.makeInterval())
+}
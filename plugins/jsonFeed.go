@@ -0,0 +1,235 @@
+package plugins
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stellar/kelp/api"
+)
+
+// jsonPathSeparator separates the URL of a json feed from the path of the price in the response and the options of the request, the
+// fragment of a URL is never sent to the server so it cannot clash with the URL
+const jsonPathSeparator = "#"
+
+// jsonFeed reads the price from any HTTP API that returns JSON, which lets niche data sources be used without writing a new feed.
+// The URL is "<url>#<jsonPath>[#<option>]...", for example "https://api.example.com/ticker?symbol=XLMUSD#.data[0].last". The JSON path
+// accepts the JSONPath ("$.data[0].last"), jq (".data[0].last") and dotted ("data.0.last") styles, and quoted keys (`$["last.price"]`).
+// Each option is either a header "<Name>: <value>", "bearer:<token>" or "basic:<user>:<password>". Options can reference environment
+// variables as ${NAME} so secrets do not need to be written into config files.
+type jsonFeed struct {
+	url      string
+	jsonPath []string
+	headers  map[string]string
+	client   http.Client
+}
+
+// ensure that it implements PriceFeed
+var _ api.PriceFeed = &jsonFeed{}
+
+// makeJSONFeed is a factory method
+func makeJSONFeed(url string) (*jsonFeed, error) {
+	i := strings.Index(url, jsonPathSeparator)
+	if i == -1 {
+		return nil, fmt.Errorf("invalid format of json type URL, needs to be '<url>%s<jsonPath>': %s", jsonPathSeparator, url)
+	}
+	// [0] = jsonPath, [1:] = options
+	parts := strings.Split(url[i+1:], jsonPathSeparator)
+	jsonPath, e := parseJSONPath(parts[0])
+	if e != nil {
+		return nil, fmt.Errorf("invalid JSON path in json type URL '%s': %s", url[:i+1+len(parts[0])], e)
+	}
+
+	headers := map[string]string{}
+	for _, option := range parts[1:] {
+		// the option is not part of the error since it can hold a secret
+		e = parseJSONFeedOption(os.ExpandEnv(option), headers)
+		if e != nil {
+			return nil, fmt.Errorf("invalid option in json type URL '%s': %s", url[:i], e)
+		}
+	}
+
+	return &jsonFeed{
+		url:      url[:i],
+		jsonPath: jsonPath,
+		headers:  headers,
+		client:   http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// redactJSONFeedURL removes the options from the URL of a json feed since they can hold secrets
+func redactJSONFeedURL(url string) string {
+	parts := strings.SplitN(url, jsonPathSeparator, 3)
+	if len(parts) < 3 {
+		return url
+	}
+	return parts[0] + jsonPathSeparator + parts[1]
+}
+
+// parseJSONFeedOption adds the header of the option to headers
+func parseJSONFeedOption(option string, headers map[string]string) error {
+	switch {
+	case strings.HasPrefix(option, "bearer:"):
+		token := strings.TrimPrefix(option, "bearer:")
+		if token == "" {
+			return fmt.Errorf("bearer option needs a token")
+		}
+		headers["Authorization"] = "Bearer " + token
+	case strings.HasPrefix(option, "basic:"):
+		credentials := strings.TrimPrefix(option, "basic:")
+		if !strings.Contains(credentials, ":") {
+			return fmt.Errorf("basic option needs to be 'basic:<user>:<password>'")
+		}
+		headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+	default:
+		// [0] = name, [1] = value
+		header := strings.SplitN(option, ":", 2)
+		name := strings.TrimSpace(header[0])
+		if len(header) != 2 || name == "" || strings.ContainsAny(name, " \t") {
+			return fmt.Errorf("option needs to be a header '<Name>: <value>', 'bearer:<token>' or 'basic:<user>:<password>'")
+		}
+		headers[name] = strings.TrimSpace(header[1])
+	}
+	return nil
+}
+
+// parseJSONPath splits a path such as "$.data[0].last", ".data[0].last" or "data.0.last" into its keys, numeric keys index into arrays
+func parseJSONPath(jsonPath string) ([]string, error) {
+	keys := []string{}
+	p := strings.TrimPrefix(strings.TrimSpace(jsonPath), "$")
+	for len(p) > 0 {
+		switch p[0] {
+		case '[':
+			key, rest, e := parseJSONPathBracket(p)
+			if e != nil {
+				return nil, fmt.Errorf("%s: %s", e, jsonPath)
+			}
+			keys = append(keys, key)
+			p = rest
+			continue
+		case '.':
+			p = p[1:]
+			if strings.HasPrefix(p, "[") {
+				// jq style ".[0]"
+				continue
+			}
+		default:
+			if len(keys) > 0 {
+				return nil, fmt.Errorf("expected '.' or '[' before '%s': %s", p, jsonPath)
+			}
+		}
+
+		end := strings.IndexAny(p, ".[")
+		if end == -1 {
+			end = len(p)
+		}
+		if end == 0 {
+			return nil, fmt.Errorf("JSON path cannot have empty keys: %s", jsonPath)
+		}
+		keys = append(keys, p[:end])
+		p = p[end:]
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JSON path cannot be empty")
+	}
+	return keys, nil
+}
+
+// parseJSONPathBracket parses the bracket at the start of p, which holds an array index or a quoted key, and returns the rest of p
+func parseJSONPathBracket(p string) (string, string, error) {
+	if len(p) > 1 && (p[1] == '"' || p[1] == '\'') {
+		end := strings.IndexByte(p[2:], p[1])
+		if end == -1 || !strings.HasPrefix(p[2+end+1:], "]") {
+			return "", "", fmt.Errorf("unterminated quoted key")
+		}
+		return p[2 : 2+end], p[2+end+2:], nil
+	}
+
+	end := strings.IndexByte(p, ']')
+	if end == -1 {
+		return "", "", fmt.Errorf("unterminated bracket")
+	}
+	index := p[1:end]
+	if i, e := strconv.Atoi(index); e != nil || i < 0 {
+		return "", "", fmt.Errorf("brackets need to hold an array index or a quoted key, found '%s'", index)
+	}
+	return index, p[end+1:], nil
+}
+
+// GetPrice impl
+func (f *jsonFeed) GetPrice() (float64, error) {
+	req, e := http.NewRequest("GET", f.url, nil)
+	if e != nil {
+		return 0, fmt.Errorf("error making request to '%s': %s", f.url, e)
+	}
+	req.Header.Set("Accept", "application/json")
+	for name, value := range f.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, e := f.client.Do(req)
+	if e != nil {
+		return 0, fmt.Errorf("error fetching price from '%s': %s", f.url, e)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("error fetching price from '%s': status code %d", f.url, resp.StatusCode)
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+	var body interface{}
+	e = decoder.Decode(&body)
+	if e != nil {
+		return 0, fmt.Errorf("error decoding the response from '%s': %s", f.url, e)
+	}
+	return extractJSONPrice(body, f.jsonPath)
+}
+
+// extractJSONPrice follows the keys into the decoded JSON and returns the positive price at the end of the path, which can be a number
+// or a string holding a number
+func extractJSONPrice(body interface{}, jsonPath []string) (float64, error) {
+	value := body
+	for i, k := range jsonPath {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			next, ok := v[k]
+			if !ok {
+				return 0, fmt.Errorf("key '%s' not found at '%s'", k, strings.Join(jsonPath[:i], "."))
+			}
+			value = next
+		case []interface{}:
+			index, e := strconv.Atoi(k)
+			if e != nil || index < 0 || index >= len(v) {
+				return 0, fmt.Errorf("invalid index '%s' into array of length %d at '%s'", k, len(v), strings.Join(jsonPath[:i], "."))
+			}
+			value = v[index]
+		default:
+			return 0, fmt.Errorf("cannot read key '%s' from a value that is not an object or array at '%s'", k, strings.Join(jsonPath[:i], "."))
+		}
+	}
+
+	var price float64
+	var e error
+	switch v := value.(type) {
+	case json.Number:
+		price, e = v.Float64()
+	case string:
+		price, e = strconv.ParseFloat(v, 64)
+	default:
+		return 0, fmt.Errorf("value at '%s' needs to be a number or a string holding a number: %v", strings.Join(jsonPath, "."), value)
+	}
+	if e != nil {
+		return 0, fmt.Errorf("unable to parse the price at '%s': %s", strings.Join(jsonPath, "."), e)
+	}
+	if price <= 0 {
+		return 0, fmt.Errorf("price at '%s' needs to be positive: %f", strings.Join(jsonPath, "."), price)
+	}
+	return price, nil
+}
@@ -0,0 +1,133 @@
+package plugins
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeJSONFeed(t *testing.T) {
+	f, e := makeJSONFeed("https://api.example.com/ticker?symbol=XLMUSD#$.data[0].last")
+	if assert.NoError(t, e) {
+		assert.Equal(t, "https://api.example.com/ticker?symbol=XLMUSD", f.url)
+		assert.Equal(t, []string{"data", "0", "last"}, f.jsonPath)
+		assert.Equal(t, map[string]string{}, f.headers)
+	}
+
+	os.Setenv("KELP_TEST_JSON_FEED_KEY", "secret")
+	defer os.Unsetenv("KELP_TEST_JSON_FEED_KEY")
+	f, e = makeJSONFeed("https://api.example.com/ticker#price#X-Api-Key: ${KELP_TEST_JSON_FEED_KEY}#basic:user:pass")
+	if assert.NoError(t, e) {
+		assert.Equal(t, "https://api.example.com/ticker", f.url)
+		assert.Equal(t, []string{"price"}, f.jsonPath)
+		assert.Equal(t, map[string]string{
+			"X-Api-Key":     "secret",
+			"Authorization": "Basic dXNlcjpwYXNz",
+		}, f.headers)
+	}
+
+	f, e = makeJSONFeed("https://api.example.com/ticker#price#bearer:abc")
+	if assert.NoError(t, e) {
+		assert.Equal(t, map[string]string{"Authorization": "Bearer abc"}, f.headers)
+	}
+
+	for _, url := range []string{
+		"https://api.example.com/ticker",
+		"https://api.example.com/ticker#",
+		"https://api.example.com/ticker#data..last",
+		"https://api.example.com/ticker#price#no header",
+		"https://api.example.com/ticker#price#bearer:",
+		"https://api.example.com/ticker#price#basic:user",
+	} {
+		_, e = makeJSONFeed(url)
+		assert.Error(t, e, url)
+	}
+}
+
+func TestParseJSONPath(t *testing.T) {
+	testCases := []struct {
+		jsonPath  string
+		wantKeys  []string
+		wantError bool
+	}{
+		{jsonPath: "price", wantKeys: []string{"price"}},
+		{jsonPath: "data.0.last", wantKeys: []string{"data", "0", "last"}},
+		{jsonPath: "$.data[0].last", wantKeys: []string{"data", "0", "last"}},
+		{jsonPath: ".data[0].last", wantKeys: []string{"data", "0", "last"}},
+		{jsonPath: ".[1]", wantKeys: []string{"1"}},
+		{jsonPath: "$[0][1]", wantKeys: []string{"0", "1"}},
+		{jsonPath: `$["last.price"]`, wantKeys: []string{"last.price"}},
+		{jsonPath: `.tickers['XLM/USD'].bid`, wantKeys: []string{"tickers", "XLM/USD", "bid"}},
+		{jsonPath: "", wantError: true},
+		{jsonPath: "$", wantError: true},
+		{jsonPath: ".", wantError: true},
+		{jsonPath: "data..last", wantError: true},
+		{jsonPath: "data[*]", wantError: true},
+		{jsonPath: "data[-1]", wantError: true},
+		{jsonPath: "data[0", wantError: true},
+		{jsonPath: `data["last]`, wantError: true},
+		{jsonPath: "data[0]last", wantError: true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.jsonPath, func(t *testing.T) {
+			keys, e := parseJSONPath(k.jsonPath)
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if assert.NoError(t, e) {
+				assert.Equal(t, k.wantKeys, keys)
+			}
+		})
+	}
+}
+
+func TestRedactJSONFeedURL(t *testing.T) {
+	assert.Equal(t, "https://api.example.com/ticker#price", redactJSONFeedURL("https://api.example.com/ticker#price#bearer:abc#X-Key: 1"))
+	assert.Equal(t, "https://api.example.com/ticker#price", redactJSONFeedURL("https://api.example.com/ticker#price"))
+}
+
+func TestExtractJSONPrice(t *testing.T) {
+	testCases := []struct {
+		name      string
+		body      string
+		jsonPath  string
+		wantPrice float64
+		wantError bool
+	}{
+		{name: "number", body: `{"price": 0.1234}`, jsonPath: "price", wantPrice: 0.1234},
+		{name: "string", body: `{"price": "0.1234"}`, jsonPath: "price", wantPrice: 0.1234},
+		{name: "nested array", body: `{"data": [{"last": 2.5}, {"last": 3.5}]}`, jsonPath: "data.1.last", wantPrice: 3.5},
+		{name: "top level array", body: `[1.5, 2.5]`, jsonPath: "0", wantPrice: 1.5},
+		{name: "missing key", body: `{"price": 1}`, jsonPath: "last", wantError: true},
+		{name: "index out of range", body: `{"data": [1]}`, jsonPath: "data.1", wantError: true},
+		{name: "key into number", body: `{"price": 1}`, jsonPath: "price.value", wantError: true},
+		{name: "not a number", body: `{"price": "abc"}`, jsonPath: "price", wantError: true},
+		{name: "object", body: `{"price": {"value": 1}}`, jsonPath: "price", wantError: true},
+		{name: "zero", body: `{"price": 0}`, jsonPath: "price", wantError: true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			decoder := json.NewDecoder(strings.NewReader(k.body))
+			decoder.UseNumber()
+			var body interface{}
+			if !assert.NoError(t, decoder.Decode(&body)) {
+				return
+			}
+
+			price, e := extractJSONPrice(body, strings.Split(k.jsonPath, "."))
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if assert.NoError(t, e) {
+				assert.Equal(t, k.wantPrice, price)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"net/http"
 	"reflect"
 	"sort"
 	"strconv"
@@ -27,10 +28,13 @@ type krakenExchange struct {
 	assetConverter           *model.AssetConverter
 	assetConverterOpenOrders *model.AssetConverter // kraken uses different symbols when fetching open orders!
 	apis                     []*krakenapi.KrakenApi
+	apiKeys                  []api.ExchangeAPIKey // the websocket API needs the raw keys to sign requests that the kraken client does not support
+	httpClients              []*http.Client       // one per API key, used by the kraken client and privateRequest so they share the nonces of the key
 	apiNextIndex             uint8
 	delimiter                string
 	ocOverridesHandler       *OrderConstraintsOverridesHandler
 	withdrawKeys             asset2Address2Key
+	orderParams              map[string]string
 	isSimulated              bool // will simulate add and cancel orders if this is true
 }
 
@@ -50,28 +54,39 @@ func (m asset2Address2Key) getKey(asset model.Asset, address string) (string, er
 	return key, nil
 }
 
-// makeKrakenExchange is a factory method to make the kraken exchange
-// TODO 2, should take in config file for withdrawalKeys mapping
-func makeKrakenExchange(apiKeys []api.ExchangeAPIKey, isSimulated bool) (api.Exchange, error) {
+// makeKrakenExchange is a factory method to make the kraken exchange, the withdrawal keys are read from the exchangeParams
+func makeKrakenExchange(apiKeys []api.ExchangeAPIKey, exchangeParams []api.ExchangeParam, isSimulated bool) (api.Exchange, error) {
 	if len(apiKeys) == 0 || len(apiKeys) > math.MaxUint8 {
 		return nil, fmt.Errorf("invalid number of apiKeys: %d", len(apiKeys))
 	}
 
+	orderParams, exchangeParams := extractOrderParams(exchangeParams)
+	withdrawKeys, e := parseKrakenWithdrawKeys(exchangeParams)
+	if e != nil {
+		return nil, fmt.Errorf("unable to make kraken exchange: %s", e)
+	}
+
 	krakenAPIs := []*krakenapi.KrakenApi{}
+	httpClients := []*http.Client{}
 	for _, apiKey := range apiKeys {
-		krakenAPIClient := krakenapi.New(apiKey.Key, apiKey.Secret)
+		httpClient := makeKrakenHTTPClient(apiKey.Secret)
+		krakenAPIClient := krakenapi.NewWithClient(apiKey.Key, apiKey.Secret, httpClient)
 		krakenAPIs = append(krakenAPIs, krakenAPIClient)
+		httpClients = append(httpClients, httpClient)
 	}
 
 	return &krakenExchange{
 		assetConverter:           model.KrakenAssetConverter,
 		assetConverterOpenOrders: model.KrakenAssetConverterOpenOrders,
 		apis:               krakenAPIs,
+		apiKeys:            apiKeys,
+		httpClients:        httpClients,
 		apiNextIndex:       0,
 		delimiter:          "",
 		ocOverridesHandler: MakeEmptyOrderConstraintsOverridesHandler(),
-		withdrawKeys:       asset2Address2Key{},
+		withdrawKeys:       withdrawKeys,
 		isSimulated:        isSimulated,
+		orderParams:        orderParams,
 	}, nil
 }
 
@@ -96,19 +111,13 @@ func (k *krakenExchange) AddOrder(order *model.Order) (*model.TransactionID, err
 		return model.MakeTransactionID("simulated"), nil
 	}
 
-	orderConstraints := k.GetOrderConstraints(order.Pair)
-	if order.Price.Precision() > orderConstraints.PricePrecision {
-		return nil, fmt.Errorf("kraken price precision can be a maximum of %d, got %d, value = %.12f", orderConstraints.PricePrecision, order.Price.Precision(), order.Price.AsFloat())
-	}
-	if order.Volume.Precision() > orderConstraints.VolumePrecision {
-		return nil, fmt.Errorf("kraken volume precision can be a maximum of %d, got %d, value = %.12f", orderConstraints.VolumePrecision, order.Volume.Precision(), order.Volume.AsFloat())
-	}
-
-	args := map[string]string{
-		"price": order.Price.AsString(),
+	args, e := krakenOrderArgs(order, k.GetOrderConstraints(order.Pair))
+	if e != nil {
+		return nil, e
 	}
-	log.Printf("kraken is submitting order: pair=%s, orderAction=%s, orderType=%s, volume=%s, price=%s\n",
-		pairStr, order.OrderAction.String(), order.OrderType.String(), order.Volume.AsString(), order.Price.AsString())
+	args = withKrakenOrderParams(args, mergeOrderParams(k.orderParams, order))
+	log.Printf("kraken is submitting order: pair=%s, orderAction=%s, orderType=%s, volume=%s, args=%v\n",
+		pairStr, order.OrderAction.String(), order.OrderType.String(), order.Volume.AsString(), args)
 	resp, e := k.nextAPI().AddOrder(
 		pairStr,
 		order.OrderAction.String(),
@@ -132,6 +141,48 @@ func (k *krakenExchange) AddOrder(order *model.Order) (*model.TransactionID, err
 	return nil, fmt.Errorf("no transactionIds returned from order creation")
 }
 
+// krakenOrderArgs returns the price args of the order for the AddOrder endpoint, stop orders are triggered by "price" and stop-loss-limit orders
+// are placed at "price2" once they are triggered
+func krakenOrderArgs(order *model.Order, orderConstraints *model.OrderConstraints) (map[string]string, error) {
+	if order.Volume.Precision() > orderConstraints.VolumePrecision {
+		return nil, fmt.Errorf("kraken volume precision can be a maximum of %d, got %d, value = %.12f", orderConstraints.VolumePrecision, order.Volume.Precision(), order.Volume.AsFloat())
+	}
+	prices := []*model.Number{order.Price}
+	if order.OrderType.IsStop() {
+		e := validateStopOrder(order)
+		if e != nil {
+			return nil, fmt.Errorf("invalid kraken order: %s", e)
+		}
+		prices = []*model.Number{order.StopPrice}
+		if order.OrderType == model.OrderTypeStopLimit {
+			prices = append(prices, order.Price)
+		}
+	}
+	for _, p := range prices {
+		if p.Precision() > orderConstraints.PricePrecision {
+			return nil, fmt.Errorf("kraken price precision can be a maximum of %d, got %d, value = %.12f", orderConstraints.PricePrecision, p.Precision(), p.AsFloat())
+		}
+	}
+
+	args := map[string]string{
+		"price": prices[0].AsString(),
+	}
+	if len(prices) > 1 {
+		args["price2"] = prices[1].AsString()
+	}
+	if order.PostOnly {
+		args["oflags"] = "post"
+	}
+	if order.ClientOrderID != "" {
+		userref, e := strconv.ParseInt(order.ClientOrderID, 10, 32)
+		if e != nil {
+			return nil, fmt.Errorf("kraken needs the client order ID to be a 32-bit integer, got '%s': %s", order.ClientOrderID, e)
+		}
+		args["userref"] = strconv.FormatInt(userref, 10)
+	}
+	return args, nil
+}
+
 // CancelOrder impl.
 func (k *krakenExchange) CancelOrder(txID *model.TransactionID, pair model.TradingPair) (model.CancelOrderResult, error) {
 	if k.isSimulated {
@@ -254,15 +305,27 @@ func (k *krakenExchange) GetOpenOrders(pairs []*model.TradingPair) (map[model.Tr
 		}
 
 		orderConstraints := k.GetOrderConstraints(pair)
+		order := model.Order{
+			Pair:        pair,
+			OrderAction: model.OrderActionFromString(o.Description.Type),
+			OrderType:   model.OrderTypeFromString(o.Description.OrderType),
+			Price:       model.MustNumberFromString(o.Description.PrimaryPrice, orderConstraints.PricePrecision),
+			Volume:      model.MustNumberFromString(o.Volume, orderConstraints.VolumePrecision),
+			Timestamp:   model.MakeTimestamp(int64(o.OpenTime)),
+		}
+		if o.UserRef != 0 {
+			order.ClientOrderID = strconv.Itoa(o.UserRef)
+		}
+		// the primary price of stop orders is the stop price, stop-loss-limit orders have their limit price as the secondary price
+		if order.OrderType.IsStop() {
+			order.StopPrice = order.Price
+			order.Price = nil
+			if order.OrderType == model.OrderTypeStopLimit {
+				order.Price = model.MustNumberFromString(o.Description.SecondaryPrice, orderConstraints.PricePrecision)
+			}
+		}
 		m[*pair] = append(m[*pair], model.OpenOrder{
-			Order: model.Order{
-				Pair:        pair,
-				OrderAction: model.OrderActionFromString(o.Description.Type),
-				OrderType:   model.OrderTypeFromString(o.Description.OrderType),
-				Price:       model.MustNumberFromString(o.Description.PrimaryPrice, orderConstraints.PricePrecision),
-				Volume:      model.MustNumberFromString(o.Volume, orderConstraints.VolumePrecision),
-				Timestamp:   model.MakeTimestamp(int64(o.OpenTime)),
-			},
+			Order:          order,
 			ID:             ID,
 			StartTime:      model.MakeTimestamp(int64(o.StartTime)),
 			ExpireTime:     model.MakeTimestamp(int64(o.ExpireTime)),
@@ -521,6 +584,118 @@ func getOrderType(tInfo krakenapi.TradeInfo) (model.OrderType, error) {
 	return -1, errors.New("unidentified trade action")
 }
 
+// krakenCandleIntervals are the intervals supported by the OHLC endpoint of kraken, in minutes
+var krakenCandleIntervals = map[time.Duration]int{
+	time.Minute:         1,
+	5 * time.Minute:     5,
+	15 * time.Minute:    15,
+	30 * time.Minute:    30,
+	time.Hour:           60,
+	4 * time.Hour:       240,
+	24 * time.Hour:      1440,
+	7 * 24 * time.Hour:  10080,
+	15 * 24 * time.Hour: 21600,
+}
+
+// GetCandles impl.
+func (k *krakenExchange) GetCandles(pair *model.TradingPair, interval time.Duration, since *model.Timestamp) ([]model.Candle, error) {
+	minutes, ok := krakenCandleIntervals[interval]
+	if !ok {
+		return nil, fmt.Errorf("unsupported candle interval for kraken: %s", interval)
+	}
+	pairStr, e := pair.ToString(k.assetConverter, k.delimiter)
+	if e != nil {
+		return nil, e
+	}
+
+	input := map[string]string{
+		"pair":     pairStr,
+		"interval": strconv.Itoa(minutes),
+	}
+	if since != nil {
+		// kraken uses timestamps in seconds
+		input["since"] = strconv.FormatInt(since.AsInt64()/1000, 10)
+	}
+	resp, e := k.nextAPI().Query("OHLC", input)
+	if e != nil {
+		return nil, e
+	}
+	krakenResp, ok := resp.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("could not parse response of kraken OHLC endpoint: %v", resp)
+	}
+
+	// the response is keyed by the name of the pair used by kraken, which can differ from the name in the request, along with a "last" field
+	for key, v := range krakenResp {
+		if key == "last" {
+			continue
+		}
+		rows, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("could not parse candles for pair '%s' in response of kraken OHLC endpoint: %v", key, v)
+		}
+		return readKrakenCandles(pair, interval, rows, k.GetOrderConstraints(pair))
+	}
+	return []model.Candle{}, nil
+}
+
+// readKrakenCandles converts the rows of the kraken OHLC endpoint, each row is [time, open, high, low, close, vwap, volume, count] where
+// the time is in seconds and the prices and volume are strings
+func readKrakenCandles(pair *model.TradingPair, interval time.Duration, rows []interface{}, orderConstraints *model.OrderConstraints) ([]model.Candle, error) {
+	candles := []model.Candle{}
+	for _, r := range rows {
+		row, ok := r.([]interface{})
+		if !ok || len(row) < 7 {
+			return nil, fmt.Errorf("invalid candle in response of kraken OHLC endpoint: %v", r)
+		}
+		_time, ok := row[0].(float64)
+		if !ok {
+			return nil, fmt.Errorf("could not parse time of candle in response of kraken OHLC endpoint: %v", row)
+		}
+
+		parse := func(index int, precision int8) (*model.Number, error) {
+			s, ok := row[index].(string)
+			if !ok {
+				return nil, fmt.Errorf("could not parse field at index %d of candle in response of kraken OHLC endpoint: %v", index, row)
+			}
+			return model.NumberFromString(s, precision)
+		}
+		openPrice, e := parse(1, orderConstraints.PricePrecision)
+		if e != nil {
+			return nil, e
+		}
+		highPrice, e := parse(2, orderConstraints.PricePrecision)
+		if e != nil {
+			return nil, e
+		}
+		lowPrice, e := parse(3, orderConstraints.PricePrecision)
+		if e != nil {
+			return nil, e
+		}
+		closePrice, e := parse(4, orderConstraints.PricePrecision)
+		if e != nil {
+			return nil, e
+		}
+		// index 5 is the vwap which is not part of the candle
+		volume, e := parse(6, orderConstraints.VolumePrecision)
+		if e != nil {
+			return nil, e
+		}
+
+		candles = append(candles, model.Candle{
+			Pair:      pair,
+			Timestamp: model.MakeTimestamp(int64(_time) * 1000),
+			Interval:  interval,
+			Open:      openPrice,
+			High:      highPrice,
+			Low:       lowPrice,
+			Close:     closePrice,
+			Volume:    volume,
+		})
+	}
+	return candles, nil
+}
+
 // GetWithdrawInfo impl.
 func (k *krakenExchange) GetWithdrawInfo(
 	asset model.Asset,
@@ -642,6 +817,7 @@ func (k *krakenExchange) PrepareDeposit(asset model.Asset, amount *model.Number)
 			return &api.PrepareDepositResult{
 				Fee:      dm.fee,
 				Address:  earliestAddress.address,
+				Memo:     earliestAddress.memo,
 				ExpireTs: earliestAddress.expireTs,
 			}, nil
 		}
@@ -697,6 +873,7 @@ func (k *krakenExchange) getDepositMethods(asset string) (*depositMethod, error)
 
 type depositAddress struct {
 	address  string
+	memo     string
 	expireTs int64
 	isNew    bool
 }
@@ -760,8 +937,18 @@ func parseDepositAddress(m map[string]interface{}) (*depositAddress, error) {
 		isNew = false
 	}
 
+	// memo is only returned for assets that share a deposit address across accounts, such as XLM
+	memo, e := networking.ParseString(m, "memo", "DepositAddresses")
+	if e != nil {
+		if !strings.HasPrefix(e.Error(), networking.PrefixFieldNotFound) {
+			return nil, e
+		}
+		memo = ""
+	}
+
 	return &depositAddress{
 		address:  address,
+		memo:     memo,
 		expireTs: expireTs,
 		isNew:    isNew,
 	}, nil
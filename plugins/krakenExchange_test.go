@@ -296,3 +296,68 @@ func TestWithdrawFunds(t *testing.T) {
 	fmt.Printf("refid=%v\n", result.WithdrawalID)
 	assert.Fail(t, "force fail")
 }
+
+func TestReadKrakenCandles(t *testing.T) {
+	pair := &model.TradingPair{Base: model.XLM, Quote: model.USD}
+	rows := []interface{}{
+		[]interface{}{1546300800.0, "0.117200", "0.119100", "0.116500", "0.118500", "0.118100", "125000.50", 42.0},
+	}
+
+	candles, e := readKrakenCandles(pair, 5*time.Minute, rows, model.MakeOrderConstraints(6, 2, 1))
+	if !assert.NoError(t, e) {
+		return
+	}
+	if !assert.Equal(t, 1, len(candles)) {
+		return
+	}
+	// kraken uses seconds and candles use milliseconds
+	assert.Equal(t, int64(1546300800000), candles[0].Timestamp.AsInt64())
+	assert.Equal(t, 0.1172, candles[0].Open.AsFloat())
+	assert.Equal(t, 0.1191, candles[0].High.AsFloat())
+	assert.Equal(t, 0.1165, candles[0].Low.AsFloat())
+	assert.Equal(t, 0.1185, candles[0].Close.AsFloat())
+	// the volume is read from index 6, skipping the vwap
+	assert.Equal(t, 125000.5, candles[0].Volume.AsFloat())
+
+	_, e = readKrakenCandles(pair, 5*time.Minute, []interface{}{[]interface{}{1546300800.0, 0.1172}}, model.MakeOrderConstraints(6, 2, 1))
+	assert.Error(t, e)
+}
+
+func TestKrakenOrderArgs(t *testing.T) {
+	oc := model.MakeOrderConstraints(4, 8, 0.1)
+	limit := &model.Order{OrderType: model.OrderTypeLimit, Price: model.NumberFromFloat(0.25, 4), Volume: model.NumberFromFloat(100, 8), PostOnly: true}
+	args, e := krakenOrderArgs(limit, oc)
+	if assert.NoError(t, e) {
+		assert.Equal(t, map[string]string{"price": "0.2500", "oflags": "post"}, args)
+	}
+
+	stopLoss := &model.Order{OrderType: model.OrderTypeStopLoss, StopPrice: model.NumberFromFloat(0.2, 4), Volume: model.NumberFromFloat(100, 8)}
+	args, e = krakenOrderArgs(stopLoss, oc)
+	if assert.NoError(t, e) {
+		assert.Equal(t, map[string]string{"price": "0.2000"}, args)
+	}
+
+	stopLimit := &model.Order{OrderType: model.OrderTypeStopLimit, Price: model.NumberFromFloat(0.19, 4), StopPrice: model.NumberFromFloat(0.2, 4), Volume: model.NumberFromFloat(100, 8)}
+	args, e = krakenOrderArgs(stopLimit, oc)
+	if assert.NoError(t, e) {
+		assert.Equal(t, map[string]string{"price": "0.2000", "price2": "0.1900"}, args)
+	}
+
+	// the client order ID is sent as the userref, which needs to be a 32-bit integer
+	tagged := &model.Order{OrderType: model.OrderTypeLimit, Price: model.NumberFromFloat(0.25, 4), Volume: model.NumberFromFloat(100, 8), ClientOrderID: "12345"}
+	args, e = krakenOrderArgs(tagged, oc)
+	if assert.NoError(t, e) {
+		assert.Equal(t, map[string]string{"price": "0.2500", "userref": "12345"}, args)
+	}
+	tagged.ClientOrderID = "abc"
+	_, e = krakenOrderArgs(tagged, oc)
+	assert.Error(t, e)
+
+	// stop orders need a stop price and the stop price needs to respect the price precision
+	_, e = krakenOrderArgs(&model.Order{OrderType: model.OrderTypeStopLoss, Volume: model.NumberFromFloat(100, 8)}, oc)
+	assert.Error(t, e)
+	_, e = krakenOrderArgs(&model.Order{OrderType: model.OrderTypeStopLoss, StopPrice: model.NumberFromFloat(0.2, 6), Volume: model.NumberFromFloat(100, 8)}, oc)
+	assert.Error(t, e)
+	_, e = krakenOrderArgs(&model.Order{OrderType: model.OrderTypeStopLimit, StopPrice: model.NumberFromFloat(0.2, 4), Volume: model.NumberFromFloat(100, 8)}, oc)
+	assert.Error(t, e)
+}
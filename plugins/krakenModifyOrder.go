@@ -0,0 +1,89 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// krakenEditOrderPath is the private REST method used to amend an open order, the kraken client library does not support this method so we
+// sign the request ourselves
+const krakenEditOrderPath = "/0/private/EditOrder"
+
+// ensure that krakenExchange can modify orders
+var _ api.OrderModifier = &krakenExchange{}
+
+// krakenEditOrderResponse is the response of the EditOrder method
+type krakenEditOrderResponse struct {
+	Error  []string `json:"error"`
+	Result struct {
+		Status       string `json:"status"`
+		ErrorMessage string `json:"error_message"`
+		TxID         string `json:"txid"`
+		OriginalTxID string `json:"originaltxid"`
+	} `json:"result"`
+}
+
+// ModifyOrder impl, kraken replaces the order with a new order that has a new transaction ID
+func (k *krakenExchange) ModifyOrder(txID *model.TransactionID, order *model.Order) (*model.TransactionID, error) {
+	if order.OrderType != model.OrderTypeLimit {
+		return nil, fmt.Errorf("only limit orders can be modified, orderType=%s", order.OrderType.String())
+	}
+	pairStr, e := order.Pair.ToString(k.assetConverter, k.delimiter)
+	if e != nil {
+		return nil, e
+	}
+
+	if k.isSimulated {
+		log.Printf("not modifying order on Kraken in simulation mode, transactionID=%s, order=%s\n", txID.String(), *order)
+		return txID, nil
+	}
+
+	values, e := krakenEditOrderValues(txID, pairStr, order, k.GetOrderConstraints(order.Pair))
+	if e != nil {
+		return nil, e
+	}
+	log.Printf("kraken is modifying order: transactionID=%s, pair=%s, orderAction=%s, volume=%s, price=%s, postOnly=%v\n",
+		txID.String(), pairStr, order.OrderAction.String(), order.Volume.AsString(), order.Price.AsString(), order.PostOnly)
+	var response krakenEditOrderResponse
+	e = k.privateRequest(krakenEditOrderPath, values, &response)
+	if e != nil {
+		return nil, fmt.Errorf("error modifying order '%s': %s", txID.String(), e)
+	}
+	return readKrakenEditOrderResponse(&response, txID)
+}
+
+// krakenEditOrderValues returns the params of the EditOrder method, which are the same as the params of the AddOrder method for limit orders
+func krakenEditOrderValues(txID *model.TransactionID, pairStr string, order *model.Order, orderConstraints *model.OrderConstraints) (url.Values, error) {
+	args, e := krakenOrderArgs(order, orderConstraints)
+	if e != nil {
+		return nil, e
+	}
+
+	values := url.Values{}
+	values.Set("txid", txID.String())
+	values.Set("pair", pairStr)
+	values.Set("volume", order.Volume.AsString())
+	for k, v := range args {
+		values.Set(k, v)
+	}
+	return values, nil
+}
+
+// readKrakenEditOrderResponse returns the transaction ID of the new order, kraken reports failures either in the error field or in the status
+// of the result
+func readKrakenEditOrderResponse(response *krakenEditOrderResponse, txID *model.TransactionID) (*model.TransactionID, error) {
+	if len(response.Error) > 0 {
+		return nil, fmt.Errorf("error response when modifying order '%s': %v", txID.String(), response.Error)
+	}
+	if response.Result.Status != "ok" {
+		return nil, fmt.Errorf("order '%s' was not modified, status=%s, error_message=%s", txID.String(), response.Result.Status, response.Result.ErrorMessage)
+	}
+	if response.Result.TxID == "" {
+		return nil, fmt.Errorf("no transactionId returned when modifying order '%s'", txID.String())
+	}
+	return model.MakeTransactionID(response.Result.TxID), nil
+}
@@ -0,0 +1,71 @@
+package plugins
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKrakenEditOrderValues(t *testing.T) {
+	oc := model.MakeOrderConstraints(5, 8, 0.1)
+	order := &model.Order{
+		OrderAction: model.OrderActionBuy,
+		OrderType:   model.OrderTypeLimit,
+		Price:       model.NumberFromFloat(0.12345, 5),
+		Volume:      model.NumberFromFloat(100, 8),
+		PostOnly:    true,
+	}
+
+	values, e := krakenEditOrderValues(model.MakeTransactionID("OABC-123"), "XXLMZUSD", order, oc)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, "OABC-123", values.Get("txid"))
+	assert.Equal(t, "XXLMZUSD", values.Get("pair"))
+	assert.Equal(t, "100.00000000", values.Get("volume"))
+	assert.Equal(t, "0.12345", values.Get("price"))
+	assert.Equal(t, "post", values.Get("oflags"))
+}
+
+func TestReadKrakenEditOrderResponse(t *testing.T) {
+	testCases := []struct {
+		name      string
+		response  string
+		wantTxID  string
+		wantError bool
+	}{
+		{
+			name:     "ok",
+			response: `{"error":[],"result":{"status":"ok","txid":"ONEW-456","originaltxid":"OABC-123","volume":"100","price":"0.12"}}`,
+			wantTxID: "ONEW-456",
+		}, {
+			name:      "error field",
+			response:  `{"error":["EOrder:Unknown order"]}`,
+			wantError: true,
+		}, {
+			name:      "error status",
+			response:  `{"error":[],"result":{"status":"err","error_message":"order is partially filled"}}`,
+			wantError: true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			var response krakenEditOrderResponse
+			if !assert.NoError(t, json.Unmarshal([]byte(k.response), &response)) {
+				return
+			}
+
+			txID, e := readKrakenEditOrderResponse(&response, model.MakeTransactionID("OABC-123"))
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if assert.NoError(t, e) {
+				assert.Equal(t, k.wantTxID, txID.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package plugins
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// krakenPrivatePathPrefix is the path prefix of the private REST methods of kraken, which need a nonce
+const krakenPrivatePathPrefix = "/0/private/"
+
+// krakenNonceTransport is the transport of all requests made with one API key. Kraken needs the nonces of the requests of a key to be
+// strictly increasing in the order they arrive, but the kraken client and privateRequest both make their own nonces from the clock and
+// send requests concurrently. This transport replaces the nonce of every private request with the next value of one monotonic source,
+// signs the request again and sends the private requests of the key one at a time so they arrive in the order of their nonces.
+type krakenNonceTransport struct {
+	secret string // base64 encoded API secret
+	inner  http.RoundTripper
+	now    func() time.Time
+
+	// uninitialized
+	mutex     *sync.Mutex
+	lastNonce int64
+}
+
+// ensure it is a RoundTripper
+var _ http.RoundTripper = &krakenNonceTransport{}
+
+// makeKrakenNonceTransport is a factory method
+func makeKrakenNonceTransport(secret string, inner http.RoundTripper) *krakenNonceTransport {
+	return &krakenNonceTransport{
+		secret: secret,
+		inner:  inner,
+		now:    time.Now,
+		mutex:  &sync.Mutex{},
+	}
+}
+
+// makeKrakenHTTPClient returns the client used for all requests made with the API secret
+func makeKrakenHTTPClient(secret string) *http.Client {
+	return &http.Client{Transport: makeKrakenNonceTransport(secret, http.DefaultTransport)}
+}
+
+// nextNonce returns a nonce that is greater than all the nonces returned before, needs the mutex to be held
+func (t *krakenNonceTransport) nextNonce() int64 {
+	nonce := t.now().UnixNano()
+	if nonce <= t.lastNonce {
+		nonce = t.lastNonce + 1
+	}
+	t.lastNonce = nonce
+	return nonce
+}
+
+// RoundTrip impl, public requests are sent as they are
+func (t *krakenNonceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != "POST" || !strings.HasPrefix(req.URL.Path, krakenPrivatePathPrefix) {
+		return t.inner.RoundTrip(req)
+	}
+
+	secret, e := base64.StdEncoding.DecodeString(t.secret)
+	if e != nil {
+		return nil, fmt.Errorf("unable to decode API secret: %s", e)
+	}
+	body := []byte{}
+	if req.Body != nil {
+		body, e = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if e != nil {
+			return nil, fmt.Errorf("unable to read body of kraken request: %s", e)
+		}
+	}
+	values, e := url.ParseQuery(string(body))
+	if e != nil {
+		return nil, fmt.Errorf("unable to parse body of kraken request: %s", e)
+	}
+
+	// the lock is held until the response arrives so the next request of the key is sent after this one was received
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	nonce := strconv.FormatInt(t.nextNonce(), 10)
+	values.Set("nonce", nonce)
+	data := values.Encode()
+
+	// a RoundTripper must not modify the request it is given
+	signed := new(http.Request)
+	*signed = *req
+	signed.Header = http.Header{}
+	for k, v := range req.Header {
+		signed.Header[k] = append([]string{}, v...)
+	}
+	signed.Header.Set("API-Sign", signKrakenRequest(req.URL.Path, nonce, data, secret))
+	signed.Body = ioutil.NopCloser(strings.NewReader(data))
+	signed.ContentLength = int64(len(data))
+	return t.inner.RoundTrip(signed)
+}
@@ -0,0 +1,73 @@
+package plugins
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingTransport records the requests it is given and responds with an empty JSON object
+type recordingTransport struct {
+	requests []*http.Request
+	bodies   []string
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body := ""
+	if req.Body != nil {
+		b, e := ioutil.ReadAll(req.Body)
+		if e != nil {
+			return nil, e
+		}
+		body = string(b)
+	}
+	t.requests = append(t.requests, req)
+	t.bodies = append(t.bodies, body)
+	return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader("{}")), Request: req}, nil
+}
+
+func TestKrakenNonceTransport(t *testing.T) {
+	secret := []byte("secret")
+	inner := &recordingTransport{}
+	transport := makeKrakenNonceTransport(base64.StdEncoding.EncodeToString(secret), inner)
+	// the clock goes backwards after the first request
+	times := []time.Time{time.Unix(0, 2000), time.Unix(0, 1000), time.Unix(0, 3000)}
+	transport.now = func() time.Time {
+		t := times[0]
+		times = times[1:]
+		return t
+	}
+
+	post := func(path string, body string) {
+		req, e := http.NewRequest("POST", "https://api.kraken.com"+path, strings.NewReader(body))
+		if assert.NoError(t, e) {
+			_, e = transport.RoundTrip(req)
+			assert.NoError(t, e)
+		}
+	}
+	post("/0/private/AddOrder", "nonce=5000&pair=XLMUSD")
+	post("/0/private/GetWebSocketsToken", "nonce=1")
+	post("/0/public/Time", "nonce=7")
+	post("/0/private/CancelOrder", "txid=abc")
+
+	nonces := []string{}
+	for i, body := range inner.bodies {
+		values, e := url.ParseQuery(body)
+		if !assert.NoError(t, e) {
+			return
+		}
+		nonces = append(nonces, values.Get("nonce"))
+		if strings.HasPrefix(inner.requests[i].URL.Path, krakenPrivatePathPrefix) {
+			assert.Equal(t, signKrakenRequest(inner.requests[i].URL.Path, values.Get("nonce"), body, secret), inner.requests[i].Header.Get("API-Sign"))
+		}
+	}
+	// the nonces of private requests are strictly increasing, public requests are sent as they are
+	assert.Equal(t, []string{"2000", "2001", "7", "3000"}, nonces)
+	assert.Equal(t, "pair=XLMUSD", strings.Split(inner.bodies[0], "&")[1])
+}
@@ -0,0 +1,38 @@
+package plugins
+
+import (
+	"strings"
+)
+
+// krakenOrderFlagsArg is the arg of the AddOrder endpoint that holds a comma-separated list of order flags, such as "post" for post-only
+// orders and "fciq" to prefer paying the fee in the quote currency
+const krakenOrderFlagsArg = "oflags"
+
+// withKrakenOrderParams adds the params to the args of the AddOrder endpoint, the order flags in the params are added to the order flags
+// that are already set instead of replacing them
+func withKrakenOrderParams(args map[string]string, params map[string]string) map[string]string {
+	for k, v := range params {
+		existing, ok := args[k]
+		if k == krakenOrderFlagsArg && ok && existing != "" {
+			flags := strings.Split(existing, ",")
+			for _, flag := range strings.Split(v, ",") {
+				if flag != "" && !stringsContain(flags, flag) {
+					flags = append(flags, flag)
+				}
+			}
+			args[k] = strings.Join(flags, ",")
+			continue
+		}
+		args[k] = v
+	}
+	return args
+}
+
+func stringsContain(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+package plugins
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/stellar/kelp/model"
+)
+
+// krakenMaxTxIDsPerQuery is the max number of IDs that can be passed to the QueryTrades method of the kraken API in one request
+const krakenMaxTxIDsPerQuery = 20
+
+// GetTradesForOrder impl, the IDs of the trades of the order are looked up with the QueryOrders method and the trades are fetched with the
+// QueryTrades method
+func (k *krakenExchange) GetTradesForOrder(txID *model.TransactionID, pair model.TradingPair) ([]model.Trade, error) {
+	if k.isSimulated {
+		return []model.Trade{}, nil
+	}
+
+	resp, e := k.nextAPI().Query("QueryOrders", map[string]string{
+		"txid":   txID.String(),
+		"trades": "true",
+	})
+	if e != nil {
+		return nil, fmt.Errorf("error querying order '%s': %s", txID.String(), e)
+	}
+	tradeIDs, e := krakenOrderTradeIDs(resp, txID.String())
+	if e != nil {
+		return nil, e
+	}
+
+	trades := []model.Trade{}
+	for start := 0; start < len(tradeIDs); start += krakenMaxTxIDsPerQuery {
+		end := start + krakenMaxTxIDsPerQuery
+		if end > len(tradeIDs) {
+			end = len(tradeIDs)
+		}
+
+		resp, e := k.nextAPI().Query("QueryTrades", map[string]string{
+			"txid": strings.Join(tradeIDs[start:end], ","),
+		})
+		if e != nil {
+			return nil, fmt.Errorf("error querying trades of order '%s': %s", txID.String(), e)
+		}
+		batch, e := k.readOrderTrades(resp, txID.String(), pair)
+		if e != nil {
+			return nil, e
+		}
+		trades = append(trades, batch...)
+	}
+
+	// sort to be in ascending order
+	sort.Sort(model.TradesByTsID(trades))
+	return trades, nil
+}
+
+// krakenOrderTradeIDs returns the IDs of the trades of the order in the response of the QueryOrders method
+func krakenOrderTradeIDs(resp interface{}, orderID string) ([]string, error) {
+	orders, ok := resp.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected response from QueryOrders: %v", resp)
+	}
+	order, ok := orders[orderID].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("order '%s' not found in response from QueryOrders", orderID)
+	}
+
+	// orders without trades do not have the trades field
+	rawIDs, _ := order["trades"].([]interface{})
+	tradeIDs := []string{}
+	for _, raw := range rawIDs {
+		id, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid trade ID of order '%s': %v", orderID, raw)
+		}
+		tradeIDs = append(tradeIDs, id)
+	}
+	return tradeIDs, nil
+}
+
+// readOrderTrades converts the trades in the response of the QueryTrades method in the same way as getTradeHistory converts the trades in
+// the response of the TradesHistory method, returns an error if a trade does not belong to the order or the pair
+func (k *krakenExchange) readOrderTrades(resp interface{}, orderID string, tradingPair model.TradingPair) ([]model.Trade, error) {
+	krakenTrades, ok := resp.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected response from QueryTrades: %v", resp)
+	}
+
+	trades := []model.Trade{}
+	for tradeID, v := range krakenTrades {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid trade '%s' in response from QueryTrades: %v", tradeID, v)
+		}
+		fields := map[string]string{}
+		for _, field := range []string{"ordertxid", "pair", "type", "ordertype", "price", "cost", "fee", "vol"} {
+			s, ok := m[field].(string)
+			if !ok {
+				return nil, fmt.Errorf("missing field '%s' in trade '%s'", field, tradeID)
+			}
+			fields[field] = s
+		}
+		_time, ok := m["time"].(float64)
+		if !ok {
+			return nil, fmt.Errorf("missing field 'time' in trade '%s'", tradeID)
+		}
+
+		if fields["ordertxid"] != orderID {
+			return nil, fmt.Errorf("trade '%s' belongs to order '%s' instead of order '%s'", tradeID, fields["ordertxid"], orderID)
+		}
+		pair, e := model.TradingPairFromString(4, k.assetConverter, fields["pair"])
+		if e != nil {
+			return nil, fmt.Errorf("error parsing trading pair '%s' of trade '%s': %s", fields["pair"], tradeID, e)
+		}
+		if *pair != tradingPair {
+			return nil, fmt.Errorf("trade '%s' is on trading pair %s instead of %s", tradeID, pair, &tradingPair)
+		}
+
+		orderConstraints := k.GetOrderConstraints(pair)
+		// for now use the max precision between price and volume for fee and cost
+		feeCostPrecision := orderConstraints.PricePrecision
+		if orderConstraints.VolumePrecision > feeCostPrecision {
+			feeCostPrecision = orderConstraints.VolumePrecision
+		}
+
+		numbers := map[string]*model.Number{}
+		for field, precision := range map[string]int8{
+			"price": orderConstraints.PricePrecision,
+			"vol":   orderConstraints.VolumePrecision,
+			"cost":  feeCostPrecision,
+			"fee":   feeCostPrecision,
+		} {
+			n, e := model.NumberFromString(fields[field], precision)
+			if e != nil {
+				return nil, fmt.Errorf("error parsing %s of trade '%s': %s", field, tradeID, e)
+			}
+			numbers[field] = n
+		}
+
+		trades = append(trades, model.Trade{
+			Order: model.Order{
+				Pair:        pair,
+				OrderAction: model.OrderActionFromString(fields["type"]),
+				OrderType:   model.OrderTypeFromString(fields["ordertype"]),
+				Price:       numbers["price"],
+				Volume:      numbers["vol"],
+				Timestamp:   model.MakeTimestamp(int64(_time)),
+			},
+			TransactionID: model.MakeTransactionID(tradeID),
+			Cost:          numbers["cost"],
+			Fee:           numbers["fee"],
+			FeeAsset:      pair.Quote, // kraken reports the fee in the quote currency of the pair
+		})
+	}
+	return trades, nil
+}
@@ -0,0 +1,68 @@
+package plugins
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKrakenOrderTradeIDs(t *testing.T) {
+	var resp interface{}
+	e := json.Unmarshal([]byte(`{"OQCLML-BW3P3-BUCMWZ":{"status":"closed","vol":"150.00000000","trades":["TDLH43-DVQXD-2KHVYY","TEF5IH-XB3S6-6XZJXS"]},"OWQKT5-QEWLT-4LJBUZ":{"status":"open","vol":"10.00000000"}}`), &resp)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	tradeIDs, e := krakenOrderTradeIDs(resp, "OQCLML-BW3P3-BUCMWZ")
+	if assert.NoError(t, e) {
+		assert.Equal(t, []string{"TDLH43-DVQXD-2KHVYY", "TEF5IH-XB3S6-6XZJXS"}, tradeIDs)
+	}
+
+	tradeIDs, e = krakenOrderTradeIDs(resp, "OWQKT5-QEWLT-4LJBUZ")
+	if assert.NoError(t, e) {
+		assert.Equal(t, []string{}, tradeIDs)
+	}
+
+	_, e = krakenOrderTradeIDs(resp, "OTHER")
+	assert.Error(t, e)
+}
+
+func TestKrakenReadOrderTrades(t *testing.T) {
+	k := testKrakenExchange.(*krakenExchange)
+	pair := model.TradingPair{Base: model.XLM, Quote: model.USD}
+	var resp interface{}
+	e := json.Unmarshal([]byte(`{`+
+		`"TEF5IH-XB3S6-6XZJXS":{"ordertxid":"OQCLML-BW3P3-BUCMWZ","pair":"XXLMZUSD","time":1560516024.0706,"type":"sell","ordertype":"limit","price":"0.101000","cost":"5.050000","fee":"0.008080","vol":"50.00000000"},`+
+		`"TDLH43-DVQXD-2KHVYY":{"ordertxid":"OQCLML-BW3P3-BUCMWZ","pair":"XXLMZUSD","time":1560516023.0706,"type":"sell","ordertype":"limit","price":"0.100000","cost":"10.000000","fee":"0.016000","vol":"100.00000000"}`+
+		`}`), &resp)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	trades, e := k.readOrderTrades(resp, "OQCLML-BW3P3-BUCMWZ", pair)
+	if !assert.NoError(t, e) {
+		return
+	}
+	if !assert.Equal(t, 2, len(trades)) {
+		return
+	}
+	for _, trade := range trades {
+		assert.Equal(t, pair, *trade.Pair)
+		assert.Equal(t, model.OrderActionSell, trade.OrderAction)
+		assert.Equal(t, model.OrderTypeLimit, trade.OrderType)
+		if trade.TransactionID.String() == "TDLH43-DVQXD-2KHVYY" {
+			assert.Equal(t, 0.1, trade.Price.AsFloat())
+			assert.Equal(t, 100.0, trade.Volume.AsFloat())
+			assert.Equal(t, 10.0, trade.Cost.AsFloat())
+			assert.Equal(t, 0.016, trade.Fee.AsFloat())
+			assert.Equal(t, int64(1560516023), trade.Timestamp.AsInt64())
+		}
+	}
+
+	_, e = k.readOrderTrades(resp, "OWQKT5-QEWLT-4LJBUZ", pair)
+	assert.Error(t, e, "trades of another order should be rejected")
+	_, e = k.readOrderTrades(resp, "OQCLML-BW3P3-BUCMWZ", model.TradingPair{Base: model.XLM, Quote: model.BTC})
+	assert.Error(t, e, "trades on another pair should be rejected")
+}
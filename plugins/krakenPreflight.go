@@ -0,0 +1,61 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// ensure that krakenExchange reports its time and can validate orders
+var _ api.ServerClock = &krakenExchange{}
+var _ api.OrderValidator = &krakenExchange{}
+
+// GetServerTime impl.
+func (k *krakenExchange) GetServerTime() (time.Time, error) {
+	resp, e := k.nextAPI().Time()
+	if e != nil {
+		return time.Time{}, fmt.Errorf("error fetching the server time: %s", e)
+	}
+	return time.Unix(resp.Unixtime, 0), nil
+}
+
+// ValidateOrder impl, submits the order with the validate flag so kraken checks the order and the permissions of the API key without
+// placing it. This is also done in simulation mode since the order is never placed.
+func (k *krakenExchange) ValidateOrder(order *model.Order) error {
+	pairStr, e := order.Pair.ToString(k.assetConverter, k.delimiter)
+	if e != nil {
+		return e
+	}
+
+	args, e := krakenValidateOrderArgs(order, k.GetOrderConstraints(order.Pair), mergeOrderParams(k.orderParams, order))
+	if e != nil {
+		return e
+	}
+	log.Printf("kraken is validating order: pair=%s, orderAction=%s, orderType=%s, volume=%s, args=%v\n",
+		pairStr, order.OrderAction.String(), order.OrderType.String(), order.Volume.AsString(), args)
+	_, e = k.nextAPI().AddOrder(
+		pairStr,
+		order.OrderAction.String(),
+		order.OrderType.String(),
+		order.Volume.AsString(),
+		args,
+	)
+	if e != nil {
+		return fmt.Errorf("kraken rejected the order %s: %s", *order, e)
+	}
+	return nil
+}
+
+// krakenValidateOrderArgs returns the args of the AddOrder endpoint, including the order params, with the flag that only validates the order
+func krakenValidateOrderArgs(order *model.Order, orderConstraints *model.OrderConstraints, orderParams map[string]string) (map[string]string, error) {
+	args, e := krakenOrderArgs(order, orderConstraints)
+	if e != nil {
+		return nil, e
+	}
+	args = withKrakenOrderParams(args, orderParams)
+	args["validate"] = "true"
+	return args, nil
+}
@@ -0,0 +1,257 @@
+package plugins
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Beldur/kraken-go-api-client"
+	"github.com/gorilla/websocket"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/networking"
+)
+
+// krakenWebSocketURL is the endpoint of the authenticated websocket API of Kraken
+const krakenWebSocketURL = "wss://ws-auth.kraken.com"
+
+// krakenWebSocketsTokenPath is the private REST method used to get a token for the authenticated websocket API, the kraken client library
+// does not support this method so we sign the request ourselves
+const krakenWebSocketsTokenPath = "/0/private/GetWebSocketsToken"
+
+// krakenWebSocketReadTimeout fails the stream when no message is received, kraken sends a heartbeat every second on an active subscription
+const krakenWebSocketReadTimeout = 30 * time.Second
+
+// ensure that krakenExchange implements TradeStream
+var _ api.TradeStream = &krakenExchange{}
+
+// krakenWebSocketsTokenResponse is the response of the GetWebSocketsToken method
+type krakenWebSocketsTokenResponse struct {
+	Error  []string `json:"error"`
+	Result struct {
+		Token string `json:"token"`
+	} `json:"result"`
+}
+
+// krakenWSEvent is the general shape of the non-data messages on the websocket API
+type krakenWSEvent struct {
+	Event        string `json:"event"`
+	Status       string `json:"status"`
+	ErrorMessage string `json:"errorMessage"`
+}
+
+// StreamTrades impl, it subscribes to the ownTrades feed of the websocket API
+func (k *krakenExchange) StreamTrades(pair *model.TradingPair, handler func(trade model.Trade)) error {
+	// the websocket API uses the same asset names as the open orders API, with a '/' delimiter
+	wsPair, e := pair.ToString(k.assetConverterOpenOrders, "/")
+	if e != nil {
+		return fmt.Errorf("unable to convert pair to the format of the kraken websocket API: %s", e)
+	}
+
+	token, e := k.getWebSocketsToken()
+	if e != nil {
+		return fmt.Errorf("unable to get token for the kraken websocket API: %s", e)
+	}
+
+	conn, _, e := websocket.DefaultDialer.Dial(krakenWebSocketURL, nil)
+	if e != nil {
+		return fmt.Errorf("unable to connect to the kraken websocket API: %s", e)
+	}
+	defer conn.Close()
+
+	e = conn.WriteJSON(map[string]interface{}{
+		"event": "subscribe",
+		"subscription": map[string]interface{}{
+			"name":  "ownTrades",
+			"token": token,
+			// trades that happened before the subscription are picked up by polling the trade history
+			"snapshot": false,
+		},
+	})
+	if e != nil {
+		return fmt.Errorf("unable to subscribe to the ownTrades feed of the kraken websocket API: %s", e)
+	}
+	log.Printf("subscribed to the ownTrades feed of the kraken websocket API for pair %s\n", wsPair)
+
+	for {
+		e = conn.SetReadDeadline(time.Now().Add(krakenWebSocketReadTimeout))
+		if e != nil {
+			return fmt.Errorf("unable to set read deadline on the kraken websocket connection: %s", e)
+		}
+		_, message, e := conn.ReadMessage()
+		if e != nil {
+			return fmt.Errorf("error reading from the kraken websocket API: %s", e)
+		}
+
+		trades, e := k.parseWSMessage(message, wsPair)
+		if e != nil {
+			return e
+		}
+		for _, t := range trades {
+			handler(t)
+		}
+	}
+}
+
+// parseWSMessage returns the trades on the pair in a message from the websocket API, or an error if the message reports a failure
+func (k *krakenExchange) parseWSMessage(message []byte, wsPair string) ([]model.Trade, error) {
+	if strings.HasPrefix(strings.TrimSpace(string(message)), "{") {
+		var event krakenWSEvent
+		e := json.Unmarshal(message, &event)
+		if e != nil {
+			return nil, fmt.Errorf("unable to parse event from the kraken websocket API (%s): %s", string(message), e)
+		}
+		if event.Status == "error" || event.Event == "error" {
+			return nil, fmt.Errorf("error event from the kraken websocket API: %s", event.ErrorMessage)
+		}
+		// heartbeats, system status and subscription status updates do not contain any trades
+		return []model.Trade{}, nil
+	}
+
+	// data messages are formatted as [[{tradeID: {...}}, ...], "ownTrades", {"sequence": n}]
+	var data []json.RawMessage
+	e := json.Unmarshal(message, &data)
+	if e != nil {
+		return nil, fmt.Errorf("unable to parse message from the kraken websocket API (%s): %s", string(message), e)
+	}
+	if len(data) < 2 || string(data[1]) != `"ownTrades"` {
+		return []model.Trade{}, nil
+	}
+
+	var tradeMaps []map[string]map[string]string
+	e = json.Unmarshal(data[0], &tradeMaps)
+	if e != nil {
+		return nil, fmt.Errorf("unable to parse trades from the ownTrades feed of the kraken websocket API (%s): %s", string(message), e)
+	}
+
+	trades := []model.Trade{}
+	for _, tradeMap := range tradeMaps {
+		for tradeID, m := range tradeMap {
+			if m["pair"] != wsPair {
+				continue
+			}
+
+			trade, e := k.parseWSTrade(tradeID, m)
+			if e != nil {
+				return nil, fmt.Errorf("unable to parse trade '%s' from the ownTrades feed of the kraken websocket API: %s", tradeID, e)
+			}
+			trades = append(trades, *trade)
+		}
+	}
+	sort.Sort(model.TradesByTsID(trades))
+	return trades, nil
+}
+
+// parseWSTrade converts a trade from the ownTrades feed in the same way as getTradeHistory converts a trade from the REST API
+func (k *krakenExchange) parseWSTrade(tradeID string, m map[string]string) (*model.Trade, error) {
+	assets := strings.Split(m["pair"], "/")
+	if len(assets) != 2 {
+		return nil, fmt.Errorf("invalid format of trading pair '%s'", m["pair"])
+	}
+	base, e := k.assetConverterOpenOrders.FromString(assets[0])
+	if e != nil {
+		return nil, fmt.Errorf("error parsing base asset of trading pair '%s': %s", m["pair"], e)
+	}
+	quote, e := k.assetConverterOpenOrders.FromString(assets[1])
+	if e != nil {
+		return nil, fmt.Errorf("error parsing quote asset of trading pair '%s': %s", m["pair"], e)
+	}
+	pair := &model.TradingPair{Base: base, Quote: quote}
+	_time, e := strconv.ParseFloat(m["time"], 64)
+	if e != nil {
+		return nil, fmt.Errorf("error parsing time '%s': %s", m["time"], e)
+	}
+
+	orderConstraints := k.GetOrderConstraints(pair)
+	// for now use the max precision between price and volume for fee and cost
+	feeCostPrecision := orderConstraints.PricePrecision
+	if orderConstraints.VolumePrecision > feeCostPrecision {
+		feeCostPrecision = orderConstraints.VolumePrecision
+	}
+
+	price, e := model.NumberFromString(m["price"], orderConstraints.PricePrecision)
+	if e != nil {
+		return nil, fmt.Errorf("error parsing price: %s", e)
+	}
+	volume, e := model.NumberFromString(m["vol"], orderConstraints.VolumePrecision)
+	if e != nil {
+		return nil, fmt.Errorf("error parsing volume: %s", e)
+	}
+	cost, e := model.NumberFromString(m["cost"], feeCostPrecision)
+	if e != nil {
+		return nil, fmt.Errorf("error parsing cost: %s", e)
+	}
+	fee, e := model.NumberFromString(m["fee"], feeCostPrecision)
+	if e != nil {
+		return nil, fmt.Errorf("error parsing fee: %s", e)
+	}
+
+	return &model.Trade{
+		Order: model.Order{
+			Pair:        pair,
+			OrderAction: model.OrderActionFromString(m["type"]),
+			OrderType:   model.OrderTypeFromString(m["ordertype"]),
+			Price:       price,
+			Volume:      volume,
+			// use seconds to be consistent with the timestamps of trades from getTradeHistory
+			Timestamp: model.MakeTimestamp(int64(_time)),
+		},
+		TransactionID: model.MakeTransactionID(tradeID),
+		Cost:          cost,
+		Fee:           fee,
+	}, nil
+}
+
+// getWebSocketsToken fetches a token for the authenticated websocket API using the first API key
+func (k *krakenExchange) getWebSocketsToken() (string, error) {
+	var response krakenWebSocketsTokenResponse
+	e := k.privateRequest(krakenWebSocketsTokenPath, url.Values{}, &response)
+	if e != nil {
+		return "", e
+	}
+	if len(response.Error) > 0 {
+		return "", fmt.Errorf("error response: %v", response.Error)
+	}
+	if response.Result.Token == "" {
+		return "", fmt.Errorf("empty token in response")
+	}
+	return response.Result.Token, nil
+}
+
+// privateRequest makes a request to a private REST method that the kraken client does not support using the first API key, the
+// response is decoded into the response param. The request is sent with the client of the key, which sets the nonce and signs the request.
+func (k *krakenExchange) privateRequest(urlPath string, values url.Values, response interface{}) error {
+	if len(k.apiKeys) == 0 || len(k.httpClients) == 0 {
+		return fmt.Errorf("no API keys available")
+	}
+
+	return networking.JSONRequest(
+		k.httpClients[0],
+		"POST",
+		krakenapi.APIURL+urlPath,
+		values.Encode(),
+		map[string]string{
+			"API-Key":      k.apiKeys[0].Key,
+			"Content-Type": "application/x-www-form-urlencoded",
+		},
+		response,
+		"",
+	)
+}
+
+// signKrakenRequest signs a request to a private REST method as HMAC-SHA512(urlPath + SHA256(nonce + data)) with the decoded API secret
+func signKrakenRequest(urlPath string, nonce string, data string, secret []byte) string {
+	sha := sha256.Sum256([]byte(nonce + data))
+	mac := hmac.New(sha512.New, secret)
+	mac.Write(append([]byte(urlPath), sha[:]...))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,54 @@
+package plugins
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKrakenParseWSMessage(t *testing.T) {
+	k := testKrakenExchange.(*krakenExchange)
+	message := `[[{"TDLH43-DVQXD-2KHVYY":{"cost":"10.000000","fee":"0.016000","margin":"0.00000","ordertxid":"OQCLML-BW3P3-BUCMWZ","ordertype":"limit","pair":"XLM/USD","postxid":"OGTT3Y-C6I3P-XRI6HX","price":"0.100000","time":"1560516023.070651","type":"sell","vol":"100.00000000"}},` +
+		`{"TEF5IH-XB3S6-6XZJXS":{"cost":"1.000000","fee":"0.001600","margin":"0.00000","ordertxid":"OWQKT5-QEWLT-4LJBUZ","ordertype":"limit","pair":"XBT/USD","postxid":"OGTT3Y-C6I3P-XRI6HX","price":"10000.0","time":"1560516024.070651","type":"buy","vol":"0.00010000"}}],` +
+		`"ownTrades",{"sequence":2948}]`
+
+	trades, e := k.parseWSMessage([]byte(message), "XLM/USD")
+	if !assert.NoError(t, e) {
+		return
+	}
+	if !assert.Equal(t, 1, len(trades)) {
+		return
+	}
+
+	trade := trades[0]
+	assert.Equal(t, "TDLH43-DVQXD-2KHVYY", trade.TransactionID.String())
+	assert.Equal(t, model.TradingPair{Base: model.XLM, Quote: model.USD}, *trade.Pair)
+	assert.Equal(t, model.OrderActionSell, trade.OrderAction)
+	assert.Equal(t, model.OrderTypeLimit, trade.OrderType)
+	assert.Equal(t, 0.1, trade.Price.AsFloat())
+	assert.Equal(t, 100.0, trade.Volume.AsFloat())
+	assert.Equal(t, 10.0, trade.Cost.AsFloat())
+	assert.Equal(t, 0.016, trade.Fee.AsFloat())
+	assert.Equal(t, int64(1560516023), trade.Timestamp.AsInt64())
+}
+
+func TestKrakenParseWSMessageEvents(t *testing.T) {
+	k := testKrakenExchange.(*krakenExchange)
+
+	trades, e := k.parseWSMessage([]byte(`{"event":"heartbeat"}`), "XLM/USD")
+	if assert.NoError(t, e) {
+		assert.Equal(t, 0, len(trades))
+	}
+
+	_, e = k.parseWSMessage([]byte(`{"event":"subscriptionStatus","status":"error","errorMessage":"EGeneral:Invalid arguments"}`), "XLM/USD")
+	assert.Error(t, e)
+}
+
+func TestSignKrakenRequest(t *testing.T) {
+	// same test vector as the signature of the kraken client library
+	secret, _ := base64.StdEncoding.DecodeString("SECRET")
+	sig := signKrakenRequest("/0/private/", "", "TestKey=TestValue", secret)
+	assert.Equal(t, "Uog0MyIKZmXZ4/VFOh0g1u2U+A0ohuK8oCh0HFUiHLE2Csm23CuPCDaPquh/hpnAg/pSQLeXyBELpJejgOftCQ==", sig)
+}
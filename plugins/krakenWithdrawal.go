@@ -0,0 +1,152 @@
+package plugins
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/networking"
+)
+
+// krakenWithdrawKeyParamPrefix marks the exchange params that map a withdrawal address to the name of the withdrawal key on kraken, the
+// param is "withdraw_key/<asset>/<address>[/<memo>]" and the value is the name of the key that was set up on kraken for that address
+const krakenWithdrawKeyParamPrefix = "withdraw_key/"
+
+// ensure that krakenExchange can withdraw to an address with a memo
+var _ api.WithdrawalExchange = &krakenExchange{}
+
+// parseKrakenWithdrawKeys reads the withdrawal keys from the exchange params, other params are ignored
+func parseKrakenWithdrawKeys(exchangeParams []api.ExchangeParam) (asset2Address2Key, error) {
+	keys := asset2Address2Key{}
+	for _, p := range exchangeParams {
+		if !strings.HasPrefix(p.Param, krakenWithdrawKeyParamPrefix) {
+			continue
+		}
+
+		parts := strings.SplitN(strings.TrimPrefix(p.Param, krakenWithdrawKeyParamPrefix), "/", 3)
+		if len(parts) < 2 || parts[0] == "" || parts[1] == "" || p.Value == "" {
+			return nil, fmt.Errorf("invalid withdraw key param '%s' (value='%s'), needs to be '%s<asset>/<address>[/<memo>]' with the name of the kraken withdrawal key as the value",
+				p.Param, p.Value, krakenWithdrawKeyParamPrefix)
+		}
+		memo := ""
+		if len(parts) == 3 {
+			memo = parts[2]
+		}
+
+		asset := model.Asset(parts[0])
+		if _, ok := keys[asset]; !ok {
+			keys[asset] = map[string]string{}
+		}
+		keys[asset][krakenWithdrawDestination(parts[1], memo)] = p.Value
+	}
+	return keys, nil
+}
+
+// krakenWithdrawDestination is the lookup key of a withdrawal key, kraken sets up a separate withdrawal key for each address and memo
+func krakenWithdrawDestination(address string, memo string) string {
+	if memo == "" {
+		return address
+	}
+	return address + "/" + memo
+}
+
+// Withdraw impl.
+func (k *krakenExchange) Withdraw(asset model.Asset, amount *model.Number, address string, memo string) (*api.Withdrawal, error) {
+	w, e := k.WithdrawFunds(asset, amount, krakenWithdrawDestination(address, memo))
+	if e != nil {
+		return nil, fmt.Errorf("unable to withdraw %s %s to address '%s' (memo='%s'): %s", amount.AsString(), asset, address, memo, e)
+	}
+
+	return &api.Withdrawal{
+		ID:      w.WithdrawalID,
+		Asset:   asset,
+		Amount:  amount,
+		Address: address,
+		Memo:    memo,
+		Status:  api.WithdrawalStatusPending,
+	}, nil
+}
+
+// GetWithdrawal impl.
+func (k *krakenExchange) GetWithdrawal(asset model.Asset, withdrawalID string) (*api.Withdrawal, error) {
+	krakenAsset, e := k.assetConverter.ToString(asset)
+	if e != nil {
+		return nil, e
+	}
+
+	resp, e := k.nextAPI().Query(
+		"WithdrawStatus",
+		map[string]string{
+			"asset": krakenAsset,
+		},
+	)
+	if e != nil {
+		return nil, fmt.Errorf("unable to fetch status of withdrawals of %s: %s", asset, e)
+	}
+
+	return parseWithdrawStatusResponse(resp, asset, withdrawalID)
+}
+
+// parseWithdrawStatusResponse finds the withdrawal in the recent withdrawals returned by kraken
+func parseWithdrawStatusResponse(resp interface{}, asset model.Asset, withdrawalID string) (*api.Withdrawal, error) {
+	list, ok := resp.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("could not parse response type from WithdrawStatus: %s", reflect.TypeOf(resp))
+	}
+
+	for _, elem := range list {
+		m, ok := elem.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("could not parse withdrawal type from WithdrawStatus: %s", reflect.TypeOf(elem))
+		}
+		refid, e := networking.ParseString(m, "refid", "WithdrawStatus")
+		if e != nil {
+			return nil, e
+		}
+		if refid != withdrawalID {
+			continue
+		}
+
+		status, e := networking.ParseString(m, "status", "WithdrawStatus")
+		if e != nil {
+			return nil, e
+		}
+		statusProp, _ := m["status-prop"].(string)
+		txID, _ := m["txid"].(string)
+		address, _ := m["info"].(string)
+		var amount *model.Number
+		if amountString, ok := m["amount"].(string); ok {
+			amount, e = model.NumberFromString(amountString, precisionBalances)
+			if e != nil {
+				return nil, fmt.Errorf("could not parse amount of withdrawal '%s': %s", withdrawalID, e)
+			}
+		}
+
+		return &api.Withdrawal{
+			ID:      refid,
+			Asset:   asset,
+			Amount:  amount,
+			Address: address,
+			Status:  krakenWithdrawalStatus(status, statusProp),
+			TxID:    txID,
+		}, nil
+	}
+	return nil, fmt.Errorf("withdrawal '%s' of %s is not in the recent withdrawals returned by kraken", withdrawalID, asset)
+}
+
+// krakenWithdrawalStatus maps the status and the additional status property of a kraken withdrawal
+func krakenWithdrawalStatus(status string, statusProp string) api.WithdrawalStatus {
+	if statusProp == "canceled" || statusProp == "return" {
+		return api.WithdrawalStatusFailed
+	}
+	switch status {
+	case "Success":
+		return api.WithdrawalStatusComplete
+	case "Failure":
+		return api.WithdrawalStatusFailed
+	default:
+		return api.WithdrawalStatusPending
+	}
+}
@@ -0,0 +1,60 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKrakenWithdrawKeys(t *testing.T) {
+	keys, e := parseKrakenWithdrawKeys([]api.ExchangeParam{
+		{Param: "withdraw_key/XLM/GABC", Value: "trading account"},
+		{Param: "withdraw_key/XLM/GDEF/12345", Value: "exchange deposit"},
+		{Param: "chaos_fail_rate", Value: "0.1"},
+	})
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	key, e := keys.getKey(model.XLM, krakenWithdrawDestination("GABC", ""))
+	assert.NoError(t, e)
+	assert.Equal(t, "trading account", key)
+	key, e = keys.getKey(model.XLM, krakenWithdrawDestination("GDEF", "12345"))
+	assert.NoError(t, e)
+	assert.Equal(t, "exchange deposit", key)
+	_, e = keys.getKey(model.XLM, krakenWithdrawDestination("GDEF", ""))
+	assert.Error(t, e)
+
+	_, e = parseKrakenWithdrawKeys([]api.ExchangeParam{{Param: "withdraw_key/XLM", Value: "key"}})
+	assert.Error(t, e)
+	_, e = parseKrakenWithdrawKeys([]api.ExchangeParam{{Param: "withdraw_key/XLM/GABC", Value: ""}})
+	assert.Error(t, e)
+}
+
+func TestParseWithdrawStatusResponse(t *testing.T) {
+	resp := []interface{}{
+		map[string]interface{}{"refid": "A1", "status": "Success", "txid": "hash1", "info": "GABC", "amount": "10.5"},
+		map[string]interface{}{"refid": "A2", "status": "Pending", "info": "GABC", "amount": "3"},
+		map[string]interface{}{"refid": "A3", "status": "Pending", "status-prop": "canceled", "amount": "3"},
+	}
+
+	w, e := parseWithdrawStatusResponse(resp, model.XLM, "A1")
+	if assert.NoError(t, e) {
+		assert.Equal(t, api.WithdrawalStatusComplete, w.Status)
+		assert.Equal(t, "hash1", w.TxID)
+		assert.Equal(t, "GABC", w.Address)
+		assert.Equal(t, 10.5, w.Amount.AsFloat())
+	}
+	w, e = parseWithdrawStatusResponse(resp, model.XLM, "A2")
+	if assert.NoError(t, e) {
+		assert.Equal(t, api.WithdrawalStatusPending, w.Status)
+	}
+	w, e = parseWithdrawStatusResponse(resp, model.XLM, "A3")
+	if assert.NoError(t, e) {
+		assert.Equal(t, api.WithdrawalStatusFailed, w.Status)
+	}
+	_, e = parseWithdrawStatusResponse(resp, model.XLM, "A4")
+	assert.Error(t, e)
+}
@@ -147,6 +147,8 @@ func (f *makerModeFilter) filterOps(
 			if e != nil {
 				return nil, fmt.Errorf("could not check transform offer (non-pointer case): %s", e)
 			}
+		case *ManageBuyOfferBuilder:
+			newOp, keep = f.transformBuyOfferMakerMode(topAskPrice, o)
 		default:
 			newOp = o
 			keep = true
@@ -226,3 +228,23 @@ func (f *makerModeFilter) transformOfferMakerMode(
 	}
 	return nil, keep, fmt.Errorf("unable to transform manageOffer operation: offerID=%d, amount=%.7f, price=%.7f", op.MO.OfferId, float64(op.MO.Amount)/math.Pow(10, 7), sellPrice)
 }
+
+// transformBuyOfferMakerMode applies maker mode to a native buy offer, which are always on the buying side of the trading pair
+func (f *makerModeFilter) transformBuyOfferMakerMode(topAskPrice *model.Number, op *ManageBuyOfferBuilder) (build.TransactionMutator, bool) {
+	// delete operations should never be dropped
+	if op.MBO.BuyAmount == 0 || topAskPrice == nil {
+		return op, true
+	}
+
+	keep := op.BuyPrice() < topAskPrice.AsFloat()
+	log.Printf("makerModeFilter:  buying, keep = (op price) %.7f < %.7f (topAskPrice): keep = %v", op.BuyPrice(), topAskPrice.AsFloat(), keep)
+	if keep {
+		return op, true
+	}
+
+	// new offers are dropped and modify offers are converted to delete offers
+	if droppedOp := op.dropped(); droppedOp != nil {
+		return droppedOp, false
+	}
+	return nil, false
+}
@@ -0,0 +1,86 @@
+package plugins
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/build"
+	"github.com/stellar/go/price"
+	"github.com/stellar/go/xdr"
+)
+
+// ManageBuyOfferBuilder represents a native ManageBuyOffer operation, which specifies the exact amount of the buying asset and its price in
+// units of the selling asset. The build package only supports sell offers so we build this operation ourselves.
+type ManageBuyOfferBuilder struct {
+	O   xdr.Operation
+	MBO xdr.ManageBuyOfferOp
+	Err error
+}
+
+// ensure it implements TransactionMutator
+var _ build.TransactionMutator = &ManageBuyOfferBuilder{}
+
+// makeManageBuyOffer is a factory method, offerID is 0 for new offers and sourceAccount is empty to use the source account of the transaction
+func makeManageBuyOffer(selling build.Asset, buying build.Asset, buyPrice string, buyAmount string, offerID int64, sourceAccount string) *ManageBuyOfferBuilder {
+	b := &ManageBuyOfferBuilder{}
+	b.MBO.OfferId = xdr.Int64(offerID)
+	b.MBO.Selling, b.Err = selling.ToXDR()
+	if b.Err != nil {
+		b.Err = fmt.Errorf("invalid selling asset: %s", b.Err)
+		return b
+	}
+	b.MBO.Buying, b.Err = buying.ToXDR()
+	if b.Err != nil {
+		b.Err = fmt.Errorf("invalid buying asset: %s", b.Err)
+		return b
+	}
+	b.MBO.Price, b.Err = price.Parse(buyPrice)
+	if b.Err != nil {
+		b.Err = fmt.Errorf("invalid price '%s': %s", buyPrice, b.Err)
+		return b
+	}
+	b.MBO.BuyAmount, b.Err = amount.Parse(buyAmount)
+	if b.Err != nil {
+		b.Err = fmt.Errorf("invalid amount '%s': %s", buyAmount, b.Err)
+		return b
+	}
+	if sourceAccount != "" {
+		b.Err = build.SourceAccount{AddressOrSeed: sourceAccount}.MutateOperation(&b.O)
+	}
+	return b
+}
+
+// MutateTransaction impl, it appends the operation to the transaction
+func (b ManageBuyOfferBuilder) MutateTransaction(o *build.TransactionBuilder) error {
+	if b.Err != nil {
+		return b.Err
+	}
+
+	b.O.Body, b.Err = xdr.NewOperationBody(xdr.OperationTypeManageBuyOffer, b.MBO)
+	if b.Err != nil {
+		return b.Err
+	}
+	o.TX.Operations = append(o.TX.Operations, b.O)
+	return nil
+}
+
+// BuyPrice is the price of the buying asset in units of the selling asset
+func (b *ManageBuyOfferBuilder) BuyPrice() float64 {
+	return float64(b.MBO.Price.N) / float64(b.MBO.Price.D)
+}
+
+// BuyAmount is the amount of the buying asset
+func (b *ManageBuyOfferBuilder) BuyAmount() float64 {
+	return float64(b.MBO.BuyAmount) / math.Pow(10, 7)
+}
+
+// dropped returns the operation that drops the offer, which is nil for a new offer and an operation that deletes an existing offer
+func (b *ManageBuyOfferBuilder) dropped() *ManageBuyOfferBuilder {
+	if b.MBO.OfferId == 0 {
+		return nil
+	}
+	opCopy := *b
+	opCopy.MBO.BuyAmount = 0
+	return &opCopy
+}
@@ -0,0 +1,78 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stellar/go/build"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/xdr"
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+const testIssuer = "GBMMZMK2DC4FFP4CAI6KCVNCQ7WLO5A7DQU7EC7WGHRDQBZB763X4OQI"
+
+func TestManageBuyOfferMutateTransaction(t *testing.T) {
+	selling := build.CreditAsset("USD", testIssuer)
+	op := makeManageBuyOffer(selling, build.NativeAsset(), "0.1234567", "100.5", 42, "")
+	if !assert.NoError(t, op.Err) {
+		return
+	}
+	assert.InDelta(t, 0.1234567, op.BuyPrice(), 0.0000001)
+	assert.InDelta(t, 100.5, op.BuyAmount(), 0.0000001)
+
+	tx := &build.TransactionBuilder{TX: &xdr.Transaction{}}
+	if !assert.NoError(t, op.MutateTransaction(tx)) {
+		return
+	}
+	if !assert.Equal(t, 1, len(tx.TX.Operations)) {
+		return
+	}
+	body := tx.TX.Operations[0].Body
+	assert.Equal(t, xdr.OperationTypeManageBuyOffer, body.Type)
+	mbo := body.MustManageBuyOfferOp()
+	assert.Equal(t, xdr.Int64(1005000000), mbo.BuyAmount)
+	assert.Equal(t, xdr.Int64(42), mbo.OfferId)
+	assert.Equal(t, xdr.AssetTypeAssetTypeNative, mbo.Buying.Type)
+	assert.Nil(t, tx.TX.Operations[0].SourceAccount)
+}
+
+func TestManageBuyOfferDropped(t *testing.T) {
+	newOffer := makeManageBuyOffer(build.CreditAsset("USD", testIssuer), build.NativeAsset(), "0.1", "10", 0, "")
+	assert.Nil(t, newOffer.dropped())
+
+	existingOffer := makeManageBuyOffer(build.CreditAsset("USD", testIssuer), build.NativeAsset(), "0.1", "10", 42, "")
+	dropped := existingOffer.dropped()
+	if !assert.NotNil(t, dropped) {
+		return
+	}
+	assert.Equal(t, xdr.Int64(0), dropped.MBO.BuyAmount)
+	assert.Equal(t, xdr.Int64(42), dropped.MBO.OfferId)
+	// the original op is unchanged
+	assert.Equal(t, xdr.Int64(100000000), existingOffer.MBO.BuyAmount)
+}
+
+func TestOrderConstraintsFilterBuyOffer(t *testing.T) {
+	baseAsset := hProtocol.Asset{Type: "native"}
+	quoteAsset := hProtocol.Asset{Type: "credit_alphanum4", Code: "USD", Issuer: testIssuer}
+	f := MakeFilterOrderConstraints(model.MakeOrderConstraints(7, 7, 10), baseAsset, quoteAsset)
+
+	keep := makeManageBuyOffer(build.CreditAsset("USD", testIssuer), build.NativeAsset(), "0.1", "10", 0, "")
+	dropNew := makeManageBuyOffer(build.CreditAsset("USD", testIssuer), build.NativeAsset(), "0.1", "9", 0, "")
+	dropExisting := makeManageBuyOffer(build.CreditAsset("USD", testIssuer), build.NativeAsset(), "0.1", "9", 42, "")
+
+	ops, e := f.Apply([]build.TransactionMutator{keep, dropNew, dropExisting}, []hProtocol.Offer{}, []hProtocol.Offer{})
+	if !assert.NoError(t, e) {
+		return
+	}
+	if !assert.Equal(t, 2, len(ops)) {
+		return
+	}
+	assert.Equal(t, keep, ops[0])
+	deleteOp, ok := ops[1].(*ManageBuyOfferBuilder)
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.Equal(t, xdr.Int64(42), deleteOp.MBO.OfferId)
+	assert.Equal(t, xdr.Int64(0), deleteOp.MBO.BuyAmount)
+}
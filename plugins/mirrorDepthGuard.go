@@ -0,0 +1,134 @@
+package plugins
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// depthGuard stops the mirroring of a backing orderbook that looks broken, such as during an exchange glitch or a flash crash, by checking
+// the top of the backing orderbook against a reference price feed and the depth of each mirrored side against a minimum
+type depthGuard struct {
+	referenceFeed   *api.FeedPair // nil when the price deviation is not checked
+	maxDeviationPct float64       // 0 when the price deviation is not checked
+	minDepthQuote   float64       // 0 when the depth is not checked
+	description     string
+}
+
+// makeDepthGuard is a factory method, returns nil when neither the max price deviation nor the min depth is set. The reference feed uses
+// the REFERENCE_FEED_* config when set, otherwise the PRICE_FEED_* config of the price anchor.
+func makeDepthGuard(
+	maxDeviationPct float64,
+	minDepthQuote float64,
+	referenceFeedAType string,
+	referenceFeedAURL string,
+	referenceFeedBType string,
+	referenceFeedBURL string,
+	anchor *priceAnchor,
+) (*depthGuard, error) {
+	if maxDeviationPct < 0 {
+		return nil, fmt.Errorf("MAX_PRICE_DEVIATION_PCT cannot be negative: %f", maxDeviationPct)
+	}
+	if minDepthQuote < 0 {
+		return nil, fmt.Errorf("MIN_BACKING_DEPTH_QUOTE cannot be negative: %f", minDepthQuote)
+	}
+	hasReferenceFeed := referenceFeedAType != "" || referenceFeedAURL != "" || referenceFeedBType != "" || referenceFeedBURL != ""
+	if maxDeviationPct == 0 && hasReferenceFeed {
+		return nil, fmt.Errorf("REFERENCE_FEED_* is only used with MAX_PRICE_DEVIATION_PCT, which is not set")
+	}
+	if maxDeviationPct == 0 && minDepthQuote == 0 {
+		return nil, nil
+	}
+
+	var referenceFeed *api.FeedPair
+	referenceDescription := "none"
+	if maxDeviationPct > 0 {
+		if hasReferenceFeed {
+			if referenceFeedAType == "" || referenceFeedAURL == "" || referenceFeedBType == "" || referenceFeedBURL == "" {
+				return nil, fmt.Errorf("need to specify all of REFERENCE_FEED_A_TYPE, REFERENCE_FEED_A_URL, REFERENCE_FEED_B_TYPE and REFERENCE_FEED_B_URL in mirror strategy config file")
+			}
+			feedPair, e := MakeFeedPair(referenceFeedAType, referenceFeedAURL, referenceFeedBType, referenceFeedBURL)
+			if e != nil {
+				return nil, fmt.Errorf("unable to make reference price feed: %s", e)
+			}
+			referenceFeed = feedPair
+			referenceDescription = fmt.Sprintf("feedA=%s(%s), feedB=%s(%s)", referenceFeedAType, referenceFeedAURL, referenceFeedBType, referenceFeedBURL)
+		} else if anchor != nil {
+			referenceFeed = anchor.feedPair
+			referenceDescription = anchor.description
+		} else {
+			return nil, fmt.Errorf("MAX_PRICE_DEVIATION_PCT needs a reference price, set REFERENCE_FEED_* or PRICE_FEED_* in mirror strategy config file")
+		}
+	}
+
+	return &depthGuard{
+		referenceFeed:   referenceFeed,
+		maxDeviationPct: maxDeviationPct,
+		minDepthQuote:   minDepthQuote,
+		description:     fmt.Sprintf("maxDeviationPct=%.4f, minDepthQuote=%.8f, reference=%s", maxDeviationPct, minDepthQuote, referenceDescription),
+	}, nil
+}
+
+// String is the stringer method
+func (g *depthGuard) String() string {
+	return fmt.Sprintf("depthGuard[%s]", g.description)
+}
+
+// check returns a non-empty reason when the backing orderbook should not be mirrored, the bids and asks need to be expressed in units of
+// the primary quote asset and only the mirrored sides are checked for depth
+func (g *depthGuard) check(bids []model.Order, asks []model.Order, checkBids bool, checkAsks bool) (string, error) {
+	if g.referenceFeed != nil {
+		referencePrice, e := g.referenceFeed.GetCenterPrice()
+		if e != nil {
+			return "", fmt.Errorf("unable to fetch price from reference price feed: %s", e)
+		}
+		reason, e := g.checkDeviation(bids, asks, referencePrice)
+		if e != nil || reason != "" {
+			return reason, e
+		}
+	}
+
+	if g.minDepthQuote > 0 {
+		if checkBids {
+			if depth := depthQuote(bids); depth < g.minDepthQuote {
+				return fmt.Sprintf("depth of backing bids (%.8f) is below MIN_BACKING_DEPTH_QUOTE (%.8f)", depth, g.minDepthQuote), nil
+			}
+		}
+		if checkAsks {
+			if depth := depthQuote(asks); depth < g.minDepthQuote {
+				return fmt.Sprintf("depth of backing asks (%.8f) is below MIN_BACKING_DEPTH_QUOTE (%.8f)", depth, g.minDepthQuote), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// checkDeviation returns a non-empty reason when the mid price of the backing orderbook deviates from the reference price by more than the max
+func (g *depthGuard) checkDeviation(bids []model.Order, asks []model.Order, referencePrice float64) (string, error) {
+	if referencePrice <= 0 {
+		return "", fmt.Errorf("invalid price from reference price feed: %f", referencePrice)
+	}
+	backingMid, e := backingMidPrice(bids, asks)
+	if e != nil {
+		// an empty backing orderbook has nothing to mirror, which is the same outcome as a tripped guard
+		return "backing orderbook is empty", nil
+	}
+
+	deviationPct := math.Abs(backingMid/referencePrice-1) * 100
+	if deviationPct > g.maxDeviationPct {
+		return fmt.Sprintf("mid price of backing orderbook (%.8f) deviates %.4f%% from the reference price (%.8f), above MAX_PRICE_DEVIATION_PCT (%.4f%%)",
+			backingMid, deviationPct, referencePrice, g.maxDeviationPct), nil
+	}
+	return "", nil
+}
+
+// depthQuote returns the total value of the levels in quote units
+func depthQuote(levels []model.Order) float64 {
+	total := 0.0
+	for _, l := range levels {
+		total += l.Price.AsFloat() * l.Volume.AsFloat()
+	}
+	return total
+}
@@ -0,0 +1,128 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDepthGuard(t *testing.T) {
+	pair := &model.TradingPair{Base: model.Asset("XLM"), Quote: model.Asset("USD")}
+	makeLevels := func(action model.OrderAction, prices []float64) []model.Order {
+		levels := []model.Order{}
+		for _, p := range prices {
+			levels = append(levels, model.Order{
+				Pair:        pair,
+				OrderAction: action,
+				OrderType:   model.OrderTypeLimit,
+				Price:       model.NumberFromFloat(p, 7),
+				Volume:      model.NumberFromFloat(100, 7),
+			})
+		}
+		return levels
+	}
+
+	testCases := []struct {
+		name            string
+		maxDeviationPct float64
+		minDepthQuote   float64
+		feedPrice       string
+		bids            []float64
+		asks            []float64
+		checkAsks       bool
+		wantTripped     bool
+	}{
+		{
+			name:            "within deviation",
+			maxDeviationPct: 5,
+			feedPrice:       "0.2",
+			bids:            []float64{0.199},
+			asks:            []float64{0.205},
+			checkAsks:       true,
+			wantTripped:     false,
+		}, {
+			name:            "above deviation",
+			maxDeviationPct: 5,
+			feedPrice:       "0.2",
+			bids:            []float64{0.1},
+			asks:            []float64{0.11},
+			checkAsks:       true,
+			wantTripped:     true,
+		}, {
+			name:            "empty orderbook",
+			maxDeviationPct: 5,
+			feedPrice:       "0.2",
+			bids:            []float64{},
+			asks:            []float64{},
+			checkAsks:       true,
+			wantTripped:     true,
+		}, {
+			name:          "enough depth",
+			minDepthQuote: 30,
+			bids:          []float64{0.2, 0.19},
+			asks:          []float64{0.21, 0.22},
+			checkAsks:     true,
+			wantTripped:   false,
+		}, {
+			name:          "thin asks",
+			minDepthQuote: 30,
+			bids:          []float64{0.2, 0.19},
+			asks:          []float64{0.21},
+			checkAsks:     true,
+			wantTripped:   true,
+		}, {
+			name:          "thin asks that are not mirrored",
+			minDepthQuote: 30,
+			bids:          []float64{0.2, 0.19},
+			asks:          []float64{0.21},
+			checkAsks:     false,
+			wantTripped:   false,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			feedType, feedBURL := "", ""
+			if k.feedPrice != "" {
+				feedType, feedBURL = "fixed", "1.0"
+			}
+			g, e := makeDepthGuard(k.maxDeviationPct, k.minDepthQuote, feedType, k.feedPrice, feedType, feedBURL, nil)
+			if !assert.NoError(t, e) || !assert.NotNil(t, g) {
+				return
+			}
+
+			reason, e := g.check(makeLevels(model.OrderActionBuy, k.bids), makeLevels(model.OrderActionSell, k.asks), true, k.checkAsks)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantTripped, reason != "", reason)
+		})
+	}
+}
+
+func TestMakeDepthGuard(t *testing.T) {
+	g, e := makeDepthGuard(0, 0, "", "", "", "", nil)
+	assert.NoError(t, e)
+	assert.Nil(t, g)
+
+	// the deviation needs a reference price
+	_, e = makeDepthGuard(5, 0, "", "", "", "", nil)
+	assert.Error(t, e)
+
+	// the price feed of the price anchor is used as the reference price
+	anchor, e := makePriceAnchor("fixed", "0.2", "fixed", "1.0")
+	if !assert.NoError(t, e) {
+		return
+	}
+	g, e = makeDepthGuard(5, 0, "", "", "", "", anchor)
+	if !assert.NoError(t, e) || !assert.NotNil(t, g) {
+		return
+	}
+	assert.Equal(t, anchor.feedPair, g.referenceFeed)
+
+	_, e = makeDepthGuard(0, 10, "fixed", "0.2", "fixed", "1.0", nil)
+	assert.Error(t, e)
+	_, e = makeDepthGuard(-1, 0, "fixed", "0.2", "fixed", "1.0", nil)
+	assert.Error(t, e)
+}
@@ -0,0 +1,198 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sync"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// hedgeRoute offsets the trades of the mirror strategy in two legs when the backing exchange does not list the primary trading pair, for
+// example an SDEX fill on XLM/USDC is offset on XLM/USDT (the backing pair, first leg) and the USDT is then converted to or from USDC on the
+// conversion pair (second leg). The conversion pair is configured as listed on the backing exchange, which can be either USDT/USDC or
+// USDC/USDT. The prices of the backing pair are converted to the quote asset of the primary pair using the mid price of the conversion pair.
+type hedgeRoute struct {
+	exchange              api.Exchange
+	conversionPair        *model.TradingPair
+	conversionConstraints *model.OrderConstraints
+	hedgeIsBase           bool // true when the hedge asset, which is the quote asset of the backing pair, is the base asset of the conversion pair
+	orderBook             *orderBookStreamer
+	orderType             model.OrderType
+	clientOrderID         string // tag of the conversion orders
+
+	// uninitialized
+	mutex        *sync.Mutex
+	pendingHedge float64 // units of the hedge asset that still need to be converted, positive values are converted to the primary quote asset
+}
+
+// makeHedgeRoute is a factory method, returns nil when the conversion pair is not set which offsets trades directly on the backing pair
+func makeHedgeRoute(exchange api.Exchange, backingPair *model.TradingPair, conversionBase string, conversionQuote string, orderType model.OrderType, clientOrderID string) (*hedgeRoute, error) {
+	if conversionBase == "" && conversionQuote == "" {
+		return nil, nil
+	}
+	if conversionBase == "" || conversionQuote == "" {
+		return nil, fmt.Errorf("need to specify both HEDGE_CONVERSION_BASE and HEDGE_CONVERSION_QUOTE in mirror strategy config file")
+	}
+
+	conversionPair := &model.TradingPair{
+		Base:  exchange.GetAssetConverter().MustFromString(conversionBase),
+		Quote: exchange.GetAssetConverter().MustFromString(conversionQuote),
+	}
+	var hedgeIsBase bool
+	switch backingPair.Quote {
+	case conversionPair.Base:
+		hedgeIsBase = true
+	case conversionPair.Quote:
+		hedgeIsBase = false
+	default:
+		return nil, fmt.Errorf("the hedge conversion pair '%s' needs to include the quote asset of the backing pair '%s'", conversionPair, backingPair)
+	}
+
+	return &hedgeRoute{
+		exchange:              exchange,
+		conversionPair:        conversionPair,
+		conversionConstraints: exchange.GetOrderConstraints(conversionPair),
+		hedgeIsBase:           hedgeIsBase,
+		orderBook:             makeOrderBookStreamer(exchange, conversionPair, 1),
+		orderType:             orderType,
+		clientOrderID:         clientOrderID,
+		mutex:                 &sync.Mutex{},
+	}, nil
+}
+
+// String is the stringer method
+func (r *hedgeRoute) String() string {
+	return fmt.Sprintf("hedgeRoute[conversionPair=%s, hedgeIsBase=%v, orderType=%s, conversionConstraints=%s]", r.conversionPair, r.hedgeIsBase, r.orderType, r.conversionConstraints)
+}
+
+// quoteRate returns the units of the primary quote asset that one unit of the hedge asset is worth, using the mid price of the conversion pair
+func (r *hedgeRoute) quoteRate() (float64, error) {
+	ob, e := r.orderBook.GetOrderBook()
+	if e != nil {
+		return 0, fmt.Errorf("unable to fetch orderbook of hedge conversion pair '%s': %s", r.conversionPair, e)
+	}
+	topBid := ob.TopBid()
+	topAsk := ob.TopAsk()
+	if topBid == nil || topAsk == nil {
+		return 0, fmt.Errorf("orderbook of hedge conversion pair '%s' needs both bids and asks to compute the conversion rate", r.conversionPair)
+	}
+
+	mid := (topBid.Price.AsFloat() + topAsk.Price.AsFloat()) / 2
+	if mid <= 0 {
+		return 0, fmt.Errorf("invalid mid price of hedge conversion pair '%s': %f", r.conversionPair, mid)
+	}
+	if r.hedgeIsBase {
+		return mid, nil
+	}
+	return 1 / mid, nil
+}
+
+// convertOrders expresses the prices of the orders on the backing pair in units of the primary quote asset
+func convertOrders(orders []model.Order, rate float64) []model.Order {
+	converted := []model.Order{}
+	for _, o := range orders {
+		o.Price = o.Price.Scale(rate)
+		converted = append(converted, o)
+	}
+	return converted
+}
+
+// addHedge records the units of the hedge asset that need to be converted after an offset order of the given action is placed on the
+// backing pair, selling on the backing pair receives the hedge asset and buying on the backing pair spends it
+func (r *hedgeRoute) addHedge(backingAction model.OrderAction, hedgeAmount float64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if backingAction.IsSell() {
+		r.pendingHedge += hedgeAmount
+	} else {
+		r.pendingHedge -= hedgeAmount
+	}
+}
+
+// makeConversionOrder makes the order on the conversion pair that converts the pending amount of the hedge asset, it returns nil when the
+// pending amount is below the minimum volume of the conversion pair so that it is netted against the amounts of later offsets
+func (r *hedgeRoute) makeConversionOrder(pendingHedge float64, ob *model.OrderBook) (*model.Order, error) {
+	if pendingHedge == 0 {
+		return nil, nil
+	}
+
+	// we sell the hedge asset when converting it to the primary quote asset, which is a sell order when the hedge asset is the base asset
+	action := model.OrderActionBuy
+	if (pendingHedge > 0) == r.hedgeIsBase {
+		action = model.OrderActionSell
+	}
+	topOrder := ob.TopAsk()
+	if action.IsSell() {
+		topOrder = ob.TopBid()
+	}
+	if topOrder == nil {
+		return nil, fmt.Errorf("no orders on the side of the orderbook of hedge conversion pair '%s' that a %s order would take", r.conversionPair, action.String())
+	}
+
+	volume := math.Abs(pendingHedge)
+	if !r.hedgeIsBase {
+		volume = volume / topOrder.Price.AsFloat()
+	}
+	order := &model.Order{
+		Pair:          r.conversionPair,
+		OrderAction:   action,
+		OrderType:     r.orderType,
+		Price:         model.NumberByCappingPrecision(topOrder.Price, r.conversionConstraints.PricePrecision),
+		Volume:        model.NumberFromFloat(volume, r.conversionConstraints.VolumePrecision),
+		Timestamp:     nil,
+		ClientOrderID: r.clientOrderID,
+	}
+	if order.Volume.AsFloat() < r.conversionConstraints.MinBaseVolume.AsFloat() {
+		return nil, nil
+	}
+	return order, nil
+}
+
+// convert places the second leg of the offset that converts the pending amount of the hedge asset on the conversion pair
+func (r *hedgeRoute) convert() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	ob, e := r.orderBook.GetOrderBook()
+	if e != nil {
+		return fmt.Errorf("unable to fetch orderbook of hedge conversion pair '%s': %s", r.conversionPair, e)
+	}
+	order, e := r.makeConversionOrder(r.pendingHedge, ob)
+	if e != nil {
+		return e
+	}
+	if order == nil {
+		log.Printf("hedge-skip | conversionPair=%s | pendingHedge=%f | minBaseVolume=%f\n", r.conversionPair, r.pendingHedge, r.conversionConstraints.MinBaseVolume.AsFloat())
+		return nil
+	}
+
+	transactionID, e := r.exchange.AddOrder(order)
+	if e != nil {
+		return fmt.Errorf("error when converting hedge asset (order=%s, pendingHedge=%f): %s", order, r.pendingHedge, e)
+	}
+	if transactionID == nil {
+		return fmt.Errorf("error when converting hedge asset (order=%s, pendingHedge=%f): transactionID was <nil>", order, r.pendingHedge)
+	}
+
+	converted := order.Volume.AsFloat()
+	if !r.hedgeIsBase {
+		converted = order.Volume.Multiply(*order.Price).AsFloat()
+	}
+	if r.pendingHedge > 0 {
+		r.pendingHedge -= converted
+	} else {
+		r.pendingHedge += converted
+	}
+	log.Printf("hedge-success | conversionPair=%s | orderAction=%s | baseAmt=%f | priceQuote=%f | transactionID=%s | pendingHedge=%f\n",
+		r.conversionPair,
+		order.OrderAction.String(),
+		order.Volume.AsFloat(),
+		order.Price.AsFloat(),
+		transactionID,
+		r.pendingHedge)
+	return nil
+}
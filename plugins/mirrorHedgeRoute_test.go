@@ -0,0 +1,125 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHedgeRouteConversionOrder(t *testing.T) {
+	makeOrderBook := func(pair *model.TradingPair, bid float64, ask float64) *model.OrderBook {
+		makeLevel := func(action model.OrderAction, price float64) model.Order {
+			return model.Order{
+				Pair:        pair,
+				OrderAction: action,
+				OrderType:   model.OrderTypeLimit,
+				Price:       model.NumberFromFloat(price, 4),
+				Volume:      model.NumberFromFloat(1000, 2),
+			}
+		}
+		return model.MakeOrderBook(pair, []model.Order{makeLevel(model.OrderActionSell, ask)}, []model.Order{makeLevel(model.OrderActionBuy, bid)})
+	}
+
+	testCases := []struct {
+		name         string
+		pair         *model.TradingPair
+		hedgeIsBase  bool
+		pendingHedge float64
+		wantAction   model.OrderAction
+		wantPrice    float64
+		wantVolume   float64
+	}{
+		{
+			name:         "sell hedge as base",
+			pair:         &model.TradingPair{Base: model.Asset("USDT"), Quote: model.Asset("USDC")},
+			hedgeIsBase:  true,
+			pendingHedge: 100,
+			wantAction:   model.OrderActionSell,
+			wantPrice:    0.999,
+			wantVolume:   100,
+		}, {
+			name:         "buy hedge as base",
+			pair:         &model.TradingPair{Base: model.Asset("USDT"), Quote: model.Asset("USDC")},
+			hedgeIsBase:  true,
+			pendingHedge: -100,
+			wantAction:   model.OrderActionBuy,
+			wantPrice:    1.001,
+			wantVolume:   100,
+		}, {
+			name:         "sell hedge as quote",
+			pair:         &model.TradingPair{Base: model.Asset("USDC"), Quote: model.Asset("USDT")},
+			pendingHedge: 100,
+			wantAction:   model.OrderActionBuy,
+			wantPrice:    1.25,
+			wantVolume:   80,
+		}, {
+			name:         "buy hedge as quote",
+			pair:         &model.TradingPair{Base: model.Asset("USDC"), Quote: model.Asset("USDT")},
+			pendingHedge: -100,
+			wantAction:   model.OrderActionSell,
+			wantPrice:    0.8,
+			wantVolume:   125,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			r := &hedgeRoute{
+				conversionPair:        k.pair,
+				conversionConstraints: model.MakeOrderConstraints(4, 2, 1),
+				hedgeIsBase:           k.hedgeIsBase,
+				orderType:             model.OrderTypeLimit,
+			}
+			ob := makeOrderBook(k.pair, 0.999, 1.001)
+			if !k.hedgeIsBase {
+				ob = makeOrderBook(k.pair, 0.8, 1.25)
+			}
+
+			order, e := r.makeConversionOrder(k.pendingHedge, ob)
+			if !assert.NoError(t, e) || !assert.NotNil(t, order) {
+				return
+			}
+			assert.Equal(t, k.wantAction, order.OrderAction)
+			assert.Equal(t, k.wantPrice, order.Price.AsFloat())
+			assert.Equal(t, k.wantVolume, order.Volume.AsFloat())
+		})
+	}
+}
+
+func TestHedgeRouteConversionOrderBelowMinVolume(t *testing.T) {
+	pair := &model.TradingPair{Base: model.Asset("USDT"), Quote: model.Asset("USDC")}
+	r := &hedgeRoute{
+		conversionPair:        pair,
+		conversionConstraints: model.MakeOrderConstraints(4, 2, 10),
+		hedgeIsBase:           true,
+		orderType:             model.OrderTypeLimit,
+	}
+	ob := model.MakeOrderBook(pair, []model.Order{}, []model.Order{})
+
+	// nothing to convert, and the empty orderbook is not needed
+	order, e := r.makeConversionOrder(0, ob)
+	assert.NoError(t, e)
+	assert.Nil(t, order)
+
+	ob = model.MakeOrderBook(
+		pair,
+		[]model.Order{{Pair: pair, OrderAction: model.OrderActionSell, Price: model.NumberFromFloat(1.001, 4), Volume: model.NumberFromFloat(1000, 2)}},
+		[]model.Order{{Pair: pair, OrderAction: model.OrderActionBuy, Price: model.NumberFromFloat(0.999, 4), Volume: model.NumberFromFloat(1000, 2)}},
+	)
+	// amounts below the min volume are kept pending so they can be netted with later offsets
+	order, e = r.makeConversionOrder(5, ob)
+	assert.NoError(t, e)
+	assert.Nil(t, order)
+}
+
+func TestConvertOrders(t *testing.T) {
+	pair := &model.TradingPair{Base: model.Asset("XLM"), Quote: model.Asset("USDT")}
+	orders := []model.Order{{Pair: pair, OrderAction: model.OrderActionBuy, Price: model.NumberFromFloat(0.1, 7), Volume: model.NumberFromFloat(10, 7)}}
+
+	converted := convertOrders(orders, 1.02)
+	assert.InDelta(t, 0.102, converted[0].Price.AsFloat(), 0.0000001)
+	assert.Equal(t, 10.0, converted[0].Volume.AsFloat())
+	// the original orders are not modified
+	assert.Equal(t, 0.1, orders[0].Price.AsFloat())
+}
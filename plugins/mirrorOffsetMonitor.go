@@ -0,0 +1,346 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// pendingOffset is an order placed on the backing exchange to offset a trade that has not been confirmed as filled yet
+type pendingOffset struct {
+	transactionID *model.TransactionID
+	order         model.Order
+	primaryPair   *model.TradingPair // pair of the trade that the order offsets, the trades of the order are reported on this pair
+	placedAt      time.Time
+}
+
+// offsetOrderMonitor keeps track of offset orders until they are no longer open on the backing exchange
+type offsetOrderMonitor struct {
+	timeout time.Duration
+	pending map[string]*pendingOffset
+}
+
+// makeOffsetOrderMonitor is a factory method, returns nil when the timeout is 0 which disables the monitor
+func makeOffsetOrderMonitor(timeoutSeconds uint32) *offsetOrderMonitor {
+	if timeoutSeconds == 0 {
+		return nil
+	}
+
+	return &offsetOrderMonitor{
+		timeout: time.Duration(timeoutSeconds) * time.Second,
+		pending: map[string]*pendingOffset{},
+	}
+}
+
+func (m *offsetOrderMonitor) track(transactionID *model.TransactionID, order model.Order, primaryPair *model.TradingPair) {
+	m.pending[transactionID.String()] = &pendingOffset{
+		transactionID: transactionID,
+		order:         order,
+		primaryPair:   primaryPair,
+		placedAt:      time.Now(),
+	}
+}
+
+// checkOffsetOrders polls the open orders on the backing exchange, re-credits the baseSurplus for the unfilled remainder of
+// any offset order that has been open for longer than the timeout, and re-places it at the current top of the backing orderbook. Stale
+// post-only orders are therefore replaced by orders that take liquidity. When offsetModifyOrders is set, unfilled stale orders are moved
+// to the new price in place where the backing exchange supports it. Orders that are no longer open, including the ones canceled here, are
+// settled with the trades that filled them so orders canceled outside the bot, expired or rejected are offset again and fills that happen
+// right before a cancel are not offset twice.
+func (s *mirrorStrategy) checkOffsetOrders() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.offsetMonitor.pending) == 0 {
+		return nil
+	}
+
+	openOrdersMap, e := s.exchange.GetOpenOrders([]*model.TradingPair{s.backingPair})
+	if e != nil {
+		return fmt.Errorf("unable to fetch open orders on backing exchange: %s", e)
+	}
+	openOrders := map[string]model.OpenOrder{}
+	for _, o := range openOrdersMap[*s.backingPair] {
+		openOrders[o.ID] = o
+	}
+
+	// modified orders are tracked under their new transaction ID, which should not be checked in this cycle
+	pending := map[string]*pendingOffset{}
+	for txID, p := range s.offsetMonitor.pending {
+		pending[txID] = p
+	}
+	for txID, p := range pending {
+		openOrder, isOpen := openOrders[txID]
+		if !isOpen {
+			e = s.settleClosedOffset(txID, p)
+			if e != nil {
+				return e
+			}
+			continue
+		}
+
+		executed := model.NumberConstants.Zero
+		if openOrder.VolumeExecuted != nil {
+			executed = openOrder.VolumeExecuted
+		}
+		if executed.AsFloat() > 0 {
+			log.Printf("offset-partial | transactionID=%s | newOrderAction=%s | newOrderBaseAmt=%f | executedBaseAmt=%f\n",
+				txID, p.order.OrderAction.String(), p.order.Volume.AsFloat(), executed.AsFloat())
+		}
+
+		if time.Since(p.placedAt) < s.offsetMonitor.timeout {
+			continue
+		}
+
+		if s.offsetModifyOrders && executed.AsFloat() == 0 && s.modifyStaleOffset(p) {
+			continue
+		}
+
+		result, e := s.exchange.CancelOrder(p.transactionID, *s.backingPair)
+		if e != nil {
+			return fmt.Errorf("unable to cancel stale offset order (transactionID=%s): %s", txID, e)
+		}
+		if result != model.CancelResultCancelSuccessful {
+			log.Printf("offset-cancel | transactionID=%s | result=%s, will retry in the next cycle\n", txID, result.String())
+			continue
+		}
+		log.Printf("offset-stale | transactionID=%s | newOrderAction=%s | postOnly=%v | newOrderBaseAmt=%f | executedBaseAmt=%f\n",
+			txID,
+			p.order.OrderAction.String(),
+			p.order.PostOnly,
+			p.order.Volume.AsFloat(),
+			executed.AsFloat())
+
+		// the order can fill after the open orders were fetched, so the unfilled remainder is settled with the trades of the canceled order
+		e = s.settleClosedOffset(txID, p)
+		if e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// settleClosedOffset confirms how much of an offset order that is no longer open was filled using the trades of the order, reports the trades,
+// re-credits the unfilled remainder to the baseSurplus and re-places it. The order stays pending when its trades cannot be fetched so it is
+// settled in a later cycle. Simulated orders are never placed so they are considered to be filled. Needs s.mutex to be held.
+func (s *mirrorStrategy) settleClosedOffset(txID string, p *pendingOffset) error {
+	trades := []model.Trade{s.estimateOffsetTrade(p)}
+	if !s.simMode {
+		var e error
+		trades, e = s.exchange.GetTradesForOrder(p.transactionID, *s.backingPair)
+		if e != nil {
+			log.Printf("offset-unconfirmed | transactionID=%s | unable to fetch trades of closed offset order, will retry in the next cycle: %s\n", txID, e)
+			return nil
+		}
+		s.logOffsetTrades(p, trades)
+	}
+	delete(s.offsetMonitor.pending, txID)
+	s.reportOffsetTrades(p, trades)
+
+	filled := filledVolume(trades)
+
+	remainder := model.DecimalFromNumber(*p.order.Volume).Subtract(*filled)
+	if remainder.Sign() <= 0 {
+		log.Printf("offset-filled | transactionID=%s | newOrderAction=%s | newOrderBaseAmt=%f | newOrderPriceQuote=%f\n",
+			txID, p.order.OrderAction.String(), p.order.Volume.AsFloat(), p.order.Price.AsFloat())
+		return nil
+	}
+
+	// the unfilled remainder needs to be offset again
+	s.baseSurplus[p.order.OrderAction].total = s.baseSurplus[p.order.OrderAction].total.Add(*remainder)
+	if s.hedge != nil {
+		// the hedge asset of the unfilled remainder was never received or spent so it is taken out of the pending conversion
+		s.hedge.addHedge(p.order.OrderAction.Reverse(), remainder.Multiply(*model.DecimalFromNumber(*p.order.Price)).AsFloat())
+	}
+	log.Printf("offset-closed | transactionID=%s | newOrderAction=%s | newOrderBaseAmt=%f | filledBaseAmt=%f | recreditedBaseAmt=%f | baseSurplusTotal=%f\n",
+		txID,
+		p.order.OrderAction.String(),
+		p.order.Volume.AsFloat(),
+		filled.AsFloat(),
+		remainder.AsFloat(),
+		s.baseSurplus[p.order.OrderAction].total.AsFloat())
+
+	e := s.replaceOffset(p.order.OrderAction, p.primaryPair)
+	if e != nil {
+		return fmt.Errorf("unable to re-place the unfilled remainder of offset order (transactionID=%s): %s", txID, e)
+	}
+	return nil
+}
+
+// filledVolume returns the base volume filled by the trades of an order
+func filledVolume(trades []model.Trade) *model.Decimal {
+	filled := model.DecimalConstants.Zero
+	for _, t := range trades {
+		filled = filled.Add(*model.DecimalFromNumber(*t.Volume))
+	}
+	return filled
+}
+
+// modifyStaleOffset moves an unfilled stale offset order to the price given by modifiedOffsetPrice without cancelling it, which keeps the
+// amount committed from the baseSurplus unchanged. Partially filled orders are not modified because exchanges differ in how they count the
+// filled volume of a modified order. Returns false when the order was not modified so it is cancelled and re-placed instead, needs s.mutex
+// to be held.
+func (s *mirrorStrategy) modifyStaleOffset(p *pendingOffset) bool {
+	orderModifier, ok := s.exchange.(api.OrderModifier)
+	if !ok {
+		log.Printf("backing exchange cannot modify orders, disabling OFFSET_MODIFY_ORDERS\n")
+		s.offsetModifyOrders = false
+		return false
+	}
+
+	ob, e := s.exchange.GetOrderBook(s.backingPair, 1)
+	if e != nil {
+		log.Printf("unable to fetch orderbook from backing exchange to modify stale offset order (transactionID=%s), re-placing it instead: %s\n", p.transactionID, e)
+		return false
+	}
+	price, ok := modifiedOffsetPrice(p.order, ob, s.backingConstraints.PricePrecision)
+	if !ok {
+		return false
+	}
+	newOrder := p.order
+	newOrder.Price = price
+
+	transactionID, e := orderModifier.ModifyOrder(p.transactionID, &newOrder)
+	if e == api.ErrModifyOrderUnsupported {
+		log.Printf("backing exchange does not support modifying orders, disabling OFFSET_MODIFY_ORDERS\n")
+		s.offsetModifyOrders = false
+		return false
+	}
+	if e != nil {
+		log.Printf("unable to modify stale offset order (transactionID=%s), re-placing it instead: %s\n", p.transactionID, e)
+		return false
+	}
+
+	delete(s.offsetMonitor.pending, p.transactionID.String())
+	s.offsetMonitor.track(transactionID, newOrder, p.primaryPair)
+	log.Printf("offset-modify | transactionID=%s | newTransactionID=%s | newOrderAction=%s | postOnly=%v | newOrderBaseAmt=%f | oldPriceQuote=%f | newOrderPriceQuote=%f\n",
+		p.transactionID,
+		transactionID,
+		newOrder.OrderAction.String(),
+		newOrder.PostOnly,
+		newOrder.Volume.AsFloat(),
+		p.order.Price.AsFloat(),
+		newOrder.Price.AsFloat())
+	return true
+}
+
+// modifiedOffsetPrice returns the price that a stale offset order is modified to, which is the best passive price for post-only orders and
+// the top of the opposite side of the backing orderbook otherwise, as used by replaceOffset. Returns false when the order cannot be moved
+// to a new price, which includes a post-only order that is already at the top of the book since it needs to fall back to a taker order.
+func modifiedOffsetPrice(order model.Order, ob *model.OrderBook, pricePrecision int8) (*model.Number, bool) {
+	var topPrice *model.Number
+	if order.PostOnly {
+		passivePrice, e := passiveOffsetPrice(order.OrderAction, ob)
+		if e != nil {
+			return nil, false
+		}
+		topPrice = passivePrice
+	} else {
+		topOrder := ob.TopBid()
+		if order.OrderAction.IsBuy() {
+			topOrder = ob.TopAsk()
+		}
+		if topOrder == nil {
+			return nil, false
+		}
+		topPrice = topOrder.Price
+	}
+
+	price := model.NumberByCappingPrecision(topPrice, pricePrecision)
+	if price.AsFloat() == order.Price.AsFloat() {
+		return nil, false
+	}
+	return price, true
+}
+
+// logOffsetTrades logs the fill price and the fees of an offset order as reported by the backing exchange, which can differ from the price
+// of the order and the estimated fees that were reported when the order was placed
+func (s *mirrorStrategy) logOffsetTrades(p *pendingOffset, trades []model.Trade) {
+	volume, avgPrice, fees := summarizeOrderTrades(trades)
+	log.Printf("offset-trades | transactionID=%s | newOrderAction=%s | numTrades=%d | filledBaseAmt=%f | avgFillPriceQuote=%f | newOrderPriceQuote=%f | fees=%f | estimatedFees=%f\n",
+		p.transactionID,
+		p.order.OrderAction.String(),
+		len(trades),
+		volume,
+		avgPrice,
+		p.order.Price.AsFloat(),
+		fees,
+		volume*avgPrice*s.offsetFee(p.order))
+}
+
+// summarizeOrderTrades returns the filled volume, the volume-weighted average price and the fees of the trades of an order
+func summarizeOrderTrades(trades []model.Trade) (float64, float64, float64) {
+	volume := 0.0
+	cost := 0.0
+	fees := 0.0
+	for _, t := range trades {
+		volume += t.Volume.AsFloat()
+		cost += t.Volume.AsFloat() * t.Price.AsFloat()
+		if t.Fee != nil {
+			fees += t.Fee.AsFloat()
+		}
+	}
+	if volume == 0 {
+		return 0, 0, fees
+	}
+	return volume, cost / volume, fees
+}
+
+// replaceOffset places the uncommitted baseSurplus for the orderAction at the current top of the backing orderbook and converts its hedge
+// asset the same way as HandleFill, needs s.mutex to be held
+func (s *mirrorStrategy) replaceOffset(orderAction model.OrderAction, primaryPair *model.TradingPair) error {
+	surplus := s.baseSurplus[orderAction]
+	uncommittedBase := surplus.total.Subtract(*surplus.committed)
+	if uncommittedBase.Cmp(*model.DecimalFromNumber(s.backingConstraints.MinBaseVolume)) < 0 {
+		// leave it in the baseSurplus so it is offset along with the next fill
+		log.Printf("offset-replace-skip | newOrderAction=%s | uncommittedBase=%f | minBaseVolume=%f\n", orderAction.String(), uncommittedBase.AsFloat(), s.backingConstraints.MinBaseVolume.AsFloat())
+		return nil
+	}
+	// the volume is rounded toward zero so the order never offsets more than the uncommitted baseSurplus, the rest is offset with the next fill
+	newVolume := uncommittedBase.AsNumber(s.backingConstraints.VolumePrecision, model.RoundDown)
+
+	ob, e := s.exchange.GetOrderBook(s.backingPair, 1)
+	if e != nil {
+		return fmt.Errorf("unable to fetch orderbook from backing exchange: %s", e)
+	}
+	// we take the best price on the opposite side of the book so the order fills immediately
+	topOrder := ob.TopBid()
+	if orderAction.IsBuy() {
+		topOrder = ob.TopAsk()
+	}
+	if topOrder == nil {
+		return fmt.Errorf("backing orderbook is empty on the side needed to offset a %s order", orderAction.String())
+	}
+
+	newOrder := model.Order{
+		Pair:          s.backingPair,
+		OrderAction:   orderAction,
+		OrderType:     model.OrderTypeLimit,
+		Price:         model.NumberByCappingPrecision(topOrder.Price, s.backingConstraints.PricePrecision),
+		Volume:        newVolume,
+		Timestamp:     nil,
+		ClientOrderID: s.offsetOrderTag,
+	}
+	transactionID, e := s.exchange.AddOrder(&newOrder)
+	if e != nil {
+		return fmt.Errorf("error when re-placing offset (newOrder=%s): %s", newOrder, e)
+	}
+	if transactionID == nil {
+		return fmt.Errorf("error when re-placing offset (newOrder=%s): transactionID was <nil>", newOrder)
+	}
+
+	surplus.total = surplus.total.Subtract(*model.DecimalFromNumber(*newVolume))
+	s.offsetMonitor.track(transactionID, newOrder, primaryPair)
+	s.hedgeOffset(newOrder, transactionID)
+	log.Printf("offset-replace-success | newOrderAction=%s | baseSurplusTotal=%f | baseSurplusCommitted=%f | newOrderBaseAmt=%f | newOrderPriceQuote=%f | transactionID=%s\n",
+		orderAction.String(),
+		surplus.total.AsFloat(),
+		surplus.committed.AsFloat(),
+		newOrder.Volume.AsFloat(),
+		newOrder.Price.AsFloat(),
+		transactionID)
+	return nil
+}
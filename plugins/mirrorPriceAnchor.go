@@ -0,0 +1,86 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// priceAnchor moves the levels of the backing orderbook so they are centered on the price of a price feed instead of the mid price of the
+// backing orderbook. The levels keep their volumes and their distance from the mid price of the backing orderbook in relative terms, which
+// protects the offers on the primary exchange against a manipulated or thin backing orderbook.
+type priceAnchor struct {
+	feedPair    *api.FeedPair
+	description string
+}
+
+// makePriceAnchor is a factory method, returns nil when the price feed is not set which keeps the prices of the backing orderbook
+func makePriceAnchor(feedTypeA string, feedURLA string, feedTypeB string, feedURLB string) (*priceAnchor, error) {
+	if feedTypeA == "" && feedURLA == "" && feedTypeB == "" && feedURLB == "" {
+		return nil, nil
+	}
+	if feedTypeA == "" || feedURLA == "" || feedTypeB == "" || feedURLB == "" {
+		return nil, fmt.Errorf("need to specify all of PRICE_FEED_A_TYPE, PRICE_FEED_A_URL, PRICE_FEED_B_TYPE and PRICE_FEED_B_URL in mirror strategy config file")
+	}
+
+	feedPair, e := MakeFeedPair(feedTypeA, feedURLA, feedTypeB, feedURLB)
+	if e != nil {
+		return nil, fmt.Errorf("unable to make price feed: %s", e)
+	}
+	return &priceAnchor{
+		feedPair:    feedPair,
+		description: fmt.Sprintf("feedA=%s(%s), feedB=%s(%s)", feedTypeA, feedURLA, feedTypeB, feedURLB),
+	}, nil
+}
+
+// String is the stringer method
+func (a *priceAnchor) String() string {
+	return fmt.Sprintf("priceAnchor[%s]", a.description)
+}
+
+// anchor returns the bids and asks re-priced relative to the price of the price feed, the bids and asks need to be expressed in units of
+// the primary quote asset
+func (a *priceAnchor) anchor(bids []model.Order, asks []model.Order) ([]model.Order, []model.Order, error) {
+	feedPrice, e := a.feedPair.GetCenterPrice()
+	if e != nil {
+		return nil, nil, fmt.Errorf("unable to fetch price from price feed: %s", e)
+	}
+	backingMid, e := backingMidPrice(bids, asks)
+	if e != nil {
+		return nil, nil, e
+	}
+	rate, e := anchorRate(backingMid, feedPrice)
+	if e != nil {
+		return nil, nil, e
+	}
+
+	log.Printf("anchoring backing orderbook to price feed: feedPrice=%.8f, backingMid=%.8f, deviation=%.4f%%\n", feedPrice, backingMid, (backingMid/feedPrice-1)*100)
+	return convertOrders(bids, rate), convertOrders(asks, rate), nil
+}
+
+// backingMidPrice returns the mid price of the top levels, or the price of the top level of the only side that has levels
+func backingMidPrice(bids []model.Order, asks []model.Order) (float64, error) {
+	if len(bids) > 0 && len(asks) > 0 {
+		return (bids[0].Price.AsFloat() + asks[0].Price.AsFloat()) / 2, nil
+	}
+	if len(bids) > 0 {
+		return bids[0].Price.AsFloat(), nil
+	}
+	if len(asks) > 0 {
+		return asks[0].Price.AsFloat(), nil
+	}
+	return 0, fmt.Errorf("backing orderbook is empty, cannot anchor it to the price feed")
+}
+
+// anchorRate returns the factor that moves the backing mid price to the feed price
+func anchorRate(backingMid float64, feedPrice float64) (float64, error) {
+	if feedPrice <= 0 {
+		return 0, fmt.Errorf("invalid price from price feed: %f", feedPrice)
+	}
+	if backingMid <= 0 {
+		return 0, fmt.Errorf("invalid mid price of backing orderbook: %f", backingMid)
+	}
+	return feedPrice / backingMid, nil
+}
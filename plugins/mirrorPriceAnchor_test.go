@@ -0,0 +1,101 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriceAnchor(t *testing.T) {
+	pair := &model.TradingPair{Base: model.Asset("XLM"), Quote: model.Asset("USD")}
+	makeLevels := func(action model.OrderAction, prices []float64) []model.Order {
+		levels := []model.Order{}
+		for _, p := range prices {
+			levels = append(levels, model.Order{
+				Pair:        pair,
+				OrderAction: action,
+				OrderType:   model.OrderTypeLimit,
+				Price:       model.NumberFromFloat(p, 7),
+				Volume:      model.NumberFromFloat(100, 7),
+			})
+		}
+		return levels
+	}
+
+	testCases := []struct {
+		name       string
+		bids       []float64
+		asks       []float64
+		feedPrice  string
+		wantBids   []float64
+		wantAsks   []float64
+		wantErrSet bool
+	}{
+		{
+			name:      "both sides",
+			bids:      []float64{0.19, 0.18},
+			asks:      []float64{0.21, 0.22},
+			feedPrice: "0.1",
+			wantBids:  []float64{0.095, 0.09},
+			wantAsks:  []float64{0.105, 0.11},
+		}, {
+			name:      "only bids",
+			bids:      []float64{0.25, 0.20},
+			asks:      []float64{},
+			feedPrice: "0.5",
+			wantBids:  []float64{0.5, 0.4},
+			wantAsks:  []float64{},
+		}, {
+			name:       "empty orderbook",
+			bids:       []float64{},
+			asks:       []float64{},
+			feedPrice:  "0.5",
+			wantErrSet: true,
+		}, {
+			name:       "invalid feed price",
+			bids:       []float64{0.19},
+			asks:       []float64{0.21},
+			feedPrice:  "0",
+			wantErrSet: true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			a, e := makePriceAnchor("fixed", k.feedPrice, "fixed", "1.0")
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			bids, asks, e := a.anchor(makeLevels(model.OrderActionBuy, k.bids), makeLevels(model.OrderActionSell, k.asks))
+			if k.wantErrSet {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			if !assert.Equal(t, len(k.wantBids), len(bids)) || !assert.Equal(t, len(k.wantAsks), len(asks)) {
+				return
+			}
+			for i, b := range bids {
+				assert.InDelta(t, k.wantBids[i], b.Price.AsFloat(), 0.0000001)
+				assert.Equal(t, 100.0, b.Volume.AsFloat())
+			}
+			for i, o := range asks {
+				assert.InDelta(t, k.wantAsks[i], o.Price.AsFloat(), 0.0000001)
+				assert.Equal(t, 100.0, o.Volume.AsFloat())
+			}
+		})
+	}
+}
+
+func TestMakePriceAnchorDisabled(t *testing.T) {
+	a, e := makePriceAnchor("", "", "", "")
+	assert.NoError(t, e)
+	assert.Nil(t, a)
+
+	_, e = makePriceAnchor("fixed", "1.0", "", "")
+	assert.Error(t, e)
+}
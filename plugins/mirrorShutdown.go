@@ -0,0 +1,72 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/stellar/kelp/model"
+)
+
+// BackingOrderCanceller is implemented by strategies that place orders on a backing exchange, so the orders can be canceled when the bot stops
+type BackingOrderCanceller interface {
+	CancelBackingOrders() error
+}
+
+// ensure that mirrorStrategy can cancel its offset orders
+var _ BackingOrderCanceller = &mirrorStrategy{}
+
+// CancelBackingOrders cancels the offset orders of this bot that are still open on the backing exchange, which are the tracked offset orders
+// and the open orders on the backing pair (and on the hedge conversion pair) tagged with the client order ID of this bot. Orders placed on
+// the account by anything else are left open. Nothing is canceled when KEEP_OFFSET_ORDERS_ON_SHUTDOWN is set.
+func (s *mirrorStrategy) CancelBackingOrders() error {
+	if !s.offsetTrades || s.keepOffsetOrders {
+		return nil
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	pairs := []*model.TradingPair{s.backingPair}
+	if s.hedge != nil {
+		pairs = append(pairs, s.hedge.conversionPair)
+	}
+	openOrders, e := s.exchange.GetOpenOrders(pairs)
+	if e != nil {
+		return fmt.Errorf("unable to fetch open orders on backing exchange: %s", e)
+	}
+
+	toCancel := map[string]model.TradingPair{}
+	if s.offsetMonitor != nil {
+		for txID := range s.offsetMonitor.pending {
+			toCancel[txID] = *s.backingPair
+		}
+	}
+	for pair, orders := range openOrders {
+		for _, o := range orders {
+			if o.ClientOrderID == s.offsetOrderTag {
+				toCancel[o.ID] = pair
+			}
+		}
+	}
+	log.Printf("canceling %d open offset orders on the backing exchange (clientOrderID=%s)\n", len(toCancel), s.offsetOrderTag)
+
+	numFailed := 0
+	for id, pair := range toCancel {
+		txID := model.MakeTransactionID(id)
+		result, e := s.exchange.CancelOrder(txID, pair)
+		if e != nil {
+			log.Printf("offset-cancel | transactionID=%s | error=%s\n", txID, e)
+			numFailed++
+			continue
+		}
+		log.Printf("offset-cancel | transactionID=%s | result=%s\n", txID, result.String())
+		if result == model.CancelResultFailed {
+			numFailed++
+		} else if s.offsetMonitor != nil {
+			delete(s.offsetMonitor.pending, id)
+		}
+	}
+	if numFailed > 0 {
+		return fmt.Errorf("unable to cancel %d of %d open offset orders on the backing exchange", numFailed, len(toCancel))
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package plugins
+
+import (
+	"time"
+
+	"github.com/stellar/kelp/model"
+)
+
+// MirrorSnapshotProvider is implemented by strategies that can report the backing orderbook they last mirrored
+type MirrorSnapshotProvider interface {
+	GetMirrorSnapshot() *MirrorSnapshot
+}
+
+// MirrorSnapshot is the backing exchange orderbook used in the last update of the mirror strategy along with the SDEX offers derived from it
+type MirrorSnapshot struct {
+	FetchedAtMillis int64                 `json:"fetched_at_millis"`
+	BackingPair     *model.TradingPair    `json:"backing_pair"`
+	PerLevelSpread  float64               `json:"per_level_spread"`
+	VolumeDivideBy  float64               `json:"volume_divide_by"`
+	BidSkewFactor   float64               `json:"bid_skew_factor"`
+	AskSkewFactor   float64               `json:"ask_skew_factor"`
+	Bids            []MirrorSnapshotLevel `json:"bids"`
+	Asks            []MirrorSnapshotLevel `json:"asks"`
+}
+
+// MirrorSnapshotLevel aligns a single level of the backing orderbook with the SDEX offer derived from it
+type MirrorSnapshotLevel struct {
+	Level                 int     `json:"level"`
+	BackingPrice          float64 `json:"backing_price"`
+	BackingVolume         float64 `json:"backing_volume"`
+	BackingTimestampMilli *int64  `json:"backing_timestamp_millis"`
+	OfferPrice            float64 `json:"offer_price"`
+	OfferVolume           float64 `json:"offer_volume"`
+	BelowMinBaseVolume    bool    `json:"below_min_base_volume"`
+}
+
+// ensure this implements MirrorSnapshotProvider
+var _ MirrorSnapshotProvider = &mirrorStrategy{}
+
+// GetMirrorSnapshot impl, returns nil if the strategy has not run an update yet
+func (s *mirrorStrategy) GetMirrorSnapshot() *MirrorSnapshot {
+	s.snapshotMutex.Lock()
+	defer s.snapshotMutex.Unlock()
+
+	return s.snapshot
+}
+
+func (s *mirrorStrategy) recordSnapshot(fetchedAt time.Time, bids []model.Order, asks []model.Order) {
+	snapshot := &MirrorSnapshot{
+		FetchedAtMillis: fetchedAt.UnixNano() / int64(time.Millisecond),
+		BackingPair:     s.backingPair,
+		PerLevelSpread:  s.perLevelSpread,
+		VolumeDivideBy:  s.volumeDivideBy,
+		BidSkewFactor:   s.bidSkewFactor,
+		AskSkewFactor:   s.askSkewFactor,
+		Bids:            s.makeSnapshotLevels(bids, 1-s.perLevelSpread, true),
+		Asks:            s.makeSnapshotLevels(asks, 1+s.perLevelSpread, false),
+	}
+
+	s.snapshotMutex.Lock()
+	defer s.snapshotMutex.Unlock()
+	s.snapshot = snapshot
+}
+
+// makeSnapshotLevels uses the same math as updateLevels to derive the offer for each level
+func (s *mirrorStrategy) makeSnapshotLevels(orders []model.Order, priceMultiplier float64, isBuy bool) []MirrorSnapshotLevel {
+	levels := []MirrorSnapshotLevel{}
+	for i, o := range orders {
+		price := model.NumberByCappingPrecision(o.Price.Scale(priceMultiplier), s.primaryConstraints.PricePrecision)
+		vol := model.NumberByCappingPrecision(s.levelVolume(o.Volume, i, isBuy), s.primaryConstraints.VolumePrecision)
+
+		var ts *int64
+		if o.Timestamp != nil {
+			tsMillis := o.Timestamp.AsInt64()
+			ts = &tsMillis
+		}
+
+		levels = append(levels, MirrorSnapshotLevel{
+			Level:                 i,
+			BackingPrice:          o.Price.AsFloat(),
+			BackingVolume:         o.Volume.AsFloat(),
+			BackingTimestampMilli: ts,
+			OfferPrice:            price.AsFloat(),
+			OfferVolume:           vol.AsFloat(),
+			BelowMinBaseVolume:    vol.AsFloat() < s.backingConstraints.MinBaseVolume.AsFloat(),
+		})
+	}
+	return levels
+}
@@ -3,10 +3,13 @@ package plugins
 import (
 	"fmt"
 	"log"
+	"math"
 	"sync"
+	"time"
 
 	"github.com/stellar/go/build"
 	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/support/config"
 	"github.com/stellar/kelp/api"
 	"github.com/stellar/kelp/model"
 	"github.com/stellar/kelp/support/toml"
@@ -18,47 +21,90 @@ type mirrorConfig struct {
 	Exchange                string  `valid:"-" toml:"EXCHANGE"`
 	ExchangeBase            string  `valid:"-" toml:"EXCHANGE_BASE"`
 	ExchangeQuote           string  `valid:"-" toml:"EXCHANGE_QUOTE"`
+	HedgeConversionBase     string  `valid:"-" toml:"HEDGE_CONVERSION_BASE"`
+	HedgeConversionQuote    string  `valid:"-" toml:"HEDGE_CONVERSION_QUOTE"`
 	OrderbookDepth          int32   `valid:"-" toml:"ORDERBOOK_DEPTH"`
+	MirrorSides             string  `valid:"-" toml:"MIRROR_SIDES"`
 	VolumeDivideBy          float64 `valid:"-" toml:"VOLUME_DIVIDE_BY"`
+	VolumeCurve             string  `valid:"-" toml:"VOLUME_CURVE"`
+	VolumeCurveLambda       float64 `valid:"-" toml:"VOLUME_CURVE_LAMBDA"`
 	PerLevelSpread          float64 `valid:"-" toml:"PER_LEVEL_SPREAD"`
+	MinEdge                 float64 `valid:"-" toml:"MIN_EDGE"`
 	PricePrecisionOverride  *int8   `valid:"-" toml:"PRICE_PRECISION_OVERRIDE"`
 	VolumePrecisionOverride *int8   `valid:"-" toml:"VOLUME_PRECISION_OVERRIDE"`
 	// Deprecated: use MIN_BASE_VOLUME_OVERRIDE instead
 	MinBaseVolumeDeprecated *float64                 `valid:"-" toml:"MIN_BASE_VOLUME" deprecated:"true"`
 	MinBaseVolumeOverride   *float64                 `valid:"-" toml:"MIN_BASE_VOLUME_OVERRIDE"`
 	MinQuoteVolumeOverride  *float64                 `valid:"-" toml:"MIN_QUOTE_VOLUME_OVERRIDE"`
+	BidVolumeSkew           *float64                 `valid:"-" toml:"BID_VOLUME_SKEW"`
+	AskVolumeSkew           *float64                 `valid:"-" toml:"ASK_VOLUME_SKEW"`
+	VolumeSkewAuto          bool                     `valid:"-" toml:"VOLUME_SKEW_AUTO"`
+	VolumeSkewTargetRatio   *float64                 `valid:"-" toml:"VOLUME_SKEW_TARGET_BASE_RATIO"`
+	VolumeSkewMax           float64                  `valid:"-" toml:"VOLUME_SKEW_MAX"`
 	OffsetTrades            bool                     `valid:"-" toml:"OFFSET_TRADES"`
+	OffsetOrderTimeoutSecs  uint32                   `valid:"-" toml:"OFFSET_ORDER_TIMEOUT_SECONDS"`
+	OffsetOrderType         string                   `valid:"-" toml:"OFFSET_ORDER_TYPE"`
+	OffsetMaxSlippage       float64                  `valid:"-" toml:"OFFSET_MAX_SLIPPAGE"`
+	OffsetPostOnly          bool                     `valid:"-" toml:"OFFSET_POST_ONLY"`
+	OffsetModifyOrders      bool                     `valid:"-" toml:"OFFSET_MODIFY_ORDERS"`
+	ZombieOrderCheckSecs    uint32                   `valid:"-" toml:"ZOMBIE_ORDER_CHECK_SECONDS"`
+	ZombieOrderGraceSecs    uint32                   `valid:"-" toml:"ZOMBIE_ORDER_GRACE_SECONDS"`
+	ZombieOrderAction       string                   `valid:"-" toml:"ZOMBIE_ORDER_ACTION"`
+	ConstraintsRefreshSecs  uint32                   `valid:"-" toml:"ORDER_CONSTRAINTS_REFRESH_SECONDS"`
+	SkipPreflight           bool                     `valid:"-" toml:"SKIP_PREFLIGHT_CHECKS"`
+	KeepOffsetOrders        bool                     `valid:"-" toml:"KEEP_OFFSET_ORDERS_ON_SHUTDOWN"`
 	ExchangeAPIKeys         toml.ExchangeAPIKeysToml `valid:"-" toml:"EXCHANGE_API_KEYS"`
 	ExchangeParams          toml.ExchangeParamsToml  `valid:"-" toml:"EXCHANGE_PARAMS"`
 	ExchangeHeaders         toml.ExchangeHeadersToml `valid:"-" toml:"EXCHANGE_HEADERS"`
+	PrimaryFees             *FeeSchedule             `valid:"-" toml:"PRIMARY_FEES"`
+	BackingFees             *FeeSchedule             `valid:"-" toml:"BACKING_FEES"`
+	RebalanceMode           string                   `valid:"-" toml:"REBALANCE_MODE"`
+	RebalanceCheckSecs      uint32                   `valid:"-" toml:"REBALANCE_CHECK_INTERVAL_SECONDS"`
+	RebalanceCooldownSecs   uint32                   `valid:"-" toml:"REBALANCE_COOLDOWN_SECONDS"`
+	RebalanceMinFraction    float64                  `valid:"-" toml:"REBALANCE_MIN_FRACTION"`
+	RebalanceTargetFraction float64                  `valid:"-" toml:"REBALANCE_TARGET_FRACTION"`
+	PriceFeedAType          string                   `valid:"-" toml:"PRICE_FEED_A_TYPE"`
+	PriceFeedAURL           string                   `valid:"-" toml:"PRICE_FEED_A_URL"`
+	PriceFeedBType          string                   `valid:"-" toml:"PRICE_FEED_B_TYPE"`
+	PriceFeedBURL           string                   `valid:"-" toml:"PRICE_FEED_B_URL"`
+	MaxPriceDeviationPct    float64                  `valid:"-" toml:"MAX_PRICE_DEVIATION_PCT"`
+	MinBackingDepthQuote    float64                  `valid:"-" toml:"MIN_BACKING_DEPTH_QUOTE"`
+	ReferenceFeedAType      string                   `valid:"-" toml:"REFERENCE_FEED_A_TYPE"`
+	ReferenceFeedAURL       string                   `valid:"-" toml:"REFERENCE_FEED_A_URL"`
+	ReferenceFeedBType      string                   `valid:"-" toml:"REFERENCE_FEED_B_TYPE"`
+	ReferenceFeedBURL       string                   `valid:"-" toml:"REFERENCE_FEED_B_URL"`
 }
 
 // String impl.
 func (c mirrorConfig) String() string {
 	return utils.StructString(c, map[string]func(interface{}) interface{}{
-		"EXCHANGE_API_KEYS":         utils.Hide,
-		"EXCHANGE_PARAMS":           utils.Hide,
-		"EXCHANGE_HEADERS":          utils.Hide,
-		"PRICE_PRECISION_OVERRIDE":  utils.UnwrapInt8Pointer,
-		"VOLUME_PRECISION_OVERRIDE": utils.UnwrapInt8Pointer,
-		"MIN_BASE_VOLUME":           utils.UnwrapFloat64Pointer,
-		"MIN_BASE_VOLUME_OVERRIDE":  utils.UnwrapFloat64Pointer,
-		"MIN_QUOTE_VOLUME_OVERRIDE": utils.UnwrapFloat64Pointer,
+		"EXCHANGE_API_KEYS":             utils.Hide,
+		"EXCHANGE_PARAMS":               utils.Hide,
+		"EXCHANGE_HEADERS":              utils.Hide,
+		"PRICE_PRECISION_OVERRIDE":      utils.UnwrapInt8Pointer,
+		"VOLUME_PRECISION_OVERRIDE":     utils.UnwrapInt8Pointer,
+		"MIN_BASE_VOLUME":               utils.UnwrapFloat64Pointer,
+		"MIN_BASE_VOLUME_OVERRIDE":      utils.UnwrapFloat64Pointer,
+		"MIN_QUOTE_VOLUME_OVERRIDE":     utils.UnwrapFloat64Pointer,
+		"BID_VOLUME_SKEW":               utils.UnwrapFloat64Pointer,
+		"ASK_VOLUME_SKEW":               utils.UnwrapFloat64Pointer,
+		"VOLUME_SKEW_TARGET_BASE_RATIO": utils.UnwrapFloat64Pointer,
 	})
 }
 
 // assetSurplus holds information about how many units of an asset needs to be offset on the exchange
-// negative values mean we have eagerly offset an asset, likely because of minBaseVolume requirements of the backingExchange
+// negative values mean we have eagerly offset an asset, likely because of minBaseVolume requirements of the backingExchange.
+// The amounts are exact decimals so that the many additions and subtractions do not drift away from the minBaseVolume thresholds.
 type assetSurplus struct {
-	total     *model.Number // total value in base asset units that are pending to be offset
-	committed *model.Number // base asset units that are already committed to being offset
+	total     *model.Decimal // total value in base asset units that are pending to be offset
+	committed *model.Decimal // base asset units that are already committed to being offset
 }
 
 // makeAssetSurplus is a factory method
 func makeAssetSurplus() *assetSurplus {
 	return &assetSurplus{
-		total:     model.NumberConstants.Zero,
-		committed: model.NumberConstants.Zero,
+		total:     model.DecimalConstants.Zero,
+		committed: model.DecimalConstants.Zero,
 	}
 }
 
@@ -72,16 +118,47 @@ type mirrorStrategy struct {
 	backingPair        *model.TradingPair
 	backingConstraints *model.OrderConstraints
 	orderbookDepth     int32
+	mirrorBids         bool // false when only the asks are mirrored
+	mirrorAsks         bool // false when only the bids are mirrored
 	perLevelSpread     float64
 	volumeDivideBy     float64
+	volumeCurve        *volumeCurve
+	volumeSkew         *volumeSkew
 	exchange           api.Exchange
+	backingOrderBook   *orderBookStreamer // streams the backing orderbook when the exchange supports it
+	hedge              *hedgeRoute        // nil when the backing pair has the same quote asset as the primary pair
+	priceAnchor        *priceAnchor       // nil when the levels are priced using the backing orderbook
+	depthGuard         *depthGuard        // nil when the backing orderbook is always mirrored
 	offsetTrades       bool
+	simMode            bool   // orders are not placed on the backing exchange
+	offsetOrderTag     string // client order ID of the offset orders of this bot, used to cancel them on shutdown
+	keepOffsetOrders   bool   // offset orders are left open on the backing exchange when the bot stops
 	mutex              *sync.Mutex
+	snapshotMutex      *sync.Mutex
 	baseSurplus        map[model.OrderAction]*assetSurplus // baseSurplus keeps track of any surplus we have of the base asset that needs to be offset on the backing exchange
+	offsetMonitor      *offsetOrderMonitor                 // nil when offset orders are assumed to fill completely
+	zombieTracker      *openOrdersTracker                  // nil when open orders on the backing exchange are not checked for zombie orders
+	offsetOrderType    model.OrderType                     // market offset orders are guarded by offsetMaxSlippage
+	offsetMaxSlippage  float64                             // only used when offsetOrderType is market
+	offsetPostOnly     bool                                // post-only offset orders fall back to taker orders when rejected or stale
+	offsetModifyOrders bool                                // stale offset orders are modified in place instead of being cancelled and re-placed
+	constraintsRefresh time.Duration                       // 0 when the backing order constraints are never refreshed
+	primaryFees        *FeeSchedule                        // nil when fees on the primary exchange are not accounted for
+	backingFees        *FeeSchedule                        // nil when fees on the backing exchange are not accounted for
+	netFees            *model.Decimal                      // running total of estimated fees net of rebates in quote units, negative values are earnings
+	rebalance          *rebalancer                         // nil when assets are not rebalanced between SDEX and the backing exchange
 
 	// uninitialized
-	maxBackingBase  *model.Number
-	maxBackingQuote *model.Number
+	annotator              api.Annotator   // nil when annotations are not collected
+	offsetFillHandler      api.FillHandler // nil when offset trades are not reported
+	maxBackingBase         *model.Number
+	maxBackingQuote        *model.Number
+	baseBalance            float64 // balance of the base asset on the primary exchange as of the last PreUpdate
+	quoteBalance           float64 // balance of the quote asset on the primary exchange as of the last PreUpdate
+	bidSkewFactor          float64 // multiplier for the volume of the bids in the current update
+	askSkewFactor          float64 // multiplier for the volume of the asks in the current update
+	snapshot               *MirrorSnapshot
+	lastConstraintsRefresh time.Time
 }
 
 // ensure this implements api.Strategy
@@ -90,6 +167,15 @@ var _ api.Strategy = &mirrorStrategy{}
 // ensure this implements api.FillHandler
 var _ api.FillHandler = &mirrorStrategy{}
 
+// ensure this implements api.Annotatable
+var _ api.Annotatable = &mirrorStrategy{}
+
+// ensure this implements api.OffsetFillReporter
+var _ api.OffsetFillReporter = &mirrorStrategy{}
+
+// ensure this implements api.StateSnapshotter
+var _ api.StateSnapshotter = &mirrorStrategy{}
+
 func convertDeprecatedMirrorConfigValues(config *mirrorConfig) {
 	if config.MinBaseVolumeOverride != nil && config.MinBaseVolumeDeprecated != nil {
 		log.Printf("deprecation warning: cannot set both '%s' (deprecated) and '%s' in the mirror strategy config, using value from '%s'\n", "MIN_BASE_VOLUME", "MIN_BASE_VOLUME_OVERRIDE", "MIN_BASE_VOLUME_OVERRIDE")
@@ -104,6 +190,194 @@ func convertDeprecatedMirrorConfigValues(config *mirrorConfig) {
 // makeMirrorStrategy is a factory method
 func makeMirrorStrategy(sdex *SDEX, ieif *IEIF, pair *model.TradingPair, baseAsset *hProtocol.Asset, quoteAsset *hProtocol.Asset, config *mirrorConfig, simMode bool) (api.Strategy, error) {
 	convertDeprecatedMirrorConfigValues(config)
+	curve, e := makeVolumeCurve(config.VolumeCurve, config.VolumeCurveLambda, config.OrderbookDepth)
+	if e != nil {
+		return nil, fmt.Errorf("invalid VOLUME_CURVE config in mirror strategy config file: %s", e)
+	}
+	skew, e := makeVolumeSkew(config.BidVolumeSkew, config.AskVolumeSkew, config.VolumeSkewAuto, config.VolumeSkewTargetRatio, config.VolumeSkewMax)
+	if e != nil {
+		return nil, fmt.Errorf("invalid volume skew config in mirror strategy config file: %s", e)
+	}
+
+	if e = config.PrimaryFees.validate(); e != nil {
+		return nil, fmt.Errorf("invalid PRIMARY_FEES config in mirror strategy config file: %s", e)
+	}
+	if e = config.BackingFees.validate(); e != nil {
+		return nil, fmt.Errorf("invalid BACKING_FEES config in mirror strategy config file: %s", e)
+	}
+	mirrorBids, mirrorAsks, e := parseMirrorSides(config.MirrorSides)
+	if e != nil {
+		return nil, e
+	}
+	perLevelSpread := minEdgeSpread(config)
+	offsetOrderType, e := parseOffsetOrderType(config)
+	if e != nil {
+		return nil, e
+	}
+	if config.OffsetModifyOrders && config.OffsetOrderTimeoutSecs == 0 {
+		return nil, fmt.Errorf("need to specify OFFSET_ORDER_TIMEOUT_SECONDS in mirror strategy config file when OFFSET_MODIFY_ORDERS is set")
+	}
+	if e = validateOffsetPostOnly(config, offsetOrderType); e != nil {
+		return nil, e
+	}
+	zombieAction, e := parseZombieOrderAction(config.ZombieOrderAction)
+	if e != nil {
+		return nil, fmt.Errorf("invalid mirror strategy config file: %s", e)
+	}
+	if config.ZombieOrderCheckSecs > 0 && !config.OffsetTrades {
+		return nil, fmt.Errorf("ZOMBIE_ORDER_CHECK_SECONDS can only be set when OFFSET_TRADES is set in mirror strategy config file")
+	}
+	zombieGraceSecs := config.ZombieOrderGraceSecs
+	if zombieGraceSecs == 0 {
+		zombieGraceSecs = defaultZombieOrderGraceSecs
+	}
+
+	exchange, backingPair, e := makeMirrorBackingExchange(config, simMode)
+	if e != nil {
+		return nil, e
+	}
+	// we have two sets of (tradingPair, orderConstraints): the primaryExchange and the backingExchange
+	primaryConstraints := sdex.GetOrderConstraints(pair)
+	backingConstraints := exchange.GetOrderConstraints(backingPair)
+	if !config.SkipPreflight {
+		checks := RunExchangePreflight(exchange, backingPair, config.OffsetTrades)
+		for _, c := range checks {
+			log.Printf("mirror preflight | %s\n", c)
+		}
+		if e = PreflightError(checks); e != nil {
+			return nil, fmt.Errorf("the backing exchange is not ready for the mirror strategy (set SKIP_PREFLIGHT_CHECKS in the mirror strategy config file to start anyway): %s", e)
+		}
+	}
+	offsetOrderTag := makeClientOrderIDTag(sdex.TradingAccount, backingPair)
+	hedge, e := makeHedgeRoute(exchange, backingPair, config.HedgeConversionBase, config.HedgeConversionQuote, offsetOrderType, offsetOrderTag)
+	if e != nil {
+		return nil, fmt.Errorf("invalid hedge conversion config in mirror strategy config file: %s", e)
+	}
+	if hedge != nil {
+		log.Printf("using %s\n", hedge)
+	}
+	priceAnchor, e := makePriceAnchor(config.PriceFeedAType, config.PriceFeedAURL, config.PriceFeedBType, config.PriceFeedBURL)
+	if e != nil {
+		return nil, fmt.Errorf("invalid price feed config in mirror strategy config file: %s", e)
+	}
+	if priceAnchor != nil {
+		log.Printf("using %s\n", priceAnchor)
+	}
+	depthGuard, e := makeDepthGuard(
+		config.MaxPriceDeviationPct,
+		config.MinBackingDepthQuote,
+		config.ReferenceFeedAType,
+		config.ReferenceFeedAURL,
+		config.ReferenceFeedBType,
+		config.ReferenceFeedBURL,
+		priceAnchor,
+	)
+	if e != nil {
+		return nil, fmt.Errorf("invalid depth guard config in mirror strategy config file: %s", e)
+	}
+	if depthGuard != nil {
+		log.Printf("using %s\n", depthGuard)
+	}
+	zombieTracker := makeOpenOrdersTracker(exchange, backingPair, config.ZombieOrderCheckSecs, zombieGraceSecs, zombieAction)
+	if zombieTracker != nil {
+		log.Printf("using %s\n", zombieTracker)
+	}
+	if config.RebalanceMode != "" && !config.OffsetTrades {
+		return nil, fmt.Errorf("REBALANCE_MODE can only be set when OFFSET_TRADES is set in mirror strategy config file")
+	}
+	// only the assets that are the same on both venues can be transferred between them
+	rebalanceAssets := []rebalanceAsset{}
+	if pair.Base == backingPair.Base {
+		rebalanceAssets = append(rebalanceAssets, rebalanceAsset{asset: backingPair.Base, sdexAsset: *baseAsset})
+	}
+	if pair.Quote == backingPair.Quote {
+		rebalanceAssets = append(rebalanceAssets, rebalanceAsset{asset: backingPair.Quote, sdexAsset: *quoteAsset})
+	}
+	rebalance, e := makeRebalancer(
+		sdex,
+		exchange,
+		rebalanceAssets,
+		config.RebalanceMode,
+		config.RebalanceCheckSecs,
+		config.RebalanceCooldownSecs,
+		config.RebalanceMinFraction,
+		config.RebalanceTargetFraction,
+	)
+	if e != nil {
+		return nil, fmt.Errorf("invalid rebalance config in mirror strategy config file: %s", e)
+	}
+	if rebalance != nil {
+		log.Printf("using %s\n", rebalance)
+	}
+	log.Printf("primaryPair='%s', primaryConstraints=%s\n", pair, primaryConstraints)
+	log.Printf("backingPair='%s', backingConstraints=%s\n", backingPair, backingConstraints)
+	log.Printf("using %s\n", curve)
+	log.Printf("using %s\n", skew)
+	return &mirrorStrategy{
+		sdex:               sdex,
+		ieif:               ieif,
+		baseAsset:          baseAsset,
+		quoteAsset:         quoteAsset,
+		primaryConstraints: primaryConstraints,
+		backingPair:        backingPair,
+		backingConstraints: backingConstraints,
+		orderbookDepth:     config.OrderbookDepth,
+		mirrorBids:         mirrorBids,
+		mirrorAsks:         mirrorAsks,
+		perLevelSpread:     perLevelSpread,
+		volumeDivideBy:     config.VolumeDivideBy,
+		volumeCurve:        curve,
+		volumeSkew:         skew,
+		bidSkewFactor:      1.0,
+		askSkewFactor:      1.0,
+		exchange:           exchange,
+		backingOrderBook:   makeOrderBookStreamer(exchange, backingPair, config.OrderbookDepth),
+		hedge:              hedge,
+		priceAnchor:        priceAnchor,
+		depthGuard:         depthGuard,
+		offsetTrades:       config.OffsetTrades,
+		simMode:            simMode,
+		offsetOrderTag:     offsetOrderTag,
+		keepOffsetOrders:   config.KeepOffsetOrders,
+		offsetMonitor:      makeOffsetOrderMonitor(config.OffsetOrderTimeoutSecs),
+		zombieTracker:      zombieTracker,
+		offsetOrderType:    offsetOrderType,
+		offsetMaxSlippage:  config.OffsetMaxSlippage,
+		offsetPostOnly:     config.OffsetPostOnly,
+		offsetModifyOrders: config.OffsetModifyOrders,
+		constraintsRefresh: time.Duration(config.ConstraintsRefreshSecs) * time.Second,
+		primaryFees:        config.PrimaryFees,
+		backingFees:        config.BackingFees,
+		netFees:            model.DecimalConstants.Zero,
+		rebalance:          rebalance,
+		mutex:              &sync.Mutex{},
+		snapshotMutex:      &sync.Mutex{},
+		baseSurplus: map[model.OrderAction]*assetSurplus{
+			model.OrderActionBuy:  makeAssetSurplus(),
+			model.OrderActionSell: makeAssetSurplus(),
+		},
+	}, nil
+}
+
+// RunMirrorPreflight reads the mirror strategy config file and runs the preflight checks against its backing exchange, without trading
+func RunMirrorPreflight(stratConfigPath string) ([]PreflightCheck, error) {
+	var cfg mirrorConfig
+	e := config.Read(stratConfigPath, &cfg)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse the mirror strategy config file '%s': %s", stratConfigPath, e)
+	}
+	convertDeprecatedMirrorConfigValues(&cfg)
+
+	exchange, backingPair, e := makeMirrorBackingExchange(&cfg, true)
+	if e != nil {
+		return nil, fmt.Errorf("unable to make the backing exchange '%s': %s", cfg.Exchange, e)
+	}
+	return RunExchangePreflight(exchange, backingPair, cfg.OffsetTrades), nil
+}
+
+// makeMirrorBackingExchange makes the backing exchange of the mirror strategy with the order constraint overrides of the config applied,
+// along with the backing pair
+func makeMirrorBackingExchange(config *mirrorConfig, simMode bool) (api.Exchange, *model.TradingPair, error) {
 	var exchange api.Exchange
 	var e error
 	if config.OffsetTrades {
@@ -112,30 +386,31 @@ func makeMirrorStrategy(sdex *SDEX, ieif *IEIF, pair *model.TradingPair, baseAss
 		exchangeHeaders := config.ExchangeHeaders.ToExchangeHeaders()
 		exchange, e = MakeTradingExchange(config.Exchange, exchangeAPIKeys, exchangeParams, exchangeHeaders, simMode)
 		if e != nil {
-			return nil, e
+			return nil, nil, e
 		}
 
 		if config.MinBaseVolumeOverride != nil && *config.MinBaseVolumeOverride <= 0.0 {
-			return nil, fmt.Errorf("need to specify positive MIN_BASE_VOLUME_OVERRIDE config param in mirror strategy config file")
+			return nil, nil, fmt.Errorf("need to specify positive MIN_BASE_VOLUME_OVERRIDE config param in mirror strategy config file")
 		}
 		if config.MinQuoteVolumeOverride != nil && *config.MinQuoteVolumeOverride <= 0.0 {
-			return nil, fmt.Errorf("need to specify positive MIN_QUOTE_VOLUME_OVERRIDE config param in mirror strategy config file")
+			return nil, nil, fmt.Errorf("need to specify positive MIN_QUOTE_VOLUME_OVERRIDE config param in mirror strategy config file")
 		}
 		if config.VolumePrecisionOverride != nil && *config.VolumePrecisionOverride < 0 {
-			return nil, fmt.Errorf("need to specify non-negative VOLUME_PRECISION_OVERRIDE config param in mirror strategy config file")
+			return nil, nil, fmt.Errorf("need to specify non-negative VOLUME_PRECISION_OVERRIDE config param in mirror strategy config file")
 		}
 		if config.PricePrecisionOverride != nil && *config.PricePrecisionOverride < 0 {
-			return nil, fmt.Errorf("need to specify non-negative PRICE_PRECISION_OVERRIDE config param in mirror strategy config file")
+			return nil, nil, fmt.Errorf("need to specify non-negative PRICE_PRECISION_OVERRIDE config param in mirror strategy config file")
 		}
 	} else {
-		exchange, e = MakeExchange(config.Exchange, simMode)
+		// the exchange is only used for market data here so the only params we pass on are the ccxt-pro bridge used to stream orderbooks
+		// and the options of the orderbook
+		exchangeParams := ccxtMarketDataParams(config.ExchangeParams.ToExchangeParams())
+		exchange, e = makeExchangeWithParams(config.Exchange, exchangeParams, simMode)
 		if e != nil {
-			return nil, e
+			return nil, nil, e
 		}
 	}
 
-	// we have two sets of (tradingPair, orderConstraints): the primaryExchange and the backingExchange
-	primaryConstraints := sdex.GetOrderConstraints(pair)
 	// backingPair is taken from the mirror strategy config not from the passed in trading pair
 	backingPair := &model.TradingPair{
 		Base:  exchange.GetAssetConverter().MustFromString(config.ExchangeBase),
@@ -167,30 +442,143 @@ func makeMirrorStrategy(sdex *SDEX, ieif *IEIF, pair *model.TradingPair, baseAss
 			&minQuoteVolume,
 		))
 	}
-	backingConstraints := exchange.GetOrderConstraints(backingPair)
-	log.Printf("primaryPair='%s', primaryConstraints=%s\n", pair, primaryConstraints)
-	log.Printf("backingPair='%s', backingConstraints=%s\n", backingPair, backingConstraints)
-	return &mirrorStrategy{
-		sdex:               sdex,
-		ieif:               ieif,
-		baseAsset:          baseAsset,
-		quoteAsset:         quoteAsset,
-		primaryConstraints: primaryConstraints,
-		backingPair:        backingPair,
-		backingConstraints: backingConstraints,
-		orderbookDepth:     config.OrderbookDepth,
-		perLevelSpread:     config.PerLevelSpread,
-		volumeDivideBy:     config.VolumeDivideBy,
-		exchange:           exchange,
-		offsetTrades:       config.OffsetTrades,
-		mutex:              &sync.Mutex{},
-		baseSurplus: map[model.OrderAction]*assetSurplus{
-			model.OrderActionBuy:  makeAssetSurplus(),
-			model.OrderActionSell: makeAssetSurplus(),
-		},
+	return exchange, backingPair, nil
+}
+
+// MirrorBackingExchange is the exchange that the mirror strategy offsets its trades on
+type MirrorBackingExchange struct {
+	Name     string
+	Exchange api.Exchange
+	Pair     *model.TradingPair
+}
+
+// LoadMirrorBackingExchange reads the mirror strategy config file and makes the backing exchange with its API keys, returns nil when
+// OFFSET_TRADES is not set since trades are not offset on the backing exchange then
+func LoadMirrorBackingExchange(stratConfigPath string) (*MirrorBackingExchange, error) {
+	var cfg mirrorConfig
+	e := config.Read(stratConfigPath, &cfg)
+	if e != nil {
+		return nil, fmt.Errorf("could not read mirror strategy config file '%s': %s", stratConfigPath, e)
+	}
+	if !cfg.OffsetTrades {
+		return nil, nil
+	}
+
+	exchange, e := MakeTradingExchange(
+		cfg.Exchange,
+		cfg.ExchangeAPIKeys.ToExchangeAPIKeys(),
+		cfg.ExchangeParams.ToExchangeParams(),
+		cfg.ExchangeHeaders.ToExchangeHeaders(),
+		false,
+	)
+	if e != nil {
+		return nil, fmt.Errorf("could not make backing exchange '%s': %s", cfg.Exchange, e)
+	}
+	base, e := exchange.GetAssetConverter().FromString(cfg.ExchangeBase)
+	if e != nil {
+		return nil, fmt.Errorf("invalid EXCHANGE_BASE in mirror strategy config file: %s", e)
+	}
+	quote, e := exchange.GetAssetConverter().FromString(cfg.ExchangeQuote)
+	if e != nil {
+		return nil, fmt.Errorf("invalid EXCHANGE_QUOTE in mirror strategy config file: %s", e)
+	}
+
+	return &MirrorBackingExchange{
+		Name:     cfg.Exchange,
+		Exchange: exchange,
+		Pair:     &model.TradingPair{Base: base, Quote: quote},
 	}, nil
 }
 
+// parseOffsetOrderType validates OFFSET_ORDER_TYPE, which defaults to limit orders, and the slippage guard needed for market orders
+func parseOffsetOrderType(config *mirrorConfig) (model.OrderType, error) {
+	switch config.OffsetOrderType {
+	case "", "limit":
+		return model.OrderTypeLimit, nil
+	case "market":
+		if config.OffsetMaxSlippage <= 0 || config.OffsetMaxSlippage >= 1 {
+			return model.OrderTypeLimit, fmt.Errorf("need to specify OFFSET_MAX_SLIPPAGE in the range (0, 1) in mirror strategy config file when OFFSET_ORDER_TYPE is 'market': %f", config.OffsetMaxSlippage)
+		}
+		return model.OrderTypeMarket, nil
+	}
+	return model.OrderTypeLimit, fmt.Errorf("invalid OFFSET_ORDER_TYPE in mirror strategy config file, needs to be either 'market' or 'limit': %s", config.OffsetOrderType)
+}
+
+// parseMirrorSides parses the MIRROR_SIDES config and returns whether the bids and the asks are mirrored, defaults to both sides
+func parseMirrorSides(sides string) (bool, bool, error) {
+	switch sides {
+	case "", "both":
+		return true, true, nil
+	case "bids":
+		return true, false, nil
+	case "asks":
+		return false, true, nil
+	}
+	return false, false, fmt.Errorf("invalid MIRROR_SIDES in mirror strategy config file, needs to be one of 'both', 'bids' or 'asks': %s", sides)
+}
+
+// validateOffsetPostOnly checks that post-only offset orders are limit orders and that OFFSET_ORDER_TIMEOUT_SECONDS is set, which is
+// needed to fall back to taker orders when post-only orders are not filled
+func validateOffsetPostOnly(config *mirrorConfig, offsetOrderType model.OrderType) error {
+	if !config.OffsetPostOnly {
+		return nil
+	}
+	if offsetOrderType.IsMarket() {
+		return fmt.Errorf("OFFSET_POST_ONLY cannot be used when OFFSET_ORDER_TYPE is 'market' in mirror strategy config file")
+	}
+	if config.OffsetOrderTimeoutSecs == 0 {
+		return fmt.Errorf("need to specify OFFSET_ORDER_TIMEOUT_SECONDS in mirror strategy config file when OFFSET_POST_ONLY is set")
+	}
+	return nil
+}
+
+// minEdgeSpread returns the per-level spread to use, which is raised when needed so every level keeps at least MIN_EDGE after paying
+// the fees for making on the primary exchange and offsetting as a taker on the backing exchange. Rebates and incentives reduce these
+// fees so venues that pay makers allow tighter spreads.
+func minEdgeSpread(config *mirrorConfig) float64 {
+	roundTripFee := config.PrimaryFees.EffectiveMakerFee()
+	if config.OffsetTrades {
+		// post-only offset orders can still fall back to taker orders so we always use the taker fee here
+		roundTripFee += config.BackingFees.EffectiveTakerFee()
+	}
+
+	minSpread := config.MinEdge + roundTripFee
+	log.Printf("min edge check: MIN_EDGE=%.6f, roundTripFee=%.6f, primaryFees=%s, backingFees=%s\n", config.MinEdge, roundTripFee, config.PrimaryFees, config.BackingFees)
+	if config.PerLevelSpread < minSpread {
+		log.Printf("PER_LEVEL_SPREAD (%.6f) does not cover MIN_EDGE after fees, using a per level spread of %.6f instead\n", config.PerLevelSpread, minSpread)
+		return minSpread
+	}
+	return config.PerLevelSpread
+}
+
+// SetAnnotator impl
+func (s *mirrorStrategy) SetAnnotator(annotator api.Annotator) {
+	s.annotator = annotator
+}
+
+// SetOffsetFillHandler impl
+func (s *mirrorStrategy) SetOffsetFillHandler(handler api.FillHandler) {
+	s.offsetFillHandler = handler
+}
+
+// Snapshot impl, UpdateWithOps changes the skew factors and the mirror snapshot and emits annotations
+func (s *mirrorStrategy) Snapshot() func() {
+	annotator := s.annotator
+	bidSkewFactor := s.bidSkewFactor
+	askSkewFactor := s.askSkewFactor
+	snapshot := s.GetMirrorSnapshot()
+	s.annotator = nil
+
+	return func() {
+		s.annotator = annotator
+		s.bidSkewFactor = bidSkewFactor
+		s.askSkewFactor = askSkewFactor
+		s.snapshotMutex.Lock()
+		defer s.snapshotMutex.Unlock()
+		s.snapshot = snapshot
+	}
+}
+
 // PruneExistingOffers deletes any extra offers
 func (s *mirrorStrategy) PruneExistingOffers(buyingAOffers []hProtocol.Offer, sellingAOffers []hProtocol.Offer) ([]build.TransactionMutator, []hProtocol.Offer, []hProtocol.Offer) {
 	return []build.TransactionMutator{}, buyingAOffers, sellingAOffers
@@ -198,9 +586,67 @@ func (s *mirrorStrategy) PruneExistingOffers(buyingAOffers []hProtocol.Offer, se
 
 // PreUpdate changes the strategy's state in prepration for the update
 func (s *mirrorStrategy) PreUpdate(maxAssetA float64, maxAssetB float64, trustA float64, trustB float64) error {
-	if s.offsetTrades {
-		return s.recordBalances()
+	s.baseBalance = maxAssetA
+	s.quoteBalance = maxAssetB
+	if s.constraintsRefresh > 0 && time.Since(s.lastConstraintsRefresh) >= s.constraintsRefresh {
+		// a failed refresh is not fatal since we can continue with the constraints we already have
+		e := s.refreshBackingConstraints()
+		if e != nil {
+			log.Printf("unable to refresh order constraints of backing exchange, continuing with backingConstraints=%s: %s\n", s.backingConstraints, e)
+		}
+		s.lastConstraintsRefresh = time.Now()
 	}
+
+	if !s.offsetTrades {
+		return nil
+	}
+
+	if s.offsetMonitor != nil {
+		e := s.checkOffsetOrders()
+		if e != nil {
+			return fmt.Errorf("unable to check offset orders: %s", e)
+		}
+	}
+	if s.zombieTracker != nil && s.zombieTracker.isDue(time.Now()) {
+		// a failed check is not fatal since zombie orders only lock up capital on the backing exchange
+		e := s.checkZombieOrders()
+		if e != nil {
+			log.Printf("unable to check for zombie orders on backing exchange: %s\n", e)
+		}
+	}
+	e := s.recordBalances()
+	if e != nil {
+		return e
+	}
+
+	if s.rebalance != nil && s.rebalance.isDue() {
+		s.rebalance.check(
+			map[model.Asset]float64{s.backingPair.Base: s.baseBalance, s.backingPair.Quote: s.quoteBalance},
+			map[model.Asset]float64{s.backingPair.Base: s.maxBackingBase.AsFloat(), s.backingPair.Quote: s.maxBackingQuote.AsFloat()},
+		)
+	}
+	return nil
+}
+
+// refreshBackingConstraints reloads the order constraints from the backing exchange so we adapt when the exchange changes them.
+// Any overrides from the config continue to be applied on top of the reloaded constraints by the exchange.
+func (s *mirrorStrategy) refreshBackingConstraints() error {
+	if refresher, ok := s.exchange.(api.ConstraintsRefresher); ok {
+		e := refresher.RefreshOrderConstraints()
+		if e != nil {
+			return fmt.Errorf("could not refresh order constraints: %s", e)
+		}
+	}
+	newConstraints := s.exchange.GetOrderConstraints(s.backingPair)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if newConstraints.Equal(s.backingConstraints) {
+		return nil
+	}
+	log.Printf("warning: order constraints of backing exchange changed for backingPair='%s', old backingConstraints=%s, new backingConstraints=%s\n", s.backingPair, s.backingConstraints, newConstraints)
+	s.backingConstraints = newConstraints
 	return nil
 }
 
@@ -231,19 +677,56 @@ func (s *mirrorStrategy) UpdateWithOps(
 	buyingAOffers []hProtocol.Offer,
 	sellingAOffers []hProtocol.Offer,
 ) ([]build.TransactionMutator, error) {
-	ob, e := s.exchange.GetOrderBook(s.backingPair, s.orderbookDepth)
+	ob, e := s.backingOrderBook.GetOrderBook()
 	if e != nil {
 		return nil, e
 	}
+	fetchedAt := time.Now()
 
-	// limit bids and asks to max 50 operations each because of Stellar's limit of 100 ops/tx
+	// the number of levels is bounded by ORDERBOOK_DEPTH, the trader splits the resulting ops across transactions when they
+	// exceed Stellar's limit of 100 ops/tx so deep books can be fully mirrored
 	bids := ob.Bids()
-	if len(bids) > 50 {
-		bids = bids[:50]
-	}
 	asks := ob.Asks()
-	if len(asks) > 50 {
-		asks = asks[:50]
+	backingQuote := s.maxBackingQuote
+	if s.hedge != nil {
+		rate, e := s.hedge.quoteRate()
+		if e != nil {
+			return nil, e
+		}
+		bids = convertOrders(bids, rate)
+		asks = convertOrders(asks, rate)
+		if backingQuote != nil {
+			backingQuote = backingQuote.Scale(rate)
+		}
+	}
+	if s.depthGuard != nil {
+		reason, e := s.depthGuard.check(bids, asks, s.mirrorBids, s.mirrorAsks)
+		if e != nil {
+			return nil, e
+		}
+		if reason != "" {
+			// deleting the offers is not an error so the trader keeps running and resumes mirroring once the backing orderbook recovers
+			annotate(s.annotator, api.AnnotationLevelWarning, "mirror", fmt.Sprintf("deleting all offers instead of mirroring the backing orderbook: %s", reason))
+			ops := s.sdex.DeleteAllOffers(buyingAOffers)
+			ops = append(ops, s.sdex.DeleteAllOffers(sellingAOffers)...)
+			return ops, nil
+		}
+	}
+	if s.priceAnchor != nil {
+		bids, asks, e = s.priceAnchor.anchor(bids, asks)
+		if e != nil {
+			return nil, e
+		}
+	}
+	topBids := bids
+	s.updateSkewFactors(bids, asks)
+	s.recordSnapshot(fetchedAt, bids, asks)
+	// a side that is not mirrored has no levels so any offers left on that side are deleted, which also means we never need a balance for it
+	if !s.mirrorBids {
+		bids = []model.Order{}
+	}
+	if !s.mirrorAsks {
+		asks = []model.Order{}
 	}
 
 	sellBalanceCoordinator := balanceCoordinator{
@@ -268,15 +751,15 @@ func (s *mirrorStrategy) UpdateWithOps(
 
 	buyBalanceCoordinator := balanceCoordinator{
 		placedUnits:      model.NumberConstants.Zero,
-		backingBalance:   s.maxBackingQuote,
+		backingBalance:   backingQuote,
 		backingAssetType: "quote",
 		isBackingBuy:     true,
 	}
 	sellOps, e := s.updateLevels(
 		sellingAOffers,
 		asks,
-		s.sdex.ModifySellOffer,
-		s.sdex.CreateSellOffer,
+		s.modifySellOffer,
+		s.createSellOffer,
 		(1 + s.perLevelSpread),
 		false,
 		buyBalanceCoordinator, // we buy on the backing exchange to offset trades that are sold on the primary exchange
@@ -287,7 +770,7 @@ func (s *mirrorStrategy) UpdateWithOps(
 	log.Printf("num. sellOps in this update: %d\n", len(sellOps))
 
 	ops := []build.TransactionMutator{}
-	if len(ob.Bids()) > 0 && len(sellingAOffers) > 0 && ob.Bids()[0].Price.AsFloat() >= utils.PriceAsFloat(sellingAOffers[0].Price) {
+	if len(topBids) > 0 && len(sellingAOffers) > 0 && topBids[0].Price.AsFloat() >= utils.PriceAsFloat(sellingAOffers[0].Price) {
 		ops = append(ops, sellOps...)
 		ops = append(ops, buyOps...)
 	} else {
@@ -301,17 +784,18 @@ func (s *mirrorStrategy) UpdateWithOps(
 func (s *mirrorStrategy) updateLevels(
 	oldOffers []hProtocol.Offer,
 	newOrders []model.Order,
-	modifyOffer func(offer hProtocol.Offer, price float64, amount float64, incrementalNativeAmountRaw float64) (*build.ManageOfferBuilder, error),
-	createOffer func(baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset, price float64, amount float64, incrementalNativeAmountRaw float64) (*build.ManageOfferBuilder, error),
+	modifyOffer func(offer hProtocol.Offer, price float64, amount float64, incrementalNativeAmountRaw float64) (build.TransactionMutator, error),
+	createOffer func(baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset, price float64, amount float64, incrementalNativeAmountRaw float64) (build.TransactionMutator, error),
 	priceMultiplier float64,
-	hackPriceInvertForBuyOrderChangeCheck bool, // needed because createBuy and modBuy inverts price so we need this for price comparison in doModifyOffer
+	isBuy bool,
 	bc balanceCoordinator,
 ) ([]build.TransactionMutator, error) {
 	ops := []build.TransactionMutator{}
 	deleteOps := []build.TransactionMutator{}
+	numSkippedMinVolume := 0
 	if len(newOrders) >= len(oldOffers) {
 		for i := 0; i < len(oldOffers); i++ {
-			modifyOp, deleteOp, e := s.doModifyOffer(oldOffers[i], newOrders[i], priceMultiplier, modifyOffer, hackPriceInvertForBuyOrderChangeCheck)
+			modifyOp, deleteOp, e := s.doModifyOffer(oldOffers[i], newOrders[i], i, priceMultiplier, modifyOffer, isBuy)
 			if e != nil {
 				return nil, e
 			}
@@ -329,11 +813,12 @@ func (s *mirrorStrategy) updateLevels(
 		// create offers for remaining new bids
 		for i := len(oldOffers); i < len(newOrders); i++ {
 			price := newOrders[i].Price.Scale(priceMultiplier)
-			vol := newOrders[i].Volume.Scale(1.0 / s.volumeDivideBy)
+			vol := s.levelVolume(newOrders[i].Volume, i, isBuy)
 			incrementalNativeAmountRaw := s.sdex.ComputeIncrementalNativeAmountRaw(true)
 
 			if vol.AsFloat() < s.backingConstraints.MinBaseVolume.AsFloat() {
 				log.Printf("skip level creation, baseVolume (%s) < minBaseVolume (%s) of backing exchange\n", vol.AsString(), s.backingConstraints.MinBaseVolume.AsString())
+				numSkippedMinVolume++
 				continue
 			}
 
@@ -346,9 +831,9 @@ func (s *mirrorStrategy) updateLevels(
 				return nil, e
 			}
 			if mo != nil {
-				ops = append(ops, *mo)
+				ops = append(ops, mo)
 				// update the cached liabilities if we create a valid operation to create an offer
-				if hackPriceInvertForBuyOrderChangeCheck {
+				if isBuy {
 					s.ieif.AddLiabilities(*s.quoteAsset, *s.baseAsset, vol.Multiply(*price).AsFloat(), vol.AsFloat(), incrementalNativeAmountRaw)
 				} else {
 					s.ieif.AddLiabilities(*s.baseAsset, *s.quoteAsset, vol.AsFloat(), vol.Multiply(*price).AsFloat(), incrementalNativeAmountRaw)
@@ -357,7 +842,7 @@ func (s *mirrorStrategy) updateLevels(
 		}
 	} else {
 		for i := 0; i < len(newOrders); i++ {
-			modifyOp, deleteOp, e := s.doModifyOffer(oldOffers[i], newOrders[i], priceMultiplier, modifyOffer, hackPriceInvertForBuyOrderChangeCheck)
+			modifyOp, deleteOp, e := s.doModifyOffer(oldOffers[i], newOrders[i], i, priceMultiplier, modifyOffer, isBuy)
 			if e != nil {
 				return nil, e
 			}
@@ -379,6 +864,17 @@ func (s *mirrorStrategy) updateLevels(
 		}
 	}
 
+	side := "ask"
+	if isBuy {
+		side = "bid"
+	}
+	if bc.numSkipped > 0 {
+		annotate(s.annotator, api.AnnotationLevelWarning, "mirror", fmt.Sprintf("skipped %d %s levels: insufficient backing balance of %s asset", bc.numSkipped, side, bc.backingAssetType))
+	}
+	if numSkippedMinVolume > 0 {
+		annotate(s.annotator, api.AnnotationLevelInfo, "mirror", fmt.Sprintf("skipped %d %s levels: volume below the min base volume of the backing exchange", numSkippedMinVolume, side))
+	}
+
 	// prepend deleteOps because we want to delete offers first so we "free" up our liabilities capacity to place the new/modified offers
 	allOps := append(deleteOps, ops...)
 	log.Printf("prepended %d deleteOps\n", len(deleteOps))
@@ -386,20 +882,44 @@ func (s *mirrorStrategy) updateLevels(
 	return allOps, nil
 }
 
+// levelVolume converts the volume of a level in the backing orderbook to the volume we want to place for that level
+func (s *mirrorStrategy) levelVolume(backingVolume *model.Number, levelIndex int, isBuy bool) *model.Number {
+	skewFactor := s.askSkewFactor
+	if isBuy {
+		skewFactor = s.bidSkewFactor
+	}
+	return backingVolume.Scale(s.volumeCurve.factor(levelIndex) * skewFactor / s.volumeDivideBy)
+}
+
+// updateSkewFactors computes the volume skew for the current update from the balances on the primary exchange, valued at the mid price of
+// the backing orderbook
+func (s *mirrorStrategy) updateSkewFactors(bids []model.Order, asks []model.Order) {
+	midPrice := 0.0
+	if len(bids) > 0 && len(asks) > 0 {
+		midPrice = (bids[0].Price.AsFloat() + asks[0].Price.AsFloat()) / 2
+	}
+	s.bidSkewFactor, s.askSkewFactor = s.volumeSkew.factors(s.baseBalance, s.quoteBalance, midPrice)
+	if s.bidSkewFactor != 1.0 || s.askSkewFactor != 1.0 {
+		log.Printf("skewing volumes for inventory (baseBalance=%.7f, quoteBalance=%.7f, midPrice=%.7f): bidSkewFactor=%.4f, askSkewFactor=%.4f\n",
+			s.baseBalance, s.quoteBalance, midPrice, s.bidSkewFactor, s.askSkewFactor)
+	}
+}
+
 // doModifyOffer returns a new modifyOp, deleteOp, error
 func (s *mirrorStrategy) doModifyOffer(
 	oldOffer hProtocol.Offer,
 	newOrder model.Order,
+	levelIndex int,
 	priceMultiplier float64,
-	modifyOffer func(offer hProtocol.Offer, price float64, amount float64, incrementalNativeAmountRaw float64) (*build.ManageOfferBuilder, error),
-	hackPriceInvertForBuyOrderChangeCheck bool, // needed because createBuy and modBuy inverts price so we need this for price comparison in doModifyOffer
+	modifyOffer func(offer hProtocol.Offer, price float64, amount float64, incrementalNativeAmountRaw float64) (build.TransactionMutator, error),
+	isBuy bool,
 ) (build.TransactionMutator, build.TransactionMutator, error) {
 	price := newOrder.Price.Scale(priceMultiplier)
-	vol := newOrder.Volume.Scale(1.0 / s.volumeDivideBy)
+	vol := s.levelVolume(newOrder.Volume, levelIndex, isBuy)
 	oldPrice := model.MustNumberFromString(oldOffer.Price, s.primaryConstraints.PricePrecision)
 	oldVol := model.MustNumberFromString(oldOffer.Amount, s.primaryConstraints.VolumePrecision)
-	if hackPriceInvertForBuyOrderChangeCheck {
-		// we want to multiply oldVol by the original oldPrice so we can get the correct oldVol, since ModifyBuyOffer multiplies price * vol
+	if isBuy {
+		// buy offers are stored as sell offers of the quote asset so convert the existing offer to the base amount and the price of the base asset
 		oldVol = oldVol.Multiply(*oldPrice)
 		oldPrice = model.InvertNumber(oldPrice)
 	}
@@ -408,7 +928,7 @@ func (s *mirrorStrategy) doModifyOffer(
 	sameOrderParams := oldPrice.EqualsPrecisionNormalized(*price, epsilon) && oldVol.EqualsPrecisionNormalized(*vol, epsilon)
 	if sameOrderParams {
 		// update the cached liabilities if we keep the existing offer
-		if hackPriceInvertForBuyOrderChangeCheck {
+		if isBuy {
 			s.ieif.AddLiabilities(oldOffer.Selling, oldOffer.Buying, oldVol.Multiply(*oldPrice).AsFloat(), oldVol.AsFloat(), incrementalNativeAmountRaw)
 		} else {
 			s.ieif.AddLiabilities(oldOffer.Selling, oldOffer.Buying, oldVol.AsFloat(), oldVol.Multiply(*oldPrice).AsFloat(), incrementalNativeAmountRaw)
@@ -436,12 +956,12 @@ func (s *mirrorStrategy) doModifyOffer(
 	}
 	if mo != nil {
 		// update the cached liabilities if we create a valid operation to modify the offer
-		if hackPriceInvertForBuyOrderChangeCheck {
+		if isBuy {
 			s.ieif.AddLiabilities(oldOffer.Selling, oldOffer.Buying, offerAmount.Multiply(*offerPrice).AsFloat(), offerAmount.AsFloat(), incrementalNativeAmountRaw)
 		} else {
 			s.ieif.AddLiabilities(oldOffer.Selling, oldOffer.Buying, offerAmount.AsFloat(), offerAmount.Multiply(*offerPrice).AsFloat(), incrementalNativeAmountRaw)
 		}
-		return *mo, nil, nil
+		return mo, nil, nil
 	}
 
 	// since mo is nil we want to delete this offer
@@ -449,6 +969,40 @@ func (s *mirrorStrategy) doModifyOffer(
 	return nil, deleteOp, nil
 }
 
+// modifySellOffer adapts SDEX.ModifySellOffer to the signature used by updateLevels, buy offers use the native ManageBuyOffer op instead
+func (s *mirrorStrategy) modifySellOffer(offer hProtocol.Offer, price float64, amount float64, incrementalNativeAmountRaw float64) (build.TransactionMutator, error) {
+	mo, e := s.sdex.ModifySellOffer(offer, price, amount, incrementalNativeAmountRaw)
+	if e != nil || mo == nil {
+		return nil, e
+	}
+	return *mo, nil
+}
+
+// createSellOffer adapts SDEX.CreateSellOffer to the signature used by updateLevels
+func (s *mirrorStrategy) createSellOffer(baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset, price float64, amount float64, incrementalNativeAmountRaw float64) (build.TransactionMutator, error) {
+	mo, e := s.sdex.CreateSellOffer(baseAsset, quoteAsset, price, amount, incrementalNativeAmountRaw)
+	if e != nil || mo == nil {
+		return nil, e
+	}
+	return *mo, nil
+}
+
+// GetRebalanceSnapshot impl, returns nil when assets are not rebalanced
+func (s *mirrorStrategy) GetRebalanceSnapshot() *RebalanceSnapshot {
+	if s.rebalance == nil {
+		return nil
+	}
+	return s.rebalance.snapshot()
+}
+
+// ApproveRebalanceTransfer impl
+func (s *mirrorStrategy) ApproveRebalanceTransfer(id string, approve bool) error {
+	if s.rebalance == nil {
+		return fmt.Errorf("assets are not rebalanced by this bot, set REBALANCE_MODE in the mirror strategy config file")
+	}
+	return s.rebalance.approve(id, approve)
+}
+
 // PostUpdate changes the strategy's state after the update has taken place
 func (s *mirrorStrategy) PostUpdate() error {
 	return nil
@@ -464,8 +1018,9 @@ func (s *mirrorStrategy) GetFillHandlers() ([]api.FillHandler, error) {
 
 func (s *mirrorStrategy) baseVolumeToOffset(trade model.Trade, newOrderAction model.OrderAction) (newVolume *model.Number, ok bool) {
 	uncommittedBase := s.baseSurplus[newOrderAction].total.Subtract(*s.baseSurplus[newOrderAction].committed)
+	minBaseVolume := model.DecimalFromNumber(s.backingConstraints.MinBaseVolume)
 
-	if uncommittedBase.AsFloat() < s.backingConstraints.MinBaseVolume.Scale(0.5).AsFloat() {
+	if uncommittedBase.Cmp(*minBaseVolume.Multiply(*model.MustDecimalFromString("0.5"))) < 0 {
 		log.Printf("offset-skip | tradeID=%s | tradeBaseAmt=%f | tradeQuoteAmt=%f | tradePriceQuote=%f | minBaseVolume=%f | newOrderAction=%s | baseSurplusTotal=%f | baseSurplusCommitted=%f\n",
 			trade.TransactionID.String(),
 			trade.Volume.AsFloat(),
@@ -478,13 +1033,11 @@ func (s *mirrorStrategy) baseVolumeToOffset(trade model.Trade, newOrderAction mo
 		return nil, false
 	}
 
-	if uncommittedBase.AsFloat() > s.backingConstraints.MinBaseVolume.AsFloat() {
-		newVolume = uncommittedBase
-	} else {
-		// we want to offset the MinBaseVolume and take a deficit in the baseSurplus on success
-		newVolume = &s.backingConstraints.MinBaseVolume
+	if uncommittedBase.Cmp(*minBaseVolume) > 0 {
+		return uncommittedBase.AsNumber(s.backingConstraints.VolumePrecision, model.RoundHalfUp), true
 	}
-	return model.NumberByCappingPrecision(newVolume, s.backingConstraints.VolumePrecision), true
+	// we want to offset the MinBaseVolume and take a deficit in the baseSurplus on success
+	return model.NumberByCappingPrecision(&s.backingConstraints.MinBaseVolume, s.backingConstraints.VolumePrecision), true
 }
 
 // HandleFill impl
@@ -495,22 +1048,39 @@ func (s *mirrorStrategy) HandleFill(trade model.Trade) error {
 
 	newOrderAction := trade.OrderAction.Reverse()
 	// increase the baseSurplus for the additional amount that needs to be offset because of the incoming trade
-	s.baseSurplus[newOrderAction].total = s.baseSurplus[newOrderAction].total.Add(*trade.Volume)
+	s.baseSurplus[newOrderAction].total = s.baseSurplus[newOrderAction].total.Add(*model.DecimalFromNumber(*trade.Volume))
+
+	// the trade price is in units of the primary quote asset so it is converted when the backing pair has a different quote asset
+	rate := 1.0
+	if s.hedge != nil {
+		var e error
+		rate, e = s.hedge.quoteRate()
+		if e != nil {
+			return fmt.Errorf("unable to convert trade price to offset it on the backing pair: %s", e)
+		}
+	}
+	tradePrice := trade.Price.Scale(1 / rate)
 
 	newVolume, ok := s.baseVolumeToOffset(trade, newOrderAction)
 	if !ok {
 		return nil
 	}
 	// commit the newVolume that we are trying to use so the next handler does not double-count this amount
-	s.baseSurplus[newOrderAction].committed = s.baseSurplus[newOrderAction].committed.Add(*newVolume)
+	s.baseSurplus[newOrderAction].committed = s.baseSurplus[newOrderAction].committed.Add(*model.DecimalFromNumber(*newVolume))
 
 	newOrder := model.Order{
-		Pair:        s.backingPair, // we want to offset trades on the backing exchange so use the backing exchange's trading pair
-		OrderAction: newOrderAction,
-		OrderType:   model.OrderTypeLimit,
-		Price:       model.NumberByCappingPrecision(trade.Price, s.backingConstraints.PricePrecision),
-		Volume:      newVolume,
-		Timestamp:   nil,
+		Pair:          s.backingPair, // we want to offset trades on the backing exchange so use the backing exchange's trading pair
+		OrderAction:   newOrderAction,
+		OrderType:     model.OrderTypeLimit,
+		Price:         model.NumberByCappingPrecision(tradePrice, s.backingConstraints.PricePrecision),
+		Volume:        newVolume,
+		Timestamp:     nil,
+		ClientOrderID: s.offsetOrderTag,
+	}
+	if s.offsetOrderType.IsMarket() {
+		s.guardMarketOffsetOrder(&newOrder)
+	} else if s.offsetPostOnly {
+		s.makePostOnlyOffsetOrder(&newOrder)
 	}
 	log.Printf("offset-attempt | tradeID=%s | tradeBaseAmt=%f | tradeQuoteAmt=%f | tradePriceQuote=%f | newOrderAction=%s | baseSurplusTotal=%f | baseSurplusCommitted=%f | minBaseVolume=%f | newOrderBaseAmt=%f | newOrderQuoteAmt=%f | newOrderPriceQuote=%f\n",
 		trade.TransactionID.String(),
@@ -525,6 +1095,12 @@ func (s *mirrorStrategy) HandleFill(trade model.Trade) error {
 		newOrder.Volume.Multiply(*newOrder.Price).AsFloat(),
 		newOrder.Price.AsFloat())
 	transactionID, e := s.exchange.AddOrder(&newOrder)
+	if e != nil && newOrder.PostOnly {
+		log.Printf("post-only offset order was rejected, offsetting with a taker order at the trade price instead (newOrder=%s): %s\n", newOrder, e)
+		newOrder.PostOnly = false
+		newOrder.Price = model.NumberByCappingPrecision(tradePrice, s.backingConstraints.PricePrecision)
+		transactionID, e = s.exchange.AddOrder(&newOrder)
+	}
 	if e != nil {
 		return fmt.Errorf("error when offsetting trade (newOrder=%s): %s", newOrder, e)
 	}
@@ -533,10 +1109,19 @@ func (s *mirrorStrategy) HandleFill(trade model.Trade) error {
 	}
 
 	// update the baseSurplus on success
-	s.baseSurplus[newOrderAction].total = s.baseSurplus[newOrderAction].total.Subtract(*newVolume)
-	s.baseSurplus[newOrderAction].committed = s.baseSurplus[newOrderAction].committed.Subtract(*newVolume)
+	s.baseSurplus[newOrderAction].total = s.baseSurplus[newOrderAction].total.Subtract(*model.DecimalFromNumber(*newVolume))
+	s.baseSurplus[newOrderAction].committed = s.baseSurplus[newOrderAction].committed.Subtract(*model.DecimalFromNumber(*newVolume))
+	if s.offsetMonitor != nil {
+		// the monitor re-credits the baseSurplus if the order does not fill completely and reports its trades once it is settled
+		s.offsetMonitor.track(transactionID, newOrder, trade.Pair)
+	}
+
+	// fees are accounted in units of the primary quote asset
+	primaryOrder := newOrder
+	primaryOrder.Price = newOrder.Price.Scale(rate)
+	fees := s.accountFees(trade, primaryOrder)
 
-	log.Printf("offset-success | tradeID=%s | tradeBaseAmt=%f | tradeQuoteAmt=%f | tradePriceQuote=%f | newOrderAction=%s | baseSurplusTotal=%f | baseSurplusCommitted=%f | minBaseVolume=%f | newOrderBaseAmt=%f | newOrderQuoteAmt=%f | newOrderPriceQuote=%f | transactionID=%s\n",
+	log.Printf("offset-success | tradeID=%s | tradeBaseAmt=%f | tradeQuoteAmt=%f | tradePriceQuote=%f | newOrderAction=%s | baseSurplusTotal=%f | baseSurplusCommitted=%f | minBaseVolume=%f | newOrderBaseAmt=%f | newOrderQuoteAmt=%f | newOrderPriceQuote=%f | transactionID=%s | estimatedFeesQuote=%f | netFeesQuote=%f\n",
 		trade.TransactionID.String(),
 		trade.Volume.AsFloat(),
 		trade.Volume.Multiply(*trade.Price).AsFloat(),
@@ -548,16 +1133,215 @@ func (s *mirrorStrategy) HandleFill(trade model.Trade) error {
 		newOrder.Volume.AsFloat(),
 		newOrder.Volume.Multiply(*newOrder.Price).AsFloat(),
 		newOrder.Price.AsFloat(),
-		transactionID)
+		transactionID,
+		fees.AsFloat(),
+		s.netFees.AsFloat())
+
+	if s.offsetMonitor == nil {
+		// offset orders are not settled without the monitor so they are reported as if they filled completely
+		p := &pendingOffset{transactionID: transactionID, order: newOrder, primaryPair: trade.Pair}
+		s.reportOffsetTrades(p, []model.Trade{s.estimateOffsetTrade(p)})
+	}
+
+	s.hedgeOffset(newOrder, transactionID)
 	return nil
 }
 
+// hedgeOffset converts the hedge asset that the offset order placed on the backing pair receives or spends, it does nothing when the backing
+// pair offsets trades directly
+func (s *mirrorStrategy) hedgeOffset(offsetOrder model.Order, transactionID *model.TransactionID) {
+	if s.hedge == nil {
+		return
+	}
+
+	s.hedge.addHedge(offsetOrder.OrderAction, offsetOrder.Volume.Multiply(*offsetOrder.Price).AsFloat())
+	e := s.hedge.convert()
+	if e != nil {
+		// the first leg was already placed and the pending amount of the hedge asset is converted with the next offset so only log the error
+		log.Printf("unable to convert hedge asset after offsetting trade (transactionID=%s): %s\n", transactionID, e)
+		annotate(s.annotator, api.AnnotationLevelWarning, "mirror", fmt.Sprintf("unable to convert the hedge asset on %s, it will be retried with the next offset", s.hedge.conversionPair))
+	}
+}
+
+// estimateOffsetTrade returns the trade of an offset order on the backing pair assuming that it fills completely at its price with the
+// estimated fees of the backing exchange, which is used when the trades of the order are not known
+func (s *mirrorStrategy) estimateOffsetTrade(p *pendingOffset) model.Trade {
+	order := p.order
+	order.Timestamp = model.MakeTimestampFromTime(time.Now())
+	cost := order.Volume.Multiply(*order.Price)
+	return model.Trade{
+		Order:         order,
+		TransactionID: p.transactionID,
+		Cost:          cost,
+		Fee:           cost.Scale(s.offsetFee(order)),
+		FeeAsset:      s.backingPair.Quote,
+	}
+}
+
+// reportOffsetTrades reports the trades of an offset order to the offsetFillHandler as trades on the primary trading pair with prices in units
+// of the primary quote asset. The trades were already made on the backing exchange so errors are only logged.
+func (s *mirrorStrategy) reportOffsetTrades(p *pendingOffset, trades []model.Trade) {
+	if s.offsetFillHandler == nil || len(trades) == 0 {
+		return
+	}
+
+	rate := 1.0
+	if s.hedge != nil {
+		var e error
+		rate, e = s.hedge.quoteRate()
+		if e != nil {
+			log.Printf("unable to report trades of offset order (transactionID=%s): %s\n", p.transactionID, e)
+			return
+		}
+	}
+	for _, t := range trades {
+		e := s.offsetFillHandler.HandleFill(makePrimaryOffsetTrade(t, p.primaryPair, s.backingPair, rate))
+		if e != nil {
+			log.Printf("unable to report offset trade (transactionID=%s): %s\n", p.transactionID, e)
+		}
+	}
+}
+
+// makePrimaryOffsetTrade expresses a trade on the backing pair as a trade on the primary pair, converting its price and any fee in the backing
+// quote asset to the primary quote asset with the rate. The mirror strategy considers the assets of both pairs to be equivalent.
+func makePrimaryOffsetTrade(t model.Trade, primaryPair *model.TradingPair, backingPair *model.TradingPair, rate float64) model.Trade {
+	primary := t
+	primary.Pair = primaryPair
+	primary.Price = t.Price.Scale(rate)
+	primary.Cost = t.Volume.Multiply(*primary.Price)
+	switch t.FeeAsset {
+	case backingPair.Quote:
+		primary.FeeAsset = primaryPair.Quote
+		if t.Fee != nil {
+			primary.Fee = t.Fee.Scale(rate)
+		}
+	case backingPair.Base:
+		primary.FeeAsset = primaryPair.Base
+	}
+	return primary
+}
+
+// makePostOnlyOffsetOrder converts the offset order into a post-only order at the best passive price, which is the top of the backing
+// orderbook on the same side as the order, to earn the maker fees. The price of the trade is kept if the backing orderbook cannot be fetched.
+func (s *mirrorStrategy) makePostOnlyOffsetOrder(order *model.Order) {
+	order.PostOnly = true
+	ob, e := s.backingOrderBook.GetOrderBook()
+	if e != nil {
+		log.Printf("unable to fetch backing orderbook to offset with a post-only order at the best passive price, using the trade price instead: %s\n", e)
+		return
+	}
+
+	price, e := passiveOffsetPrice(order.OrderAction, ob)
+	if e != nil {
+		log.Printf("unable to compute the best passive price of post-only offset order, using the trade price instead: %s\n", e)
+		return
+	}
+	order.Price = model.NumberByCappingPrecision(price, s.backingConstraints.PricePrecision)
+}
+
+// passiveOffsetPrice returns the best price at which an order does not take liquidity, which joins the top of the orderbook on the same side
+func passiveOffsetPrice(action model.OrderAction, ob *model.OrderBook) (*model.Number, error) {
+	topOrder := ob.TopBid()
+	if action.IsSell() {
+		topOrder = ob.TopAsk()
+	}
+	if topOrder == nil {
+		return nil, fmt.Errorf("no orders on the side of the backing orderbook that a %s order would join", action.String())
+	}
+	return topOrder.Price, nil
+}
+
+// offsetFee returns the fee of the backing exchange for the offset order, post-only orders pay the maker fee
+func (s *mirrorStrategy) offsetFee(offsetOrder model.Order) float64 {
+	if offsetOrder.PostOnly {
+		return s.backingFees.EffectiveMakerFee()
+	}
+	return s.backingFees.EffectiveTakerFee()
+}
+
+// guardMarketOffsetOrder converts the offset order into a market order when the backing orderbook can fill it within offsetMaxSlippage of the
+// top of the book, setting its price to the worst price expected to be filled. Otherwise the order remains a limit order at the slippage guard
+// so it takes as much liquidity as allowed, or at the trade price if the backing orderbook cannot be fetched.
+func (s *mirrorStrategy) guardMarketOffsetOrder(order *model.Order) {
+	ob, e := s.backingOrderBook.GetOrderBook()
+	if e != nil {
+		log.Printf("unable to fetch backing orderbook to offset with a market order, offsetting with a limit order at the trade price instead: %s\n", e)
+		return
+	}
+
+	price, withinSlippage, e := marketOffsetPrice(order.OrderAction, order.Volume, ob, s.offsetMaxSlippage)
+	if e != nil {
+		log.Printf("unable to compute price of market offset order, offsetting with a limit order at the trade price instead: %s\n", e)
+		return
+	}
+
+	order.Price = model.NumberByCappingPrecision(price, s.backingConstraints.PricePrecision)
+	if !withinSlippage {
+		log.Printf("market offset order would exceed OFFSET_MAX_SLIPPAGE (%f), offsetting with a limit order at the slippage guard price (%s) instead\n", s.offsetMaxSlippage, order.Price.AsString())
+		annotate(s.annotator, api.AnnotationLevelWarning, "mirror", fmt.Sprintf("market offset order would exceed the max slippage of %f, used a limit order at the slippage guard price instead", s.offsetMaxSlippage))
+		return
+	}
+	order.OrderType = model.OrderTypeMarket
+}
+
+// marketOffsetPrice walks the opposite side of the orderbook to find the worst price needed to fill the volume of a market order. If the
+// orderbook cannot fill the volume within maxSlippage of its top price then it returns the slippage guard price and false.
+func marketOffsetPrice(action model.OrderAction, volume *model.Number, ob *model.OrderBook, maxSlippage float64) (*model.Number, bool, error) {
+	levels := ob.Asks()
+	guardMultiplier := 1 + maxSlippage
+	if action.IsSell() {
+		levels = ob.Bids()
+		guardMultiplier = 1 - maxSlippage
+	}
+	if len(levels) == 0 {
+		return nil, false, fmt.Errorf("no orders on the side of the backing orderbook that a %s order would take", action.String())
+	}
+
+	topPrice := levels[0].Price
+	guardPrice := topPrice.Scale(guardMultiplier)
+	worstPrice := topPrice
+	remaining := volume.AsFloat()
+	for _, level := range levels {
+		if remaining <= 0 {
+			break
+		}
+		worstPrice = level.Price
+		remaining -= level.Volume.AsFloat()
+	}
+	if remaining > 0 {
+		// the visible orderbook cannot fill the order so we cannot know the slippage
+		return guardPrice, false, nil
+	}
+
+	slippage := math.Abs(worstPrice.AsFloat()-topPrice.AsFloat()) / topPrice.AsFloat()
+	if slippage > maxSlippage {
+		return guardPrice, false, nil
+	}
+	return worstPrice, true, nil
+}
+
+// accountFees adds the estimated fees for making the trade on the primary exchange and the offset order on the backing exchange
+// to the running total, net of any rebates and incentives. Returns the fees for this trade in quote units, negative values are earnings.
+func (s *mirrorStrategy) accountFees(trade model.Trade, offsetOrder model.Order) *model.Number {
+	precision := s.backingConstraints.PricePrecision
+	if s.backingConstraints.VolumePrecision > precision {
+		precision = s.backingConstraints.VolumePrecision
+	}
+
+	primaryFees := trade.Volume.Multiply(*trade.Price).Scale(s.primaryFees.EffectiveMakerFee())
+	backingFees := offsetOrder.Volume.Multiply(*offsetOrder.Price).Scale(s.offsetFee(offsetOrder))
+	fees := model.NumberByCappingPrecision(primaryFees.Add(*backingFees), precision)
+	s.netFees = s.netFees.Add(*model.DecimalFromNumber(*fees))
+	return fees
+}
+
 // balanceCoordinator coordinates the balances from the backing exchange with orders placed on the primary exchange
 type balanceCoordinator struct {
 	placedUnits      *model.Number
 	backingBalance   *model.Number
 	backingAssetType string
 	isBackingBuy     bool
+	numSkipped       int // number of levels skipped because of insufficient balance
 }
 
 func (b *balanceCoordinator) checkBalance(vol *model.Number, price *model.Number) bool {
@@ -569,6 +1353,7 @@ func (b *balanceCoordinator) checkBalance(vol *model.Number, price *model.Number
 	newPlacedUnits := b.placedUnits.Add(*additionalUnits)
 	if newPlacedUnits.AsFloat() > b.backingBalance.AsFloat() {
 		log.Printf("skip level creation, not enough balance of %s asset on backing exchange: %s (needs at least %s)\n", b.backingAssetType, b.backingBalance.AsString(), newPlacedUnits.AsString())
+		b.numSkipped++
 		return false
 	}
 
@@ -0,0 +1,454 @@
+package plugins
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarketOffsetPrice(t *testing.T) {
+	pair := &model.TradingPair{Base: model.Asset("XLM"), Quote: model.Asset("USD")}
+	makeLevels := func(action model.OrderAction, prices []float64, volumes []float64) []model.Order {
+		levels := []model.Order{}
+		for i, p := range prices {
+			levels = append(levels, model.Order{
+				Pair:        pair,
+				OrderAction: action,
+				OrderType:   model.OrderTypeLimit,
+				Price:       model.NumberFromFloat(p, 7),
+				Volume:      model.NumberFromFloat(volumes[i], 7),
+			})
+		}
+		return levels
+	}
+	ob := model.MakeOrderBook(
+		pair,
+		makeLevels(model.OrderActionSell, []float64{1.00, 1.01, 1.05}, []float64{10, 10, 10}),
+		makeLevels(model.OrderActionBuy, []float64{0.99, 0.985, 0.90}, []float64{10, 10, 10}),
+	)
+
+	testCases := []struct {
+		name               string
+		action             model.OrderAction
+		volume             float64
+		maxSlippage        float64
+		wantPrice          float64
+		wantWithinSlippage bool
+	}{
+		{
+			name:               "buy within top level",
+			action:             model.OrderActionBuy,
+			volume:             5,
+			maxSlippage:        0.02,
+			wantPrice:          1.00,
+			wantWithinSlippage: true,
+		}, {
+			name:               "buy across levels",
+			action:             model.OrderActionBuy,
+			volume:             15,
+			maxSlippage:        0.02,
+			wantPrice:          1.01,
+			wantWithinSlippage: true,
+		}, {
+			name:               "buy exceeds slippage",
+			action:             model.OrderActionBuy,
+			volume:             25,
+			maxSlippage:        0.02,
+			wantPrice:          1.02,
+			wantWithinSlippage: false,
+		}, {
+			name:               "sell across levels",
+			action:             model.OrderActionSell,
+			volume:             20,
+			maxSlippage:        0.01,
+			wantPrice:          0.985,
+			wantWithinSlippage: true,
+		}, {
+			name:               "sell deeper than orderbook",
+			action:             model.OrderActionSell,
+			volume:             50,
+			maxSlippage:        0.5,
+			wantPrice:          0.495,
+			wantWithinSlippage: false,
+		},
+	}
+
+	for _, kase := range testCases {
+		t.Run(kase.name, func(t *testing.T) {
+			price, withinSlippage, e := marketOffsetPrice(kase.action, model.NumberFromFloat(kase.volume, 7), ob, kase.maxSlippage)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.InDelta(t, kase.wantPrice, price.AsFloat(), 0.0000001)
+			assert.Equal(t, kase.wantWithinSlippage, withinSlippage)
+		})
+	}
+}
+
+func TestMarketOffsetPriceEmptyOrderbook(t *testing.T) {
+	pair := &model.TradingPair{Base: model.Asset("XLM"), Quote: model.Asset("USD")}
+	ob := model.MakeOrderBook(pair, []model.Order{}, []model.Order{})
+
+	_, _, e := marketOffsetPrice(model.OrderActionBuy, model.NumberFromFloat(1, 7), ob, 0.01)
+	assert.Error(t, e)
+}
+
+func TestPassiveOffsetPrice(t *testing.T) {
+	pair := &model.TradingPair{Base: model.Asset("XLM"), Quote: model.Asset("USD")}
+	makeLevel := func(action model.OrderAction, price float64) model.Order {
+		return model.Order{
+			Pair:        pair,
+			OrderAction: action,
+			OrderType:   model.OrderTypeLimit,
+			Price:       model.NumberFromFloat(price, 7),
+			Volume:      model.NumberFromFloat(10, 7),
+		}
+	}
+	ob := model.MakeOrderBook(
+		pair,
+		[]model.Order{makeLevel(model.OrderActionSell, 1.01), makeLevel(model.OrderActionSell, 1.02)},
+		[]model.Order{makeLevel(model.OrderActionBuy, 0.99), makeLevel(model.OrderActionBuy, 0.98)},
+	)
+
+	price, e := passiveOffsetPrice(model.OrderActionBuy, ob)
+	if assert.NoError(t, e) {
+		assert.Equal(t, 0.99, price.AsFloat())
+	}
+	price, e = passiveOffsetPrice(model.OrderActionSell, ob)
+	if assert.NoError(t, e) {
+		assert.Equal(t, 1.01, price.AsFloat())
+	}
+
+	_, e = passiveOffsetPrice(model.OrderActionBuy, model.MakeOrderBook(pair, ob.Asks(), []model.Order{}))
+	assert.Error(t, e)
+}
+
+func TestModifiedOffsetPrice(t *testing.T) {
+	pair := &model.TradingPair{Base: model.Asset("XLM"), Quote: model.Asset("USD")}
+	makeLevel := func(action model.OrderAction, price float64) model.Order {
+		return model.Order{
+			Pair:        pair,
+			OrderAction: action,
+			OrderType:   model.OrderTypeLimit,
+			Price:       model.NumberFromFloat(price, 7),
+			Volume:      model.NumberFromFloat(10, 7),
+		}
+	}
+	ob := model.MakeOrderBook(
+		pair,
+		[]model.Order{makeLevel(model.OrderActionSell, 1.01), makeLevel(model.OrderActionSell, 1.02)},
+		[]model.Order{makeLevel(model.OrderActionBuy, 0.99), makeLevel(model.OrderActionBuy, 0.98)},
+	)
+
+	testCases := []struct {
+		name      string
+		order     model.Order
+		wantOk    bool
+		wantPrice float64
+	}{
+		{
+			name:      "taker buy moves to top ask",
+			order:     makeLevel(model.OrderActionBuy, 1.00),
+			wantOk:    true,
+			wantPrice: 1.01,
+		}, {
+			name:      "taker sell moves to top bid",
+			order:     makeLevel(model.OrderActionSell, 1.00),
+			wantOk:    true,
+			wantPrice: 0.99,
+		}, {
+			name:      "post-only buy moves to top bid",
+			order:     model.Order{Pair: pair, OrderAction: model.OrderActionBuy, OrderType: model.OrderTypeLimit, Price: model.NumberFromFloat(0.97, 7), Volume: model.NumberFromFloat(10, 7), PostOnly: true},
+			wantOk:    true,
+			wantPrice: 0.99,
+		}, {
+			name:   "post-only sell already at top ask",
+			order:  model.Order{Pair: pair, OrderAction: model.OrderActionSell, OrderType: model.OrderTypeLimit, Price: model.NumberFromFloat(1.01, 7), Volume: model.NumberFromFloat(10, 7), PostOnly: true},
+			wantOk: false,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			price, ok := modifiedOffsetPrice(k.order, ob, 7)
+			if !assert.Equal(t, k.wantOk, ok) || !ok {
+				return
+			}
+			assert.Equal(t, k.wantPrice, price.AsFloat())
+		})
+	}
+
+	_, ok := modifiedOffsetPrice(makeLevel(model.OrderActionBuy, 1.00), model.MakeOrderBook(pair, []model.Order{}, ob.Bids()), 7)
+	assert.False(t, ok)
+}
+
+func TestValidateOffsetPostOnly(t *testing.T) {
+	testCases := []struct {
+		name      string
+		config    mirrorConfig
+		orderType model.OrderType
+		wantError bool
+	}{
+		{
+			name:      "disabled",
+			config:    mirrorConfig{},
+			orderType: model.OrderTypeMarket,
+		}, {
+			name:      "limit with timeout",
+			config:    mirrorConfig{OffsetPostOnly: true, OffsetOrderTimeoutSecs: 30},
+			orderType: model.OrderTypeLimit,
+		}, {
+			name:      "market",
+			config:    mirrorConfig{OffsetPostOnly: true, OffsetOrderTimeoutSecs: 30},
+			orderType: model.OrderTypeMarket,
+			wantError: true,
+		}, {
+			name:      "no timeout",
+			config:    mirrorConfig{OffsetPostOnly: true},
+			orderType: model.OrderTypeLimit,
+			wantError: true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			e := validateOffsetPostOnly(&k.config, k.orderType)
+			assert.Equal(t, k.wantError, e != nil)
+		})
+	}
+}
+
+func TestParseMirrorSides(t *testing.T) {
+	testCases := []struct {
+		sides     string
+		wantBids  bool
+		wantAsks  bool
+		wantError bool
+	}{
+		{sides: "", wantBids: true, wantAsks: true},
+		{sides: "both", wantBids: true, wantAsks: true},
+		{sides: "bids", wantBids: true, wantAsks: false},
+		{sides: "asks", wantBids: false, wantAsks: true},
+		{sides: "sells", wantError: true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.sides, func(t *testing.T) {
+			bids, asks, e := parseMirrorSides(k.sides)
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantBids, bids)
+			assert.Equal(t, k.wantAsks, asks)
+		})
+	}
+}
+
+func TestSummarizeOrderTrades(t *testing.T) {
+	makeTrade := func(price float64, volume float64, fee float64) model.Trade {
+		return model.Trade{
+			Order: model.Order{
+				Price:  model.NumberFromFloat(price, 7),
+				Volume: model.NumberFromFloat(volume, 7),
+			},
+			Fee: model.NumberFromFloat(fee, 7),
+		}
+	}
+
+	volume, avgPrice, fees := summarizeOrderTrades([]model.Trade{
+		makeTrade(0.1, 100, 0.016),
+		makeTrade(0.13, 50, 0.0104),
+	})
+	assert.InDelta(t, 150.0, volume, 1e-9)
+	assert.InDelta(t, 0.11, avgPrice, 1e-9)
+	assert.InDelta(t, 0.0264, fees, 1e-9)
+
+	volume, avgPrice, fees = summarizeOrderTrades([]model.Trade{})
+	assert.Equal(t, 0.0, volume)
+	assert.Equal(t, 0.0, avgPrice)
+	assert.Equal(t, 0.0, fees)
+}
+
+// orderTradesExchange reports the trades of orders from a map, and an error for orders without trades in the map. It records the orders
+// that are added.
+type orderTradesExchange struct {
+	api.Exchange
+	trades map[string][]model.Trade
+	added  []model.Order
+}
+
+func (x *orderTradesExchange) AddOrder(order *model.Order) (*model.TransactionID, error) {
+	x.added = append(x.added, *order)
+	return model.MakeTransactionID(fmt.Sprintf("added%d", len(x.added))), nil
+}
+
+func (x *orderTradesExchange) GetTradesForOrder(txID *model.TransactionID, pair model.TradingPair) ([]model.Trade, error) {
+	trades, ok := x.trades[txID.String()]
+	if !ok {
+		return nil, fmt.Errorf("trades of order '%s' are not available", txID)
+	}
+	return trades, nil
+}
+
+func (x *orderTradesExchange) GetOrderBook(pair *model.TradingPair, maxCount int32) (*model.OrderBook, error) {
+	makeLevel := func(action model.OrderAction) model.Order {
+		return model.Order{Pair: pair, OrderAction: action, Price: model.NumberFromFloat(0.5, 7), Volume: model.NumberFromFloat(1000, 7)}
+	}
+	return model.MakeOrderBook(pair, []model.Order{makeLevel(model.OrderActionSell)}, []model.Order{makeLevel(model.OrderActionBuy)}), nil
+}
+
+// fillRecorder records the fills that it handles
+type fillRecorder struct {
+	fills []model.Trade
+}
+
+func (r *fillRecorder) HandleFill(trade model.Trade) error {
+	r.fills = append(r.fills, trade)
+	return nil
+}
+
+func TestSettleClosedOffset(t *testing.T) {
+	pair := &model.TradingPair{Base: model.XLM, Quote: model.USD}
+	primaryPair := &model.TradingPair{Base: model.XLM, Quote: model.Asset("USDC")}
+	recorder := &fillRecorder{}
+	fill := func(volume float64) model.Trade {
+		return model.Trade{Order: model.Order{Price: model.NumberFromFloat(0.1, 7), Volume: model.NumberFromFloat(volume, 7)}}
+	}
+	exchange := &orderTradesExchange{trades: map[string][]model.Trade{
+		"filled":   {fill(60), fill(40)},
+		"partial":  {fill(40)},
+		"canceled": {},
+	}}
+	s := &mirrorStrategy{
+		exchange:    exchange,
+		backingPair: pair,
+		// the remainders stay in the baseSurplus instead of being re-placed
+		backingConstraints: model.MakeOrderConstraints(7, 7, 1000),
+		offsetMonitor:      makeOffsetOrderMonitor(60),
+		baseSurplus: map[model.OrderAction]*assetSurplus{
+			model.OrderActionBuy:  makeAssetSurplus(),
+			model.OrderActionSell: makeAssetSurplus(),
+		},
+		// one USDT is worth 2 USDC at the mid price of USDC/USDT
+		hedge: &hedgeRoute{
+			conversionPair: &model.TradingPair{Base: model.Asset("USDC"), Quote: model.USD},
+			orderBook:      makeOrderBookStreamer(exchange, &model.TradingPair{Base: model.Asset("USDC"), Quote: model.USD}, 1),
+			mutex:          &sync.Mutex{},
+		},
+		offsetFillHandler: recorder,
+	}
+	s.hedge.addHedge(model.OrderActionSell, 40)
+	order := model.Order{Pair: pair, OrderAction: model.OrderActionSell, Price: model.NumberFromFloat(0.1, 7), Volume: model.NumberFromFloat(100, 7)}
+	for _, txID := range []string{"filled", "partial", "canceled", "unavailable"} {
+		s.offsetMonitor.track(model.MakeTransactionID(txID), order, primaryPair)
+		if !assert.NoError(t, s.settleClosedOffset(txID, s.offsetMonitor.pending[txID])) {
+			return
+		}
+	}
+
+	// the remainders of the partially filled and the canceled orders are offset again, 60 + 100
+	assert.InDelta(t, 160.0, s.baseSurplus[model.OrderActionSell].total.AsFloat(), 1e-9)
+	assert.InDelta(t, 0.0, s.baseSurplus[model.OrderActionBuy].total.AsFloat(), 1e-9)
+	// the hedge asset of the four sell orders was added when they were placed and the remainders never received it, 40 - 16
+	assert.InDelta(t, 24.0, s.hedge.pendingHedge, 1e-9)
+	// the trades of the settled orders are reported on the primary pair
+	if assert.Equal(t, 3, len(recorder.fills)) {
+		for _, f := range recorder.fills {
+			assert.Equal(t, primaryPair, f.Pair)
+			assert.InDelta(t, 0.2, f.Price.AsFloat(), 1e-9)
+		}
+	}
+	// orders are only settled once their trades are known
+	assert.Equal(t, 1, len(s.offsetMonitor.pending))
+	assert.NotNil(t, s.offsetMonitor.pending["unavailable"])
+}
+
+func TestMakePrimaryOffsetTrade(t *testing.T) {
+	backingPair := &model.TradingPair{Base: model.XLM, Quote: model.Asset("USDT")}
+	primaryPair := &model.TradingPair{Base: model.XLM, Quote: model.Asset("USDC")}
+
+	testCases := []struct {
+		name         string
+		feeAsset     model.Asset
+		wantFee      float64
+		wantFeeAsset model.Asset
+	}{
+		{
+			name:         "fee in quote asset",
+			feeAsset:     backingPair.Quote,
+			wantFee:      0.02,
+			wantFeeAsset: primaryPair.Quote,
+		}, {
+			name:         "fee in base asset",
+			feeAsset:     backingPair.Base,
+			wantFee:      0.01,
+			wantFeeAsset: primaryPair.Base,
+		}, {
+			name:         "fee in other asset",
+			feeAsset:     model.Asset("BNB"),
+			wantFee:      0.01,
+			wantFeeAsset: model.Asset("BNB"),
+		}, {
+			name:         "fee asset not reported",
+			feeAsset:     model.Asset(""),
+			wantFee:      0.01,
+			wantFeeAsset: model.Asset(""),
+		},
+	}
+
+	for _, kase := range testCases {
+		t.Run(kase.name, func(t *testing.T) {
+			trade := model.Trade{
+				Order: model.Order{
+					Pair:        backingPair,
+					OrderAction: model.OrderActionSell,
+					Price:       model.NumberFromFloat(0.1, 7),
+					Volume:      model.NumberFromFloat(100, 7),
+				},
+				Fee:      model.NumberFromFloat(0.01, 7),
+				FeeAsset: kase.feeAsset,
+			}
+
+			primary := makePrimaryOffsetTrade(trade, primaryPair, backingPair, 2)
+			assert.Equal(t, primaryPair, primary.Pair)
+			assert.InDelta(t, 0.2, primary.Price.AsFloat(), 1e-9)
+			assert.InDelta(t, 20.0, primary.Cost.AsFloat(), 1e-9)
+			assert.InDelta(t, kase.wantFee, primary.Fee.AsFloat(), 1e-9)
+			assert.Equal(t, kase.wantFeeAsset, primary.FeeAsset)
+			// the trade on the backing pair is not changed
+			assert.Equal(t, backingPair, trade.Pair)
+		})
+	}
+}
+
+func TestReplaceOffsetRoundsDown(t *testing.T) {
+	pair := &model.TradingPair{Base: model.XLM, Quote: model.USD}
+	exchange := &orderTradesExchange{}
+	s := &mirrorStrategy{
+		exchange:           exchange,
+		backingPair:        pair,
+		backingConstraints: model.MakeOrderConstraints(7, 1, 1),
+		offsetMonitor:      makeOffsetOrderMonitor(60),
+		baseSurplus: map[model.OrderAction]*assetSurplus{
+			model.OrderActionBuy:  makeAssetSurplus(),
+			model.OrderActionSell: makeAssetSurplus(),
+		},
+	}
+	s.baseSurplus[model.OrderActionSell].total = model.MustDecimalFromString("10.59")
+
+	if !assert.NoError(t, s.replaceOffset(model.OrderActionSell, pair)) || !assert.Equal(t, 1, len(exchange.added)) {
+		return
+	}
+	assert.Equal(t, "10.5", exchange.added[0].Volume.AsString())
+	// the part that was rounded off stays in the baseSurplus
+	assert.Equal(t, "0.09", s.baseSurplus[model.OrderActionSell].total.String())
+	assert.Equal(t, 1, len(s.offsetMonitor.pending))
+}
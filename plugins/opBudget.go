@@ -0,0 +1,81 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// ledgerCloseDuration is the approximate time between ledgers on the Stellar network
+const ledgerCloseDuration = 5 * time.Second
+
+// opBudget is a token bucket that limits the number of operations submitted per ledger window. Tokens are refilled continuously
+// at a rate of opsPerLedger per ledgerCloseDuration up to the burst capacity. A submission that needs more tokens than are available
+// takes them on credit and waits until the bucket has refilled, so large batches are delayed instead of rejected.
+type opBudget struct {
+	opsPerLedger uint32
+	burst        uint32
+	mutex        *sync.Mutex
+	tokens       float64
+	lastRefill   time.Time
+	now          func() time.Time
+}
+
+// makeOpBudget is a factory method, a burst of 0 uses opsPerLedger as the burst capacity
+func makeOpBudget(opsPerLedger uint32, burst uint32) (*opBudget, error) {
+	if opsPerLedger == 0 {
+		return nil, fmt.Errorf("opsPerLedger needs to be positive")
+	}
+	if burst == 0 {
+		burst = opsPerLedger
+	}
+	if burst < opsPerLedger {
+		return nil, fmt.Errorf("burst (%d) cannot be less than opsPerLedger (%d)", burst, opsPerLedger)
+	}
+
+	return &opBudget{
+		opsPerLedger: opsPerLedger,
+		burst:        burst,
+		mutex:        &sync.Mutex{},
+		tokens:       float64(burst),
+		lastRefill:   time.Now(),
+		now:          time.Now,
+	}, nil
+}
+
+// String is the stringer function
+func (b *opBudget) String() string {
+	return fmt.Sprintf("opBudget[opsPerLedger=%d, burst=%d, ledgerCloseDuration=%s]", b.opsPerLedger, b.burst, ledgerCloseDuration)
+}
+
+// reserve takes numOps tokens from the bucket and returns how long the caller needs to wait before submitting them
+func (b *opBudget) reserve(numOps int) time.Duration {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := b.now()
+	ratePerSecond := float64(b.opsPerLedger) / ledgerCloseDuration.Seconds()
+	b.tokens += now.Sub(b.lastRefill).Seconds() * ratePerSecond
+	if b.tokens > float64(b.burst) {
+		b.tokens = float64(b.burst)
+	}
+	b.lastRefill = now
+
+	b.tokens -= float64(numOps)
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / ratePerSecond * float64(time.Second))
+}
+
+// wait blocks until numOps operations can be submitted within the budget
+func (b *opBudget) wait(numOps int) {
+	delay := b.reserve(numOps)
+	if delay <= 0 {
+		return
+	}
+
+	log.Printf("op budget exhausted, waiting %s before submitting %d ops (%s)\n", delay, numOps, b)
+	time.Sleep(delay)
+}
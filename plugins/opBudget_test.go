@@ -0,0 +1,47 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpBudgetReserve(t *testing.T) {
+	// 10 ops per 5 second ledger is a refill rate of 2 ops per second
+	b, e := makeOpBudget(10, 20)
+	if !assert.NoError(t, e) {
+		return
+	}
+	start := time.Unix(1000, 0)
+	now := start
+	b.now = func() time.Time { return now }
+	b.lastRefill = start
+
+	// the full burst is available without waiting
+	assert.Equal(t, time.Duration(0), b.reserve(20))
+	// the next op needs to wait for half a second of refill
+	assert.Equal(t, 500*time.Millisecond, b.reserve(1))
+
+	// ops taken on credit need to be paid back before more ops can be submitted
+	now = start.Add(time.Second)
+	assert.Equal(t, time.Second, b.reserve(3))
+
+	// the bucket never refills above the burst capacity
+	now = start.Add(time.Hour)
+	assert.Equal(t, time.Duration(0), b.reserve(20))
+	assert.Equal(t, 2500*time.Millisecond, b.reserve(5))
+}
+
+func TestMakeOpBudget(t *testing.T) {
+	b, e := makeOpBudget(10, 0)
+	if assert.NoError(t, e) {
+		assert.Equal(t, uint32(10), b.burst)
+	}
+
+	_, e = makeOpBudget(0, 10)
+	assert.Error(t, e)
+
+	_, e = makeOpBudget(10, 5)
+	assert.Error(t, e)
+}
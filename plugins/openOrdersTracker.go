@@ -0,0 +1,164 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// zombieOrderAction is what the open orders tracker does with zombie orders
+type zombieOrderAction string
+
+// zombieOrderAction values
+const (
+	zombieOrderActionWarn   zombieOrderAction = "warn"
+	zombieOrderActionCancel zombieOrderAction = "cancel"
+)
+
+// parseZombieOrderAction parses the ZOMBIE_ORDER_ACTION config, defaults to warn
+func parseZombieOrderAction(action string) (zombieOrderAction, error) {
+	switch action {
+	case "", string(zombieOrderActionWarn):
+		return zombieOrderActionWarn, nil
+	case string(zombieOrderActionCancel):
+		return zombieOrderActionCancel, nil
+	default:
+		return "", fmt.Errorf("invalid ZOMBIE_ORDER_ACTION '%s', needs to be either 'warn' or 'cancel'", action)
+	}
+}
+
+// defaultZombieOrderGraceSecs is how long an order can be open before it is flagged when ZOMBIE_ORDER_GRACE_SECONDS is not set
+const defaultZombieOrderGraceSecs uint32 = 300
+
+// zombieOrder is an open order that the bot is not managing
+type zombieOrder struct {
+	order   model.OpenOrder
+	openFor time.Duration
+}
+
+// openOrdersTracker maintains a view of the open orders of the account on a trading pair of an exchange and flags zombie orders, which
+// are orders that the bot is not managing and that have been open for longer than the grace period. The exchange API does not stream
+// open orders so the view is refreshed by polling.
+type openOrdersTracker struct {
+	exchange    api.Exchange
+	pair        *model.TradingPair
+	interval    time.Duration
+	gracePeriod time.Duration
+	action      zombieOrderAction
+
+	// uninitialized
+	firstSeen  map[string]time.Time // orders that were open as of the last refresh, keyed by ID
+	openOrders []model.OpenOrder
+	lastCheck  time.Time
+}
+
+// makeOpenOrdersTracker is a factory method, returns nil when the interval is 0 which disables the tracker
+func makeOpenOrdersTracker(exchange api.Exchange, pair *model.TradingPair, intervalSeconds uint32, graceSeconds uint32, action zombieOrderAction) *openOrdersTracker {
+	if intervalSeconds == 0 {
+		return nil
+	}
+
+	return &openOrdersTracker{
+		exchange:    exchange,
+		pair:        pair,
+		interval:    time.Duration(intervalSeconds) * time.Second,
+		gracePeriod: time.Duration(graceSeconds) * time.Second,
+		action:      action,
+		firstSeen:   map[string]time.Time{},
+		openOrders:  []model.OpenOrder{},
+	}
+}
+
+// String is the stringer method
+func (t *openOrdersTracker) String() string {
+	return fmt.Sprintf("openOrdersTracker[pair=%s, interval=%s, gracePeriod=%s, action=%s]", t.pair, t.interval, t.gracePeriod, t.action)
+}
+
+// isDue returns true when the view needs to be refreshed
+func (t *openOrdersTracker) isDue(now time.Time) bool {
+	return now.Sub(t.lastCheck) >= t.interval
+}
+
+// refresh fetches the open orders from the exchange and returns the zombie orders, intents are the IDs of the orders the bot is managing
+func (t *openOrdersTracker) refresh(intents map[string]bool, now time.Time) ([]zombieOrder, error) {
+	openOrdersMap, e := t.exchange.GetOpenOrders([]*model.TradingPair{t.pair})
+	if e != nil {
+		return nil, fmt.Errorf("unable to fetch open orders: %s", e)
+	}
+	t.lastCheck = now
+	return t.update(openOrdersMap[*t.pair], intents, now), nil
+}
+
+// update replaces the view with the given open orders and returns the zombie orders. We cannot rely on the start time reported by the
+// exchanges so the age of an order is measured from when it was first seen, which means orders left over from before a restart are
+// flagged once the grace period has passed after the restart.
+func (t *openOrdersTracker) update(openOrders []model.OpenOrder, intents map[string]bool, now time.Time) []zombieOrder {
+	firstSeen := map[string]time.Time{}
+	zombies := []zombieOrder{}
+	for _, o := range openOrders {
+		seen, ok := t.firstSeen[o.ID]
+		if !ok {
+			seen = now
+		}
+		firstSeen[o.ID] = seen
+
+		openFor := now.Sub(seen)
+		// stop orders are meant to rest on the exchange until they are triggered, for example to protect the hedge inventory
+		if intents[o.ID] || o.OrderType.IsStop() || openFor < t.gracePeriod {
+			continue
+		}
+		zombies = append(zombies, zombieOrder{order: o, openFor: openFor})
+	}
+
+	t.firstSeen = firstSeen
+	t.openOrders = openOrders
+	return zombies
+}
+
+// checkZombieOrders refreshes the view of the open orders on the backing exchange and warns about or cancels the zombie orders. Offset
+// orders are only managed by the bot while the offset monitor tracks them, so offset orders that are still open after the grace period
+// are flagged when OFFSET_ORDER_TIMEOUT_SECONDS is not set. Cancelled zombie orders are not offset again.
+func (s *mirrorStrategy) checkZombieOrders() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	intents := map[string]bool{}
+	if s.offsetMonitor != nil {
+		for txID := range s.offsetMonitor.pending {
+			intents[txID] = true
+		}
+	}
+	zombies, e := s.zombieTracker.refresh(intents, time.Now())
+	if e != nil {
+		return fmt.Errorf("unable to refresh open orders on backing exchange: %s", e)
+	}
+	log.Printf("open-orders | pair=%s | numOpen=%d | numManaged=%d | numZombies=%d\n", s.backingPair, len(s.zombieTracker.openOrders), len(intents), len(zombies))
+
+	for _, z := range zombies {
+		executed := model.NumberConstants.Zero
+		if z.order.VolumeExecuted != nil {
+			executed = z.order.VolumeExecuted
+		}
+		log.Printf("zombie-order | transactionID=%s | orderAction=%s | baseAmt=%f | executedBaseAmt=%f | priceQuote=%f | openSecs=%.0f | action=%s\n",
+			z.order.ID,
+			z.order.OrderAction.String(),
+			z.order.Volume.AsFloat(),
+			executed.AsFloat(),
+			z.order.Price.AsFloat(),
+			z.openFor.Seconds(),
+			s.zombieTracker.action)
+		if s.zombieTracker.action != zombieOrderActionCancel {
+			continue
+		}
+
+		result, e := s.exchange.CancelOrder(model.MakeTransactionID(z.order.ID), *s.backingPair)
+		if e != nil {
+			return fmt.Errorf("unable to cancel zombie order (transactionID=%s): %s", z.order.ID, e)
+		}
+		log.Printf("zombie-cancel | transactionID=%s | result=%s\n", z.order.ID, result.String())
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseZombieOrderAction(t *testing.T) {
+	action, e := parseZombieOrderAction("")
+	if assert.NoError(t, e) {
+		assert.Equal(t, zombieOrderActionWarn, action)
+	}
+	action, e = parseZombieOrderAction("cancel")
+	if assert.NoError(t, e) {
+		assert.Equal(t, zombieOrderActionCancel, action)
+	}
+	_, e = parseZombieOrderAction("delete")
+	assert.Error(t, e)
+}
+
+func TestOpenOrdersTrackerUpdate(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	tracker := makeOpenOrdersTracker(nil, &model.TradingPair{Base: model.XLM, Quote: model.USD}, 30, 300, zombieOrderActionWarn)
+	assert.True(t, tracker.isDue(now))
+	assert.Nil(t, makeOpenOrdersTracker(nil, &model.TradingPair{Base: model.XLM, Quote: model.USD}, 0, 300, zombieOrderActionWarn))
+
+	orders := []model.OpenOrder{{ID: "managed"}, {ID: "forgotten"}}
+	intents := map[string]bool{"managed": true}
+	// orders are not flagged within the grace period
+	assert.Equal(t, 0, len(tracker.update(orders, intents, now)))
+	assert.Equal(t, 0, len(tracker.update(orders, intents, now.Add(299*time.Second))))
+
+	// a new order starts its own grace period
+	orders = append(orders, model.OpenOrder{ID: "new"})
+	zombies := tracker.update(orders, intents, now.Add(300*time.Second))
+	if assert.Equal(t, 1, len(zombies)) {
+		assert.Equal(t, "forgotten", zombies[0].order.ID)
+		assert.Equal(t, 300*time.Second, zombies[0].openFor)
+	}
+	assert.Equal(t, 3, len(tracker.openOrders))
+
+	// orders that are no longer open are dropped from the view, and an order that is re-opened with the same ID starts over
+	zombies = tracker.update([]model.OpenOrder{{ID: "new"}}, map[string]bool{}, now.Add(600*time.Second))
+	if assert.Equal(t, 1, len(zombies)) {
+		assert.Equal(t, "new", zombies[0].order.ID)
+	}
+	assert.Equal(t, 1, len(tracker.firstSeen))
+	assert.Equal(t, 0, len(tracker.update([]model.OpenOrder{{ID: "forgotten"}}, map[string]bool{}, now.Add(601*time.Second))))
+}
+
+func TestOpenOrdersTrackerSkipsStopOrders(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	tracker := makeOpenOrdersTracker(nil, &model.TradingPair{Base: model.XLM, Quote: model.USD}, 30, 300, zombieOrderActionCancel)
+	orders := []model.OpenOrder{
+		{ID: "stop", Order: model.Order{OrderType: model.OrderTypeStopLoss}},
+		{ID: "limit", Order: model.Order{OrderType: model.OrderTypeLimit}},
+	}
+	assert.Equal(t, 0, len(tracker.update(orders, map[string]bool{}, now)))
+
+	zombies := tracker.update(orders, map[string]bool{}, now.Add(300*time.Second))
+	if assert.Equal(t, 1, len(zombies)) {
+		assert.Equal(t, "limit", zombies[0].order.ID)
+	}
+}
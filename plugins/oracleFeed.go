@@ -0,0 +1,168 @@
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// oracleFeed reads prices published on-chain so pricing does not depend on centralized REST APIs.
+// The URL "stellar/<maxAgeSeconds>/<accountID>/<dataKey>" reads a data entry of a Stellar account with the value "<price>,<unixTimestampSeconds>".
+// The URL "chainlink/<maxAgeSeconds>/<gatewayURL>" reads the latest round from a Chainlink-compatible HTTP gateway (see chainlinkRoundData).
+type oracleFeed struct {
+	url        string
+	maxAge     time.Duration
+	fetchPrice func() (price float64, updatedAt time.Time, e error)
+}
+
+// ensure that it implements PriceQuoteFeed
+var _ api.PriceQuoteFeed = &oracleFeed{}
+
+// ensure that it implements TimestampedPriceFeed
+var _ api.TimestampedPriceFeed = &oracleFeed{}
+
+// chainlinkRoundData is the response from a Chainlink-compatible gateway, modeled after the latestRoundData call on an aggregator
+type chainlinkRoundData struct {
+	Answer    json.Number `json:"answer"`
+	Decimals  uint8       `json:"decimals"`
+	UpdatedAt int64       `json:"updatedAt"`
+}
+
+// makeOracleFeed is a factory method
+func makeOracleFeed(url string) (*oracleFeed, error) {
+	// [0] = source, [1] = maxAgeSeconds, [2] = source-specific remainder
+	urlParts := strings.SplitN(url, "/", 3)
+	if len(urlParts) != 3 {
+		return nil, fmt.Errorf("invalid format of oracle type URL, needs at least 3 parts after splitting URL by '/', has %d: %s", len(urlParts), url)
+	}
+
+	maxAgeSeconds, e := strconv.ParseUint(urlParts[1], 10, 32)
+	if e != nil || maxAgeSeconds == 0 {
+		return nil, fmt.Errorf("invalid maxAgeSeconds in oracle type URL, needs to be a positive integer: %s", urlParts[1])
+	}
+	f := &oracleFeed{
+		url:    url,
+		maxAge: time.Duration(maxAgeSeconds) * time.Second,
+	}
+
+	switch urlParts[0] {
+	case "stellar":
+		// [0] = accountID, [1] = dataKey
+		accountParts := strings.Split(urlParts[2], "/")
+		if len(accountParts) != 2 {
+			return nil, fmt.Errorf("invalid format of stellar oracle URL, needs an accountID and dataKey separated by '/': %s", url)
+		}
+
+		// the oracle account lives on the network the bot trades on, defaulting to another network could read a different account
+		if privateSdexHackVar == nil {
+			return nil, fmt.Errorf("stellar oracle URL can only be used by a bot since it reads from the Horizon server of the bot: %s", url)
+		}
+		client := privateSdexHackVar.API
+		f.fetchPrice = func() (float64, time.Time, error) {
+			return fetchStellarOraclePrice(client, accountParts[0], accountParts[1])
+		}
+	case "chainlink":
+		gatewayURL := urlParts[2]
+		httpClient := http.Client{Timeout: 10 * time.Second}
+		f.fetchPrice = func() (float64, time.Time, error) {
+			return fetchChainlinkOraclePrice(httpClient, gatewayURL)
+		}
+	default:
+		return nil, fmt.Errorf("unrecognized oracle source '%s', needs to be either 'stellar' or 'chainlink'", urlParts[0])
+	}
+	return f, nil
+}
+
+func fetchStellarOraclePrice(client *horizonclient.Client, accountID string, dataKey string) (float64, time.Time, error) {
+	account, e := client.AccountDetail(horizonclient.AccountRequest{AccountID: accountID})
+	if e != nil {
+		return 0, time.Time{}, fmt.Errorf("unable to load oracle account '%s': %s", accountID, e)
+	}
+
+	valueBytes, e := account.GetData(dataKey)
+	if e != nil {
+		return 0, time.Time{}, fmt.Errorf("unable to read data entry '%s' on oracle account '%s': %s", dataKey, accountID, e)
+	}
+
+	// [0] = price, [1] = unix timestamp in seconds
+	valueParts := strings.Split(string(valueBytes), ",")
+	if len(valueParts) != 2 {
+		return 0, time.Time{}, fmt.Errorf("invalid value in data entry '%s' on oracle account '%s', needs to be '<price>,<unixTimestampSeconds>': %s", dataKey, accountID, string(valueBytes))
+	}
+	price, e := strconv.ParseFloat(valueParts[0], 64)
+	if e != nil {
+		return 0, time.Time{}, fmt.Errorf("unable to parse price in data entry '%s' on oracle account '%s': %s", dataKey, accountID, e)
+	}
+	ts, e := strconv.ParseInt(valueParts[1], 10, 64)
+	if e != nil {
+		return 0, time.Time{}, fmt.Errorf("unable to parse timestamp in data entry '%s' on oracle account '%s': %s", dataKey, accountID, e)
+	}
+	return price, time.Unix(ts, 0), nil
+}
+
+func fetchChainlinkOraclePrice(httpClient http.Client, gatewayURL string) (float64, time.Time, error) {
+	var roundData chainlinkRoundData
+	e := utils.GetJSON(httpClient, gatewayURL, &roundData)
+	if e != nil {
+		return 0, time.Time{}, fmt.Errorf("unable to fetch round data from chainlink gateway: %s", e)
+	}
+
+	answer, e := roundData.Answer.Float64()
+	if e != nil {
+		return 0, time.Time{}, fmt.Errorf("unable to parse answer from chainlink gateway (%s): %s", roundData.Answer, e)
+	}
+	price := answer / math.Pow(10, float64(roundData.Decimals))
+	return price, time.Unix(roundData.UpdatedAt, 0), nil
+}
+
+// GetPrice impl, returns an error if the oracle has not been updated within maxAge
+func (f *oracleFeed) GetPrice() (float64, error) {
+	q, e := f.GetPriceQuote()
+	if e != nil {
+		return 0, e
+	}
+	return q.Mid, nil
+}
+
+// GetPriceQuote impl, oracles only publish a single price so the bid and ask are not available
+func (f *oracleFeed) GetPriceQuote() (*api.PriceQuote, error) {
+	price, updatedAt, e := f.fetchPrice()
+	if e != nil {
+		return nil, fmt.Errorf("unable to fetch price from oracle (%s): %s", f.url, e)
+	}
+
+	age := time.Since(updatedAt)
+	if age > f.maxAge {
+		return nil, fmt.Errorf("oracle price (%s) is stale, last updated at %s which is older than the max age of %s", f.url, updatedAt.Format(time.RFC3339), f.maxAge)
+	}
+	if price <= 0 {
+		return nil, fmt.Errorf("oracle price (%s) needs to be positive, was %f", f.url, price)
+	}
+	return &api.PriceQuote{
+		Mid:           price,
+		LastTradeTime: &updatedAt,
+		Source:        fmt.Sprintf("oracle (%s)", f.url),
+	}, nil
+}
+
+// GetTimestampedPrice impl, the price is updated when the oracle published it
+func (f *oracleFeed) GetTimestampedPrice() (*api.TimestampedPrice, error) {
+	fetchedAt := time.Now()
+	q, e := f.GetPriceQuote()
+	if e != nil {
+		return nil, e
+	}
+	return &api.TimestampedPrice{
+		Price:     q.Mid,
+		UpdatedAt: *q.LastTradeTime,
+		FetchedAt: fetchedAt,
+	}, nil
+}
@@ -0,0 +1,145 @@
+package plugins
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stellar/go/build"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeOracleFeed(t *testing.T) {
+	testCases := []struct {
+		url        string
+		hasHorizon bool
+		wantMaxAge time.Duration
+		wantErr    bool
+	}{
+		{
+			url:        "stellar/300/" + testIssuer + "/XLM_USD",
+			hasHorizon: true,
+			wantMaxAge: 300 * time.Second,
+		}, {
+			// the oracle account is only read from the Horizon server of the bot
+			url:        "stellar/300/" + testIssuer + "/XLM_USD",
+			hasHorizon: false,
+			wantErr:    true,
+		}, {
+			url:        "stellar/300/" + testIssuer,
+			hasHorizon: true,
+			wantErr:    true,
+		}, {
+			url:        "stellar/300/" + testIssuer + "/XLM_USD/extra",
+			hasHorizon: true,
+			wantErr:    true,
+		}, {
+			url:        "chainlink/60/https://example.com/feeds/xlm-usd/latestRoundData",
+			wantMaxAge: 60 * time.Second,
+		}, {
+			url:     "chainlink/0/https://example.com/feeds/xlm-usd/latestRoundData",
+			wantErr: true,
+		}, {
+			url:     "chainlink/-5/https://example.com/feeds/xlm-usd/latestRoundData",
+			wantErr: true,
+		}, {
+			url:     "chainlink/60",
+			wantErr: true,
+		}, {
+			url:     "band/60/https://example.com",
+			wantErr: true,
+		},
+	}
+
+	defer func(original *privateSdexHack) { privateSdexHackVar = original }(privateSdexHackVar)
+	for _, kase := range testCases {
+		t.Run(fmt.Sprintf("%s_%v", kase.url, kase.hasHorizon), func(t *testing.T) {
+			privateSdexHackVar = nil
+			if kase.hasHorizon {
+				privateSdexHackVar = &privateSdexHack{API: horizonclient.DefaultTestNetClient, Network: build.TestNetwork}
+			}
+
+			f, e := makeOracleFeed(kase.url)
+			if kase.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, kase.wantMaxAge, f.maxAge)
+			assert.NotNil(t, f.fetchPrice)
+		})
+	}
+}
+
+func TestOracleFeedStaleness(t *testing.T) {
+	now := time.Now()
+	testCases := []struct {
+		name      string
+		price     float64
+		updatedAt time.Time
+		fetchErr  error
+		wantErr   bool
+	}{
+		{
+			name:      "fresh",
+			price:     0.1,
+			updatedAt: now.Add(-30 * time.Second),
+		}, {
+			name:      "stale",
+			price:     0.1,
+			updatedAt: now.Add(-90 * time.Second),
+			wantErr:   true,
+		}, {
+			name:      "non-positive price",
+			price:     0,
+			updatedAt: now.Add(-30 * time.Second),
+			wantErr:   true,
+		}, {
+			name:     "fetch error",
+			fetchErr: fmt.Errorf("gateway unavailable"),
+			wantErr:  true,
+		},
+	}
+
+	for _, kase := range testCases {
+		t.Run(kase.name, func(t *testing.T) {
+			f := &oracleFeed{
+				url:    "chainlink/60/https://example.com",
+				maxAge: 60 * time.Second,
+				fetchPrice: func() (float64, time.Time, error) {
+					return kase.price, kase.updatedAt, kase.fetchErr
+				},
+			}
+
+			tp, e := f.GetTimestampedPrice()
+			if kase.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, kase.price, tp.Price)
+			assert.Equal(t, kase.updatedAt, tp.UpdatedAt)
+		})
+	}
+}
+
+func TestFetchChainlinkOraclePrice(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"answer": "12345678", "decimals": 8, "updatedAt": 1600000000}`))
+	}))
+	defer server.Close()
+
+	price, updatedAt, e := fetchChainlinkOraclePrice(http.Client{Timeout: 5 * time.Second}, server.URL)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.InDelta(t, 0.12345678, price, 1e-12)
+	assert.Equal(t, int64(1600000000), updatedAt.Unix())
+}
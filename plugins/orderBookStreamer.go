@@ -0,0 +1,85 @@
+package plugins
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/monitoring"
+)
+
+// orderBookStreamRetryDelay is how long the orderBookStreamer waits before reconnecting an OrderBookStream that failed
+const orderBookStreamRetryDelay = 5 * time.Second
+
+// orderBookStreamStaleAfter is how long a streamed orderbook is used after its last update before falling back to fetching the orderbook
+const orderBookStreamStaleAfter = 30 * time.Second
+
+// orderBookStreamer serves the latest orderbook pushed by the exchange when it implements api.OrderBookStream, and fetches the orderbook
+// from the exchange when it cannot stream orderbooks or while the stream is disconnected or stale
+type orderBookStreamer struct {
+	exchange api.Exchange
+	pair     *model.TradingPair
+	maxCount int32
+	mutex    *sync.Mutex
+
+	// uninitialized
+	started   bool
+	latest    *model.OrderBook
+	updatedAt time.Time
+}
+
+// makeOrderBookStreamer is a factory method, the stream is started lazily on the first call to GetOrderBook
+func makeOrderBookStreamer(exchange api.Exchange, pair *model.TradingPair, maxCount int32) *orderBookStreamer {
+	return &orderBookStreamer{
+		exchange: exchange,
+		pair:     pair,
+		maxCount: maxCount,
+		mutex:    &sync.Mutex{},
+	}
+}
+
+// GetOrderBook returns the latest orderbook of the pair
+func (o *orderBookStreamer) GetOrderBook() (*model.OrderBook, error) {
+	o.mutex.Lock()
+	if !o.started {
+		o.started = true
+		if orderBookStream, ok := o.exchange.(api.OrderBookStream); ok {
+			go o.stream(orderBookStream)
+		}
+	}
+	latest := o.latest
+	updatedAt := o.updatedAt
+	o.mutex.Unlock()
+
+	if latest != nil && time.Since(updatedAt) <= orderBookStreamStaleAfter {
+		return latest, nil
+	}
+	defer monitoring.StartSpan(monitoring.SpanOrderBookFetch)()
+	return o.exchange.GetOrderBook(o.pair, o.maxCount)
+}
+
+// stream runs forever, reconnecting the orderbook stream whenever it fails
+func (o *orderBookStreamer) stream(orderBookStream api.OrderBookStream) {
+	for {
+		log.Printf("starting orderbook stream for pair %s\n", o.pair)
+		e := orderBookStream.StreamOrderBook(o.pair, o.maxCount, func(ob *model.OrderBook) {
+			o.mutex.Lock()
+			defer o.mutex.Unlock()
+			o.latest = ob
+			o.updatedAt = time.Now()
+		})
+		// do not serve the last streamed orderbook while disconnected
+		o.mutex.Lock()
+		o.latest = nil
+		o.mutex.Unlock()
+
+		if e == api.ErrOrderBookStreamUnsupported {
+			log.Printf("exchange does not support streaming orderbooks, fetching the orderbook for pair %s on every request\n", o.pair)
+			return
+		}
+		log.Printf("orderbook stream for pair %s ended, fetching the orderbook until it reconnects in %s: %s\n", o.pair, orderBookStreamRetryDelay, e)
+		time.Sleep(orderBookStreamRetryDelay)
+	}
+}
@@ -60,6 +60,17 @@ func (f *orderConstraintsFilter) Apply(
 				return nil, fmt.Errorf("could not check transform offer (non-pointer case): %s", e)
 			}
 			opPtr = &o
+		case *ManageBuyOfferBuilder:
+			if f.shouldKeepBuyOffer(o) {
+				filteredOps = append(filteredOps, o)
+				numKeep++
+			} else {
+				numDropped++
+				if droppedOp := o.dropped(); droppedOp != nil {
+					filteredOps = append(filteredOps, droppedOp)
+				}
+			}
+			continue
 		default:
 			keep = true
 		}
@@ -128,3 +139,24 @@ func (f *orderConstraintsFilter) shouldKeepOffer(op *build.ManageOfferBuilder) (
 	log.Printf("orderConstraintsFilter:  buying, baseAmount=%.8f, quoteAmount=%.8f, keep = true\n", baseAmount, quoteAmount)
 	return true, nil
 }
+
+// shouldKeepBuyOffer checks the constraints for a native buy offer, its amount is in units of the base asset
+func (f *orderConstraintsFilter) shouldKeepBuyOffer(op *ManageBuyOfferBuilder) bool {
+	// delete operations should never be dropped
+	if op.MBO.BuyAmount == 0 {
+		return true
+	}
+
+	baseAmount := op.BuyAmount()
+	quoteAmount := baseAmount * op.BuyPrice()
+	if baseAmount < f.oc.MinBaseVolume.AsFloat() {
+		log.Printf("orderConstraintsFilter:  buying, keep = (baseAmount) %.8f < %s (MinBaseVolume): keep = false\n", baseAmount, f.oc.MinBaseVolume.AsString())
+		return false
+	}
+	if f.oc.MinQuoteVolume != nil && quoteAmount < f.oc.MinQuoteVolume.AsFloat() {
+		log.Printf("orderConstraintsFilter:  buying, keep = (quoteAmount) %.8f < %s (MinQuoteVolume): keep = false\n", quoteAmount, f.oc.MinQuoteVolume.AsString())
+		return false
+	}
+	log.Printf("orderConstraintsFilter:  buying, baseAmount=%.8f, quoteAmount=%.8f, keep = true\n", baseAmount, quoteAmount)
+	return true
+}
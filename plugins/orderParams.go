@@ -0,0 +1,38 @@
+package plugins
+
+import (
+	"strings"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// orderParamPrefix is the prefix of the EXCHANGE_PARAMS that are sent with every order instead of being used to make the exchange, for
+// example order_timeInForce="IOC" sends timeInForce="IOC" with every order
+const orderParamPrefix = "order_"
+
+// extractOrderParams separates the params sent with every order, without the prefix, from the params meant for making the exchange
+func extractOrderParams(exchangeParams []api.ExchangeParam) (map[string]string, []api.ExchangeParam) {
+	orderParams := map[string]string{}
+	innerParams := []api.ExchangeParam{}
+	for _, p := range exchangeParams {
+		if strings.HasPrefix(p.Param, orderParamPrefix) {
+			orderParams[strings.TrimPrefix(p.Param, orderParamPrefix)] = p.Value
+			continue
+		}
+		innerParams = append(innerParams, p)
+	}
+	return orderParams, innerParams
+}
+
+// mergeOrderParams returns the params to send with the order, the params set on the order take precedence over the params of the exchange
+func mergeOrderParams(exchangeOrderParams map[string]string, order *model.Order) map[string]string {
+	merged := map[string]string{}
+	for k, v := range exchangeOrderParams {
+		merged[k] = v
+	}
+	for k, v := range order.Params {
+		merged[k] = v
+	}
+	return merged
+}
@@ -0,0 +1,37 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractOrderParams(t *testing.T) {
+	orderParams, innerParams := extractOrderParams([]api.ExchangeParam{
+		{Param: "order_timeInForce", Value: "GTC"},
+		{Param: "recvWindow", Value: "5000"},
+		{Param: "order_oflags", Value: "fciq"},
+	})
+	assert.Equal(t, map[string]string{"timeInForce": "GTC", "oflags": "fciq"}, orderParams)
+	assert.Equal(t, []api.ExchangeParam{{Param: "recvWindow", Value: "5000"}}, innerParams)
+}
+
+func TestMergeOrderParams(t *testing.T) {
+	exchangeOrderParams := map[string]string{"timeInForce": "GTC", "oflags": "fciq"}
+	order := &model.Order{Params: map[string]string{"timeInForce": "IOC"}}
+
+	assert.Equal(t, map[string]string{"timeInForce": "IOC", "oflags": "fciq"}, mergeOrderParams(exchangeOrderParams, order))
+	assert.Equal(t, map[string]string{"timeInForce": "GTC", "oflags": "fciq"}, mergeOrderParams(exchangeOrderParams, &model.Order{}))
+	// the params of the exchange are not modified
+	assert.Equal(t, "GTC", exchangeOrderParams["timeInForce"])
+}
+
+func TestWithKrakenOrderParams(t *testing.T) {
+	args := withKrakenOrderParams(map[string]string{"price": "0.1", "oflags": "post"}, map[string]string{"oflags": "fciq,post", "timeinforce": "GTC"})
+	assert.Equal(t, map[string]string{"price": "0.1", "oflags": "post,fciq", "timeinforce": "GTC"}, args)
+
+	args = withKrakenOrderParams(map[string]string{"price": "0.1"}, map[string]string{"oflags": "fcib"})
+	assert.Equal(t, map[string]string{"price": "0.1", "oflags": "fcib"}, args)
+}
@@ -0,0 +1,220 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// pendulumConfig contains the configuration params for this Strategy
+type pendulumConfig struct {
+	PriceTolerance        float64 `valid:"-" toml:"PRICE_TOLERANCE"`
+	AmountTolerance       float64 `valid:"-" toml:"AMOUNT_TOLERANCE"`
+	AmountOfABase         float64 `valid:"-" toml:"AMOUNT_OF_A_BASE"`        // the size of the buy and the sell order
+	StartPrice            float64 `valid:"-" toml:"START_PRICE"`             // center price used until the first fill
+	Spread                float64 `valid:"-" toml:"SPREAD"`                  // this is the bid-ask spread (i.e. it is not the spread from the center price)
+	PriceIncrementPercent float64 `valid:"-" toml:"PRICE_INCREMENT_PERCENT"` // how far the center price is moved from the fill price in the direction of the fill
+	MinPrice              float64 `valid:"-" toml:"MIN_PRICE"`               // no buy order is placed below this price, 0 for no bound
+	MaxPrice              float64 `valid:"-" toml:"MAX_PRICE"`               // no sell order is placed above this price, 0 for no bound
+}
+
+// String impl.
+func (c pendulumConfig) String() string {
+	return utils.StructString(c, nil)
+}
+
+// validate checks the config
+func (c *pendulumConfig) validate() error {
+	if c.AmountOfABase <= 0 {
+		return fmt.Errorf("AMOUNT_OF_A_BASE needs to be positive: %f", c.AmountOfABase)
+	}
+	if c.StartPrice <= 0 {
+		return fmt.Errorf("START_PRICE needs to be positive: %f", c.StartPrice)
+	}
+	if c.Spread <= 0 || c.Spread >= 1 {
+		return fmt.Errorf("SPREAD needs to be between 0 and 1: %f", c.Spread)
+	}
+	if c.PriceIncrementPercent < 0 || c.PriceIncrementPercent >= 1 {
+		return fmt.Errorf("PRICE_INCREMENT_PERCENT needs to be between 0 and 1: %f", c.PriceIncrementPercent)
+	}
+	if c.MinPrice < 0 || c.MaxPrice < 0 {
+		return fmt.Errorf("MIN_PRICE and MAX_PRICE cannot be negative (minPrice=%f, maxPrice=%f)", c.MinPrice, c.MaxPrice)
+	}
+	if c.MaxPrice > 0 && c.MinPrice >= c.MaxPrice {
+		return fmt.Errorf("MIN_PRICE needs to be less than MAX_PRICE (minPrice=%f, maxPrice=%f)", c.MinPrice, c.MaxPrice)
+	}
+	return nil
+}
+
+// makePendulumStrategy is a factory method for the pendulum strategy, which places one buy and one sell order around the price of the last
+// fill and moves the price in the direction of every fill so it swings back and forth between buying and selling
+func makePendulumStrategy(
+	sdex *SDEX,
+	pair *model.TradingPair,
+	ieif *IEIF,
+	assetBase *hProtocol.Asset,
+	assetQuote *hProtocol.Asset,
+	config *pendulumConfig,
+) (api.Strategy, error) {
+	e := config.validate()
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the pendulum strategy because of an invalid config: %s", e)
+	}
+
+	orderConstraints := sdex.GetOrderConstraints(pair)
+	state := makePendulumState(config.StartPrice, config.PriceIncrementPercent, config.MinPrice, config.MaxPrice)
+	sellSideStrategy := makeSellSideStrategy(
+		sdex,
+		orderConstraints,
+		ieif,
+		assetBase,
+		assetQuote,
+		makePendulumLevelProvider(state, false, config.Spread, config.AmountOfABase, orderConstraints),
+		config.PriceTolerance,
+		config.AmountTolerance,
+		false,
+	)
+	// switch sides of base/quote here for buy side
+	buySideStrategy := makeSellSideStrategy(
+		sdex,
+		orderConstraints,
+		ieif,
+		assetQuote,
+		assetBase,
+		makePendulumLevelProvider(state, true, config.Spread, config.AmountOfABase, orderConstraints),
+		config.PriceTolerance,
+		config.AmountTolerance,
+		true,
+	)
+
+	return makeComposeStrategy(
+		assetBase,
+		assetQuote,
+		buySideStrategy,
+		sellSideStrategy,
+	), nil
+}
+
+// pendulumState is the center price shared by both sides of the pendulum strategy, which is moved by the fills of the bot
+type pendulumState struct {
+	priceIncrementPercent float64
+	minPrice              float64
+	maxPrice              float64
+
+	// uninitialized
+	mutex       *sync.Mutex
+	centerPrice float64
+}
+
+// ensure it implements the FillHandler interface
+var _ api.FillHandler = &pendulumState{}
+
+// makePendulumState is a factory method
+func makePendulumState(startPrice float64, priceIncrementPercent float64, minPrice float64, maxPrice float64) *pendulumState {
+	s := &pendulumState{
+		priceIncrementPercent: priceIncrementPercent,
+		minPrice:              minPrice,
+		maxPrice:              maxPrice,
+		mutex:                 &sync.Mutex{},
+	}
+	s.centerPrice = s.bound(startPrice)
+	return s
+}
+
+// bound keeps the price within the min and max price
+func (s *pendulumState) bound(price float64) float64 {
+	if s.maxPrice > 0 && price > s.maxPrice {
+		return s.maxPrice
+	}
+	if price < s.minPrice {
+		return s.minPrice
+	}
+	return price
+}
+
+// getCenterPrice returns the current center price
+func (s *pendulumState) getCenterPrice() float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.centerPrice
+}
+
+// HandleFill impl, the center price is derived from the fill price rather than accumulated so that the partial fills of one order move the
+// center price only once
+func (s *pendulumState) HandleFill(trade model.Trade) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	fillPrice := trade.Price.AsFloat()
+	newCenterPrice := fillPrice * (1 + s.priceIncrementPercent)
+	if trade.OrderAction.IsBuy() {
+		newCenterPrice = fillPrice * (1 - s.priceIncrementPercent)
+	}
+	newCenterPrice = s.bound(newCenterPrice)
+	log.Printf("pendulum | fill | action=%s | fillPrice=%.8f | oldCenterPrice=%.8f | newCenterPrice=%.8f\n", trade.OrderAction.String(), fillPrice, s.centerPrice, newCenterPrice)
+	s.centerPrice = newCenterPrice
+	return nil
+}
+
+// pendulumLevelProvider provides the single level of one side of the pendulum strategy
+type pendulumLevelProvider struct {
+	state            *pendulumState
+	isBuy            bool
+	spread           float64
+	amountOfBase     float64
+	orderConstraints *model.OrderConstraints
+}
+
+// ensure it implements the LevelProvider interface
+var _ api.LevelProvider = &pendulumLevelProvider{}
+
+// makePendulumLevelProvider is a factory method
+func makePendulumLevelProvider(state *pendulumState, isBuy bool, spread float64, amountOfBase float64, orderConstraints *model.OrderConstraints) api.LevelProvider {
+	return &pendulumLevelProvider{
+		state:            state,
+		isBuy:            isBuy,
+		spread:           spread,
+		amountOfBase:     amountOfBase,
+		orderConstraints: orderConstraints,
+	}
+}
+
+// GetLevels impl.
+func (p *pendulumLevelProvider) GetLevels(maxAssetBase float64, maxAssetQuote float64) ([]api.Level, error) {
+	centerPrice := p.state.getCenterPrice()
+	if p.isBuy {
+		price := centerPrice * (1 - p.spread/2)
+		if price <= 0 || price < p.state.minPrice {
+			log.Printf("pendulum | buy price %.8f is below the min price %.8f, not placing a buy order\n", price, p.state.minPrice)
+			return []api.Level{}, nil
+		}
+		// the buy side works with inverted prices, the amount stays in units of the base asset of the bot
+		return []api.Level{{
+			Price:  *model.NumberFromFloat(1/price, p.orderConstraints.PricePrecision),
+			Amount: *model.NumberFromFloat(p.amountOfBase, p.orderConstraints.VolumePrecision),
+		}}, nil
+	}
+
+	price := centerPrice * (1 + p.spread/2)
+	if p.state.maxPrice > 0 && price > p.state.maxPrice {
+		log.Printf("pendulum | sell price %.8f is above the max price %.8f, not placing a sell order\n", price, p.state.maxPrice)
+		return []api.Level{}, nil
+	}
+	return []api.Level{{
+		Price:  *model.NumberFromFloat(price, p.orderConstraints.PricePrecision),
+		Amount: *model.NumberFromFloat(p.amountOfBase, p.orderConstraints.VolumePrecision),
+	}}, nil
+}
+
+// GetFillHandlers impl, only the sell side returns the shared state so every fill moves the center price once
+func (p *pendulumLevelProvider) GetFillHandlers() ([]api.FillHandler, error) {
+	if p.isBuy {
+		return nil, nil
+	}
+	return []api.FillHandler{p.state}, nil
+}
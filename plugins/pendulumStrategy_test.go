@@ -0,0 +1,74 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPendulumStateHandleFill(t *testing.T) {
+	pair := &model.TradingPair{Base: model.XLM, Quote: model.USD}
+	makeTrade := func(action model.OrderAction, price float64) model.Trade {
+		return model.Trade{Order: model.Order{
+			Pair:        pair,
+			OrderAction: action,
+			Price:       model.NumberFromFloat(price, 7),
+			Volume:      model.NumberFromFloat(100, 7),
+		}}
+	}
+
+	s := makePendulumState(0.10, 0.05, 0.08, 0.12)
+	assert.Equal(t, 0.10, s.getCenterPrice())
+
+	testCases := []struct {
+		name       string
+		trade      model.Trade
+		wantCenter float64
+	}{
+		{name: "sell fill moves up", trade: makeTrade(model.OrderActionSell, 0.1), wantCenter: 0.105},
+		{name: "partial fill of the same order", trade: makeTrade(model.OrderActionSell, 0.1), wantCenter: 0.105},
+		{name: "buy fill moves down", trade: makeTrade(model.OrderActionBuy, 0.1), wantCenter: 0.095},
+		{name: "bounded by the max price", trade: makeTrade(model.OrderActionSell, 0.118), wantCenter: 0.12},
+		{name: "bounded by the min price", trade: makeTrade(model.OrderActionBuy, 0.082), wantCenter: 0.08},
+	}
+
+	for _, k := range testCases {
+		e := s.HandleFill(k.trade)
+		if !assert.NoError(t, e, k.name) {
+			return
+		}
+		assert.InDelta(t, k.wantCenter, s.getCenterPrice(), 0.0000001, k.name)
+	}
+}
+
+func TestPendulumLevelProvider(t *testing.T) {
+	oc := model.MakeOrderConstraints(7, 7, 1)
+	s := makePendulumState(0.10, 0.05, 0.0995, 0.1004)
+	sell := makePendulumLevelProvider(s, false, 0.01, 100, oc)
+	buy := makePendulumLevelProvider(s, true, 0.01, 100, oc)
+
+	// the sell price is above the max price
+	levels, e := sell.GetLevels(1000, 1000)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, []api.Level{}, levels)
+
+	// the buy price is within the bounds and is inverted
+	levels, e = buy.GetLevels(1000, 1000)
+	if !assert.NoError(t, e) || !assert.Equal(t, 1, len(levels)) {
+		return
+	}
+	assert.InDelta(t, 1/0.0995, levels[0].Price.AsFloat(), 0.0000001)
+	assert.Equal(t, 100.0, levels[0].Amount.AsFloat())
+
+	// only one side handles fills so the center price is moved once per fill
+	handlers, e := buy.GetFillHandlers()
+	assert.NoError(t, e)
+	assert.Nil(t, handlers)
+	handlers, e = sell.GetFillHandlers()
+	assert.NoError(t, e)
+	assert.Equal(t, 1, len(handlers))
+}
@@ -0,0 +1,203 @@
+package plugins
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// defaultPreflightMaxClockSkew is how far the local clock can be from the clock of the exchange before signed requests are likely rejected
+const defaultPreflightMaxClockSkew = 5 * time.Second
+
+// PreflightCheck is the result of one of the checks that are run against an exchange before a bot starts quoting
+type PreflightCheck struct {
+	Name    string
+	Passed  bool
+	Skipped bool // the exchange does not support the check, Passed is true so it does not stop the bot
+	Message string
+}
+
+// String is the stringer function
+func (c PreflightCheck) String() string {
+	status := "ok"
+	if c.Skipped {
+		status = "skipped"
+	} else if !c.Passed {
+		status = "FAILED"
+	}
+	return fmt.Sprintf("[%s] %s: %s", status, c.Name, c.Message)
+}
+
+// RunExchangePreflight checks that the exchange is reachable, that the trading pair exists on it and that the clock of the exchange agrees
+// with the local clock. When needsTrading is set it also checks that the API keys are valid and allowed to read balances and orders and
+// to trade. Every check is run so all problems are reported at once.
+func RunExchangePreflight(exchange api.Exchange, pair *model.TradingPair, needsTrading bool) []PreflightCheck {
+	checks := []PreflightCheck{}
+	ob, pairCheck := preflightTradingPair(exchange, pair)
+	checks = append(checks, pairCheck, preflightClockSkew(exchange, defaultPreflightMaxClockSkew))
+	if !needsTrading {
+		return checks
+	}
+
+	checks = append(checks, preflightBalances(exchange, pair), preflightOpenOrders(exchange, pair))
+	return append(checks, preflightTradePermission(exchange, pair, ob))
+}
+
+// PreflightError combines the failed checks into one error, returns nil when all checks passed
+func PreflightError(checks []PreflightCheck) error {
+	failed := []string{}
+	for _, c := range checks {
+		if !c.Passed {
+			failed = append(failed, fmt.Sprintf("%s: %s", c.Name, c.Message))
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d preflight check(s) failed: %s", len(failed), strings.Join(failed, "; "))
+}
+
+func preflightTradingPair(exchange api.Exchange, pair *model.TradingPair) (*model.OrderBook, PreflightCheck) {
+	check := PreflightCheck{Name: "trading pair"}
+	ob, e := exchange.GetOrderBook(pair, 1)
+	if e != nil {
+		check.Message = fmt.Sprintf("unable to fetch the orderbook of %s, check that the pair exists on the exchange: %s", pair, e)
+		return nil, check
+	}
+
+	check.Passed = true
+	if ob.TopAsk() == nil && ob.TopBid() == nil {
+		check.Message = fmt.Sprintf("%s exists but its orderbook is empty", pair)
+	} else {
+		check.Message = fmt.Sprintf("%s exists", pair)
+	}
+	return ob, check
+}
+
+func preflightClockSkew(exchange api.Exchange, maxSkew time.Duration) PreflightCheck {
+	check := PreflightCheck{Name: "clock skew"}
+	serverClock, ok := exchange.(api.ServerClock)
+	if !ok {
+		return skippedPreflightCheck(check, api.ErrServerTimeUnsupported)
+	}
+
+	before := time.Now()
+	serverTime, e := serverClock.GetServerTime()
+	after := time.Now()
+	if e == api.ErrServerTimeUnsupported {
+		return skippedPreflightCheck(check, e)
+	}
+	if e != nil {
+		check.Message = fmt.Sprintf("unable to fetch the time of the exchange: %s", e)
+		return check
+	}
+
+	skew := clockSkew(before, after, serverTime)
+	check.Passed = skew <= maxSkew && skew >= -maxSkew
+	check.Message = fmt.Sprintf("the exchange clock is %s ahead of the local clock, which can be off by at most %s", skew, maxSkew)
+	if !check.Passed {
+		check.Message += ", synchronize the clock of this machine (for example with NTP)"
+	}
+	return check
+}
+
+// clockSkew returns how far the local clock is behind the server, assuming the server read its clock halfway through the request
+func clockSkew(before time.Time, after time.Time, serverTime time.Time) time.Duration {
+	midpoint := before.Add(after.Sub(before) / 2)
+	return serverTime.Sub(midpoint)
+}
+
+func preflightBalances(exchange api.Exchange, pair *model.TradingPair) PreflightCheck {
+	check := PreflightCheck{Name: "read balances"}
+	balances, e := exchange.GetAccountBalances([]interface{}{pair.Base, pair.Quote})
+	if e != nil {
+		check.Message = fmt.Sprintf("unable to read the balances, check that the API key is valid and allowed to query funds: %s", e)
+		return check
+	}
+
+	check.Passed = true
+	check.Message = fmt.Sprintf("%s=%s, %s=%s", pair.Base, preflightBalance(balances, pair.Base), pair.Quote, preflightBalance(balances, pair.Quote))
+	return check
+}
+
+func preflightBalance(balances map[interface{}]model.Number, asset model.Asset) string {
+	balance, ok := balances[asset]
+	if !ok {
+		return "0"
+	}
+	return balance.AsString()
+}
+
+func preflightOpenOrders(exchange api.Exchange, pair *model.TradingPair) PreflightCheck {
+	check := PreflightCheck{Name: "read open orders"}
+	openOrders, e := exchange.GetOpenOrders([]*model.TradingPair{pair})
+	if e != nil {
+		check.Message = fmt.Sprintf("unable to read the open orders, check that the API key is allowed to query orders: %s", e)
+		return check
+	}
+
+	check.Passed = true
+	check.Message = fmt.Sprintf("%d open order(s) on %s", len(openOrders[*pair]), pair)
+	return check
+}
+
+func preflightTradePermission(exchange api.Exchange, pair *model.TradingPair, ob *model.OrderBook) PreflightCheck {
+	check := PreflightCheck{Name: "trade permission"}
+	orderValidator, ok := exchange.(api.OrderValidator)
+	if !ok {
+		return skippedPreflightCheck(check, api.ErrValidateOrderUnsupported)
+	}
+	order := preflightValidationOrder(pair, ob, exchange.GetOrderConstraints(pair))
+	if order == nil {
+		check.Passed = true
+		check.Skipped = true
+		check.Message = "the orderbook is empty so there is no price to validate an order at"
+		return check
+	}
+
+	e := orderValidator.ValidateOrder(order)
+	if e == api.ErrValidateOrderUnsupported {
+		return skippedPreflightCheck(check, e)
+	}
+	if e != nil {
+		check.Message = fmt.Sprintf("a test order was rejected, check that the API key is allowed to trade: %s", e)
+		return check
+	}
+	check.Passed = true
+	check.Message = "a test order was accepted without being placed"
+	return check
+}
+
+// preflightValidationOrder returns a limit sell of the minimum volume far above the market so it would not fill even if it was placed,
+// returns nil when the orderbook has no price to start from
+func preflightValidationOrder(pair *model.TradingPair, ob *model.OrderBook, orderConstraints *model.OrderConstraints) *model.Order {
+	if ob == nil {
+		return nil
+	}
+	top := ob.TopAsk()
+	if top == nil {
+		top = ob.TopBid()
+	}
+	if top == nil {
+		return nil
+	}
+
+	return &model.Order{
+		Pair:        pair,
+		OrderAction: model.OrderActionSell,
+		OrderType:   model.OrderTypeLimit,
+		Price:       model.NumberFromFloat(top.Price.AsFloat()*2, orderConstraints.PricePrecision),
+		Volume:      model.NumberFromFloat(orderConstraints.MinBaseVolume.AsFloat(), orderConstraints.VolumePrecision),
+		PostOnly:    true,
+	}
+}
+
+func skippedPreflightCheck(check PreflightCheck, reason error) PreflightCheck {
+	check.Passed = true
+	check.Skipped = true
+	check.Message = reason.Error()
+	return check
+}
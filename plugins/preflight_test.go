@@ -0,0 +1,72 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClockSkew(t *testing.T) {
+	before := time.Unix(1000, 0)
+	after := before.Add(2 * time.Second)
+
+	assert.Equal(t, time.Duration(0), clockSkew(before, after, before.Add(time.Second)))
+	assert.Equal(t, 9*time.Second, clockSkew(before, after, before.Add(10*time.Second)))
+	assert.Equal(t, -11*time.Second, clockSkew(before, after, before.Add(-10*time.Second)))
+}
+
+func TestPreflightValidationOrder(t *testing.T) {
+	pair := &model.TradingPair{Base: model.XLM, Quote: model.USD}
+	oc := model.MakeOrderConstraints(5, 2, 20)
+	ask := model.Order{Price: model.NumberFromFloat(0.1, 5), Volume: model.NumberFromFloat(100, 2)}
+	bid := model.Order{Price: model.NumberFromFloat(0.09, 5), Volume: model.NumberFromFloat(100, 2)}
+
+	order := preflightValidationOrder(pair, model.MakeOrderBook(pair, []model.Order{ask}, []model.Order{bid}), oc)
+	if assert.NotNil(t, order) {
+		assert.Equal(t, model.OrderActionSell, order.OrderAction)
+		assert.Equal(t, model.OrderTypeLimit, order.OrderType)
+		assert.Equal(t, "0.20000", order.Price.AsString())
+		assert.Equal(t, "20.00", order.Volume.AsString())
+		assert.True(t, order.PostOnly)
+	}
+
+	order = preflightValidationOrder(pair, model.MakeOrderBook(pair, []model.Order{}, []model.Order{bid}), oc)
+	if assert.NotNil(t, order) {
+		assert.Equal(t, "0.18000", order.Price.AsString())
+	}
+
+	assert.Nil(t, preflightValidationOrder(pair, model.MakeOrderBook(pair, []model.Order{}, []model.Order{}), oc))
+	assert.Nil(t, preflightValidationOrder(pair, nil, oc))
+}
+
+func TestPreflightError(t *testing.T) {
+	assert.NoError(t, PreflightError([]PreflightCheck{
+		{Name: "a", Passed: true},
+		{Name: "b", Passed: true, Skipped: true},
+	}))
+
+	e := PreflightError([]PreflightCheck{
+		{Name: "a", Passed: true},
+		{Name: "b", Message: "bad key"},
+	})
+	if assert.Error(t, e) {
+		assert.Equal(t, "1 preflight check(s) failed: b: bad key", e.Error())
+	}
+}
+
+func TestKrakenValidateOrderArgs(t *testing.T) {
+	order := &model.Order{
+		OrderAction: model.OrderActionSell,
+		OrderType:   model.OrderTypeLimit,
+		Price:       model.NumberFromFloat(0.2, 5),
+		Volume:      model.NumberFromFloat(20, 8),
+		PostOnly:    true,
+	}
+	args, e := krakenValidateOrderArgs(order, model.MakeOrderConstraints(5, 8, 20), map[string]string{"oflags": "fciq", "validate": "false"})
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, map[string]string{"price": "0.20000", "oflags": "post,fciq", "validate": "true"}, args)
+}
@@ -2,12 +2,11 @@ package plugins
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/stellar/go/build"
 	"github.com/stellar/go/clients/horizonclient"
 	"github.com/stellar/kelp/api"
-	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/monitoring"
 )
 
 // privateSdexHack is a temporary hack struct for SDEX price feeds pending refactor
@@ -34,8 +33,16 @@ func SetPrivateSdexHack(api *horizonclient.Client, ieif *IEIF, network build.Net
 	return nil
 }
 
-// MakePriceFeed makes a PriceFeed
+// MakePriceFeed makes a PriceFeed, which is monitored for freshness when EnablePriceFeedFreshnessMonitor was called
 func MakePriceFeed(feedType string, url string) (api.PriceFeed, error) {
+	feed, e := makePriceFeed(feedType, url)
+	if e != nil {
+		return nil, e
+	}
+	return monitorPriceFeedFreshness(feedType, url, feed), nil
+}
+
+func makePriceFeed(feedType string, url string) (api.PriceFeed, error) {
 	switch feedType {
 	case "crypto":
 		return newCMCFeed(url), nil
@@ -44,36 +51,47 @@ func MakePriceFeed(feedType string, url string) (api.PriceFeed, error) {
 	case "fixed":
 		return newFixedFeed(url), nil
 	case "exchange":
-		// [0] = exchangeType, [1] = base, [2] = quote
-		urlParts := strings.Split(url, "/")
-		if len(urlParts) != 3 {
-			return nil, fmt.Errorf("invalid format of exchange type URL, needs exactly 3 parts after splitting URL by '/', has %d: %s", len(urlParts), url)
+		exchange, e := makeExchangeFeed(url)
+		if e != nil {
+			return nil, fmt.Errorf("error occurred while making the exchange price feed: %s", e)
 		}
-
-		exchange, e := MakeExchange(urlParts[0], true)
+		return exchange, nil
+	case "sdex":
+		sdex, e := makeSDEXFeed(url)
 		if e != nil {
-			return nil, fmt.Errorf("cannot make priceFeed because of an error when making the '%s' exchange: %s", urlParts[0], e)
+			return nil, fmt.Errorf("error occurred while making the SDEX price feed: %s", e)
 		}
-		baseAsset, e := exchange.GetAssetConverter().FromString(urlParts[1])
+		return sdex, nil
+	case "oracle":
+		oracle, e := makeOracleFeed(url)
 		if e != nil {
-			return nil, fmt.Errorf("cannot make priceFeed because of an error when converting the base asset: %s", e)
+			return nil, fmt.Errorf("error occurred while making the oracle price feed: %s", e)
 		}
-		quoteAsset, e := exchange.GetAssetConverter().FromString(urlParts[2])
+		return oracle, nil
+	case "ramp":
+		ramp, e := makeRampPriceFeed(url)
 		if e != nil {
-			return nil, fmt.Errorf("cannot make priceFeed because of an error when converting the quote asset: %s", e)
+			return nil, fmt.Errorf("error occurred while making the ramp price feed: %s", e)
 		}
-		tradingPair := model.TradingPair{
-			Base:  baseAsset,
-			Quote: quoteAsset,
+		return ramp, nil
+	case "cross":
+		cross, e := makeCrossRatePriceFeed(url)
+		if e != nil {
+			return nil, fmt.Errorf("error occurred while making the cross rate price feed: %s", e)
 		}
-		tickerAPI := api.TickerAPI(exchange)
-		return newExchangeFeed(url, &tickerAPI, &tradingPair), nil
-	case "sdex":
-		sdex, e := makeSDEXFeed(url)
+		return cross, nil
+	case "json":
+		jsonFeed, e := makeJSONFeed(url)
 		if e != nil {
-			return nil, fmt.Errorf("error occurred while making the SDEX price feed: %s", e)
+			return nil, fmt.Errorf("error occurred while making the json price feed: %s", e)
 		}
-		return sdex, nil
+		return jsonFeed, nil
+	case "custom":
+		custom, e := makeCustomPriceFeed(url)
+		if e != nil {
+			return nil, fmt.Errorf("error occurred while making the custom price feed: %s", e)
+		}
+		return custom, nil
 	}
 	return nil, fmt.Errorf("unable to make price feed for feedType=%s and url=%s", feedType, url)
 }
@@ -91,7 +109,26 @@ func MakeFeedPair(dataTypeA, dataFeedAUrl, dataTypeB, dataFeedBUrl string) (*api
 	}
 
 	return &api.FeedPair{
-		FeedA: feedA,
-		FeedB: feedB,
+		FeedA: makeTimedPriceFeed(feedA),
+		FeedB: makeTimedPriceFeed(feedB),
 	}, nil
 }
+
+// timedPriceFeed records the time spent fetching prices in the spans of the update cycle
+type timedPriceFeed struct {
+	child api.PriceFeed
+}
+
+// ensure it implements the PriceFeed interface
+var _ api.PriceFeed = &timedPriceFeed{}
+
+// makeTimedPriceFeed is a factory method
+func makeTimedPriceFeed(child api.PriceFeed) *timedPriceFeed {
+	return &timedPriceFeed{child: child}
+}
+
+// GetPrice impl
+func (f *timedPriceFeed) GetPrice() (float64, error) {
+	defer monitoring.StartSpan(monitoring.SpanPriceFetch)()
+	return f.child.GetPrice()
+}
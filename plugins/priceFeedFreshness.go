@@ -0,0 +1,190 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/stellar/kelp/api"
+)
+
+// PriceFeedFreshnessStats is the freshness of a monitored price feed as of the last check
+type PriceFeedFreshnessStats struct {
+	Price            float64 `json:"price"`
+	UnchangedSeconds float64 `json:"unchanged_seconds"` // time since the price last changed
+	AgeSeconds       float64 `json:"age_seconds"`       // time since the source last updated the price
+	Stale            bool    `json:"stale"`
+	StaleReason      string  `json:"stale_reason,omitempty"`
+	StaleCount       int     `json:"stale_count"` // number of times the feed became stale since the bot started
+}
+
+// priceFeedFreshnessMonitor wraps a price feed and flags it as stale when its price has not changed within maxUnchanged or its source has
+// not updated the price within maxAge, which catches upstream APIs that froze but keep answering. When halt is set a stale price is
+// returned as an error so the strategy stops quoting on it.
+type priceFeedFreshnessMonitor struct {
+	name         string
+	child        api.PriceFeed
+	maxUnchanged time.Duration // 0 does not check whether the price changes
+	maxAge       time.Duration // 0 does not check when the price was updated
+	halt         bool
+	now          func() time.Time
+
+	// uninitialized
+	mutex      *sync.Mutex
+	hasPrice   bool
+	lastPrice  float64
+	changedAt  time.Time
+	updatedAt  time.Time
+	stale      bool
+	staleCount int
+}
+
+// ensure that it implements PriceFeed and TimestampedPriceFeed
+var _ api.PriceFeed = &priceFeedFreshnessMonitor{}
+var _ api.TimestampedPriceFeed = &priceFeedFreshnessMonitor{}
+
+// makePriceFeedFreshnessMonitor is a factory method
+func makePriceFeedFreshnessMonitor(name string, child api.PriceFeed, maxUnchanged time.Duration, maxAge time.Duration, halt bool) *priceFeedFreshnessMonitor {
+	return &priceFeedFreshnessMonitor{
+		name:         name,
+		child:        child,
+		maxUnchanged: maxUnchanged,
+		maxAge:       maxAge,
+		halt:         halt,
+		now:          time.Now,
+		mutex:        &sync.Mutex{},
+	}
+}
+
+// GetPrice impl
+func (m *priceFeedFreshnessMonitor) GetPrice() (float64, error) {
+	tp, e := m.GetTimestampedPrice()
+	if e != nil {
+		return 0, e
+	}
+	return tp.Price, nil
+}
+
+// GetTimestampedPrice impl
+func (m *priceFeedFreshnessMonitor) GetTimestampedPrice() (*api.TimestampedPrice, error) {
+	tp, e := api.GetTimestampedPrice(m.child)
+	if e != nil {
+		return nil, e
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	now := m.now()
+	if !m.hasPrice || tp.Price != m.lastPrice {
+		m.changedAt = now
+	}
+	m.hasPrice = true
+	m.lastPrice = tp.Price
+	m.updatedAt = tp.UpdatedAt
+
+	reason := m.update(now)
+	if reason != "" && m.halt {
+		return nil, fmt.Errorf("price feed '%s' is stale, not quoting on it: %s", m.name, reason)
+	}
+	return tp, nil
+}
+
+// check returns the freshness of the feed as of now, the feed becomes stale when the price is not fetched
+func (m *priceFeedFreshnessMonitor) check() PriceFeedFreshnessStats {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	now := m.now()
+	reason := m.update(now)
+
+	stats := PriceFeedFreshnessStats{
+		Price:       m.lastPrice,
+		Stale:       m.stale,
+		StaleReason: reason,
+		StaleCount:  m.staleCount,
+	}
+	if m.hasPrice {
+		stats.UnchangedSeconds = now.Sub(m.changedAt).Seconds()
+		stats.AgeSeconds = now.Sub(m.updatedAt).Seconds()
+	}
+	return stats
+}
+
+// update sets the stale flag as of now and returns why the feed is stale, empty when it is fresh. Needs to be called while holding the mutex.
+func (m *priceFeedFreshnessMonitor) update(now time.Time) string {
+	reason := ""
+	if m.hasPrice && m.maxUnchanged > 0 && now.Sub(m.changedAt) > m.maxUnchanged {
+		reason = fmt.Sprintf("the price %.7f has not changed for %s (max %s)", m.lastPrice, now.Sub(m.changedAt).Round(time.Second), m.maxUnchanged)
+	} else if m.hasPrice && m.maxAge > 0 && now.Sub(m.updatedAt) > m.maxAge {
+		reason = fmt.Sprintf("the price was last updated %s ago (max %s)", now.Sub(m.updatedAt).Round(time.Second), m.maxAge)
+	}
+
+	if reason != "" && !m.stale {
+		m.staleCount++
+		log.Printf("price feed '%s' became stale: %s\n", m.name, reason)
+	} else if reason == "" && m.stale {
+		log.Printf("price feed '%s' is fresh again\n", m.name)
+	}
+	m.stale = reason != ""
+	return reason
+}
+
+// priceFeedFreshnessSettings are the limits of the monitors made by monitorPriceFeedFreshness
+type priceFeedFreshnessSettings struct {
+	maxUnchanged time.Duration
+	maxAge       time.Duration
+	halt         bool
+}
+
+// priceFeedFreshness is set once the freshness of the price feeds of this process is monitored
+var priceFeedFreshness *priceFeedFreshnessSettings
+
+// priceFeedFreshnessMonitors are the monitored price feeds made by this process keyed by the type and URL of the feed
+var priceFeedFreshnessMonitors = map[string]*priceFeedFreshnessMonitor{}
+var priceFeedFreshnessMutex = &sync.Mutex{}
+
+// EnablePriceFeedFreshnessMonitor monitors the freshness of the price feeds that are made after this is called, 0 disables the check of
+// maxUnchanged or maxAge. Fixed price feeds are not monitored since their price never changes.
+func EnablePriceFeedFreshnessMonitor(maxUnchanged time.Duration, maxAge time.Duration, halt bool) {
+	priceFeedFreshnessMutex.Lock()
+	defer priceFeedFreshnessMutex.Unlock()
+
+	priceFeedFreshness = &priceFeedFreshnessSettings{
+		maxUnchanged: maxUnchanged,
+		maxAge:       maxAge,
+		halt:         halt,
+	}
+	log.Printf("monitoring the freshness of price feeds: maxUnchanged=%s, maxAge=%s, halt=%v\n", maxUnchanged, maxAge, halt)
+}
+
+// monitorPriceFeedFreshness wraps the feed with a freshness monitor when monitoring is enabled, feeds with the same type and URL share a monitor
+func monitorPriceFeedFreshness(feedType string, url string, feed api.PriceFeed) api.PriceFeed {
+	priceFeedFreshnessMutex.Lock()
+	defer priceFeedFreshnessMutex.Unlock()
+	if priceFeedFreshness == nil || feedType == "fixed" {
+		return feed
+	}
+
+	if feedType == "json" {
+		url = redactJSONFeedURL(url)
+	}
+	name := fmt.Sprintf("%s/%s", feedType, url)
+	if m, ok := priceFeedFreshnessMonitors[name]; ok {
+		return m
+	}
+	m := makePriceFeedFreshnessMonitor(name, feed, priceFeedFreshness.maxUnchanged, priceFeedFreshness.maxAge, priceFeedFreshness.halt)
+	priceFeedFreshnessMonitors[name] = m
+	return m
+}
+
+// CheckPriceFeedFreshness returns the freshness of the monitored price feeds as of now, keyed by the type and URL of the feed
+func CheckPriceFeedFreshness() map[string]PriceFeedFreshnessStats {
+	priceFeedFreshnessMutex.Lock()
+	defer priceFeedFreshnessMutex.Unlock()
+
+	m := map[string]PriceFeedFreshnessStats{}
+	for name, monitor := range priceFeedFreshnessMonitors {
+		m[name] = monitor.check()
+	}
+	return m
+}
@@ -0,0 +1,95 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stretchr/testify/assert"
+)
+
+// timestampedFeed is a price feed that returns the price and update time that are set on it
+type timestampedFeed struct {
+	price     float64
+	updatedAt time.Time
+}
+
+func (f *timestampedFeed) GetPrice() (float64, error) {
+	return f.price, nil
+}
+
+func (f *timestampedFeed) GetTimestampedPrice() (*api.TimestampedPrice, error) {
+	return &api.TimestampedPrice{Price: f.price, UpdatedAt: f.updatedAt, FetchedAt: f.updatedAt}, nil
+}
+
+func TestPriceFeedFreshnessMonitorUnchanged(t *testing.T) {
+	now := time.Unix(1000, 0)
+	feed := &timestampedFeed{price: 1.0, updatedAt: now}
+	m := makePriceFeedFreshnessMonitor("test", feed, 10*time.Second, 0, false)
+	m.now = func() time.Time { return now }
+
+	price, e := m.GetPrice()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 1.0, price)
+	assert.False(t, m.check().Stale)
+
+	// an updated timestamp without a change in price is still stale
+	now = now.Add(11 * time.Second)
+	feed.updatedAt = now
+	_, e = m.GetPrice()
+	assert.NoError(t, e)
+	stats := m.check()
+	assert.True(t, stats.Stale)
+	assert.Equal(t, 11.0, stats.UnchangedSeconds)
+	assert.Equal(t, 1, stats.StaleCount)
+
+	feed.price = 1.1
+	_, e = m.GetPrice()
+	assert.NoError(t, e)
+	stats = m.check()
+	assert.False(t, stats.Stale)
+	assert.Equal(t, 0.0, stats.UnchangedSeconds)
+	assert.Equal(t, 1, stats.StaleCount)
+}
+
+func TestPriceFeedFreshnessMonitorAge(t *testing.T) {
+	now := time.Unix(1000, 0)
+	feed := &timestampedFeed{price: 1.0, updatedAt: now.Add(-5 * time.Second)}
+	m := makePriceFeedFreshnessMonitor("test", feed, 0, 10*time.Second, false)
+	m.now = func() time.Time { return now }
+
+	_, e := m.GetPrice()
+	assert.NoError(t, e)
+	stats := m.check()
+	assert.False(t, stats.Stale)
+	assert.Equal(t, 5.0, stats.AgeSeconds)
+
+	// the feed becomes stale when the price is not fetched again
+	now = now.Add(6 * time.Second)
+	stats = m.check()
+	assert.True(t, stats.Stale)
+	assert.Equal(t, 11.0, stats.AgeSeconds)
+	assert.Contains(t, stats.StaleReason, "last updated")
+}
+
+func TestPriceFeedFreshnessMonitorHalt(t *testing.T) {
+	now := time.Unix(1000, 0)
+	feed := &timestampedFeed{price: 1.0, updatedAt: now}
+	m := makePriceFeedFreshnessMonitor("test", feed, 10*time.Second, 0, true)
+	m.now = func() time.Time { return now }
+
+	_, e := m.GetPrice()
+	assert.NoError(t, e)
+
+	now = now.Add(11 * time.Second)
+	_, e = m.GetPrice()
+	assert.Error(t, e)
+
+	feed.price = 1.1
+	price, e := m.GetPrice()
+	if assert.NoError(t, e) {
+		assert.Equal(t, 1.1, price)
+	}
+}
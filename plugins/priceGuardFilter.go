@@ -0,0 +1,119 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/stellar/go/build"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// priceGuardFilter drops sell offers below a minimum price and buy offers above a maximum price, whatever strategy computed them, so a
+// broken price feed cannot give away the inventory of the bot. Prices are in units of the quote asset per unit of the base asset.
+type priceGuardFilter struct {
+	minSellPrice float64 // 0 does not check sell offers
+	maxBuyPrice  float64 // 0 does not check buy offers
+	baseAsset    hProtocol.Asset
+	quoteAsset   hProtocol.Asset
+}
+
+var _ SubmitFilter = &priceGuardFilter{}
+
+// MakeFilterPriceGuard makes a submit filter that keeps the prices of offers within the guard rails, returns nil when neither is set
+func MakeFilterPriceGuard(minSellPrice float64, maxBuyPrice float64, baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset) SubmitFilter {
+	if minSellPrice <= 0 && maxBuyPrice <= 0 {
+		return nil
+	}
+
+	return &priceGuardFilter{
+		minSellPrice: minSellPrice,
+		maxBuyPrice:  maxBuyPrice,
+		baseAsset:    baseAsset,
+		quoteAsset:   quoteAsset,
+	}
+}
+
+// Apply impl.
+func (f *priceGuardFilter) Apply(
+	ops []build.TransactionMutator,
+	sellingOffers []hProtocol.Offer,
+	buyingOffers []hProtocol.Offer,
+) ([]build.TransactionMutator, error) {
+	numKeep := 0
+	numDropped := 0
+	filteredOps := []build.TransactionMutator{}
+	for _, op := range ops {
+		var mo *build.ManageOfferBuilder
+		switch o := op.(type) {
+		case *build.ManageOfferBuilder:
+			mo = o
+		case build.ManageOfferBuilder:
+			mo = &o
+		case *ManageBuyOfferBuilder:
+			// delete operations should never be dropped
+			if o.MBO.BuyAmount == 0 || f.isBuyPriceAllowed(o.BuyPrice()) {
+				filteredOps = append(filteredOps, o)
+				numKeep++
+				continue
+			}
+			log.Printf("priceGuardFilter: dropping native buy offer (offerID=%d) at price %.7f above MAX_BUY_PRICE %.7f\n", o.MBO.OfferId, o.BuyPrice(), f.maxBuyPrice)
+			numDropped++
+			if droppedOp := o.dropped(); droppedOp != nil {
+				filteredOps = append(filteredOps, droppedOp)
+			}
+			continue
+		default:
+			filteredOps = append(filteredOps, op)
+			continue
+		}
+
+		// delete operations should never be dropped
+		if mo.MO.Amount == 0 {
+			filteredOps = append(filteredOps, mo)
+			numKeep++
+			continue
+		}
+
+		isSell, e := utils.IsSelling(f.baseAsset, f.quoteAsset, mo.MO.Selling, mo.MO.Buying)
+		if e != nil {
+			return nil, fmt.Errorf("error when running the isSelling check: %s", e)
+		}
+		// prices are in units of the buying asset so they are inverted for buy offers
+		price := float64(mo.MO.Price.N) / float64(mo.MO.Price.D)
+		if !isSell {
+			price = 1 / price
+		}
+
+		if isSell && !f.isSellPriceAllowed(price) {
+			log.Printf("priceGuardFilter: dropping sell offer (offerID=%d) at price %.7f below MIN_SELL_PRICE %.7f\n", mo.MO.OfferId, price, f.minSellPrice)
+		} else if !isSell && !f.isBuyPriceAllowed(price) {
+			log.Printf("priceGuardFilter: dropping buy offer (offerID=%d) at price %.7f above MAX_BUY_PRICE %.7f\n", mo.MO.OfferId, price, f.maxBuyPrice)
+		} else {
+			filteredOps = append(filteredOps, mo)
+			numKeep++
+			continue
+		}
+
+		numDropped++
+		if mo.MO.OfferId != 0 {
+			// modify offers should be converted to delete offers so the existing offer does not stay at its old price, new offers can be dropped
+			opCopy := *mo
+			opCopy.MO.Amount = 0
+			filteredOps = append(filteredOps, &opCopy)
+		}
+	}
+
+	if numDropped > 0 {
+		log.Printf("priceGuardFilter: kept %d, dropped %d ops from original %d ops\n", numKeep, numDropped, len(ops))
+	}
+	return filteredOps, nil
+}
+
+func (f *priceGuardFilter) isSellPriceAllowed(price float64) bool {
+	return f.minSellPrice <= 0 || price >= f.minSellPrice
+}
+
+func (f *priceGuardFilter) isBuyPriceAllowed(price float64) bool {
+	return f.maxBuyPrice <= 0 || price <= f.maxBuyPrice
+}
@@ -0,0 +1,91 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stellar/go/build"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeFilterPriceGuard(t *testing.T) {
+	baseAsset := hProtocol.Asset{Type: "native"}
+	quoteAsset := hProtocol.Asset{Type: "credit_alphanum4", Code: "USD", Issuer: testIssuer}
+	assert.Nil(t, MakeFilterPriceGuard(0, 0, baseAsset, quoteAsset))
+	assert.NotNil(t, MakeFilterPriceGuard(0.5, 0, baseAsset, quoteAsset))
+	assert.NotNil(t, MakeFilterPriceGuard(0, 2, baseAsset, quoteAsset))
+}
+
+func TestPriceGuardFilterApply(t *testing.T) {
+	baseAsset := hProtocol.Asset{Type: "native"}
+	quoteAsset := hProtocol.Asset{Type: "credit_alphanum4", Code: "USD", Issuer: testIssuer}
+	f := MakeFilterPriceGuard(1.5, 2.5, baseAsset, quoteAsset)
+
+	usd := build.CreditAsset("USD", testIssuer)
+	sellRate := func(price string) build.Rate {
+		return build.Rate{Selling: build.NativeAsset(), Buying: usd, Price: build.Price(price)}
+	}
+	// buy offers sell the quote asset at a price of base per quote, 0.5 base per quote is a price of 2 quote per base
+	buyRate := func(price string) build.Rate {
+		return build.Rate{Selling: usd, Buying: build.NativeAsset(), Price: build.Price(price)}
+	}
+	sellKeep := build.CreateOffer(sellRate("1.5"), build.Amount("10"))
+	sellDropNew := build.CreateOffer(sellRate("1"), build.Amount("10"))
+	sellDropExisting := build.UpdateOffer(sellRate("0.001"), build.Amount("10"), build.OfferID(11))
+	sellDelete := build.DeleteOffer(sellRate("0.001"), build.OfferID(12))
+	buyKeep := build.CreateOffer(buyRate("0.5"), build.Amount("10"))
+	buyDropExisting := build.UpdateOffer(buyRate("0.25"), build.Amount("10"), build.OfferID(13))
+	nativeBuyKeep := makeManageBuyOffer(usd, build.NativeAsset(), "2.5", "10", 0, "")
+	nativeBuyDropNew := makeManageBuyOffer(usd, build.NativeAsset(), "3", "10", 0, "")
+	nativeBuyDropExisting := makeManageBuyOffer(usd, build.NativeAsset(), "100", "10", 14, "")
+
+	ops, e := f.Apply([]build.TransactionMutator{
+		&sellKeep,
+		&sellDropNew,
+		&sellDropExisting,
+		&sellDelete,
+		&buyKeep,
+		&buyDropExisting,
+		nativeBuyKeep,
+		nativeBuyDropNew,
+		nativeBuyDropExisting,
+	}, []hProtocol.Offer{}, []hProtocol.Offer{})
+	if !assert.NoError(t, e) {
+		return
+	}
+	if !assert.Equal(t, 7, len(ops)) {
+		return
+	}
+
+	assert.Equal(t, &sellKeep, ops[0])
+	// existing offers that would move beyond the guard rails are deleted
+	deletedSell := ops[1].(*build.ManageOfferBuilder)
+	assert.Equal(t, xdr.Int64(11), deletedSell.MO.OfferId)
+	assert.Equal(t, xdr.Int64(0), deletedSell.MO.Amount)
+	assert.Equal(t, &sellDelete, ops[2])
+	assert.Equal(t, &buyKeep, ops[3])
+	deletedBuy := ops[4].(*build.ManageOfferBuilder)
+	assert.Equal(t, xdr.Int64(13), deletedBuy.MO.OfferId)
+	assert.Equal(t, xdr.Int64(0), deletedBuy.MO.Amount)
+	assert.Equal(t, nativeBuyKeep, ops[5])
+	deletedNativeBuy := ops[6].(*ManageBuyOfferBuilder)
+	assert.Equal(t, xdr.Int64(14), deletedNativeBuy.MBO.OfferId)
+	assert.Equal(t, xdr.Int64(0), deletedNativeBuy.MBO.BuyAmount)
+	// the original ops are unchanged
+	assert.Equal(t, xdr.Int64(100000000), sellDropExisting.MO.Amount)
+	assert.Equal(t, xdr.Int64(100000000), nativeBuyDropExisting.MBO.BuyAmount)
+}
+
+func TestFilterPipelinePriceGuardCannotBeDisabled(t *testing.T) {
+	baseAsset := hProtocol.Asset{Type: "native"}
+	quoteAsset := hProtocol.Asset{Type: "credit_alphanum4", Code: "USD", Issuer: testIssuer}
+	p := &FilterPipeline{filters: []*pipelineFilter{}}
+	p.add(FilterNamePriceGuard, MakeFilterPriceGuard(1, 0, baseAsset, quoteAsset))
+
+	no := false
+	assert.Error(t, p.Configure(FilterNamePriceGuard, &no, nil))
+	priority := 10
+	assert.NoError(t, p.Configure(FilterNamePriceGuard, nil, &priority))
+	assert.Equal(t, "FilterPipeline[price_guard(priority=10, enabled)]", p.String())
+}
@@ -0,0 +1,99 @@
+package plugins
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stellar/kelp/api"
+)
+
+// rampStepsPrefix marks the optional last part of the URL of a ramp feed that moves the price in steps
+const rampStepsPrefix = "steps:"
+
+// rampPriceFeed moves the price from a start price to an end price over a period of time, so a seller can follow a predetermined price
+// trajectory such as during a token launch. The price is the start price before the period and the end price after it. Within the period the
+// price moves linearly, or in equal steps when steps is set.
+type rampPriceFeed struct {
+	startPrice float64
+	endPrice   float64
+	startTime  time.Time
+	endTime    time.Time
+	steps      int // 0 when the price moves linearly
+	now        func() time.Time
+}
+
+// ensure that it implements PriceFeed
+var _ api.PriceFeed = &rampPriceFeed{}
+
+// makeRampPriceFeed is a factory method, the URL is "<startPrice>/<endPrice>/<startTime>/<endTime>[/steps:<numSteps>]" where the times are
+// in RFC3339 format, for example "0.10/0.25/2020-06-01T00:00:00Z/2020-06-30T00:00:00Z/steps:29"
+func makeRampPriceFeed(url string) (*rampPriceFeed, error) {
+	return makeRampPriceFeedWithClock(url, time.Now)
+}
+
+func makeRampPriceFeedWithClock(url string, now func() time.Time) (*rampPriceFeed, error) {
+	parts := strings.Split(url, "/")
+	if len(parts) != 4 && len(parts) != 5 {
+		return nil, fmt.Errorf("invalid format of ramp type URL, needs to be '<startPrice>/<endPrice>/<startTime>/<endTime>[/%s<numSteps>]': %s", rampStepsPrefix, url)
+	}
+
+	startPrice, e := strconv.ParseFloat(parts[0], 64)
+	if e != nil || startPrice <= 0 {
+		return nil, fmt.Errorf("invalid start price '%s' in ramp type URL, needs to be a positive number", parts[0])
+	}
+	endPrice, e := strconv.ParseFloat(parts[1], 64)
+	if e != nil || endPrice <= 0 {
+		return nil, fmt.Errorf("invalid end price '%s' in ramp type URL, needs to be a positive number", parts[1])
+	}
+	startTime, e := time.Parse(time.RFC3339, parts[2])
+	if e != nil {
+		return nil, fmt.Errorf("invalid start time '%s' in ramp type URL, needs to be in RFC3339 format: %s", parts[2], e)
+	}
+	endTime, e := time.Parse(time.RFC3339, parts[3])
+	if e != nil {
+		return nil, fmt.Errorf("invalid end time '%s' in ramp type URL, needs to be in RFC3339 format: %s", parts[3], e)
+	}
+	if !endTime.After(startTime) {
+		return nil, fmt.Errorf("the end time (%s) of the ramp type URL needs to be after its start time (%s)", parts[3], parts[2])
+	}
+
+	steps := 0
+	if len(parts) == 5 {
+		if !strings.HasPrefix(parts[4], rampStepsPrefix) {
+			return nil, fmt.Errorf("invalid last part '%s' of ramp type URL, needs to be '%s<numSteps>'", parts[4], rampStepsPrefix)
+		}
+		steps, e = strconv.Atoi(strings.TrimPrefix(parts[4], rampStepsPrefix))
+		if e != nil || steps <= 0 {
+			return nil, fmt.Errorf("invalid number of steps '%s' in ramp type URL, needs to be a positive integer", parts[4])
+		}
+	}
+
+	return &rampPriceFeed{
+		startPrice: startPrice,
+		endPrice:   endPrice,
+		startTime:  startTime,
+		endTime:    endTime,
+		steps:      steps,
+		now:        now,
+	}, nil
+}
+
+// GetPrice impl
+func (f *rampPriceFeed) GetPrice() (float64, error) {
+	now := f.now()
+	if !now.After(f.startTime) {
+		return f.startPrice, nil
+	}
+	if !now.Before(f.endTime) {
+		return f.endPrice, nil
+	}
+
+	progress := float64(now.Sub(f.startTime)) / float64(f.endTime.Sub(f.startTime))
+	if f.steps > 0 {
+		progress = math.Floor(progress*float64(f.steps)) / float64(f.steps)
+	}
+	return f.startPrice + (f.endPrice-f.startPrice)*progress, nil
+}
@@ -0,0 +1,79 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRampPriceFeed(t *testing.T) {
+	start := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name      string
+		url       string
+		at        time.Time
+		wantPrice float64
+	}{
+		{
+			name:      "before start",
+			url:       "0.10/0.20/2020-06-01T00:00:00Z/2020-06-11T00:00:00Z",
+			at:        start.Add(-time.Hour),
+			wantPrice: 0.10,
+		}, {
+			name:      "linear halfway",
+			url:       "0.10/0.20/2020-06-01T00:00:00Z/2020-06-11T00:00:00Z",
+			at:        start.Add(5 * 24 * time.Hour),
+			wantPrice: 0.15,
+		}, {
+			name:      "after end",
+			url:       "0.10/0.20/2020-06-01T00:00:00Z/2020-06-11T00:00:00Z",
+			at:        start.Add(20 * 24 * time.Hour),
+			wantPrice: 0.20,
+		}, {
+			name:      "decreasing",
+			url:       "0.20/0.10/2020-06-01T00:00:00Z/2020-06-11T00:00:00Z",
+			at:        start.Add(24 * time.Hour),
+			wantPrice: 0.19,
+		}, {
+			name:      "steps holds the price within a step",
+			url:       "0.10/0.20/2020-06-01T00:00:00Z/2020-06-11T00:00:00Z/steps:2",
+			at:        start.Add(4 * 24 * time.Hour),
+			wantPrice: 0.10,
+		}, {
+			name:      "steps moves to the next step",
+			url:       "0.10/0.20/2020-06-01T00:00:00Z/2020-06-11T00:00:00Z/steps:2",
+			at:        start.Add(6 * 24 * time.Hour),
+			wantPrice: 0.15,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			f, e := makeRampPriceFeedWithClock(k.url, func() time.Time { return k.at })
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			price, e := f.GetPrice()
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.InDelta(t, k.wantPrice, price, 1e-12)
+		})
+	}
+}
+
+func TestMakeRampPriceFeedInvalid(t *testing.T) {
+	for _, url := range []string{
+		"0.10/0.20/2020-06-01T00:00:00Z",
+		"0/0.20/2020-06-01T00:00:00Z/2020-06-11T00:00:00Z",
+		"0.10/0.20/2020-06-11T00:00:00Z/2020-06-01T00:00:00Z",
+		"0.10/0.20/2020-06-01/2020-06-11T00:00:00Z",
+		"0.10/0.20/2020-06-01T00:00:00Z/2020-06-11T00:00:00Z/steps:0",
+		"0.10/0.20/2020-06-01T00:00:00Z/2020-06-11T00:00:00Z/2",
+	} {
+		_, e := makeRampPriceFeed(url)
+		assert.Error(t, e, url)
+	}
+}
@@ -0,0 +1,406 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/build"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// rebalance modes
+const (
+	RebalanceModeDryRun   = "dry_run"  // transfers are only logged and recorded
+	RebalanceModeApproval = "approval" // transfers are recorded and wait to be approved from the GUI before they are made
+	RebalanceModeAuto     = "auto"     // transfers are made as soon as they are needed
+)
+
+// directions of a rebalance transfer
+const (
+	RebalanceToExchange = "to_exchange" // a Stellar payment from the trading account to the deposit address of the backing exchange
+	RebalanceToSDEX     = "to_sdex"     // a withdrawal from the backing exchange to the trading account
+)
+
+// statuses of a rebalance transfer
+const (
+	RebalanceStatusDryRun          = "dry_run"
+	RebalanceStatusPendingApproval = "pending_approval"
+	RebalanceStatusRejected        = "rejected"
+	RebalanceStatusSubmitted       = "submitted"
+	RebalanceStatusComplete        = "complete" // the backing exchange confirmed that the withdrawal was sent
+	RebalanceStatusManual          = "manual"   // the venues do not support the transfer so it needs to be made by hand
+	RebalanceStatusFailed          = "failed"
+)
+
+const defaultRebalanceCheckIntervalSecs = 300
+const defaultRebalanceCooldownSecs = 3600
+const maxRecentRebalanceTransfers = 50
+
+// RebalanceTransfer is a transfer of an asset between the trading account on SDEX and the backing exchange
+type RebalanceTransfer struct {
+	ID              string  `json:"id"`
+	Asset           string  `json:"asset"`
+	Direction       string  `json:"direction"`
+	Amount          float64 `json:"amount"`
+	SDEXBalance     float64 `json:"sdex_balance"`
+	ExchangeBalance float64 `json:"exchange_balance"`
+	Status          string  `json:"status"`
+	Reference       string  `json:"reference"` // transaction hash of the payment or ID of the withdrawal
+	Error           string  `json:"error,omitempty"`
+	CreatedAtMillis int64   `json:"created_at_millis"`
+}
+
+// RebalanceSnapshot is the state of the rebalancer for the GUI
+type RebalanceSnapshot struct {
+	Mode           string              `json:"mode"`
+	MinFraction    float64             `json:"min_fraction"`
+	TargetFraction float64             `json:"target_fraction"`
+	Transfers      []RebalanceTransfer `json:"transfers"` // most recent first
+}
+
+// RebalanceProvider is implemented by strategies that rebalance their assets between SDEX and a backing exchange
+type RebalanceProvider interface {
+	GetRebalanceSnapshot() *RebalanceSnapshot
+	ApproveRebalanceTransfer(id string, approve bool) error
+}
+
+// rebalanceAsset is an asset that is held on both venues
+type rebalanceAsset struct {
+	asset     model.Asset
+	sdexAsset hProtocol.Asset
+}
+
+// rebalancer moves assets between the trading account on SDEX and the backing exchange when the share of either venue drops below the
+// min fraction of the total holdings of an asset, the transfer brings the share of that venue back to the target fraction
+type rebalancer struct {
+	sdex           *SDEX
+	exchange       api.Exchange
+	assets         []rebalanceAsset
+	mode           string
+	checkInterval  time.Duration
+	cooldown       time.Duration
+	minFraction    float64
+	targetFraction float64
+	now            func() time.Time
+
+	// uninitialized
+	mutex        *sync.Mutex
+	lastCheck    time.Time
+	lastTransfer map[model.Asset]time.Time
+	transfers    []*RebalanceTransfer // most recent first, bounded by maxRecentRebalanceTransfers
+	numTransfers int
+}
+
+// makeRebalancer is a factory method, returns nil when the mode is not set which disables rebalancing
+func makeRebalancer(
+	sdex *SDEX,
+	exchange api.Exchange,
+	assets []rebalanceAsset,
+	mode string,
+	checkIntervalSecs uint32,
+	cooldownSecs uint32,
+	minFraction float64,
+	targetFraction float64,
+) (*rebalancer, error) {
+	if mode == "" {
+		return nil, nil
+	}
+	if mode != RebalanceModeDryRun && mode != RebalanceModeApproval && mode != RebalanceModeAuto {
+		return nil, fmt.Errorf("invalid REBALANCE_MODE '%s', needs to be one of '%s', '%s' or '%s'", mode, RebalanceModeDryRun, RebalanceModeApproval, RebalanceModeAuto)
+	}
+	if minFraction <= 0 || minFraction >= 0.5 {
+		return nil, fmt.Errorf("REBALANCE_MIN_FRACTION needs to be between 0 and 0.5: %f", minFraction)
+	}
+	if targetFraction <= minFraction || targetFraction > 0.5 {
+		return nil, fmt.Errorf("REBALANCE_TARGET_FRACTION needs to be greater than REBALANCE_MIN_FRACTION and at most 0.5: %f", targetFraction)
+	}
+	if len(assets) == 0 {
+		return nil, fmt.Errorf("the backing exchange does not hold any of the assets of the trading pair so there is nothing to rebalance")
+	}
+	if checkIntervalSecs == 0 {
+		checkIntervalSecs = defaultRebalanceCheckIntervalSecs
+	}
+	if cooldownSecs == 0 {
+		cooldownSecs = defaultRebalanceCooldownSecs
+	}
+
+	return &rebalancer{
+		sdex:           sdex,
+		exchange:       exchange,
+		assets:         assets,
+		mode:           mode,
+		checkInterval:  time.Duration(checkIntervalSecs) * time.Second,
+		cooldown:       time.Duration(cooldownSecs) * time.Second,
+		minFraction:    minFraction,
+		targetFraction: targetFraction,
+		now:            time.Now,
+		mutex:          &sync.Mutex{},
+		lastTransfer:   map[model.Asset]time.Time{},
+		transfers:      []*RebalanceTransfer{},
+	}, nil
+}
+
+// String is the stringer method
+func (r *rebalancer) String() string {
+	assets := []model.Asset{}
+	for _, a := range r.assets {
+		assets = append(assets, a.asset)
+	}
+	return fmt.Sprintf("rebalancer[mode=%s, assets=%v, checkInterval=%s, cooldown=%s, minFraction=%f, targetFraction=%f]",
+		r.mode, assets, r.checkInterval, r.cooldown, r.minFraction, r.targetFraction)
+}
+
+// computeRebalanceTransfer returns the direction and amount of the transfer that brings the share of the venue with the smaller balance to
+// the target fraction, or an amount of 0 when both venues hold at least the min fraction of the total
+func computeRebalanceTransfer(sdexBalance float64, exchangeBalance float64, minFraction float64, targetFraction float64) (string, float64) {
+	total := sdexBalance + exchangeBalance
+	if total <= 0 {
+		return "", 0
+	}
+
+	if sdexBalance < minFraction*total {
+		return RebalanceToSDEX, targetFraction*total - sdexBalance
+	}
+	if exchangeBalance < minFraction*total {
+		return RebalanceToExchange, targetFraction*total - exchangeBalance
+	}
+	return "", 0
+}
+
+// isDue returns true when the balances should be checked
+func (r *rebalancer) isDue() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.now().Sub(r.lastCheck) >= r.checkInterval
+}
+
+// check proposes and, depending on the mode, makes the transfers needed for the balances on both venues
+func (r *rebalancer) check(sdexBalances map[model.Asset]float64, exchangeBalances map[model.Asset]float64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := r.now()
+	r.lastCheck = now
+	r.trackWithdrawals()
+	for _, a := range r.assets {
+		if now.Sub(r.lastTransfer[a.asset]) < r.cooldown {
+			// balances are not reliable while a transfer is in flight
+			continue
+		}
+
+		sdexBalance := sdexBalances[a.asset]
+		exchangeBalance := exchangeBalances[a.asset]
+		direction, amount := computeRebalanceTransfer(sdexBalance, exchangeBalance, r.minFraction, r.targetFraction)
+		if amount <= 0 {
+			continue
+		}
+
+		r.numTransfers++
+		t := &RebalanceTransfer{
+			ID:              fmt.Sprintf("%d-%d", now.Unix(), r.numTransfers),
+			Asset:           string(a.asset),
+			Direction:       direction,
+			Amount:          model.NumberFromFloat(amount, utils.SdexPrecision).AsFloat(),
+			SDEXBalance:     sdexBalance,
+			ExchangeBalance: exchangeBalance,
+			CreatedAtMillis: now.UnixNano() / int64(time.Millisecond),
+		}
+		r.lastTransfer[a.asset] = now
+		r.record(t)
+		log.Printf("rebalance | proposed | mode=%s | id=%s | asset=%s | direction=%s | amount=%f | sdexBalance=%f | exchangeBalance=%f\n",
+			r.mode, t.ID, t.Asset, t.Direction, t.Amount, sdexBalance, exchangeBalance)
+
+		switch r.mode {
+		case RebalanceModeDryRun:
+			t.Status = RebalanceStatusDryRun
+		case RebalanceModeApproval:
+			t.Status = RebalanceStatusPendingApproval
+		case RebalanceModeAuto:
+			r.execute(t, a)
+		}
+	}
+}
+
+// record adds the transfer to the list of recent transfers
+func (r *rebalancer) record(t *RebalanceTransfer) {
+	r.transfers = append([]*RebalanceTransfer{t}, r.transfers...)
+	if len(r.transfers) > maxRecentRebalanceTransfers {
+		r.transfers = r.transfers[:maxRecentRebalanceTransfers]
+	}
+}
+
+// approve makes or rejects a transfer that is pending approval
+func (r *rebalancer) approve(id string, approve bool) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, t := range r.transfers {
+		if t.ID != id {
+			continue
+		}
+		if t.Status != RebalanceStatusPendingApproval {
+			return fmt.Errorf("rebalance transfer '%s' is not pending approval, status is '%s'", id, t.Status)
+		}
+
+		if !approve {
+			t.Status = RebalanceStatusRejected
+			log.Printf("rebalance | rejected | id=%s\n", id)
+			return nil
+		}
+		for _, a := range r.assets {
+			if string(a.asset) == t.Asset {
+				// the cooldown starts again since the balances change once the transfer is made
+				r.lastTransfer[a.asset] = r.now()
+				r.execute(t, a)
+				return nil
+			}
+		}
+		return fmt.Errorf("unknown asset '%s' of rebalance transfer '%s'", t.Asset, id)
+	}
+	return fmt.Errorf("rebalance transfer '%s' not found", id)
+}
+
+// execute makes the transfer and records the outcome on it
+func (r *rebalancer) execute(t *RebalanceTransfer, a rebalanceAsset) {
+	var e error
+	if t.Direction == RebalanceToSDEX {
+		e = r.withdraw(t, a)
+	} else {
+		e = r.deposit(t, a)
+	}
+	if e != nil {
+		t.Status = RebalanceStatusFailed
+		t.Error = e.Error()
+		log.Printf("rebalance | failed | id=%s | error=%s\n", t.ID, e)
+		return
+	}
+	log.Printf("rebalance | %s | id=%s | reference=%s\n", t.Status, t.ID, t.Reference)
+}
+
+// withdraw withdraws the asset from the backing exchange to the trading account
+func (r *rebalancer) withdraw(t *RebalanceTransfer, a rebalanceAsset) error {
+	amount := model.NumberFromFloat(t.Amount, utils.SdexPrecision)
+	if withdrawalExchange, ok := r.exchange.(api.WithdrawalExchange); ok {
+		w, e := withdrawalExchange.Withdraw(a.asset, amount, r.sdex.TradingAccount, "")
+		if e != nil {
+			return fmt.Errorf("could not withdraw %s %s from backing exchange to trading account %s: %s", amount.AsString(), a.asset, r.sdex.TradingAccount, e)
+		}
+		t.Status = RebalanceStatusSubmitted
+		t.Reference = w.ID
+		return nil
+	}
+
+	result, e := r.exchange.WithdrawFunds(a.asset, amount, r.sdex.TradingAccount)
+	if e != nil {
+		return fmt.Errorf("could not withdraw %s %s from backing exchange to trading account %s: %s", amount.AsString(), a.asset, r.sdex.TradingAccount, e)
+	}
+	t.Status = RebalanceStatusSubmitted
+	t.Reference = result.WithdrawalID
+	return nil
+}
+
+// trackWithdrawals updates the submitted withdrawals with their status on the backing exchange, withdrawals stay submitted when the backing
+// exchange cannot report their status. Callers need to hold mutex.
+func (r *rebalancer) trackWithdrawals() {
+	withdrawalExchange, ok := r.exchange.(api.WithdrawalExchange)
+	if !ok {
+		return
+	}
+
+	for _, t := range r.transfers {
+		if t.Direction != RebalanceToSDEX || t.Status != RebalanceStatusSubmitted || t.Reference == "" {
+			continue
+		}
+
+		w, e := withdrawalExchange.GetWithdrawal(model.Asset(t.Asset), t.Reference)
+		if e != nil {
+			log.Printf("rebalance | unable to fetch status of withdrawal | id=%s | reference=%s | error=%s\n", t.ID, t.Reference, e)
+			continue
+		}
+		switch w.Status {
+		case api.WithdrawalStatusComplete:
+			t.Status = RebalanceStatusComplete
+			log.Printf("rebalance | %s | id=%s | reference=%s | txID=%s\n", t.Status, t.ID, t.Reference, w.TxID)
+		case api.WithdrawalStatusFailed:
+			t.Status = RebalanceStatusFailed
+			t.Error = fmt.Sprintf("backing exchange reported that withdrawal '%s' failed", t.Reference)
+			log.Printf("rebalance | failed | id=%s | error=%s\n", t.ID, t.Error)
+		}
+	}
+}
+
+// deposit pays the asset from the trading account to the deposit address of the backing exchange, which needs to be a Stellar account
+func (r *rebalancer) deposit(t *RebalanceTransfer, a rebalanceAsset) error {
+	amount := model.NumberFromFloat(t.Amount, utils.SdexPrecision)
+	instructions, e := r.exchange.PrepareDeposit(a.asset, amount)
+	if e != nil {
+		return fmt.Errorf("could not prepare deposit of %s %s on backing exchange: %s", amount.AsString(), a.asset, e)
+	}
+	if _, e = strkey.Decode(strkey.VersionByteAccountID, instructions.Address); e != nil {
+		t.Status = RebalanceStatusManual
+		t.Error = fmt.Sprintf("deposit address '%s' of backing exchange is not a Stellar account, the deposit needs to be made manually", instructions.Address)
+		return nil
+	}
+
+	ops := []build.TransactionMutator{makeRebalancePayment(r.sdex.TradingAccount, instructions.Address, a.sdexAsset, amount.AsString())}
+	if instructions.Memo != "" {
+		ops = append(ops, makeRebalanceMemo(instructions.Memo))
+	}
+	t.Status = RebalanceStatusSubmitted
+	// the callback is invoked before SubmitOpsSynch returns so the rebalancer is still locked here
+	return r.sdex.SubmitOpsSynch(ops, func(hash string, e error) {
+		if e != nil {
+			t.Status = RebalanceStatusFailed
+			t.Error = e.Error()
+			log.Printf("rebalance | failed | id=%s | error=%s\n", t.ID, e)
+			return
+		}
+		t.Reference = hash
+	})
+}
+
+// makeRebalancePayment makes the payment op from the trading account to the destination
+func makeRebalancePayment(source string, destination string, asset hProtocol.Asset, amount string) build.PaymentBuilder {
+	var amountMutator interface{} = build.NativeAmount{Amount: amount}
+	if asset.Type != "native" {
+		amountMutator = build.CreditAmount{Code: asset.Code, Issuer: asset.Issuer, Amount: amount}
+	}
+	return build.Payment(
+		build.SourceAccount{AddressOrSeed: source},
+		build.Destination{AddressOrSeed: destination},
+		amountMutator,
+	)
+}
+
+// makeRebalanceMemo uses an ID memo when the memo is numeric since exchanges usually credit deposits by a numeric ID
+func makeRebalanceMemo(memo string) build.TransactionMutator {
+	if id, e := strconv.ParseUint(memo, 10, 64); e == nil {
+		return build.MemoID{Value: id}
+	}
+	return build.MemoText{Value: memo}
+}
+
+// snapshot returns the state of the rebalancer
+func (r *rebalancer) snapshot() *RebalanceSnapshot {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	transfers := []RebalanceTransfer{}
+	for _, t := range r.transfers {
+		transfers = append(transfers, *t)
+	}
+	return &RebalanceSnapshot{
+		Mode:           r.mode,
+		MinFraction:    r.minFraction,
+		TargetFraction: r.targetFraction,
+		Transfers:      transfers,
+	}
+}
@@ -0,0 +1,115 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stellar/go/build"
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeRebalanceTransfer(t *testing.T) {
+	testCases := []struct {
+		sdexBalance     float64
+		exchangeBalance float64
+		wantDirection   string
+		wantAmount      float64
+	}{
+		{100, 100, "", 0},
+		{0, 0, "", 0},
+		{25, 75, "", 0},
+		{10, 90, RebalanceToSDEX, 40},
+		{0, 100, RebalanceToSDEX, 50},
+		{95, 5, RebalanceToExchange, 45},
+	}
+
+	for _, kase := range testCases {
+		direction, amount := computeRebalanceTransfer(kase.sdexBalance, kase.exchangeBalance, 0.2, 0.5)
+		assert.Equal(t, kase.wantDirection, direction)
+		assert.InDelta(t, kase.wantAmount, amount, 0.0000001)
+	}
+}
+
+func TestMakeRebalancer(t *testing.T) {
+	assets := []rebalanceAsset{{asset: model.XLM}}
+
+	r, e := makeRebalancer(nil, nil, assets, "", 0, 0, 0, 0)
+	assert.NoError(t, e)
+	assert.Nil(t, r)
+
+	_, e = makeRebalancer(nil, nil, assets, "always", 0, 0, 0.2, 0.5)
+	assert.Error(t, e)
+	_, e = makeRebalancer(nil, nil, assets, RebalanceModeAuto, 0, 0, 0.5, 0.5)
+	assert.Error(t, e)
+	_, e = makeRebalancer(nil, nil, assets, RebalanceModeAuto, 0, 0, 0.2, 0.1)
+	assert.Error(t, e)
+	_, e = makeRebalancer(nil, nil, []rebalanceAsset{}, RebalanceModeAuto, 0, 0, 0.2, 0.5)
+	assert.Error(t, e)
+
+	r, e = makeRebalancer(nil, nil, assets, RebalanceModeDryRun, 0, 0, 0.2, 0.5)
+	if assert.NoError(t, e) {
+		assert.Equal(t, defaultRebalanceCheckIntervalSecs*time.Second, r.checkInterval)
+		assert.Equal(t, defaultRebalanceCooldownSecs*time.Second, r.cooldown)
+	}
+}
+
+func TestRebalancerDryRun(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	r, e := makeRebalancer(nil, nil, []rebalanceAsset{{asset: model.XLM}, {asset: model.USD}}, RebalanceModeDryRun, 60, 600, 0.2, 0.5)
+	if !assert.NoError(t, e) {
+		return
+	}
+	r.now = func() time.Time { return now }
+	assert.True(t, r.isDue())
+
+	r.check(map[model.Asset]float64{model.XLM: 10, model.USD: 50}, map[model.Asset]float64{model.XLM: 90, model.USD: 50})
+	assert.False(t, r.isDue())
+	snapshot := r.snapshot()
+	if assert.Equal(t, 1, len(snapshot.Transfers)) {
+		assert.Equal(t, string(model.XLM), snapshot.Transfers[0].Asset)
+		assert.Equal(t, RebalanceToSDEX, snapshot.Transfers[0].Direction)
+		assert.Equal(t, 40.0, snapshot.Transfers[0].Amount)
+		assert.Equal(t, RebalanceStatusDryRun, snapshot.Transfers[0].Status)
+	}
+
+	// the asset is not checked again within the cooldown
+	now = now.Add(300 * time.Second)
+	assert.True(t, r.isDue())
+	r.check(map[model.Asset]float64{model.XLM: 10, model.USD: 50}, map[model.Asset]float64{model.XLM: 90, model.USD: 50})
+	assert.Equal(t, 1, len(r.snapshot().Transfers))
+
+	now = now.Add(300 * time.Second)
+	r.check(map[model.Asset]float64{model.XLM: 10, model.USD: 50}, map[model.Asset]float64{model.XLM: 90, model.USD: 50})
+	assert.Equal(t, 2, len(r.snapshot().Transfers))
+
+	// transfers can only be approved in approval mode
+	assert.Error(t, r.approve(snapshot.Transfers[0].ID, true))
+}
+
+func TestRebalancerApproval(t *testing.T) {
+	r, e := makeRebalancer(nil, nil, []rebalanceAsset{{asset: model.XLM}}, RebalanceModeApproval, 60, 600, 0.2, 0.5)
+	if !assert.NoError(t, e) {
+		return
+	}
+	r.now = func() time.Time { return time.Unix(1600000000, 0) }
+
+	r.check(map[model.Asset]float64{model.XLM: 95}, map[model.Asset]float64{model.XLM: 5})
+	snapshot := r.snapshot()
+	if !assert.Equal(t, 1, len(snapshot.Transfers)) {
+		return
+	}
+	assert.Equal(t, RebalanceToExchange, snapshot.Transfers[0].Direction)
+	assert.Equal(t, RebalanceStatusPendingApproval, snapshot.Transfers[0].Status)
+
+	assert.Error(t, r.approve("unknown", false))
+	assert.NoError(t, r.approve(snapshot.Transfers[0].ID, false))
+	assert.Equal(t, RebalanceStatusRejected, r.snapshot().Transfers[0].Status)
+	// a transfer can only be decided once
+	assert.Error(t, r.approve(snapshot.Transfers[0].ID, true))
+}
+
+func TestMakeRebalanceMemo(t *testing.T) {
+	assert.Equal(t, build.MemoID{Value: 12345}, makeRebalanceMemo("12345"))
+	assert.Equal(t, build.MemoText{Value: "abc-123"}, makeRebalanceMemo("abc-123"))
+}
@@ -1,6 +1,7 @@
 package plugins
 
 import (
+	"database/sql"
 	"fmt"
 	"log"
 	"math"
@@ -8,6 +9,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/nikhilsaraf/go-tools/multithreading"
@@ -15,8 +17,10 @@ import (
 	"github.com/stellar/go/build"
 	"github.com/stellar/go/clients/horizonclient"
 	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/xdr"
 	"github.com/stellar/kelp/api"
 	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/monitoring"
 	"github.com/stellar/kelp/support/networking"
 	"github.com/stellar/kelp/support/utils"
 )
@@ -47,12 +51,19 @@ type SDEX struct {
 	assetMap                      map[model.Asset]hProtocol.Asset // this is needed until we fully address putting SDEX behind the Exchange interface
 	opFeeStroopsFn                OpFeeStroops
 	tradingOnSdex                 bool
+	coreURL                       string      // submit directly to stellar-core when set, see SetCoreURL
+	coreDB                        *sql.DB     // read offers and orderbooks from the database of stellar-core when set, see SetCoreDB
+	passiveOffers                 bool        // create all new offers as passive offers when set, see SetPassiveOffers
+	opBudget                      *opBudget   // limits the ops submitted per ledger window when set, see SetOpBudget
+	offerCache                    *offerCache // nil unless the trades and offers are streamed from Horizon, see EnableStreaming
 
 	// uninitialized
-	seqNum             uint64
-	reloadSeqNum       bool
+	txSources          []*txSource // the source account followed by the additional source accounts, see SetAdditionalSourceSeeds
+	nextTxSourceIndex  int
+	sourceMutex        *sync.Mutex
 	ieif               *IEIF
 	ocOverridesHandler *OrderConstraintsOverridesHandler
+	txFailures         *txFailureRecorder
 }
 
 // enforce SDEX implements api.Constrainable
@@ -61,6 +72,9 @@ var _ api.Constrainable = &SDEX{}
 // enforce SDEX implements api.ExchangeShim
 var _ api.ExchangeShim = &SDEX{}
 
+// enforce SDEX can load the balances of all assets with a single request
+var _ accountBalancesLoader = &SDEX{}
+
 // Balance repesents an asset's balance response from the assetBalance method below
 type Balance struct {
 	Balance float64
@@ -118,7 +132,9 @@ func MakeSDEX(
 		sdex.SourceSeed = sdex.TradingSeed
 		log.Println("No Source Account Set")
 	}
-	sdex.reloadSeqNum = true
+	sdex.txSources = []*txSource{makeTxSource(sdex.SourceAccount, sdex.SourceSeed)}
+	sdex.sourceMutex = &sync.Mutex{}
+	sdex.txFailures = makeTxFailureRecorder()
 
 	return sdex
 }
@@ -154,26 +170,6 @@ func (sdex *SDEX) GetAssetConverter() model.AssetConverterInterface {
 	return model.Display
 }
 
-func (sdex *SDEX) incrementSeqNum() {
-	if sdex.reloadSeqNum {
-		log.Println("reloading sequence number")
-		acctReq := horizonclient.AccountRequest{AccountID: sdex.SourceAccount}
-		accountDetail, err := sdex.API.AccountDetail(acctReq)
-		if err != nil {
-			log.Printf("error loading account detail: %s\n", err)
-			return
-		}
-		seqNum, err := accountDetail.GetSequenceNumber()
-		if err != nil {
-			log.Printf("error getting seq num: %s\n", err)
-			return
-		}
-		sdex.seqNum = uint64(seqNum)
-		sdex.reloadSeqNum = false
-	}
-	sdex.seqNum++
-}
-
 // GetOrderConstraints impl
 func (sdex *SDEX) GetOrderConstraints(pair *model.TradingPair) *model.OrderConstraints {
 	return sdex.ocOverridesHandler.Apply(pair, sdexOrderConstraints)
@@ -202,68 +198,157 @@ func (sdex *SDEX) DeleteOffer(offer hProtocol.Offer) build.ManageOfferBuilder {
 		Price:   build.Price(offer.Price),
 	}
 
-	if sdex.SourceAccount == sdex.TradingAccount {
+	if !sdex.opsNeedTradingSource() {
 		return build.ManageOffer(false, build.Amount("0"), rate, build.OfferID(offer.ID))
 	}
 	return build.ManageOffer(false, build.Amount("0"), rate, build.OfferID(offer.ID), build.SourceAccount{AddressOrSeed: sdex.TradingAccount})
 }
 
-// ModifyBuyOffer modifies a buy offer
-func (sdex *SDEX) ModifyBuyOffer(offer hProtocol.Offer, price float64, amount float64, incrementalNativeAmountRaw float64) (*build.ManageOfferBuilder, error) {
-	return sdex.ModifySellOffer(offer, 1/price, amount*price, incrementalNativeAmountRaw)
+// ModifyBuyOffer modifies a buy offer with a native ManageBuyOffer op, price is in units of the quote asset and amount is the exact amount of the
+// base asset to buy. Returns nil if the offer would exceed the liability or trust limits.
+func (sdex *SDEX) ModifyBuyOffer(offer hProtocol.Offer, price float64, amount float64, incrementalNativeAmountRaw float64) (build.TransactionMutator, error) {
+	// the offer is stored as a sell offer of the quote asset so selling and buying are already in the order of the buy offer
+	return sdex.createModifyBuyOffer(&offer, offer.Selling, offer.Buying, price, amount, incrementalNativeAmountRaw)
 }
 
 // ModifySellOffer modifies a sell offer
 func (sdex *SDEX) ModifySellOffer(offer hProtocol.Offer, price float64, amount float64, incrementalNativeAmountRaw float64) (*build.ManageOfferBuilder, error) {
-	return sdex.createModifySellOffer(&offer, offer.Selling, offer.Buying, price, amount, incrementalNativeAmountRaw)
+	// passive offers retain their passive flag when modified so this is always a regular manage offer op
+	return sdex.createModifySellOffer(&offer, offer.Selling, offer.Buying, price, amount, incrementalNativeAmountRaw, false)
 }
 
-// CreateSellOffer creates a sell offer
+// CreateSellOffer creates a sell offer, which is passive if SetPassiveOffers was enabled
 func (sdex *SDEX) CreateSellOffer(base hProtocol.Asset, counter hProtocol.Asset, price float64, amount float64, incrementalNativeAmountRaw float64) (*build.ManageOfferBuilder, error) {
-	return sdex.createModifySellOffer(nil, base, counter, price, amount, incrementalNativeAmountRaw)
+	return sdex.createModifySellOffer(nil, base, counter, price, amount, incrementalNativeAmountRaw, sdex.passiveOffers)
+}
+
+// CreatePassiveSellOffer creates a passive sell offer, which does not take offers at the same price on the other side of the orderbook
+func (sdex *SDEX) CreatePassiveSellOffer(base hProtocol.Asset, counter hProtocol.Asset, price float64, amount float64, incrementalNativeAmountRaw float64) (*build.ManageOfferBuilder, error) {
+	return sdex.createModifySellOffer(nil, base, counter, price, amount, incrementalNativeAmountRaw, true)
+}
+
+// SetPassiveOffers sets whether all new offers should be created as passive offers
+func (sdex *SDEX) SetPassiveOffers(passiveOffers bool) {
+	sdex.passiveOffers = passiveOffers
+	if passiveOffers {
+		log.Printf("creating all new offers as passive offers\n")
+	}
+}
+
+// SetOpBudget limits the number of operations submitted per ledger window across all transactions of this account, submissions that
+// exceed the budget are delayed to smooth out bursts. A burst of 0 allows at most opsPerLedger operations at once.
+func (sdex *SDEX) SetOpBudget(opsPerLedger uint32, burst uint32) error {
+	b, e := makeOpBudget(opsPerLedger, burst)
+	if e != nil {
+		return fmt.Errorf("unable to make op budget: %s", e)
+	}
+	sdex.opBudget = b
+	log.Printf("limiting submitted operations using %s\n", b)
+	return nil
+}
+
+// ShareOpBudget makes this SDEX take its submissions from the op budget of the other SDEX, used when several trading pairs of the same
+// account run in one process. It does nothing when the other SDEX does not have an op budget.
+func (sdex *SDEX) ShareOpBudget(other *SDEX) {
+	if other.opBudget != nil {
+		sdex.opBudget = other.opBudget
+	}
 }
 
 func (sdex *SDEX) minReserve(subentries int32) float64 {
+	return MinAccountBalance(subentries)
+}
+
+// MinAccountBalance is the minimum balance of XLM that an account with the number of subentries needs to hold
+func MinAccountBalance(subentries int32) float64 {
 	return float64(2+subentries) * baseReserve
 }
 
 // assetBalance returns asset balance, asset trust limit, reserve balance (zero for non-XLM), error
 func (sdex *SDEX) _assetBalance(asset hProtocol.Asset) (*api.Balance, error) {
+	lookup, e := sdex.loadAccountBalances()
+	if e != nil {
+		return nil, e
+	}
+	return lookup(asset)
+}
+
+// loadAccountBalances loads the account once and returns the lookup of the balance of each asset, so the balances of all assets can be
+// read from a single request
+func (sdex *SDEX) loadAccountBalances() (func(asset hProtocol.Asset) (*api.Balance, error), error) {
 	acctReq := horizonclient.AccountRequest{AccountID: sdex.TradingAccount}
 	account, err := sdex.API.AccountDetail(acctReq)
 	if err != nil {
 		return nil, fmt.Errorf("error: unable to load account to fetch balance: %s", err)
 	}
 
+	return func(asset hProtocol.Asset) (*api.Balance, error) {
+		return sdex.accountBalance(account, asset)
+	}, nil
+}
+
+// accountBalance returns the balance of the asset in the loaded account
+func (sdex *SDEX) accountBalance(account hProtocol.Account, asset hProtocol.Asset) (*api.Balance, error) {
 	for _, balance := range account.Balances {
 		if utils.AssetsEqual(balance.Asset, asset) {
 			b, e := strconv.ParseFloat(balance.Balance, 64)
 			if e != nil {
 				return nil, fmt.Errorf("error: cannot parse balance: %s", e)
 			}
+			liabilities, e := parseBalanceLiabilities(balance)
+			if e != nil {
+				return nil, fmt.Errorf("error: cannot parse liabilities: %s", e)
+			}
 			if balance.Asset.Type == utils.Native {
 				return &api.Balance{
-					Balance: b,
-					Trust:   maxLumenTrust,
-					Reserve: sdex.minReserve(account.SubentryCount) + sdex.operationalBuffer,
+					Balance:     b,
+					Trust:       maxLumenTrust,
+					Reserve:     sdex.minReserve(account.SubentryCount) + sdex.operationalBuffer,
+					Liabilities: liabilities,
 				}, nil
 			}
 
+			// the issuer can revoke the authorization while the bot is running, which fails every offer on the asset
+			if balance.IsAuthorized != nil && !*balance.IsAuthorized {
+				return nil, fmt.Errorf("error: account %s is not authorized by the issuer %s to trade %s, the issuer may have revoked the authorization of the trustline, run `kelp trust check` for details",
+					sdex.TradingAccount, balance.Asset.Issuer, balance.Asset.Code)
+			}
 			t, e := strconv.ParseFloat(balance.Limit, 64)
 			if e != nil {
 				return nil, fmt.Errorf("error: cannot parse trust limit: %s", e)
 			}
 
 			return &api.Balance{
-				Balance: b,
-				Trust:   t,
-				Reserve: b * sdex.operationalBufferNonNativePct,
+				Balance:     b,
+				Trust:       t,
+				Reserve:     b * sdex.operationalBufferNonNativePct,
+				Liabilities: liabilities,
 			}, nil
 		}
 	}
 	return nil, errors.New("could not find a balance for the asset passed in")
 }
 
+// parseBalanceLiabilities returns the liabilities reported by Horizon on the balance line, nil if they are not reported
+func parseBalanceLiabilities(balance hProtocol.Balance) (*api.BalanceLiabilities, error) {
+	if balance.BuyingLiabilities == "" || balance.SellingLiabilities == "" {
+		return nil, nil
+	}
+
+	buying, e := strconv.ParseFloat(balance.BuyingLiabilities, 64)
+	if e != nil {
+		return nil, fmt.Errorf("cannot parse buying liabilities: %s", e)
+	}
+	selling, e := strconv.ParseFloat(balance.SellingLiabilities, 64)
+	if e != nil {
+		return nil, fmt.Errorf("cannot parse selling liabilities: %s", e)
+	}
+	return &api.BalanceLiabilities{
+		Buying:  buying,
+		Selling: selling,
+	}, nil
+}
+
 // GetBalanceHack impl
 func (sdex *SDEX) GetBalanceHack(asset hProtocol.Asset) (*api.Balance, error) {
 	b, e := sdex._assetBalance(asset)
@@ -276,13 +361,13 @@ func (sdex *SDEX) LoadOffersHack() ([]hProtocol.Offer, error) {
 }
 
 func (sdex *SDEX) _loadOffers() ([]hProtocol.Offer, error) {
-	return utils.LoadAllOffers(sdex.TradingAccount, sdex.API)
+	return sdex.loadOffers()
 }
 
 // ComputeIncrementalNativeAmountRaw returns the native amount that will be added to liabilities because of fee and min-reserve additions
 func (sdex *SDEX) ComputeIncrementalNativeAmountRaw(isNewOffer bool) float64 {
 	incrementalNativeAmountRaw := 0.0
-	if sdex.TradingAccount == sdex.SourceAccount {
+	if sdex.tradingAccountPaysFees() {
 		// at the minimum it will cost us a unit of base fee for this operation
 		incrementalNativeAmountRaw += baseFee
 	}
@@ -294,7 +379,7 @@ func (sdex *SDEX) ComputeIncrementalNativeAmountRaw(isNewOffer bool) float64 {
 }
 
 // createModifySellOffer is the main method that handles the logic of creating or modifying an offer, note that all offers are treated as sell offers in Stellar
-func (sdex *SDEX) createModifySellOffer(offer *hProtocol.Offer, selling hProtocol.Asset, buying hProtocol.Asset, price float64, amount float64, incrementalNativeAmountRaw float64) (*build.ManageOfferBuilder, error) {
+func (sdex *SDEX) createModifySellOffer(offer *hProtocol.Offer, selling hProtocol.Asset, buying hProtocol.Asset, price float64, amount float64, incrementalNativeAmountRaw float64, passive bool) (*build.ManageOfferBuilder, error) {
 	if price <= 0 {
 		return nil, fmt.Errorf("error: cannot create or modify offer, invalid price: %.8f", price)
 	}
@@ -351,13 +436,90 @@ func (sdex *SDEX) createModifySellOffer(offer *hProtocol.Offer, selling hProtoco
 	if offer != nil {
 		mutators = append(mutators, build.OfferID(offer.ID))
 	}
-	if sdex.SourceAccount != sdex.TradingAccount {
+	if sdex.opsNeedTradingSource() {
 		mutators = append(mutators, build.SourceAccount{AddressOrSeed: sdex.TradingAccount})
 	}
+	if passive && offer == nil {
+		result := build.ManageOffer(true, mutators...)
+		// mirror the passive offer into MO so submit filters and other consumers can inspect it like any other new offer
+		result.MO = xdr.ManageSellOfferOp{
+			Selling: result.PO.Selling,
+			Buying:  result.PO.Buying,
+			Amount:  result.PO.Amount,
+			Price:   result.PO.Price,
+		}
+		return &result, nil
+	}
 	result := build.ManageOffer(false, mutators...)
 	return &result, nil
 }
 
+// createModifyBuyOffer handles the logic of creating or modifying a buy offer using a native ManageBuyOffer op, price is the price of the buying
+// asset in units of the selling asset and amount is the amount of the buying asset. Returns a nil op if the offer would exceed the limits.
+func (sdex *SDEX) createModifyBuyOffer(offer *hProtocol.Offer, selling hProtocol.Asset, buying hProtocol.Asset, price float64, amount float64, incrementalNativeAmountRaw float64) (build.TransactionMutator, error) {
+	if price <= 0 {
+		return nil, fmt.Errorf("error: cannot create or modify buy offer, invalid price: %.8f", price)
+	}
+	if amount <= 0 {
+		return nil, fmt.Errorf("error: cannot create or modify buy offer, invalid amount: %.8f", amount)
+	}
+
+	// check liability limits on the asset being sold
+	incrementalSell := price * amount
+	willOversell, e := sdex.ieif.willOversell(selling, incrementalSell)
+	if e != nil {
+		return nil, e
+	}
+	if willOversell {
+		return nil, nil
+	}
+
+	// check trust limits on asset being bought
+	willOverbuy, e := sdex.ieif.willOverbuy(buying, amount)
+	if e != nil {
+		return nil, e
+	}
+	if willOverbuy {
+		return nil, nil
+	}
+
+	// explicitly check that we will not oversell XLM because of fee and min reserves
+	if sdex.tradingOnSdex {
+		incrementalNativeAmountTotal := incrementalNativeAmountRaw
+		if selling.Type == utils.Native {
+			incrementalNativeAmountTotal += incrementalSell
+		}
+		willOversellNative, e := sdex.ieif.willOversellNative(incrementalNativeAmountTotal)
+		if e != nil {
+			return nil, e
+		}
+		if willOversellNative {
+			return nil, nil
+		}
+	}
+
+	offerID := int64(0)
+	if offer != nil {
+		offerID = offer.ID
+	}
+	sourceAccount := ""
+	if sdex.opsNeedTradingSource() {
+		sourceAccount = sdex.TradingAccount
+	}
+	result := makeManageBuyOffer(
+		utils.Asset2Asset(selling),
+		utils.Asset2Asset(buying),
+		strconv.FormatFloat(price, 'f', int(sdexOrderConstraints.PricePrecision), 64),
+		strconv.FormatFloat(amount, 'f', int(sdexOrderConstraints.VolumePrecision), 64),
+		offerID,
+		sourceAccount,
+	)
+	if result.Err != nil {
+		return nil, fmt.Errorf("error: cannot create or modify buy offer: %s", result.Err)
+	}
+	return result, nil
+}
+
 // SubmitOpsSynch is the forced synchronous version of SubmitOps below
 func (sdex *SDEX) SubmitOpsSynch(ops []build.TransactionMutator, asyncCallback func(hash string, e error)) error {
 	return sdex.submitOps(ops, asyncCallback, false)
@@ -370,11 +532,19 @@ func (sdex *SDEX) SubmitOps(ops []build.TransactionMutator, asyncCallback func(h
 
 // submitOps submits the passed in operations to the network in a single transaction. Asynchronous or not based on flag.
 func (sdex *SDEX) submitOps(ops []build.TransactionMutator, asyncCallback func(hash string, e error), asyncMode bool) error {
-	sdex.incrementSeqNum()
+	if sdex.opBudget != nil {
+		sdex.opBudget.wait(len(ops))
+	}
+
+	source := sdex.nextTxSource()
+	seqNum, e := source.sequence.next(sdex.loadSequenceNumber)
+	if e != nil {
+		return fmt.Errorf("SubmitOps error: %s", e)
+	}
 	muts := []build.TransactionMutator{
-		build.Sequence{Sequence: sdex.seqNum},
+		build.Sequence{Sequence: seqNum},
 		sdex.Network,
-		build.SourceAccount{AddressOrSeed: sdex.SourceAccount},
+		build.SourceAccount{AddressOrSeed: source.address},
 	}
 	// compute fee per operation
 	opFee, e := sdex.opFeeStroopsFn()
@@ -385,31 +555,57 @@ func (sdex *SDEX) submitOps(ops []build.TransactionMutator, asyncCallback func(h
 	// add transaction mutators
 	muts = append(muts, ops...)
 
+	endBuildSpan := monitoring.StartSpan(monitoring.SpanTxBuild)
 	tx, e := build.Transaction(muts...)
 	if e != nil {
 		return errors.Wrap(e, "SubmitOps error: ")
 	}
 
 	// convert to xdr string
-	txeB64, e := sdex.sign(tx)
+	txeB64, e := sdex.sign(tx, source)
 	if e != nil {
 		return e
 	}
+	endBuildSpan()
 	log.Printf("tx XDR: %s\n", txeB64)
 
+	submitFn := func(txeB64 string, asyncCallback func(hash string, e error), asyncMode bool) {
+		sdex.submit(txeB64, source, asyncCallback, asyncMode)
+	}
+	if sdex.coreURL != "" {
+		txHash, e := tx.HashHex()
+		if e != nil {
+			return fmt.Errorf("SubmitOps error when computing tx hash: %s", e)
+		}
+		submitFn = func(txeB64 string, asyncCallback func(hash string, e error), asyncMode bool) {
+			sdex.submitToCore(txeB64, txHash, source, asyncCallback, asyncMode)
+		}
+	}
+	// the span is started here so the confirmation of an asynchronous submission is attributed to the update cycle that submitted it
+	innerSubmitFn := submitFn
+	endConfirmSpan := monitoring.StartSpan(monitoring.SpanTxConfirm)
+	submitFn = func(txeB64 string, asyncCallback func(hash string, e error), asyncMode bool) {
+		innerSubmitFn(txeB64, asyncCallback, asyncMode)
+		endConfirmSpan()
+		if sdex.offerCache != nil {
+			// deleted offers are not streamed so the offers need to be polled once the transaction is in a ledger
+			sdex.offerCache.invalidate()
+		}
+	}
+
 	// submit
 	if !sdex.simMode {
 		if asyncMode {
 			log.Println("submitting tx XDR to network (async)")
 			e = sdex.threadTracker.TriggerGoroutine(func(inputs []interface{}) {
-				sdex.submit(txeB64, asyncCallback, true)
+				submitFn(txeB64, asyncCallback, true)
 			}, nil)
 			if e != nil {
 				return fmt.Errorf("unable to trigger goroutine to submit tx XDR to network asynchronously: %s", e)
 			}
 		} else {
 			log.Println("submitting tx XDR to network (synch)")
-			sdex.submit(txeB64, asyncCallback, false)
+			submitFn(txeB64, asyncCallback, false)
 		}
 	} else {
 		log.Println("not submitting tx XDR to network in simulation mode, calling asyncCallback with empty hash value")
@@ -418,19 +614,34 @@ func (sdex *SDEX) submitOps(ops []build.TransactionMutator, asyncCallback func(h
 	return nil
 }
 
-// CreateBuyOffer creates a buy offer
-func (sdex *SDEX) CreateBuyOffer(base hProtocol.Asset, counter hProtocol.Asset, price float64, amount float64, incrementalNativeAmountRaw float64) (*build.ManageOfferBuilder, error) {
-	return sdex.CreateSellOffer(counter, base, 1/price, amount*price, incrementalNativeAmountRaw)
+// CreateBuyOffer creates a buy offer with a native ManageBuyOffer op, price is in units of the counter asset and amount is the exact amount of the
+// base asset to buy. Stellar does not have a passive ManageBuyOffer op so this falls back to CreatePassiveBuyOffer if SetPassiveOffers was enabled.
+// Returns nil if the offer would exceed the liability or trust limits.
+func (sdex *SDEX) CreateBuyOffer(base hProtocol.Asset, counter hProtocol.Asset, price float64, amount float64, incrementalNativeAmountRaw float64) (build.TransactionMutator, error) {
+	if sdex.passiveOffers {
+		op, e := sdex.CreatePassiveBuyOffer(base, counter, price, amount, incrementalNativeAmountRaw)
+		if e != nil || op == nil {
+			return nil, e
+		}
+		return op, nil
+	}
+	return sdex.createModifyBuyOffer(nil, counter, base, price, amount, incrementalNativeAmountRaw)
 }
 
-func (sdex *SDEX) sign(tx *build.TransactionBuilder) (string, error) {
+// CreatePassiveBuyOffer creates a passive buy offer, Stellar only has passive sell offers so this is a passive sell offer of the counter asset
+// at the inverted price, which does not preserve the exact amount of the base asset
+func (sdex *SDEX) CreatePassiveBuyOffer(base hProtocol.Asset, counter hProtocol.Asset, price float64, amount float64, incrementalNativeAmountRaw float64) (*build.ManageOfferBuilder, error) {
+	return sdex.CreatePassiveSellOffer(counter, base, 1/price, amount*price, incrementalNativeAmountRaw)
+}
+
+func (sdex *SDEX) sign(tx *build.TransactionBuilder, source *txSource) (string, error) {
 	var txe build.TransactionEnvelopeBuilder
 	var e error
 
-	if sdex.SourceSeed != sdex.TradingSeed {
-		txe, e = tx.Sign(sdex.SourceSeed, sdex.TradingSeed)
+	if source.seed != sdex.TradingSeed {
+		txe, e = tx.Sign(source.seed, sdex.TradingSeed)
 	} else {
-		txe, e = tx.Sign(sdex.SourceSeed)
+		txe, e = tx.Sign(source.seed)
 	}
 	if e != nil {
 		return "", e
@@ -439,7 +650,7 @@ func (sdex *SDEX) sign(tx *build.TransactionBuilder) (string, error) {
 	return txe.Base64()
 }
 
-func (sdex *SDEX) submit(txeB64 string, asyncCallback func(hash string, e error), asyncMode bool) {
+func (sdex *SDEX) submit(txeB64 string, source *txSource, asyncCallback func(hash string, e error), asyncMode bool) {
 	resp, err := sdex.API.SubmitTransactionXDR(txeB64)
 	if err != nil {
 		if herr, ok := errors.Cause(err).(*horizonclient.Error); ok {
@@ -451,10 +662,14 @@ func (sdex *SDEX) submit(txeB64 string, asyncCallback func(hash string, e error)
 				return
 			}
 			if rcs.TransactionCode == "tx_bad_seq" {
-				log.Println("(async) error: tx_bad_seq, setting flag to reload seq number")
-				sdex.reloadSeqNum = true
+				log.Printf("(async) error: tx_bad_seq, setting flag to reload seq number of source account %s\n", source.address)
+				source.sequence.invalidate()
 			}
 			log.Println("(async) error: result code details: tx code =", rcs.TransactionCode, ", opcodes =", rcs.OperationCodes)
+			failure := makeTxFailure(rcs)
+			sdex.recordTxFailure(failure)
+			log.Printf("(async) error: %s\n", failure.Error())
+			err = failure
 		} else {
 			log.Printf("(async) error: tx failed for unknown reason, error message: %s\n", err)
 		}
@@ -683,6 +898,7 @@ func (sdex *SDEX) tradesPage2TradeHistoryResult(baseAsset hProtocol.Asset, quote
 			TransactionID: model.MakeTransactionID(t.ID),
 			Cost:          price.Multiply(*vol),
 			Fee:           model.NumberFromFloat(baseFee, sdexOrderConstraints.PricePrecision),
+			FeeAsset:      model.XLM,
 		})
 
 		cursor = t.PT
@@ -752,18 +968,27 @@ func (sdex *SDEX) GetOrderBook(pair *model.TradingPair, maxCount int32) (*model.
 		BuyingAssetIssuer:  quoteAsset.Issuer,
 	}
 
-	ob, e := sdex.API.OrderBook(obReq)
-	if e != nil {
-		return nil, fmt.Errorf("cannot get SDEX orderbook: %s", e)
+	var bids, asks []hProtocol.PriceLevel
+	if sdex.coreDB != nil {
+		bids, asks, e = sdex.loadOrderBookFromCore(baseAsset, quoteAsset, maxCount)
+		if e != nil {
+			return nil, fmt.Errorf("cannot get SDEX orderbook from stellar-core: %s", e)
+		}
+	} else {
+		ob, e := sdex.API.OrderBook(obReq)
+		if e != nil {
+			return nil, fmt.Errorf("cannot get SDEX orderbook: %s", e)
+		}
+		bids, asks = ob.Bids, ob.Asks
 	}
 
 	ts := model.MakeTimestamp(time.Now().UnixNano() / int64(time.Millisecond))
-	transformedBids, e := sdex.transformHorizonOrders(pair, ob.Bids, model.OrderActionBuy, ts, maxCount)
+	transformedBids, e := sdex.transformHorizonOrders(pair, bids, model.OrderActionBuy, ts, maxCount)
 	if e != nil {
 		return nil, fmt.Errorf("could not transform bid side of SDEX orderbook: %s", e)
 	}
 
-	transformedAsks, e := sdex.transformHorizonOrders(pair, ob.Asks, model.OrderActionSell, ts, maxCount)
+	transformedAsks, e := sdex.transformHorizonOrders(pair, asks, model.OrderActionSell, ts, maxCount)
 	if e != nil {
 		return nil, fmt.Errorf("could not transform ask side of SDEX orderbook: %s", e)
 	}
@@ -0,0 +1,130 @@
+package plugins
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// LoadAccountTrades fetches the trades of the account on the pair of base and quote assets that happened between from and to (inclusive).
+// It pages through the trades of the account from the newest so it stops once it reaches trades older than from. Trades are returned
+// in ascending order and report the base network fee in XLM, like the trade history of SDEX.
+func LoadAccountTrades(
+	client *horizonclient.Client,
+	accountID string,
+	pair *model.TradingPair,
+	baseAsset hProtocol.Asset,
+	quoteAsset hProtocol.Asset,
+	from time.Time,
+	to time.Time,
+) ([]model.Trade, error) {
+	trades := []model.Trade{}
+	cursor := ""
+	for {
+		tradesPage, e := client.Trades(horizonclient.TradeRequest{
+			ForAccount: accountID,
+			Order:      horizonclient.OrderDesc,
+			Cursor:     cursor,
+			Limit:      uint(maxPageLimit),
+		})
+		if e != nil {
+			return nil, fmt.Errorf("error while fetching trades of account %s (cursor=%s): %s", accountID, cursor, e)
+		}
+
+		records := tradesPage.Embedded.Records
+		for _, t := range records {
+			if t.LedgerCloseTime.After(to) {
+				continue
+			}
+			if t.LedgerCloseTime.Before(from) {
+				return reverseTrades(trades), nil
+			}
+
+			trade, e := accountTrade2Trade(accountID, pair, baseAsset, quoteAsset, t)
+			if e != nil {
+				return nil, fmt.Errorf("could not convert trade (ID=%s): %s", t.ID, e)
+			}
+			if trade != nil {
+				trades = append(trades, *trade)
+			}
+		}
+
+		if len(records) < maxPageLimit {
+			return reverseTrades(trades), nil
+		}
+		cursor = records[len(records)-1].PT
+	}
+}
+
+// accountTrade2Trade converts a trade of the account into a trade on the pair from the point of view of the account, returns nil when the
+// trade was on a different pair of assets
+func accountTrade2Trade(accountID string, pair *model.TradingPair, baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset, t hProtocol.Trade) (*model.Trade, error) {
+	tradeBaseAsset := utils.Native
+	if t.BaseAssetType != utils.Native {
+		tradeBaseAsset = t.BaseAssetCode + ":" + t.BaseAssetIssuer
+	}
+	tradeCounterAsset := utils.Native
+	if t.CounterAssetType != utils.Native {
+		tradeCounterAsset = t.CounterAssetCode + ":" + t.CounterAssetIssuer
+	}
+	sdexBaseAsset := utils.Asset2String(baseAsset)
+	sdexQuoteAsset := utils.Asset2String(quoteAsset)
+
+	// the account sold the base asset of the trade if it was on the selling side of it
+	accountSoldTradeBase := (t.BaseAccount == accountID) == t.BaseIsSeller
+	var baseAmount, quoteAmount string
+	var action model.OrderAction
+	if sdexBaseAsset == tradeBaseAsset && sdexQuoteAsset == tradeCounterAsset {
+		baseAmount, quoteAmount = t.BaseAmount, t.CounterAmount
+		action = model.OrderActionBuy
+		if accountSoldTradeBase {
+			action = model.OrderActionSell
+		}
+	} else if sdexBaseAsset == tradeCounterAsset && sdexQuoteAsset == tradeBaseAsset {
+		baseAmount, quoteAmount = t.CounterAmount, t.BaseAmount
+		action = model.OrderActionSell
+		if accountSoldTradeBase {
+			action = model.OrderActionBuy
+		}
+	} else {
+		return nil, nil
+	}
+
+	vol, e := model.NumberFromString(baseAmount, sdexOrderConstraints.VolumePrecision)
+	if e != nil {
+		return nil, fmt.Errorf("could not convert base amount to model.Number: %s", e)
+	}
+	cost, e := model.NumberFromString(quoteAmount, sdexOrderConstraints.VolumePrecision)
+	if e != nil {
+		return nil, fmt.Errorf("could not convert quote amount to model.Number: %s", e)
+	}
+	if vol.AsFloat() == 0 {
+		return nil, fmt.Errorf("trade has a base amount of 0")
+	}
+	price := model.NumberFromFloat(cost.AsFloat()/vol.AsFloat(), sdexOrderConstraints.PricePrecision)
+
+	return &model.Trade{
+		Order: model.Order{
+			Pair:        pair,
+			OrderAction: action,
+			OrderType:   model.OrderTypeLimit,
+			Price:       price,
+			Volume:      vol,
+			Timestamp:   model.MakeTimestampFromTime(t.LedgerCloseTime),
+		},
+		TransactionID: model.MakeTransactionID(t.ID),
+		Cost:          cost,
+		Fee:           model.NumberFromFloat(baseFee, sdexOrderConstraints.PricePrecision),
+	}, nil
+}
+
+func reverseTrades(trades []model.Trade) []model.Trade {
+	for i, j := 0, len(trades)-1; i < j; i, j = i+1, j-1 {
+		trades[i], trades[j] = trades[j], trades[i]
+	}
+	return trades
+}
@@ -0,0 +1,75 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountTrade2Trade(t *testing.T) {
+	pair := &model.TradingPair{Base: model.Asset("XLM"), Quote: model.Asset("USD")}
+	native := hProtocol.Asset{Type: "native"}
+	usd := hProtocol.Asset{Type: "credit_alphanum4", Code: "USD", Issuer: "GUSDISSUER"}
+	makeTrade := func(baseIsNative bool, baseAccount string, baseIsSeller bool) hProtocol.Trade {
+		trade := hProtocol.Trade{
+			ID:              "trade1",
+			LedgerCloseTime: time.Unix(1577836800, 0),
+			BaseAccount:     baseAccount,
+			BaseIsSeller:    baseIsSeller,
+		}
+		if baseIsNative {
+			trade.BaseAssetType, trade.BaseAmount = "native", "100.0000000"
+			trade.CounterAssetType, trade.CounterAssetCode, trade.CounterAssetIssuer, trade.CounterAmount = "credit_alphanum4", "USD", "GUSDISSUER", "5.0000000"
+		} else {
+			trade.BaseAssetType, trade.BaseAssetCode, trade.BaseAssetIssuer, trade.BaseAmount = "credit_alphanum4", "USD", "GUSDISSUER", "5.0000000"
+			trade.CounterAssetType, trade.CounterAmount = "native", "100.0000000"
+		}
+		return trade
+	}
+
+	testCases := []struct {
+		name       string
+		trade      hProtocol.Trade
+		wantAction model.OrderAction
+	}{
+		{
+			name:       "account sold the base asset",
+			trade:      makeTrade(true, "GTRADER", true),
+			wantAction: model.OrderActionSell,
+		}, {
+			name:       "counterparty sold the base asset",
+			trade:      makeTrade(true, "GOTHER", true),
+			wantAction: model.OrderActionBuy,
+		}, {
+			name:       "account sold the quote asset on the inverted pair",
+			trade:      makeTrade(false, "GTRADER", true),
+			wantAction: model.OrderActionBuy,
+		}, {
+			name:       "counterparty sold the quote asset on the inverted pair",
+			trade:      makeTrade(false, "GOTHER", true),
+			wantAction: model.OrderActionSell,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			trade, e := accountTrade2Trade("GTRADER", pair, native, usd, k.trade)
+			if !assert.NoError(t, e) || !assert.NotNil(t, trade) {
+				return
+			}
+			assert.Equal(t, k.wantAction, trade.OrderAction)
+			assert.Equal(t, 100.0, trade.Volume.AsFloat())
+			assert.Equal(t, 5.0, trade.Cost.AsFloat())
+			assert.Equal(t, 0.05, trade.Price.AsFloat())
+		})
+	}
+
+	other := hProtocol.Asset{Type: "credit_alphanum4", Code: "EUR", Issuer: "GEURISSUER"}
+	trade, e := accountTrade2Trade("GTRADER", pair, native, other, makeTrade(true, "GTRADER", true))
+	if assert.NoError(t, e) {
+		assert.Nil(t, trade)
+	}
+}
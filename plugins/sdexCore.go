@@ -0,0 +1,310 @@
+package plugins
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	// registers the postgres driver used to read from the database of stellar-core
+	_ "github.com/lib/pq"
+	"github.com/stellar/go/amount"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/xdr"
+	"github.com/stellar/kelp/support/networking"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// coreTxResponse is the response from the /tx endpoint on stellar-core's HTTP port
+type coreTxResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"` // base64-encoded TransactionResult, only set when status is ERROR
+}
+
+// constants for the status field in coreTxResponse
+const (
+	coreTxStatusPending       = "PENDING"
+	coreTxStatusDuplicate     = "DUPLICATE"
+	coreTxStatusError         = "ERROR"
+	coreTxStatusTryAgainLater = "TRY_AGAIN_LATER"
+)
+
+// coreHTTPClient is used to submit transactions to stellar-core, which responds as soon as it has checked the tx so a hung connection
+// is the only reason for a request to take long
+var coreHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// SetCoreURL makes this SDEX instance submit transactions directly to the HTTP port of a (co-located) stellar-core instance instead of
+// going through Horizon, cutting out one network hop. Account state is still read from Horizon, offers and orderbooks are read from Horizon
+// unless SetCoreDB is also used.
+func (sdex *SDEX) SetCoreURL(coreURL string) error {
+	coreURL = strings.TrimSuffix(coreURL, "/")
+	if _, e := url.ParseRequestURI(coreURL); e != nil {
+		return fmt.Errorf("invalid stellar-core URL '%s': %s", coreURL, e)
+	}
+
+	sdex.coreURL = coreURL
+	log.Printf("submitting transactions directly to stellar-core at %s\n", sdex.coreURL)
+	return nil
+}
+
+// submitToCore submits the transaction to stellar-core, core does not wait for the transaction to be included in a ledger so the hash
+// passed to the callback is the locally computed hash of a transaction that has been accepted into core's pending queue
+func (sdex *SDEX) submitToCore(txeB64 string, txHash string, source *txSource, asyncCallback func(hash string, e error), asyncMode bool) {
+	modeString := "(synch)"
+	if asyncMode {
+		modeString = "(async)"
+	}
+
+	reqURL := fmt.Sprintf("%s/tx?blob=%s", sdex.coreURL, url.QueryEscape(txeB64))
+	var resp coreTxResponse
+	e := networking.JSONRequest(coreHTTPClient, "GET", reqURL, "", map[string]string{}, &resp, "")
+	if e != nil {
+		log.Printf("%s error: could not submit tx to stellar-core: %s\n", modeString, e)
+		sdex.invokeAsyncCallback(asyncCallback, "", e, asyncMode)
+		return
+	}
+
+	switch resp.Status {
+	case coreTxStatusPending, coreTxStatusDuplicate:
+		log.Printf("%s tx accepted by stellar-core with status %s, hash: %s\n", modeString, resp.Status, txHash)
+		sdex.invokeAsyncCallback(asyncCallback, txHash, nil, asyncMode)
+	case coreTxStatusError:
+		// we cannot cheaply tell a bad sequence number apart from other errors without decoding the result so always reload it
+		source.sequence.invalidate()
+		e = fmt.Errorf("stellar-core rejected tx (hash=%s), result XDR: %s", txHash, resp.Error)
+		if rcs, de := decodeTxResult(resp.Error); de != nil {
+			log.Printf("%s error: %s\n", modeString, de)
+		} else {
+			failure := makeTxFailure(rcs)
+			sdex.recordTxFailure(failure)
+			e = failure
+		}
+		log.Printf("%s error: %s, setting flag to reload seq number\n", modeString, e)
+		sdex.invokeAsyncCallback(asyncCallback, "", e, asyncMode)
+	default:
+		// TRY_AGAIN_LATER or any other unrecognized status means the tx was not accepted and the sequence number was not consumed
+		source.sequence.invalidate()
+		e = fmt.Errorf("stellar-core did not accept tx (hash=%s), status: %s", txHash, resp.Status)
+		log.Printf("%s error: %s\n", modeString, e)
+		sdex.invokeAsyncCallback(asyncCallback, "", e, asyncMode)
+	}
+}
+
+// coreOffersQuery selects the offers of an account from the offers table of stellar-core
+const coreOffersQuery = "SELECT sellerid, offerid, sellingasset, buyingasset, amount, pricen, priced, lastmodified FROM offers WHERE sellerid = $1 ORDER BY offerid"
+
+// coreOrderBookQuery selects the price levels of the offers that sell one asset for another from the offers table of stellar-core, in the
+// order in which they are taken. Offers can have the same price written as different fractions (1/2 and 2/4) so the groups are merged by
+// addCorePriceLevel, which is also why the number of levels cannot be limited in the query.
+const coreOrderBookQuery = "SELECT pricen, priced, SUM(amount) FROM offers WHERE sellingasset = $1 AND buyingasset = $2 GROUP BY pricen, priced ORDER BY MIN(price), pricen"
+
+// coreOfferRow is a row of the offers table of stellar-core, the assets are base64 encoded XDR and the amount is in stroops
+type coreOfferRow struct {
+	sellerID     string
+	offerID      int64
+	sellingAsset string
+	buyingAsset  string
+	amount       int64
+	priceN       int32
+	priceD       int32
+	lastModified int32
+}
+
+// corePriceLevelRow is the total amount in stroops of the offers of the orderbook at one price
+type corePriceLevelRow struct {
+	priceN int32
+	priceD int32
+	amount int64
+}
+
+// SetCoreDB makes this SDEX instance read the offers of the trading account and the orderbook directly from the database of a (co-located)
+// stellar-core instance instead of polling Horizon, which is usually a few ledgers ahead of Horizon's view of the network. The database
+// needs to store the assets of offers as base64 encoded XDR, which stellar-core does since v11.
+func (sdex *SDEX) SetCoreDB(dataSourceName string) error {
+	db, e := sql.Open("postgres", dataSourceName)
+	if e != nil {
+		return fmt.Errorf("unable to open stellar-core database: %s", e)
+	}
+	e = db.Ping()
+	if e != nil {
+		db.Close()
+		return fmt.Errorf("unable to connect to stellar-core database: %s", e)
+	}
+
+	sdex.coreDB = db
+	log.Printf("reading offers and orderbooks directly from the stellar-core database\n")
+	return nil
+}
+
+// loadOffersFromCore loads the offers of the account from the database of stellar-core
+func (sdex *SDEX) loadOffersFromCore(account string) ([]hProtocol.Offer, error) {
+	rows, e := sdex.coreDB.Query(coreOffersQuery, account)
+	if e != nil {
+		return nil, fmt.Errorf("unable to query offers from stellar-core database: %s", e)
+	}
+	defer rows.Close()
+
+	offers := []hProtocol.Offer{}
+	for rows.Next() {
+		var r coreOfferRow
+		e = rows.Scan(&r.sellerID, &r.offerID, &r.sellingAsset, &r.buyingAsset, &r.amount, &r.priceN, &r.priceD, &r.lastModified)
+		if e != nil {
+			return nil, fmt.Errorf("unable to scan offer from stellar-core database: %s", e)
+		}
+		offer, e := makeCoreOffer(r)
+		if e != nil {
+			return nil, e
+		}
+		offers = append(offers, *offer)
+	}
+	if e = rows.Err(); e != nil {
+		return nil, fmt.Errorf("unable to read offers from stellar-core database: %s", e)
+	}
+	return offers, nil
+}
+
+// loadOrderBookFromCore loads the bids and asks of the orderbook from the database of stellar-core, in the same format as Horizon returns them
+func (sdex *SDEX) loadOrderBookFromCore(baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset, maxCount int32) (bids []hProtocol.PriceLevel, asks []hProtocol.PriceLevel, e error) {
+	base, e := encodeCoreAsset(baseAsset)
+	if e != nil {
+		return nil, nil, e
+	}
+	quote, e := encodeCoreAsset(quoteAsset)
+	if e != nil {
+		return nil, nil, e
+	}
+
+	askRows, e := sdex.queryCorePriceLevels(base, quote, maxCount)
+	if e != nil {
+		return nil, nil, fmt.Errorf("unable to load asks: %s", e)
+	}
+	bidRows, e := sdex.queryCorePriceLevels(quote, base, maxCount)
+	if e != nil {
+		return nil, nil, fmt.Errorf("unable to load bids: %s", e)
+	}
+	return makeCorePriceLevels(bidRows, true), makeCorePriceLevels(askRows, false), nil
+}
+
+func (sdex *SDEX) queryCorePriceLevels(selling string, buying string, maxCount int32) ([]corePriceLevelRow, error) {
+	rows, e := sdex.coreDB.Query(coreOrderBookQuery, selling, buying)
+	if e != nil {
+		return nil, fmt.Errorf("unable to query orderbook from stellar-core database: %s", e)
+	}
+	defer rows.Close()
+
+	levels := []corePriceLevelRow{}
+	for rows.Next() {
+		var r corePriceLevelRow
+		e = rows.Scan(&r.priceN, &r.priceD, &r.amount)
+		if e != nil {
+			return nil, fmt.Errorf("unable to scan price level from stellar-core database: %s", e)
+		}
+		var done bool
+		levels, done = addCorePriceLevel(levels, r, maxCount)
+		if done {
+			break
+		}
+	}
+	if e = rows.Err(); e != nil {
+		return nil, fmt.Errorf("unable to read orderbook from stellar-core database: %s", e)
+	}
+	return levels, nil
+}
+
+// addCorePriceLevel adds the row to the price levels, which are sorted by price, merging it into the last level when both have the same price.
+// Returns true once the row starts a level beyond the maxCount levels, which is then not added.
+func addCorePriceLevel(levels []corePriceLevelRow, r corePriceLevelRow, maxCount int32) ([]corePriceLevelRow, bool) {
+	if len(levels) > 0 {
+		last := &levels[len(levels)-1]
+		// the cross products of two int32 fractions always fit in an int64
+		if int64(last.priceN)*int64(r.priceD) == int64(r.priceN)*int64(last.priceD) {
+			last.amount += r.amount
+			return levels, false
+		}
+	}
+	if int32(len(levels)) >= maxCount {
+		return levels, true
+	}
+	return append(levels, r), false
+}
+
+// makeCoreOffer converts a row of the offers table of stellar-core to the offer that Horizon would return for it
+func makeCoreOffer(r coreOfferRow) (*hProtocol.Offer, error) {
+	selling, e := decodeCoreAsset(r.sellingAsset)
+	if e != nil {
+		return nil, fmt.Errorf("unable to decode selling asset of offer %d: %s", r.offerID, e)
+	}
+	buying, e := decodeCoreAsset(r.buyingAsset)
+	if e != nil {
+		return nil, fmt.Errorf("unable to decode buying asset of offer %d: %s", r.offerID, e)
+	}
+
+	offer := hProtocol.Offer{
+		ID:                 r.offerID,
+		PT:                 strconv.FormatInt(r.offerID, 10),
+		Seller:             r.sellerID,
+		Selling:            selling,
+		Buying:             buying,
+		Amount:             amount.String(xdr.Int64(r.amount)),
+		Price:              big.NewRat(int64(r.priceN), int64(r.priceD)).FloatString(7),
+		LastModifiedLedger: r.lastModified,
+	}
+	offer.PriceR.N = r.priceN
+	offer.PriceR.D = r.priceD
+	return &offer, nil
+}
+
+// makeCorePriceLevels converts the price levels of the offers on one side of the orderbook to the price levels that Horizon would return.
+// Like Horizon the bids keep the amount of the quote asset that is offered and are priced in units of the quote asset, which inverts the
+// price of the offers that sell the quote asset.
+func makeCorePriceLevels(rows []corePriceLevelRow, isBid bool) []hProtocol.PriceLevel {
+	levels := []hProtocol.PriceLevel{}
+	for _, r := range rows {
+		n, d := r.priceN, r.priceD
+		if isBid {
+			n, d = d, n
+		}
+		level := hProtocol.PriceLevel{
+			Price:  big.NewRat(int64(n), int64(d)).FloatString(7),
+			Amount: amount.String(xdr.Int64(r.amount)),
+		}
+		level.PriceR.N = n
+		level.PriceR.D = d
+		levels = append(levels, level)
+	}
+	return levels
+}
+
+// encodeCoreAsset encodes the asset as base64 XDR, which is how stellar-core stores the assets of offers
+func encodeCoreAsset(asset hProtocol.Asset) (string, error) {
+	xdrAsset, e := utils.Asset2Asset(asset).ToXDR()
+	if e != nil {
+		return "", fmt.Errorf("unable to convert asset %s to XDR: %s", utils.Asset2String(asset), e)
+	}
+	encoded, e := xdr.MarshalBase64(xdrAsset)
+	if e != nil {
+		return "", fmt.Errorf("unable to encode asset %s: %s", utils.Asset2String(asset), e)
+	}
+	return encoded, nil
+}
+
+// decodeCoreAsset decodes an asset stored by stellar-core as base64 XDR
+func decodeCoreAsset(encoded string) (hProtocol.Asset, error) {
+	var xdrAsset xdr.Asset
+	e := xdr.SafeUnmarshalBase64(encoded, &xdrAsset)
+	if e != nil {
+		return hProtocol.Asset{}, fmt.Errorf("unable to decode asset XDR '%s': %s", encoded, e)
+	}
+
+	var assetType, code, issuer string
+	e = xdrAsset.Extract(&assetType, &code, &issuer)
+	if e != nil {
+		return hProtocol.Asset{}, fmt.Errorf("unable to extract asset from XDR '%s': %s", encoded, e)
+	}
+	return hProtocol.Asset{Type: assetType, Code: code, Issuer: issuer}, nil
+}
@@ -0,0 +1,142 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoreAssetEncoding(t *testing.T) {
+	for _, asset := range []hProtocol.Asset{
+		{Type: "native"},
+		{Type: "credit_alphanum4", Code: "USD", Issuer: testIssuer},
+		{Type: "credit_alphanum12", Code: "LONGASSET", Issuer: testIssuer},
+	} {
+		encoded, e := encodeCoreAsset(asset)
+		if !assert.NoError(t, e) {
+			return
+		}
+		decoded, e := decodeCoreAsset(encoded)
+		if assert.NoError(t, e) {
+			assert.Equal(t, asset, decoded)
+		}
+	}
+
+	_, e := decodeCoreAsset("not xdr")
+	assert.Error(t, e)
+}
+
+func TestMakeCoreOffer(t *testing.T) {
+	usd := hProtocol.Asset{Type: "credit_alphanum4", Code: "USD", Issuer: testIssuer}
+	native, e := encodeCoreAsset(hProtocol.Asset{Type: "native"})
+	if !assert.NoError(t, e) {
+		return
+	}
+	encodedUSD, e := encodeCoreAsset(usd)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	offer, e := makeCoreOffer(coreOfferRow{
+		sellerID:     testIssuer,
+		offerID:      42,
+		sellingAsset: native,
+		buyingAsset:  encodedUSD,
+		amount:       1234567890,
+		priceN:       1,
+		priceD:       3,
+		lastModified: 100,
+	})
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, int64(42), offer.ID)
+	assert.Equal(t, "42", offer.PT)
+	assert.Equal(t, testIssuer, offer.Seller)
+	assert.Equal(t, hProtocol.Asset{Type: "native"}, offer.Selling)
+	assert.Equal(t, usd, offer.Buying)
+	assert.Equal(t, "123.4567890", offer.Amount)
+	assert.Equal(t, "0.3333333", offer.Price)
+	assert.Equal(t, int32(1), offer.PriceR.N)
+	assert.Equal(t, int32(3), offer.PriceR.D)
+	assert.Equal(t, int32(100), offer.LastModifiedLedger)
+}
+
+func TestMakeCorePriceLevels(t *testing.T) {
+	rows := []corePriceLevelRow{
+		{priceN: 1, priceD: 2, amount: 100000000},
+		{priceN: 3, priceD: 4, amount: 25000000},
+	}
+
+	asks := makeCorePriceLevels(rows, false)
+	if assert.Equal(t, 2, len(asks)) {
+		assert.Equal(t, "0.5000000", asks[0].Price)
+		assert.Equal(t, "10.0000000", asks[0].Amount)
+		assert.Equal(t, int32(1), asks[0].PriceR.N)
+		assert.Equal(t, int32(2), asks[0].PriceR.D)
+	}
+
+	// bids are priced in units of the quote asset, which inverts the price of the offers
+	bids := makeCorePriceLevels(rows, true)
+	if assert.Equal(t, 2, len(bids)) {
+		assert.Equal(t, "2.0000000", bids[0].Price)
+		assert.Equal(t, "1.3333333", bids[1].Price)
+		assert.Equal(t, "2.5000000", bids[1].Amount)
+		assert.Equal(t, int32(4), bids[1].PriceR.N)
+		assert.Equal(t, int32(3), bids[1].PriceR.D)
+	}
+}
+
+func TestAddCorePriceLevel(t *testing.T) {
+	rows := []corePriceLevelRow{
+		{priceN: 1, priceD: 2, amount: 100},
+		// the same price as the first row written as a different fraction
+		{priceN: 2, priceD: 4, amount: 50},
+		{priceN: 3, priceD: 4, amount: 25},
+		{priceN: 6, priceD: 8, amount: 5},
+		{priceN: 1, priceD: 1, amount: 10},
+		{priceN: 2, priceD: 1, amount: 20},
+	}
+
+	testCases := []struct {
+		maxCount   int32
+		wantLevels []corePriceLevelRow
+	}{
+		{
+			maxCount: 1,
+			wantLevels: []corePriceLevelRow{
+				{priceN: 1, priceD: 2, amount: 150},
+			},
+		}, {
+			maxCount: 2,
+			wantLevels: []corePriceLevelRow{
+				{priceN: 1, priceD: 2, amount: 150},
+				{priceN: 3, priceD: 4, amount: 30},
+			},
+		}, {
+			maxCount: 10,
+			wantLevels: []corePriceLevelRow{
+				{priceN: 1, priceD: 2, amount: 150},
+				{priceN: 3, priceD: 4, amount: 30},
+				{priceN: 1, priceD: 1, amount: 10},
+				{priceN: 2, priceD: 1, amount: 20},
+			},
+		},
+	}
+
+	for _, kase := range testCases {
+		t.Run(fmt.Sprintf("%d", kase.maxCount), func(t *testing.T) {
+			levels := []corePriceLevelRow{}
+			for _, r := range rows {
+				var done bool
+				levels, done = addCorePriceLevel(levels, r, kase.maxCount)
+				if done {
+					break
+				}
+			}
+			assert.Equal(t, kase.wantLevels, levels)
+		})
+	}
+}
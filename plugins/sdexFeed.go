@@ -2,6 +2,7 @@ package plugins
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/stellar/go/build"
@@ -12,19 +13,38 @@ import (
 	"github.com/stellar/kelp/support/utils"
 )
 
+// sdexFeedWeightedPrefix marks the optional part of the sdex feed URL that enables the depth-weighted mid price
+const sdexFeedWeightedPrefix = "weighted:"
+
 // sdexFeed represents a pricefeed from the SDEX
 type sdexFeed struct {
 	sdex       *SDEX
 	assetBase  *hProtocol.Asset
 	assetQuote *hProtocol.Asset
+	// depth-weighted mid price over the top maxLevels levels, disabled when maxLevels is 0 in which case the top of the book is used
+	maxLevels int32
+	notional  float64 // in units of the quote asset
 }
 
-// ensure that it implements PriceFeed
-var _ api.PriceFeed = &sdexFeed{}
+// ensure that it implements PriceQuoteFeed
+var _ api.PriceQuoteFeed = &sdexFeed{}
 
-// makeSDEXFeed creates a price feed from buysell's url fields
+// makeSDEXFeed creates a price feed from buysell's url fields, the URL is "CODE:ISSUER/CODE:ISSUER[/weighted:<maxLevels>:<notional>]"
 func makeSDEXFeed(url string) (*sdexFeed, error) {
 	urlParts := strings.Split(url, "/")
+	if len(urlParts) != 2 && len(urlParts) != 3 {
+		return nil, fmt.Errorf("invalid format of sdex type URL, needs 2 or 3 parts after splitting URL by '/', has %d: %s", len(urlParts), url)
+	}
+
+	var maxLevels int32
+	var notional float64
+	if len(urlParts) == 3 {
+		var e error
+		maxLevels, notional, e = parseSDEXFeedWeighting(urlParts[2])
+		if e != nil {
+			return nil, fmt.Errorf("unable to parse depth weighting of sdex feed URL: %s", e)
+		}
+	}
 
 	baseAsset, e := parseHorizonAsset(urlParts[0])
 	if e != nil {
@@ -80,9 +100,32 @@ func makeSDEXFeed(url string) (*sdexFeed, error) {
 		sdex:       sdex,
 		assetBase:  baseAsset,
 		assetQuote: quoteAsset,
+		maxLevels:  maxLevels,
+		notional:   notional,
 	}, nil
 }
 
+// parseSDEXFeedWeighting parses the "weighted:<maxLevels>:<notional>" part of the sdex feed URL
+func parseSDEXFeedWeighting(weighting string) (int32, float64, error) {
+	if !strings.HasPrefix(weighting, sdexFeedWeightedPrefix) {
+		return 0, 0, fmt.Errorf("needs to be formatted as '%s<maxLevels>:<notional>': %s", sdexFeedWeightedPrefix, weighting)
+	}
+	parts := strings.Split(strings.TrimPrefix(weighting, sdexFeedWeightedPrefix), ":")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("needs to be formatted as '%s<maxLevels>:<notional>': %s", sdexFeedWeightedPrefix, weighting)
+	}
+
+	maxLevels, e := strconv.ParseInt(parts[0], 10, 32)
+	if e != nil || maxLevels <= 0 {
+		return 0, 0, fmt.Errorf("maxLevels needs to be a positive integer: %s", parts[0])
+	}
+	notional, e := strconv.ParseFloat(parts[1], 64)
+	if e != nil || notional <= 0 {
+		return 0, 0, fmt.Errorf("notional needs to be a positive number: %s", parts[1])
+	}
+	return int32(maxLevels), notional, nil
+}
+
 func parseHorizonAsset(assetString string) (*hProtocol.Asset, error) {
 	parts := strings.Split(assetString, ":")
 	code := parts[0]
@@ -98,14 +141,99 @@ func parseHorizonAsset(assetString string) (*hProtocol.Asset, error) {
 
 // GetPrice returns the SDEX mid price for the trading pair
 func (s *sdexFeed) GetPrice() (float64, error) {
+	q, e := s.GetPriceQuote()
+	if e != nil {
+		return 0, e
+	}
+	return q.Mid, nil
+}
+
+// GetPriceQuote returns the top of the SDEX orderbook for the trading pair, or the depth-weighted prices when configured
+func (s *sdexFeed) GetPriceQuote() (*api.PriceQuote, error) {
+	if s.maxLevels > 0 {
+		return s.getDepthWeightedPriceQuote()
+	}
+
 	orderBook, e := s.sdex.GetOrderBook(s.sdex.pair, 1)
 	if e != nil {
-		return 0, fmt.Errorf("unable to get sdex price: %s", e)
+		return nil, fmt.Errorf("unable to get sdex price: %s", e)
+	}
+	if len(orderBook.Bids()) == 0 || len(orderBook.Asks()) == 0 {
+		return nil, fmt.Errorf("unable to get sdex price because one side of the orderbook is empty (bids=%d, asks=%d)", len(orderBook.Bids()), len(orderBook.Asks()))
 	}
 
 	topBidPrice := orderBook.Bids()[0].Price
 	topAskPrice := orderBook.Asks()[0].Price
 
 	centerPrice := topBidPrice.Add(*topAskPrice).Scale(0.5).AsFloat()
-	return centerPrice, nil
+	bidPrice := topBidPrice.AsFloat()
+	askPrice := topAskPrice.AsFloat()
+	return &api.PriceQuote{
+		Bid:    &bidPrice,
+		Ask:    &askPrice,
+		Mid:    centerPrice,
+		Source: fmt.Sprintf("sdex orderbook (%s/%s)", utils.Asset2CodeString(*s.assetBase), utils.Asset2CodeString(*s.assetQuote)),
+	}, nil
+}
+
+// getDepthWeightedPriceQuote uses the average price of filling the notional on each side of the SDEX orderbook so dust orders at the top of a
+// thin orderbook do not move the price
+func (s *sdexFeed) getDepthWeightedPriceQuote() (*api.PriceQuote, error) {
+	orderBook, e := s.sdex.GetOrderBook(s.sdex.pair, s.maxLevels)
+	if e != nil {
+		return nil, fmt.Errorf("unable to get sdex price: %s", e)
+	}
+
+	bidPrice, e := depthWeightedPrice(orderBook.Bids(), s.notional)
+	if e != nil {
+		return nil, fmt.Errorf("unable to get depth-weighted sdex bid price: %s", e)
+	}
+	askPrice, e := depthWeightedPrice(orderBook.Asks(), s.notional)
+	if e != nil {
+		return nil, fmt.Errorf("unable to get depth-weighted sdex ask price: %s", e)
+	}
+
+	return &api.PriceQuote{
+		Bid: &bidPrice,
+		Ask: &askPrice,
+		Mid: (bidPrice + askPrice) / 2,
+		Source: fmt.Sprintf("sdex orderbook (%s/%s) weighted over %d levels for a notional of %f",
+			utils.Asset2CodeString(*s.assetBase),
+			utils.Asset2CodeString(*s.assetQuote),
+			s.maxLevels,
+			s.notional,
+		),
+	}, nil
+}
+
+// depthWeightedPrice is the average price of filling the notional, in units of the quote asset, against the levels which are ordered from the
+// top of the book. If the levels cannot fill the notional then it is the average price of all the levels.
+func depthWeightedPrice(levels []model.Order, notional float64) (float64, error) {
+	if len(levels) == 0 {
+		return 0, fmt.Errorf("the side of the orderbook is empty")
+	}
+
+	remainingQuote := notional
+	totalBase := 0.0
+	totalQuote := 0.0
+	for _, level := range levels {
+		if remainingQuote <= 0 {
+			break
+		}
+
+		price := level.Price.AsFloat()
+		levelQuote := level.Volume.AsFloat() * price
+		takenQuote := levelQuote
+		if takenQuote > remainingQuote {
+			takenQuote = remainingQuote
+		}
+		totalQuote += takenQuote
+		totalBase += takenQuote / price
+		remainingQuote -= takenQuote
+	}
+
+	if totalBase <= 0 {
+		return 0, fmt.Errorf("the side of the orderbook has no volume")
+	}
+	return totalQuote / totalBase, nil
 }
@@ -0,0 +1,74 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDepthWeightedPrice(t *testing.T) {
+	makeLevels := func(prices []float64, volumes []float64) []model.Order {
+		levels := []model.Order{}
+		for i, p := range prices {
+			levels = append(levels, model.Order{
+				OrderAction: model.OrderActionSell,
+				OrderType:   model.OrderTypeLimit,
+				Price:       model.NumberFromFloat(p, 7),
+				Volume:      model.NumberFromFloat(volumes[i], 7),
+			})
+		}
+		return levels
+	}
+
+	testCases := []struct {
+		name      string
+		levels    []model.Order
+		notional  float64
+		wantPrice float64
+	}{
+		{
+			name:      "filled by top level",
+			levels:    makeLevels([]float64{2.0, 4.0}, []float64{10, 10}),
+			notional:  10,
+			wantPrice: 2.0,
+		}, {
+			// 20 quote at 2.0 buys 10 base, 20 quote at 4.0 buys 5 base
+			name:      "dust at top level",
+			levels:    makeLevels([]float64{2.0, 4.0}, []float64{10, 10}),
+			notional:  40,
+			wantPrice: 40.0 / 15.0,
+		}, {
+			name:      "notional deeper than levels",
+			levels:    makeLevels([]float64{2.0, 4.0}, []float64{10, 10}),
+			notional:  1000,
+			wantPrice: 60.0 / 20.0,
+		},
+	}
+
+	for _, kase := range testCases {
+		t.Run(kase.name, func(t *testing.T) {
+			price, e := depthWeightedPrice(kase.levels, kase.notional)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.InDelta(t, kase.wantPrice, price, 0.0000001)
+		})
+	}
+
+	_, e := depthWeightedPrice([]model.Order{}, 10)
+	assert.Error(t, e)
+}
+
+func TestParseSDEXFeedWeighting(t *testing.T) {
+	maxLevels, notional, e := parseSDEXFeedWeighting("weighted:20:500.5")
+	if assert.NoError(t, e) {
+		assert.Equal(t, int32(20), maxLevels)
+		assert.Equal(t, 500.5, notional)
+	}
+
+	for _, invalid := range []string{"20:500", "weighted:20", "weighted:0:500", "weighted:20:-1", "weighted:a:500"} {
+		_, _, e := parseSDEXFeedWeighting(invalid)
+		assert.Error(t, e, invalid)
+	}
+}
@@ -0,0 +1,185 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// offerStreamRetryDelay is how long the offer stream waits before reconnecting to Horizon after it failed
+const offerStreamRetryDelay = 5 * time.Second
+
+// maxCachedOffersAge bounds how long the offers are served from the cache without changes, as a safety net for changes missed by the streams
+const maxCachedOffersAge = time.Minute
+
+// ensure that SDEX implements TradeStream so the FillTracker detects fills as soon as Horizon streams them
+var _ api.TradeStream = &SDEX{}
+
+// EnableStreaming makes this SDEX instance use the streaming (SSE) endpoints of Horizon for the trades and the offers of the trading account.
+// Trades are streamed to the FillTracker, which keeps polling in case the stream misses trades. The offers are polled once and served from a
+// cache until a streamed change of the offers, a streamed trade or a submitted transaction invalidates it, and are polled on every load
+// while the offer stream is disconnected.
+func (sdex *SDEX) EnableStreaming() {
+	sdex.offerCache = makeOfferCache(maxCachedOffersAge, time.Now)
+	go sdex.streamOffers()
+	log.Printf("streaming trades and offers of account %s from Horizon\n", sdex.TradingAccount)
+}
+
+// streamOffers runs forever, reconnecting the offer stream whenever it fails
+func (sdex *SDEX) streamOffers() {
+	for {
+		sdex.offerCache.setConnected(true)
+		e := sdex.API.StreamOffers(context.Background(), horizonclient.OfferRequest{ForAccount: sdex.TradingAccount}, func(offer hProtocol.Offer) {
+			sdex.offerCache.invalidate()
+		})
+		sdex.offerCache.setConnected(false)
+		log.Printf("offer stream ended, polling offers until it reconnects in %s: %s\n", offerStreamRetryDelay, e)
+		time.Sleep(offerStreamRetryDelay)
+	}
+}
+
+// StreamTrades impl, it streams the trades of the trading account from Horizon
+func (sdex *SDEX) StreamTrades(pair *model.TradingPair, handler func(trade model.Trade)) error {
+	if sdex.offerCache == nil {
+		return api.ErrTradeStreamUnsupported
+	}
+	if *pair != *sdex.pair {
+		return fmt.Errorf("passed in pair (%s) did not match sdex.pair (%s)", pair.String(), sdex.pair.String())
+	}
+
+	baseAsset, quoteAsset, e := sdex.Assets()
+	if e != nil {
+		return fmt.Errorf("error while converting pair to base and quote asset: %s", e)
+	}
+
+	var handlerErr error
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	e = sdex.API.StreamTrades(ctx, horizonclient.TradeRequest{ForAccount: sdex.TradingAccount}, func(t hProtocol.Trade) {
+		// any trade of the account changes its offers, including trades on other pairs that use the same assets
+		sdex.offerCache.invalidate()
+
+		trade, e := accountTrade2Trade(sdex.TradingAccount, sdex.pair, baseAsset, quoteAsset, t)
+		if e != nil {
+			handlerErr = fmt.Errorf("could not convert streamed trade (ID=%s): %s", t.ID, e)
+			cancel()
+			return
+		}
+		if trade == nil {
+			return
+		}
+		handler(*trade)
+	})
+	if handlerErr != nil {
+		return handlerErr
+	}
+	if e != nil {
+		return fmt.Errorf("error while streaming trades of account %s: %s", sdex.TradingAccount, e)
+	}
+	return fmt.Errorf("trade stream of account %s was closed", sdex.TradingAccount)
+}
+
+// loadOffers returns the cached offers when the streams guarantee that they have not changed, otherwise it polls them
+func (sdex *SDEX) loadOffers() ([]hProtocol.Offer, error) {
+	if sdex.offerCache == nil {
+		return sdex.pollOffers()
+	}
+
+	if offers, ok := sdex.offerCache.get(); ok {
+		return offers, nil
+	}
+	// the offers are only cached if the stream was connected for the whole duration of the poll, otherwise a change could have been missed
+	version := sdex.offerCache.version()
+	offers, e := sdex.pollOffers()
+	if e != nil {
+		return nil, e
+	}
+	sdex.offerCache.set(offers, version)
+	return offers, nil
+}
+
+// pollOffers loads the offers of the trading account from the database of stellar-core when it is set, otherwise from Horizon
+func (sdex *SDEX) pollOffers() ([]hProtocol.Offer, error) {
+	if sdex.coreDB != nil {
+		return sdex.loadOffersFromCore(sdex.TradingAccount)
+	}
+	return utils.LoadAllOffers(sdex.TradingAccount, sdex.API)
+}
+
+// offerCache holds the last polled offers of the account while nothing has changed them
+type offerCache struct {
+	maxAge time.Duration
+	now    func() time.Time
+
+	// uninitialized
+	mutex     *sync.Mutex
+	offers    []hProtocol.Offer
+	updatedAt time.Time
+	valid     bool
+	connected bool
+	changes   uint64 // incremented by every invalidation so a poll that raced with a change is not cached
+}
+
+func makeOfferCache(maxAge time.Duration, now func() time.Time) *offerCache {
+	return &offerCache{
+		maxAge: maxAge,
+		now:    now,
+		mutex:  &sync.Mutex{},
+	}
+}
+
+// get returns the cached offers if they are still valid
+func (c *offerCache) get() ([]hProtocol.Offer, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if !c.valid || !c.connected || c.now().Sub(c.updatedAt) > c.maxAge {
+		return nil, false
+	}
+	return append([]hProtocol.Offer{}, c.offers...), true
+}
+
+// version returns the number of changes seen so far, a poll that starts at this version can be cached only if no change happens meanwhile
+func (c *offerCache) version() uint64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.changes
+}
+
+// set caches the polled offers unless they were invalidated or the stream disconnected since the version was taken
+func (c *offerCache) set(offers []hProtocol.Offer, version uint64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if !c.connected || c.changes != version {
+		return
+	}
+	c.offers = append([]hProtocol.Offer{}, offers...)
+	c.updatedAt = c.now()
+	c.valid = true
+}
+
+// invalidate drops the cached offers because they may have changed
+func (c *offerCache) invalidate() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.valid = false
+	c.changes++
+}
+
+// setConnected records whether the offer stream is connected, offers are never served from the cache while it is not
+func (c *offerCache) setConnected(connected bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.connected != connected {
+		c.connected = connected
+		c.valid = false
+		c.changes++
+	}
+}
@@ -0,0 +1,54 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOfferCache(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	c := makeOfferCache(time.Minute, func() time.Time { return now })
+	offers := []hProtocol.Offer{{ID: 1}, {ID: 2}}
+
+	// offers are not cached while the stream is disconnected
+	c.set(offers, c.version())
+	_, ok := c.get()
+	assert.False(t, ok)
+
+	c.setConnected(true)
+	c.set(offers, c.version())
+	cached, ok := c.get()
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.Equal(t, offers, cached)
+
+	// a change invalidates the cache
+	c.invalidate()
+	_, ok = c.get()
+	assert.False(t, ok)
+
+	// a poll that raced with a change is not cached
+	version := c.version()
+	c.invalidate()
+	c.set(offers, version)
+	_, ok = c.get()
+	assert.False(t, ok)
+
+	// cached offers expire after the max age
+	c.set(offers, c.version())
+	_, ok = c.get()
+	assert.True(t, ok)
+	now = now.Add(time.Minute + time.Second)
+	_, ok = c.get()
+	assert.False(t, ok)
+
+	// a disconnect invalidates the cache
+	c.set(offers, c.version())
+	c.setConnected(false)
+	_, ok = c.get()
+	assert.False(t, ok)
+}
@@ -0,0 +1,157 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/kelp/support/networking"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// accountSequence hands out the sequence numbers of a source account
+type accountSequence struct {
+	accountID string
+	mutex     *sync.Mutex
+	seqNum    uint64
+	reload    bool
+}
+
+// accountSequences is shared by all the SDEX instances in the process so instances for different trading accounts can use the same source
+// account without handing out the same sequence number twice
+var accountSequences = map[string]*accountSequence{}
+var accountSequencesMutex = &sync.Mutex{}
+
+// getAccountSequence returns the accountSequence of the account, creating it the first time it is requested
+func getAccountSequence(accountID string) *accountSequence {
+	accountSequencesMutex.Lock()
+	defer accountSequencesMutex.Unlock()
+
+	s, ok := accountSequences[accountID]
+	if !ok {
+		s = &accountSequence{
+			accountID: accountID,
+			mutex:     &sync.Mutex{},
+			reload:    true,
+		}
+		accountSequences[accountID] = s
+	}
+	return s
+}
+
+// next returns the sequence number to use for the next transaction of the account, using loadFn to load the current sequence number of the
+// account the first time and after it was invalidated
+func (s *accountSequence) next(loadFn func(accountID string) (uint64, error)) (uint64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.reload {
+		seqNum, e := loadFn(s.accountID)
+		if e != nil {
+			return 0, fmt.Errorf("unable to load sequence number of account %s: %s", s.accountID, e)
+		}
+		s.seqNum = seqNum
+		s.reload = false
+	}
+	s.seqNum++
+	return s.seqNum, nil
+}
+
+// invalidate reloads the sequence number before the next transaction, used when a transaction was rejected without consuming its sequence number
+func (s *accountSequence) invalidate() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.reload = true
+}
+
+// txSource is an account that pays the fee and provides the sequence number of the transactions submitted by the SDEX
+type txSource struct {
+	address  string
+	seed     string
+	sequence *accountSequence
+}
+
+func makeTxSource(address string, seed string) *txSource {
+	return &txSource{
+		address:  address,
+		seed:     seed,
+		sequence: getAccountSequence(address),
+	}
+}
+
+// SetAdditionalSourceSeeds adds source accounts that take turns with the source account in paying the fees and providing the sequence
+// numbers of transactions, so transactions submitted in quick succession do not all wait on the sequence number of a single account
+func (sdex *SDEX) SetAdditionalSourceSeeds(seeds []string) error {
+	sdex.sourceMutex.Lock()
+	defer sdex.sourceMutex.Unlock()
+
+	for _, seed := range seeds {
+		address, e := utils.ParseSecret(seed)
+		if e != nil {
+			return fmt.Errorf("invalid additional source secret seed: %s", e)
+		}
+		if address == nil {
+			return fmt.Errorf("additional source secret seeds cannot be empty")
+		}
+		for _, s := range sdex.txSources {
+			if s.address == *address {
+				return fmt.Errorf("source account %s is used more than once", *address)
+			}
+		}
+		sdex.txSources = append(sdex.txSources, makeTxSource(*address, seed))
+	}
+	log.Printf("using %d source accounts for transactions\n", len(sdex.txSources))
+	return nil
+}
+
+// nextTxSource returns the source account for the next transaction, rotating through the source accounts
+func (sdex *SDEX) nextTxSource() *txSource {
+	sdex.sourceMutex.Lock()
+	defer sdex.sourceMutex.Unlock()
+
+	s := sdex.txSources[sdex.nextTxSourceIndex]
+	sdex.nextTxSourceIndex = (sdex.nextTxSourceIndex + 1) % len(sdex.txSources)
+	return s
+}
+
+// opsNeedTradingSource is true when the ops need to set the trading account as their source because the transactions are not always
+// sourced from the trading account
+func (sdex *SDEX) opsNeedTradingSource() bool {
+	sdex.sourceMutex.Lock()
+	defer sdex.sourceMutex.Unlock()
+	return len(sdex.txSources) > 1 || sdex.SourceAccount != sdex.TradingAccount
+}
+
+// tradingAccountPaysFees is true when the trading account is one of the source accounts
+func (sdex *SDEX) tradingAccountPaysFees() bool {
+	sdex.sourceMutex.Lock()
+	defer sdex.sourceMutex.Unlock()
+	for _, s := range sdex.txSources {
+		if s.address == sdex.TradingAccount {
+			return true
+		}
+	}
+	return false
+}
+
+// loadSequenceNumber loads the current sequence number of the account from horizon
+func (sdex *SDEX) loadSequenceNumber(accountID string) (uint64, error) {
+	client := sdex.API
+	// a cached sequence number would be rejected with tx_bad_seq so the account details are always loaded from horizon
+	if cachingHTTP, ok := client.HTTP.(*networking.CachingHTTPClient); ok {
+		uncachedClient := *client
+		uncachedClient.HTTP = cachingHTTP.Uncached()
+		client = &uncachedClient
+	}
+
+	accountDetail, e := client.AccountDetail(horizonclient.AccountRequest{AccountID: accountID})
+	if e != nil {
+		return 0, fmt.Errorf("error loading account detail: %s", e)
+	}
+	seqNum, e := accountDetail.GetSequenceNumber()
+	if e != nil {
+		return 0, fmt.Errorf("error getting seq num: %s", e)
+	}
+	return uint64(seqNum), nil
+}
@@ -0,0 +1,80 @@
+package plugins
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountSequence(t *testing.T) {
+	loads := 0
+	loadFn := func(accountID string) (uint64, error) {
+		loads++
+		return 100, nil
+	}
+	s := &accountSequence{accountID: "GSOURCE", mutex: &sync.Mutex{}, reload: true}
+
+	for _, want := range []uint64{101, 102, 103} {
+		seqNum, e := s.next(loadFn)
+		if !assert.NoError(t, e) {
+			return
+		}
+		assert.Equal(t, want, seqNum)
+	}
+	assert.Equal(t, 1, loads)
+
+	s.invalidate()
+	seqNum, e := s.next(loadFn)
+	if assert.NoError(t, e) {
+		assert.Equal(t, uint64(101), seqNum)
+	}
+	assert.Equal(t, 2, loads)
+
+	s.invalidate()
+	_, e = s.next(func(accountID string) (uint64, error) {
+		return 0, fmt.Errorf("horizon is down")
+	})
+	assert.Error(t, e)
+}
+
+func TestAdditionalSourceSeeds(t *testing.T) {
+	kps := []*keypair.Full{}
+	for i := 0; i < 3; i++ {
+		kp, e := keypair.Random()
+		if !assert.NoError(t, e) {
+			return
+		}
+		kps = append(kps, kp)
+	}
+	trading, extra1, extra2 := kps[0], kps[1], kps[2]
+	sdex := &SDEX{
+		SourceAccount:  trading.Address(),
+		SourceSeed:     trading.Seed(),
+		TradingAccount: trading.Address(),
+		TradingSeed:    trading.Seed(),
+		txSources:      []*txSource{makeTxSource(trading.Address(), trading.Seed())},
+		sourceMutex:    &sync.Mutex{},
+	}
+	assert.False(t, sdex.opsNeedTradingSource())
+
+	e := sdex.SetAdditionalSourceSeeds([]string{extra1.Seed(), extra2.Seed()})
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.True(t, sdex.opsNeedTradingSource())
+	assert.True(t, sdex.tradingAccountPaysFees())
+
+	rotation := []string{}
+	for i := 0; i < 4; i++ {
+		rotation = append(rotation, sdex.nextTxSource().address)
+	}
+	assert.Equal(t, []string{trading.Address(), extra1.Address(), extra2.Address(), trading.Address()}, rotation)
+
+	// the same account cannot be used twice
+	assert.Error(t, sdex.SetAdditionalSourceSeeds([]string{extra1.Seed()}))
+	// different instances share the sequence numbers of the same account
+	assert.Equal(t, sdex.txSources[1].sequence, makeTxSource(extra1.Address(), extra1.Seed()).sequence)
+}
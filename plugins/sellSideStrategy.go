@@ -28,7 +28,8 @@ type sellSideStrategy struct {
 	action              string
 
 	// uninitialized
-	currentLevels []api.Level // levels for current iteration
+	annotator     api.Annotator // nil when annotations are not collected
+	currentLevels []api.Level   // levels for current iteration
 	maxAssetBase  float64
 	maxAssetQuote float64
 }
@@ -36,6 +37,12 @@ type sellSideStrategy struct {
 // ensure it implements SideStrategy
 var _ api.SideStrategy = &sellSideStrategy{}
 
+// ensure it implements Annotatable
+var _ api.Annotatable = &sellSideStrategy{}
+
+// ensure it implements StateSnapshotter
+var _ api.StateSnapshotter = &sellSideStrategy{}
+
 // makeSellSideStrategy is a factory method for sellSideStrategy
 func makeSellSideStrategy(
 	sdex *SDEX,
@@ -66,6 +73,20 @@ func makeSellSideStrategy(
 	}
 }
 
+// SetAnnotator impl
+func (s *sellSideStrategy) SetAnnotator(annotator api.Annotator) {
+	s.annotator = annotator
+}
+
+// Snapshot impl, the levels are only loaded in PreUpdate so UpdateWithOps only emits annotations
+func (s *sellSideStrategy) Snapshot() func() {
+	annotator := s.annotator
+	s.annotator = nil
+	return func() {
+		s.annotator = annotator
+	}
+}
+
 // PruneExistingOffers impl
 func (s *sellSideStrategy) PruneExistingOffers(offers []hProtocol.Offer) ([]build.TransactionMutator, []hProtocol.Offer) {
 	// figure out which offers we want to prune
@@ -141,6 +162,11 @@ func (s *sellSideStrategy) PreUpdate(maxAssetBase float64, maxAssetQuote float64
 	if nothingToSell || lineFull {
 		s.currentLevels = []api.Level{}
 		log.Printf("no capacity to place sell orders (nothingToSell = %v, lineFull = %v)\n", nothingToSell, lineFull)
+		reason := "no balance of the asset to sell"
+		if lineFull {
+			reason = "trustline of the asset to receive is full"
+		}
+		annotate(s.annotator, api.AnnotationLevelWarning, s.action, fmt.Sprintf("not placing any %s offers: %s", s.action, reason))
 		return nil
 	}
 
@@ -230,7 +256,7 @@ func (s *sellSideStrategy) createPrecedingOffers(
 		}
 
 		var offerPrice *model.Number
-		var op *build.ManageOfferBuilder
+		var op build.TransactionMutator
 		offerPrice, hitCapacityLimit, op, e = s.createSellLevel(i, *targetPrice, *targetAmount)
 		if e != nil {
 			return 0, false, nil, nil, fmt.Errorf("unable to create new preceding offer: %s", e)
@@ -291,7 +317,7 @@ func (s *sellSideStrategy) UpdateWithOps(offers []hProtocol.Offer) (ops []build.
 		}
 
 		var offerPrice *model.Number
-		var op *build.ManageOfferBuilder
+		var op build.TransactionMutator
 		if isModify {
 			offerPrice, hitCapacityLimit, op, e = s.modifySellLevel(offers, i, *targetPrice, *targetAmount)
 		} else {
@@ -317,6 +343,10 @@ func (s *sellSideStrategy) UpdateWithOps(offers []hProtocol.Offer) (ops []build.
 		}
 	}
 
+	if hitCapacityLimit {
+		annotate(s.annotator, api.AnnotationLevelWarning, s.action, fmt.Sprintf("not all %d %s levels could be placed: insufficient balance or trustline capacity", len(s.currentLevels), s.action))
+	}
+
 	// prepend deleteOps because we want to delete offers first so we "free" up our liabilities capacity to place the new/modified offers
 	ops = append(deleteOps, ops...)
 
@@ -365,8 +395,25 @@ func (s *sellSideStrategy) computeRemainderAmount(incrementalSellAmount float64,
 		availableSellingCapacity.Selling, availableBuyingCapacity.Buying, price)
 }
 
+// buyOfferTargets converts the price and amount of an offer on the buy side, which are inverted and in units of the quote asset, into the
+// price and amount of a native buy offer of the base asset. Rounding to the order constraints recovers the exact amount of the base asset that
+// the level asked for.
+func (s *sellSideStrategy) buyOfferTargets(price float64, amount float64) (float64, float64) {
+	buyPrice := model.NumberFromFloat(1/price, s.orderConstraints.PricePrecision)
+	buyAmount := model.NumberFromFloat(amount*price, s.orderConstraints.VolumePrecision)
+	return buyPrice.AsFloat(), buyAmount.AsFloat()
+}
+
+// sellOfferMutator converts the result of placing a sell offer to a TransactionMutator, keeping the op as a nil interface when we hit the limits
+func sellOfferMutator(op *build.ManageOfferBuilder, e error) (build.TransactionMutator, error) {
+	if e != nil || op == nil {
+		return nil, e
+	}
+	return op, nil
+}
+
 // createSellLevel returns offerPrice, hitCapacityLimit, op, error.
-func (s *sellSideStrategy) createSellLevel(index int, targetPrice model.Number, targetAmount model.Number) (*model.Number, bool, *build.ManageOfferBuilder, error) {
+func (s *sellSideStrategy) createSellLevel(index int, targetPrice model.Number, targetAmount model.Number) (*model.Number, bool, build.TransactionMutator, error) {
 	incrementalNativeAmountRaw := s.sdex.ComputeIncrementalNativeAmountRaw(true)
 	targetPrice = *model.NumberByCappingPrecision(&targetPrice, s.orderConstraints.PricePrecision)
 	targetAmount = *model.NumberByCappingPrecision(&targetAmount, s.orderConstraints.VolumePrecision)
@@ -375,15 +422,22 @@ func (s *sellSideStrategy) createSellLevel(index int, targetPrice model.Number,
 		targetPrice.AsFloat(),
 		targetAmount.AsFloat(),
 		incrementalNativeAmountRaw,
-		func(price float64, amount float64, incrementalNativeAmountRaw float64) (*build.ManageOfferBuilder, error) {
+		func(price float64, amount float64, incrementalNativeAmountRaw float64) (build.TransactionMutator, error) {
 			priceLogged := price
 			amountLogged := amount
 			if s.divideAmountByPrice {
-				priceLogged = 1 / price
-				amountLogged = amount * price
+				priceLogged, amountLogged = s.buyOfferTargets(price, amount)
 			}
 			log.Printf("%s | create | level=%d | priceQuote=%.8f | amtBase=%.8f\n", s.action, index+1, priceLogged, amountLogged)
-			return s.sdex.CreateSellOffer(*s.assetBase, *s.assetQuote, price, amount, incrementalNativeAmountRaw)
+			if s.currentLevels[index].Passive {
+				// Stellar does not have a passive ManageBuyOffer op so passive levels on the buy side remain inverted passive sell offers
+				return sellOfferMutator(s.sdex.CreatePassiveSellOffer(*s.assetBase, *s.assetQuote, price, amount, incrementalNativeAmountRaw))
+			}
+			if s.divideAmountByPrice {
+				// the assets are switched for the buy side so assetQuote is the bot's base asset
+				return s.sdex.CreateBuyOffer(*s.assetQuote, *s.assetBase, priceLogged, amountLogged, incrementalNativeAmountRaw)
+			}
+			return sellOfferMutator(s.sdex.CreateSellOffer(*s.assetBase, *s.assetQuote, price, amount, incrementalNativeAmountRaw))
 		},
 		*s.assetBase,
 		*s.assetQuote,
@@ -392,7 +446,7 @@ func (s *sellSideStrategy) createSellLevel(index int, targetPrice model.Number,
 }
 
 // modifySellLevel returns offerPrice, hitCapacityLimit, op, error.
-func (s *sellSideStrategy) modifySellLevel(offers []hProtocol.Offer, index int, targetPrice model.Number, targetAmount model.Number) (*model.Number, bool, *build.ManageOfferBuilder, error) {
+func (s *sellSideStrategy) modifySellLevel(offers []hProtocol.Offer, index int, targetPrice model.Number, targetAmount model.Number) (*model.Number, bool, build.TransactionMutator, error) {
 	highestPrice := targetPrice.AsFloat() + targetPrice.AsFloat()*s.priceTolerance
 	lowestPrice := targetPrice.AsFloat() - targetPrice.AsFloat()*s.priceTolerance
 	minAmount := targetAmount.AsFloat() - targetAmount.AsFloat()*s.amountTolerance
@@ -419,7 +473,7 @@ func (s *sellSideStrategy) modifySellLevel(offers []hProtocol.Offer, index int,
 		targetPrice.AsFloat(),
 		targetAmount.AsFloat(),
 		incrementalNativeAmountRaw,
-		func(price float64, amount float64, incrementalNativeAmountRaw float64) (*build.ManageOfferBuilder, error) {
+		func(price float64, amount float64, incrementalNativeAmountRaw float64) (build.TransactionMutator, error) {
 			priceLogged := price
 			amountLogged := amount
 			curPriceLogged := curPrice
@@ -429,8 +483,7 @@ func (s *sellSideStrategy) modifySellLevel(offers []hProtocol.Offer, index int,
 			minAmountLogged := minAmount
 			maxAmountLogged := maxAmount
 			if s.divideAmountByPrice {
-				priceLogged = 1 / price
-				amountLogged = amount * price
+				priceLogged, amountLogged = s.buyOfferTargets(price, amount)
 				curPriceLogged = 1 / curPrice
 				curAmountLogged = curAmount * curPrice
 				minAmountLogged = minAmount * curPrice
@@ -441,7 +494,10 @@ func (s *sellSideStrategy) modifySellLevel(offers []hProtocol.Offer, index int,
 			}
 			log.Printf("%s | modify | level=%d | targetPriceQuote=%.8f | targetAmtBase=%.8f | curPriceQuote=%.8f | lowPriceQuote=%.8f | highPriceQuote=%.8f | curAmtBase=%.8f | minAmtBase=%.8f | maxAmtBase=%.8f\n",
 				s.action, index+1, priceLogged, amountLogged, curPriceLogged, lowestPriceLogged, highestPriceLogged, curAmountLogged, minAmountLogged, maxAmountLogged)
-			return s.sdex.ModifySellOffer(offers[index], price, amount, incrementalNativeAmountRaw)
+			if s.divideAmountByPrice {
+				return s.sdex.ModifyBuyOffer(offers[index], priceLogged, amountLogged, incrementalNativeAmountRaw)
+			}
+			return sellOfferMutator(s.sdex.ModifySellOffer(offers[index], price, amount, incrementalNativeAmountRaw))
 		},
 		offers[index].Selling,
 		offers[index].Buying,
@@ -454,10 +510,10 @@ func (s *sellSideStrategy) placeOrderWithRetry(
 	targetPrice float64,
 	targetAmount float64,
 	incrementalNativeAmountRaw float64,
-	placeOffer func(price float64, amount float64, incrementalNativeAmountRaw float64) (*build.ManageOfferBuilder, error),
+	placeOffer func(price float64, amount float64, incrementalNativeAmountRaw float64) (build.TransactionMutator, error),
 	assetBase hProtocol.Asset,
 	assetQuote hProtocol.Asset,
-) (bool, *build.ManageOfferBuilder, error) {
+) (bool, build.TransactionMutator, error) {
 	op, e := placeOffer(targetPrice, targetAmount, incrementalNativeAmountRaw)
 	if e != nil {
 		return false, nil, e
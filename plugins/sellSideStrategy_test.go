@@ -110,3 +110,39 @@ func TestComputeOffersToPrune(t *testing.T) {
 		})
 	}
 }
+
+func TestBuyOfferTargets(t *testing.T) {
+	testCases := []struct {
+		invertedPrice float64
+		invertedAmt   float64
+		wantPrice     float64
+		wantAmount    float64
+	}{
+		{
+			invertedPrice: 0.5,
+			invertedAmt:   20.0,
+			wantPrice:     2.0,
+			wantAmount:    10.0,
+		}, {
+			// an amount of 10 of the base asset at a price of 3 is stored as an inverted amount that cannot be represented exactly
+			invertedPrice: 1.0 / 3,
+			invertedAmt:   30.0000001,
+			wantPrice:     3.0,
+			wantAmount:    10.0,
+		}, {
+			invertedPrice: 1.0 / 0.1234567,
+			invertedAmt:   1.234567,
+			wantPrice:     0.1234567,
+			wantAmount:    10.0,
+		},
+	}
+
+	for _, kase := range testCases {
+		t.Run(fmt.Sprintf("%f_%f", kase.invertedPrice, kase.invertedAmt), func(t *testing.T) {
+			s := &sellSideStrategy{orderConstraints: model.MakeOrderConstraints(7, 7, 0.1)}
+			price, amount := s.buyOfferTargets(kase.invertedPrice, kase.invertedAmt)
+			assert.Equal(t, kase.wantPrice, price)
+			assert.Equal(t, kase.wantAmount, amount)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package plugins
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/stellar/kelp/model"
 
@@ -18,11 +19,20 @@ type sellConfig struct {
 	DataFeedBURL           string        `valid:"-" toml:"DATA_FEED_B_URL"`
 	PriceTolerance         float64       `valid:"-" toml:"PRICE_TOLERANCE"`
 	AmountTolerance        float64       `valid:"-" toml:"AMOUNT_TOLERANCE"`
-	AmountOfABase          float64       `valid:"-" toml:"AMOUNT_OF_A_BASE"` // the size of order
+	AmountOfABase          float64       `valid:"-" toml:"AMOUNT_OF_A_BASE"`  // the size of order
+	AmountOfAQuote         float64       `valid:"-" toml:"AMOUNT_OF_A_QUOTE"` // the size of order in units of the quote asset, used instead of AMOUNT_OF_A_BASE
 	RateOffsetPercent      float64       `valid:"-" toml:"RATE_OFFSET_PERCENT"`
 	RateOffset             float64       `valid:"-" toml:"RATE_OFFSET"`
 	RateOffsetPercentFirst bool          `valid:"-" toml:"RATE_OFFSET_PERCENT_FIRST"`
 	Levels                 []StaticLevel `valid:"-" toml:"LEVELS"`
+	// optionally scale the spread of all levels by the volatility of the center price over this window, 0 disables it
+	VolatilityWindowSeconds    uint32  `valid:"-" toml:"VOLATILITY_WINDOW_SECONDS"`
+	VolatilitySpreadMultiplier float64 `valid:"-" toml:"VOLATILITY_SPREAD_MULTIPLIER"`
+	VolatilityMaxSpreadFactor  float64 `valid:"-" toml:"VOLATILITY_MAX_SPREAD_FACTOR"`
+	PriceFeedCacheConfig       `valid:"-"`
+	// optionally post only a visible fraction of each level and release the hidden remainder in slices as it fills, 0 disables it
+	IcebergVisibleFraction      float64 `valid:"-" toml:"ICEBERG_VISIBLE_FRACTION"`
+	IcebergSliceIntervalSeconds uint32  `valid:"-" toml:"ICEBERG_SLICE_INTERVAL_SECONDS"`
 }
 
 // String impl.
@@ -39,15 +49,18 @@ func makeSellStrategy(
 	assetQuote *hProtocol.Asset,
 	config *sellConfig,
 ) (api.Strategy, error) {
-	pf, e := MakeFeedPair(
-		config.DataTypeA,
-		config.DataFeedAURL,
-		config.DataTypeB,
-		config.DataFeedBURL,
-	)
+	e := validateAmountUnits(config.AmountOfABase, config.AmountOfAQuote)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the sell strategy because of an invalid amount config: %s", e)
+	}
+	pf, e := config.makeFeedPair(config.DataTypeA, config.DataFeedAURL, config.DataTypeB, config.DataFeedBURL)
 	if e != nil {
 		return nil, fmt.Errorf("cannot make the sell strategy because we could not make the feed pair: %s", e)
 	}
+	volatility, e := makeVolatilitySpread(pf, config.VolatilityWindowSeconds, config.VolatilitySpreadMultiplier, config.VolatilityMaxSpreadFactor)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the sell strategy because of an invalid volatility config: %s", e)
+	}
 
 	orderConstraints := sdex.GetOrderConstraints(pair)
 	offset := rateOffset{
@@ -55,13 +68,22 @@ func makeSellStrategy(
 		absolute:     config.RateOffset,
 		percentFirst: config.RateOffsetPercentFirst,
 	}
+	levelProvider := makeStaticSpreadLevelProvider(config.Levels, config.AmountOfABase, config.AmountOfAQuote, offset, pf, orderConstraints, volatility)
+	if config.IcebergVisibleFraction != 0 {
+		levelProvider, e = makeIcebergLevelProvider(levelProvider, config.IcebergVisibleFraction, time.Duration(config.IcebergSliceIntervalSeconds)*time.Second, orderConstraints)
+		if e != nil {
+			return nil, fmt.Errorf("cannot make the sell strategy because of an invalid ICEBERG_VISIBLE_FRACTION: %s", e)
+		}
+	} else if config.IcebergSliceIntervalSeconds != 0 {
+		return nil, fmt.Errorf("cannot make the sell strategy because ICEBERG_SLICE_INTERVAL_SECONDS can only be set together with ICEBERG_VISIBLE_FRACTION")
+	}
 	sellSideStrategy := makeSellSideStrategy(
 		sdex,
 		orderConstraints,
 		ieif,
 		assetBase,
 		assetQuote,
-		makeStaticSpreadLevelProvider(config.Levels, config.AmountOfABase, offset, pf, orderConstraints),
+		levelProvider,
 		config.PriceTolerance,
 		config.AmountTolerance,
 		false,
@@ -1,6 +1,7 @@
 package plugins
 
 import (
+	"fmt"
 	"log"
 
 	"github.com/stellar/kelp/api"
@@ -10,8 +11,9 @@ import (
 // StaticLevel represents a layer in the orderbook defined statically
 // extracted here because it's shared by strategy and sideStrategy where strategy depeneds on sideStrategy
 type StaticLevel struct {
-	SPREAD float64 `valid:"-" json:"spread"`
-	AMOUNT float64 `valid:"-" json:"amount"`
+	SPREAD  float64 `valid:"-" json:"spread"`
+	AMOUNT  float64 `valid:"-" json:"amount"`
+	PASSIVE bool    `valid:"-" json:"passive"`
 }
 
 // how much to offset your rates by. Can use percent and offset together.
@@ -36,28 +38,54 @@ type rateOffset struct {
 type staticSpreadLevelProvider struct {
 	staticLevels     []StaticLevel
 	amountOfBase     float64
+	amountOfQuote    float64 // when set the amount of each level is specified in units of the quote asset instead of amountOfBase
 	offset           rateOffset
 	pf               *api.FeedPair
 	orderConstraints *model.OrderConstraints
+	volatility       *volatilitySpread // nil if spreads are not scaled by volatility
 }
 
 // ensure it implements the LevelProvider interface
 var _ api.LevelProvider = &staticSpreadLevelProvider{}
 
+// validateAmountUnits checks that the level amounts are specified in either units of the base asset or units of the quote asset
+func validateAmountUnits(amountOfBase float64, amountOfQuote float64) error {
+	if amountOfQuote < 0 {
+		return fmt.Errorf("AMOUNT_OF_A_QUOTE cannot be negative: %f", amountOfQuote)
+	}
+	if amountOfQuote > 0 && amountOfBase != 0 {
+		return fmt.Errorf("only one of AMOUNT_OF_A_BASE and AMOUNT_OF_A_QUOTE can be set")
+	}
+	return nil
+}
+
 // makeStaticSpreadLevelProvider is a factory method
-func makeStaticSpreadLevelProvider(staticLevels []StaticLevel, amountOfBase float64, offset rateOffset, pf *api.FeedPair, orderConstraints *model.OrderConstraints) api.LevelProvider {
+func makeStaticSpreadLevelProvider(staticLevels []StaticLevel, amountOfBase float64, amountOfQuote float64, offset rateOffset, pf *api.FeedPair, orderConstraints *model.OrderConstraints, volatility *volatilitySpread) api.LevelProvider {
 	return &staticSpreadLevelProvider{
 		staticLevels:     staticLevels,
 		amountOfBase:     amountOfBase,
+		amountOfQuote:    amountOfQuote,
 		offset:           offset,
 		pf:               pf,
 		orderConstraints: orderConstraints,
+		volatility:       volatility,
 	}
 }
 
 // GetLevels impl.
 func (p *staticSpreadLevelProvider) GetLevels(maxAssetBase float64, maxAssetQuote float64) ([]api.Level, error) {
-	centerPrice, e := p.pf.GetCenterPrice()
+	var centerPrice float64
+	var e error
+	spreadFactor := 1.0
+	if p.volatility != nil {
+		// fetch the center price through the volatility feed so it is recorded as a sample
+		centerPrice, e = p.volatility.feed.GetPrice()
+		if e == nil {
+			spreadFactor = p.volatility.factor()
+		}
+	} else {
+		centerPrice, e = p.pf.GetCenterPrice()
+	}
 	if e != nil {
 		log.Printf("error: center price couldn't be loaded! | %s\n", e)
 		return nil, e
@@ -81,16 +109,32 @@ func (p *staticSpreadLevelProvider) GetLevels(maxAssetBase float64, maxAssetQuot
 
 	levels := []api.Level{}
 	for _, sl := range p.staticLevels {
-		absoluteSpread := centerPrice * sl.SPREAD
+		absoluteSpread := centerPrice * sl.SPREAD * spreadFactor
+		// we always add here because it is only used in the context of selling so we always charge a higher price to include a spread
+		price := centerPrice + absoluteSpread
 		levels = append(levels, api.Level{
-			// we always add here because it is only used in the context of selling so we always charge a higher price to include a spread
-			Price:  *model.NumberFromFloat(centerPrice+absoluteSpread, p.orderConstraints.PricePrecision),
-			Amount: *model.NumberFromFloat(sl.AMOUNT*p.amountOfBase, p.orderConstraints.VolumePrecision),
+			Price:   *model.NumberFromFloat(price, p.orderConstraints.PricePrecision),
+			Amount:  *model.NumberFromFloat(p.levelAmount(sl.AMOUNT, price), p.orderConstraints.VolumePrecision),
+			Passive: sl.PASSIVE,
 		})
 	}
 	return levels, nil
 }
 
+// levelAmount returns the amount of a level in units of the base asset of the bot. The price is the price of the level, which is inverted
+// on the buy side so it is in units of the base asset per unit of the quote asset there.
+func (p *staticSpreadLevelProvider) levelAmount(amount float64, price float64) float64 {
+	if p.amountOfQuote == 0 {
+		return amount * p.amountOfBase
+	}
+
+	quoteAmount := amount * p.amountOfQuote
+	if p.offset.invert {
+		return quoteAmount * price
+	}
+	return quoteAmount / price
+}
+
 // GetFillHandlers impl
 func (p *staticSpreadLevelProvider) GetFillHandlers() ([]api.FillHandler, error) {
 	return nil, nil
@@ -0,0 +1,80 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateAmountUnits(t *testing.T) {
+	assert.NoError(t, validateAmountUnits(10, 0))
+	assert.NoError(t, validateAmountUnits(0, 500))
+	assert.Error(t, validateAmountUnits(10, 500))
+	assert.Error(t, validateAmountUnits(0, -500))
+}
+
+func TestStaticSpreadLevelProviderAmountOfQuote(t *testing.T) {
+	testCases := []struct {
+		name          string
+		amountOfBase  float64
+		amountOfQuote float64
+		invert        bool
+		wantPrices    []float64
+		wantAmounts   []float64
+	}{
+		{
+			name:         "base units",
+			amountOfBase: 10,
+			wantPrices:   []float64{0.202, 0.21},
+			wantAmounts:  []float64{10, 20},
+		}, {
+			name:          "quote units on the sell side",
+			amountOfQuote: 500,
+			wantPrices:    []float64{0.202, 0.21},
+			// 500 / 0.202 and 1000 / 0.21
+			wantAmounts: []float64{2475.2475248, 4761.9047619},
+		}, {
+			name:          "quote units on the buy side",
+			amountOfQuote: 500,
+			invert:        true,
+			// prices are in units of the base asset per unit of the quote asset on the buy side
+			wantPrices:  []float64{5.05, 5.25},
+			wantAmounts: []float64{2525, 5250},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			feedA, feedB := "0.2", "1.0"
+			if k.invert {
+				feedA, feedB = feedB, feedA
+			}
+			pf, e := MakeFeedPair("fixed", feedA, "fixed", feedB)
+			if !assert.NoError(t, e) {
+				return
+			}
+			p := makeStaticSpreadLevelProvider(
+				[]StaticLevel{{SPREAD: 0.01, AMOUNT: 1}, {SPREAD: 0.05, AMOUNT: 2}},
+				k.amountOfBase,
+				k.amountOfQuote,
+				rateOffset{invert: k.invert},
+				pf,
+				model.MakeOrderConstraints(7, 7, 0.1),
+				nil,
+			)
+
+			levels, e := p.GetLevels(1000000, 1000000)
+			if !assert.NoError(t, e) {
+				return
+			}
+			if !assert.Equal(t, len(k.wantAmounts), len(levels)) {
+				return
+			}
+			for i, l := range levels {
+				assert.InDelta(t, k.wantPrices[i], l.Price.AsFloat(), 0.0000001)
+				assert.InDelta(t, k.wantAmounts[i], l.Amount.AsFloat(), 0.0000001)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package plugins
+
+import (
+	"fmt"
+
+	"github.com/stellar/kelp/model"
+)
+
+// validateStopOrder checks that a stop order has the prices its type needs, stop-loss orders become market orders when they are triggered so
+// they do not have a limit price
+func validateStopOrder(order *model.Order) error {
+	if order.StopPrice == nil || order.StopPrice.AsFloat() <= 0 {
+		return fmt.Errorf("%s order needs a positive stop price", order.OrderType)
+	}
+	if order.OrderType == model.OrderTypeStopLimit && (order.Price == nil || order.Price.AsFloat() <= 0) {
+		return fmt.Errorf("%s order needs a positive limit price", order.OrderType)
+	}
+	if order.OrderType == model.OrderTypeStopLoss && order.Price != nil {
+		return fmt.Errorf("%s order cannot have a limit price, use %s instead", order.OrderType, model.OrderTypeStopLimit)
+	}
+	if order.PostOnly {
+		return fmt.Errorf("%s order cannot be post-only", order.OrderType)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package plugins
+
+import (
+	"sync"
+	"time"
+)
+
+// timeSample is a single value recorded at a point in time
+type timeSample struct {
+	t     time.Time
+	value float64
+}
+
+// timeSeries is a small in-memory store of samples that only retains the samples within a rolling window of the latest sample
+type timeSeries struct {
+	window  time.Duration
+	mutex   *sync.Mutex
+	samples []timeSample
+}
+
+// makeTimeSeries is a factory method
+func makeTimeSeries(window time.Duration) *timeSeries {
+	return &timeSeries{
+		window:  window,
+		mutex:   &sync.Mutex{},
+		samples: []timeSample{},
+	}
+}
+
+// add records a sample and evicts the samples that have fallen out of the window, samples are expected in chronological order
+func (ts *timeSeries) add(t time.Time, value float64) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	ts.samples = append(ts.samples, timeSample{t: t, value: value})
+	cutoff := t.Add(-ts.window)
+	firstIdx := 0
+	for firstIdx < len(ts.samples) && ts.samples[firstIdx].t.Before(cutoff) {
+		firstIdx++
+	}
+	ts.samples = ts.samples[firstIdx:]
+}
+
+// values returns a copy of the values within the window in chronological order
+func (ts *timeSeries) values() []float64 {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	values := []float64{}
+	for _, s := range ts.samples {
+		values = append(values, s.value)
+	}
+	return values
+}
@@ -0,0 +1,261 @@
+package plugins
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/stellar/go/build"
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/support/networking"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// TrustlineStatus is the state of the trustline of an account for a non-native asset
+type TrustlineStatus struct {
+	AssetCode                       string `json:"asset_code"`
+	AssetIssuer                     string `json:"asset_issuer"`
+	Exists                          bool   `json:"exists"`
+	AuthRequired                    bool   `json:"auth_required"`  // the issuer needs to authorize trustlines before the asset can be held
+	AuthRevocable                   bool   `json:"auth_revocable"` // the issuer can revoke the authorization, which removes the offers of the account
+	Authorized                      bool   `json:"authorized"`
+	AuthorizedToMaintainLiabilities bool   `json:"authorized_to_maintain_liabilities"` // the account can keep its existing offers but cannot place new ones
+	ClawbackEnabled                 bool   `json:"clawback_enabled"`                   // the issuer can claw back the asset from the account
+	Balance                         string `json:"balance"`
+	Limit                           string `json:"limit"`
+	AvailableLimit                  string `json:"available_limit"`   // how much more of the asset the account can receive given its balance and buying liabilities
+	Problem                         string `json:"problem"`           // empty when the trustline can be used for trading
+	Warning                         string `json:"warning,omitempty"` // risks of trading the asset that do not prevent trading
+}
+
+// String is the stringer function
+func (t TrustlineStatus) String() string {
+	return fmt.Sprintf("TrustlineStatus[asset=%s:%s, exists=%v, authRequired=%v, authRevocable=%v, authorized=%v, authorizedToMaintainLiabilities=%v, clawbackEnabled=%v, balance=%s, limit=%s, availableLimit=%s]",
+		t.AssetCode, t.AssetIssuer, t.Exists, t.AuthRequired, t.AuthRevocable, t.Authorized, t.AuthorizedToMaintainLiabilities, t.ClawbackEnabled, t.Balance, t.Limit, t.AvailableLimit)
+}
+
+// accountAuthFlags are the authorization flags of an account and its trustlines, the horizon protocol types of the SDK predate the
+// clawback and maintain liabilities flags so they are read from the account response separately
+type accountAuthFlags struct {
+	Flags struct {
+		AuthRequired        bool `json:"auth_required"`
+		AuthRevocable       bool `json:"auth_revocable"`
+		AuthClawbackEnabled bool `json:"auth_clawback_enabled"`
+	} `json:"flags"`
+	Balances []trustlineAuthFlags `json:"balances"`
+}
+
+// trustlineAuthFlags are the authorization flags of a trustline
+type trustlineAuthFlags struct {
+	AssetType                         string `json:"asset_type"`
+	AssetCode                         string `json:"asset_code"`
+	AssetIssuer                       string `json:"asset_issuer"`
+	IsAuthorizedToMaintainLiabilities *bool  `json:"is_authorized_to_maintain_liabilities"`
+	IsClawbackEnabled                 *bool  `json:"is_clawback_enabled"`
+}
+
+func loadAccountAuthFlags(client *horizonclient.Client, accountID string) (*accountAuthFlags, error) {
+	var flags accountAuthFlags
+	accountURL := fmt.Sprintf("%s/accounts/%s", strings.TrimSuffix(client.HorizonURL, "/"), accountID)
+	e := networking.JSONRequest(http.DefaultClient, "GET", accountURL, "", map[string]string{}, &flags, "")
+	if e != nil {
+		return nil, fmt.Errorf("unable to load authorization flags of account %s: %s", accountID, e)
+	}
+	return &flags, nil
+}
+
+// LoadTrustlines checks the trustlines of the account for the non-native assets, loading the issuers of the assets to check whether they
+// require trustlines to be authorized or can claw back the assets
+func LoadTrustlines(client *horizonclient.Client, accountID string, assets []hProtocol.Asset) ([]TrustlineStatus, error) {
+	creditAssets := []hProtocol.Asset{}
+	for _, asset := range assets {
+		if asset.Type != "native" {
+			creditAssets = append(creditAssets, asset)
+		}
+	}
+
+	// the account and the issuers are independent so they are loaded from horizon concurrently
+	var account hProtocol.Account
+	var accountFlags *accountAuthFlags
+	issuerFlags := make([]*accountAuthFlags, len(creditAssets))
+	fns := []func() error{
+		func() error {
+			var e error
+			account, e = client.AccountDetail(horizonclient.AccountRequest{AccountID: accountID})
+			if e != nil {
+				return fmt.Errorf("unable to load account %s: %s", accountID, e)
+			}
+			return nil
+		},
+		func() error {
+			var e error
+			accountFlags, e = loadAccountAuthFlags(client, accountID)
+			return e
+		},
+	}
+	for i, asset := range creditAssets {
+		i, asset := i, asset
+		fns = append(fns, func() error {
+			var e error
+			issuerFlags[i], e = loadAccountAuthFlags(client, asset.Issuer)
+			if e != nil {
+				return fmt.Errorf("unable to load issuer account %s of asset %s: %s", asset.Issuer, asset.Code, e)
+			}
+			return nil
+		})
+	}
+	e := utils.RunConcurrently(fns...)
+	if e != nil {
+		return nil, e
+	}
+
+	statuses := []TrustlineStatus{}
+	for i, asset := range creditAssets {
+		status, e := makeTrustlineStatus(account, accountFlags, asset, issuerFlags[i])
+		if e != nil {
+			return nil, e
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func makeTrustlineStatus(account hProtocol.Account, accountFlags *accountAuthFlags, asset hProtocol.Asset, issuerFlags *accountAuthFlags) (TrustlineStatus, error) {
+	status := TrustlineStatus{
+		AssetCode:       asset.Code,
+		AssetIssuer:     asset.Issuer,
+		AuthRequired:    issuerFlags.Flags.AuthRequired,
+		AuthRevocable:   issuerFlags.Flags.AuthRevocable,
+		ClawbackEnabled: issuerFlags.Flags.AuthClawbackEnabled,
+	}
+
+	var balance *hProtocol.Balance
+	for i := range account.Balances {
+		b := account.Balances[i]
+		if b.Asset.Type != "native" && b.Asset.Code == asset.Code && b.Asset.Issuer == asset.Issuer {
+			balance = &b
+			break
+		}
+	}
+	if balance == nil {
+		status.Problem = fmt.Sprintf("account %s has no trustline for %s:%s", account.AccountID, asset.Code, asset.Issuer)
+		status.Warning = authWarning(status, account.AccountID)
+		return status, nil
+	}
+
+	status.Exists = true
+	status.Balance = balance.Balance
+	status.Limit = balance.Limit
+	// horizon versions that do not report authorization only return trustlines that are usable
+	status.Authorized = balance.IsAuthorized == nil || *balance.IsAuthorized
+	for _, f := range accountFlags.Balances {
+		if f.AssetCode != asset.Code || f.AssetIssuer != asset.Issuer {
+			continue
+		}
+		status.AuthorizedToMaintainLiabilities = f.IsAuthorizedToMaintainLiabilities != nil && *f.IsAuthorizedToMaintainLiabilities
+		// the clawback flag of the issuer only applies to trustlines that are created after it was set
+		if f.IsClawbackEnabled != nil {
+			status.ClawbackEnabled = *f.IsClawbackEnabled
+		}
+	}
+
+	available, e := availableLimit(*balance)
+	if e != nil {
+		return status, fmt.Errorf("unable to compute the available limit of the trustline for %s:%s: %s", asset.Code, asset.Issuer, e)
+	}
+	status.AvailableLimit = strconv.FormatFloat(available, 'f', 7, 64)
+
+	if !status.Authorized && status.AuthorizedToMaintainLiabilities {
+		status.Problem = fmt.Sprintf("the issuer %s has only authorized account %s to maintain its existing offers for %s, new offers cannot be placed until the issuer fully authorizes the trustline", asset.Issuer, account.AccountID, asset.Code)
+	} else if !status.Authorized {
+		status.Problem = fmt.Sprintf("the issuer %s has not authorized account %s to hold %s, ask the issuer to authorize the trustline", asset.Issuer, account.AccountID, asset.Code)
+	} else if available <= 0 {
+		status.Problem = fmt.Sprintf("the trustline of account %s for %s:%s is at its limit (%s) and cannot receive more of the asset", account.AccountID, asset.Code, asset.Issuer, balance.Limit)
+	}
+	status.Warning = authWarning(status, account.AccountID)
+	return status, nil
+}
+
+// authWarning describes what the issuer can do to the holdings and offers of the account, empty when the issuer cannot interfere
+func authWarning(status TrustlineStatus, accountID string) string {
+	warnings := []string{}
+	if status.ClawbackEnabled {
+		warnings = append(warnings, fmt.Sprintf("the issuer %s can claw back %s from account %s", status.AssetIssuer, status.AssetCode, accountID))
+	}
+	if status.AuthRevocable {
+		warnings = append(warnings, fmt.Sprintf("the issuer %s can revoke the authorization of the trustline for %s, which removes the offers of account %s", status.AssetIssuer, status.AssetCode, accountID))
+	}
+	return strings.Join(warnings, "; ")
+}
+
+// availableLimit is the amount of the asset that the trustline can still receive
+func availableLimit(balance hProtocol.Balance) (float64, error) {
+	limit, e := strconv.ParseFloat(balance.Limit, 64)
+	if e != nil {
+		return 0, fmt.Errorf("invalid limit '%s': %s", balance.Limit, e)
+	}
+	amount, e := strconv.ParseFloat(balance.Balance, 64)
+	if e != nil {
+		return 0, fmt.Errorf("invalid balance '%s': %s", balance.Balance, e)
+	}
+	buyingLiabilities := 0.0
+	if balance.BuyingLiabilities != "" {
+		buyingLiabilities, e = strconv.ParseFloat(balance.BuyingLiabilities, 64)
+		if e != nil {
+			return 0, fmt.Errorf("invalid buying liabilities '%s': %s", balance.BuyingLiabilities, e)
+		}
+	}
+	return limit - amount - buyingLiabilities, nil
+}
+
+// AddTrustlineOps returns the ops that add the missing trustlines of the tradingAccount with the max limit
+func AddTrustlineOps(statuses []TrustlineStatus, tradingAccount string) []build.TransactionMutator {
+	ops := []build.TransactionMutator{}
+	for _, s := range statuses {
+		if s.Exists {
+			continue
+		}
+		ops = append(ops, build.Trust(s.AssetCode, s.AssetIssuer, build.SourceAccount{AddressOrSeed: tradingAccount}))
+	}
+	return ops
+}
+
+// RemoveTrustlineOps returns the ops that remove the existing trustlines of the tradingAccount. A trustline can only be removed once its
+// balance is zero so it fails without any ops when any of them still holds the asset.
+func RemoveTrustlineOps(statuses []TrustlineStatus, tradingAccount string) ([]build.TransactionMutator, error) {
+	ops := []build.TransactionMutator{}
+	for _, s := range statuses {
+		if !s.Exists {
+			continue
+		}
+
+		balance, e := strconv.ParseFloat(s.Balance, 64)
+		if e != nil {
+			return nil, fmt.Errorf("invalid balance '%s' of the trustline for %s:%s: %s", s.Balance, s.AssetCode, s.AssetIssuer, e)
+		}
+		if balance != 0 {
+			return nil, fmt.Errorf("cannot remove the trustline for %s:%s because it has a balance of %s, send the balance back to the issuer or trade it away first", s.AssetCode, s.AssetIssuer, s.Balance)
+		}
+		ops = append(ops, build.RemoveTrust(s.AssetCode, s.AssetIssuer, build.SourceAccount{AddressOrSeed: tradingAccount}))
+	}
+	return ops, nil
+}
+
+// SubmitTrustlineOps submits the trustline ops in a single transaction and waits for the result
+func SubmitTrustlineOps(sdex *SDEX, ops []build.TransactionMutator) (string, error) {
+	var txHash string
+	var txErr error
+	e := sdex.SubmitOpsSynch(ops, func(hash string, e error) {
+		txHash = hash
+		txErr = e
+	})
+	if e != nil {
+		return "", fmt.Errorf("unable to submit trustline transaction: %s", e)
+	}
+	if txErr != nil {
+		return "", fmt.Errorf("trustline transaction failed: %s", txErr)
+	}
+	return txHash, nil
+}
@@ -0,0 +1,134 @@
+package plugins
+
+import (
+	"testing"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/protocols/horizon/base"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeTrustlineStatus(t *testing.T) {
+	usdc := hProtocol.Asset{Type: "credit_alphanum4", Code: "USDC", Issuer: "GUSDCISSUER"}
+	makeBalance := func(amount string, limit string, buyingLiabilities string, isAuthorized *bool) hProtocol.Balance {
+		return hProtocol.Balance{
+			Balance:           amount,
+			Limit:             limit,
+			BuyingLiabilities: buyingLiabilities,
+			IsAuthorized:      isAuthorized,
+			Asset:             base.Asset{Type: usdc.Type, Code: usdc.Code, Issuer: usdc.Issuer},
+		}
+	}
+	authorized := true
+	unauthorized := false
+
+	testCases := []struct {
+		name                    string
+		balances                []hProtocol.Balance
+		authRequired            bool
+		maintainLiabilities     bool
+		clawbackEnabled         bool
+		wantExists              bool
+		wantAuthorized          bool
+		wantAvailableLimit      string
+		wantProblem             bool
+		wantMaintainLiabilities bool
+		wantWarning             bool
+	}{
+		{
+			name:        "missing",
+			balances:    []hProtocol.Balance{},
+			wantProblem: true,
+		}, {
+			name:               "usable",
+			balances:           []hProtocol.Balance{makeBalance("10.0000000", "100.0000000", "5.0000000", &authorized)},
+			wantExists:         true,
+			wantAuthorized:     true,
+			wantAvailableLimit: "85.0000000",
+		}, {
+			name:               "authorization not reported",
+			balances:           []hProtocol.Balance{makeBalance("0.0000000", "100.0000000", "", nil)},
+			wantExists:         true,
+			wantAuthorized:     true,
+			wantAvailableLimit: "100.0000000",
+		}, {
+			name:               "not authorized",
+			balances:           []hProtocol.Balance{makeBalance("0.0000000", "100.0000000", "0.0000000", &unauthorized)},
+			authRequired:       true,
+			wantExists:         true,
+			wantAuthorized:     false,
+			wantAvailableLimit: "100.0000000",
+			wantProblem:        true,
+		}, {
+			name:               "at limit",
+			balances:           []hProtocol.Balance{makeBalance("100.0000000", "100.0000000", "0.0000000", &authorized)},
+			wantExists:         true,
+			wantAuthorized:     true,
+			wantAvailableLimit: "0.0000000",
+			wantProblem:        true,
+		}, {
+			name:                    "authorized to maintain liabilities",
+			balances:                []hProtocol.Balance{makeBalance("10.0000000", "100.0000000", "0.0000000", &unauthorized)},
+			authRequired:            true,
+			maintainLiabilities:     true,
+			wantExists:              true,
+			wantAuthorized:          false,
+			wantAvailableLimit:      "90.0000000",
+			wantProblem:             true,
+			wantMaintainLiabilities: true,
+		}, {
+			name:               "clawback enabled",
+			balances:           []hProtocol.Balance{makeBalance("10.0000000", "100.0000000", "0.0000000", &authorized)},
+			authRequired:       true,
+			clawbackEnabled:    true,
+			wantExists:         true,
+			wantAuthorized:     true,
+			wantAvailableLimit: "90.0000000",
+			wantWarning:        true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			account := hProtocol.Account{AccountID: "GTRADER", Balances: k.balances}
+			accountFlags := &accountAuthFlags{Balances: []trustlineAuthFlags{{
+				AssetType:                         usdc.Type,
+				AssetCode:                         usdc.Code,
+				AssetIssuer:                       usdc.Issuer,
+				IsAuthorizedToMaintainLiabilities: &k.maintainLiabilities,
+				IsClawbackEnabled:                 &k.clawbackEnabled,
+			}}}
+			issuerFlags := &accountAuthFlags{}
+			issuerFlags.Flags.AuthRequired = k.authRequired
+			status, e := makeTrustlineStatus(account, accountFlags, usdc, issuerFlags)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantExists, status.Exists)
+			assert.Equal(t, k.authRequired, status.AuthRequired)
+			assert.Equal(t, k.wantAuthorized, status.Authorized)
+			assert.Equal(t, k.wantAvailableLimit, status.AvailableLimit)
+			assert.Equal(t, k.wantProblem, status.Problem != "")
+			assert.Equal(t, k.wantMaintainLiabilities, status.AuthorizedToMaintainLiabilities)
+			assert.Equal(t, k.clawbackEnabled, status.ClawbackEnabled)
+			assert.Equal(t, k.wantWarning, status.Warning != "")
+		})
+	}
+}
+
+func TestTrustlineOps(t *testing.T) {
+	statuses := []TrustlineStatus{
+		{AssetCode: "USDC", AssetIssuer: "GUSDCISSUER", Exists: false},
+		{AssetCode: "EURT", AssetIssuer: "GEURTISSUER", Exists: true, Balance: "0.0000000"},
+	}
+	assert.Equal(t, 1, len(AddTrustlineOps(statuses, "GTRADER")))
+
+	ops, e := RemoveTrustlineOps(statuses, "GTRADER")
+	if assert.NoError(t, e) {
+		assert.Equal(t, 1, len(ops))
+	}
+
+	statuses[1].Balance = "1.5000000"
+	_, e = RemoveTrustlineOps(statuses, "GTRADER")
+	assert.Error(t, e)
+}
@@ -0,0 +1,313 @@
+package plugins
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/xdr"
+)
+
+// maxRecentTxFailures is the number of failed transactions kept in the TxFailureStats
+const maxRecentTxFailures = 20
+
+// txCodeCauses maps the transaction result codes used by horizon to their causes
+var txCodeCauses = map[string]string{
+	"tx_failed":               "one or more operations failed",
+	"tx_too_early":            "the ledger close time is before the lower time bound of the transaction",
+	"tx_too_late":             "the ledger close time is after the upper time bound of the transaction",
+	"tx_missing_operation":    "the transaction has no operations",
+	"tx_bad_seq":              "the sequence number does not match the source account, it is reloaded before the next transaction",
+	"tx_bad_auth":             "the transaction is missing signatures or is signed for a different network",
+	"tx_insufficient_balance": "the fee would take the source account below its minimum reserve",
+	"tx_no_source_account":    "the source account does not exist",
+	"tx_insufficient_fee":     "the fee is below the network minimum or too low during surge pricing",
+	"tx_bad_auth_extra":       "the transaction has unused signatures",
+	"tx_internal_error":       "stellar-core had an internal error",
+}
+
+// opCodeCauses maps the operation result codes used by horizon to their causes, the offer codes are shared by ManageSellOffer,
+// ManageBuyOffer and CreatePassiveSellOffer
+var opCodeCauses = map[string]string{
+	"op_bad_auth":            "the operation is missing signatures",
+	"op_no_source_account":   "the source account of the operation does not exist",
+	"op_not_supported":       "the operation is not supported by the network",
+	"op_too_many_subentries": "the account has the maximum number of offers and trustlines",
+	"op_exceeded_work_limit": "the offer crossed too many offers",
+	"op_malformed":           "the operation is invalid, for example a price or amount of zero",
+	"op_sell_no_trust":       "the account does not have a trustline for the selling asset",
+	"op_buy_no_trust":        "the account does not have a trustline for the buying asset",
+	"op_sell_not_authorized": "the account is not authorized by the issuer to sell the asset",
+	"op_buy_not_authorized":  "the account is not authorized by the issuer to buy the asset",
+	"op_line_full":           "the trustline of the buying asset cannot hold the amount that would be bought",
+	"op_underfunded":         "the account does not have enough of the selling asset after its liabilities",
+	"op_cross_self":          "the offer would cross an offer of the same account",
+	"op_sell_no_issuer":      "the issuer of the selling asset does not exist",
+	"op_buy_no_issuer":       "the issuer of the buying asset does not exist",
+	"op_offer_not_found":     "the offer to update or delete does not exist, it was probably filled",
+	"op_low_reserve":         "the account does not have enough XLM for the reserve of a new offer or trustline",
+	"op_src_no_trust":        "the source account does not have a trustline for the asset",
+	"op_src_not_authorized":  "the source account is not authorized by the issuer to send the asset",
+	"op_no_destination":      "the destination account does not exist",
+	"op_no_trust":            "the destination account does not have a trustline for the asset",
+	"op_not_authorized":      "the destination account is not authorized by the issuer to hold the asset",
+	"op_no_issuer":           "the issuer of the asset does not exist",
+	"op_invalid_limit":       "the trustline limit is below the current balance and liabilities",
+	"op_self_not_allowed":    "the account cannot trust an asset it issues",
+}
+
+var xdrTxCodes = map[xdr.TransactionResultCode]string{
+	xdr.TransactionResultCodeTxSuccess:             "tx_success",
+	xdr.TransactionResultCodeTxFailed:              "tx_failed",
+	xdr.TransactionResultCodeTxTooEarly:            "tx_too_early",
+	xdr.TransactionResultCodeTxTooLate:             "tx_too_late",
+	xdr.TransactionResultCodeTxMissingOperation:    "tx_missing_operation",
+	xdr.TransactionResultCodeTxBadSeq:              "tx_bad_seq",
+	xdr.TransactionResultCodeTxBadAuth:             "tx_bad_auth",
+	xdr.TransactionResultCodeTxInsufficientBalance: "tx_insufficient_balance",
+	xdr.TransactionResultCodeTxNoAccount:           "tx_no_source_account",
+	xdr.TransactionResultCodeTxInsufficientFee:     "tx_insufficient_fee",
+	xdr.TransactionResultCodeTxBadAuthExtra:        "tx_bad_auth_extra",
+	xdr.TransactionResultCodeTxInternalError:       "tx_internal_error",
+}
+
+var xdrOpCodes = map[xdr.OperationResultCode]string{
+	xdr.OperationResultCodeOpBadAuth:           "op_bad_auth",
+	xdr.OperationResultCodeOpNoAccount:         "op_no_source_account",
+	xdr.OperationResultCodeOpNotSupported:      "op_not_supported",
+	xdr.OperationResultCodeOpTooManySubentries: "op_too_many_subentries",
+	xdr.OperationResultCodeOpExceededWorkLimit: "op_exceeded_work_limit",
+}
+
+// xdrOfferCodes is keyed by the values of ManageSellOfferResultCode, which are the same as the values of ManageBuyOfferResultCode
+var xdrOfferCodes = map[int32]string{
+	int32(xdr.ManageSellOfferResultCodeManageSellOfferSuccess):           "op_success",
+	int32(xdr.ManageSellOfferResultCodeManageSellOfferMalformed):         "op_malformed",
+	int32(xdr.ManageSellOfferResultCodeManageSellOfferSellNoTrust):       "op_sell_no_trust",
+	int32(xdr.ManageSellOfferResultCodeManageSellOfferBuyNoTrust):        "op_buy_no_trust",
+	int32(xdr.ManageSellOfferResultCodeManageSellOfferSellNotAuthorized): "op_sell_not_authorized",
+	int32(xdr.ManageSellOfferResultCodeManageSellOfferBuyNotAuthorized):  "op_buy_not_authorized",
+	int32(xdr.ManageSellOfferResultCodeManageSellOfferLineFull):          "op_line_full",
+	int32(xdr.ManageSellOfferResultCodeManageSellOfferUnderfunded):       "op_underfunded",
+	int32(xdr.ManageSellOfferResultCodeManageSellOfferCrossSelf):         "op_cross_self",
+	int32(xdr.ManageSellOfferResultCodeManageSellOfferSellNoIssuer):      "op_sell_no_issuer",
+	int32(xdr.ManageSellOfferResultCodeManageSellOfferBuyNoIssuer):       "op_buy_no_issuer",
+	int32(xdr.ManageSellOfferResultCodeManageSellOfferNotFound):          "op_offer_not_found",
+	int32(xdr.ManageSellOfferResultCodeManageSellOfferLowReserve):        "op_low_reserve",
+}
+
+var xdrPaymentCodes = map[xdr.PaymentResultCode]string{
+	xdr.PaymentResultCodePaymentSuccess:          "op_success",
+	xdr.PaymentResultCodePaymentMalformed:        "op_malformed",
+	xdr.PaymentResultCodePaymentUnderfunded:      "op_underfunded",
+	xdr.PaymentResultCodePaymentSrcNoTrust:       "op_src_no_trust",
+	xdr.PaymentResultCodePaymentSrcNotAuthorized: "op_src_not_authorized",
+	xdr.PaymentResultCodePaymentNoDestination:    "op_no_destination",
+	xdr.PaymentResultCodePaymentNoTrust:          "op_no_trust",
+	xdr.PaymentResultCodePaymentNotAuthorized:    "op_not_authorized",
+	xdr.PaymentResultCodePaymentLineFull:         "op_line_full",
+	xdr.PaymentResultCodePaymentNoIssuer:         "op_no_issuer",
+}
+
+var xdrChangeTrustCodes = map[xdr.ChangeTrustResultCode]string{
+	xdr.ChangeTrustResultCodeChangeTrustSuccess:        "op_success",
+	xdr.ChangeTrustResultCodeChangeTrustMalformed:      "op_malformed",
+	xdr.ChangeTrustResultCodeChangeTrustNoIssuer:       "op_no_issuer",
+	xdr.ChangeTrustResultCodeChangeTrustInvalidLimit:   "op_invalid_limit",
+	xdr.ChangeTrustResultCodeChangeTrustLowReserve:     "op_low_reserve",
+	xdr.ChangeTrustResultCodeChangeTrustSelfNotAllowed: "op_self_not_allowed",
+}
+
+// OpFailure is an operation that failed in a transaction
+type OpFailure struct {
+	Index int    `json:"index"` // index of the operation in the transaction
+	Code  string `json:"code"`
+	Cause string `json:"cause"`
+}
+
+// TxFailure is the reason a transaction failed, it is passed to the callback of SubmitOps as the error
+type TxFailure struct {
+	TxCode  string      `json:"tx_code"`
+	TxCause string      `json:"tx_cause"`
+	Ops     []OpFailure `json:"ops"` // only the operations that failed
+}
+
+// ensure it implements error
+var _ error = &TxFailure{}
+
+// Error impl
+func (f *TxFailure) Error() string {
+	opStrings := []string{}
+	for _, op := range f.Ops {
+		opStrings = append(opStrings, fmt.Sprintf("op %d %s (%s)", op.Index, op.Code, op.Cause))
+	}
+	if len(opStrings) == 0 {
+		return fmt.Sprintf("tx failed with %s (%s)", f.TxCode, f.TxCause)
+	}
+	return fmt.Sprintf("tx failed with %s (%s): %s", f.TxCode, f.TxCause, strings.Join(opStrings, ", "))
+}
+
+// makeTxFailure maps the result codes of a failed transaction to their causes
+func makeTxFailure(rcs *hProtocol.TransactionResultCodes) *TxFailure {
+	f := &TxFailure{
+		TxCode:  rcs.TransactionCode,
+		TxCause: causeOf(txCodeCauses, rcs.TransactionCode),
+		Ops:     []OpFailure{},
+	}
+	for i, code := range rcs.OperationCodes {
+		if code == "op_success" {
+			continue
+		}
+		f.Ops = append(f.Ops, OpFailure{
+			Index: i,
+			Code:  code,
+			Cause: causeOf(opCodeCauses, code),
+		})
+	}
+	return f
+}
+
+func causeOf(causes map[string]string, code string) string {
+	if cause, ok := causes[code]; ok {
+		return cause
+	}
+	return "unknown result code"
+}
+
+// decodeTxResult decodes a base64 encoded XDR TransactionResult, as returned by stellar-core, into the result codes used by horizon. Only
+// the operations used by the bot are decoded, the results of other operations are reported with the type of the operation.
+func decodeTxResult(resultXDR string) (*hProtocol.TransactionResultCodes, error) {
+	var txResult xdr.TransactionResult
+	e := xdr.SafeUnmarshalBase64(resultXDR, &txResult)
+	if e != nil {
+		return nil, fmt.Errorf("unable to decode result XDR: %s", e)
+	}
+
+	txCode, ok := xdrTxCodes[txResult.Result.Code]
+	if !ok {
+		txCode = txResult.Result.Code.String()
+	}
+	rcs := &hProtocol.TransactionResultCodes{TransactionCode: txCode}
+	if txResult.Result.Results == nil {
+		return rcs, nil
+	}
+
+	for _, opResult := range *txResult.Result.Results {
+		rcs.OperationCodes = append(rcs.OperationCodes, opResultCode(opResult))
+	}
+	return rcs, nil
+}
+
+func opResultCode(opResult xdr.OperationResult) string {
+	if opResult.Code != xdr.OperationResultCodeOpInner {
+		if code, ok := xdrOpCodes[opResult.Code]; ok {
+			return code
+		}
+		return opResult.Code.String()
+	}
+
+	tr := opResult.Tr
+	var code string
+	var ok bool
+	switch {
+	case tr == nil:
+		ok = false
+	case tr.Type == xdr.OperationTypeManageSellOffer && tr.ManageSellOfferResult != nil:
+		code, ok = xdrOfferCodes[int32(tr.ManageSellOfferResult.Code)]
+	case tr.Type == xdr.OperationTypeCreatePassiveSellOffer && tr.CreatePassiveSellOfferResult != nil:
+		code, ok = xdrOfferCodes[int32(tr.CreatePassiveSellOfferResult.Code)]
+	case tr.Type == xdr.OperationTypeManageBuyOffer && tr.ManageBuyOfferResult != nil:
+		code, ok = xdrOfferCodes[int32(tr.ManageBuyOfferResult.Code)]
+	case tr.Type == xdr.OperationTypePayment && tr.PaymentResult != nil:
+		code, ok = xdrPaymentCodes[tr.PaymentResult.Code]
+	case tr.Type == xdr.OperationTypeChangeTrust && tr.ChangeTrustResult != nil:
+		code, ok = xdrChangeTrustCodes[tr.ChangeTrustResult.Code]
+	}
+	if !ok {
+		if tr == nil {
+			return "op_inner"
+		}
+		return fmt.Sprintf("op_inner(%s)", tr.Type.String())
+	}
+	return code
+}
+
+// TxFailureRecord is a transaction that failed at the given time
+type TxFailureRecord struct {
+	Time string `json:"time"`
+	*TxFailure
+}
+
+// TxFailureStats summarizes the transactions that failed since the bot started
+type TxFailureStats struct {
+	NumFailed int               `json:"num_failed"`
+	Counts    map[string]int    `json:"counts"` // failures by transaction code, or by operation code for the operations that failed in a tx_failed transaction
+	Recent    []TxFailureRecord `json:"recent"` // most recent first, bounded by maxRecentTxFailures
+}
+
+// txFailureRecorder collects the failed transactions of the SDEX instance
+type txFailureRecorder struct {
+	mutex *sync.Mutex
+	stats TxFailureStats
+}
+
+func makeTxFailureRecorder() *txFailureRecorder {
+	return &txFailureRecorder{
+		mutex: &sync.Mutex{},
+		stats: TxFailureStats{
+			Counts: map[string]int{},
+			Recent: []TxFailureRecord{},
+		},
+	}
+}
+
+func (r *txFailureRecorder) record(f *TxFailure, t time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.stats.NumFailed++
+	if len(f.Ops) == 0 {
+		r.stats.Counts[f.TxCode]++
+	}
+	for _, op := range f.Ops {
+		r.stats.Counts[op.Code]++
+	}
+
+	r.stats.Recent = append([]TxFailureRecord{{Time: t.UTC().Format(time.RFC3339), TxFailure: f}}, r.stats.Recent...)
+	if len(r.stats.Recent) > maxRecentTxFailures {
+		r.stats.Recent = r.stats.Recent[:maxRecentTxFailures]
+	}
+}
+
+func (r *txFailureRecorder) snapshot() TxFailureStats {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	counts := map[string]int{}
+	for code, n := range r.stats.Counts {
+		counts[code] = n
+	}
+	return TxFailureStats{
+		NumFailed: r.stats.NumFailed,
+		Counts:    counts,
+		Recent:    append([]TxFailureRecord{}, r.stats.Recent...),
+	}
+}
+
+// recordTxFailure adds the failed transaction to the stats of this SDEX instance
+func (sdex *SDEX) recordTxFailure(f *TxFailure) {
+	if sdex.txFailures == nil {
+		return
+	}
+	sdex.txFailures.record(f, time.Now())
+}
+
+// TxFailureStats returns the transactions submitted by this SDEX instance that failed, with the causes of the failures
+func (sdex *SDEX) TxFailureStats() TxFailureStats {
+	if sdex.txFailures == nil {
+		return makeTxFailureRecorder().snapshot()
+	}
+	return sdex.txFailures.snapshot()
+}
@@ -0,0 +1,63 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeTxFailure(t *testing.T) {
+	f := makeTxFailure(&hProtocol.TransactionResultCodes{
+		TransactionCode: "tx_failed",
+		OperationCodes:  []string{"op_success", "op_underfunded", "op_low_reserve", "op_something_new"},
+	})
+
+	assert.Equal(t, "tx_failed", f.TxCode)
+	assert.Equal(t, txCodeCauses["tx_failed"], f.TxCause)
+	assert.Equal(t, []OpFailure{
+		{Index: 1, Code: "op_underfunded", Cause: opCodeCauses["op_underfunded"]},
+		{Index: 2, Code: "op_low_reserve", Cause: opCodeCauses["op_low_reserve"]},
+		{Index: 3, Code: "op_something_new", Cause: "unknown result code"},
+	}, f.Ops)
+
+	f = makeTxFailure(&hProtocol.TransactionResultCodes{TransactionCode: "tx_bad_seq"})
+	assert.Equal(t, 0, len(f.Ops))
+	assert.Equal(t, "tx failed with tx_bad_seq ("+txCodeCauses["tx_bad_seq"]+")", f.Error())
+}
+
+func TestDecodeTxResult(t *testing.T) {
+	// tx_failed with a ManageSellOffer that failed with op_underfunded, a ManageBuyOffer that failed with op_low_reserve, and an operation
+	// that failed with op_no_source_account
+	resultXDR := "AAAAAAAAASz/////AAAAAwAAAAAAAAAD////+QAAAAAAAAAM////9P////4AAAAA"
+
+	rcs, e := decodeTxResult(resultXDR)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, "tx_failed", rcs.TransactionCode)
+	assert.Equal(t, []string{"op_underfunded", "op_low_reserve", "op_no_source_account"}, rcs.OperationCodes)
+
+	_, e = decodeTxResult("not xdr")
+	assert.Error(t, e)
+}
+
+func TestTxFailureRecorder(t *testing.T) {
+	r := makeTxFailureRecorder()
+	now := time.Unix(1600000000, 0)
+	for i := 0; i < maxRecentTxFailures+5; i++ {
+		r.record(makeTxFailure(&hProtocol.TransactionResultCodes{
+			TransactionCode: "tx_failed",
+			OperationCodes:  []string{"op_underfunded", "op_underfunded"},
+		}), now)
+	}
+	r.record(makeTxFailure(&hProtocol.TransactionResultCodes{TransactionCode: "tx_bad_seq"}), now.Add(time.Second))
+
+	stats := r.snapshot()
+	assert.Equal(t, maxRecentTxFailures+6, stats.NumFailed)
+	assert.Equal(t, map[string]int{"op_underfunded": 2 * (maxRecentTxFailures + 5), "tx_bad_seq": 1}, stats.Counts)
+	assert.Equal(t, maxRecentTxFailures, len(stats.Recent))
+	assert.Equal(t, "tx_bad_seq", stats.Recent[0].TxCode)
+	assert.Equal(t, "2020-09-13T12:26:41Z", stats.Recent[0].Time)
+}
@@ -0,0 +1,129 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/stellar/kelp/api"
+)
+
+// minVolatilitySamples is the number of samples needed before the volatility is reported, i.e. at least 2 returns
+const minVolatilitySamples = 3
+
+// volatilityFeed wraps a child price feed and records every price it returns so it can report the volatility of the feed,
+// which is the rolling standard deviation of the returns between consecutive samples within the window
+type volatilityFeed struct {
+	child  api.PriceFeed
+	series *timeSeries
+	now    func() time.Time
+}
+
+// ensure that it implements PriceFeed
+var _ api.PriceFeed = &volatilityFeed{}
+
+// makeVolatilityFeed is a factory method
+func makeVolatilityFeed(child api.PriceFeed, window time.Duration) *volatilityFeed {
+	return &volatilityFeed{
+		child:  child,
+		series: makeTimeSeries(window),
+		now:    time.Now,
+	}
+}
+
+// GetPrice impl, records the price of the child feed as a sample
+func (f *volatilityFeed) GetPrice() (float64, error) {
+	price, e := f.child.GetPrice()
+	if e != nil {
+		return 0, e
+	}
+	if price <= 0 {
+		return 0, fmt.Errorf("price from child feed needs to be positive to compute volatility, was %f", price)
+	}
+
+	f.series.add(f.now(), price)
+	return price, nil
+}
+
+// GetVolatility returns the volatility as a decimal (0.01 = 1%) and false if there are not enough samples in the window yet
+func (f *volatilityFeed) GetVolatility() (float64, bool) {
+	prices := f.series.values()
+	if len(prices) < minVolatilitySamples {
+		return 0, false
+	}
+
+	returns := []float64{}
+	for i := 1; i < len(prices); i++ {
+		returns = append(returns, prices[i]/prices[i-1]-1)
+	}
+
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	variance /= float64(len(returns) - 1)
+	return math.Sqrt(variance), true
+}
+
+// centerPriceFeed exposes the center price of a feed pair as a PriceFeed so it can be wrapped by other feeds
+type centerPriceFeed struct {
+	pair *api.FeedPair
+}
+
+// ensure that it implements PriceFeed
+var _ api.PriceFeed = &centerPriceFeed{}
+
+// GetPrice impl
+func (f *centerPriceFeed) GetPrice() (float64, error) {
+	return f.pair.GetCenterPrice()
+}
+
+// volatilitySpread scales the spread of levels by the current volatility of the center price:
+// spread * min(1 + multiplier * volatility, maxFactor), a maxFactor of 0 does not cap the scaling
+type volatilitySpread struct {
+	feed       *volatilityFeed
+	multiplier float64
+	maxFactor  float64
+}
+
+// makeVolatilitySpread is a factory method, returns nil when the volatility window is 0 so spreads are not scaled
+func makeVolatilitySpread(pf *api.FeedPair, windowSeconds uint32, multiplier float64, maxFactor float64) (*volatilitySpread, error) {
+	if windowSeconds == 0 {
+		return nil, nil
+	}
+	if multiplier <= 0 {
+		return nil, fmt.Errorf("VOLATILITY_SPREAD_MULTIPLIER needs to be positive when VOLATILITY_WINDOW_SECONDS is set: %f", multiplier)
+	}
+	if maxFactor != 0 && maxFactor < 1 {
+		return nil, fmt.Errorf("VOLATILITY_MAX_SPREAD_FACTOR needs to be at least 1 (or 0 to not cap the scaling): %f", maxFactor)
+	}
+
+	return &volatilitySpread{
+		feed:       makeVolatilityFeed(&centerPriceFeed{pair: pf}, time.Duration(windowSeconds)*time.Second),
+		multiplier: multiplier,
+		maxFactor:  maxFactor,
+	}, nil
+}
+
+// factor returns the value that spreads should be scaled by, spreads are not scaled until there are enough samples
+func (v *volatilitySpread) factor() float64 {
+	volatility, ok := v.feed.GetVolatility()
+	if !ok {
+		log.Printf("not enough samples to compute volatility yet, not scaling spread\n")
+		return 1.0
+	}
+
+	factor := 1 + v.multiplier*volatility
+	if v.maxFactor != 0 && factor > v.maxFactor {
+		factor = v.maxFactor
+	}
+	log.Printf("volatility=%.7f, spread scale factor=%.4f\n", volatility, factor)
+	return factor
+}
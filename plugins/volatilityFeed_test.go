@@ -0,0 +1,86 @@
+package plugins
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// sequenceFeed returns the prices in order, one per call
+type sequenceFeed struct {
+	prices []float64
+	i      int
+}
+
+func (f *sequenceFeed) GetPrice() (float64, error) {
+	p := f.prices[f.i]
+	f.i++
+	return p, nil
+}
+
+func TestTimeSeriesWindow(t *testing.T) {
+	ts := makeTimeSeries(10 * time.Second)
+	start := time.Unix(1000, 0)
+	ts.add(start, 1.0)
+	ts.add(start.Add(5*time.Second), 2.0)
+	ts.add(start.Add(10*time.Second), 3.0)
+	assert.Equal(t, []float64{1.0, 2.0, 3.0}, ts.values())
+
+	// samples older than the window of the latest sample are evicted
+	ts.add(start.Add(16*time.Second), 4.0)
+	assert.Equal(t, []float64{3.0, 4.0}, ts.values())
+}
+
+func TestVolatilityFeed(t *testing.T) {
+	// returns are +10%, -10%, +10%
+	f := makeVolatilityFeed(&sequenceFeed{prices: []float64{100, 110, 99, 108.9}}, time.Minute)
+	now := time.Unix(1000, 0)
+	f.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		_, e := f.GetPrice()
+		if !assert.NoError(t, e) {
+			return
+		}
+		_, ok := f.GetVolatility()
+		assert.False(t, ok, "volatility should not be available before there are enough samples")
+	}
+
+	for i := 0; i < 2; i++ {
+		_, e := f.GetPrice()
+		if !assert.NoError(t, e) {
+			return
+		}
+	}
+	volatility, ok := f.GetVolatility()
+	if assert.True(t, ok) {
+		// mean return is 0.1/3, sample standard deviation of (0.1, -0.1, 0.1)
+		mean := 0.1 / 3
+		expected := math.Sqrt((2*(0.1-mean)*(0.1-mean) + (-0.1-mean)*(-0.1-mean)) / 2)
+		assert.InDelta(t, expected, volatility, 1e-9)
+	}
+}
+
+func TestVolatilitySpreadFactor(t *testing.T) {
+	v := &volatilitySpread{
+		feed:       makeVolatilityFeed(&sequenceFeed{prices: []float64{100, 110, 99, 108.9}}, time.Minute),
+		multiplier: 10,
+		maxFactor:  2,
+	}
+	assert.Equal(t, 1.0, v.factor())
+
+	for i := 0; i < 4; i++ {
+		_, e := v.feed.GetPrice()
+		if !assert.NoError(t, e) {
+			return
+		}
+	}
+	// 1 + 10 * ~0.115 is capped at 2
+	assert.Equal(t, 2.0, v.factor())
+
+	v.maxFactor = 0
+	volatility, _ := v.feed.GetVolatility()
+	assert.InDelta(t, 1+10*volatility, v.factor(), 1e-9)
+}
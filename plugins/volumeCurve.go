@@ -0,0 +1,59 @@
+package plugins
+
+import (
+	"fmt"
+	"math"
+)
+
+// volumeCurve shapes the volume of each level relative to the volume of the same level in the source orderbook
+type volumeCurve struct {
+	curveType string
+	lambda    float64
+}
+
+// constants for the supported types of volumeCurve
+const (
+	volumeCurveFlat        = "flat"
+	volumeCurveLinear      = "linear"
+	volumeCurveExponential = "exponential"
+)
+
+// makeVolumeCurve is a factory method, an empty curveType is treated as a flat curve. numLevels is the maximum number of levels that the curve
+// is applied to, a linear curve needs to keep the volume of the deepest level positive.
+func makeVolumeCurve(curveType string, lambda float64, numLevels int32) (*volumeCurve, error) {
+	switch curveType {
+	case "":
+		curveType = volumeCurveFlat
+	case volumeCurveFlat, volumeCurveExponential:
+	case volumeCurveLinear:
+		if numLevels > 1 && 1+lambda*float64(numLevels-1) <= 0 {
+			return nil, fmt.Errorf("lambda for the '%s' volume curve needs to be > %f to keep the volume of all %d levels positive, was %f",
+				curveType, -1/float64(numLevels-1), numLevels, lambda)
+		}
+	default:
+		return nil, fmt.Errorf("unrecognized volume curve type '%s', needs to be one of: %s, %s, %s", curveType, volumeCurveFlat, volumeCurveLinear, volumeCurveExponential)
+	}
+
+	return &volumeCurve{
+		curveType: curveType,
+		lambda:    lambda,
+	}, nil
+}
+
+// factor returns the multiplier for the volume at the level with the given index, levelIndex 0 is the top of the book.
+// A positive lambda makes deeper levels thicker than the top of the book and a negative lambda makes them thinner.
+func (c *volumeCurve) factor(levelIndex int) float64 {
+	switch c.curveType {
+	case volumeCurveLinear:
+		return 1 + c.lambda*float64(levelIndex)
+	case volumeCurveExponential:
+		return math.Exp(c.lambda * float64(levelIndex))
+	default:
+		return 1.0
+	}
+}
+
+// String is the stringer function
+func (c *volumeCurve) String() string {
+	return fmt.Sprintf("volumeCurve[type=%s, lambda=%f]", c.curveType, c.lambda)
+}
@@ -0,0 +1,88 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeVolumeCurve(t *testing.T) {
+	testCases := []struct {
+		curveType string
+		lambda    float64
+		numLevels int32
+		wantType  string
+		wantErr   bool
+	}{
+		{curveType: "", lambda: 0, numLevels: 10, wantType: volumeCurveFlat},
+		{curveType: volumeCurveExponential, lambda: -5, numLevels: 10, wantType: volumeCurveExponential},
+		{curveType: volumeCurveLinear, lambda: 0.5, numLevels: 10, wantType: volumeCurveLinear},
+		{curveType: volumeCurveLinear, lambda: -0.1, numLevels: 10, wantType: volumeCurveLinear},
+		// the 10th level would have a volume of 0
+		{curveType: volumeCurveLinear, lambda: -1.0 / 9, numLevels: 10, wantErr: true},
+		{curveType: volumeCurveLinear, lambda: -0.5, numLevels: 10, wantErr: true},
+		{curveType: volumeCurveLinear, lambda: -0.5, numLevels: 2, wantType: volumeCurveLinear},
+		{curveType: volumeCurveLinear, lambda: -5, numLevels: 1, wantType: volumeCurveLinear},
+		{curveType: "quadratic", lambda: 1, numLevels: 10, wantErr: true},
+	}
+
+	for _, kase := range testCases {
+		c, e := makeVolumeCurve(kase.curveType, kase.lambda, kase.numLevels)
+		if kase.wantErr {
+			assert.Error(t, e, "curveType=%s, lambda=%f, numLevels=%d", kase.curveType, kase.lambda, kase.numLevels)
+			continue
+		}
+		if !assert.NoError(t, e) {
+			continue
+		}
+		assert.Equal(t, kase.wantType, c.curveType)
+	}
+}
+
+func TestVolumeCurveFactor(t *testing.T) {
+	testCases := []struct {
+		name        string
+		curveType   string
+		lambda      float64
+		wantFactors []float64
+	}{
+		{
+			name:        "flat",
+			curveType:   volumeCurveFlat,
+			lambda:      0.5,
+			wantFactors: []float64{1, 1, 1, 1},
+		}, {
+			name:        "linear thicker",
+			curveType:   volumeCurveLinear,
+			lambda:      0.5,
+			wantFactors: []float64{1, 1.5, 2, 2.5},
+		}, {
+			name:        "linear thinner",
+			curveType:   volumeCurveLinear,
+			lambda:      -0.25,
+			wantFactors: []float64{1, 0.75, 0.5, 0.25},
+		}, {
+			name:        "exponential thicker",
+			curveType:   volumeCurveExponential,
+			lambda:      0.5,
+			wantFactors: []float64{1, 1.6487212707, 2.7182818285, 4.4816890703},
+		}, {
+			name:        "exponential thinner",
+			curveType:   volumeCurveExponential,
+			lambda:      -1,
+			wantFactors: []float64{1, 0.3678794412, 0.1353352832, 0.0497870684},
+		},
+	}
+
+	for _, kase := range testCases {
+		t.Run(kase.name, func(t *testing.T) {
+			c, e := makeVolumeCurve(kase.curveType, kase.lambda, int32(len(kase.wantFactors)))
+			if !assert.NoError(t, e) {
+				return
+			}
+			for i, want := range kase.wantFactors {
+				assert.InDelta(t, want, c.factor(i), 1e-9, "levelIndex=%d", i)
+			}
+		})
+	}
+}
@@ -0,0 +1,392 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/build"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/xdr"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// VolumeLimit caps the volume that is traded on one or both sides of the pair in a rolling window of time, caps of 0 are not enforced
+type VolumeLimit struct {
+	Window   time.Duration
+	Action   *model.OrderAction // nil limits the volume traded on both sides
+	BaseCap  float64
+	QuoteCap float64
+}
+
+// ParseVolumeLimit parses a volume limit, the window is a duration such as "24h" or a number of days such as "7d" and the action is
+// "buy", "sell" or "both" (default)
+func ParseVolumeLimit(window string, action string, baseCap float64, quoteCap float64) (VolumeLimit, error) {
+	var d time.Duration
+	if strings.HasSuffix(window, "d") {
+		days, e := strconv.ParseFloat(strings.TrimSuffix(window, "d"), 64)
+		if e != nil {
+			return VolumeLimit{}, fmt.Errorf("invalid window '%s': %s", window, e)
+		}
+		d = time.Duration(days * float64(24*time.Hour))
+	} else {
+		var e error
+		d, e = time.ParseDuration(window)
+		if e != nil {
+			return VolumeLimit{}, fmt.Errorf("invalid window '%s': %s", window, e)
+		}
+	}
+	if d <= 0 {
+		return VolumeLimit{}, fmt.Errorf("window needs to be positive: %s", window)
+	}
+
+	var a *model.OrderAction
+	switch action {
+	case "", "both":
+	case "buy", "sell":
+		oa := model.OrderActionFromString(action)
+		a = &oa
+	default:
+		return VolumeLimit{}, fmt.Errorf("invalid action '%s', needs to be one of 'buy', 'sell' or 'both'", action)
+	}
+
+	if baseCap < 0 || quoteCap < 0 {
+		return VolumeLimit{}, fmt.Errorf("caps cannot be negative: baseCap=%f, quoteCap=%f", baseCap, quoteCap)
+	}
+	if baseCap == 0 && quoteCap == 0 {
+		return VolumeLimit{}, fmt.Errorf("need to set a base cap or a quote cap for the %s window", window)
+	}
+
+	return VolumeLimit{
+		Window:   d,
+		Action:   a,
+		BaseCap:  baseCap,
+		QuoteCap: quoteCap,
+	}, nil
+}
+
+func (l VolumeLimit) appliesTo(action model.OrderAction) bool {
+	return l.Action == nil || *l.Action == action
+}
+
+// String is the stringer function
+func (l VolumeLimit) String() string {
+	action := "both"
+	if l.Action != nil {
+		action = l.Action.String()
+	}
+	return fmt.Sprintf("VolumeLimit[window=%s, action=%s, baseCap=%f, quoteCap=%f]", l.Window, action, l.BaseCap, l.QuoteCap)
+}
+
+// MaxVolumeWindow returns the longest window of the limits, which is how far back the fills of the bot need to be tracked
+func MaxVolumeWindow(limits []VolumeLimit) time.Duration {
+	var maxWindow time.Duration
+	for _, l := range limits {
+		if l.Window > maxWindow {
+			maxWindow = l.Window
+		}
+	}
+	return maxWindow
+}
+
+// volumeFill is the volume of a fill of the bot
+type volumeFill struct {
+	id     string // empty when the trade has no ID
+	time   time.Time
+	action model.OrderAction
+	base   float64
+	quote  float64
+}
+
+// volumeLimitFilter caps the amounts of offers so the volume filled in each window, plus the amounts of the offers that are open, does
+// not exceed the limits. It counts the amounts that were actually filled, which it receives from the fill tracker.
+type volumeLimitFilter struct {
+	limits     []VolumeLimit
+	maxWindow  time.Duration
+	baseAsset  hProtocol.Asset
+	quoteAsset hProtocol.Asset
+	now        func() time.Time
+
+	// uninitialized
+	mutex   *sync.Mutex
+	fills   []volumeFill
+	fillIDs map[string]bool
+}
+
+var _ SubmitFilter = &volumeLimitFilter{}
+var _ api.FillHandler = &volumeLimitFilter{}
+
+// MakeFilterVolumeLimit makes a submit filter that limits the volume traded in rolling windows, returns nil when there are no limits
+func MakeFilterVolumeLimit(limits []VolumeLimit, baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset) SubmitFilter {
+	if len(limits) == 0 {
+		return nil
+	}
+	return makeVolumeLimitFilter(limits, baseAsset, quoteAsset, time.Now)
+}
+
+func makeVolumeLimitFilter(limits []VolumeLimit, baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset, now func() time.Time) *volumeLimitFilter {
+	return &volumeLimitFilter{
+		limits:     limits,
+		maxWindow:  MaxVolumeWindow(limits),
+		baseAsset:  baseAsset,
+		quoteAsset: quoteAsset,
+		now:        now,
+		mutex:      &sync.Mutex{},
+		fills:      []volumeFill{},
+		fillIDs:    map[string]bool{},
+	}
+}
+
+// HandleFill impl, it is also used to load the fills from before the bot started
+func (f *volumeLimitFilter) HandleFill(trade model.Trade) error {
+	if trade.Timestamp == nil || trade.Volume == nil {
+		return nil
+	}
+	fillTime := time.Unix(0, trade.Timestamp.AsInt64()*int64(time.Millisecond))
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if !fillTime.After(f.now().Add(-f.maxWindow)) {
+		return nil
+	}
+	id := ""
+	if trade.TransactionID != nil {
+		id = trade.TransactionID.String()
+		if f.fillIDs[id] {
+			return nil
+		}
+		f.fillIDs[id] = true
+	}
+
+	quote := 0.0
+	if trade.Cost != nil {
+		quote = trade.Cost.AsFloat()
+	} else if trade.Price != nil {
+		quote = trade.Volume.AsFloat() * trade.Price.AsFloat()
+	}
+	f.fills = append(f.fills, volumeFill{
+		id:     id,
+		time:   fillTime,
+		action: trade.OrderAction,
+		base:   trade.Volume.AsFloat(),
+		quote:  quote,
+	})
+	return nil
+}
+
+// prune drops the fills that are outside every window, needs to be called with the lock held
+func (f *volumeLimitFilter) prune(now time.Time) {
+	cutoff := now.Add(-f.maxWindow)
+	fills := []volumeFill{}
+	fillIDs := map[string]bool{}
+	for _, fill := range f.fills {
+		if fill.time.After(cutoff) {
+			fills = append(fills, fill)
+			if fill.id != "" {
+				fillIDs[fill.id] = true
+			}
+		}
+	}
+	f.fills = fills
+	f.fillIDs = fillIDs
+}
+
+// volumeUsage is the base and quote volume counted against each limit, in the same order as the limits
+type volumeUsage []struct {
+	base  float64
+	quote float64
+}
+
+// usage returns the volume filled in the window of each limit, needs to be called with the lock held
+func (f *volumeLimitFilter) usage(now time.Time) volumeUsage {
+	u := make(volumeUsage, len(f.limits))
+	for i, l := range f.limits {
+		cutoff := now.Add(-l.Window)
+		for _, fill := range f.fills {
+			if fill.time.After(cutoff) && l.appliesTo(fill.action) {
+				u[i].base += fill.base
+				u[i].quote += fill.quote
+			}
+		}
+	}
+	return u
+}
+
+// add counts the base volume traded on the side at the price (quote per base) against the limits that apply to the side
+func (u volumeUsage) add(limits []VolumeLimit, action model.OrderAction, base float64, price float64) {
+	for i, l := range limits {
+		if l.appliesTo(action) {
+			u[i].base += base
+			u[i].quote += base * price
+		}
+	}
+}
+
+// remainingBase returns the base volume that can still be traded on the side at the price (quote per base) without exceeding any limit
+func (u volumeUsage) remainingBase(limits []VolumeLimit, action model.OrderAction, price float64) float64 {
+	remaining := math.Inf(1)
+	for i, l := range limits {
+		if !l.appliesTo(action) {
+			continue
+		}
+		if l.BaseCap > 0 {
+			remaining = math.Min(remaining, l.BaseCap-u[i].base)
+		}
+		if l.QuoteCap > 0 && price > 0 {
+			remaining = math.Min(remaining, (l.QuoteCap-u[i].quote)/price)
+		}
+	}
+	return math.Max(remaining, 0)
+}
+
+// Apply impl.
+func (f *volumeLimitFilter) Apply(
+	ops []build.TransactionMutator,
+	sellingOffers []hProtocol.Offer,
+	buyingOffers []hProtocol.Offer,
+) ([]build.TransactionMutator, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	now := f.now()
+	f.prune(now)
+	// the volume that was filled plus the amounts of the offers that can still be filled
+	u := f.usage(now)
+
+	// the offers that are not updated in this cycle can still be filled so their amounts count against the limits
+	updatedOfferIDs := map[int64]bool{}
+	for _, op := range ops {
+		switch o := op.(type) {
+		case *build.ManageOfferBuilder:
+			updatedOfferIDs[int64(o.MO.OfferId)] = true
+		case build.ManageOfferBuilder:
+			updatedOfferIDs[int64(o.MO.OfferId)] = true
+		case *ManageBuyOfferBuilder:
+			updatedOfferIDs[int64(o.MBO.OfferId)] = true
+		}
+	}
+	for _, o := range sellingOffers {
+		if updatedOfferIDs[o.ID] {
+			continue
+		}
+		amount, e := strconv.ParseFloat(o.Amount, 64)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse amount of offer %d: %s", o.ID, e)
+		}
+		u.add(f.limits, model.OrderActionSell, amount, float64(o.PriceR.N)/float64(o.PriceR.D))
+	}
+	for _, o := range buyingOffers {
+		if updatedOfferIDs[o.ID] {
+			continue
+		}
+		amount, e := strconv.ParseFloat(o.Amount, 64)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse amount of offer %d: %s", o.ID, e)
+		}
+		// buying offers sell the quote asset at a price of base per quote
+		u.add(f.limits, model.OrderActionBuy, amount*float64(o.PriceR.N)/float64(o.PriceR.D), float64(o.PriceR.D)/float64(o.PriceR.N))
+	}
+
+	numKeep := 0
+	numCapped := 0
+	numDropped := 0
+	filteredOps := []build.TransactionMutator{}
+	for _, op := range ops {
+		var mo *build.ManageOfferBuilder
+		switch o := op.(type) {
+		case *build.ManageOfferBuilder:
+			mo = o
+		case build.ManageOfferBuilder:
+			mo = &o
+		case *ManageBuyOfferBuilder:
+			// delete operations should never be dropped
+			if o.MBO.BuyAmount == 0 {
+				filteredOps = append(filteredOps, o)
+				continue
+			}
+			available := u.remainingBase(f.limits, model.OrderActionBuy, o.BuyPrice())
+			if available >= o.BuyAmount() {
+				u.add(f.limits, model.OrderActionBuy, o.BuyAmount(), o.BuyPrice())
+				filteredOps = append(filteredOps, o)
+				numKeep++
+				continue
+			}
+			cappedAmount := xdr.Int64(math.Floor(available * math.Pow(10, 7)))
+			if cappedAmount <= 0 {
+				numDropped++
+				if droppedOp := o.dropped(); droppedOp != nil {
+					filteredOps = append(filteredOps, droppedOp)
+				}
+				continue
+			}
+			opCopy := *o
+			opCopy.MBO.BuyAmount = cappedAmount
+			u.add(f.limits, model.OrderActionBuy, opCopy.BuyAmount(), opCopy.BuyPrice())
+			filteredOps = append(filteredOps, &opCopy)
+			numCapped++
+			continue
+		default:
+			filteredOps = append(filteredOps, op)
+			continue
+		}
+
+		// delete operations should never be dropped
+		if mo.MO.Amount == 0 {
+			filteredOps = append(filteredOps, mo)
+			continue
+		}
+
+		isSell, e := utils.IsSelling(f.baseAsset, f.quoteAsset, mo.MO.Selling, mo.MO.Buying)
+		if e != nil {
+			return nil, fmt.Errorf("error when running the isSelling check: %s", e)
+		}
+		action := model.OrderActionBuy
+		if isSell {
+			action = model.OrderActionSell
+		}
+		// amounts are in units of the selling asset and prices in units of the buying asset
+		amount := float64(mo.MO.Amount) / math.Pow(10, 7)
+		opPrice := float64(mo.MO.Price.N) / float64(mo.MO.Price.D)
+		baseAmount, price := amount, opPrice
+		if !isSell {
+			baseAmount, price = amount*opPrice, 1/opPrice
+		}
+
+		available := u.remainingBase(f.limits, action, price)
+		if available >= baseAmount {
+			u.add(f.limits, action, baseAmount, price)
+			filteredOps = append(filteredOps, mo)
+			numKeep++
+			continue
+		}
+
+		cappedAmount := available
+		if !isSell {
+			cappedAmount = available * price
+		}
+		cappedAmountRaw := xdr.Int64(math.Floor(cappedAmount * math.Pow(10, 7)))
+		if cappedAmountRaw <= 0 {
+			numDropped++
+			if mo.MO.OfferId != 0 {
+				// modify offers should be converted to delete offers, new offers can be dropped
+				opCopy := *mo
+				opCopy.MO.Amount = 0
+				filteredOps = append(filteredOps, &opCopy)
+			}
+			continue
+		}
+		opCopy := *mo
+		opCopy.MO.Amount = cappedAmountRaw
+		u.add(f.limits, action, available, price)
+		filteredOps = append(filteredOps, &opCopy)
+		numCapped++
+	}
+
+	log.Printf("volumeLimitFilter: kept %d, capped %d, dropped %d ops from original %d ops\n", numKeep, numCapped, numDropped, len(ops))
+	return filteredOps, nil
+}
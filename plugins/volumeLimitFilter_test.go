@@ -0,0 +1,159 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stellar/go/build"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/xdr"
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseVolumeLimit(t *testing.T) {
+	sell := model.OrderActionSell
+	testCases := []struct {
+		window   string
+		action   string
+		baseCap  float64
+		quoteCap float64
+		wantErr  bool
+		want     VolumeLimit
+	}{
+		{window: "24h", action: "", baseCap: 100, want: VolumeLimit{Window: 24 * time.Hour, BaseCap: 100}},
+		{window: "7d", action: "both", quoteCap: 50, want: VolumeLimit{Window: 7 * 24 * time.Hour, QuoteCap: 50}},
+		{window: "1.5d", action: "sell", baseCap: 1, quoteCap: 2, want: VolumeLimit{Window: 36 * time.Hour, Action: &sell, BaseCap: 1, QuoteCap: 2}},
+		{window: "24h", action: "hold", baseCap: 100, wantErr: true},
+		{window: "7 days", action: "", baseCap: 100, wantErr: true},
+		{window: "0h", action: "", baseCap: 100, wantErr: true},
+		{window: "24h", action: "", wantErr: true},
+		{window: "24h", action: "", baseCap: -1, quoteCap: 5, wantErr: true},
+	}
+
+	for _, k := range testCases {
+		t.Run(fmt.Sprintf("%s/%s/%v/%v", k.window, k.action, k.baseCap, k.quoteCap), func(t *testing.T) {
+			limit, e := ParseVolumeLimit(k.window, k.action, k.baseCap, k.quoteCap)
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.want, limit)
+		})
+	}
+}
+
+func makeTestVolumeFill(id string, ts time.Time, action model.OrderAction, base float64, price float64) model.Trade {
+	return model.Trade{
+		Order: model.Order{
+			OrderAction: action,
+			Price:       model.NumberFromFloat(price, 7),
+			Volume:      model.NumberFromFloat(base, 7),
+			Timestamp:   model.MakeTimestampFromTime(ts),
+		},
+		TransactionID: model.MakeTransactionID(id),
+	}
+}
+
+func TestVolumeLimitFilterRemaining(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	sell := model.OrderActionSell
+	f := makeVolumeLimitFilter([]VolumeLimit{
+		{Window: 24 * time.Hour, Action: &sell, BaseCap: 100},
+		{Window: 7 * 24 * time.Hour, QuoteCap: 1000},
+	}, hProtocol.Asset{Type: "native"}, hProtocol.Asset{Type: "credit_alphanum4", Code: "USD", Issuer: testIssuer}, func() time.Time { return now })
+
+	fills := []model.Trade{
+		makeTestVolumeFill("1", now.Add(-1*time.Hour), model.OrderActionSell, 30, 2),
+		// counted twice by the fill tracker and when loading the earlier fills
+		makeTestVolumeFill("1", now.Add(-1*time.Hour), model.OrderActionSell, 30, 2),
+		// outside the 24h window but inside the 7d window
+		makeTestVolumeFill("2", now.Add(-48*time.Hour), model.OrderActionSell, 50, 2),
+		makeTestVolumeFill("3", now.Add(-2*time.Hour), model.OrderActionBuy, 100, 2),
+		// outside every window
+		makeTestVolumeFill("4", now.Add(-8*24*time.Hour), model.OrderActionSell, 500, 2),
+	}
+	for _, fill := range fills {
+		if !assert.NoError(t, f.HandleFill(fill)) {
+			return
+		}
+	}
+	assert.Equal(t, 3, len(f.fills))
+
+	u := f.usage(now)
+	// sell: min(100 - 30 base, (1000 - 60 - 100 - 200) / 2 = 320 base)
+	assert.InDelta(t, 70.0, u.remainingBase(f.limits, model.OrderActionSell, 2), 0.0000001)
+	// buy: only the quote cap of the 7d window applies
+	assert.InDelta(t, 320.0, u.remainingBase(f.limits, model.OrderActionBuy, 2), 0.0000001)
+	// at a higher price fewer base units fit under the quote cap
+	assert.InDelta(t, 64.0, u.remainingBase(f.limits, model.OrderActionSell, 10), 0.0000001)
+
+	// buying counts against the 7d limit that applies to both sides
+	u.add(f.limits, model.OrderActionBuy, 20, 2)
+	assert.InDelta(t, 300.0, u.remainingBase(f.limits, model.OrderActionBuy, 2), 0.0000001)
+	assert.InDelta(t, 70.0, u.remainingBase(f.limits, model.OrderActionSell, 2), 0.0000001)
+
+	// the 1h old fill leaves the 24h window
+	later := now.Add(23*time.Hour + time.Minute)
+	assert.InDelta(t, 100.0, f.usage(later).remainingBase(f.limits, model.OrderActionSell, 2), 0.0000001)
+}
+
+func TestVolumeLimitFilterApply(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	baseAsset := hProtocol.Asset{Type: "native"}
+	quoteAsset := hProtocol.Asset{Type: "credit_alphanum4", Code: "USD", Issuer: testIssuer}
+	sell := model.OrderActionSell
+	buy := model.OrderActionBuy
+	f := makeVolumeLimitFilter([]VolumeLimit{
+		{Window: 24 * time.Hour, Action: &sell, BaseCap: 100},
+		{Window: 24 * time.Hour, Action: &buy, QuoteCap: 30},
+	}, baseAsset, quoteAsset, func() time.Time { return now })
+	if !assert.NoError(t, f.HandleFill(makeTestVolumeFill("1", now.Add(-time.Hour), model.OrderActionSell, 50, 2))) {
+		return
+	}
+
+	usd := build.CreditAsset("USD", testIssuer)
+	sellRate := build.Rate{Selling: build.NativeAsset(), Buying: usd, Price: build.Price("2")}
+	keep := build.CreateOffer(sellRate, build.Amount("20"))
+	capped := build.UpdateOffer(sellRate, build.Amount("30"), build.OfferID(11))
+	dropNew := build.CreateOffer(sellRate, build.Amount("5"))
+	dropExisting := build.UpdateOffer(sellRate, build.Amount("5"), build.OfferID(12))
+	deleteOp := build.DeleteOffer(sellRate, build.OfferID(13))
+	// buying 50 base for 0.5 quote each, selling 25 quote at a price of 2 base per quote
+	buyOp := build.CreateOffer(build.Rate{Selling: usd, Buying: build.NativeAsset(), Price: build.Price("2")}, build.Amount("25"))
+	nativeBuy := makeManageBuyOffer(usd, build.NativeAsset(), "0.5", "20", 0, "")
+
+	// offer 10 is not updated in this cycle so it takes up 10 base units of the sell capacity, offer 11 is updated by the capped op
+	sellingOffers := []hProtocol.Offer{
+		{ID: 10, Amount: "10.0000000", PriceR: hProtocol.Price{N: 2, D: 1}},
+		{ID: 11, Amount: "30.0000000", PriceR: hProtocol.Price{N: 2, D: 1}},
+	}
+	ops, e := f.Apply([]build.TransactionMutator{&keep, &capped, &dropNew, &dropExisting, &deleteOp, &buyOp, nativeBuy}, sellingOffers, []hProtocol.Offer{})
+	if !assert.NoError(t, e) {
+		return
+	}
+	if !assert.Equal(t, 6, len(ops)) {
+		return
+	}
+
+	// 100 - 50 filled - 10 open leaves 40 base units to sell, 20 of which are left for the capped op
+	assert.Equal(t, &keep, ops[0])
+	cappedOp := ops[1].(*build.ManageOfferBuilder)
+	assert.Equal(t, xdr.Int64(11), cappedOp.MO.OfferId)
+	assert.Equal(t, xdr.Int64(200000000), cappedOp.MO.Amount)
+	droppedOp := ops[2].(*build.ManageOfferBuilder)
+	assert.Equal(t, xdr.Int64(12), droppedOp.MO.OfferId)
+	assert.Equal(t, xdr.Int64(0), droppedOp.MO.Amount)
+	assert.Equal(t, &deleteOp, ops[3])
+	// the buy offer spends 25 of the 30 quote units, which leaves 10 base units at a price of 0.5 for the native buy offer
+	assert.Equal(t, &buyOp, ops[4])
+	cappedBuy := ops[5].(*ManageBuyOfferBuilder)
+	assert.Equal(t, xdr.Int64(100000000), cappedBuy.MBO.BuyAmount)
+	// the original ops are unchanged
+	assert.Equal(t, xdr.Int64(300000000), capped.MO.Amount)
+	assert.Equal(t, xdr.Int64(200000000), nativeBuy.MBO.BuyAmount)
+}
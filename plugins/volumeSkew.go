@@ -0,0 +1,85 @@
+package plugins
+
+import (
+	"fmt"
+	"math"
+)
+
+// defaultSkewTargetBaseRatio keeps the value of the inventory evenly split between the base and quote assets
+const defaultSkewTargetBaseRatio = 0.5
+
+// volumeSkew scales the volume of the bids and asks we place so the inventory drifts back towards a target instead of blindly mirroring the
+// source orderbook. The fixed skews always apply, and in auto mode the volumes are additionally skewed by how far the share of the inventory
+// held in the base asset is from the target: holding too much base shrinks the bids and grows the asks, and vice versa.
+type volumeSkew struct {
+	bidSkew         float64
+	askSkew         float64
+	auto            bool
+	targetBaseRatio float64
+	maxAutoSkew     float64
+}
+
+// makeVolumeSkew is a factory method, nil skews default to 1.0 (no skew) and a nil targetBaseRatio defaults to an even split
+func makeVolumeSkew(bidSkew *float64, askSkew *float64, auto bool, targetBaseRatio *float64, maxAutoSkew float64) (*volumeSkew, error) {
+	v := &volumeSkew{
+		bidSkew:         1.0,
+		askSkew:         1.0,
+		auto:            auto,
+		targetBaseRatio: defaultSkewTargetBaseRatio,
+		maxAutoSkew:     maxAutoSkew,
+	}
+	if bidSkew != nil {
+		if *bidSkew < 0 {
+			return nil, fmt.Errorf("BID_VOLUME_SKEW needs to be non-negative, was %f", *bidSkew)
+		}
+		v.bidSkew = *bidSkew
+	}
+	if askSkew != nil {
+		if *askSkew < 0 {
+			return nil, fmt.Errorf("ASK_VOLUME_SKEW needs to be non-negative, was %f", *askSkew)
+		}
+		v.askSkew = *askSkew
+	}
+	if !auto {
+		return v, nil
+	}
+
+	if targetBaseRatio != nil {
+		if *targetBaseRatio <= 0 || *targetBaseRatio >= 1 {
+			return nil, fmt.Errorf("VOLUME_SKEW_TARGET_BASE_RATIO needs to be in the range (0, 1), was %f", *targetBaseRatio)
+		}
+		v.targetBaseRatio = *targetBaseRatio
+	}
+	if maxAutoSkew <= 0 || maxAutoSkew > 1 {
+		return nil, fmt.Errorf("VOLUME_SKEW_MAX needs to be in the range (0, 1] when VOLUME_SKEW_AUTO is enabled, was %f", maxAutoSkew)
+	}
+	return v, nil
+}
+
+// factors returns the multipliers for the volume of the bids and the asks given the balances of the account and the mid price, which is
+// used to value the base asset in units of the quote asset. Auto skew is not applied when the inventory cannot be valued.
+func (v *volumeSkew) factors(baseBalance float64, quoteBalance float64, midPrice float64) (bidFactor float64, askFactor float64) {
+	bidFactor = v.bidSkew
+	askFactor = v.askSkew
+	if !v.auto {
+		return bidFactor, askFactor
+	}
+
+	baseValue := baseBalance * midPrice
+	totalValue := baseValue + quoteBalance
+	if midPrice <= 0 || totalValue <= 0 {
+		return bidFactor, askFactor
+	}
+
+	// deviation is -1 when holding only quote and +1 when holding only base, regardless of the target
+	deviation := (baseValue/totalValue - v.targetBaseRatio) / math.Max(v.targetBaseRatio, 1-v.targetBaseRatio)
+	deviation = math.Max(-1, math.Min(1, deviation))
+	bidFactor *= 1 - v.maxAutoSkew*deviation
+	askFactor *= 1 + v.maxAutoSkew*deviation
+	return bidFactor, askFactor
+}
+
+// String is the stringer function
+func (v *volumeSkew) String() string {
+	return fmt.Sprintf("volumeSkew[bidSkew=%f, askSkew=%f, auto=%v, targetBaseRatio=%f, maxAutoSkew=%f]", v.bidSkew, v.askSkew, v.auto, v.targetBaseRatio, v.maxAutoSkew)
+}
@@ -0,0 +1,107 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVolumeSkewFactors(t *testing.T) {
+	half := 0.5
+	quarter := 0.25
+	testCases := []struct {
+		name            string
+		bidSkew         *float64
+		targetBaseRatio *float64
+		auto            bool
+		baseBalance     float64
+		quoteBalance    float64
+		midPrice        float64
+		wantBid         float64
+		wantAsk         float64
+	}{
+		{
+			name:         "no skew",
+			baseBalance:  100,
+			quoteBalance: 0,
+			midPrice:     1.0,
+			wantBid:      1.0,
+			wantAsk:      1.0,
+		}, {
+			name:         "fixed skew",
+			bidSkew:      &half,
+			baseBalance:  100,
+			quoteBalance: 0,
+			midPrice:     1.0,
+			wantBid:      0.5,
+			wantAsk:      1.0,
+		}, {
+			name:         "auto at target",
+			auto:         true,
+			baseBalance:  50,
+			quoteBalance: 100,
+			midPrice:     2.0,
+			wantBid:      1.0,
+			wantAsk:      1.0,
+		}, {
+			name:         "auto with too much base",
+			auto:         true,
+			baseBalance:  75,
+			quoteBalance: 25,
+			midPrice:     1.0,
+			wantBid:      0.75,
+			wantAsk:      1.25,
+		}, {
+			name:         "auto with only quote",
+			auto:         true,
+			baseBalance:  0,
+			quoteBalance: 100,
+			midPrice:     1.0,
+			wantBid:      1.5,
+			wantAsk:      0.5,
+		}, {
+			name:            "auto with a target and fixed skew",
+			auto:            true,
+			bidSkew:         &half,
+			targetBaseRatio: &quarter,
+			baseBalance:     100,
+			quoteBalance:    0,
+			midPrice:        1.0,
+			wantBid:         0.25,
+			wantAsk:         1.5,
+		}, {
+			name:         "auto without a mid price",
+			auto:         true,
+			baseBalance:  100,
+			quoteBalance: 0,
+			midPrice:     0,
+			wantBid:      1.0,
+			wantAsk:      1.0,
+		},
+	}
+
+	for _, kase := range testCases {
+		t.Run(kase.name, func(t *testing.T) {
+			v, e := makeVolumeSkew(kase.bidSkew, nil, kase.auto, kase.targetBaseRatio, 0.5)
+			if !assert.NoError(t, e) {
+				return
+			}
+			bid, ask := v.factors(kase.baseBalance, kase.quoteBalance, kase.midPrice)
+			assert.InDelta(t, kase.wantBid, bid, 0.0000001)
+			assert.InDelta(t, kase.wantAsk, ask, 0.0000001)
+		})
+	}
+}
+
+func TestMakeVolumeSkewValidation(t *testing.T) {
+	negative := -1.0
+	one := 1.0
+	_, e := makeVolumeSkew(&negative, nil, false, nil, 0)
+	assert.Error(t, e)
+	_, e = makeVolumeSkew(nil, nil, true, nil, 0)
+	assert.Error(t, e)
+	_, e = makeVolumeSkew(nil, nil, true, &one, 0.5)
+	assert.Error(t, e)
+	_, e = makeVolumeSkew(nil, nil, false, nil, 0)
+	assert.NoError(t, e)
+}
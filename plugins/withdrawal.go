@@ -0,0 +1,38 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// WaitForWithdrawal polls the exchange until the withdrawal is complete or failed, it returns an error when the status cannot be fetched
+// or the withdrawal is still pending after the timeout
+func WaitForWithdrawal(
+	exchange api.WithdrawalExchange,
+	asset model.Asset,
+	withdrawalID string,
+	pollInterval time.Duration,
+	timeout time.Duration,
+) (*api.Withdrawal, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		w, e := exchange.GetWithdrawal(asset, withdrawalID)
+		if e != nil {
+			return nil, fmt.Errorf("unable to fetch status of withdrawal '%s': %s", withdrawalID, e)
+		}
+		if w.Status.IsFinal() {
+			log.Printf("withdrawal '%s' of %s is %s (txID=%s)\n", withdrawalID, asset, w.Status, w.TxID)
+			return w, nil
+		}
+
+		if !time.Now().Add(pollInterval).Before(deadline) {
+			return w, fmt.Errorf("withdrawal '%s' of %s is still %s after %s", withdrawalID, asset, w.Status, timeout)
+		}
+		log.Printf("withdrawal '%s' of %s is %s, checking again in %s\n", withdrawalID, asset, w.Status, pollInterval)
+		time.Sleep(pollInterval)
+	}
+}
@@ -0,0 +1,78 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// statusSequenceExchange reports the statuses in order, one per call, and keeps reporting the last status
+type statusSequenceExchange struct {
+	statuses []api.WithdrawalStatus
+	numCalls int
+}
+
+func (x *statusSequenceExchange) Withdraw(asset model.Asset, amount *model.Number, address string, memo string) (*api.Withdrawal, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (x *statusSequenceExchange) GetWithdrawal(asset model.Asset, withdrawalID string) (*api.Withdrawal, error) {
+	i := x.numCalls
+	if i >= len(x.statuses) {
+		i = len(x.statuses) - 1
+	}
+	x.numCalls++
+	return &api.Withdrawal{ID: withdrawalID, Asset: asset, Status: x.statuses[i]}, nil
+}
+
+func TestWaitForWithdrawal(t *testing.T) {
+	testCases := []struct {
+		name         string
+		statuses     []api.WithdrawalStatus
+		timeout      time.Duration
+		wantStatus   api.WithdrawalStatus
+		wantNumCalls int
+		wantErrorSet bool
+	}{
+		{
+			name:         "completes",
+			statuses:     []api.WithdrawalStatus{api.WithdrawalStatusPending, api.WithdrawalStatusPending, api.WithdrawalStatusComplete},
+			timeout:      time.Second,
+			wantStatus:   api.WithdrawalStatusComplete,
+			wantNumCalls: 3,
+		}, {
+			name:         "fails",
+			statuses:     []api.WithdrawalStatus{api.WithdrawalStatusFailed},
+			timeout:      time.Second,
+			wantStatus:   api.WithdrawalStatusFailed,
+			wantNumCalls: 1,
+		}, {
+			name:         "times out",
+			statuses:     []api.WithdrawalStatus{api.WithdrawalStatusPending},
+			timeout:      0,
+			wantStatus:   api.WithdrawalStatusPending,
+			wantNumCalls: 1,
+			wantErrorSet: true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			x := &statusSequenceExchange{statuses: k.statuses}
+			w, e := WaitForWithdrawal(x, model.XLM, "id1", time.Millisecond, k.timeout)
+			if k.wantErrorSet {
+				assert.Error(t, e)
+			} else {
+				assert.NoError(t, e)
+			}
+			if assert.NotNil(t, w) {
+				assert.Equal(t, k.wantStatus, w.Status)
+			}
+			assert.Equal(t, k.wantNumCalls, x.numCalls)
+		})
+	}
+}
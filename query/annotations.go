@@ -0,0 +1,89 @@
+package query
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/stellar/kelp/api"
+)
+
+// annotationMaxAge is how long an annotation remains visible after it was last emitted
+const annotationMaxAge = 10 * time.Minute
+
+// maxAnnotations is the maximum number of annotations returned, the most recently emitted annotations are kept
+const maxAnnotations = 20
+
+// annotationsOutput is the response from the getAnnotations IPC request
+type annotationsOutput struct {
+	Annotations []api.Annotation `json:"annotations"`
+}
+
+// AnnotationStore aggregates the annotations emitted by a strategy so that repeated annotations are shown once with a count
+type AnnotationStore struct {
+	mutex       *sync.Mutex
+	annotations map[string]*api.Annotation
+	now         func() time.Time
+}
+
+// ensure it implements Annotator
+var _ api.Annotator = &AnnotationStore{}
+
+// MakeAnnotationStore is a factory method
+func MakeAnnotationStore() *AnnotationStore {
+	return &AnnotationStore{
+		mutex:       &sync.Mutex{},
+		annotations: map[string]*api.Annotation{},
+		now:         time.Now,
+	}
+}
+
+// Annotate impl
+func (s *AnnotationStore) Annotate(level api.AnnotationLevel, source string, message string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	nowMillis := s.now().UnixNano() / int64(time.Millisecond)
+	key := string(level) + "|" + source + "|" + message
+	if a, ok := s.annotations[key]; ok {
+		a.Count++
+		a.LastSeenMillis = nowMillis
+		return
+	}
+
+	s.annotations[key] = &api.Annotation{
+		Level:           level,
+		Source:          source,
+		Message:         message,
+		Count:           1,
+		FirstSeenMillis: nowMillis,
+		LastSeenMillis:  nowMillis,
+	}
+}
+
+// recent evicts the annotations that are older than annotationMaxAge and returns the rest, most recently emitted first
+func (s *AnnotationStore) recent() []api.Annotation {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	cutoffMillis := s.now().Add(-annotationMaxAge).UnixNano() / int64(time.Millisecond)
+	annotations := []api.Annotation{}
+	for key, a := range s.annotations {
+		if a.LastSeenMillis < cutoffMillis {
+			delete(s.annotations, key)
+			continue
+		}
+		annotations = append(annotations, *a)
+	}
+
+	sort.Slice(annotations, func(i int, j int) bool {
+		if annotations[i].LastSeenMillis != annotations[j].LastSeenMillis {
+			return annotations[i].LastSeenMillis > annotations[j].LastSeenMillis
+		}
+		return annotations[i].Message < annotations[j].Message
+	})
+	if len(annotations) > maxAnnotations {
+		annotations = annotations[:maxAnnotations]
+	}
+	return annotations
+}
@@ -0,0 +1,21 @@
+package query
+
+import (
+	"fmt"
+
+	"github.com/stellar/kelp/plugins"
+)
+
+// getCapacity returns the capacity report of the assets of the trading pair for the getCapacity IPC request
+func (s *Server) getCapacity() (*plugins.CapacityReport, error) {
+	assetBase, assetQuote, e := s.sdex.Assets()
+	if e != nil {
+		return nil, fmt.Errorf("error getting assets from sdex: %s", e)
+	}
+
+	report, e := s.sdex.IEIF().CapacityReport(assetBase, assetQuote, s.sdex.ComputeIncrementalNativeAmountRaw(true))
+	if e != nil {
+		return nil, fmt.Errorf("error computing capacity report: %s", e)
+	}
+	return report, nil
+}
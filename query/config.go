@@ -0,0 +1,120 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// redactedValue replaces the value of secret fields in the output of the getConfig IPC request
+const redactedValue = "<redacted>"
+
+// secretConfigKeys are the config fields that are always redacted, any field containing one of secretConfigKeySubstrings is redacted as well
+var secretConfigKeys = map[string]bool{
+	"EXCHANGE_API_KEYS":        true,
+	"EXCHANGE_PARAMS":          true,
+	"EXCHANGE_HEADERS":         true,
+	"MONITORING_TLS_KEY":       true,
+	"ACCEPTABLE_GOOGLE_EMAILS": true,
+}
+var secretConfigKeySubstrings = []string{"SECRET", "API_KEY", "PASSWORD", "TOKEN"}
+
+// configOutput is the response from the getConfig IPC request, secrets are redacted and secret seeds are converted to their public keys
+type configOutput struct {
+	Strategy       string                 `json:"strategy"`
+	TradingAccount string                 `json:"trading_account"`
+	TraderConfig   map[string]interface{} `json:"trader_config"`
+	StrategyConfig map[string]interface{} `json:"strategy_config"` // nil if the strategy does not use a config file
+}
+
+func (s *Server) getConfig() (*configOutput, error) {
+	// the json keys of the BotConfig match the toml keys in lower case
+	botConfigBytes, e := json.Marshal(s.botConfig)
+	if e != nil {
+		return nil, fmt.Errorf("unable to marshal trader config: %s", e)
+	}
+	traderConfig := map[string]interface{}{}
+	e = json.Unmarshal(botConfigBytes, &traderConfig)
+	if e != nil {
+		return nil, fmt.Errorf("unable to unmarshal trader config: %s", e)
+	}
+
+	var strategyConfig map[string]interface{}
+	if s.stratConfigPath != "" {
+		strategyConfig = map[string]interface{}{}
+		_, e = toml.DecodeFile(s.stratConfigPath, &strategyConfig)
+		if e != nil {
+			return nil, fmt.Errorf("unable to read strategy config at path '%s': %s", s.stratConfigPath, e)
+		}
+	}
+
+	return &configOutput{
+		Strategy:       s.strategyName,
+		TradingAccount: s.botConfig.TradingAccount(),
+		TraderConfig:   redactConfig(traderConfig),
+		StrategyConfig: redactConfig(strategyConfig),
+	}, nil
+}
+
+// redactConfig redacts secrets in the config, including nested tables, and converts secret seeds to their public keys
+func redactConfig(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+
+	redacted := map[string]interface{}{}
+	for k, v := range m {
+		key := strings.ToUpper(k)
+		if strings.HasSuffix(key, "SECRET_SEED") {
+			redacted[k] = secretSeedToPublicKey(v)
+			continue
+		}
+		if isSecretConfigKey(key) {
+			redacted[k] = redactedValue
+			continue
+		}
+
+		switch value := v.(type) {
+		case map[string]interface{}:
+			redacted[k] = redactConfig(value)
+		case []map[string]interface{}:
+			tables := []map[string]interface{}{}
+			for _, t := range value {
+				tables = append(tables, redactConfig(t))
+			}
+			redacted[k] = tables
+		default:
+			redacted[k] = v
+		}
+	}
+	return redacted
+}
+
+func isSecretConfigKey(key string) bool {
+	if secretConfigKeys[key] {
+		return true
+	}
+	for _, substring := range secretConfigKeySubstrings {
+		if strings.Contains(key, substring) {
+			return true
+		}
+	}
+	return false
+}
+
+// secretSeedToPublicKey never returns the secret, even if it cannot be parsed
+func secretSeedToPublicKey(v interface{}) interface{} {
+	secret, ok := v.(string)
+	if !ok || secret == "" {
+		return ""
+	}
+
+	pk, e := utils.ParseSecret(secret)
+	if e != nil || pk == nil {
+		return redactedValue
+	}
+	return *pk
+}
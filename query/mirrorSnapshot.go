@@ -0,0 +1,15 @@
+package query
+
+import (
+	"github.com/stellar/kelp/plugins"
+)
+
+// getMirrorSnapshot returns nil if the strategy does not mirror a backing exchange or has not run an update yet
+func (s *Server) getMirrorSnapshot() *plugins.MirrorSnapshot {
+	provider, ok := s.strategy.(plugins.MirrorSnapshotProvider)
+	if !ok {
+		s.l.Infof("strategy '%s' does not provide a mirror snapshot\n", s.strategyName)
+		return nil
+	}
+	return provider.GetMirrorSnapshot()
+}
@@ -0,0 +1,54 @@
+package query
+
+import (
+	"fmt"
+
+	"github.com/stellar/kelp/support/utils"
+)
+
+// offersOutput is the response from the getOffers IPC request, prices are in units of the quote asset and amounts in units of the base asset
+type offersOutput struct {
+	Bids []offerOutput `json:"bids"`
+	Asks []offerOutput `json:"asks"`
+}
+
+type offerOutput struct {
+	ID     int64   `json:"id"`
+	Price  float64 `json:"price"`
+	Amount float64 `json:"amount"`
+}
+
+func (s *Server) getOffers() (*offersOutput, error) {
+	assetBase, assetQuote, e := s.sdex.Assets()
+	if e != nil {
+		return nil, fmt.Errorf("error getting assets from sdex: %s", e)
+	}
+
+	offers, e := s.exchangeShim.LoadOffersHack()
+	if e != nil {
+		return nil, fmt.Errorf("error loading offers: %s", e)
+	}
+	sellingAOffers, buyingAOffers := utils.FilterOffers(offers, assetBase, assetQuote)
+
+	output := &offersOutput{
+		Bids: []offerOutput{},
+		Asks: []offerOutput{},
+	}
+	for _, o := range buyingAOffers {
+		// bids sell the quote asset so we invert the price and convert the amount to units of the base asset
+		price := utils.PriceAsFloat(o.Price)
+		output.Bids = append(output.Bids, offerOutput{
+			ID:     o.ID,
+			Price:  1 / price,
+			Amount: utils.AmountStringAsFloat(o.Amount) * price,
+		})
+	}
+	for _, o := range sellingAOffers {
+		output.Asks = append(output.Asks, offerOutput{
+			ID:     o.ID,
+			Price:  utils.PriceAsFloat(o.Price),
+			Amount: utils.AmountStringAsFloat(o.Amount),
+		})
+	}
+	return output, nil
+}
@@ -0,0 +1,45 @@
+package query
+
+import (
+	"fmt"
+
+	"github.com/stellar/kelp/accounting"
+)
+
+// pnlOutput is the response from the getPnL IPC request, unrealized P&L is valued at the mid price of the orderbook of the trading pair
+type pnlOutput struct {
+	FillTrackingEnabled bool `json:"fill_tracking_enabled"`
+	*accounting.Report
+	MarkPriceError string `json:"mark_price_error,omitempty"`
+}
+
+func (s *Server) getPnL() *pnlOutput {
+	markPrices := map[string]float64{}
+	markPriceError := ""
+	midPrice, e := s.getMidPrice()
+	if e != nil {
+		// still report the realized P&L when the orderbook is unavailable
+		markPriceError = fmt.Sprintf("unable to get mark price: %s", e)
+	} else {
+		markPrices[s.tradingPair.String()] = midPrice
+	}
+
+	return &pnlOutput{
+		FillTrackingEnabled: s.botConfig.FillTrackerSleepMillis != 0,
+		Report:              s.ledger.Report(markPrices),
+		MarkPriceError:      markPriceError,
+	}
+}
+
+func (s *Server) getMidPrice() (float64, error) {
+	ob, e := s.exchangeShim.GetOrderBook(s.tradingPair, 1)
+	if e != nil {
+		return 0, fmt.Errorf("error fetching orderbook: %s", e)
+	}
+	topBid := ob.TopBid()
+	topAsk := ob.TopAsk()
+	if topBid == nil || topAsk == nil {
+		return 0, fmt.Errorf("orderbook does not have both bids and asks")
+	}
+	return (topBid.Price.AsFloat() + topAsk.Price.AsFloat()) / 2, nil
+}
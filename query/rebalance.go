@@ -0,0 +1,58 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stellar/kelp/plugins"
+)
+
+// prefixes of the IPC commands that approve or reject a pending rebalance transfer, followed by the id of the transfer
+const (
+	approveRebalancePrefix = "approveRebalance "
+	rejectRebalancePrefix  = "rejectRebalance "
+)
+
+// rebalanceDecisionOutput is the response to an approved or rejected rebalance transfer
+type rebalanceDecisionOutput struct {
+	TransferID string `json:"transfer_id"`
+	Approved   bool   `json:"approved"`
+}
+
+// getRebalanceProvider returns nil if the strategy does not rebalance assets
+func (s *Server) getRebalanceProvider() plugins.RebalanceProvider {
+	provider, ok := s.strategy.(plugins.RebalanceProvider)
+	if !ok {
+		s.l.Infof("strategy '%s' does not rebalance assets\n", s.strategyName)
+		return nil
+	}
+	return provider
+}
+
+// getRebalance returns nil if the strategy does not rebalance assets
+func (s *Server) getRebalance() *plugins.RebalanceSnapshot {
+	provider := s.getRebalanceProvider()
+	if provider == nil {
+		return nil
+	}
+	return provider.GetRebalanceSnapshot()
+}
+
+// decideRebalance approves or rejects the pending rebalance transfer with the id that follows the prefix of the command
+func (s *Server) decideRebalance(cmd string) (*rebalanceDecisionOutput, error) {
+	approve := strings.HasPrefix(cmd, approveRebalancePrefix)
+	id := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(cmd, approveRebalancePrefix), rejectRebalancePrefix))
+	if id == "" {
+		return nil, fmt.Errorf("need to specify the id of the rebalance transfer")
+	}
+
+	provider := s.getRebalanceProvider()
+	if provider == nil {
+		return nil, fmt.Errorf("strategy '%s' does not rebalance assets", s.strategyName)
+	}
+	e := provider.ApproveRebalanceTransfer(id, approve)
+	if e != nil {
+		return nil, e
+	}
+	return &rebalanceDecisionOutput{TransferID: id, Approved: approve}, nil
+}
@@ -0,0 +1,25 @@
+package query
+
+import (
+	"github.com/stellar/kelp/trader"
+)
+
+// runHistoryOutput is the response from the getRunHistory IPC request
+type runHistoryOutput struct {
+	Enabled   bool                   `json:"enabled"`
+	Snapshots []trader.CycleSnapshot `json:"snapshots"` // oldest first
+}
+
+func (s *Server) getRunHistory() *runHistoryOutput {
+	snapshots := s.bot.RunHistory()
+	if snapshots == nil {
+		return &runHistoryOutput{
+			Enabled:   false,
+			Snapshots: []trader.CycleSnapshot{},
+		}
+	}
+	return &runHistoryOutput{
+		Enabled:   true,
+		Snapshots: snapshots,
+	}
+}
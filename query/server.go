@@ -4,12 +4,15 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/stellar/kelp/support/utils"
 
 	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/kelp/accounting"
 	"github.com/stellar/kelp/api"
 	"github.com/stellar/kelp/model"
 	"github.com/stellar/kelp/plugins"
@@ -19,14 +22,20 @@ import (
 
 // Server is a query server with which the trade command will serve information about an actively running bot
 type Server struct {
-	l            logger.Logger
-	strategyName string
-	strategy     api.Strategy
-	botConfig    trader.BotConfig
-	client       *horizonclient.Client
-	sdex         *plugins.SDEX
-	exchangeShim api.ExchangeShim
-	tradingPair  *model.TradingPair
+	l               logger.Logger
+	strategyName    string
+	strategy        api.Strategy
+	botConfig       trader.BotConfig
+	client          *horizonclient.Client
+	sdex            *plugins.SDEX
+	exchangeShim    api.ExchangeShim
+	tradingPair     *model.TradingPair
+	bot             *trader.Trader
+	ledger          *accounting.Ledger
+	stratConfigPath string
+	annotations     *AnnotationStore
+	tradesMutex     *sync.Mutex
+	recentTrades    []model.Trade // most recent first, bounded by maxRecentTrades
 }
 
 // MakeServer is a factory method
@@ -39,16 +48,31 @@ func MakeServer(
 	sdex *plugins.SDEX,
 	exchangeShim api.ExchangeShim,
 	tradingPair *model.TradingPair,
+	bot *trader.Trader,
+	ledger *accounting.Ledger,
+	stratConfigPath string,
 ) *Server {
+	// strategies that emit annotations send them to the query server which aggregates them for the GUI
+	annotations := MakeAnnotationStore()
+	if a, ok := strategy.(api.Annotatable); ok {
+		a.SetAnnotator(annotations)
+	}
+
 	return &Server{
-		l:            l,
-		strategyName: strategyName,
-		strategy:     strategy,
-		botConfig:    botConfig,
-		client:       client,
-		sdex:         sdex,
-		exchangeShim: exchangeShim,
-		tradingPair:  tradingPair,
+		l:               l,
+		strategyName:    strategyName,
+		strategy:        strategy,
+		botConfig:       botConfig,
+		client:          client,
+		sdex:            sdex,
+		exchangeShim:    exchangeShim,
+		tradingPair:     tradingPair,
+		bot:             bot,
+		ledger:          ledger,
+		stratConfigPath: stratConfigPath,
+		annotations:     annotations,
+		tradesMutex:     &sync.Mutex{},
+		recentTrades:    []model.Trade{},
 	}
 }
 
@@ -57,53 +81,126 @@ func (s *Server) StartIPC() error {
 	pipeRead := os.NewFile(uintptr(3), "pipe_read")
 	pipeWrite := os.NewFile(uintptr(4), "pipe_write")
 
-	scanner := bufio.NewScanner(pipeRead)
+	reader := bufio.NewReader(pipeRead)
 	s.l.Infof("waiting for IPC command...\n")
-	for scanner.Scan() {
-		command := scanner.Text()
-		s.l.Infof("...received IPC command: %s\n", command)
+	for {
+		// commands are a single line when the GUI is using the legacy protocol
+		msg, e := utils.ReadIPCMessage(reader, "")
+		if e == io.EOF {
+			return nil
+		}
+		if e != nil {
+			return fmt.Errorf("error while reading commands in query server: %s", e)
+		}
+
+		command := string(msg.Payload)
+		s.l.Infof("...received IPC command (protocol version %d): %s\n", msg.Version, command)
 		output, e := s.executeCommandIPC(command)
 		if e != nil {
 			return fmt.Errorf("error while executing IPC Command ('%s'): %s", command, e)
 		}
-		if !strings.HasSuffix(output, "\n") {
-			output += "\n"
-		}
 
-		output += utils.IPCBoundary + "\n"
-		s.l.Infof("responding to IPC command ('%s') with output: %s", command, output)
-		_, e = pipeWrite.WriteString(output)
+		s.l.Infof("responding to IPC command ('%s') with output: %s\n", command, output)
+		e = utils.WriteIPCResponse(pipeWrite, msg.Version, []byte(output))
 		if e != nil {
 			return fmt.Errorf("error while writing output to pipeWrite (name=%s; fd=%v): %s", pipeWrite.Name(), pipeWrite.Fd(), e)
 		}
 		s.l.Infof("waiting for next IPC command...\n")
 	}
-
-	if e := scanner.Err(); e != nil {
-		return fmt.Errorf("error while reading commands in query server: %s", e)
-	}
-	return nil
 }
 
 func (s *Server) executeCommandIPC(cmd string) (string, error) {
 	cmd = strings.TrimSpace(cmd)
+	if strings.HasPrefix(cmd, approveRebalancePrefix) || strings.HasPrefix(cmd, rejectRebalancePrefix) {
+		output, e := s.decideRebalance(cmd)
+		if e != nil {
+			return marshalIPCOutput(ipcErrorOutput{Error: fmt.Sprintf("unable to decide rebalance transfer: %s", e)})
+		}
+		return marshalIPCOutput(output)
+	}
 
 	switch cmd {
 	case "":
 		return "", nil
+	case utils.IPCVersionCommand:
+		return marshalIPCOutput(utils.IPCVersionOutput{IPCVersion: utils.IPCVersion})
 	case "getBotInfo":
 		output, e := s.getBotInfo()
 		if e != nil {
 			return "", fmt.Errorf("unable to get bot info: %s", e)
 		}
-
-		outputBytes, e := json.MarshalIndent(output, "", "  ")
+		return marshalIPCOutput(output)
+	case "previewOps":
+		output, e := s.bot.Preview()
 		if e != nil {
-			return "", fmt.Errorf("unable to marshall output to JSON: %s", e)
+			// return the error to the caller instead of stopping the query server since the next update cycle may succeed
+			output = &trader.Preview{Diff: fmt.Sprintf("unable to preview ops: %s", e)}
+		}
+		return marshalIPCOutput(output)
+	case "getMirrorSnapshot":
+		snapshot := s.getMirrorSnapshot()
+		if snapshot == nil {
+			return "{}", nil
 		}
-		return string(outputBytes), nil
+		return marshalIPCOutput(snapshot)
+	case "getRebalance":
+		snapshot := s.getRebalance()
+		if snapshot == nil {
+			return "{}", nil
+		}
+		return marshalIPCOutput(snapshot)
+	case "getAnnotations":
+		return marshalIPCOutput(annotationsOutput{Annotations: s.annotations.recent()})
+	case "getOffers":
+		output, e := s.getOffers()
+		if e != nil {
+			// return the error to the caller instead of stopping the query server since the request may succeed when retried
+			return marshalIPCOutput(ipcErrorOutput{Error: fmt.Sprintf("unable to get offers: %s", e)})
+		}
+		return marshalIPCOutput(output)
+	case "getTrades":
+		return marshalIPCOutput(s.getTrades())
+	case "getConfig":
+		output, e := s.getConfig()
+		if e != nil {
+			return marshalIPCOutput(ipcErrorOutput{Error: fmt.Sprintf("unable to get config: %s", e)})
+		}
+		return marshalIPCOutput(output)
+	case "getHealth":
+		return marshalIPCOutput(s.bot.Health())
+	case "getPnL":
+		return marshalIPCOutput(s.getPnL())
+	case "getUpdateTiming":
+		timing := s.bot.LastUpdateTiming()
+		if timing == nil {
+			return "{}", nil
+		}
+		return marshalIPCOutput(timing)
+	case "getRunHistory":
+		return marshalIPCOutput(s.getRunHistory())
+	case "getTxFailures":
+		return marshalIPCOutput(s.sdex.TxFailureStats())
+	case "getCapacity":
+		output, e := s.getCapacity()
+		if e != nil {
+			return marshalIPCOutput(ipcErrorOutput{Error: fmt.Sprintf("unable to get capacity: %s", e)})
+		}
+		return marshalIPCOutput(output)
 	default:
 		// don't do anything if the input is an incorrect command because we take input from standard in
 		return "", nil
 	}
 }
+
+// ipcErrorOutput is the response to an IPC request that failed without stopping the query server
+type ipcErrorOutput struct {
+	Error string `json:"error"`
+}
+
+func marshalIPCOutput(output interface{}) (string, error) {
+	outputBytes, e := json.MarshalIndent(output, "", "  ")
+	if e != nil {
+		return "", fmt.Errorf("unable to marshall output to JSON: %s", e)
+	}
+	return string(outputBytes), nil
+}
@@ -0,0 +1,83 @@
+package query
+
+import (
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// maxRecentTrades is the number of fills that the query server keeps in memory for the getTrades IPC request
+const maxRecentTrades = 50
+
+// tradesOutput is the response from the getTrades IPC request, trades are the fills seen by the fill tracker since the bot started, most recent first
+type tradesOutput struct {
+	FillTrackingEnabled bool          `json:"fill_tracking_enabled"`
+	Trades              []tradeOutput `json:"trades"`
+}
+
+type tradeOutput struct {
+	TransactionID   string  `json:"transaction_id"`
+	Action          string  `json:"action"`
+	Price           float64 `json:"price"`
+	Volume          float64 `json:"volume"`
+	Cost            float64 `json:"cost"`
+	Fee             float64 `json:"fee"`
+	TimestampMillis int64   `json:"timestamp_millis"`
+}
+
+// ensure it implements FillHandler
+var _ api.FillHandler = &Server{}
+
+// HandleFill impl, records the fill so it can be returned by the getTrades IPC request
+func (s *Server) HandleFill(trade model.Trade) error {
+	s.tradesMutex.Lock()
+	defer s.tradesMutex.Unlock()
+
+	s.recentTrades = append([]model.Trade{trade}, s.recentTrades...)
+	if len(s.recentTrades) > maxRecentTrades {
+		s.recentTrades = s.recentTrades[:maxRecentTrades]
+	}
+	return nil
+}
+
+func (s *Server) getTrades() *tradesOutput {
+	s.tradesMutex.Lock()
+	defer s.tradesMutex.Unlock()
+
+	trades := []tradeOutput{}
+	for _, t := range s.recentTrades {
+		trades = append(trades, tradeOutput{
+			TransactionID:   stringOrEmpty(t.TransactionID),
+			Action:          t.OrderAction.String(),
+			Price:           floatOrZero(t.Price),
+			Volume:          floatOrZero(t.Volume),
+			Cost:            floatOrZero(t.Cost),
+			Fee:             floatOrZero(t.Fee),
+			TimestampMillis: timestampOrZero(t.Timestamp),
+		})
+	}
+	return &tradesOutput{
+		FillTrackingEnabled: s.botConfig.FillTrackerSleepMillis != 0,
+		Trades:              trades,
+	}
+}
+
+func stringOrEmpty(txID *model.TransactionID) string {
+	if txID == nil {
+		return ""
+	}
+	return txID.String()
+}
+
+func floatOrZero(n *model.Number) float64 {
+	if n == nil {
+		return 0
+	}
+	return n.AsFloat()
+}
+
+func timestampOrZero(ts *model.Timestamp) int64 {
+	if ts == nil {
+		return 0
+	}
+	return ts.AsInt64()
+}
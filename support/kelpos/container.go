@@ -0,0 +1,156 @@
+package kelpos
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ContainerConfig configures running commands in their own Docker container instead of a child process, which isolates the bots of a
+// multi-tenant deployment from each other and from the GUI server
+type ContainerConfig struct {
+	Image         string   // Docker image that contains the kelp binary
+	BinPath       string   // path of the kelp binary inside the image
+	CPUs          string   // value of the --cpus flag of docker run, empty for no limit
+	Memory        string   // value of the --memory flag of docker run, empty for no limit
+	RestartPolicy string   // value of the --restart flag of docker run, empty or "no" removes the container when it exits
+	Network       string   // value of the --network flag of docker run, empty for the default network of Docker
+	Mounts        []string // host directories that are mounted at the same path in the container so the paths in the command resolve
+	IPCDir        string   // host directory for the named pipes of the IPC channel, it is mounted in the container
+}
+
+// validate checks the config
+func (c *ContainerConfig) validate() error {
+	if c.Image == "" {
+		return fmt.Errorf("need to specify the image of the container")
+	}
+	if c.BinPath == "" {
+		return fmt.Errorf("need to specify the path of the kelp binary in the container")
+	}
+	if c.IPCDir == "" {
+		return fmt.Errorf("need to specify the directory for the named pipes of the IPC channel")
+	}
+	return nil
+}
+
+// ContainerName returns the name of the Docker container used for the namespace
+func ContainerName(namespace string) string {
+	return "kelp-" + namespace
+}
+
+// dockerRunArgs returns the arguments of the docker run command that runs the kelp command in a container. The child process reads IPC
+// commands on fd 3 and writes responses on fd 4 so the named pipes are opened on those file descriptors inside the container, which keeps
+// the same IPC surface as a child process.
+func (c *ContainerConfig) dockerRunArgs(containerName string, pipeInPath string, pipeOutPath string, cmd string) []string {
+	args := []string{"run", "-i", "--name", containerName}
+	if c.RestartPolicy == "" || c.RestartPolicy == "no" {
+		args = append(args, "--rm")
+	} else {
+		args = append(args, "--restart", c.RestartPolicy)
+	}
+	if c.CPUs != "" {
+		args = append(args, "--cpus", c.CPUs)
+	}
+	if c.Memory != "" {
+		args = append(args, "--memory", c.Memory)
+	}
+	if c.Network != "" {
+		args = append(args, "--network", c.Network)
+	}
+	mounts := append([]string{}, c.Mounts...)
+	for _, dir := range append(mounts, filepath.Dir(pipeInPath)) {
+		args = append(args, "-v", fmt.Sprintf("%s:%s", dir, dir))
+	}
+
+	innerCmd := fmt.Sprintf("exec %s %s 3<%s 4>%s", c.BinPath, cmd, pipeInPath, pipeOutPath)
+	return append(args, c.Image, "sh", "-c", innerCmd)
+}
+
+// BackgroundContainer runs the provided kelp command in a Docker container in the background and registers it. The IPC channel of the
+// command is connected to named pipes in the IPC dir instead of the anonymous pipes used by Background, which cannot be passed to a container.
+func (kos *KelpOS) BackgroundContainer(namespace string, config *ContainerConfig, cmd string) (*Process, error) {
+	e := config.validate()
+	if e != nil {
+		return nil, fmt.Errorf("invalid container config: %s", e)
+	}
+	containerName := ContainerName(namespace)
+
+	// remove the container of a previous run, which is kept when it has a restart policy
+	_ = exec.Command("docker", "rm", "-f", containerName).Run()
+
+	pipeDir := filepath.Join(config.IPCDir, containerName)
+	pipeInPath := filepath.Join(pipeDir, "in")
+	pipeOutPath := filepath.Join(pipeDir, "out")
+	e = os.MkdirAll(pipeDir, 0700)
+	if e != nil {
+		return nil, fmt.Errorf("could not create directory for named pipes '%s': %s", pipeDir, e)
+	}
+	for _, path := range []string{pipeInPath, pipeOutPath} {
+		_ = os.Remove(path)
+		output, e := exec.Command("mkfifo", "-m", "0600", path).CombinedOutput()
+		if e != nil {
+			return nil, fmt.Errorf("could not create named pipe '%s': %s (output=%s)", path, e, string(output))
+		}
+	}
+	// open both ends for reading and writing so the open does not block until the container opens the other end, and so the pipes stay
+	// open when the container is restarted
+	pipeIn, e := os.OpenFile(pipeInPath, os.O_RDWR, 0600)
+	if e != nil {
+		return nil, fmt.Errorf("could not open named pipe '%s': %s", pipeInPath, e)
+	}
+	pipeOut, e := os.OpenFile(pipeOutPath, os.O_RDWR, 0600)
+	if e != nil {
+		return nil, fmt.Errorf("could not open named pipe '%s': %s", pipeOutPath, e)
+	}
+
+	args := config.dockerRunArgs(containerName, pipeInPath, pipeOutPath, cmd)
+	c := exec.Command("docker", args...)
+	stdinWriter, e := c.StdinPipe()
+	if e != nil {
+		return nil, fmt.Errorf("could not get Stdin pipe for docker command '%s': %s", strings.Join(args, " "), e)
+	}
+	stdoutReader, e := c.StdoutPipe()
+	if e != nil {
+		return nil, fmt.Errorf("could not get Stdout pipe for docker command '%s': %s", strings.Join(args, " "), e)
+	}
+
+	e = c.Start()
+	if e != nil {
+		return nil, fmt.Errorf("could not start docker command '%s': %s", strings.Join(args, " "), e)
+	}
+	log.Printf("started container '%s' with image '%s' (cpus=%s, memory=%s, restart=%s)\n", containerName, config.Image, config.CPUs, config.Memory, config.RestartPolicy)
+
+	p := &Process{
+		Cmd:           c,
+		Stdin:         stdinWriter,
+		Stdout:        stdoutReader,
+		PipeIn:        pipeIn,
+		PipeOut:       pipeOut,
+		PipeOutReader: bufio.NewReader(pipeOut),
+		IPCMutex:      &sync.Mutex{},
+		IPCVersion:    makeUnknownIPCVersion(),
+		Container:     containerName,
+	}
+	e = kos.register(namespace, p)
+	if e != nil {
+		return nil, fmt.Errorf("error registering docker command '%s': %s", strings.Join(args, " "), e)
+	}
+
+	return p, nil
+}
+
+// stopContainer removes the container of the process, killing the docker client does not stop the container
+func stopContainer(p *Process) error {
+	output, e := exec.Command("docker", "rm", "-f", p.Container).CombinedOutput()
+	if e != nil {
+		return fmt.Errorf("could not remove container '%s': %s (output=%s)", p.Container, e, string(output))
+	}
+	p.PipeIn.Close()
+	p.PipeOut.Close()
+	return nil
+}
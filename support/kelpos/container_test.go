@@ -0,0 +1,48 @@
+package kelpos
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDockerRunArgs(t *testing.T) {
+	testCases := []struct {
+		name   string
+		config ContainerConfig
+		want   []string
+	}{
+		{
+			name: "no limits",
+			config: ContainerConfig{
+				Image:   "kelp:latest",
+				BinPath: "/app/kelp",
+				Mounts:  []string{"/ops/configs"},
+			},
+			want: []string{"run", "-i", "--name", "kelp-bot", "--rm",
+				"-v", "/ops/configs:/ops/configs",
+				"-v", "/ops/ipc/kelp-bot:/ops/ipc/kelp-bot",
+				"kelp:latest", "sh", "-c", "exec /app/kelp trade --with-ipc 3</ops/ipc/kelp-bot/in 4>/ops/ipc/kelp-bot/out"},
+		}, {
+			name: "limits and restart policy",
+			config: ContainerConfig{
+				Image:         "kelp:latest",
+				BinPath:       "kelp",
+				CPUs:          "0.5",
+				Memory:        "256m",
+				RestartPolicy: "on-failure:3",
+				Network:       "host",
+			},
+			want: []string{"run", "-i", "--name", "kelp-bot", "--restart", "on-failure:3", "--cpus", "0.5", "--memory", "256m", "--network", "host",
+				"-v", "/ops/ipc/kelp-bot:/ops/ipc/kelp-bot",
+				"kelp:latest", "sh", "-c", "exec kelp trade --with-ipc 3</ops/ipc/kelp-bot/in 4>/ops/ipc/kelp-bot/out"},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			args := k.config.dockerRunArgs(ContainerName("bot"), "/ops/ipc/kelp-bot/in", "/ops/ipc/kelp-bot/out", "trade --with-ipc")
+			assert.Equal(t, k.want, args)
+		})
+	}
+}
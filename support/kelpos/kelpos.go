@@ -1,12 +1,14 @@
 package kelpos
 
 import (
+	"bufio"
 	"io"
 	"os"
 	"os/exec"
 	"sync"
 
 	"github.com/stellar/kelp/gui/model2"
+	"github.com/stellar/kelp/support/utils"
 )
 
 // KelpOS is a struct that manages all subprocesses started by this Kelp process
@@ -24,6 +26,22 @@ type Process struct {
 	Stdout  io.ReadCloser
 	PipeIn  *os.File
 	PipeOut *os.File
+	// PipeOutReader buffers PipeOut and should be used for all reads so no buffered bytes are lost between IPC messages
+	PipeOutReader *bufio.Reader
+	// IPCMutex is held across writing an IPC request to PipeIn and reading its response from PipeOutReader so concurrent requests to the
+	// process do not interleave, it is a pointer because processes are registered by value
+	IPCMutex *sync.Mutex
+	// IPCVersion is the IPC protocol version negotiated with the process by the first IPC request, utils.IPCVersionUnknown until then. It is
+	// guarded by IPCMutex and is a pointer for the same reason.
+	IPCVersion *int
+	// Container is the name of the Docker container that runs the command, empty when the command runs as a child process
+	Container string
+}
+
+// makeUnknownIPCVersion returns the IPCVersion of a process that was just started
+func makeUnknownIPCVersion() *int {
+	version := utils.IPCVersionUnknown
+	return &version
 }
 
 // singleton is the singleton instance of KelpOS
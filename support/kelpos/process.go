@@ -7,6 +7,7 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"sync"
 )
 
 // StreamOutput runs the provided command in a streaming fashion
@@ -44,6 +45,17 @@ func (kos *KelpOS) Stop(namespace string) error {
 			return fmt.Errorf("could not stop command because of an error when unregistering command for namespace '%s': %s", namespace, e)
 		}
 
+		if p.Container != "" {
+			log.Printf("removing container '%s'\n", p.Container)
+			e = stopContainer(p)
+			if e != nil {
+				return fmt.Errorf("could not stop command for namespace '%s': %s", namespace, e)
+			}
+			// the docker client exits by itself once the container is removed
+			_ = p.Cmd.Process.Kill()
+			return nil
+		}
+
 		log.Printf("killing process %d\n", p.Cmd.Process.Pid)
 		return p.Cmd.Process.Kill()
 	}
@@ -107,11 +119,14 @@ func (kos *KelpOS) Background(namespace string, cmd string) (*Process, error) {
 	}
 
 	p := &Process{
-		Cmd:     c,
-		Stdin:   stdinWriter,
-		Stdout:  stdoutReader,
-		PipeIn:  childInputWriter,
-		PipeOut: childOutputReader,
+		Cmd:           c,
+		Stdin:         stdinWriter,
+		Stdout:        stdoutReader,
+		PipeIn:        childInputWriter,
+		PipeOut:       childOutputReader,
+		PipeOutReader: bufio.NewReader(childOutputReader),
+		IPCMutex:      &sync.Mutex{},
+		IPCVersion:    makeUnknownIPCVersion(),
 	}
 	e = kos.register(namespace, p)
 	if e != nil {
@@ -0,0 +1,285 @@
+package kelpos
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// serviceRestartDelaySeconds is how long the service manager waits before restarting a service that exited
+const serviceRestartDelaySeconds = 5
+
+// serviceNameRegex limits the names of services to characters that are valid for both systemd units and Windows services
+var serviceNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+// ServiceConfig configures a kelp command that is registered with the service manager of the OS (systemd on Linux, the service control
+// manager on Windows) so it starts at boot and is restarted when it exits, instead of running in a terminal that dies with the session
+type ServiceConfig struct {
+	Name        string   // name of the systemd unit (without the .service suffix) or of the Windows service
+	Description string   // human readable description of the service
+	BinPath     string   // absolute path of the kelp binary
+	Args        []string // arguments of the kelp command, such as "server" or "trade -c ..."
+	WorkingDir  string   // absolute path of the directory the command runs in, relative paths in the args and the configs resolve against it
+	LogPath     string   // absolute path of the file that stdout and stderr are appended to
+	RunAs       string   // user that runs a systemd system unit, empty runs it as root. Not used for user units and on Windows
+	UserUnit    bool     // installs a systemd user unit instead of a system unit, which does not need root. Not used on Windows
+}
+
+// validate checks the config
+func (c *ServiceConfig) validate() error {
+	if e := validateServiceName(c.Name); e != nil {
+		return e
+	}
+	if !filepath.IsAbs(c.BinPath) {
+		return fmt.Errorf("path of the kelp binary needs to be absolute: %s", c.BinPath)
+	}
+	if !filepath.IsAbs(c.WorkingDir) {
+		return fmt.Errorf("working directory needs to be absolute: %s", c.WorkingDir)
+	}
+	if !filepath.IsAbs(c.LogPath) {
+		return fmt.Errorf("path of the log file needs to be absolute: %s", c.LogPath)
+	}
+	if len(c.Args) == 0 {
+		return fmt.Errorf("need to specify the kelp command that the service runs")
+	}
+	return nil
+}
+
+func validateServiceName(name string) error {
+	if !serviceNameRegex.MatchString(name) {
+		return fmt.Errorf("invalid service name '%s', it can only contain letters, digits, '_', '.' and '-'", name)
+	}
+	return nil
+}
+
+// InstallService registers the service with the service manager of the OS, enables it to start at boot and starts it
+func InstallService(c *ServiceConfig) error {
+	if e := c.validate(); e != nil {
+		return fmt.Errorf("invalid service config: %s", e)
+	}
+	if e := os.MkdirAll(filepath.Dir(c.LogPath), 0755); e != nil {
+		return fmt.Errorf("could not create the directory of the log file: %s", e)
+	}
+
+	switch runtime.GOOS {
+	case "linux":
+		return installSystemdUnit(c)
+	case "windows":
+		return installWindowsService(c)
+	default:
+		return fmt.Errorf("services are not supported on %s, only on linux and windows", runtime.GOOS)
+	}
+}
+
+// UninstallService stops the service and removes it from the service manager of the OS
+func UninstallService(name string, userUnit bool) error {
+	if e := validateServiceName(name); e != nil {
+		return e
+	}
+
+	switch runtime.GOOS {
+	case "linux":
+		return uninstallSystemdUnit(name, userUnit)
+	case "windows":
+		return uninstallWindowsService(name)
+	default:
+		return fmt.Errorf("services are not supported on %s, only on linux and windows", runtime.GOOS)
+	}
+}
+
+// ServiceStatus returns the status of the service as reported by the service manager of the OS
+func ServiceStatus(name string, userUnit bool) (string, error) {
+	if e := validateServiceName(name); e != nil {
+		return "", e
+	}
+
+	switch runtime.GOOS {
+	case "linux":
+		// systemctl status exits with a non-zero code when the unit is not running, the output is still the status
+		output, _ := exec.Command("systemctl", systemctlArgs(userUnit, "status", "--no-pager", systemdUnitName(name))...).CombinedOutput()
+		return string(output), nil
+	case "windows":
+		output, e := exec.Command("sc.exe", "query", name).CombinedOutput()
+		if e != nil {
+			return "", fmt.Errorf("could not query service '%s': %s: %s", name, e, strings.TrimSpace(string(output)))
+		}
+		return string(output), nil
+	default:
+		return "", fmt.Errorf("services are not supported on %s, only on linux and windows", runtime.GOOS)
+	}
+}
+
+func systemdUnitName(name string) string {
+	return name + ".service"
+}
+
+// systemdUnitPath returns the path of the unit file, user units are in the systemd config directory of the user
+func systemdUnitPath(name string, userUnit bool) (string, error) {
+	if !userUnit {
+		return filepath.Join("/etc/systemd/system", systemdUnitName(name)), nil
+	}
+
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		homeDir := os.Getenv("HOME")
+		if homeDir == "" {
+			return "", fmt.Errorf("could not find the home directory of the user, HOME is not set")
+		}
+		configDir = filepath.Join(homeDir, ".config")
+	}
+	return filepath.Join(configDir, "systemd", "user", systemdUnitName(name)), nil
+}
+
+func systemctlArgs(userUnit bool, args ...string) []string {
+	if userUnit {
+		return append([]string{"--user"}, args...)
+	}
+	return args
+}
+
+func runSystemctl(userUnit bool, args ...string) error {
+	output, e := exec.Command("systemctl", systemctlArgs(userUnit, args...)...).CombinedOutput()
+	if e != nil {
+		return fmt.Errorf("error running 'systemctl %s': %s: %s", strings.Join(systemctlArgs(userUnit, args...), " "), e, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func installSystemdUnit(c *ServiceConfig) error {
+	unitPath, e := systemdUnitPath(c.Name, c.UserUnit)
+	if e != nil {
+		return e
+	}
+	if e = os.MkdirAll(filepath.Dir(unitPath), 0755); e != nil {
+		return fmt.Errorf("could not create the directory of the unit file: %s", e)
+	}
+	if e = ioutil.WriteFile(unitPath, []byte(systemdUnit(c)), 0644); e != nil {
+		return fmt.Errorf("could not write the unit file %s (system units need to be installed as root): %s", unitPath, e)
+	}
+
+	if e = runSystemctl(c.UserUnit, "daemon-reload"); e != nil {
+		return e
+	}
+	return runSystemctl(c.UserUnit, "enable", "--now", systemdUnitName(c.Name))
+}
+
+func uninstallSystemdUnit(name string, userUnit bool) error {
+	unitPath, e := systemdUnitPath(name, userUnit)
+	if e != nil {
+		return e
+	}
+	if _, e = os.Stat(unitPath); e != nil {
+		return fmt.Errorf("service '%s' is not installed, could not find the unit file %s: %s", name, unitPath, e)
+	}
+
+	if e = runSystemctl(userUnit, "disable", "--now", systemdUnitName(name)); e != nil {
+		return e
+	}
+	if e = os.Remove(unitPath); e != nil {
+		return fmt.Errorf("could not remove the unit file %s: %s", unitPath, e)
+	}
+	return runSystemctl(userUnit, "daemon-reload")
+}
+
+// systemdUnit returns the contents of the unit file, the service is restarted whenever it exits and its output is appended to the log file
+func systemdUnit(c *ServiceConfig) string {
+	execStart := []string{systemdQuote(c.BinPath)}
+	for _, a := range c.Args {
+		execStart = append(execStart, systemdQuote(a))
+	}
+	wantedBy := "multi-user.target"
+	if c.UserUnit {
+		wantedBy = "default.target"
+	}
+
+	lines := []string{
+		"[Unit]",
+		"Description=" + c.Description,
+		"After=network-online.target",
+		"Wants=network-online.target",
+		"",
+		"[Service]",
+		"Type=simple",
+		"WorkingDirectory=" + strings.Replace(c.WorkingDir, "%", "%%", -1),
+		"ExecStart=" + strings.Join(execStart, " "),
+		"Restart=always",
+		fmt.Sprintf("RestartSec=%d", serviceRestartDelaySeconds),
+		"StandardOutput=append:" + strings.Replace(c.LogPath, "%", "%%", -1),
+		"StandardError=append:" + strings.Replace(c.LogPath, "%", "%%", -1),
+	}
+	if c.RunAs != "" && !c.UserUnit {
+		lines = append(lines, "User="+c.RunAs)
+	}
+	lines = append(lines,
+		"",
+		"[Install]",
+		"WantedBy="+wantedBy,
+		"",
+	)
+	return strings.Join(lines, "\n")
+}
+
+// systemdQuote quotes the word for a systemd command line when needed, '%' is escaped since systemd expands specifiers
+func systemdQuote(word string) string {
+	word = strings.Replace(word, "%", "%%", -1)
+	if word != "" && !strings.ContainsAny(word, " \t\"'\\;$") {
+		return word
+	}
+	word = strings.Replace(word, `\`, `\\`, -1)
+	word = strings.Replace(word, `"`, `\"`, -1)
+	return `"` + word + `"`
+}
+
+// windowsServiceBinPath returns the command line that the service control manager runs, the hidden "service run" command of kelp reports
+// to the service control manager and runs the kelp command as its child with the output appended to the log file
+func windowsServiceBinPath(c *ServiceConfig) string {
+	words := []string{c.BinPath, "service", "run", "--name", c.Name, "--log", c.LogPath, "--working-dir", c.WorkingDir, "--"}
+	words = append(words, c.Args...)
+
+	quoted := []string{}
+	for _, w := range words {
+		if w == "" || strings.ContainsAny(w, " \t\"") {
+			w = `"` + strings.Replace(w, `"`, `\"`, -1) + `"`
+		}
+		quoted = append(quoted, w)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func runSc(args ...string) error {
+	output, e := exec.Command("sc.exe", args...).CombinedOutput()
+	if e != nil {
+		return fmt.Errorf("error running 'sc.exe %s' (services need to be installed from an administrator prompt): %s: %s", strings.Join(args, " "), e, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func installWindowsService(c *ServiceConfig) error {
+	e := runSc("create", c.Name, "binPath=", windowsServiceBinPath(c), "start=", "delayed-auto", "DisplayName=", c.Name)
+	if e != nil {
+		return e
+	}
+	if e = runSc("description", c.Name, c.Description); e != nil {
+		return e
+	}
+	// restart the service whenever it stops, including when the kelp command exits with an error instead of crashing
+	restart := fmt.Sprintf("restart/%d", serviceRestartDelaySeconds*1000)
+	if e = runSc("failure", c.Name, "reset=", "86400", "actions=", strings.Join([]string{restart, restart, restart}, "/")); e != nil {
+		return e
+	}
+	if e = runSc("failureflag", c.Name, "1"); e != nil {
+		return e
+	}
+	return runSc("start", c.Name)
+}
+
+func uninstallWindowsService(name string) error {
+	// stopping fails when the service is not running, which does not prevent deleting it
+	_ = runSc("stop", name)
+	return runSc("delete", name)
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+// +build !windows
+
+package kelpos
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// RunWindowsService is only supported on Windows, systemd runs the kelp command directly on Linux
+func RunWindowsService(name string, binPath string, args []string, workingDir string, logPath string) error {
+	return fmt.Errorf("running as a Windows service is not supported on %s", runtime.GOOS)
+}
+
+// IsWindowsService is always false outside of Windows
+func IsWindowsService() (bool, error) {
+	return false, nil
+}
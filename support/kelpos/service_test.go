@@ -0,0 +1,80 @@
+package kelpos
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSystemdUnit(t *testing.T) {
+	c := &ServiceConfig{
+		Name:        "kelp-bot-trader",
+		Description: "Kelp bot",
+		BinPath:     "/opt/kelp/kelp",
+		Args:        []string{"trade", "--botConf", "/opt/kelp/my configs/trader.cfg", "--strategy", "buysell"},
+		WorkingDir:  "/opt/kelp",
+		LogPath:     "/opt/kelp/logs/kelp-bot-trader.log",
+		RunAs:       "kelp",
+	}
+	assert.NoError(t, c.validate())
+	assert.Equal(t, `[Unit]
+Description=Kelp bot
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=simple
+WorkingDirectory=/opt/kelp
+ExecStart=/opt/kelp/kelp trade --botConf "/opt/kelp/my configs/trader.cfg" --strategy buysell
+Restart=always
+RestartSec=5
+StandardOutput=append:/opt/kelp/logs/kelp-bot-trader.log
+StandardError=append:/opt/kelp/logs/kelp-bot-trader.log
+User=kelp
+
+[Install]
+WantedBy=multi-user.target
+`, systemdUnit(c))
+
+	// user units run as the user that installed them
+	c.UserUnit = true
+	assert.NotContains(t, systemdUnit(c), "User=")
+	assert.Contains(t, systemdUnit(c), "WantedBy=default.target")
+}
+
+func TestSystemdQuote(t *testing.T) {
+	assert.Equal(t, "server", systemdQuote("server"))
+	assert.Equal(t, `"a b"`, systemdQuote("a b"))
+	assert.Equal(t, `"say \"hi\""`, systemdQuote(`say "hi"`))
+	assert.Equal(t, "100%%", systemdQuote("100%"))
+	assert.Equal(t, `""`, systemdQuote(""))
+}
+
+func TestWindowsServiceBinPath(t *testing.T) {
+	c := &ServiceConfig{
+		Name:       "kelp-server",
+		BinPath:    `C:\Program Files\kelp\kelp.exe`,
+		Args:       []string{"server", "--port", "8000"},
+		WorkingDir: `C:\kelp`,
+		LogPath:    `C:\kelp\logs\kelp-server.log`,
+	}
+	assert.Equal(t, `"C:\Program Files\kelp\kelp.exe" service run --name kelp-server --log C:\kelp\logs\kelp-server.log --working-dir C:\kelp -- server --port 8000`,
+		windowsServiceBinPath(c))
+}
+
+func TestServiceConfigValidate(t *testing.T) {
+	valid := ServiceConfig{Name: "kelp-server", BinPath: "/opt/kelp/kelp", Args: []string{"server"}, WorkingDir: "/opt/kelp", LogPath: "/opt/kelp/kelp.log"}
+	assert.NoError(t, valid.validate())
+
+	invalidName := valid
+	invalidName.Name = "kelp server"
+	assert.Error(t, invalidName.validate())
+
+	relativeBin := valid
+	relativeBin.BinPath = "kelp"
+	assert.Error(t, relativeBin.validate())
+
+	noArgs := valid
+	noArgs.Args = nil
+	assert.Error(t, noArgs.validate())
+}
@@ -0,0 +1,81 @@
+//go:build windows
+// +build windows
+
+package kelpos
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"golang.org/x/sys/windows/svc"
+)
+
+// RunWindowsService reports to the service control manager and runs the kelp command as a child process with its output appended to the
+// log file. The service stops with an error when the command exits so the recovery actions of the service restart it.
+func RunWindowsService(name string, binPath string, args []string, workingDir string, logPath string) error {
+	return svc.Run(name, &windowsService{
+		binPath:    binPath,
+		args:       args,
+		workingDir: workingDir,
+		logPath:    logPath,
+	})
+}
+
+// windowsService implements svc.Handler
+type windowsService struct {
+	binPath    string
+	args       []string
+	workingDir string
+	logPath    string
+}
+
+// Execute impl.
+func (s *windowsService) Execute(args []string, requests <-chan svc.ChangeRequest, changes chan<- svc.Status) (bool, uint32) {
+	changes <- svc.Status{State: svc.StartPending}
+
+	logFile, e := os.OpenFile(s.logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if e != nil {
+		return true, 1
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(s.binPath, s.args...)
+	cmd.Dir = s.workingDir
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	if e = cmd.Start(); e != nil {
+		fmt.Fprintf(logFile, "could not start the kelp command of the service: %s\n", e)
+		return true, 1
+	}
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+
+	changes <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for {
+		select {
+		case e := <-exited:
+			fmt.Fprintf(logFile, "kelp command of the service exited, the service will be restarted: %v\n", e)
+			return true, 1
+		case r := <-requests:
+			switch r.Cmd {
+			case svc.Interrogate:
+				changes <- r.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				changes <- svc.Status{State: svc.StopPending}
+				// the command cannot be sent a SIGTERM on Windows so it is killed, offers left behind by a bot are handled by
+				// STARTUP_OFFERS_ACTION when it starts again
+				_ = cmd.Process.Kill()
+				<-exited
+				return false, 0
+			}
+		}
+	}
+}
+
+// IsWindowsService returns true when the process was started by the service control manager
+func IsWindowsService() (bool, error) {
+	return svc.IsWindowsService()
+}
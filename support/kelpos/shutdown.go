@@ -0,0 +1,167 @@
+package kelpos
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// DefaultShutdownGracePeriod is how long a bot has to run its shutdown hooks before it is killed
+const DefaultShutdownGracePeriod = 30 * time.Second
+
+// stopPollInterval is how often a stopping process is checked for exit
+const stopPollInterval = 100 * time.Millisecond
+
+type shutdownHook struct {
+	name   string
+	fn     func() error
+	onExit bool // also run when the process finishes on its own
+}
+
+// ShutdownHooks are run by a bot process when it is asked to stop so it does not leave offers, orders or unsaved state behind. The hooks run
+// one after the other in the order they were registered, hooks that have not finished when the grace period is over are abandoned.
+type ShutdownHooks struct {
+	gracePeriod time.Duration
+	mutex       *sync.Mutex
+	hooks       []shutdownHook
+	once        *sync.Once
+}
+
+// MakeShutdownHooks is a factory method
+func MakeShutdownHooks(gracePeriod time.Duration) *ShutdownHooks {
+	return &ShutdownHooks{
+		gracePeriod: gracePeriod,
+		mutex:       &sync.Mutex{},
+		hooks:       []shutdownHook{},
+		once:        &sync.Once{},
+	}
+}
+
+// Register adds a hook that is run on shutdown
+func (h *ShutdownHooks) Register(name string, fn func() error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.hooks = append(h.hooks, shutdownHook{name: name, fn: fn})
+}
+
+// RegisterOnExit adds a hook that is run on shutdown and also when the process finishes on its own, such as after the last update cycle
+// of a bot started with a fixed number of iterations
+func (h *ShutdownHooks) RegisterOnExit(name string, fn func() error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.hooks = append(h.hooks, shutdownHook{name: name, fn: fn, onExit: true})
+}
+
+// Run runs the hooks within the grace period and returns the errors of the hooks that failed or did not finish in time. The hooks are only
+// run once, later calls to Run or RunOnExit return no errors.
+func (h *ShutdownHooks) Run() []error {
+	return h.run(false)
+}
+
+// RunOnExit runs the hooks registered with RegisterOnExit like Run does, it is called when the process finishes on its own
+func (h *ShutdownHooks) RunOnExit() []error {
+	return h.run(true)
+}
+
+func (h *ShutdownHooks) run(onExitOnly bool) []error {
+	errors := []error{}
+	h.once.Do(func() {
+		h.mutex.Lock()
+		hooks := []shutdownHook{}
+		for _, hook := range h.hooks {
+			if !onExitOnly || hook.onExit {
+				hooks = append(hooks, hook)
+			}
+		}
+		h.mutex.Unlock()
+
+		deadline := time.After(h.gracePeriod)
+		for i, hook := range hooks {
+			log.Printf("running shutdown hook '%s' (%d of %d)\n", hook.name, i+1, len(hooks))
+			done := make(chan error, 1)
+			go func(fn func() error) {
+				done <- fn()
+			}(hook.fn)
+
+			select {
+			case e := <-done:
+				if e != nil {
+					log.Printf("shutdown hook '%s' failed: %s\n", hook.name, e)
+					errors = append(errors, fmt.Errorf("shutdown hook '%s' failed: %s", hook.name, e))
+				}
+			case <-deadline:
+				for _, skipped := range hooks[i:] {
+					errors = append(errors, fmt.Errorf("shutdown hook '%s' did not finish within the grace period of %s", skipped.name, h.gracePeriod))
+				}
+				log.Printf("grace period of %s is over, abandoning %d remaining shutdown hooks\n", h.gracePeriod, len(hooks)-i)
+				return
+			}
+		}
+	})
+	return errors
+}
+
+// RunOnSignal runs the hooks when the process receives SIGTERM or SIGINT and then calls exitFn with exit code 0 if all hooks succeeded, or 1
+func (h *ShutdownHooks) RunOnSignal(exitFn func(code int)) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		sig := <-signals
+		// a second signal kills the process right away
+		signal.Reset(syscall.SIGTERM, syscall.SIGINT)
+		log.Printf("received signal '%s', running shutdown hooks with a grace period of %s (send the signal again to exit right away)\n", sig, h.gracePeriod)
+		errors := h.Run()
+		if len(errors) > 0 {
+			log.Printf("shutdown finished with %d errors, exiting\n", len(errors))
+			exitFn(1)
+			return
+		}
+		log.Printf("shutdown finished, exiting\n")
+		exitFn(0)
+	}()
+}
+
+// StopGracefully asks the command at the provided namespace to stop so it can run its shutdown hooks, and kills it if it is still running
+// after the grace period. The command needs to be unregistered once it exits, like the commands started by the GUI for bots.
+func (kos *KelpOS) StopGracefully(namespace string, gracePeriod time.Duration) error {
+	p, exists := kos.GetProcess(namespace)
+	if !exists {
+		return fmt.Errorf("process with namespace does not exist: %s", namespace)
+	}
+
+	if p.Container != "" {
+		// docker stop sends SIGTERM to the kelp process in the container and kills it after the timeout
+		log.Printf("stopping container '%s' with a grace period of %s\n", p.Container, gracePeriod)
+		output, e := exec.Command("docker", "stop", "-t", fmt.Sprintf("%d", int(gracePeriod.Seconds())), p.Container).CombinedOutput()
+		if e != nil {
+			log.Printf("could not stop container '%s', removing it: %s (output=%s)\n", p.Container, e, string(output))
+		}
+		return kos.Stop(namespace)
+	}
+
+	pid := p.Cmd.Process.Pid
+	log.Printf("sending SIGTERM to process %d with a grace period of %s\n", pid, gracePeriod)
+	e := p.Cmd.Process.Signal(syscall.SIGTERM)
+	if e != nil {
+		log.Printf("could not send SIGTERM to process %d, killing it: %s\n", pid, e)
+		return kos.Stop(namespace)
+	}
+
+	deadline := time.Now().Add(gracePeriod)
+	for time.Now().Before(deadline) {
+		current, exists := kos.GetProcess(namespace)
+		if !exists || current.Cmd.Process.Pid != pid {
+			log.Printf("process %d stopped gracefully\n", pid)
+			return nil
+		}
+		time.Sleep(stopPollInterval)
+	}
+
+	log.Printf("process %d did not stop within the grace period of %s\n", pid, gracePeriod)
+	return kos.Stop(namespace)
+}
@@ -0,0 +1,73 @@
+package kelpos
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShutdownHooks(t *testing.T) {
+	ran := []string{}
+	h := MakeShutdownHooks(time.Second)
+	h.Register("first", func() error {
+		ran = append(ran, "first")
+		return nil
+	})
+	h.Register("second", func() error {
+		ran = append(ran, "second")
+		return fmt.Errorf("failed")
+	})
+	h.Register("third", func() error {
+		ran = append(ran, "third")
+		return nil
+	})
+
+	errors := h.Run()
+	assert.Equal(t, []string{"first", "second", "third"}, ran)
+	assert.Equal(t, 1, len(errors))
+
+	// hooks only run once
+	assert.Equal(t, 0, len(h.Run()))
+	assert.Equal(t, 3, len(ran))
+}
+
+func TestShutdownHooksGracePeriod(t *testing.T) {
+	ran := []string{}
+	h := MakeShutdownHooks(50 * time.Millisecond)
+	h.Register("slow", func() error {
+		time.Sleep(time.Second)
+		return nil
+	})
+	h.Register("skipped", func() error {
+		ran = append(ran, "skipped")
+		return nil
+	})
+
+	start := time.Now()
+	errors := h.Run()
+	assert.True(t, time.Since(start) < time.Second)
+	assert.Equal(t, 2, len(errors))
+	assert.Equal(t, 0, len(ran))
+}
+
+func TestShutdownHooksOnExit(t *testing.T) {
+	ran := []string{}
+	h := MakeShutdownHooks(time.Second)
+	h.Register("signal only", func() error {
+		ran = append(ran, "signal only")
+		return nil
+	})
+	h.RegisterOnExit("on exit", func() error {
+		ran = append(ran, "on exit")
+		return nil
+	})
+
+	assert.Equal(t, 0, len(h.RunOnExit()))
+	assert.Equal(t, []string{"on exit"}, ran)
+
+	// hooks only run once
+	assert.Equal(t, 0, len(h.Run()))
+	assert.Equal(t, []string{"on exit"}, ran)
+}
@@ -0,0 +1,129 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StructuredLogger is a Logger that includes a set of fields with every entry.
+// It is also an io.Writer so it can be set as the output of the standard library logger, see LevelOfLine for the level of each line written.
+type StructuredLogger interface {
+	Logger
+	io.Writer
+
+	// SetField sets a field that is included in every subsequent entry, such as the id of the current update cycle
+	SetField(key string, value interface{})
+}
+
+// most of the code logs with the standard library logger, which has no levels, so the level of a line is derived from the words it contains
+var (
+	warnLinePattern  = regexp.MustCompile(`(?i)\b(warning|deprecated)\b`)
+	errorLinePattern = regexp.MustCompile(`(?i)\b(error|unable to|could not|couldn't|cannot|failed|panic)\b`)
+)
+
+// LevelOfLine returns the level of a line written by the standard library logger, lines that mention a warning are logged at LevelWarn,
+// lines that mention an error or a failure are logged at LevelError and all other lines are logged at LevelInfo
+func LevelOfLine(line string) Level {
+	if warnLinePattern.MatchString(line) {
+		return LevelWarn
+	}
+	if errorLinePattern.MatchString(line) {
+		return LevelError
+	}
+	return LevelInfo
+}
+
+// jsonLogger writes each entry as a single line JSON object so the logs can be shipped to log aggregators like ELK or Loki
+type jsonLogger struct {
+	out    io.Writer
+	level  Level
+	mutex  *sync.Mutex
+	fields map[string]interface{}
+}
+
+// ensure it implements StructuredLogger
+var _ StructuredLogger = &jsonLogger{}
+
+// MakeJSONLogger is the factory method, entries below the passed in level are dropped
+func MakeJSONLogger(out io.Writer, level Level, fields map[string]interface{}) StructuredLogger {
+	fieldsCopy := map[string]interface{}{}
+	for k, v := range fields {
+		fieldsCopy[k] = v
+	}
+
+	return &jsonLogger{
+		out:    out,
+		level:  level,
+		mutex:  &sync.Mutex{},
+		fields: fieldsCopy,
+	}
+}
+
+// Info impl
+func (l *jsonLogger) Info(msg string) {
+	l.log(LevelInfo, msg)
+}
+
+// Infof impl
+func (l *jsonLogger) Infof(msg string, args ...interface{}) {
+	l.log(LevelInfo, fmt.Sprintf(msg, args...))
+}
+
+// Error impl
+func (l *jsonLogger) Error(msg string) {
+	l.log(LevelError, msg)
+}
+
+// Errorf impl
+func (l *jsonLogger) Errorf(msg string, args ...interface{}) {
+	l.log(LevelError, fmt.Sprintf(msg, args...))
+}
+
+// Write impl, called by the standard library logger once for each entry
+func (l *jsonLogger) Write(p []byte) (int, error) {
+	line := string(p)
+	l.log(LevelOfLine(line), line)
+	return len(p), nil
+}
+
+// SetField impl
+func (l *jsonLogger) SetField(key string, value interface{}) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.fields[key] = value
+}
+
+func (l *jsonLogger) log(level Level, msg string) {
+	if level < l.level {
+		return
+	}
+	// the trailing newline is implied by the line-delimited JSON format
+	msg = strings.TrimRight(msg, "\n")
+	if msg == "" {
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	entry := map[string]interface{}{}
+	for k, v := range l.fields {
+		entry[k] = v
+	}
+	entry["time"] = time.Now().UTC().Format(time.RFC3339Nano)
+	entry["level"] = level.String()
+	entry["msg"] = msg
+
+	line, e := json.Marshal(entry)
+	if e != nil {
+		// fall back to logging the raw message so it is not lost
+		line = []byte(fmt.Sprintf(`{"level":"error","msg":%q}`, fmt.Sprintf("unable to marshal log entry to json (%s): %s", e, msg)))
+	}
+	l.out.Write(append(line, '\n'))
+}
@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevelOfLine(t *testing.T) {
+	testCases := []struct {
+		line string
+		want Level
+	}{
+		{"num. buyOps in this update: 3\n", LevelInfo},
+		{"shutdown finished with 0 errors, exiting\n", LevelInfo},
+		{"unable to check for zombie orders on backing exchange: timeout\n", LevelError},
+		{"error advancing bot state: bot is stopped\n", LevelError},
+		{"scheduler could not pause bot 'bot1': not running\n", LevelError},
+		{"rebalance | failed | id=1 | error=timeout\n", LevelError},
+		{"PANIC!! recovered to log it in the file\n", LevelError},
+		{"warning: skipping op that would fail with op_underfunded\n", LevelWarn},
+		{"deprecation warning: cannot set both 'A' (deprecated) and 'B'\n", LevelWarn},
+	}
+
+	for _, kase := range testCases {
+		t.Run(kase.line, func(t *testing.T) {
+			assert.Equal(t, kase.want, LevelOfLine(kase.line))
+		})
+	}
+}
+
+func TestJSONLoggerWrite(t *testing.T) {
+	out := &bytes.Buffer{}
+	l := MakeJSONLogger(out, LevelWarn, map[string]interface{}{"bot_name": "bot1"})
+
+	for _, line := range []string{"num. buyOps in this update: 3\n", "warning: order constraints changed\n", "unable to submit tx: timeout\n"} {
+		n, e := l.Write([]byte(line))
+		if !assert.NoError(t, e) {
+			return
+		}
+		assert.Equal(t, len(line), n)
+	}
+
+	// the info line is below the level of the logger
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if !assert.Equal(t, 2, len(lines)) {
+		return
+	}
+	levels := []string{}
+	for _, line := range lines {
+		entry := map[string]interface{}{}
+		if !assert.NoError(t, json.Unmarshal([]byte(line), &entry)) {
+			return
+		}
+		assert.Equal(t, "bot1", entry["bot_name"])
+		levels = append(levels, entry["level"].(string))
+	}
+	assert.Equal(t, []string{"warn", "error"}, levels)
+}
@@ -0,0 +1,141 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// rotatingFile is an io.Writer that writes to a file and rotates it once it exceeds a maximum size or age.
+// Rotated files are renamed with the time of rotation as a suffix and only the most recent maxBackups files are kept.
+type rotatingFile struct {
+	filename   string
+	maxBytes   int64
+	maxAge     time.Duration
+	maxBackups int
+
+	mutex    *sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+}
+
+// ensure it implements io.WriteCloser
+var _ io.WriteCloser = &rotatingFile{}
+
+// MakeRotatingFile is a factory method, rotation by size or by age is disabled when the corresponding value is 0 and all backups are kept when maxBackups is 0
+func MakeRotatingFile(filename string, maxBytes int64, maxAge time.Duration, maxBackups int) (io.WriteCloser, error) {
+	f := &rotatingFile{
+		filename:   filename,
+		maxBytes:   maxBytes,
+		maxAge:     maxAge,
+		maxBackups: maxBackups,
+		mutex:      &sync.Mutex{},
+	}
+
+	e := f.open()
+	if e != nil {
+		return nil, e
+	}
+	return f, nil
+}
+
+// Write impl
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.shouldRotate(int64(len(p))) {
+		e := f.rotate()
+		if e != nil {
+			return 0, fmt.Errorf("unable to rotate log file: %s", e)
+		}
+	}
+
+	n, e := f.file.Write(p)
+	f.size += int64(n)
+	return n, e
+}
+
+// Close impl
+func (f *rotatingFile) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.file.Close()
+}
+
+func (f *rotatingFile) shouldRotate(numBytes int64) bool {
+	if f.size == 0 {
+		// never rotate an empty file, otherwise an entry larger than maxBytes would rotate forever
+		return false
+	}
+	if f.maxBytes > 0 && f.size+numBytes > f.maxBytes {
+		return true
+	}
+	return f.maxAge > 0 && time.Since(f.openedAt) >= f.maxAge
+}
+
+func (f *rotatingFile) open() error {
+	file, e := os.OpenFile(f.filename, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	if e != nil {
+		return fmt.Errorf("unable to open log file: %s", e)
+	}
+	info, e := file.Stat()
+	if e != nil {
+		file.Close()
+		return fmt.Errorf("unable to stat log file: %s", e)
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = time.Now()
+	return nil
+}
+
+func (f *rotatingFile) rotate() error {
+	e := f.file.Close()
+	if e != nil {
+		return fmt.Errorf("unable to close log file: %s", e)
+	}
+
+	backupName := fmt.Sprintf("%s.%s", f.filename, time.Now().Format("20060102T150405.000000000"))
+	e = os.Rename(f.filename, backupName)
+	if e != nil {
+		return fmt.Errorf("unable to rename log file to '%s': %s", backupName, e)
+	}
+
+	e = f.removeOldBackups()
+	if e != nil {
+		return e
+	}
+	return f.open()
+}
+
+func (f *rotatingFile) removeOldBackups() error {
+	if f.maxBackups <= 0 {
+		return nil
+	}
+
+	backups, e := filepath.Glob(f.filename + ".*")
+	if e != nil {
+		return fmt.Errorf("unable to list backups of log file: %s", e)
+	}
+	if len(backups) <= f.maxBackups {
+		return nil
+	}
+
+	// the timestamp suffix sorts lexicographically in chronological order
+	sort.Strings(backups)
+	for _, name := range backups[:len(backups)-f.maxBackups] {
+		e = os.Remove(name)
+		if e != nil {
+			return fmt.Errorf("unable to remove old log file '%s': %s", name, e)
+		}
+	}
+	return nil
+}
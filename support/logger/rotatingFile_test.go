@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotatingFileBySize(t *testing.T) {
+	dir, e := ioutil.TempDir("", "kelp_logger_test")
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "bot.log")
+	f, e := MakeRotatingFile(filename, 10, 0, 2)
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer f.Close()
+
+	// each write fills the file so every subsequent write rotates it
+	for _, line := range []string{"line 1 ..\n", "line 2 ..\n", "line 3 ..\n", "line 4 ..\n"} {
+		_, e = f.Write([]byte(line))
+		if !assert.NoError(t, e) {
+			return
+		}
+	}
+
+	current, e := ioutil.ReadFile(filename)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, "line 4 ..\n", string(current))
+
+	backups, e := filepath.Glob(filename + ".*")
+	if !assert.NoError(t, e) {
+		return
+	}
+	if !assert.Equal(t, 2, len(backups)) {
+		return
+	}
+	// only the most recent backups are kept
+	for i, want := range []string{"line 2 ..\n", "line 3 ..\n"} {
+		contents, e := ioutil.ReadFile(backups[i])
+		if !assert.NoError(t, e) {
+			return
+		}
+		assert.Equal(t, want, string(contents))
+	}
+}
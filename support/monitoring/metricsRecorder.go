@@ -1,10 +1,14 @@
 package monitoring
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"sync"
+)
 
-// MetricsRecorder uses a map to store metrics and implements the api.Metrics interface.
+// MetricsRecorder uses a map to store metrics and implements the api.Metrics interface. It is safe to update the metrics while they are served.
 type metricsRecorder struct {
 	records map[string]interface{}
+	mutex   sync.Mutex
 }
 
 var _ Metrics = &metricsRecorder{}
@@ -25,6 +29,8 @@ func MakeMetricsRecorder(records map[string]interface{}) (Metrics, error) {
 // UpdateMetrics updates (or adds if non-existent) metrics in the records for all key-value
 // pairs in the provided map of metrics.
 func (m *metricsRecorder) UpdateMetrics(metrics map[string]interface{}) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
 	for k, v := range metrics {
 		m.records[k] = v
 	}
@@ -32,5 +38,7 @@ func (m *metricsRecorder) UpdateMetrics(metrics map[string]interface{}) {
 
 // MarshalJSON gives the JSON representation of the records.
 func (m *metricsRecorder) MarshalJSON() ([]byte, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
 	return json.Marshal(m.records)
 }
@@ -0,0 +1,108 @@
+package monitoring
+
+import (
+	"sync"
+	"time"
+)
+
+// names of the spans of an update cycle
+const (
+	SpanLoadBalances    = "load_balances"
+	SpanLoadOffers      = "load_offers"
+	SpanLiabilities     = "liabilities"
+	SpanPreUpdate       = "pre_update"
+	SpanPruneOffers     = "prune_offers"
+	SpanOpsConstruction = "ops_construction" // includes the price and orderbook fetches of the strategy
+	SpanSubmitFilters   = "submit_filters"
+	SpanTxSubmit        = "tx_submit" // time the update cycle spends submitting ops, includes the confirmation when ops are submitted synchronously
+	SpanPostUpdate      = "post_update"
+	SpanPriceFetch      = "price_fetch"
+	SpanOrderBookFetch  = "orderbook_fetch"
+	SpanTxBuild         = "tx_build"   // building and signing a transaction
+	SpanTxConfirm       = "tx_confirm" // from sending a transaction to the network until its result is known
+)
+
+// Span is the time spent in a named step of an update cycle, steps that run more than once in a cycle are aggregated into a single span
+type Span struct {
+	Name              string `json:"name"`
+	StartOffsetMillis int64  `json:"start_offset_millis"` // when the step first started, relative to the start of the update cycle
+	DurationMillis    int64  `json:"duration_millis"`     // total time of all runs of the step
+	Count             int    `json:"count"`
+}
+
+// SpanRecorder records the spans of a single update cycle, it is safe for concurrent use since transactions are confirmed asynchronously
+type SpanRecorder struct {
+	start time.Time
+	mutex *sync.Mutex
+	spans []*Span
+	index map[string]*Span
+}
+
+// MakeSpanRecorder is a factory method
+func MakeSpanRecorder(start time.Time) *SpanRecorder {
+	return &SpanRecorder{
+		start: start,
+		mutex: &sync.Mutex{},
+		spans: []*Span{},
+		index: map[string]*Span{},
+	}
+}
+
+// Record adds a run of the named step
+func (r *SpanRecorder) Record(name string, start time.Time, end time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	s, ok := r.index[name]
+	if !ok {
+		s = &Span{
+			Name:              name,
+			StartOffsetMillis: start.Sub(r.start).Nanoseconds() / int64(time.Millisecond),
+		}
+		r.index[name] = s
+		r.spans = append(r.spans, s)
+	}
+	s.DurationMillis += end.Sub(start).Nanoseconds() / int64(time.Millisecond)
+	s.Count++
+}
+
+// Spans returns a copy of the spans in the order in which the steps first started
+func (r *SpanRecorder) Spans() []Span {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	spans := []Span{}
+	for _, s := range r.spans {
+		spans = append(spans, *s)
+	}
+	return spans
+}
+
+// the recorder of the running update cycle, there is one bot per process so plugins can add spans without threading the recorder through
+// every call
+var currentSpanRecorder *SpanRecorder
+var currentSpanRecorderMutex = &sync.Mutex{}
+
+// SetCurrentSpanRecorder sets the recorder of the update cycle that is starting, nil when the update cycle has ended
+func SetCurrentSpanRecorder(r *SpanRecorder) {
+	currentSpanRecorderMutex.Lock()
+	defer currentSpanRecorderMutex.Unlock()
+	currentSpanRecorder = r
+}
+
+// StartSpan starts a run of the named step in the running update cycle and returns the function that ends it, use as
+// defer monitoring.StartSpan(name)(). The run is attributed to the cycle that was running when it started and it is dropped when no
+// cycle is running.
+func StartSpan(name string) func() {
+	currentSpanRecorderMutex.Lock()
+	r := currentSpanRecorder
+	currentSpanRecorderMutex.Unlock()
+
+	if r == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		r.Record(name, start, time.Now())
+	}
+}
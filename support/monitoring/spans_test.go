@@ -0,0 +1,41 @@
+package monitoring
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpanRecorder(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	r := MakeSpanRecorder(start)
+	r.Record(SpanLoadBalances, start, start.Add(120*time.Millisecond))
+	r.Record(SpanPriceFetch, start.Add(200*time.Millisecond), start.Add(250*time.Millisecond))
+	r.Record(SpanPriceFetch, start.Add(300*time.Millisecond), start.Add(330*time.Millisecond))
+
+	assert.Equal(t, []Span{
+		{Name: SpanLoadBalances, StartOffsetMillis: 0, DurationMillis: 120, Count: 1},
+		{Name: SpanPriceFetch, StartOffsetMillis: 200, DurationMillis: 80, Count: 2},
+	}, r.Spans())
+}
+
+func TestStartSpan(t *testing.T) {
+	SetCurrentSpanRecorder(nil)
+	// spans are dropped when no update cycle is running
+	StartSpan(SpanTxBuild)()
+
+	r := MakeSpanRecorder(time.Now())
+	SetCurrentSpanRecorder(r)
+	defer SetCurrentSpanRecorder(nil)
+	end := StartSpan(SpanTxBuild)
+	// the run stays attributed to the cycle in which it started
+	SetCurrentSpanRecorder(MakeSpanRecorder(time.Now()))
+	end()
+
+	spans := r.Spans()
+	if assert.Equal(t, 1, len(spans)) {
+		assert.Equal(t, SpanTxBuild, spans[0].Name)
+		assert.Equal(t, 1, spans[0].Count)
+	}
+}
@@ -0,0 +1,190 @@
+package networking
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheRule reuses the responses of GET requests with a URL path that matches the pattern for the TTL
+type CacheRule struct {
+	PathPattern *regexp.Regexp
+	TTL         time.Duration
+}
+
+// HorizonCacheRules returns the rules that cache the orderbook and account details endpoints of horizon, a TTL of 0 does not cache the endpoint.
+// The offers, trades and other endpoints of an account are never cached because the bots need to see the effects of their own transactions.
+func HorizonCacheRules(orderbookTTL time.Duration, accountTTL time.Duration) []CacheRule {
+	rules := []CacheRule{}
+	if orderbookTTL > 0 {
+		rules = append(rules, CacheRule{PathPattern: regexp.MustCompile(`/order_book/?$`), TTL: orderbookTTL})
+	}
+	if accountTTL > 0 {
+		rules = append(rules, CacheRule{PathPattern: regexp.MustCompile(`/accounts/[^/]+/?$`), TTL: accountTTL})
+	}
+	return rules
+}
+
+// CachingHTTPClient wraps an http.Client and reuses the successful responses of GET requests that match one of its rules, so components
+// that request the same data within one cycle only hit the server once. Once the TTL of a response expires it is revalidated with a
+// conditional request using the ETag and Last-Modified headers of the response, when the server sends them.
+// It satisfies the HTTP interfaces of the horizon clients.
+type CachingHTTPClient struct {
+	client  *http.Client
+	rules   []CacheRule
+	mutex   *sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+// cacheEntry is a successful response of a GET request
+type cacheEntry struct {
+	status       string
+	statusCode   int
+	header       http.Header
+	body         []byte
+	fetchedAt    time.Time
+	etag         string
+	lastModified string
+}
+
+// MakeCachingHTTPClient is a factory method
+func MakeCachingHTTPClient(client *http.Client, rules []CacheRule) *CachingHTTPClient {
+	return &CachingHTTPClient{
+		client:  client,
+		rules:   rules,
+		mutex:   &sync.Mutex{},
+		entries: map[string]*cacheEntry{},
+	}
+}
+
+// Do executes the request, serving GET requests from the cache when possible
+func (c *CachingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	ttl := c.ttl(req)
+	if ttl == 0 {
+		return c.client.Do(req)
+	}
+
+	key := req.URL.String()
+	c.mutex.Lock()
+	entry := c.entries[key]
+	c.mutex.Unlock()
+	if entry != nil && time.Since(entry.fetchedAt) < ttl {
+		return entry.response(req), nil
+	}
+
+	sendReq := req
+	if entry != nil && (entry.etag != "" || entry.lastModified != "") {
+		sendReq = conditionalRequest(req, entry)
+	}
+	resp, e := c.client.Do(sendReq)
+	if e != nil {
+		return nil, e
+	}
+
+	if resp.StatusCode == http.StatusNotModified && sendReq != req {
+		resp.Body.Close()
+		refreshed := *entry
+		refreshed.fetchedAt = time.Now()
+		c.store(key, &refreshed)
+		return refreshed.response(req), nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+	body, e := ioutil.ReadAll(resp.Body)
+	if e != nil {
+		return nil, e
+	}
+	fresh := &cacheEntry{
+		status:       resp.Status,
+		statusCode:   resp.StatusCode,
+		header:       resp.Header,
+		body:         body,
+		fetchedAt:    time.Now(),
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+	}
+	c.store(key, fresh)
+	return fresh.response(req), nil
+}
+
+// Get issues a GET request to the URL
+func (c *CachingHTTPClient) Get(url string) (*http.Response, error) {
+	req, e := http.NewRequest("GET", url, nil)
+	if e != nil {
+		return nil, e
+	}
+	return c.Do(req)
+}
+
+// PostForm issues a POST request to the URL, which is never cached
+func (c *CachingHTTPClient) PostForm(url string, data url.Values) (*http.Response, error) {
+	return c.client.PostForm(url, data)
+}
+
+// Uncached returns the wrapped client, for requests that always need the current data such as loading the sequence number of an account
+func (c *CachingHTTPClient) Uncached() *http.Client {
+	return c.client
+}
+
+// ttl returns how long the response of the request can be reused, 0 when it should not be cached
+func (c *CachingHTTPClient) ttl(req *http.Request) time.Duration {
+	// streaming requests are long-lived and never cached
+	if req.Method != "GET" || strings.Contains(req.Header.Get("Accept"), "text/event-stream") {
+		return 0
+	}
+	for _, rule := range c.rules {
+		if rule.PathPattern.MatchString(req.URL.Path) {
+			return rule.TTL
+		}
+	}
+	return 0
+}
+
+func (c *CachingHTTPClient) store(key string, entry *cacheEntry) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries[key] = entry
+}
+
+// conditionalRequest copies the request with the headers that let the server answer with 304 Not Modified when the entry is still current
+func conditionalRequest(req *http.Request, entry *cacheEntry) *http.Request {
+	condReq := req.WithContext(req.Context())
+	condReq.Header = http.Header{}
+	for k, v := range req.Header {
+		condReq.Header[k] = v
+	}
+	if entry.etag != "" {
+		condReq.Header.Set("If-None-Match", entry.etag)
+	}
+	if entry.lastModified != "" {
+		condReq.Header.Set("If-Modified-Since", entry.lastModified)
+	}
+	return condReq
+}
+
+// response makes a new response for the request with a copy of the cached body so every caller can read and close it
+func (entry *cacheEntry) response(req *http.Request) *http.Response {
+	header := http.Header{}
+	for k, v := range entry.header {
+		header[k] = v
+	}
+	return &http.Response{
+		Status:        entry.status,
+		StatusCode:    entry.statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(entry.body)),
+		ContentLength: int64(len(entry.body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,69 @@
+package networking
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCachingHTTPClient(t *testing.T) {
+	requests := map[string]int{}
+	conditional := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			conditional++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprintf(w, "response %d of %s", requests[r.URL.Path], r.URL.Path)
+	}))
+	defer server.Close()
+
+	c := MakeCachingHTTPClient(http.DefaultClient, HorizonCacheRules(time.Hour, 20*time.Millisecond))
+	get := func(path string) string {
+		resp, e := c.Get(server.URL + path)
+		if !assert.NoError(t, e) {
+			return ""
+		}
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		body, e := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, e)
+		return string(body)
+	}
+
+	// cached within the TTL
+	assert.Equal(t, "response 1 of /order_book", get("/order_book"))
+	assert.Equal(t, "response 1 of /order_book", get("/order_book"))
+	assert.Equal(t, 1, requests["/order_book"])
+
+	// revalidated with a conditional request after the TTL
+	assert.Equal(t, "response 1 of /accounts/GABC", get("/accounts/GABC"))
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, "response 1 of /accounts/GABC", get("/accounts/GABC"))
+	assert.Equal(t, 2, requests["/accounts/GABC"])
+	assert.Equal(t, 1, conditional)
+
+	// other endpoints of an account are not cached
+	assert.Equal(t, "response 1 of /accounts/GABC/offers", get("/accounts/GABC/offers"))
+	assert.Equal(t, "response 2 of /accounts/GABC/offers", get("/accounts/GABC/offers"))
+
+	// streaming requests are not cached
+	req, e := http.NewRequest("GET", server.URL+"/order_book", nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, e := c.Do(req)
+	if assert.NoError(t, e) {
+		resp.Body.Close()
+	}
+	assert.Equal(t, 2, requests["/order_book"])
+}
@@ -2,9 +2,16 @@ package prefs
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
+	"strconv"
+	"strings"
 )
 
+// Version is the format version of the preferences file written by this version of Kelp.
+// Version 0 is the legacy empty file, version 1 stores the version number as the contents of the file.
+const Version = 1
+
 // Preferences denotes a preferences file
 type Preferences struct {
 	filepath string
@@ -27,10 +34,54 @@ func (p *Preferences) FirstTime() bool {
 
 // SetNotFirstTime saves a file on the file system to denote that this is not the first time
 func (p *Preferences) SetNotFirstTime() error {
-	emptyFile, e := os.Create(p.filepath)
+	e := p.write()
 	if e != nil {
 		return fmt.Errorf("could not create file '%s' when setting not first time prefs file: %s", p.filepath, e)
 	}
-	emptyFile.Close()
 	return nil
 }
+
+// CheckCompat should be called at startup, it migrates a preferences file written by an older version of Kelp to the current Version
+// and fails fast if the file was written by a newer version of Kelp whose format we cannot read
+func (p *Preferences) CheckCompat() error {
+	if p.FirstTime() {
+		return nil
+	}
+
+	version, e := p.readVersion()
+	if e != nil {
+		return fmt.Errorf("could not read version of prefs file '%s': %s", p.filepath, e)
+	}
+	if version > Version {
+		return fmt.Errorf("prefs file '%s' was written by a newer version of Kelp (version=%d, max supported version=%d), upgrade Kelp or delete the file", p.filepath, version, Version)
+	}
+	if version < Version {
+		e = p.write()
+		if e != nil {
+			return fmt.Errorf("could not migrate prefs file '%s' from version %d to version %d: %s", p.filepath, version, Version, e)
+		}
+	}
+	return nil
+}
+
+func (p *Preferences) readVersion() (int, error) {
+	contents, e := ioutil.ReadFile(p.filepath)
+	if e != nil {
+		return 0, e
+	}
+
+	s := strings.TrimSpace(string(contents))
+	if s == "" {
+		// legacy prefs files were empty
+		return 0, nil
+	}
+	version, e := strconv.Atoi(s)
+	if e != nil {
+		return 0, fmt.Errorf("unrecognized contents, expected a version number: %s", e)
+	}
+	return version, nil
+}
+
+func (p *Preferences) write() error {
+	return ioutil.WriteFile(p.filepath, []byte(fmt.Sprintf("%d\n", Version)), 0666)
+}
@@ -9,6 +9,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/mitchellh/mapstructure"
 	"github.com/stellar/kelp/api"
@@ -35,6 +36,7 @@ type Ccxt struct {
 	httpClient   *http.Client
 	exchangeName string
 	instanceName string
+	marketsMutex *sync.RWMutex
 	markets      map[string]CcxtMarket
 	headersMap   map[string]string
 }
@@ -78,6 +80,7 @@ func MakeInitializedCcxtExchange(exchangeName string, apiKey api.ExchangeAPIKey,
 		httpClient:   http.DefaultClient,
 		exchangeName: exchangeName,
 		instanceName: instanceName,
+		marketsMutex: &sync.RWMutex{},
 	}
 
 	e = c.initialize(apiKey, params, headers)
@@ -148,19 +151,10 @@ func (c *Ccxt) initialize(apiKey api.ExchangeAPIKey, params []api.ExchangeParam,
 	}
 
 	// load markets to populate fields related to markets
-	var marketsResponse interface{}
-	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/loadMarkets"
-	e = networking.JSONRequest(c.httpClient, "POST", url, "", map[string]string{}, &marketsResponse, "error")
-	if e != nil {
-		return fmt.Errorf("error loading markets for exchange instance (exchange=%s, instanceName=%s): %s", c.exchangeName, c.instanceName, e)
-	}
-	// decode markets and sets it on the ccxt instance
-	var markets map[string]CcxtMarket
-	e = mapstructure.Decode(marketsResponse, &markets)
+	e = c.loadMarkets(false)
 	if e != nil {
-		return fmt.Errorf("error converting loadMarkets output to a map of Market for exchange instance (exchange=%s, instanceName=%s): %s", c.exchangeName, c.instanceName, e)
+		return e
 	}
-	c.markets = markets
 
 	headersMap := map[string]string{}
 	for _, header := range headers {
@@ -227,6 +221,37 @@ func (c *Ccxt) newInstance(apiKey api.ExchangeAPIKey, params []api.ExchangeParam
 	return nil
 }
 
+// ReloadMarkets forces CCXT to fetch the markets from the exchange again, which updates the precision and limits of each market
+func (c *Ccxt) ReloadMarkets() error {
+	return c.loadMarkets(true)
+}
+
+func (c *Ccxt) loadMarkets(reload bool) error {
+	data := ""
+	if reload {
+		// ccxt returns the markets it has cached unless the reload argument is set
+		data = "[true]"
+	}
+
+	var marketsResponse interface{}
+	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/loadMarkets"
+	e := networking.JSONRequest(c.httpClient, "POST", url, data, map[string]string{}, &marketsResponse, "error")
+	if e != nil {
+		return fmt.Errorf("error loading markets for exchange instance (exchange=%s, instanceName=%s): %s", c.exchangeName, c.instanceName, e)
+	}
+	// decode markets and sets it on the ccxt instance
+	var markets map[string]CcxtMarket
+	e = mapstructure.Decode(marketsResponse, &markets)
+	if e != nil {
+		return fmt.Errorf("error converting loadMarkets output to a map of Market for exchange instance (exchange=%s, instanceName=%s): %s", c.exchangeName, c.instanceName, e)
+	}
+
+	c.marketsMutex.Lock()
+	defer c.marketsMutex.Unlock()
+	c.markets = markets
+	return nil
+}
+
 // symbolExists returns an error if the symbol does not exist
 func (c *Ccxt) symbolExists(tradingPair string) error {
 	// get list of symbols available on exchange
@@ -256,6 +281,9 @@ func (c *Ccxt) symbolExists(tradingPair string) error {
 
 // GetMarket returns the CcxtMarket instance
 func (c *Ccxt) GetMarket(tradingPair string) *CcxtMarket {
+	c.marketsMutex.RLock()
+	defer c.marketsMutex.RUnlock()
+
 	if v, ok := c.markets[tradingPair]; ok {
 		return &v
 	}
@@ -264,6 +292,9 @@ func (c *Ccxt) GetMarket(tradingPair string) *CcxtMarket {
 
 // GetMarkets returns all the markets
 func (c *Ccxt) GetMarkets() map[string]CcxtMarket {
+	c.marketsMutex.RLock()
+	defer c.marketsMutex.RUnlock()
+
 	return c.markets
 }
 
@@ -293,6 +324,27 @@ func (c *Ccxt) FetchTicker(tradingPair string) (map[string]interface{}, error) {
 	return tickerMap, nil
 }
 
+// HasFetchTime returns true when the exchange reports its current time with fetchTime
+func (c *Ccxt) HasFetchTime() (bool, error) {
+	return c.has("fetchTime")
+}
+
+// FetchTime calls the /fetchTime endpoint on CCXT, returning the current time of the exchange as a unix timestamp in milliseconds
+func (c *Ccxt) FetchTime() (int64, error) {
+	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/fetchTime"
+	var output interface{}
+	e := networking.JSONRequest(c.httpClient, "POST", url, "", c.headersMap, &output, "error")
+	if e != nil {
+		return 0, fmt.Errorf("error fetching the time of exchange '%s': %s", c.exchangeName, e)
+	}
+
+	millis, ok := output.(float64)
+	if !ok {
+		return 0, fmt.Errorf("could not convert the time of exchange '%s' to a float64, type = %s", c.exchangeName, reflect.TypeOf(output))
+	}
+	return int64(millis), nil
+}
+
 // CcxtOrder represents an order in the orderbook
 type CcxtOrder struct {
 	Price  float64
@@ -357,6 +409,7 @@ type CcxtTrade struct {
 	Cost      float64 `json:"cost"`
 	Datetime  string  `json:"datetime"`
 	ID        string  `json:"id"`
+	Order     string  `json:"order"` // ID of the order that the trade filled, empty when the exchange does not report it
 	Price     float64 `json:"price"`
 	Side      string  `json:"side"`
 	Symbol    string  `json:"symbol"`
@@ -392,6 +445,60 @@ func (c *Ccxt) FetchTrades(tradingPair string) ([]CcxtTrade, error) {
 	return output, nil
 }
 
+// CcxtCandle represents an OHLCV candle as returned by CCXT, the volume is in units of the base asset
+type CcxtCandle struct {
+	Timestamp int64
+	Open      float64
+	High      float64
+	Low       float64
+	Close     float64
+	Volume    float64
+}
+
+// FetchOHLCV calls the /fetchOHLCV endpoint on CCXT, trading pair is the CCXT version of the trading pair and timeframe is the CCXT name
+// of the interval (example: "5m"). The most recent candles are returned when maybeSince is nil.
+func (c *Ccxt) FetchOHLCV(tradingPair string, timeframe string, maybeSince *int64) ([]CcxtCandle, error) {
+	e := c.symbolExists(tradingPair)
+	if e != nil {
+		return nil, fmt.Errorf("symbol does not exist: %s", e)
+	}
+
+	// marshal input data
+	input := []interface{}{tradingPair, timeframe}
+	if maybeSince != nil {
+		input = append(input, *maybeSince)
+	}
+	data, e := json.Marshal(&input)
+	if e != nil {
+		return nil, fmt.Errorf("error marshaling input (tradingPair=%s, timeframe=%s) as an array for exchange '%s': %s", tradingPair, timeframe, c.exchangeName, e)
+	}
+
+	// fetch candles for symbol
+	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/fetchOHLCV"
+	// each candle is an array of [timestamp, open, high, low, close, volume]
+	output := [][]float64{}
+	e = networking.JSONRequest(c.httpClient, "POST", url, string(data), c.headersMap, &output, "error")
+	if e != nil {
+		return nil, fmt.Errorf("error fetching candles for trading pair '%s' (timeframe=%s): %s", tradingPair, timeframe, e)
+	}
+
+	candles := []CcxtCandle{}
+	for _, o := range output {
+		if len(o) < 6 {
+			return nil, fmt.Errorf("invalid candle for trading pair '%s', needs 6 values: %v", tradingPair, o)
+		}
+		candles = append(candles, CcxtCandle{
+			Timestamp: int64(o[0]),
+			Open:      o[1],
+			High:      o[2],
+			Low:       o[3],
+			Close:     o[4],
+			Volume:    o[5],
+		})
+	}
+	return candles, nil
+}
+
 // FetchMyTrades calls the /fetchMyTrades endpoint on CCXT, trading pair is the CCXT version of the trading pair
 func (c *Ccxt) FetchMyTrades(tradingPair string, limit int, maybeCursorStart interface{}) ([]CcxtTrade, error) {
 	e := c.symbolExists(tradingPair)
@@ -425,6 +532,97 @@ func (c *Ccxt) FetchMyTrades(tradingPair string, limit int, maybeCursorStart int
 	return output, nil
 }
 
+// FetchOrderTrades returns the trades of the account that filled the order, trading pair is the CCXT version of the trading pair. It calls the
+// /fetchOrderTrades endpoint on CCXT when the exchange supports it, otherwise it calls the /fetchMyTrades endpoint and keeps the trades of
+// the order, which only finds the trades of recent orders.
+func (c *Ccxt) FetchOrderTrades(orderID string, tradingPair string) ([]CcxtTrade, error) {
+	e := c.symbolExists(tradingPair)
+	if e != nil {
+		return nil, fmt.Errorf("symbol does not exist: %s", e)
+	}
+
+	hasFetchOrderTrades, e := c.has("fetchOrderTrades")
+	if e != nil {
+		return nil, fmt.Errorf("error checking whether exchange '%s' supports fetchOrderTrades: %s", c.exchangeName, e)
+	}
+	method := "fetchOrderTrades"
+	inputData := []interface{}{orderID, tradingPair}
+	if !hasFetchOrderTrades {
+		method = "fetchMyTrades"
+		inputData = []interface{}{tradingPair}
+	}
+
+	// marshal input data
+	data, e := json.Marshal(&inputData)
+	if e != nil {
+		return nil, fmt.Errorf("error marshaling input (%v) for exchange '%s': %s", inputData, c.exchangeName, e)
+	}
+
+	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/" + method
+	// decode generic data (see "https://blog.golang.org/json-and-go#TOC_4.")
+	output := []CcxtTrade{}
+	e = networking.JSONRequest(c.httpClient, "POST", url, string(data), c.headersMap, &output, "error")
+	if e != nil {
+		return nil, fmt.Errorf("error fetching trades for order '%s' on trading pair '%s': %s", orderID, tradingPair, e)
+	}
+
+	trades := []CcxtTrade{}
+	for _, t := range output {
+		if t.Order == orderID {
+			trades = append(trades, t)
+		}
+	}
+	return trades, nil
+}
+
+// has returns true when the exchange supports the CCXT method natively or by emulating it
+func (c *Ccxt) has(method string) (bool, error) {
+	v, e := c.hasValue(method)
+	if e != nil {
+		return false, e
+	}
+	// CCXT reports true, false or "emulated"
+	switch v := v.(type) {
+	case bool:
+		return v, nil
+	case string:
+		return v == "emulated", nil
+	default:
+		return false, nil
+	}
+}
+
+// HasNative returns true when the exchange supports the CCXT method natively, methods that CCXT emulates using other methods return false
+func (c *Ccxt) HasNative(method string) (bool, error) {
+	v, e := c.hasValue(method)
+	if e != nil {
+		return false, e
+	}
+	native, ok := v.(bool)
+	return ok && native, nil
+}
+
+// hasValue returns the value of the method in the "has" map of the exchange details, or nil when the method is not listed
+func (c *Ccxt) hasValue(method string) (interface{}, error) {
+	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName
+	// decode generic data (see "https://blog.golang.org/json-and-go#TOC_4.")
+	var exchangeOutput interface{}
+	e := networking.JSONRequest(c.httpClient, "GET", url, "", c.headersMap, &exchangeOutput, "error")
+	if e != nil {
+		return nil, fmt.Errorf("error fetching details of exchange instance (exchange=%s, instanceName=%s): %s", c.exchangeName, c.instanceName, e)
+	}
+
+	exchangeMap, ok := exchangeOutput.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("could not convert the details of exchange instance to a map[string]interface{}, type = %s", reflect.TypeOf(exchangeOutput))
+	}
+	hasMap, ok := exchangeMap["has"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("'has' field not in result of exchange details (exchange=%s, instanceName=%s)", c.exchangeName, c.instanceName)
+	}
+	return hasMap[method], nil
+}
+
 // CcxtBalance represents the balance for an asset
 type CcxtBalance struct {
 	Total float64
@@ -483,6 +681,8 @@ type CcxtOpenOrder struct {
 	Symbol    string
 	Type      string
 	Timestamp int64
+	// ClientOrderID is the ID set on the order when it was created, empty for orders without one
+	ClientOrderID string
 }
 
 // FetchOpenOrders calls the /fetchOpenOrders endpoint on CCXT
@@ -537,7 +737,32 @@ func (c *Ccxt) FetchOpenOrders(tradingPairs []string) (map[string][]CcxtOpenOrde
 
 // CreateLimitOrder calls the /createOrder endpoint on CCXT with a limit price and the order type set to "limit"
 func (c *Ccxt) CreateLimitOrder(tradingPair string, side string, amount float64, price float64) (*CcxtOpenOrder, error) {
-	orderType := "limit"
+	return c.createLimitOrder(tradingPair, side, amount, price, nil)
+}
+
+// CreatePostOnlyLimitOrder calls the /createOrder endpoint on CCXT with the unified postOnly param, so the exchange rejects the order
+// instead of filling it when it would take liquidity
+func (c *Ccxt) CreatePostOnlyLimitOrder(tradingPair string, side string, amount float64, price float64) (*CcxtOpenOrder, error) {
+	return c.createLimitOrder(tradingPair, side, amount, price, map[string]interface{}{"postOnly": true})
+}
+
+// CreateLimitOrderWithParams calls the /createOrder endpoint on CCXT with a limit price, the order type set to "limit" and the params, which
+// can be unified params such as "postOnly" and "timeInForce" or params specific to the exchange. nil params are not sent.
+func (c *Ccxt) CreateLimitOrderWithParams(tradingPair string, side string, amount float64, price float64, params map[string]interface{}) (*CcxtOpenOrder, error) {
+	return c.createLimitOrder(tradingPair, side, amount, price, params)
+}
+
+// CreateStopOrder calls the /createOrder endpoint on CCXT with the stopPrice param, the orderType is the name of the stop order type on the
+// exchange and the price is nil for stop orders that become market orders when they are triggered
+func (c *Ccxt) CreateStopOrder(tradingPair string, orderType string, side string, amount float64, price *float64, stopPrice float64) (*CcxtOpenOrder, error) {
+	return c.createOrder(tradingPair, orderType, side, amount, price, map[string]interface{}{"stopPrice": stopPrice})
+}
+
+func (c *Ccxt) createLimitOrder(tradingPair string, side string, amount float64, price float64, params map[string]interface{}) (*CcxtOpenOrder, error) {
+	return c.createOrder(tradingPair, "limit", side, amount, &price, params)
+}
+
+func (c *Ccxt) createOrder(tradingPair string, orderType string, side string, amount float64, price *float64, params map[string]interface{}) (*CcxtOpenOrder, error) {
 	e := c.symbolExists(tradingPair)
 	if e != nil {
 		return nil, fmt.Errorf("symbol does not exist: %s", e)
@@ -551,6 +776,9 @@ func (c *Ccxt) CreateLimitOrder(tradingPair string, side string, amount float64,
 		amount,
 		price,
 	}
+	if params != nil {
+		inputData = append(inputData, params)
+	}
 	data, e := json.Marshal(&inputData)
 	if e != nil {
 		return nil, fmt.Errorf("error marshaling input (%v) for exchange '%s': %s", inputData, c.exchangeName, e)
@@ -578,6 +806,53 @@ func (c *Ccxt) CreateLimitOrder(tradingPair string, side string, amount float64,
 	return &openOrder, nil
 }
 
+// EditLimitOrder calls the /editOrder endpoint on CCXT to change the amount and price of an open limit order, the unified postOnly param is
+// set for post-only orders. Some exchanges return the edited order with a new ID.
+func (c *Ccxt) EditLimitOrder(orderID string, tradingPair string, side string, amount float64, price float64, postOnly bool) (*CcxtOpenOrder, error) {
+	e := c.symbolExists(tradingPair)
+	if e != nil {
+		return nil, fmt.Errorf("symbol does not exist: %s", e)
+	}
+
+	// marshal input data
+	inputData := []interface{}{
+		orderID,
+		tradingPair,
+		"limit",
+		side,
+		amount,
+		price,
+	}
+	if postOnly {
+		inputData = append(inputData, map[string]interface{}{"postOnly": true})
+	}
+	data, e := json.Marshal(&inputData)
+	if e != nil {
+		return nil, fmt.Errorf("error marshaling input (%v) for exchange '%s': %s", inputData, c.exchangeName, e)
+	}
+
+	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/editOrder"
+	// decode generic data (see "https://blog.golang.org/json-and-go#TOC_4.")
+	var output interface{}
+	e = networking.JSONRequest(c.httpClient, "POST", url, string(data), c.headersMap, &output, "error")
+	if e != nil {
+		return nil, fmt.Errorf("error editing order: %s", e)
+	}
+
+	outputMap, ok := output.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("could not convert the output to a map[string]interface{}, type = %s", reflect.TypeOf(output))
+	}
+
+	var openOrder CcxtOpenOrder
+	e = mapstructure.Decode(outputMap, &openOrder)
+	if e != nil {
+		return nil, fmt.Errorf("could not decode outputMap to openOrder (%v): %s", outputMap, e)
+	}
+
+	return &openOrder, nil
+}
+
 // CancelOrder calls the /cancelOrder endpoint on CCXT with the orderID and tradingPair
 func (c *Ccxt) CancelOrder(orderID string, tradingPair string) (*CcxtOpenOrder, error) {
 	e := c.symbolExists(tradingPair)
@@ -616,3 +891,85 @@ func (c *Ccxt) CancelOrder(orderID string, tradingPair string) (*CcxtOpenOrder,
 
 	return &openOrder, nil
 }
+
+// CcxtTransaction represents a deposit or withdrawal
+type CcxtTransaction struct {
+	ID        string
+	TxID      string
+	Timestamp int64
+	Address   string
+	Tag       string
+	Type      string
+	Amount    float64
+	Currency  string
+	Status    string // one of "pending", "ok", "failed" or "canceled"
+}
+
+// Withdraw calls the /withdraw endpoint on CCXT, tag is the memo of the transfer and is omitted when empty
+func (c *Ccxt) Withdraw(currency string, amount float64, address string, tag string) (*CcxtTransaction, error) {
+	inputData := []interface{}{
+		currency,
+		amount,
+		address,
+	}
+	if tag != "" {
+		inputData = append(inputData, tag)
+	}
+	data, e := json.Marshal(&inputData)
+	if e != nil {
+		return nil, fmt.Errorf("error marshaling input (%v) for exchange '%s': %s", inputData, c.exchangeName, e)
+	}
+
+	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/withdraw"
+	// decode generic data (see "https://blog.golang.org/json-and-go#TOC_4.")
+	var output interface{}
+	e = networking.JSONRequest(c.httpClient, "POST", url, string(data), c.headersMap, &output, "error")
+	if e != nil {
+		return nil, fmt.Errorf("error withdrawing %f %s: %s", amount, currency, e)
+	}
+
+	outputMap, ok := output.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("could not convert the output to a map[string]interface{}, type = %s", reflect.TypeOf(output))
+	}
+	var transaction CcxtTransaction
+	e = mapstructure.Decode(outputMap, &transaction)
+	if e != nil {
+		return nil, fmt.Errorf("could not decode outputMap to transaction (%v): %s", outputMap, e)
+	}
+	return &transaction, nil
+}
+
+// FetchWithdrawals calls the /fetchWithdrawals endpoint on CCXT
+func (c *Ccxt) FetchWithdrawals(currency string) ([]CcxtTransaction, error) {
+	inputData := []interface{}{
+		currency,
+	}
+	data, e := json.Marshal(&inputData)
+	if e != nil {
+		return nil, fmt.Errorf("error marshaling input (%v) for exchange '%s': %s", inputData, c.exchangeName, e)
+	}
+
+	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/fetchWithdrawals"
+	// decode generic data (see "https://blog.golang.org/json-and-go#TOC_4.")
+	var output interface{}
+	e = networking.JSONRequest(c.httpClient, "POST", url, string(data), c.headersMap, &output, "error")
+	if e != nil {
+		return nil, fmt.Errorf("error fetching withdrawals of %s: %s", currency, e)
+	}
+
+	outputList, ok := output.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("could not convert the output to a []interface{}, type = %s", reflect.TypeOf(output))
+	}
+	transactions := []CcxtTransaction{}
+	for _, o := range outputList {
+		var transaction CcxtTransaction
+		e = mapstructure.Decode(o, &transaction)
+		if e != nil {
+			return nil, fmt.Errorf("could not decode withdrawal (%v): %s", o, e)
+		}
+		transactions = append(transactions, transaction)
+	}
+	return transactions, nil
+}
@@ -0,0 +1,189 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stellar/kelp/api"
+)
+
+// ccxtProReadTimeout fails a watch when the bridge does not push anything, ccxt-pro resolves a watch on every update so an active market
+// pushes well within this time
+const ccxtProReadTimeout = 60 * time.Second
+
+// CcxtProBridge is a client of a bridge that runs ccxt-pro (https://ccxt.pro) and relays the results of its watch* methods over a websocket.
+// Every watch opens its own connection to the bridge and sends a single request:
+//
+//	{"id": 1, "exchange": "binance", "config": {"apiKey": "...", "secret": "...", ...}, "method": "watchOrderBook", "args": ["XLM/USDT", 20]}
+//
+// after which the bridge pushes a message for every time the watch resolves, either {"id": 1, "result": ...} or {"id": 1, "error": "..."}
+// where the result is in the same format as the corresponding ccxt method.
+type CcxtProBridge struct {
+	url          string
+	exchangeName string
+	config       map[string]string
+}
+
+// ccxtProRequest is the request that starts a watch on the bridge
+type ccxtProRequest struct {
+	ID       int64             `json:"id"`
+	Exchange string            `json:"exchange"`
+	Config   map[string]string `json:"config"`
+	Method   string            `json:"method"`
+	Args     []interface{}     `json:"args"`
+}
+
+// ccxtProMessage is a message pushed by the bridge
+type ccxtProMessage struct {
+	ID     int64           `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  string          `json:"error"`
+}
+
+// ccxtProOrderBook is the format of the orderbook returned by watchOrderBook, where each entry is [price, amount]
+type ccxtProOrderBook struct {
+	Asks [][]float64 `json:"asks"`
+	Bids [][]float64 `json:"bids"`
+}
+
+// MakeCcxtProBridge is a factory method, the apiKey and params are passed to the ccxt-pro exchange in the same way as for ccxt-rest
+func MakeCcxtProBridge(bridgeURL string, exchangeName string, apiKey api.ExchangeAPIKey, params []api.ExchangeParam) (*CcxtProBridge, error) {
+	u, e := url.Parse(bridgeURL)
+	if e != nil {
+		return nil, fmt.Errorf("unable to parse ccxt-pro bridge URL '%s': %s", bridgeURL, e)
+	}
+	if u.Scheme != "ws" && u.Scheme != "wss" {
+		return nil, fmt.Errorf("ccxt-pro bridge URL needs to use the ws or wss scheme: %s", bridgeURL)
+	}
+
+	config := map[string]string{
+		"apiKey": apiKey.Key,
+		"secret": apiKey.Secret,
+	}
+	for _, param := range params {
+		config[param.Param] = param.Value
+	}
+
+	return &CcxtProBridge{
+		url:          bridgeURL,
+		exchangeName: exchangeName,
+		config:       config,
+	}, nil
+}
+
+// WatchOrderBook blocks and invokes the handler with the orderbook of the trading pair every time it changes until the watch fails or the
+// handler returns an error, trading pair is the CCXT version of the trading pair
+func (b *CcxtProBridge) WatchOrderBook(tradingPair string, limit int, handler func(ob map[string][]CcxtOrder) error) error {
+	return b.watch("watchOrderBook", []interface{}{tradingPair, limit}, func(result json.RawMessage) error {
+		ob, e := parseCcxtProOrderBook(result)
+		if e != nil {
+			return e
+		}
+		return handler(ob)
+	})
+}
+
+// WatchMyTrades blocks and invokes the handler with the new trades of the account on the trading pair until the watch fails or the handler
+// returns an error, trading pair is the CCXT version of the trading pair
+func (b *CcxtProBridge) WatchMyTrades(tradingPair string, handler func(trades []CcxtTrade) error) error {
+	return b.watch("watchMyTrades", []interface{}{tradingPair}, func(result json.RawMessage) error {
+		var trades []CcxtTrade
+		e := json.Unmarshal(result, &trades)
+		if e != nil {
+			return fmt.Errorf("unable to parse trades from ccxt-pro bridge (%s): %s", string(result), e)
+		}
+		return handler(trades)
+	})
+}
+
+func (b *CcxtProBridge) watch(method string, args []interface{}, handler func(result json.RawMessage) error) error {
+	conn, _, e := websocket.DefaultDialer.Dial(b.url, nil)
+	if e != nil {
+		return fmt.Errorf("unable to connect to ccxt-pro bridge: %s", e)
+	}
+	defer conn.Close()
+
+	request := ccxtProRequest{
+		ID:       time.Now().UnixNano(),
+		Exchange: b.exchangeName,
+		Config:   b.config,
+		Method:   method,
+		Args:     args,
+	}
+	e = conn.WriteJSON(request)
+	if e != nil {
+		return fmt.Errorf("unable to send '%s' request to ccxt-pro bridge: %s", method, e)
+	}
+	log.Printf("started '%s' on the ccxt-pro bridge for exchange '%s' with args %v\n", method, b.exchangeName, args)
+
+	for {
+		e = conn.SetReadDeadline(time.Now().Add(ccxtProReadTimeout))
+		if e != nil {
+			return fmt.Errorf("unable to set read deadline on the ccxt-pro bridge connection: %s", e)
+		}
+		_, message, e := conn.ReadMessage()
+		if e != nil {
+			return fmt.Errorf("error reading '%s' from ccxt-pro bridge: %s", method, e)
+		}
+
+		result, e := parseCcxtProMessage(message, request.ID)
+		if e != nil {
+			return fmt.Errorf("error in '%s' from ccxt-pro bridge: %s", method, e)
+		}
+		if result == nil {
+			continue
+		}
+		e = handler(result)
+		if e != nil {
+			return e
+		}
+	}
+}
+
+// parseCcxtProMessage returns the result in a message pushed by the bridge, or nil if the message is not a response to the request
+func parseCcxtProMessage(message []byte, requestID int64) (json.RawMessage, error) {
+	var m ccxtProMessage
+	e := json.Unmarshal(message, &m)
+	if e != nil {
+		return nil, fmt.Errorf("unable to parse message (%s): %s", string(message), e)
+	}
+	if m.ID != requestID {
+		return nil, nil
+	}
+	if m.Error != "" {
+		return nil, fmt.Errorf("%s", m.Error)
+	}
+	if len(m.Result) == 0 {
+		return nil, fmt.Errorf("message did not contain a result: %s", string(message))
+	}
+	return m.Result, nil
+}
+
+// parseCcxtProOrderBook converts the orderbook into the same format as FetchOrderBook
+func parseCcxtProOrderBook(result json.RawMessage) (map[string][]CcxtOrder, error) {
+	var ob ccxtProOrderBook
+	e := json.Unmarshal(result, &ob)
+	if e != nil {
+		return nil, fmt.Errorf("unable to parse orderbook from ccxt-pro bridge (%s): %s", string(result), e)
+	}
+
+	output := map[string][]CcxtOrder{}
+	for k, entries := range map[string][][]float64{"asks": ob.Asks, "bids": ob.Bids} {
+		parsedList := []CcxtOrder{}
+		for _, entry := range entries {
+			if len(entry) < 2 {
+				return nil, fmt.Errorf("invalid entry in '%s' of orderbook from ccxt-pro bridge: %v", k, entry)
+			}
+			parsedList = append(parsedList, CcxtOrder{
+				Price:  entry[0],
+				Amount: entry[1],
+			})
+		}
+		output[k] = parsedList
+	}
+	return output, nil
+}
@@ -0,0 +1,78 @@
+package sdk
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeCcxtProBridge(t *testing.T) {
+	params := []api.ExchangeParam{{Param: "password", Value: "pass"}}
+	b, e := MakeCcxtProBridge("ws://localhost:3001", "binance", api.ExchangeAPIKey{Key: "key", Secret: "secret"}, params)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, map[string]string{"apiKey": "key", "secret": "secret", "password": "pass"}, b.config)
+
+	_, e = MakeCcxtProBridge("http://localhost:3001", "binance", api.ExchangeAPIKey{}, nil)
+	assert.Error(t, e)
+}
+
+func TestParseCcxtProMessage(t *testing.T) {
+	testCases := []struct {
+		name       string
+		message    string
+		wantResult string
+		wantError  bool
+	}{
+		{
+			name:       "result",
+			message:    `{"id": 7, "result": [1, 2]}`,
+			wantResult: `[1, 2]`,
+		}, {
+			name:       "other request",
+			message:    `{"id": 8, "result": [1, 2]}`,
+			wantResult: "",
+		}, {
+			name:      "error",
+			message:   `{"id": 7, "error": "NetworkError: timed out"}`,
+			wantError: true,
+		}, {
+			name:      "missing result",
+			message:   `{"id": 7}`,
+			wantError: true,
+		}, {
+			name:      "invalid json",
+			message:   `[`,
+			wantError: true,
+		},
+	}
+
+	for _, kase := range testCases {
+		t.Run(kase.name, func(t *testing.T) {
+			result, e := parseCcxtProMessage([]byte(kase.message), 7)
+			if kase.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, kase.wantResult, string(result))
+		})
+	}
+}
+
+func TestParseCcxtProOrderBook(t *testing.T) {
+	ob, e := parseCcxtProOrderBook(json.RawMessage(`{"asks": [[0.11, 100], [0.12, 50]], "bids": [[0.1, 20]], "nonce": 5}`))
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, []CcxtOrder{{Price: 0.11, Amount: 100}, {Price: 0.12, Amount: 50}}, ob["asks"])
+	assert.Equal(t, []CcxtOrder{{Price: 0.1, Amount: 20}}, ob["bids"])
+
+	_, e = parseCcxtProOrderBook(json.RawMessage(`{"asks": [[0.11]], "bids": []}`))
+	assert.Error(t, e)
+}
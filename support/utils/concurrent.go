@@ -0,0 +1,25 @@
+package utils
+
+import "sync"
+
+// RunConcurrently runs the functions concurrently and waits for all of them to return, it returns the error of the first function in the
+// list that failed. It is used for independent network requests, such as requests to horizon, that would otherwise be made one after the other.
+func RunConcurrently(fns ...func() error) error {
+	errors := make([]error, len(fns))
+	var wg sync.WaitGroup
+	for i, fn := range fns {
+		wg.Add(1)
+		go func(i int, fn func() error) {
+			defer wg.Done()
+			errors[i] = fn()
+		}(i, fn)
+	}
+	wg.Wait()
+
+	for _, e := range errors {
+		if e != nil {
+			return e
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunConcurrently(t *testing.T) {
+	var numRan int32
+	ok := func() error {
+		atomic.AddInt32(&numRan, 1)
+		return nil
+	}
+	failWith := func(msg string) func() error {
+		return func() error {
+			atomic.AddInt32(&numRan, 1)
+			return fmt.Errorf("%s", msg)
+		}
+	}
+
+	assert.NoError(t, RunConcurrently())
+	assert.NoError(t, RunConcurrently(ok, ok, ok))
+	assert.Equal(t, int32(3), numRan)
+
+	// all functions run and the error of the first failed function in the list is returned
+	numRan = 0
+	e := RunConcurrently(ok, failWith("first"), failWith("second"))
+	if assert.Error(t, e) {
+		assert.Equal(t, "first", e.Error())
+	}
+	assert.Equal(t, int32(3), numRan)
+}
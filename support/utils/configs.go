@@ -31,6 +31,11 @@ func StructString(s interface{}, transforms map[string]func(interface{}) interfa
 	numFields := reflect.TypeOf(s).NumField()
 	for i := 0; i < numFields; i++ {
 		field := reflect.TypeOf(s).Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && reflect.ValueOf(s).Field(i).CanInterface() {
+			// the fields of embedded structs are part of the config itself
+			buf.WriteString(StructString(reflect.ValueOf(s).Field(i).Interface(), transforms))
+			continue
+		}
 		fieldName := field.Name
 		fieldDisplayName := field.Tag.Get("toml")
 		if fieldDisplayName == "" {
@@ -77,6 +82,20 @@ func SecretKey2PublicKey(i interface{}) interface{} {
 	return fmt.Sprintf("[secret key to account %s]", *pk)
 }
 
+// SecretKeys2PublicKeys converts a list of secret keys to public keys
+func SecretKeys2PublicKeys(i interface{}) interface{} {
+	secrets, ok := i.([]string)
+	if !ok {
+		log.Fatal("field was not a list of strings")
+	}
+
+	pks := []interface{}{}
+	for _, secret := range secrets {
+		pks = append(pks, SecretKey2PublicKey(secret))
+	}
+	return pks
+}
+
 // Passthrough returns the input
 func passthrough(i interface{}) interface{} {
 	return i
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// parameters of the key derivation, the cost makes a guess of the passphrase take about 100ms
+const (
+	passphraseSaltSize = 16
+	passphraseKeySize  = 32
+	passphraseScryptN  = 1 << 15
+	passphraseScryptR  = 8
+	passphraseScryptP  = 1
+)
+
+// EncryptWithPassphrase encrypts the plaintext with AES-256-GCM using a key derived from the passphrase with scrypt. The output contains
+// the salt and the nonce followed by the ciphertext so it can be decrypted with DecryptWithPassphrase.
+func EncryptWithPassphrase(plaintext []byte, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("passphrase cannot be empty")
+	}
+
+	salt := make([]byte, passphraseSaltSize)
+	if _, e := rand.Read(salt); e != nil {
+		return nil, fmt.Errorf("could not generate salt: %s", e)
+	}
+	gcm, e := passphraseCipher(passphrase, salt)
+	if e != nil {
+		return nil, e
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, e := rand.Read(nonce); e != nil {
+		return nil, fmt.Errorf("could not generate nonce: %s", e)
+	}
+
+	output := append(salt, nonce...)
+	return gcm.Seal(output, nonce, plaintext, nil), nil
+}
+
+// DecryptWithPassphrase decrypts the output of EncryptWithPassphrase, it fails when the passphrase is wrong or the data was modified
+func DecryptWithPassphrase(data []byte, passphrase string) ([]byte, error) {
+	if len(data) < passphraseSaltSize {
+		return nil, fmt.Errorf("encrypted data is too short")
+	}
+
+	salt := data[:passphraseSaltSize]
+	gcm, e := passphraseCipher(passphrase, salt)
+	if e != nil {
+		return nil, e
+	}
+	data = data[passphraseSaltSize:]
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("encrypted data is too short")
+	}
+
+	plaintext, e := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if e != nil {
+		return nil, fmt.Errorf("could not decrypt data, the passphrase may be wrong: %s", e)
+	}
+	return plaintext, nil
+}
+
+func passphraseCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, e := scrypt.Key([]byte(passphrase), salt, passphraseScryptN, passphraseScryptR, passphraseScryptP, passphraseKeySize)
+	if e != nil {
+		return nil, fmt.Errorf("could not derive key from passphrase: %s", e)
+	}
+	block, e := aes.NewCipher(key)
+	if e != nil {
+		return nil, fmt.Errorf("could not make cipher: %s", e)
+	}
+	gcm, e := cipher.NewGCM(block)
+	if e != nil {
+		return nil, fmt.Errorf("could not make GCM cipher: %s", e)
+	}
+	return gcm, nil
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptWithPassphrase(t *testing.T) {
+	plaintext := []byte("SDJ4LG5NL5QLZ5LIQTPVVEF3MVUMR6V4VYPLY3SC3L3LXGZ2DYV2ORLH")
+
+	encrypted, e := EncryptWithPassphrase(plaintext, "correct horse")
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.NotContains(t, string(encrypted), string(plaintext))
+
+	// the salt and nonce are random so the same plaintext encrypts differently every time
+	encrypted2, e := EncryptWithPassphrase(plaintext, "correct horse")
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.NotEqual(t, encrypted, encrypted2)
+
+	decrypted, e := DecryptWithPassphrase(encrypted, "correct horse")
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, plaintext, decrypted)
+
+	_, e = DecryptWithPassphrase(encrypted, "wrong horse")
+	assert.Error(t, e)
+
+	tampered := append([]byte{}, encrypted...)
+	tampered[len(tampered)-1] ^= 1
+	_, e = DecryptWithPassphrase(tampered, "correct horse")
+	assert.Error(t, e)
+
+	_, e = DecryptWithPassphrase(encrypted[:10], "correct horse")
+	assert.Error(t, e)
+
+	_, e = EncryptWithPassphrase(plaintext, "")
+	assert.Error(t, e)
+}
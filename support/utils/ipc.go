@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// IPCVersion is the current version of the IPC framing protocol
+const IPCVersion = 1
+
+// IPCVersionLegacy is the version reported for messages that use the unframed protocol terminated by IPCBoundary
+const IPCVersionLegacy = 0
+
+// IPCVersionUnknown is the version of a peer before it was negotiated with NegotiateIPCVersion
+const IPCVersionUnknown = -1
+
+// IPCVersionCommand asks the peer for the latest version of the protocol it supports, peers that only support the legacy protocol respond
+// with an empty payload as they do for every unrecognized command
+const IPCVersionCommand = "getIPCVersion"
+
+// IPCVersionOutput is the response to the IPCVersionCommand
+type IPCVersionOutput struct {
+	IPCVersion int `json:"ipc_version"`
+}
+
+// ipcFramePrefix starts the header line of every framed message: "KELPIPC/<version> <payloadLength>\n" followed by exactly payloadLength bytes
+const ipcFramePrefix = "KELPIPC/"
+
+// IPCMessage is a single message read from an IPC pipe
+type IPCMessage struct {
+	Version int
+	Payload []byte
+}
+
+// WriteIPCMessage writes the payload as a single length-prefixed frame, the payload can contain any bytes including newlines and the IPCBoundary
+func WriteIPCMessage(w io.Writer, payload []byte) error {
+	header := fmt.Sprintf("%s%d %d\n", ipcFramePrefix, IPCVersion, len(payload))
+	_, e := w.Write(append([]byte(header), payload...))
+	if e != nil {
+		return fmt.Errorf("could not write IPC message: %s", e)
+	}
+	return nil
+}
+
+// WriteIPCRequest writes the payload using the protocol version negotiated with the peer, legacy requests are a single line
+func WriteIPCRequest(w io.Writer, version int, payload []byte) error {
+	if version != IPCVersionLegacy {
+		return WriteIPCMessage(w, payload)
+	}
+
+	if strings.Contains(string(payload), "\n") {
+		return fmt.Errorf("legacy IPC requests cannot contain newlines: %s", string(payload))
+	}
+	_, e := io.WriteString(w, string(payload)+"\n")
+	if e != nil {
+		return fmt.Errorf("could not write legacy IPC request: %s", e)
+	}
+	return nil
+}
+
+// NegotiateIPCVersion asks the peer for the protocol version it supports using the legacy protocol, which every peer can read, and returns
+// the version to use for later requests
+func NegotiateIPCVersion(w io.Writer, r *bufio.Reader) (int, error) {
+	e := WriteIPCRequest(w, IPCVersionLegacy, []byte(IPCVersionCommand))
+	if e != nil {
+		return IPCVersionUnknown, e
+	}
+	msg, e := ReadIPCMessage(r, IPCBoundary)
+	if e != nil {
+		return IPCVersionUnknown, fmt.Errorf("could not read response to IPC version request: %s", e)
+	}
+	if len(strings.TrimSpace(string(msg.Payload))) == 0 {
+		return IPCVersionLegacy, nil
+	}
+
+	var output IPCVersionOutput
+	e = json.Unmarshal(msg.Payload, &output)
+	if e != nil {
+		return IPCVersionUnknown, fmt.Errorf("could not parse response to IPC version request '%s': %s", string(msg.Payload), e)
+	}
+	if output.IPCVersion > IPCVersion {
+		return IPCVersion, nil
+	}
+	return output.IPCVersion, nil
+}
+
+// WriteIPCResponse writes the payload using the same protocol version as the request it responds to so older peers can still read it
+func WriteIPCResponse(w io.Writer, requestVersion int, payload []byte) error {
+	if requestVersion != IPCVersionLegacy {
+		return WriteIPCMessage(w, payload)
+	}
+
+	output := string(payload)
+	if !strings.HasSuffix(output, "\n") {
+		output += "\n"
+	}
+	output += IPCBoundary + "\n"
+	_, e := io.WriteString(w, output)
+	if e != nil {
+		return fmt.Errorf("could not write legacy IPC message: %s", e)
+	}
+	return nil
+}
+
+// ReadIPCMessage reads the next message and detects which version of the protocol the peer is using.
+// Unframed (legacy) messages are read line-by-line until a line containing legacyBoundary, or as a single line if legacyBoundary is empty.
+func ReadIPCMessage(r *bufio.Reader, legacyBoundary string) (*IPCMessage, error) {
+	line, e := r.ReadString('\n')
+	if e != nil && (e != io.EOF || line == "") {
+		return nil, e
+	}
+
+	if !strings.HasPrefix(line, ipcFramePrefix) {
+		return readLegacyIPCMessage(r, line, legacyBoundary)
+	}
+
+	headerParts := strings.Fields(strings.TrimPrefix(line, ipcFramePrefix))
+	if len(headerParts) != 2 {
+		return nil, fmt.Errorf("invalid IPC frame header: %s", line)
+	}
+	version, e := strconv.Atoi(headerParts[0])
+	if e != nil {
+		return nil, fmt.Errorf("invalid version in IPC frame header '%s': %s", line, e)
+	}
+	if version > IPCVersion {
+		return nil, fmt.Errorf("unsupported IPC protocol version %d, maximum supported version is %d", version, IPCVersion)
+	}
+	length, e := strconv.Atoi(headerParts[1])
+	if e != nil || length < 0 {
+		return nil, fmt.Errorf("invalid payload length in IPC frame header: %s", line)
+	}
+
+	payload := make([]byte, length)
+	_, e = io.ReadFull(r, payload)
+	if e != nil {
+		return nil, fmt.Errorf("could not read IPC payload of length %d: %s", length, e)
+	}
+	return &IPCMessage{
+		Version: version,
+		Payload: payload,
+	}, nil
+}
+
+func readLegacyIPCMessage(r *bufio.Reader, firstLine string, legacyBoundary string) (*IPCMessage, error) {
+	if legacyBoundary == "" {
+		return &IPCMessage{
+			Version: IPCVersionLegacy,
+			Payload: []byte(strings.TrimRight(firstLine, "\n")),
+		}, nil
+	}
+
+	output := ""
+	line := firstLine
+	for !strings.Contains(line, legacyBoundary) {
+		output += strings.TrimRight(line, "\n")
+
+		var e error
+		line, e = r.ReadString('\n')
+		if e != nil {
+			return nil, fmt.Errorf("could not read legacy IPC message before reaching the boundary: %s", e)
+		}
+	}
+	return &IPCMessage{
+		Version: IPCVersionLegacy,
+		Payload: []byte(output),
+	}, nil
+}
@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIPCMessageRoundTrip(t *testing.T) {
+	payloads := []string{
+		"",
+		"getBotInfo",
+		"{\n  \"a\": 1\n}",
+		"log line containing the boundary " + IPCBoundary + "\nand more",
+	}
+
+	var buf bytes.Buffer
+	for _, p := range payloads {
+		if !assert.NoError(t, WriteIPCMessage(&buf, []byte(p))) {
+			return
+		}
+	}
+
+	reader := bufio.NewReader(&buf)
+	for _, p := range payloads {
+		msg, e := ReadIPCMessage(reader, IPCBoundary)
+		if !assert.NoError(t, e) {
+			return
+		}
+		assert.Equal(t, IPCVersion, msg.Version)
+		assert.Equal(t, p, string(msg.Payload))
+	}
+}
+
+func TestReadIPCMessageLegacy(t *testing.T) {
+	var buf bytes.Buffer
+	if !assert.NoError(t, WriteIPCResponse(&buf, IPCVersionLegacy, []byte("{\n  \"a\": 1\n}"))) {
+		return
+	}
+	msg, e := ReadIPCMessage(bufio.NewReader(&buf), IPCBoundary)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, IPCVersionLegacy, msg.Version)
+	assert.Equal(t, "{  \"a\": 1}", string(msg.Payload))
+
+	// legacy requests are a single line
+	msg, e = ReadIPCMessage(bufio.NewReader(strings.NewReader("getBotInfo\nnext")), "")
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, IPCVersionLegacy, msg.Version)
+	assert.Equal(t, "getBotInfo", string(msg.Payload))
+}
+
+func TestReadIPCMessageUnsupportedVersion(t *testing.T) {
+	_, e := ReadIPCMessage(bufio.NewReader(strings.NewReader("KELPIPC/99 2\nhi")), IPCBoundary)
+	assert.Error(t, e)
+}
+
+func TestNegotiateIPCVersion(t *testing.T) {
+	testCases := []struct {
+		name        string
+		response    string
+		wantVersion int
+		wantErr     bool
+	}{
+		{
+			name:        "legacy peer",
+			response:    "\n" + IPCBoundary + "\n",
+			wantVersion: IPCVersionLegacy,
+		}, {
+			name:        "current peer",
+			response:    "{\n  \"ipc_version\": 1\n}\n" + IPCBoundary + "\n",
+			wantVersion: IPCVersion,
+		}, {
+			name:        "newer peer",
+			response:    "{\"ipc_version\": 99}\n" + IPCBoundary + "\n",
+			wantVersion: IPCVersion,
+		}, {
+			name:     "invalid response",
+			response: "not json\n" + IPCBoundary + "\n",
+			wantErr:  true,
+		}, {
+			name:     "no response",
+			response: "",
+			wantErr:  true,
+		},
+	}
+
+	for _, kase := range testCases {
+		t.Run(kase.name, func(t *testing.T) {
+			var request bytes.Buffer
+			version, e := NegotiateIPCVersion(&request, bufio.NewReader(strings.NewReader(kase.response)))
+			// the request is a legacy request so peers of any version can read it
+			assert.Equal(t, IPCVersionCommand+"\n", request.String())
+			if kase.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, kase.wantVersion, version)
+		})
+	}
+}
+
+func TestWriteIPCRequest(t *testing.T) {
+	var buf bytes.Buffer
+	if !assert.NoError(t, WriteIPCRequest(&buf, IPCVersionLegacy, []byte("getBotInfo"))) {
+		return
+	}
+	if !assert.NoError(t, WriteIPCRequest(&buf, IPCVersion, []byte("getBotInfo"))) {
+		return
+	}
+
+	reader := bufio.NewReader(&buf)
+	for _, wantVersion := range []int{IPCVersionLegacy, IPCVersion} {
+		msg, e := ReadIPCMessage(reader, "")
+		if !assert.NoError(t, e) {
+			return
+		}
+		assert.Equal(t, wantVersion, msg.Version)
+		assert.Equal(t, "getBotInfo", string(msg.Payload))
+	}
+
+	assert.Error(t, WriteIPCRequest(&buf, IPCVersionLegacy, []byte("two\nlines")))
+}
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// parameters of the time-based one-time passwords, these are the defaults of RFC 6238 that authenticator apps use
+const (
+	totpStep       = 30 * time.Second
+	totpDigits     = 6
+	totpSecretSize = 20
+	totpMaxDrift   = 1 // number of time steps that a code can be ahead or behind to allow for clock drift
+)
+
+// totpEncoding is the base32 encoding of the secret as entered into authenticator apps, which do not use padding
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret returns a random secret for time-based one-time passwords, encoded in base32
+func GenerateTOTPSecret() (string, error) {
+	secret := make([]byte, totpSecretSize)
+	_, e := rand.Read(secret)
+	if e != nil {
+		return "", fmt.Errorf("unable to generate random secret: %s", e)
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// TOTP returns the time-based one-time password of the base32 encoded secret at time t as defined in RFC 6238 with HMAC-SHA1
+func TOTP(secret string, t time.Time) (string, error) {
+	key, e := decodeTOTPSecret(secret)
+	if e != nil {
+		return "", e
+	}
+	return totpCode(key, t.Unix()/int64(totpStep/time.Second)), nil
+}
+
+// CheckTOTP returns true when the code is the time-based one-time password of the base32 encoded secret at time t or up to one time step
+// before or after it
+func CheckTOTP(secret string, code string, t time.Time) (bool, error) {
+	key, e := decodeTOTPSecret(secret)
+	if e != nil {
+		return false, e
+	}
+
+	counter := t.Unix() / int64(totpStep/time.Second)
+	isValid := false
+	for drift := int64(-totpMaxDrift); drift <= totpMaxDrift; drift++ {
+		// compare all codes in constant time so the response time does not reveal which step matched
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, counter+drift)), []byte(code)) == 1 {
+			isValid = true
+		}
+	}
+	return isValid, nil
+}
+
+// decodeTOTPSecret decodes the secret ignoring spaces, padding and the case of the letters since authenticator apps display it in groups
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	normalized := strings.ToUpper(strings.TrimRight(strings.Replace(secret, " ", "", -1), "="))
+	key, e := totpEncoding.DecodeString(normalized)
+	if e != nil {
+		return nil, fmt.Errorf("invalid base32 TOTP secret: %s", e)
+	}
+	if len(key) == 0 {
+		return nil, fmt.Errorf("TOTP secret is empty")
+	}
+	return key, nil
+}
+
+// totpCode computes the HOTP value of RFC 4226 for the counter, truncated to totpDigits digits
+func totpCode(key []byte, counter int64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(counter))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%mod)
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// secret of the test vectors of RFC 6238 for HMAC-SHA1, which is the ASCII string "12345678901234567890"
+const rfcTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTP(t *testing.T) {
+	// the RFC lists 8 digit codes, the 6 digit codes are their last 6 digits
+	testCases := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.want, func(t *testing.T) {
+			code, e := TOTP(rfcTOTPSecret, time.Unix(k.unix, 0))
+			if assert.NoError(t, e) {
+				assert.Equal(t, k.want, code)
+			}
+		})
+	}
+
+	// authenticator apps display the secret in lower case groups
+	code, e := TOTP("gezd gnbv gy3t qojq gezd gnbv gy3t qojq", time.Unix(59, 0))
+	if assert.NoError(t, e) {
+		assert.Equal(t, "287082", code)
+	}
+
+	_, e = TOTP("not base32!", time.Unix(59, 0))
+	assert.Error(t, e)
+}
+
+func TestCheckTOTP(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	testCases := []struct {
+		name string
+		at   time.Time
+		want bool
+	}{
+		{name: "same step", at: now, want: true},
+		{name: "one step later", at: now.Add(30 * time.Second), want: true},
+		{name: "one step earlier", at: now.Add(-30 * time.Second), want: true},
+		{name: "two steps later", at: now.Add(60 * time.Second), want: false},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			isValid, e := CheckTOTP(rfcTOTPSecret, "081804", k.at)
+			if assert.NoError(t, e) {
+				assert.Equal(t, k.want, isValid)
+			}
+		})
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, e := GenerateTOTPSecret()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 32, len(secret))
+
+	_, e = TOTP(secret, time.Now())
+	assert.NoError(t, e)
+}
@@ -18,22 +18,100 @@ type FeeConfig struct {
 	MaxOpFeeStroops uint64  `valid:"-" toml:"MAX_OP_FEE_STROOPS" json:"max_op_fee_stroops"` // max fee in stroops per operation to use
 }
 
+// SubmitFilterConfig enables, disables or reorders one of the filters that ops pass through before they are submitted
+type SubmitFilterConfig struct {
+	Name     string `valid:"-" toml:"NAME" json:"name"`
+	Enabled  *bool  `valid:"-" toml:"ENABLED" json:"enabled"`   // nil keeps the filter enabled when it is available
+	Priority *int   `valid:"-" toml:"PRIORITY" json:"priority"` // filters run in ascending order of priority, nil keeps the default priority
+}
+
+// String is the stringer function
+func (c SubmitFilterConfig) String() string {
+	enabled := "<default>"
+	if c.Enabled != nil {
+		enabled = fmt.Sprintf("%v", *c.Enabled)
+	}
+	priority := "<default>"
+	if c.Priority != nil {
+		priority = fmt.Sprintf("%d", *c.Priority)
+	}
+	return fmt.Sprintf("SubmitFilterConfig[name=%s, enabled=%s, priority=%s]", c.Name, enabled, priority)
+}
+
+// VolumeLimitConfig caps the volume that the bot fills in a rolling window, such as the trading limits imposed by an exchange or a regulator
+type VolumeLimitConfig struct {
+	Window   string  `valid:"-" toml:"WINDOW" json:"window"`       // duration such as "24h" or a number of days such as "7d"
+	Action   string  `valid:"-" toml:"ACTION" json:"action"`       // buy, sell or both (default)
+	BaseCap  float64 `valid:"-" toml:"BASE_CAP" json:"base_cap"`   // 0 does not cap the base volume
+	QuoteCap float64 `valid:"-" toml:"QUOTE_CAP" json:"quote_cap"` // 0 does not cap the quote volume
+}
+
+// PairConfig is a trading pair that runs with its own strategy in the same process as the other PAIRS of the bot, sharing the horizon
+// connection and the sequence numbers, and the trading account and the op budget with the other pairs of the same trading account
+type PairConfig struct {
+	TradingSecretSeed string `valid:"-" toml:"TRADING_SECRET_SEED" json:"trading_secret_seed"` // empty uses the TRADING_SECRET_SEED of the bot
+	AssetCodeA        string `valid:"-" toml:"ASSET_CODE_A" json:"asset_code_a"`
+	IssuerA           string `valid:"-" toml:"ISSUER_A" json:"issuer_a"`
+	AssetCodeB        string `valid:"-" toml:"ASSET_CODE_B" json:"asset_code_b"`
+	IssuerB           string `valid:"-" toml:"ISSUER_B" json:"issuer_b"`
+	Strategy          string `valid:"-" toml:"STRATEGY" json:"strategy"`               // empty uses the strategy of the --strategy flag
+	StrategyConfig    string `valid:"-" toml:"STRATEGY_CONFIG" json:"strategy_config"` // path of the strategy config file, empty uses the --stratConf flag
+}
+
+// String is the stringer function
+func (p PairConfig) String() string {
+	return fmt.Sprintf("%s:%s/%s:%s", p.AssetCodeA, p.IssuerA, p.AssetCodeB, p.IssuerB)
+}
+
 // BotConfig represents the configuration params for the bot
 type BotConfig struct {
 	SourceSecretSeed                   string     `valid:"-" toml:"SOURCE_SECRET_SEED" json:"source_secret_seed"`
 	TradingSecretSeed                  string     `valid:"-" toml:"TRADING_SECRET_SEED" json:"trading_secret_seed"`
+	AdditionalSourceSecretSeeds        []string   `valid:"-" toml:"ADDITIONAL_SOURCE_SECRET_SEEDS" json:"additional_source_secret_seeds"`
 	AssetCodeA                         string     `valid:"-" toml:"ASSET_CODE_A" json:"asset_code_a"`
 	IssuerA                            string     `valid:"-" toml:"ISSUER_A" json:"issuer_a"`
 	AssetCodeB                         string     `valid:"-" toml:"ASSET_CODE_B" json:"asset_code_b"`
 	IssuerB                            string     `valid:"-" toml:"ISSUER_B" json:"issuer_b"`
 	TickIntervalSeconds                int32      `valid:"-" toml:"TICK_INTERVAL_SECONDS" json:"tick_interval_seconds"`
 	MaxTickDelayMillis                 int64      `valid:"-" toml:"MAX_TICK_DELAY_MILLIS" json:"max_tick_delay_millis"`
+	TickIntervalJitter                 float64    `valid:"-" toml:"TICK_INTERVAL_JITTER" json:"tick_interval_jitter"`
+	TickAlignToClock                   bool       `valid:"-" toml:"TICK_ALIGN_TO_CLOCK" json:"tick_align_to_clock"`
+	TickAlignOffsetSeconds             int32      `valid:"-" toml:"TICK_ALIGN_OFFSET_SECONDS" json:"tick_align_offset_seconds"`
 	DeleteCyclesThreshold              int64      `valid:"-" toml:"DELETE_CYCLES_THRESHOLD" json:"delete_cycles_threshold"`
+	WatchdogMaxMissedIntervals         uint32     `valid:"-" toml:"WATCHDOG_MAX_MISSED_INTERVALS" json:"watchdog_max_missed_intervals"`
+	WatchdogAction                     string     `valid:"-" toml:"WATCHDOG_ACTION" json:"watchdog_action"`
+	StartupOffersAction                string     `valid:"-" toml:"STARTUP_OFFERS_ACTION" json:"startup_offers_action"`
+	OpOrderingPolicy                   string     `valid:"-" toml:"OP_ORDERING_POLICY" json:"op_ordering_policy"`
 	SubmitMode                         string     `valid:"-" toml:"SUBMIT_MODE" json:"submit_mode"`
+	MaxChurnPerCycle                   uint32     `valid:"-" toml:"MAX_CHURN_PER_CYCLE" json:"max_churn_per_cycle"`
+	AmountJitter                       float64    `valid:"-" toml:"AMOUNT_JITTER" json:"amount_jitter"`
+	AmountJitterSeed                   int64      `valid:"-" toml:"AMOUNT_JITTER_SEED" json:"amount_jitter_seed"`
+	MinSellPrice                       float64    `valid:"-" toml:"MIN_SELL_PRICE" json:"min_sell_price"`
+	MaxBuyPrice                        float64    `valid:"-" toml:"MAX_BUY_PRICE" json:"max_buy_price"`
+	FillAnomalyLevelAmount             float64    `valid:"-" toml:"FILL_ANOMALY_LEVEL_AMOUNT" json:"fill_anomaly_level_amount"`
+	FillAnomalyMaxSizeMultiple         float64    `valid:"-" toml:"FILL_ANOMALY_MAX_SIZE_MULTIPLE" json:"fill_anomaly_max_size_multiple"`
+	FillAnomalyMaxPriceDeviationPct    float64    `valid:"-" toml:"FILL_ANOMALY_MAX_PRICE_DEVIATION_PCT" json:"fill_anomaly_max_price_deviation_pct"`
+	FillAnomalyFeedAType               string     `valid:"-" toml:"FILL_ANOMALY_FEED_A_TYPE" json:"fill_anomaly_feed_a_type"`
+	FillAnomalyFeedAURL                string     `valid:"-" toml:"FILL_ANOMALY_FEED_A_URL" json:"fill_anomaly_feed_a_url"`
+	FillAnomalyFeedBType               string     `valid:"-" toml:"FILL_ANOMALY_FEED_B_TYPE" json:"fill_anomaly_feed_b_type"`
+	FillAnomalyFeedBURL                string     `valid:"-" toml:"FILL_ANOMALY_FEED_B_URL" json:"fill_anomaly_feed_b_url"`
 	FillTrackerSleepMillis             uint32     `valid:"-" toml:"FILL_TRACKER_SLEEP_MILLIS" json:"fill_tracker_sleep_millis"`
 	FillTrackerDeleteCyclesThreshold   int64      `valid:"-" toml:"FILL_TRACKER_DELETE_CYCLES_THRESHOLD" json:"fill_tracker_delete_cycles_threshold"`
+	ReconcileIntervalSeconds           uint32     `valid:"-" toml:"RECONCILE_INTERVAL_SECONDS" json:"reconcile_interval_seconds"`
+	ReconcileDriftThreshold            float64    `valid:"-" toml:"RECONCILE_DRIFT_THRESHOLD" json:"reconcile_drift_threshold"`
+	PriceFeedMaxUnchangedSeconds       uint32     `valid:"-" toml:"PRICE_FEED_MAX_UNCHANGED_SECONDS" json:"price_feed_max_unchanged_seconds"`
+	PriceFeedMaxAgeSeconds             uint32     `valid:"-" toml:"PRICE_FEED_MAX_AGE_SECONDS" json:"price_feed_max_age_seconds"`
+	PriceFeedHaltWhenStale             bool       `valid:"-" toml:"PRICE_FEED_HALT_WHEN_STALE" json:"price_feed_halt_when_stale"`
 	HorizonURL                         string     `valid:"-" toml:"HORIZON_URL" json:"horizon_url"`
 	CcxtRestURL                        *string    `valid:"-" toml:"CCXT_REST_URL" json:"ccxt_rest_url"`
+	CoreURL                            string     `valid:"-" toml:"CORE_URL" json:"core_url"`
+	CoreDBURL                          string     `valid:"-" toml:"CORE_DB_URL" json:"core_db_url"`
+	PassiveOffers                      bool       `valid:"-" toml:"PASSIVE_OFFERS" json:"passive_offers"`
+	HorizonStreaming                   bool       `valid:"-" toml:"HORIZON_STREAMING" json:"horizon_streaming"`
+	HorizonCacheOrderbookTTLMillis     uint32     `valid:"-" toml:"HORIZON_CACHE_ORDERBOOK_TTL_MILLIS" json:"horizon_cache_orderbook_ttl_millis"`
+	HorizonCacheAccountTTLMillis       uint32     `valid:"-" toml:"HORIZON_CACHE_ACCOUNT_TTL_MILLIS" json:"horizon_cache_account_ttl_millis"`
+	OpBudgetPerLedger                  uint32     `valid:"-" toml:"OP_BUDGET_PER_LEDGER" json:"op_budget_per_ledger"`
+	OpBudgetBurst                      uint32     `valid:"-" toml:"OP_BUDGET_BURST" json:"op_budget_burst"`
 	Fee                                *FeeConfig `valid:"-" toml:"FEE" json:"fee"`
 	CentralizedPricePrecisionOverride  *int8      `valid:"-" toml:"CENTRALIZED_PRICE_PRECISION_OVERRIDE" json:"centralized_price_precision_override"`
 	CentralizedVolumePrecisionOverride *int8      `valid:"-" toml:"CENTRALIZED_VOLUME_PRECISION_OVERRIDE" json:"centralized_volume_precision_override"`
@@ -41,6 +119,9 @@ type BotConfig struct {
 	MinCentralizedBaseVolumeDeprecated *float64                 `valid:"-" toml:"MIN_CENTRALIZED_BASE_VOLUME" deprecated:"true" json:"min_centralized_base_volume"`
 	CentralizedMinBaseVolumeOverride   *float64                 `valid:"-" toml:"CENTRALIZED_MIN_BASE_VOLUME_OVERRIDE" json:"centralized_min_base_volume_override"`
 	CentralizedMinQuoteVolumeOverride  *float64                 `valid:"-" toml:"CENTRALIZED_MIN_QUOTE_VOLUME_OVERRIDE" json:"centralized_min_quote_volume_override"`
+	SubmitFilters                      []SubmitFilterConfig     `valid:"-" toml:"SUBMIT_FILTERS" json:"submit_filters"`
+	VolumeLimits                       []VolumeLimitConfig      `valid:"-" toml:"VOLUME_LIMITS" json:"volume_limits"`
+	Pairs                              []PairConfig             `valid:"-" toml:"PAIRS" json:"pairs"`
 	AlertType                          string                   `valid:"-" toml:"ALERT_TYPE" json:"alert_type"`
 	AlertAPIKey                        string                   `valid:"-" toml:"ALERT_API_KEY" json:"alert_api_key"`
 	MonitoringPort                     uint16                   `valid:"-" toml:"MONITORING_PORT" json:"monitoring_port"`
@@ -53,10 +134,16 @@ type BotConfig struct {
 	ExchangeAPIKeys                    toml.ExchangeAPIKeysToml `valid:"-" toml:"EXCHANGE_API_KEYS" json:"exchange_api_keys"`
 	ExchangeParams                     toml.ExchangeParamsToml  `valid:"-" toml:"EXCHANGE_PARAMS" json:"exchange_params"`
 	ExchangeHeaders                    toml.ExchangeHeadersToml `valid:"-" toml:"EXCHANGE_HEADERS" json:"exchange_headers"`
+	LogFormat                          string                   `valid:"-" toml:"LOG_FORMAT" json:"log_format"`
+	LogLevel                           string                   `valid:"-" toml:"LOG_LEVEL" json:"log_level"`
+	LogMaxSizeMB                       uint32                   `valid:"-" toml:"LOG_MAX_SIZE_MB" json:"log_max_size_mb"`
+	LogRotateIntervalHours             uint32                   `valid:"-" toml:"LOG_ROTATE_INTERVAL_HOURS" json:"log_rotate_interval_hours"`
+	LogMaxBackups                      uint32                   `valid:"-" toml:"LOG_MAX_BACKUPS" json:"log_max_backups"`
 
 	// initialized later
 	tradingAccount *string
 	sourceAccount  *string // can be nil
+	extraSources   []string
 	assetBase      hProtocol.Asset
 	assetQuote     hProtocol.Asset
 	isTradingSdex  bool
@@ -113,8 +200,10 @@ func (b BotConfig) String() string {
 		"EXCHANGE_API_KEYS":                     utils.Hide,
 		"EXCHANGE_PARAMS":                       utils.Hide,
 		"EXCHANGE_HEADERS":                      utils.Hide,
+		"CORE_DB_URL":                           utils.Hide,
 		"SOURCE_SECRET_SEED":                    utils.SecretKey2PublicKey,
 		"TRADING_SECRET_SEED":                   utils.SecretKey2PublicKey,
+		"ADDITIONAL_SOURCE_SECRET_SEEDS":        utils.SecretKeys2PublicKeys,
 		"ALERT_API_KEY":                         utils.Hide,
 		"GOOGLE_CLIENT_ID":                      utils.Hide,
 		"GOOGLE_CLIENT_SECRET":                  utils.Hide,
@@ -140,6 +229,11 @@ func (b *BotConfig) SourceAccount() string {
 	return *b.sourceAccount
 }
 
+// AdditionalSourceAccounts returns the config's additional source accounts
+func (b *BotConfig) AdditionalSourceAccounts() []string {
+	return b.extraSources
+}
+
 // AssetBase returns the config's assetBase
 func (b *BotConfig) AssetBase() hProtocol.Asset {
 	return b.assetBase
@@ -159,22 +253,30 @@ func (b *BotConfig) IsTradingSdex() bool {
 func (b *BotConfig) Init() error {
 	b.isTradingSdex = b.TradingExchange == "" || b.TradingExchange == "sdex"
 
-	if b.AssetCodeA == b.AssetCodeB && b.IssuerA == b.IssuerB {
-		return fmt.Errorf("error: both assets cannot be the same '%s:%s'", b.AssetCodeA, b.IssuerA)
-	}
+	if len(b.Pairs) > 0 {
+		// the assets of each pair are parsed by ForPair
+		if b.AssetCodeA != "" || b.IssuerA != "" || b.AssetCodeB != "" || b.IssuerB != "" {
+			return fmt.Errorf("ASSET_CODE_A, ISSUER_A, ASSET_CODE_B and ISSUER_B cannot be set together with PAIRS, set them on each pair instead")
+		}
+	} else {
+		if b.AssetCodeA == b.AssetCodeB && b.IssuerA == b.IssuerB {
+			return fmt.Errorf("error: both assets cannot be the same '%s:%s'", b.AssetCodeA, b.IssuerA)
+		}
 
-	asset, e := utils.ParseAsset(b.AssetCodeA, b.IssuerA)
-	if e != nil {
-		return fmt.Errorf("Error while parsing Asset A: %s", e)
-	}
-	b.assetBase = *asset
+		asset, e := utils.ParseAsset(b.AssetCodeA, b.IssuerA)
+		if e != nil {
+			return fmt.Errorf("Error while parsing Asset A: %s", e)
+		}
+		b.assetBase = *asset
 
-	asset, e = utils.ParseAsset(b.AssetCodeB, b.IssuerB)
-	if e != nil {
-		return fmt.Errorf("Error while parsing Asset B: %s", e)
+		asset, e = utils.ParseAsset(b.AssetCodeB, b.IssuerB)
+		if e != nil {
+			return fmt.Errorf("Error while parsing Asset B: %s", e)
+		}
+		b.assetQuote = *asset
 	}
-	b.assetQuote = *asset
 
+	var e error
 	b.tradingAccount, e = utils.ParseSecret(b.TradingSecretSeed)
 	if e != nil {
 		return e
@@ -184,5 +286,142 @@ func (b *BotConfig) Init() error {
 	}
 
 	b.sourceAccount, e = utils.ParseSecret(b.SourceSecretSeed)
-	return e
+	if e != nil {
+		return e
+	}
+
+	b.extraSources = []string{}
+	for _, seed := range b.AdditionalSourceSecretSeeds {
+		account, e := utils.ParseSecret(seed)
+		if e != nil {
+			return fmt.Errorf("invalid seed in ADDITIONAL_SOURCE_SECRET_SEEDS: %s", e)
+		}
+		if account == nil {
+			return fmt.Errorf("ADDITIONAL_SOURCE_SECRET_SEEDS cannot contain empty seeds")
+		}
+		b.extraSources = append(b.extraSources, *account)
+	}
+
+	if b.TickIntervalJitter < 0 || b.TickIntervalJitter >= 1 {
+		return fmt.Errorf("TICK_INTERVAL_JITTER needs to be at least 0 and less than 1: %f", b.TickIntervalJitter)
+	}
+	if b.TickAlignToClock {
+		if b.TickIntervalJitter > 0 || b.MaxTickDelayMillis > 0 {
+			return fmt.Errorf("TICK_ALIGN_TO_CLOCK cannot be used with TICK_INTERVAL_JITTER or MAX_TICK_DELAY_MILLIS, use TICK_ALIGN_OFFSET_SECONDS to run at a different time than other bots")
+		}
+		if b.TickAlignOffsetSeconds < 0 || b.TickAlignOffsetSeconds >= b.TickIntervalSeconds {
+			return fmt.Errorf("TICK_ALIGN_OFFSET_SECONDS needs to be at least 0 and less than TICK_INTERVAL_SECONDS: %d", b.TickAlignOffsetSeconds)
+		}
+	} else if b.TickAlignOffsetSeconds != 0 {
+		return fmt.Errorf("TICK_ALIGN_OFFSET_SECONDS can only be set when TICK_ALIGN_TO_CLOCK is set")
+	}
+
+	if b.AmountJitter < 0 || b.AmountJitter >= 1 {
+		return fmt.Errorf("AMOUNT_JITTER needs to be at least 0 and less than 1: %f", b.AmountJitter)
+	}
+
+	if b.MinSellPrice < 0 {
+		return fmt.Errorf("MIN_SELL_PRICE cannot be negative: %f", b.MinSellPrice)
+	}
+	if b.MaxBuyPrice < 0 {
+		return fmt.Errorf("MAX_BUY_PRICE cannot be negative: %f", b.MaxBuyPrice)
+	}
+
+	if b.FillAnomalyMaxSizeMultiple < 0 {
+		return fmt.Errorf("FILL_ANOMALY_MAX_SIZE_MULTIPLE cannot be negative: %f", b.FillAnomalyMaxSizeMultiple)
+	}
+	if b.FillAnomalyMaxSizeMultiple > 0 && b.FillAnomalyLevelAmount <= 0 {
+		return fmt.Errorf("FILL_ANOMALY_LEVEL_AMOUNT needs to be positive when FILL_ANOMALY_MAX_SIZE_MULTIPLE is set: %f", b.FillAnomalyLevelAmount)
+	}
+	if b.FillAnomalyMaxPriceDeviationPct < 0 {
+		return fmt.Errorf("FILL_ANOMALY_MAX_PRICE_DEVIATION_PCT cannot be negative: %f", b.FillAnomalyMaxPriceDeviationPct)
+	}
+	if b.FillAnomalyMaxPriceDeviationPct > 0 && (b.FillAnomalyFeedAType == "" || b.FillAnomalyFeedAURL == "" || b.FillAnomalyFeedBType == "" || b.FillAnomalyFeedBURL == "") {
+		return fmt.Errorf("need to specify all of FILL_ANOMALY_FEED_A_TYPE, FILL_ANOMALY_FEED_A_URL, FILL_ANOMALY_FEED_B_TYPE and FILL_ANOMALY_FEED_B_URL when FILL_ANOMALY_MAX_PRICE_DEVIATION_PCT is set")
+	}
+
+	if b.ReconcileDriftThreshold < 0 {
+		return fmt.Errorf("RECONCILE_DRIFT_THRESHOLD cannot be negative: %f", b.ReconcileDriftThreshold)
+	}
+	if b.PriceFeedHaltWhenStale && b.PriceFeedMaxUnchangedSeconds == 0 && b.PriceFeedMaxAgeSeconds == 0 {
+		return fmt.Errorf("need to specify PRICE_FEED_MAX_UNCHANGED_SECONDS or PRICE_FEED_MAX_AGE_SECONDS when PRICE_FEED_HALT_WHEN_STALE is set")
+	}
+
+	seenFilters := map[string]bool{}
+	for _, f := range b.SubmitFilters {
+		if f.Name == "" {
+			return fmt.Errorf("SUBMIT_FILTERS entries need a NAME")
+		}
+		if seenFilters[f.Name] {
+			return fmt.Errorf("submit filter '%s' is listed more than once in SUBMIT_FILTERS", f.Name)
+		}
+		seenFilters[f.Name] = true
+	}
+
+	// pairs are listed once even across trading accounts since the state files of a pair are named after its assets
+	seenPairs := map[string]bool{}
+	for _, p := range b.Pairs {
+		_, e := b.ForPair(p)
+		if e != nil {
+			return e
+		}
+		if seenPairs[p.String()] {
+			return fmt.Errorf("pair %s is listed more than once in PAIRS", p)
+		}
+		seenPairs[p.String()] = true
+	}
+	return nil
+}
+
+// BalanceShares returns the fractions of the balances of the base and quote assets of one of the PAIRS that the pair trades with. The balance
+// of an asset is split equally between the PAIRS of the same trading account that trade it so the pairs do not count the same balance twice.
+func (b BotConfig) BalanceShares(p PairConfig) (float64, float64, error) {
+	pairConfig, e := b.ForPair(p)
+	if e != nil {
+		return 0, 0, e
+	}
+
+	numBase := 0
+	numQuote := 0
+	for _, other := range b.Pairs {
+		otherConfig, e := b.ForPair(other)
+		if e != nil {
+			return 0, 0, e
+		}
+		if otherConfig.TradingAccount() != pairConfig.TradingAccount() {
+			continue
+		}
+		if otherConfig.tradesAsset(pairConfig.AssetBase()) {
+			numBase++
+		}
+		if otherConfig.tradesAsset(pairConfig.AssetQuote()) {
+			numQuote++
+		}
+	}
+	if numBase == 0 || numQuote == 0 {
+		return 0, 0, fmt.Errorf("pair %s is not one of the PAIRS", p)
+	}
+	return 1 / float64(numBase), 1 / float64(numQuote), nil
+}
+
+func (b *BotConfig) tradesAsset(asset hProtocol.Asset) bool {
+	return b.assetBase == asset || b.assetQuote == asset
+}
+
+// ForPair returns the initialized config of one of the PAIRS, which uses all other values of this config
+func (b BotConfig) ForPair(p PairConfig) (BotConfig, error) {
+	pairConfig := b
+	pairConfig.Pairs = nil
+	pairConfig.AssetCodeA = p.AssetCodeA
+	pairConfig.IssuerA = p.IssuerA
+	pairConfig.AssetCodeB = p.AssetCodeB
+	pairConfig.IssuerB = p.IssuerB
+	if p.TradingSecretSeed != "" {
+		pairConfig.TradingSecretSeed = p.TradingSecretSeed
+	}
+	e := pairConfig.Init()
+	if e != nil {
+		return BotConfig{}, fmt.Errorf("invalid pair %s in PAIRS: %s", p, e)
+	}
+	return pairConfig, nil
 }
@@ -0,0 +1,140 @@
+package trader
+
+import (
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/kelp/support/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+const testIssuer = "GBMMZMK2DC4FFP4CAI6KCVNCQ7WLO5A7DQU7EC7WGHRDQBZB763X4OQI"
+
+func TestForPair(t *testing.T) {
+	botSeed := randomSeed(t)
+	pairSeed := randomSeed(t)
+	botConfig := BotConfig{
+		TradingSecretSeed:   botSeed,
+		TickIntervalSeconds: 300,
+		Pairs: []PairConfig{
+			{AssetCodeA: "XLM", AssetCodeB: "COUPON", IssuerB: testIssuer},
+			{AssetCodeA: "XLM", AssetCodeB: "USD", IssuerB: testIssuer, TradingSecretSeed: pairSeed},
+		},
+	}
+
+	testCases := []struct {
+		name      string
+		pair      PairConfig
+		wantSeed  string
+		wantQuote string
+		wantErr   bool
+	}{
+		{
+			name:      "bot trading account",
+			pair:      botConfig.Pairs[0],
+			wantSeed:  botSeed,
+			wantQuote: "COUPON",
+		}, {
+			name:      "pair trading account",
+			pair:      botConfig.Pairs[1],
+			wantSeed:  pairSeed,
+			wantQuote: "USD",
+		}, {
+			name:    "same assets",
+			pair:    PairConfig{AssetCodeA: "XLM", AssetCodeB: "XLM"},
+			wantErr: true,
+		}, {
+			name:    "invalid trading account",
+			pair:    PairConfig{AssetCodeA: "XLM", AssetCodeB: "USD", IssuerB: testIssuer, TradingSecretSeed: "invalid"},
+			wantErr: true,
+		},
+	}
+
+	for _, kase := range testCases {
+		t.Run(kase.name, func(t *testing.T) {
+			pairConfig, e := botConfig.ForPair(kase.pair)
+			if kase.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			assert.Equal(t, kase.wantSeed, pairConfig.TradingSecretSeed)
+			assert.Equal(t, keypair.MustParse(kase.wantSeed).Address(), pairConfig.TradingAccount())
+			assert.Equal(t, utils.NativeAsset, pairConfig.AssetBase())
+			assert.Equal(t, kase.wantQuote, pairConfig.AssetQuote().Code)
+			assert.Nil(t, pairConfig.Pairs)
+			// all other values of the bot config are used
+			assert.Equal(t, botConfig.TickIntervalSeconds, pairConfig.TickIntervalSeconds)
+		})
+	}
+}
+
+func TestBalanceShares(t *testing.T) {
+	botSeed := randomSeed(t)
+	otherSeed := randomSeed(t)
+	botConfig := BotConfig{
+		TradingSecretSeed: botSeed,
+		Pairs: []PairConfig{
+			{AssetCodeA: "XLM", AssetCodeB: "COUPON", IssuerB: testIssuer},
+			{AssetCodeA: "XLM", AssetCodeB: "USD", IssuerB: testIssuer},
+			{AssetCodeA: "USD", IssuerA: testIssuer, AssetCodeB: "COUPON", IssuerB: testIssuer},
+			{AssetCodeA: "XLM", AssetCodeB: "BTC", IssuerB: testIssuer, TradingSecretSeed: otherSeed},
+		},
+	}
+
+	testCases := []struct {
+		name           string
+		pair           PairConfig
+		wantBaseShare  float64
+		wantQuoteShare float64
+		wantErr        bool
+	}{
+		{
+			name:           "native asset shared by two pairs",
+			pair:           botConfig.Pairs[0],
+			wantBaseShare:  0.5,
+			wantQuoteShare: 0.5,
+		}, {
+			name:           "both assets shared",
+			pair:           botConfig.Pairs[2],
+			wantBaseShare:  0.5,
+			wantQuoteShare: 0.5,
+		}, {
+			name:           "other trading account",
+			pair:           botConfig.Pairs[3],
+			wantBaseShare:  1.0,
+			wantQuoteShare: 1.0,
+		}, {
+			name:    "not one of the pairs",
+			pair:    PairConfig{AssetCodeA: "XLM", AssetCodeB: "EUR", IssuerB: testIssuer, TradingSecretSeed: randomSeed(t)},
+			wantErr: true,
+		},
+	}
+
+	for _, kase := range testCases {
+		t.Run(kase.name, func(t *testing.T) {
+			baseShare, quoteShare, e := botConfig.BalanceShares(kase.pair)
+			if kase.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			assert.Equal(t, kase.wantBaseShare, baseShare)
+			assert.Equal(t, kase.wantQuoteShare, quoteShare)
+		})
+	}
+}
+
+func randomSeed(t *testing.T) string {
+	kp, e := keypair.Random()
+	if e != nil {
+		t.Fatal(e)
+	}
+	return kp.Seed()
+}
@@ -0,0 +1,32 @@
+package trader
+
+import (
+	"fmt"
+
+	"github.com/stellar/kelp/plugins"
+)
+
+// checkPriceFeedFreshness triggers an alert for every monitored price feed that became stale since the last update cycle, see
+// plugins.EnablePriceFeedFreshnessMonitor
+func (t *Trader) checkPriceFeedFreshness(stats map[string]plugins.PriceFeedFreshnessStats) {
+	if t.stalePriceFeeds == nil {
+		t.stalePriceFeeds = map[string]bool{}
+	}
+
+	for name, s := range stats {
+		wasStale := t.stalePriceFeeds[name]
+		t.stalePriceFeeds[name] = s.Stale
+		if !s.Stale || wasStale {
+			continue
+		}
+
+		description := fmt.Sprintf("price feed '%s' is stale: %s", name, s.StaleReason)
+		t.l.Error(description)
+		if t.alert != nil {
+			e := t.alert.Trigger(description, s)
+			if e != nil {
+				t.l.Errorf("unable to trigger alert for the stale price feed '%s': %s", name, e)
+			}
+		}
+	}
+}
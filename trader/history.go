@@ -0,0 +1,241 @@
+package trader
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/stellar/kelp/support/utils"
+)
+
+// RunHistoryVersion is the format version of the snapshots written to the run history file by this version of Kelp
+const RunHistoryVersion = 1
+
+// MaxRunHistorySnapshots is the number of the most recent update cycles that are kept in the run history
+const MaxRunHistorySnapshots = 5000
+
+// CycleSnapshot is the operational record of a single update cycle. The balances, offers and prices are as of the start of the cycle,
+// prices are in units of the quote asset.
+type CycleSnapshot struct {
+	Version        int      `json:"version"`
+	CycleID        uint64   `json:"cycle_id"`
+	StartedAt      string   `json:"started_at"`
+	DurationMillis int64    `json:"duration_millis"`
+	Succeeded      bool     `json:"succeeded"`
+	Error          string   `json:"error,omitempty"` // the error that ended the cycle, empty when the cycle succeeded
+	BalanceBase    float64  `json:"balance_base"`
+	BalanceQuote   float64  `json:"balance_quote"`
+	NumBids        int      `json:"num_bids"`
+	NumAsks        int      `json:"num_asks"`
+	MidPrice       *float64 `json:"mid_price"`  // mid price of the offers of the bot, nil when the bot does not have offers on both sides
+	SpreadPercent  *float64 `json:"spread_pct"` // spread of the offers of the bot relative to the mid price, nil when there is no mid price
+	OpsSubmitted   int      `json:"ops_submitted"`
+}
+
+// RunHistory keeps the snapshots of the most recent update cycles. Snapshots are appended to the history file as JSON lines so they
+// survive restarts, the file is compacted once it holds twice the number of snapshots that are kept.
+type RunHistory struct {
+	filepath  string // empty when the history is only kept in memory
+	mutex     *sync.Mutex
+	snapshots []CycleSnapshot // oldest first, bounded by MaxRunHistorySnapshots
+	numLines  int             // number of snapshots in the history file
+}
+
+// MakeRunHistory is a factory method, it loads the history of previous runs from filepath if the file exists. Use an empty filepath to
+// keep the history in memory.
+func MakeRunHistory(filepath string) (*RunHistory, error) {
+	h := &RunHistory{
+		filepath:  filepath,
+		mutex:     &sync.Mutex{},
+		snapshots: []CycleSnapshot{},
+	}
+	if filepath == "" {
+		return h, nil
+	}
+
+	snapshots, numLines, e := readRunHistory(filepath)
+	if e != nil {
+		return nil, e
+	}
+	h.snapshots = snapshots
+	h.numLines = numLines
+	return h, nil
+}
+
+// ReadRunHistory reads the most recent snapshots from a run history file, oldest first. A missing file has no snapshots.
+func ReadRunHistory(filepath string) ([]CycleSnapshot, error) {
+	snapshots, _, e := readRunHistory(filepath)
+	return snapshots, e
+}
+
+func readRunHistory(filepath string) ([]CycleSnapshot, int, error) {
+	contents, e := ioutil.ReadFile(filepath)
+	if os.IsNotExist(e) {
+		return []CycleSnapshot{}, 0, nil
+	} else if e != nil {
+		return nil, 0, fmt.Errorf("could not read run history file '%s': %s", filepath, e)
+	}
+
+	snapshots := []CycleSnapshot{}
+	numLines := 0
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		numLines++
+
+		var s CycleSnapshot
+		e = json.Unmarshal(line, &s)
+		if e != nil {
+			// the last line is partially written when the bot crashes while appending to the file
+			log.Printf("skipping invalid line %d of run history file '%s': %s\n", numLines, filepath, e)
+			continue
+		}
+		if s.Version > RunHistoryVersion {
+			return nil, 0, fmt.Errorf("run history file '%s' was written by a newer version of Kelp (version=%d, max supported version=%d), upgrade Kelp or move the file", filepath, s.Version, RunHistoryVersion)
+		}
+		snapshots = append(snapshots, s)
+	}
+	e = scanner.Err()
+	if e != nil {
+		return nil, 0, fmt.Errorf("could not scan run history file '%s': %s", filepath, e)
+	}
+
+	if len(snapshots) > MaxRunHistorySnapshots {
+		snapshots = snapshots[len(snapshots)-MaxRunHistorySnapshots:]
+	}
+	return snapshots, numLines, nil
+}
+
+// record adds the snapshot of an update cycle and appends it to the history file
+func (h *RunHistory) record(s CycleSnapshot) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.snapshots = append(h.snapshots, s)
+	if len(h.snapshots) > MaxRunHistorySnapshots {
+		h.snapshots = h.snapshots[len(h.snapshots)-MaxRunHistorySnapshots:]
+	}
+	if h.filepath == "" {
+		return nil
+	}
+
+	if h.numLines+1 >= 2*MaxRunHistorySnapshots {
+		return h.compact()
+	}
+	line, e := json.Marshal(s)
+	if e != nil {
+		return fmt.Errorf("could not marshal cycle snapshot: %s", e)
+	}
+	f, e := os.OpenFile(h.filepath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if e != nil {
+		return fmt.Errorf("could not open run history file '%s': %s", h.filepath, e)
+	}
+	defer f.Close()
+	_, e = f.Write(append(line, '\n'))
+	if e != nil {
+		return fmt.Errorf("could not append to run history file '%s': %s", h.filepath, e)
+	}
+	h.numLines++
+	return nil
+}
+
+// compact rewrites the history file with only the snapshots that are kept, it writes to a temporary file first so a crash does not leave a
+// partially written history behind. Callers need to hold mutex.
+func (h *RunHistory) compact() error {
+	var buf bytes.Buffer
+	for _, s := range h.snapshots {
+		line, e := json.Marshal(s)
+		if e != nil {
+			return fmt.Errorf("could not marshal cycle snapshot: %s", e)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	tmpFilepath := h.filepath + ".tmp"
+	e := ioutil.WriteFile(tmpFilepath, buf.Bytes(), 0644)
+	if e != nil {
+		return fmt.Errorf("could not write run history file '%s': %s", tmpFilepath, e)
+	}
+	e = os.Rename(tmpFilepath, h.filepath)
+	if e != nil {
+		return fmt.Errorf("could not move run history file '%s' to '%s': %s", tmpFilepath, h.filepath, e)
+	}
+	h.numLines = len(h.snapshots)
+	return nil
+}
+
+// Snapshots returns a copy of the snapshots that are kept, oldest first
+func (h *RunHistory) Snapshots() []CycleSnapshot {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return append([]CycleSnapshot{}, h.snapshots...)
+}
+
+// SetRunHistory records a snapshot of every update cycle in the run history, it needs to be called before the bot starts
+func (t *Trader) SetRunHistory(h *RunHistory) {
+	t.updateMutex.Lock()
+	defer t.updateMutex.Unlock()
+	t.runHistory = h
+}
+
+// RunHistory returns the snapshots of the most recent update cycles, oldest first, nil when the run history is not recorded. It does not
+// wait for a running update cycle since the run history is set before the bot starts.
+func (t *Trader) RunHistory() []CycleSnapshot {
+	if t.runHistory == nil {
+		return nil
+	}
+	return t.runHistory.Snapshots()
+}
+
+// makeCycleSnapshot captures the balances and offers loaded at the start of an update cycle
+func (t *Trader) makeCycleSnapshot(startTime time.Time) CycleSnapshot {
+	s := CycleSnapshot{
+		Version:      RunHistoryVersion,
+		CycleID:      t.cycleID,
+		StartedAt:    startTime.UTC().Format(time.RFC3339),
+		BalanceBase:  t.maxAssetA,
+		BalanceQuote: t.maxAssetB,
+		NumBids:      len(t.buyingAOffers),
+		NumAsks:      len(t.sellingAOffers),
+	}
+	if len(t.buyingAOffers) == 0 || len(t.sellingAOffers) == 0 {
+		return s
+	}
+
+	// the offers are sorted so the first offers are the top of the book, buying offers are quoted inversely
+	topBid := 1 / utils.PriceAsFloat(t.buyingAOffers[0].Price)
+	topAsk := utils.PriceAsFloat(t.sellingAOffers[0].Price)
+	midPrice := (topBid + topAsk) / 2
+	spreadPct := (topAsk - topBid) / midPrice
+	s.MidPrice = &midPrice
+	s.SpreadPercent = &spreadPct
+	return s
+}
+
+// recordCycleSnapshot completes the snapshot with the outcome of the update cycle and adds it to the run history
+func (t *Trader) recordCycleSnapshot(s CycleSnapshot, startTime time.Time, succeeded bool, cycleErr error, opsSubmitted int) {
+	if t.runHistory == nil {
+		return
+	}
+
+	s.DurationMillis = time.Since(startTime).Nanoseconds() / int64(time.Millisecond)
+	s.Succeeded = succeeded
+	if !succeeded && cycleErr != nil {
+		s.Error = cycleErr.Error()
+	}
+	s.OpsSubmitted = opsSubmitted
+	e := t.runHistory.record(s)
+	if e != nil {
+		t.l.Errorf("unable to record cycle snapshot in the run history: %s\n", e)
+	}
+}
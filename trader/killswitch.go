@@ -0,0 +1,74 @@
+package trader
+
+import (
+	"os"
+
+	"github.com/stellar/go/build"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+)
+
+// EnableKillSwitch makes every update cycle check whether the kill switch file exists, while it exists the bot deletes all of its offers
+// and does not quote, regardless of the deleteCyclesThreshold. The bot quotes again from the first update cycle after the file is removed.
+func (t *Trader) EnableKillSwitch(filePath string) {
+	t.updateMutex.Lock()
+	defer t.updateMutex.Unlock()
+	t.killSwitchFile = filePath
+	t.l.Infof("enabled kill switch, the bot stops quoting and deletes its offers while the file '%s' exists\n", filePath)
+}
+
+// isKillSwitchActive returns true when the kill switch file exists, the kill switch is also considered active when the file cannot be
+// checked since it is used during incidents
+func (t *Trader) isKillSwitchActive() bool {
+	if t.killSwitchFile == "" {
+		return false
+	}
+
+	_, e := os.Stat(t.killSwitchFile)
+	if e == nil {
+		return true
+	}
+	if os.IsNotExist(e) {
+		if t.killSwitchActive {
+			t.l.Infof("kill switch file '%s' was removed, resuming quoting\n", t.killSwitchFile)
+			t.killSwitchActive = false
+		}
+		return false
+	}
+	t.l.Errorf("unable to check kill switch file '%s', treating the kill switch as active: %s\n", t.killSwitchFile, e)
+	return true
+}
+
+// haltForKillSwitch deletes all offers of the bot without waiting for the deleteCyclesThreshold, the alert is only triggered when the kill
+// switch becomes active
+func (t *Trader) haltForKillSwitch() {
+	if !t.killSwitchActive {
+		t.killSwitchActive = true
+		description := "kill switch is active (file '" + t.killSwitchFile + "' exists), the bot stops quoting and deletes all of its offers"
+		t.l.Error(description)
+		if t.alert != nil {
+			e := t.alert.Trigger(description, nil)
+			if e != nil {
+				t.l.Errorf("unable to trigger alert for the kill switch: %s", e)
+			}
+		}
+	}
+
+	dOps := []build.TransactionMutator{}
+	dOps = append(dOps, t.sdex.DeleteAllOffers(t.sellingAOffers)...)
+	t.sellingAOffers = []hProtocol.Offer{}
+	dOps = append(dOps, t.sdex.DeleteAllOffers(t.buyingAOffers)...)
+	t.buyingAOffers = []hProtocol.Offer{}
+
+	t.l.Infof("kill switch created %d operations to delete offers\n", len(dOps))
+	if len(dOps) == 0 {
+		return
+	}
+	if t.dryRunDiff {
+		t.l.Infof("not submitting the operations of the kill switch in dry run diff mode\n")
+		return
+	}
+	e := t.submitOps(dOps)
+	if e != nil {
+		t.l.Errorf("unable to delete offers for the kill switch, will retry in the next update cycle: %s\n", e)
+	}
+}
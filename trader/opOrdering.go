@@ -0,0 +1,155 @@
+package trader
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/stellar/go/build"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/plugins"
+)
+
+// OpOrderingPolicy decides the order in which the ops of an update cycle are submitted. The order matters because ops that are applied
+// earlier in a transaction can free up the liabilities that later ops need, and because the ops are applied one by one on centralized
+// exchanges and when an update cycle needs more than one transaction, so the offers changed first are the first ones to be quoted.
+type OpOrderingPolicy string
+
+// OpOrderingPolicy values
+const (
+	// OpOrderingStrategy keeps the order produced by the strategy, which deletes offers and reduces the size of offers first, it is the default
+	OpOrderingStrategy OpOrderingPolicy = "strategy"
+	// OpOrderingCancelFirst deletes offers first, then modifies and then creates offers, which frees up the most liabilities before they are needed
+	OpOrderingCancelFirst OpOrderingPolicy = "cancel-first"
+	// OpOrderingModifyFirst modifies offers first, then creates and then deletes offers, which keeps the existing quotes on the book the longest.
+	// The ops can fail when the account does not have the capacity for the new offers until the deleted offers free up their liabilities.
+	OpOrderingModifyFirst OpOrderingPolicy = "modify-first"
+	// OpOrderingPricePriority deletes offers first and then creates and modifies offers starting from the top of the book, alternating between
+	// the sell and buy sides, which minimizes the time the top of the book is not quoted
+	OpOrderingPricePriority OpOrderingPolicy = "price-priority"
+)
+
+// ParseOpOrderingPolicy converts the OP_ORDERING_POLICY config value to an OpOrderingPolicy, defaulting to OpOrderingStrategy
+func ParseOpOrderingPolicy(policy string) (OpOrderingPolicy, error) {
+	switch OpOrderingPolicy(policy) {
+	case "", OpOrderingStrategy:
+		return OpOrderingStrategy, nil
+	case OpOrderingCancelFirst, OpOrderingModifyFirst, OpOrderingPricePriority:
+		return OpOrderingPolicy(policy), nil
+	}
+	return "", fmt.Errorf("invalid OP_ORDERING_POLICY '%s', needs to be one of '%s', '%s', '%s' or '%s'",
+		policy, OpOrderingStrategy, OpOrderingCancelFirst, OpOrderingModifyFirst, OpOrderingPricePriority)
+}
+
+// SetOpOrderingPolicy sets the order in which the ops of an update cycle are submitted
+func (t *Trader) SetOpOrderingPolicy(policy OpOrderingPolicy) {
+	t.opOrderingPolicy = policy
+	t.l.Infof("ordering the ops of each update cycle with the '%s' policy\n", policy)
+}
+
+// orderedOp is an op along with its description, the description is nil for ops that do not change offers
+type orderedOp struct {
+	op      build.TransactionMutator
+	preview *OpPreview
+}
+
+// orderOps reorders the ops according to the policy. Ops that do not change offers are kept at the front in their original order.
+func (t *Trader) orderOps(ops []build.TransactionMutator) ([]build.TransactionMutator, error) {
+	if t.opOrderingPolicy == "" || t.opOrderingPolicy == OpOrderingStrategy || len(ops) < 2 {
+		return ops, nil
+	}
+
+	noOffers := map[int64]hProtocol.Offer{}
+	described := []orderedOp{}
+	for _, op := range ops {
+		var p *OpPreview
+		var e error
+		switch o := op.(type) {
+		case *build.ManageOfferBuilder:
+			p, e = t.makeOpPreview(o, noOffers)
+		case build.ManageOfferBuilder:
+			p, e = t.makeOpPreview(&o, noOffers)
+		case *plugins.ManageBuyOfferBuilder:
+			p, e = makeBuyOpPreview(o, noOffers)
+		}
+		if e != nil {
+			return nil, fmt.Errorf("unable to describe op to order it: %s", e)
+		}
+		described = append(described, orderedOp{op: op, preview: p})
+	}
+
+	switch t.opOrderingPolicy {
+	case OpOrderingCancelFirst:
+		described = orderOpsByAction(described, []string{"delete", "modify", "create"})
+	case OpOrderingModifyFirst:
+		described = orderOpsByAction(described, []string{"modify", "create", "delete"})
+	case OpOrderingPricePriority:
+		described = orderOpsByPrice(described)
+	default:
+		return nil, fmt.Errorf("unknown op ordering policy '%s'", t.opOrderingPolicy)
+	}
+
+	ordered := []build.TransactionMutator{}
+	for _, o := range described {
+		ordered = append(ordered, o.op)
+	}
+	return ordered, nil
+}
+
+// orderOpsByAction sorts the ops by the position of their action in actions, keeping the order of the strategy for ops with the same action
+func orderOpsByAction(ops []orderedOp, actions []string) []orderedOp {
+	rank := func(o orderedOp) int {
+		if o.preview == nil {
+			return -1
+		}
+		for i, a := range actions {
+			if o.preview.Action == a {
+				return i
+			}
+		}
+		return len(actions)
+	}
+
+	sorted := append([]orderedOp{}, ops...)
+	sort.SliceStable(sorted, func(i int, j int) bool {
+		return rank(sorted[i]) < rank(sorted[j])
+	})
+	return sorted
+}
+
+// orderOpsByPrice puts the deletes first followed by the other ops from the top of the book outwards, alternating between the sell side
+// (lowest price first) and the buy side (highest price first)
+func orderOpsByPrice(ops []orderedOp) []orderedOp {
+	ordered := []orderedOp{}
+	sells := []orderedOp{}
+	buys := []orderedOp{}
+	deletes := []orderedOp{}
+	for _, o := range ops {
+		switch {
+		case o.preview == nil:
+			ordered = append(ordered, o)
+		case o.preview.Action == "delete":
+			deletes = append(deletes, o)
+		case o.preview.Side == "sell":
+			sells = append(sells, o)
+		default:
+			buys = append(buys, o)
+		}
+	}
+	sort.SliceStable(sells, func(i int, j int) bool {
+		return sells[i].preview.NewPrice < sells[j].preview.NewPrice
+	})
+	sort.SliceStable(buys, func(i int, j int) bool {
+		return buys[i].preview.NewPrice > buys[j].preview.NewPrice
+	})
+
+	ordered = append(ordered, deletes...)
+	for i := 0; i < len(sells) || i < len(buys); i++ {
+		if i < len(sells) {
+			ordered = append(ordered, sells[i])
+		}
+		if i < len(buys) {
+			ordered = append(ordered, buys[i])
+		}
+	}
+	return ordered
+}
@@ -0,0 +1,228 @@
+package trader
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stellar/go/build"
+	"github.com/stellar/go/price"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/xdr"
+	"github.com/stellar/kelp/plugins"
+	"github.com/stellar/kelp/support/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func makeTestSellOp(offerID uint64, sellPrice string, amount string) *build.ManageOfferBuilder {
+	op := build.ManageOffer(
+		false,
+		build.Rate{Selling: build.NativeAsset(), Buying: build.CreditAsset("USD", testIssuer), Price: build.Price(sellPrice)},
+		build.Amount(amount),
+		build.OfferID(offerID),
+	)
+	return &op
+}
+
+// makeTestInvertedBuyOp makes a buy offer that is placed as a sell offer of the quote asset at the inverted price
+func makeTestInvertedBuyOp(offerID uint64, invertedPrice string, amount string) *build.ManageOfferBuilder {
+	op := build.ManageOffer(
+		false,
+		build.Rate{Selling: build.CreditAsset("USD", testIssuer), Buying: build.NativeAsset(), Price: build.Price(invertedPrice)},
+		build.Amount(amount),
+		build.OfferID(offerID),
+	)
+	return &op
+}
+
+func makeTestNativeBuyOp(t *testing.T, buyPrice string, amount int64) *plugins.ManageBuyOfferBuilder {
+	selling, e := build.CreditAsset("USD", testIssuer).ToXDR()
+	if !assert.NoError(t, e) {
+		t.FailNow()
+	}
+	buying, e := build.NativeAsset().ToXDR()
+	if !assert.NoError(t, e) {
+		t.FailNow()
+	}
+	p, e := price.Parse(buyPrice)
+	if !assert.NoError(t, e) {
+		t.FailNow()
+	}
+	return &plugins.ManageBuyOfferBuilder{MBO: xdr.ManageBuyOfferOp{
+		Selling:   selling,
+		Buying:    buying,
+		BuyAmount: xdr.Int64(amount),
+		Price:     p,
+	}}
+}
+
+func TestOrderOps(t *testing.T) {
+	ops := []build.TransactionMutator{
+		makeTestSellOp(1, "0.21", "10"),                   // 0: modify sell
+		makeTestSellOp(2, "0.25", "0"),                    // 1: delete sell
+		makeTestNativeBuyOp(t, "0.19", 100000000),         // 2: create buy
+		makeTestSellOp(0, "0.20", "10"),                   // 3: create sell
+		build.SetOptions(build.HomeDomain("example.com")), // 4: does not change offers
+		makeTestInvertedBuyOp(3, "6.25", "2"),             // 5: modify buy at a price of 0.16
+		makeTestInvertedBuyOp(4, "5", "0"),                // 6: delete buy
+		makeTestSellOp(0, "0.22", "10"),                   // 7: create sell
+	}
+
+	testCases := []struct {
+		policy OpOrderingPolicy
+		want   []int
+	}{
+		{
+			policy: "",
+			want:   []int{0, 1, 2, 3, 4, 5, 6, 7},
+		}, {
+			policy: OpOrderingStrategy,
+			want:   []int{0, 1, 2, 3, 4, 5, 6, 7},
+		}, {
+			policy: OpOrderingCancelFirst,
+			want:   []int{4, 1, 6, 0, 5, 2, 3, 7},
+		}, {
+			policy: OpOrderingModifyFirst,
+			want:   []int{4, 0, 5, 2, 3, 7, 1, 6},
+		}, {
+			// the sell side starts with the lowest price and the buy side with the highest price
+			policy: OpOrderingPricePriority,
+			want:   []int{4, 1, 6, 3, 2, 0, 5, 7},
+		},
+	}
+
+	for _, kase := range testCases {
+		t.Run(string(kase.policy), func(t *testing.T) {
+			trader := &Trader{
+				assetBase:        utils.NativeAsset,
+				assetQuote:       hProtocol.Asset{Type: "credit_alphanum4", Code: "USD", Issuer: testIssuer},
+				opOrderingPolicy: kase.policy,
+			}
+			ordered, e := trader.orderOps(ops)
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			want := []build.TransactionMutator{}
+			for _, i := range kase.want {
+				want = append(want, ops[i])
+			}
+			assert.Equal(t, want, ordered)
+		})
+	}
+}
+
+// makeTestOrderedOps tags each op with its index so the order can be checked, the tag is a memo which is not an op that changes offers
+func makeTestOrderedOps(previews []*OpPreview) []orderedOp {
+	ops := []orderedOp{}
+	for i, p := range previews {
+		ops = append(ops, orderedOp{op: build.MemoID{Value: uint64(i)}, preview: p})
+	}
+	return ops
+}
+
+func orderedOpIndices(ordered []orderedOp) []int {
+	indices := []int{}
+	for _, o := range ordered {
+		indices = append(indices, int(o.op.(build.MemoID).Value))
+	}
+	return indices
+}
+
+func TestOrderOpsByAction(t *testing.T) {
+	testCases := []struct {
+		previews []*OpPreview
+		actions  []string
+		want     []int
+	}{
+		{
+			previews: []*OpPreview{},
+			actions:  []string{"delete", "modify", "create"},
+			want:     []int{},
+		}, {
+			previews: []*OpPreview{{Action: "create"}, {Action: "modify"}, {Action: "delete"}},
+			actions:  []string{"delete", "modify", "create"},
+			want:     []int{2, 1, 0},
+		}, {
+			// the order of the strategy is kept for ops with the same action
+			previews: []*OpPreview{{Action: "create"}, {Action: "delete"}, {Action: "create"}, {Action: "delete"}},
+			actions:  []string{"delete", "modify", "create"},
+			want:     []int{1, 3, 0, 2},
+		}, {
+			// ops that do not change offers go first
+			previews: []*OpPreview{{Action: "delete"}, nil, {Action: "modify"}, nil},
+			actions:  []string{"modify", "create", "delete"},
+			want:     []int{1, 3, 2, 0},
+		}, {
+			// actions that are not listed go last
+			previews: []*OpPreview{{Action: "other"}, {Action: "create"}, {Action: "modify"}},
+			actions:  []string{"modify", "create"},
+			want:     []int{2, 1, 0},
+		},
+	}
+
+	for i, kase := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			ops := makeTestOrderedOps(kase.previews)
+			ordered := orderOpsByAction(ops, kase.actions)
+			assert.Equal(t, kase.want, orderedOpIndices(ordered))
+		})
+	}
+}
+
+func TestOrderOpsByPrice(t *testing.T) {
+	testCases := []struct {
+		name     string
+		previews []*OpPreview
+		want     []int
+	}{
+		{
+			name:     "empty",
+			previews: []*OpPreview{},
+			want:     []int{},
+		}, {
+			name: "alternates starting with the sell side",
+			previews: []*OpPreview{
+				{Action: "create", Side: "buy", NewPrice: 0.18},
+				{Action: "create", Side: "sell", NewPrice: 0.22},
+				{Action: "modify", Side: "buy", NewPrice: 0.19},
+				{Action: "modify", Side: "sell", NewPrice: 0.21},
+			},
+			want: []int{3, 2, 1, 0},
+		}, {
+			name: "deletes and ops that do not change offers go first",
+			previews: []*OpPreview{
+				{Action: "create", Side: "sell", NewPrice: 0.21},
+				{Action: "delete", Side: "buy"},
+				nil,
+				{Action: "delete", Side: "sell"},
+				{Action: "create", Side: "buy", NewPrice: 0.19},
+			},
+			want: []int{2, 1, 3, 0, 4},
+		}, {
+			name: "remaining ops of the longer side",
+			previews: []*OpPreview{
+				{Action: "create", Side: "buy", NewPrice: 0.17},
+				{Action: "create", Side: "buy", NewPrice: 0.19},
+				{Action: "create", Side: "sell", NewPrice: 0.21},
+				{Action: "create", Side: "buy", NewPrice: 0.18},
+			},
+			want: []int{2, 1, 3, 0},
+		}, {
+			name: "only one side",
+			previews: []*OpPreview{
+				{Action: "create", Side: "sell", NewPrice: 0.23},
+				{Action: "create", Side: "sell", NewPrice: 0.21},
+				{Action: "modify", Side: "sell", NewPrice: 0.22},
+			},
+			want: []int{1, 2, 0},
+		},
+	}
+
+	for _, kase := range testCases {
+		t.Run(kase.name, func(t *testing.T) {
+			ops := makeTestOrderedOps(kase.previews)
+			ordered := orderOpsByPrice(ops)
+			assert.Equal(t, kase.want, orderedOpIndices(ordered))
+		})
+	}
+}
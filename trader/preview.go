@@ -0,0 +1,279 @@
+package trader
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/stellar/go/build"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/plugins"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// OpPreview describes a single operation that the next update cycle would submit, prices are in units of the quote asset and amounts in units of the base asset
+type OpPreview struct {
+	Action    string  `json:"action"` // create, modify, or delete
+	Side      string  `json:"side"`   // buy or sell
+	OfferID   int64   `json:"offer_id"`
+	OldPrice  float64 `json:"old_price"`
+	OldAmount float64 `json:"old_amount"`
+	NewPrice  float64 `json:"new_price"`
+	NewAmount float64 `json:"new_amount"`
+}
+
+// String impl.
+func (o OpPreview) String() string {
+	switch o.Action {
+	case "create":
+		return fmt.Sprintf("+ %4s %.7f @ %.7f", o.Side, o.NewAmount, o.NewPrice)
+	case "delete":
+		return fmt.Sprintf("- %4s %.7f @ %.7f (offerID=%d)", o.Side, o.OldAmount, o.OldPrice, o.OfferID)
+	default:
+		return fmt.Sprintf("~ %4s %.7f @ %.7f -> %.7f @ %.7f (offerID=%d)", o.Side, o.OldAmount, o.OldPrice, o.NewAmount, o.NewPrice, o.OfferID)
+	}
+}
+
+// Preview is the set of operations the next update cycle would produce if it ran now
+type Preview struct {
+	GeneratedAt string      `json:"generated_at"`
+	Ops         []OpPreview `json:"ops"`
+	Diff        string      `json:"diff"`
+}
+
+// Preview computes the ops the strategy would produce if the update cycle ran now without submitting them.
+// It blocks while an update cycle is running and skips PreUpdate since that can have side effects (such as offsetting trades).
+// The state of the strategy and the submit filters is restored afterwards when they implement api.StateSnapshotter, strategies and
+// filters that do not implement it need to compute their ops without changing their state.
+func (t *Trader) Preview() (*Preview, error) {
+	t.updateMutex.Lock()
+	defer t.updateMutex.Unlock()
+	if ss, ok := t.strategy.(api.StateSnapshotter); ok {
+		defer ss.Snapshot()()
+	}
+	if t.cycleContext != nil {
+		t.cycleContext.BeginCycle()
+		defer t.cycleContext.EndCycle()
+	}
+
+	offers, e := t.exchangeShim.LoadOffersHack()
+	if e != nil {
+		return nil, fmt.Errorf("unable to load existing offers: %s", e)
+	}
+	sellingAOffers, buyingAOffers := utils.FilterOffers(offers, t.assetBase, t.assetQuote)
+	sort.Sort(utils.ByPrice(buyingAOffers))
+	sort.Sort(utils.ByPrice(sellingAOffers))
+
+	t.sdex.IEIF().ResetCachedBalances()
+	e = t.sdex.IEIF().ResetCachedLiabilities(t.assetBase, t.assetQuote)
+	if e != nil {
+		return nil, fmt.Errorf("unable to reset cached liabilities: %s", e)
+	}
+
+	var pruneOps []build.TransactionMutator
+	pruneOps, buyingAOffers, sellingAOffers = t.strategy.PruneExistingOffers(buyingAOffers, sellingAOffers)
+	ops, e := t.strategy.UpdateWithOps(buyingAOffers, sellingAOffers)
+	if e != nil {
+		return nil, fmt.Errorf("error in UpdateWithOps: %s", e)
+	}
+	t.submitFilters.SetNumPruneOps(len(pruneOps))
+	ops, e = t.submitFilters.Preview(ops, sellingAOffers, buyingAOffers)
+	if e != nil {
+		return nil, e
+	}
+	ops, e = t.orderOps(ops)
+	if e != nil {
+		return nil, e
+	}
+
+	return t.makePreview(append(pruneOps, ops...), offers)
+}
+
+// makePreview describes the ops that replace the existing offers
+func (t *Trader) makePreview(ops []build.TransactionMutator, offers []hProtocol.Offer) (*Preview, error) {
+	offersByID := map[int64]hProtocol.Offer{}
+	for _, o := range offers {
+		offersByID[o.ID] = o
+	}
+	opPreviews := []OpPreview{}
+	var e error
+	for _, op := range ops {
+		var opPreview *OpPreview
+		switch o := op.(type) {
+		case *build.ManageOfferBuilder:
+			opPreview, e = t.makeOpPreview(o, offersByID)
+		case build.ManageOfferBuilder:
+			opPreview, e = t.makeOpPreview(&o, offersByID)
+		case *plugins.ManageBuyOfferBuilder:
+			opPreview, e = makeBuyOpPreview(o, offersByID)
+		default:
+			continue
+		}
+		if e != nil {
+			return nil, fmt.Errorf("unable to make preview of op: %s", e)
+		}
+		opPreviews = append(opPreviews, *opPreview)
+	}
+
+	lines := []string{}
+	for _, o := range opPreviews {
+		lines = append(lines, o.String())
+	}
+	return &Preview{
+		GeneratedAt: time.Now().Format("1/_2/2006 15:04:05"),
+		Ops:         opPreviews,
+		Diff:        strings.Join(lines, "\n"),
+	}, nil
+}
+
+// Table formats the ops of the preview as a table with one row per op
+func (p *Preview) Table() string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "ACTION\tSIDE\tOFFER_ID\tOLD_PRICE\tOLD_AMOUNT\tNEW_PRICE\tNEW_AMOUNT\t")
+	for _, o := range p.Ops {
+		offerID := "-"
+		if o.OfferID != 0 {
+			offerID = strconv.FormatInt(o.OfferID, 10)
+		}
+		oldPrice, oldAmount, newPrice, newAmount := "-", "-", "-", "-"
+		if o.Action != "create" {
+			oldPrice = fmt.Sprintf("%.7f", o.OldPrice)
+			oldAmount = fmt.Sprintf("%.7f", o.OldAmount)
+		}
+		if o.Action != "delete" {
+			newPrice = fmt.Sprintf("%.7f", o.NewPrice)
+			newAmount = fmt.Sprintf("%.7f", o.NewAmount)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n", o.Action, o.Side, offerID, oldPrice, oldAmount, newPrice, newAmount)
+	}
+	w.Flush()
+	return buf.String()
+}
+
+// EnableDryRunDiff stops the update cycles from submitting any ops, instead every update cycle logs the ops it would have submitted as a table
+// and as JSON
+func (t *Trader) EnableDryRunDiff() {
+	t.updateMutex.Lock()
+	defer t.updateMutex.Unlock()
+	t.dryRunDiff = true
+}
+
+// logDryRunDiff logs the ops that the update cycle would have submitted to replace the offers
+func (t *Trader) logDryRunDiff(ops []build.TransactionMutator, offers []hProtocol.Offer) {
+	p, e := t.makePreview(ops, offers)
+	if e != nil {
+		t.l.Errorf("unable to make dry run diff: %s\n", e)
+		return
+	}
+	jsonBytes, e := json.Marshal(p)
+	if e != nil {
+		t.l.Errorf("unable to marshal dry run diff to JSON: %s\n", e)
+		return
+	}
+
+	if len(p.Ops) == 0 {
+		t.l.Infof("dry run diff for update cycle %d: no changes to the offers\n", t.cycleID)
+	} else {
+		t.l.Infof("dry run diff for update cycle %d (%d ops, not submitted):\n%s", t.cycleID, len(p.Ops), p.Table())
+	}
+	t.l.Infof("dry run diff JSON for update cycle %d: %s\n", t.cycleID, string(jsonBytes))
+}
+
+func (t *Trader) makeOpPreview(mob *build.ManageOfferBuilder, offersByID map[int64]hProtocol.Offer) (*OpPreview, error) {
+	isSell, e := utils.IsSelling(t.assetBase, t.assetQuote, mob.MO.Selling, mob.MO.Buying)
+	if e != nil {
+		return nil, fmt.Errorf("error when running the isSelling check: %s", e)
+	}
+
+	p := &OpPreview{
+		Side:    "buy",
+		OfferID: int64(mob.MO.OfferId),
+	}
+	if isSell {
+		p.Side = "sell"
+	}
+
+	if mob.MO.OfferId == 0 {
+		p.Action = "create"
+	} else if mob.MO.Amount == 0 {
+		p.Action = "delete"
+	} else {
+		p.Action = "modify"
+	}
+
+	if mob.MO.Amount != 0 {
+		sellPrice := float64(mob.MO.Price.N) / float64(mob.MO.Price.D)
+		amount := float64(mob.MO.Amount) / math.Pow(10, 7)
+		if isSell {
+			p.NewPrice = sellPrice
+			p.NewAmount = amount
+		} else {
+			// buy offers sell the quote asset so we invert the price and convert the amount to units of the base asset
+			p.NewPrice = 1 / sellPrice
+			p.NewAmount = amount * sellPrice
+		}
+	}
+
+	e = setOldOffer(p, isSell, offersByID)
+	if e != nil {
+		return nil, e
+	}
+	return p, nil
+}
+
+// makeBuyOpPreview makes the preview of a native buy offer, whose price and amount are already in units of the quote and base assets
+func makeBuyOpPreview(mbo *plugins.ManageBuyOfferBuilder, offersByID map[int64]hProtocol.Offer) (*OpPreview, error) {
+	p := &OpPreview{
+		Side:    "buy",
+		OfferID: int64(mbo.MBO.OfferId),
+	}
+	if mbo.MBO.OfferId == 0 {
+		p.Action = "create"
+	} else if mbo.MBO.BuyAmount == 0 {
+		p.Action = "delete"
+	} else {
+		p.Action = "modify"
+	}
+
+	if mbo.MBO.BuyAmount != 0 {
+		p.NewPrice = mbo.BuyPrice()
+		p.NewAmount = mbo.BuyAmount()
+	}
+
+	e := setOldOffer(p, false, offersByID)
+	if e != nil {
+		return nil, e
+	}
+	return p, nil
+}
+
+// setOldOffer sets the old price and amount of the preview from the existing offer that the op replaces, if any
+func setOldOffer(p *OpPreview, isSell bool, offersByID map[int64]hProtocol.Offer) error {
+	oldOffer, ok := offersByID[p.OfferID]
+	if !ok {
+		return nil
+	}
+
+	oldPrice := utils.PriceAsFloat(oldOffer.Price)
+	oldAmount, e := strconv.ParseFloat(oldOffer.Amount, 64)
+	if e != nil {
+		return fmt.Errorf("unable to parse amount of existing offer (offerID=%d): %s", oldOffer.ID, e)
+	}
+	if isSell {
+		p.OldPrice = oldPrice
+		p.OldAmount = oldAmount
+	} else {
+		// existing buy offers are stored as sell offers of the quote asset, including offers created with a native buy op
+		p.OldPrice = 1 / oldPrice
+		p.OldAmount = oldAmount * oldPrice
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package trader
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// StartupOffersAction is what the bot does at startup with the offers that previous runs left on its trading pair
+type StartupOffersAction string
+
+// StartupOffersAction values
+const (
+	// StartupOffersActionAdopt keeps the offers so the strategy modifies them in the first update cycle, it is the default
+	StartupOffersActionAdopt StartupOffersAction = "adopt"
+	// StartupOffersActionDelete deletes the offers before the first update cycle so no stale quotes remain after a crash
+	StartupOffersActionDelete StartupOffersAction = "delete"
+)
+
+// ParseStartupOffersAction converts the STARTUP_OFFERS_ACTION config value to a StartupOffersAction, defaulting to StartupOffersActionAdopt
+func ParseStartupOffersAction(action string) (StartupOffersAction, error) {
+	switch StartupOffersAction(action) {
+	case "", StartupOffersActionAdopt:
+		return StartupOffersActionAdopt, nil
+	case StartupOffersActionDelete:
+		return StartupOffersActionDelete, nil
+	}
+	return "", fmt.Errorf("invalid STARTUP_OFFERS_ACTION '%s', needs to be either '%s' or '%s'", action, StartupOffersActionAdopt, StartupOffersActionDelete)
+}
+
+// OfferJournalVersion is the format version of the offer journal file written by this version of Kelp
+const OfferJournalVersion = 1
+
+// offerJournalFile is the persisted form of the offer journal
+type offerJournalFile struct {
+	Version   int     `json:"version"`
+	Running   bool    `json:"running"` // false once a run stops on its own, so a crash leaves it set
+	UpdatedAt string  `json:"updated_at"`
+	OfferIDs  []int64 `json:"offer_ids"` // offers of the bot on its trading pair as of the last update cycle, sorted
+}
+
+// OfferJournal keeps the IDs of the offers placed by the bot across runs so the next run can tell which offers on its trading pair were
+// left behind by a previous run
+type OfferJournal struct {
+	filepath string // empty when the journal is only kept in memory
+	mutex    *sync.Mutex
+	previous *offerJournalFile // nil when there is no journal from a previous run
+	current  offerJournalFile
+}
+
+// MakeOfferJournal is a factory method, it loads the journal of the previous run from filepath if the file exists. Use an empty filepath to
+// keep the journal in memory.
+func MakeOfferJournal(filepath string) (*OfferJournal, error) {
+	j := &OfferJournal{
+		filepath: filepath,
+		mutex:    &sync.Mutex{},
+		current: offerJournalFile{
+			Version:  OfferJournalVersion,
+			OfferIDs: []int64{},
+		},
+	}
+	if filepath == "" {
+		return j, nil
+	}
+
+	contents, e := ioutil.ReadFile(filepath)
+	if os.IsNotExist(e) {
+		return j, nil
+	} else if e != nil {
+		return nil, fmt.Errorf("could not read offer journal file '%s': %s", filepath, e)
+	}
+
+	var f offerJournalFile
+	e = json.Unmarshal(contents, &f)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse offer journal file '%s': %s", filepath, e)
+	}
+	if f.Version > OfferJournalVersion {
+		return nil, fmt.Errorf("offer journal file '%s' was written by a newer version of Kelp (version=%d, max supported version=%d), upgrade Kelp or move the file", filepath, f.Version, OfferJournalVersion)
+	}
+	j.previous = &f
+	return j, nil
+}
+
+// record persists the IDs of the offers of the bot when they changed since the last call
+func (j *OfferJournal) record(offerIDs []int64) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	sorted := append([]int64{}, offerIDs...)
+	sort.Slice(sorted, func(i, k int) bool { return sorted[i] < sorted[k] })
+	if j.current.Running && equalIDs(sorted, j.current.OfferIDs) {
+		return nil
+	}
+
+	j.current.Running = true
+	j.current.OfferIDs = sorted
+	return j.save()
+}
+
+// markStopped persists that the run stopped on its own
+func (j *OfferJournal) markStopped() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	j.current.Running = false
+	return j.save()
+}
+
+func (j *OfferJournal) save() error {
+	if j.filepath == "" {
+		return nil
+	}
+
+	j.current.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	contents, e := json.MarshalIndent(j.current, "", "  ")
+	if e != nil {
+		return fmt.Errorf("could not marshal offer journal: %s", e)
+	}
+	tmpFilepath := j.filepath + ".tmp"
+	e = ioutil.WriteFile(tmpFilepath, contents, 0644)
+	if e != nil {
+		return fmt.Errorf("could not write offer journal file '%s': %s", tmpFilepath, e)
+	}
+	e = os.Rename(tmpFilepath, j.filepath)
+	if e != nil {
+		return fmt.Errorf("could not move offer journal file '%s' to '%s': %s", tmpFilepath, j.filepath, e)
+	}
+	return nil
+}
+
+// previousRunOffers splits the offers into the ones placed by a previous run and unknown ones. Without a journal from a previous run all
+// offers are considered to be placed by a previous run, the same as deleteAllOffers considers all offers on the trading pair to belong to
+// the bot. When offer IDs are monotonic (as on SDEX) offers newer than the journal are also considered to be placed by the previous run,
+// since they were created in the update cycle that was interrupted before the journal was updated.
+func (j *OfferJournal) previousRunOffers(offers []hProtocol.Offer, monotonicOfferIDs bool) (previousRun []hProtocol.Offer, unknown []hProtocol.Offer) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.previous == nil {
+		return offers, []hProtocol.Offer{}
+	}
+
+	journaled := map[int64]bool{}
+	maxID := int64(0)
+	for _, id := range j.previous.OfferIDs {
+		journaled[id] = true
+		if id > maxID {
+			maxID = id
+		}
+	}
+
+	previousRun = []hProtocol.Offer{}
+	unknown = []hProtocol.Offer{}
+	for _, o := range offers {
+		if journaled[o.ID] || (monotonicOfferIDs && o.ID > maxID) {
+			previousRun = append(previousRun, o)
+		} else {
+			unknown = append(unknown, o)
+		}
+	}
+	return previousRun, unknown
+}
+
+// previousRunCrashed is true when the previous run did not stop on its own
+func (j *OfferJournal) previousRunCrashed() bool {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	return j.previous != nil && j.previous.Running
+}
+
+func equalIDs(a []int64, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// RecoverOffers reconciles the offers that previous runs left on the trading pair before the first update cycle, deleting or adopting
+// them based on the action, and journals the offers of the bot in every update cycle from here on. Offers that are not in the journal are
+// left for the strategy to manage. monotonicOfferIDs should be set when offer IDs increase over time, as on SDEX.
+func (t *Trader) RecoverOffers(journal *OfferJournal, action StartupOffersAction, monotonicOfferIDs bool) error {
+	t.updateMutex.Lock()
+	defer t.updateMutex.Unlock()
+	t.offerJournal = journal
+
+	offers, e := t.exchangeShim.LoadOffersHack()
+	if e != nil {
+		return fmt.Errorf("unable to load offers to recover: %s", e)
+	}
+	sellingAOffers, buyingAOffers := utils.FilterOffers(offers, t.assetBase, t.assetQuote)
+	previousRun, unknown := journal.previousRunOffers(append(sellingAOffers, buyingAOffers...), monotonicOfferIDs)
+	if journal.previousRunCrashed() {
+		t.l.Infof("the previous run did not stop cleanly (crashed or was killed), found %d offers it left on the trading pair\n", len(previousRun))
+	} else {
+		t.l.Infof("found %d offers left on the trading pair by previous runs\n", len(previousRun))
+	}
+	if len(unknown) > 0 {
+		t.l.Infof("found %d offers on the trading pair that are not in the offer journal, leaving them to the strategy\n", len(unknown))
+	}
+	if len(previousRun) == 0 {
+		return nil
+	}
+
+	if action == StartupOffersActionAdopt {
+		t.l.Infof("adopting %d offers from previous runs (STARTUP_OFFERS_ACTION=%s)\n", len(previousRun), action)
+		return nil
+	}
+
+	dOps := t.sdex.DeleteAllOffers(previousRun)
+	t.l.Infof("deleting %d offers from previous runs before the first update cycle (STARTUP_OFFERS_ACTION=%s)\n", len(dOps), action)
+	e = t.submitOpsSynch(dOps)
+	if e != nil {
+		return fmt.Errorf("unable to delete offers from previous runs: %s", e)
+	}
+	t.l.Infof("deleted %d offers from previous runs\n", len(dOps))
+	return nil
+}
+
+// journalOffers records the offers of the bot that were loaded for the current update cycle
+func (t *Trader) journalOffers() {
+	if t.offerJournal == nil {
+		return
+	}
+
+	offerIDs := []int64{}
+	for _, offers := range [][]hProtocol.Offer{t.sellingAOffers, t.buyingAOffers} {
+		for _, o := range offers {
+			offerIDs = append(offerIDs, o.ID)
+		}
+	}
+	e := t.offerJournal.record(offerIDs)
+	if e != nil {
+		// the journal only matters when recovering from a crash so we continue trading
+		t.l.Errorf("unable to record offers in the offer journal: %s\n", e)
+	}
+}
@@ -0,0 +1,277 @@
+package trader
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stellar/go/build"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/plugins"
+	"github.com/stellar/kelp/support/logger"
+	"github.com/stellar/kelp/support/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var testUSD = hProtocol.Asset{Type: "credit_alphanum4", Code: "USD", Issuer: testIssuer}
+
+func makeTestOffers(selling hProtocol.Asset, buying hProtocol.Asset, ids ...int64) []hProtocol.Offer {
+	offers := []hProtocol.Offer{}
+	for _, id := range ids {
+		offers = append(offers, hProtocol.Offer{ID: id, Selling: selling, Buying: buying, Amount: "10.0000000", Price: "0.2000000"})
+	}
+	return offers
+}
+
+func offerIDs(offers []hProtocol.Offer) []int64 {
+	ids := []int64{}
+	for _, o := range offers {
+		ids = append(ids, o.ID)
+	}
+	return ids
+}
+
+// makeTestJournal makes a journal in a temporary dir, the journal of the previous run is written first unless previousOfferIDs is nil
+func makeTestJournal(t *testing.T, previousOfferIDs []int64, previousStopped bool) (*OfferJournal, func()) {
+	dir, e := ioutil.TempDir("", "kelp_offer_journal")
+	if !assert.NoError(t, e) {
+		t.FailNow()
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+	path := filepath.Join(dir, "offers.json")
+
+	if previousOfferIDs != nil {
+		previous, e := MakeOfferJournal(path)
+		if !assert.NoError(t, e) {
+			cleanup()
+			t.FailNow()
+		}
+		if !assert.NoError(t, previous.record(previousOfferIDs)) {
+			cleanup()
+			t.FailNow()
+		}
+		if previousStopped && !assert.NoError(t, previous.markStopped()) {
+			cleanup()
+			t.FailNow()
+		}
+	}
+
+	journal, e := MakeOfferJournal(path)
+	if !assert.NoError(t, e) {
+		cleanup()
+		t.FailNow()
+	}
+	return journal, cleanup
+}
+
+func TestOfferJournalPreviousRunOffers(t *testing.T) {
+	testCases := []struct {
+		name             string
+		previousOfferIDs []int64 // nil when there is no journal from a previous run
+		offerIDs         []int64
+		monotonic        bool
+		wantPrevious     []int64
+		wantUnknown      []int64
+	}{
+		{
+			name:             "no journal",
+			previousOfferIDs: nil,
+			offerIDs:         []int64{1, 5, 9},
+			monotonic:        true,
+			wantPrevious:     []int64{1, 5, 9},
+			wantUnknown:      []int64{},
+		}, {
+			name:             "journaled offers",
+			previousOfferIDs: []int64{5, 9},
+			offerIDs:         []int64{1, 5, 9},
+			monotonic:        false,
+			wantPrevious:     []int64{5, 9},
+			wantUnknown:      []int64{1},
+		}, {
+			// offer 12 was created in the update cycle that was interrupted before the journal was updated
+			name:             "monotonic IDs newer than the journal",
+			previousOfferIDs: []int64{5, 9},
+			offerIDs:         []int64{1, 5, 7, 12},
+			monotonic:        true,
+			wantPrevious:     []int64{5, 12},
+			wantUnknown:      []int64{1, 7},
+		}, {
+			name:             "IDs newer than the journal that are not monotonic",
+			previousOfferIDs: []int64{5, 9},
+			offerIDs:         []int64{1, 5, 7, 12},
+			monotonic:        false,
+			wantPrevious:     []int64{5},
+			wantUnknown:      []int64{1, 7, 12},
+		}, {
+			name:             "empty journal with monotonic IDs",
+			previousOfferIDs: []int64{},
+			offerIDs:         []int64{3, 4},
+			monotonic:        true,
+			wantPrevious:     []int64{3, 4},
+			wantUnknown:      []int64{},
+		}, {
+			name:             "no offers",
+			previousOfferIDs: []int64{5, 9},
+			offerIDs:         []int64{},
+			monotonic:        true,
+			wantPrevious:     []int64{},
+			wantUnknown:      []int64{},
+		},
+	}
+
+	for _, kase := range testCases {
+		t.Run(kase.name, func(t *testing.T) {
+			journal, cleanup := makeTestJournal(t, kase.previousOfferIDs, false)
+			defer cleanup()
+
+			previousRun, unknown := journal.previousRunOffers(makeTestOffers(utils.NativeAsset, testUSD, kase.offerIDs...), kase.monotonic)
+			assert.Equal(t, kase.wantPrevious, offerIDs(previousRun))
+			assert.Equal(t, kase.wantUnknown, offerIDs(unknown))
+		})
+	}
+}
+
+func TestOfferJournalPreviousRunCrashed(t *testing.T) {
+	testCases := []struct {
+		name             string
+		previousOfferIDs []int64
+		previousStopped  bool
+		want             bool
+	}{
+		{
+			name:             "no previous run",
+			previousOfferIDs: nil,
+			want:             false,
+		}, {
+			name:             "previous run was still running",
+			previousOfferIDs: []int64{5},
+			previousStopped:  false,
+			want:             true,
+		}, {
+			name:             "previous run stopped on its own",
+			previousOfferIDs: []int64{5},
+			previousStopped:  true,
+			want:             false,
+		},
+	}
+
+	for _, kase := range testCases {
+		t.Run(kase.name, func(t *testing.T) {
+			journal, cleanup := makeTestJournal(t, kase.previousOfferIDs, kase.previousStopped)
+			defer cleanup()
+
+			assert.Equal(t, kase.want, journal.previousRunCrashed())
+		})
+	}
+}
+
+func TestMakeOfferJournalNewerVersion(t *testing.T) {
+	dir, e := ioutil.TempDir("", "kelp_offer_journal")
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "offers.json")
+	contents := fmt.Sprintf(`{"version": %d, "running": true, "offer_ids": [5]}`, OfferJournalVersion+1)
+	if !assert.NoError(t, ioutil.WriteFile(path, []byte(contents), 0644)) {
+		return
+	}
+
+	_, e = MakeOfferJournal(path)
+	assert.Error(t, e)
+}
+
+// recoveryExchangeShim returns the offers and records the ops that are submitted
+type recoveryExchangeShim struct {
+	api.ExchangeShim
+	offers    []hProtocol.Offer
+	submitted []build.TransactionMutator
+}
+
+func (s *recoveryExchangeShim) LoadOffersHack() ([]hProtocol.Offer, error) {
+	return s.offers, nil
+}
+
+func (s *recoveryExchangeShim) SubmitOpsSynch(ops []build.TransactionMutator, asyncCallback func(hash string, e error)) error {
+	s.submitted = append(s.submitted, ops...)
+	asyncCallback("", nil)
+	return nil
+}
+
+func TestRecoverOffers(t *testing.T) {
+	eur := hProtocol.Asset{Type: "credit_alphanum4", Code: "EUR", Issuer: testIssuer}
+	offers := []hProtocol.Offer{}
+	offers = append(offers, makeTestOffers(utils.NativeAsset, testUSD, 5, 12)...)
+	offers = append(offers, makeTestOffers(testUSD, utils.NativeAsset, 7, 9)...)
+	// offers on another trading pair of the account are never touched
+	offers = append(offers, makeTestOffers(utils.NativeAsset, eur, 13)...)
+
+	testCases := []struct {
+		name             string
+		previousOfferIDs []int64
+		action           StartupOffersAction
+		wantDeleted      []int64
+	}{
+		{
+			name:             "adopt",
+			previousOfferIDs: []int64{5, 9},
+			action:           StartupOffersActionAdopt,
+			wantDeleted:      []int64{},
+		}, {
+			// 12 and 13 are newer than the journal but 13 is on another trading pair, 7 is not in the journal
+			name:             "delete",
+			previousOfferIDs: []int64{5, 9},
+			action:           StartupOffersActionDelete,
+			wantDeleted:      []int64{5, 12, 9},
+		}, {
+			name:             "delete without a journal",
+			previousOfferIDs: nil,
+			action:           StartupOffersActionDelete,
+			wantDeleted:      []int64{5, 12, 7, 9},
+		}, {
+			name:             "delete when no offers are left by previous runs",
+			previousOfferIDs: []int64{20},
+			action:           StartupOffersActionDelete,
+			wantDeleted:      []int64{},
+		},
+	}
+
+	for _, kase := range testCases {
+		t.Run(kase.name, func(t *testing.T) {
+			journal, cleanup := makeTestJournal(t, kase.previousOfferIDs, false)
+			defer cleanup()
+
+			shim := &recoveryExchangeShim{offers: offers}
+			sdex := plugins.MakeSDEX(nil, plugins.MakeIEIF(false), shim, "", "", testIssuer, testIssuer, build.TestNetwork, nil, 0, 0, true, nil, nil, nil)
+			trader := &Trader{
+				assetBase:    utils.NativeAsset,
+				assetQuote:   testUSD,
+				sdex:         sdex,
+				exchangeShim: shim,
+				updateMutex:  &sync.Mutex{},
+				l:            logger.MakeBasicLogger(),
+			}
+
+			e := trader.RecoverOffers(journal, kase.action, true)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, journal, trader.offerJournal)
+
+			deleted := []int64{}
+			for _, op := range shim.submitted {
+				mob, ok := op.(*build.ManageOfferBuilder)
+				if !assert.True(t, ok, "unexpected op type %T", op) {
+					return
+				}
+				assert.Equal(t, int64(0), int64(mob.MO.Amount))
+				deleted = append(deleted, int64(mob.MO.OfferId))
+			}
+			assert.Equal(t, kase.wantDeleted, deleted)
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package trader
+
+// Stop waits for the update cycle in progress to finish and skips all later update cycles, so the offers of the bot can be deleted when
+// it shuts down without the bot placing new offers
+func (t *Trader) Stop() {
+	t.updateMutex.Lock()
+	defer t.updateMutex.Unlock()
+	t.stopped = true
+	t.l.Info("stopped the update loop of the bot")
+}
+
+// MarkStopped records in the offer journal that the run stopped cleanly, it should be called once the offers of the bot are deleted
+func (t *Trader) MarkStopped() error {
+	t.updateMutex.Lock()
+	defer t.updateMutex.Unlock()
+	if t.offerJournal == nil {
+		return nil
+	}
+	return t.offerJournal.markStopped()
+}
@@ -0,0 +1,68 @@
+package trader
+
+import (
+	"time"
+
+	"github.com/stellar/kelp/support/monitoring"
+)
+
+// UpdateTiming describes the timing of an update cycle of the bot
+type UpdateTiming struct {
+	CycleID        uint64            `json:"cycle_id"`
+	StartedAt      string            `json:"started_at"`
+	DurationMillis int64             `json:"duration_millis"`
+	Succeeded      bool              `json:"succeeded"`
+	Spans          []monitoring.Span `json:"spans"` // the time spent in each step of the cycle, to find whether horizon, the exchange or the strategy is the bottleneck
+}
+
+func (t *Trader) recordUpdateTiming(startTime time.Time, succeeded bool, spans *monitoring.SpanRecorder) {
+	timing := &UpdateTiming{
+		CycleID:        t.cycleID,
+		StartedAt:      startTime.UTC().Format(time.RFC3339),
+		DurationMillis: time.Since(startTime).Nanoseconds() / int64(time.Millisecond),
+		Succeeded:      succeeded,
+	}
+
+	// steps that run outside of an update cycle, such as previews, are not attributed to this cycle
+	monitoring.SetCurrentSpanRecorder(nil)
+	spanList := spans.Spans()
+	for _, s := range spanList {
+		t.l.Infof("update-span | cycleID=%d | name=%s | startOffsetMillis=%d | durationMillis=%d | count=%d\n",
+			t.cycleID, s.Name, s.StartOffsetMillis, s.DurationMillis, s.Count)
+	}
+	if t.metrics != nil {
+		t.metrics.UpdateMetrics(map[string]interface{}{
+			"update_duration_millis": timing.DurationMillis,
+			"update_spans":           spanList,
+		})
+	}
+
+	t.timingMutex.Lock()
+	defer t.timingMutex.Unlock()
+	t.lastUpdateTiming = timing
+	t.lastSpans = spans
+	t.cycleStartedAt = time.Time{}
+	t.lastCompletedAt = time.Now()
+	t.stuckDetected = false
+}
+
+func (t *Trader) recordCycleStart(startTime time.Time) {
+	t.timingMutex.Lock()
+	defer t.timingMutex.Unlock()
+	t.inProgressCycleID = t.cycleID
+	t.cycleStartedAt = startTime
+}
+
+// LastUpdateTiming returns the timing of the last completed update cycle, or nil if no update cycle has completed yet
+func (t *Trader) LastUpdateTiming() *UpdateTiming {
+	t.timingMutex.Lock()
+	defer t.timingMutex.Unlock()
+
+	if t.lastUpdateTiming == nil {
+		return nil
+	}
+	timing := *t.lastUpdateTiming
+	// read the spans now since transactions that were submitted asynchronously add their confirmation once it arrives
+	timing.Spans = t.lastSpans.Spans()
+	return &timing
+}
@@ -2,9 +2,9 @@ package trader
 
 import (
 	"fmt"
-	"log"
 	"math"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/nikhilsaraf/go-tools/multithreading"
@@ -14,11 +14,16 @@ import (
 	"github.com/stellar/kelp/api"
 	"github.com/stellar/kelp/model"
 	"github.com/stellar/kelp/plugins"
+	"github.com/stellar/kelp/support/logger"
+	"github.com/stellar/kelp/support/monitoring"
 	"github.com/stellar/kelp/support/utils"
 )
 
 const maxLumenTrust float64 = math.MaxFloat64
 
+// maxOpsPerTransaction is the maximum number of operations that the Stellar network accepts in a single transaction
+const maxOpsPerTransaction = 100
+
 // Trader represents a market making bot, which is composed of various parts include the strategy and various APIs.
 type Trader struct {
 	api                   *horizonclient.Client
@@ -31,14 +36,32 @@ type Trader struct {
 	strategy              api.Strategy // the instance of this bot is bound to this strategy
 	timeController        api.TimeController
 	deleteCyclesThreshold int64
-	submitFilters         []plugins.SubmitFilter
+	submitFilters         *plugins.FilterPipeline
 	threadTracker         *multithreading.ThreadTracker
 	fixedIterations       *uint64
+	metrics               monitoring.Metrics // nil when the metrics are not served, see SetMetrics
 	dataKey               *model.BotKey
 	alert                 api.Alert
+	updateMutex           *sync.Mutex // held for the duration of an update cycle or a preview
+	timingMutex           *sync.Mutex // guards lastUpdateTiming and the watchdog state which are read while an update cycle may be running
+	l                     logger.Logger
 
 	// initialized runtime vars
-	deleteCycles int64
+	deleteCycles     int64
+	cycleID          uint64
+	lastUpdateTiming *UpdateTiming
+	lastSpans        *monitoring.SpanRecorder // spans of the last completed update cycle, transactions may still add to it once they are confirmed
+
+	// watchdog state, guarded by timingMutex
+	inProgressCycleID uint64    // id of the latest cycle that started
+	cycleStartedAt    time.Time // zero when no cycle is in progress
+	lastCompletedAt   time.Time
+	watchdogMaxStall  time.Duration // 0 when the watchdog is disabled
+	watchdogAction    WatchdogAction
+	watchdogStartedAt time.Time
+	stuckDetected     bool
+	stuckDetections   uint64
+	abandonedCycleID  uint64
 
 	// uninitialized runtime vars
 	maxAssetA      float64
@@ -47,6 +70,25 @@ type Trader struct {
 	trustAssetB    float64
 	buyingAOffers  []hProtocol.Offer // quoted A/B
 	sellingAOffers []hProtocol.Offer // quoted B/A
+	offerJournal   *OfferJournal     // nil when the offers are not journaled
+	runHistory     *RunHistory       // nil when the update cycles are not recorded, see SetRunHistory
+	dryRunDiff     bool              // ops are logged instead of submitted when set, see EnableDryRunDiff
+	stopped        bool              // update cycles are skipped once the bot is stopped, see Stop
+
+	// kill switch state
+	killSwitchFile   string // empty when the kill switch is not enabled, see EnableKillSwitch
+	killSwitchActive bool   // set while the kill switch file exists so the alert is only triggered once
+
+	// order of the ops of an update cycle, see SetOpOrderingPolicy
+	opOrderingPolicy OpOrderingPolicy
+	// monitored price feeds that were stale as of the last update cycle so the alert is only triggered once they become stale
+	stalePriceFeeds map[string]bool
+
+	// shares one load of the balances and offers of the account across an update cycle, nil when not used, see SetCycleContext
+	cycleContext *plugins.CycleContext
+	// fractions of the balances of the assets that the strategy trades with, see SetBalanceShares
+	baseBalanceShare  float64
+	quoteBalanceShare float64
 }
 
 // MakeBot is the factory method for the Trader struct
@@ -55,27 +97,19 @@ func MakeBot(
 	ieif *plugins.IEIF,
 	assetBase hProtocol.Asset,
 	assetQuote hProtocol.Asset,
-	tradingPair *model.TradingPair,
 	tradingAccount string,
 	sdex *plugins.SDEX,
 	exchangeShim api.ExchangeShim,
 	strategy api.Strategy,
 	timeController api.TimeController,
 	deleteCyclesThreshold int64,
-	submitMode api.SubmitMode,
+	submitFilters *plugins.FilterPipeline,
 	threadTracker *multithreading.ThreadTracker,
 	fixedIterations *uint64,
 	dataKey *model.BotKey,
 	alert api.Alert,
+	l logger.Logger,
 ) *Trader {
-	submitFilters := []plugins.SubmitFilter{
-		plugins.MakeFilterOrderConstraints(exchangeShim.GetOrderConstraints(tradingPair), assetBase, assetQuote),
-	}
-	sdexSubmitFilter := plugins.MakeFilterMakerMode(submitMode, exchangeShim, sdex, tradingPair)
-	if sdexSubmitFilter != nil {
-		submitFilters = append(submitFilters, sdexSubmitFilter)
-	}
-
 	return &Trader{
 		api:                   api,
 		ieif:                  ieif,
@@ -92,14 +126,49 @@ func MakeBot(
 		fixedIterations:       fixedIterations,
 		dataKey:               dataKey,
 		alert:                 alert,
+		updateMutex:           &sync.Mutex{},
+		timingMutex:           &sync.Mutex{},
+		l:                     l,
 		// initialized runtime vars
-		deleteCycles: 0,
+		deleteCycles:      0,
+		cycleID:           0,
+		baseBalanceShare:  1.0,
+		quoteBalanceShare: 1.0,
 	}
 }
 
+// SetMetrics reports the decisions of the submit filters in the metrics after every update cycle
+func (t *Trader) SetMetrics(metrics monitoring.Metrics) {
+	t.updateMutex.Lock()
+	defer t.updateMutex.Unlock()
+	t.metrics = metrics
+}
+
+// SetCycleContext loads the balances and offers of the account once per update cycle and shares them with the strategy and the submit
+// filters, the cycle context needs to be the exchange shim of the trader, the strategy and the IEIF
+func (t *Trader) SetCycleContext(cycleContext *plugins.CycleContext) {
+	t.updateMutex.Lock()
+	defer t.updateMutex.Unlock()
+	t.cycleContext = cycleContext
+}
+
+// SetBalanceShares makes the strategy trade with the given fractions of the balances of the base and quote assets, used when several trading
+// pairs of the same account that trade the same asset run in one process
+func (t *Trader) SetBalanceShares(baseShare float64, quoteShare float64) {
+	t.updateMutex.Lock()
+	defer t.updateMutex.Unlock()
+	t.baseBalanceShare = baseShare
+	t.quoteBalanceShare = quoteShare
+}
+
+// GetFillHandlers returns the submit filters that need to track the fills of the bot
+func (t *Trader) GetFillHandlers() []api.FillHandler {
+	return t.submitFilters.GetFillHandlers()
+}
+
 // Start starts the bot with the injected strategy
 func (t *Trader) Start() {
-	log.Println("----------------------------------------------------------------------------------------------------")
+	t.l.Info("----------------------------------------------------------------------------------------------------")
 	var lastUpdateTime time.Time
 
 	for {
@@ -109,21 +178,26 @@ func (t *Trader) Start() {
 			if t.fixedIterations != nil {
 				*t.fixedIterations = *t.fixedIterations - 1
 				if *t.fixedIterations <= 0 {
-					log.Printf("finished requested number of iterations, waiting for all threads to finish...\n")
+					t.l.Infof("finished requested number of iterations, waiting for all threads to finish...\n")
 					t.threadTracker.Wait()
-					log.Printf("...all threads finished, stopping bot update loop\n")
+					t.l.Infof("...all threads finished, stopping bot update loop\n")
+					if t.offerJournal != nil {
+						if e := t.offerJournal.markStopped(); e != nil {
+							t.l.Errorf("unable to mark the run as stopped in the offer journal: %s\n", e)
+						}
+					}
 					return
 				}
 			}
 
 			// wait for any goroutines from the current update to finish so we don't have inconsistent state reads
 			t.threadTracker.Wait()
-			log.Println("----------------------------------------------------------------------------------------------------")
+			t.l.Info("----------------------------------------------------------------------------------------------------")
 			lastUpdateTime = currentUpdateTime
 		}
 
 		sleepTime := t.timeController.SleepTime(lastUpdateTime, currentUpdateTime)
-		log.Printf("sleeping for %s...\n", sleepTime)
+		t.l.Infof("sleeping for %s...\n", sleepTime)
 		time.Sleep(sleepTime)
 	}
 }
@@ -131,148 +205,295 @@ func (t *Trader) Start() {
 // deletes all offers for the bot (not all offers on the account)
 func (t *Trader) deleteAllOffers() {
 	if t.deleteCyclesThreshold < 0 {
-		log.Printf("not deleting any offers because deleteCyclesThreshold is negative\n")
+		t.l.Infof("not deleting any offers because deleteCyclesThreshold is negative\n")
 		return
 	}
 
 	t.deleteCycles++
 	if t.deleteCycles <= t.deleteCyclesThreshold {
-		log.Printf("not deleting any offers, deleteCycles (=%d) needs to exceed deleteCyclesThreshold (=%d)\n", t.deleteCycles, t.deleteCyclesThreshold)
+		t.l.Infof("not deleting any offers, deleteCycles (=%d) needs to exceed deleteCyclesThreshold (=%d)\n", t.deleteCycles, t.deleteCyclesThreshold)
 		return
 	}
 
-	log.Printf("deleting all offers, num. continuous update cycles with errors (including this one): %d; (deleteCyclesThreshold to be exceeded=%d)\n", t.deleteCycles, t.deleteCyclesThreshold)
+	t.l.Infof("deleting all offers, num. continuous update cycles with errors (including this one): %d; (deleteCyclesThreshold to be exceeded=%d)\n", t.deleteCycles, t.deleteCyclesThreshold)
 	dOps := []build.TransactionMutator{}
 	dOps = append(dOps, t.sdex.DeleteAllOffers(t.sellingAOffers)...)
 	t.sellingAOffers = []hProtocol.Offer{}
 	dOps = append(dOps, t.sdex.DeleteAllOffers(t.buyingAOffers)...)
 	t.buyingAOffers = []hProtocol.Offer{}
 
-	log.Printf("created %d operations to delete offers\n", len(dOps))
+	t.l.Infof("created %d operations to delete offers\n", len(dOps))
 	if len(dOps) > 0 {
-		e := t.exchangeShim.SubmitOps(dOps, nil)
+		e := t.submitOps(dOps)
 		if e != nil {
-			log.Println(e)
+			t.l.Error(e.Error())
 			return
 		}
 	}
 }
 
+// submitOps submits the ops in a single transaction when they fit, otherwise it splits them into multiple transactions that are
+// submitted one after the other in the original order of the ops, so every transaction uses the next sequence number and
+// deletes are applied before the offers that depend on the liabilities they release
+func (t *Trader) submitOps(ops []build.TransactionMutator) error {
+	if t.isCycleAbandoned() {
+		return fmt.Errorf("not submitting %d operations because the watchdog abandoned update cycle %d", len(ops), t.cycleID)
+	}
+	if t.dryRunDiff {
+		t.l.Infof("not submitting %d operations in dry run mode\n", len(ops))
+		return nil
+	}
+
+	if len(ops) <= maxOpsPerTransaction {
+		return t.exchangeShim.SubmitOps(ops, nil)
+	}
+	return t.submitOpsSynch(ops)
+}
+
+// submitOpsSynch submits the ops in transactions of at most maxOpsPerTransaction ops each, waiting for each transaction to complete
+func (t *Trader) submitOpsSynch(ops []build.TransactionMutator) error {
+	batches := splitOps(ops, maxOpsPerTransaction)
+	if len(batches) > 1 {
+		t.l.Infof("splitting %d operations into %d transactions of at most %d operations each\n", len(ops), len(batches), maxOpsPerTransaction)
+	}
+	for i, batch := range batches {
+		// the callback may be invoked on a different goroutine, buffer it so it never blocks
+		results := make(chan error, 1)
+		e := t.exchangeShim.SubmitOpsSynch(batch, func(hash string, e error) {
+			results <- e
+		})
+		if e != nil {
+			return fmt.Errorf("error submitting transaction %d of %d (%d ops): %s", i+1, len(batches), len(batch), e)
+		}
+
+		e = <-results
+		if e != nil {
+			return fmt.Errorf("transaction %d of %d (%d ops) failed, not submitting the remaining transactions: %s", i+1, len(batches), len(batch), e)
+		}
+		t.l.Infof("submitted transaction %d of %d (%d ops)\n", i+1, len(batches), len(batch))
+	}
+	return nil
+}
+
+// splitOps splits the ops into consecutive batches of at most batchSize ops each, preserving their order
+func splitOps(ops []build.TransactionMutator, batchSize int) [][]build.TransactionMutator {
+	batches := [][]build.TransactionMutator{}
+	for start := 0; start < len(ops); start += batchSize {
+		end := start + batchSize
+		if end > len(ops) {
+			end = len(ops)
+		}
+		batches = append(batches, ops[start:end])
+	}
+	return batches
+}
+
 // time to update the order book and possibly readjust the offers
 func (t *Trader) update() {
+	t.updateMutex.Lock()
+	defer t.updateMutex.Unlock()
+	if t.stopped {
+		t.l.Info("skipping update cycle because the bot is stopped")
+		return
+	}
+
+	t.cycleID++
+	if sl, ok := t.l.(logger.StructuredLogger); ok {
+		sl.SetField("cycle_id", t.cycleID)
+	}
+	startTime := time.Now()
+	t.recordCycleStart(startTime)
+	spans := monitoring.MakeSpanRecorder(startTime)
+	monitoring.SetCurrentSpanRecorder(spans)
+	succeeded := false
 	var e error
+	var snapshot CycleSnapshot
+	opsSubmitted := 0
+	defer func() {
+		t.recordUpdateTiming(startTime, succeeded, spans)
+		t.recordCycleSnapshot(snapshot, startTime, succeeded, e, opsSubmitted)
+	}()
+
+	if t.cycleContext != nil {
+		t.cycleContext.BeginCycle()
+		defer t.cycleContext.EndCycle()
+	}
+
+	endSpan := monitoring.StartSpan(monitoring.SpanLoadBalances)
 	t.load()
+	endSpan()
+	endSpan = monitoring.StartSpan(monitoring.SpanLoadOffers)
 	t.loadExistingOffers()
+	endSpan()
+	t.journalOffers()
+	snapshot = t.makeCycleSnapshot(startTime)
+
+	if t.isKillSwitchActive() {
+		t.haltForKillSwitch()
+		e = fmt.Errorf("kill switch is active")
+		return
+	}
 
 	pair := &model.TradingPair{
 		Base:  model.FromHorizonAsset(t.assetBase),
 		Quote: model.FromHorizonAsset(t.assetQuote),
 	}
-	log.Printf("orderConstraints for trading pair %s: %s", pair, t.exchangeShim.GetOrderConstraints(pair))
+	t.l.Infof("orderConstraints for trading pair %s: %s", pair, t.exchangeShim.GetOrderConstraints(pair))
 
 	// TODO 2 streamline the request data instead of caching
 	// reset cache of balances for this update cycle to reduce redundant requests to calculate asset balances
 	t.sdex.IEIF().ResetCachedBalances()
 	// reset and recompute cached liabilities for this update cycle
+	endSpan = monitoring.StartSpan(monitoring.SpanLiabilities)
 	e = t.sdex.IEIF().ResetCachedLiabilities(t.assetBase, t.assetQuote)
-	log.Printf("liabilities after resetting\n")
+	endSpan()
+	t.l.Infof("liabilities after resetting\n")
 	t.sdex.IEIF().LogAllLiabilities(t.assetBase, t.assetQuote)
 	if e != nil {
-		log.Println(e)
+		t.l.Error(e.Error())
 		t.deleteAllOffers()
 		return
 	}
 
 	// strategy has a chance to set any state it needs
+	endSpan = monitoring.StartSpan(monitoring.SpanPreUpdate)
 	e = t.strategy.PreUpdate(t.maxAssetA, t.maxAssetB, t.trustAssetA, t.trustAssetB)
+	endSpan()
 	if e != nil {
-		log.Println(e)
+		t.l.Error(e.Error())
 		t.deleteAllOffers()
 		return
 	}
 
+	// the offers as they were before this update cycle, used to describe the ops in dry run mode
+	existingOffers := append(append([]hProtocol.Offer{}, t.sellingAOffers...), t.buyingAOffers...)
+
 	// delete excess offers
 	var pruneOps []build.TransactionMutator
+	endSpan = monitoring.StartSpan(monitoring.SpanPruneOffers)
 	pruneOps, t.buyingAOffers, t.sellingAOffers = t.strategy.PruneExistingOffers(t.buyingAOffers, t.sellingAOffers)
-	log.Printf("created %d operations to prune excess offers\n", len(pruneOps))
+	endSpan()
+	t.l.Infof("created %d operations to prune excess offers\n", len(pruneOps))
 	if len(pruneOps) > 0 {
-		e = t.exchangeShim.SubmitOps(pruneOps, nil)
+		endSpan = monitoring.StartSpan(monitoring.SpanTxSubmit)
+		e = t.submitOps(pruneOps)
+		endSpan()
 		if e != nil {
-			log.Println(e)
+			t.l.Error(e.Error())
 			t.deleteAllOffers()
 			return
 		}
+		opsSubmitted += len(pruneOps)
 	}
 
 	// TODO 2 streamline the request data instead of caching
 	// reset cache of balances for this update cycle to reduce redundant requests to calculate asset balances
 	t.sdex.IEIF().ResetCachedBalances()
 	// reset and recompute cached liabilities for this update cycle
+	endSpan = monitoring.StartSpan(monitoring.SpanLiabilities)
 	e = t.sdex.IEIF().ResetCachedLiabilities(t.assetBase, t.assetQuote)
-	log.Printf("liabilities after resetting\n")
+	endSpan()
+	t.l.Infof("liabilities after resetting\n")
 	t.sdex.IEIF().LogAllLiabilities(t.assetBase, t.assetQuote)
 	if e != nil {
-		log.Println(e)
+		t.l.Error(e.Error())
 		t.deleteAllOffers()
 		return
 	}
+	// warn about ops that would fail because of the capacity of the account before the strategy skips them
+	t.sdex.IEIF().LogCapacityWarnings(t.assetBase, t.assetQuote, t.sdex.ComputeIncrementalNativeAmountRaw(true))
 
+	endSpan = monitoring.StartSpan(monitoring.SpanOpsConstruction)
 	ops, e := t.strategy.UpdateWithOps(t.buyingAOffers, t.sellingAOffers)
-	log.Printf("liabilities at the end of a call to UpdateWithOps\n")
+	endSpan()
+	// the freshness is checked before handling errors since stale price feeds can be the cause of the error
+	priceFeedFreshness := plugins.CheckPriceFeedFreshness()
+	t.checkPriceFeedFreshness(priceFeedFreshness)
+	t.l.Infof("liabilities at the end of a call to UpdateWithOps\n")
 	t.sdex.IEIF().LogAllLiabilities(t.assetBase, t.assetQuote)
 	if e != nil {
-		log.Println(e)
-		log.Printf("liabilities (force recomputed) after encountering an error after a call to UpdateWithOps\n")
+		t.l.Error(e.Error())
+		t.l.Infof("liabilities (force recomputed) after encountering an error after a call to UpdateWithOps\n")
 		t.sdex.IEIF().RecomputeAndLogCachedLiabilities(t.assetBase, t.assetQuote)
 		t.deleteAllOffers()
 		return
 	}
 
-	for i, filter := range t.submitFilters {
-		ops, e = filter.Apply(ops, t.sellingAOffers, t.buyingAOffers)
-		if e != nil {
-			log.Printf("error in filter index %d: %s\n", i, e)
-			t.deleteAllOffers()
-			return
+	endSpan = monitoring.StartSpan(monitoring.SpanSubmitFilters)
+	// the prune ops were already submitted without going through the submit filters but they count toward the limits of the filters
+	t.submitFilters.SetNumPruneOps(len(pruneOps))
+	ops, e = t.submitFilters.Apply(ops, t.sellingAOffers, t.buyingAOffers)
+	endSpan()
+	if t.metrics != nil {
+		t.metrics.UpdateMetrics(map[string]interface{}{
+			"submit_filters":       t.submitFilters.Stats(),
+			"tx_failures":          t.sdex.TxFailureStats(),
+			"price_feeds":          plugins.GetPriceFeedCacheStats(),
+			"price_feed_freshness": priceFeedFreshness,
+		})
+		if t.cycleContext != nil {
+			t.metrics.UpdateMetrics(map[string]interface{}{
+				"cycle_context": t.cycleContext.Stats(),
+			})
 		}
 	}
+	if e != nil {
+		t.l.Errorf("%s\n", e)
+		t.deleteAllOffers()
+		return
+	}
+	ops, e = t.orderOps(ops)
+	if e != nil {
+		t.l.Error(e.Error())
+		t.deleteAllOffers()
+		return
+	}
 
-	log.Printf("created %d operations to update existing offers\n", len(ops))
+	t.l.Infof("created %d operations to update existing offers\n", len(ops))
+	if t.dryRunDiff {
+		t.logDryRunDiff(append(append([]build.TransactionMutator{}, pruneOps...), ops...), existingOffers)
+	}
 	if len(ops) > 0 {
-		e = t.exchangeShim.SubmitOps(ops, nil)
+		endSpan = monitoring.StartSpan(monitoring.SpanTxSubmit)
+		e = t.submitOps(ops)
+		endSpan()
 		if e != nil {
-			log.Println(e)
+			t.l.Error(e.Error())
 			t.deleteAllOffers()
 			return
 		}
+		opsSubmitted += len(ops)
 	}
 
+	endSpan = monitoring.StartSpan(monitoring.SpanPostUpdate)
 	e = t.strategy.PostUpdate()
+	endSpan()
 	if e != nil {
-		log.Println(e)
+		t.l.Error(e.Error())
 		t.deleteAllOffers()
 		return
 	}
 
 	// reset deleteCycles on every successful run
 	t.deleteCycles = 0
+	succeeded = true
 }
 
 func (t *Trader) load() {
 	// load the maximum amounts we can offer for each asset
 	baseBalance, e := t.exchangeShim.GetBalanceHack(t.assetBase)
 	if e != nil {
-		log.Println(e)
+		t.l.Error(e.Error())
 		return
 	}
 	quoteBalance, e := t.exchangeShim.GetBalanceHack(t.assetQuote)
 	if e != nil {
-		log.Println(e)
+		t.l.Error(e.Error())
 		return
 	}
 
-	t.maxAssetA = baseBalance.Balance
-	t.maxAssetB = quoteBalance.Balance
+	t.maxAssetA = baseBalance.Balance * t.baseBalanceShare
+	t.maxAssetB = quoteBalance.Balance * t.quoteBalanceShare
 	t.trustAssetA = baseBalance.Trust
 	t.trustAssetB = quoteBalance.Trust
 
@@ -285,14 +506,14 @@ func (t *Trader) load() {
 		trustBString = fmt.Sprintf("%.8f", t.trustAssetB)
 	}
 
-	log.Printf(" (base) assetA=%s, maxA=%.8f, trustA=%s\n", utils.Asset2String(t.assetBase), t.maxAssetA, trustAString)
-	log.Printf("(quote) assetB=%s, maxB=%.8f, trustB=%s\n", utils.Asset2String(t.assetQuote), t.maxAssetB, trustBString)
+	t.l.Infof(" (base) assetA=%s, maxA=%.8f, trustA=%s, balanceShare=%.4f\n", utils.Asset2String(t.assetBase), t.maxAssetA, trustAString, t.baseBalanceShare)
+	t.l.Infof("(quote) assetB=%s, maxB=%.8f, trustB=%s, balanceShare=%.4f\n", utils.Asset2String(t.assetQuote), t.maxAssetB, trustBString, t.quoteBalanceShare)
 }
 
 func (t *Trader) loadExistingOffers() {
 	offers, e := t.exchangeShim.LoadOffersHack()
 	if e != nil {
-		log.Println(e)
+		t.l.Error(e.Error())
 		return
 	}
 	t.sellingAOffers, t.buyingAOffers = utils.FilterOffers(offers, t.assetBase, t.assetQuote)
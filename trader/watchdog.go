@@ -0,0 +1,158 @@
+package trader
+
+import (
+	"fmt"
+	"time"
+)
+
+// WatchdogAction is what the watchdog does when the update loop is stuck
+type WatchdogAction string
+
+// WatchdogAction values
+const (
+	// WatchdogActionAlert only logs and triggers the alert, it is the default
+	WatchdogActionAlert WatchdogAction = "alert"
+	// WatchdogActionRestart abandons the stuck update cycle so it does not submit any operations if it unblocks, the update loop starts a
+	// new cycle as soon as the abandoned cycle returns
+	WatchdogActionRestart WatchdogAction = "restart"
+	// WatchdogActionExit invokes the exit function of the watchdog, which is expected to delete all offers and kill the bot
+	WatchdogActionExit WatchdogAction = "exit"
+)
+
+// minWatchdogCheckInterval bounds how often the watchdog checks the update loop
+const minWatchdogCheckInterval = time.Second
+
+// ParseWatchdogAction converts the WATCHDOG_ACTION config value to a WatchdogAction, defaulting to WatchdogActionAlert
+func ParseWatchdogAction(action string) (WatchdogAction, error) {
+	switch WatchdogAction(action) {
+	case "", WatchdogActionAlert:
+		return WatchdogActionAlert, nil
+	case WatchdogActionRestart:
+		return WatchdogActionRestart, nil
+	case WatchdogActionExit:
+		return WatchdogActionExit, nil
+	}
+	return "", fmt.Errorf("invalid WATCHDOG_ACTION '%s', needs to be one of '%s', '%s' or '%s'", action, WatchdogActionAlert, WatchdogActionRestart, WatchdogActionExit)
+}
+
+// Health describes the liveness of the update loop of the bot
+type Health struct {
+	Status                 string  `json:"status"` // "ok", "starting" before the first cycle completes, or "stuck" when the watchdog detects a stall
+	CycleID                uint64  `json:"cycle_id"`
+	CycleInProgress        bool    `json:"cycle_in_progress"`
+	CycleRunningSeconds    float64 `json:"cycle_running_seconds"`
+	LastCompletedAt        string  `json:"last_completed_at"`
+	SecondsSinceLastUpdate float64 `json:"seconds_since_last_update"`
+	WatchdogEnabled        bool    `json:"watchdog_enabled"`
+	WatchdogMaxStallSecs   float64 `json:"watchdog_max_stall_seconds"`
+	WatchdogAction         string  `json:"watchdog_action"`
+	StuckDetections        uint64  `json:"stuck_detections"`
+}
+
+// StartWatchdog starts a goroutine that detects when the update loop has not completed a cycle within maxStall, which happens when a
+// Horizon call hangs or there is a deadlock. exitFn is only invoked for WatchdogActionExit.
+func (t *Trader) StartWatchdog(maxStall time.Duration, action WatchdogAction, exitFn func()) {
+	t.timingMutex.Lock()
+	t.watchdogMaxStall = maxStall
+	t.watchdogAction = action
+	t.watchdogStartedAt = time.Now()
+	t.timingMutex.Unlock()
+
+	checkInterval := maxStall / 4
+	if checkInterval < minWatchdogCheckInterval {
+		checkInterval = minWatchdogCheckInterval
+	}
+	t.l.Infof("started watchdog with maxStall=%s, action=%s, checkInterval=%s\n", maxStall, action, checkInterval)
+
+	go func() {
+		for range time.Tick(checkInterval) {
+			t.checkWatchdog(exitFn)
+		}
+	}()
+}
+
+func (t *Trader) checkWatchdog(exitFn func()) {
+	t.timingMutex.Lock()
+	stalledFor := time.Since(t.lastActivityTime())
+	if stalledFor <= t.watchdogMaxStall || t.stuckDetected {
+		// only act once per stall, the flag is reset when the next cycle completes
+		t.timingMutex.Unlock()
+		return
+	}
+	t.stuckDetected = true
+	t.stuckDetections++
+	cycleInProgress := !t.cycleStartedAt.IsZero()
+	cycleID := t.inProgressCycleID
+	if cycleInProgress && t.watchdogAction == WatchdogActionRestart {
+		t.abandonedCycleID = cycleID
+	}
+	action := t.watchdogAction
+	t.timingMutex.Unlock()
+
+	description := fmt.Sprintf("watchdog detected that the update loop of the bot has not completed a cycle in %s (cycleInProgress=%v, cycleID=%d), taking action '%s'",
+		stalledFor, cycleInProgress, cycleID, action)
+	t.l.Error(description)
+	if t.alert != nil {
+		e := t.alert.Trigger(description, nil)
+		if e != nil {
+			t.l.Errorf("unable to trigger alert for the watchdog: %s", e)
+		}
+	}
+
+	switch action {
+	case WatchdogActionRestart:
+		if cycleInProgress {
+			t.l.Infof("abandoned update cycle %d, a new cycle will start as soon as it returns\n", cycleID)
+		}
+	case WatchdogActionExit:
+		exitFn()
+	}
+}
+
+// lastActivityTime is when the update loop last completed a cycle, or when the watchdog started if no cycle has completed since.
+// Callers need to hold timingMutex.
+func (t *Trader) lastActivityTime() time.Time {
+	if t.lastCompletedAt.After(t.watchdogStartedAt) {
+		return t.lastCompletedAt
+	}
+	return t.watchdogStartedAt
+}
+
+// isCycleAbandoned returns true if the watchdog abandoned the current update cycle, it is only called from within the update cycle
+func (t *Trader) isCycleAbandoned() bool {
+	t.timingMutex.Lock()
+	defer t.timingMutex.Unlock()
+	return t.abandonedCycleID != 0 && t.abandonedCycleID == t.inProgressCycleID
+}
+
+// Health returns the liveness of the update loop
+func (t *Trader) Health() *Health {
+	t.timingMutex.Lock()
+	defer t.timingMutex.Unlock()
+
+	now := time.Now()
+	h := &Health{
+		Status:          "ok",
+		CycleID:         t.inProgressCycleID,
+		CycleInProgress: !t.cycleStartedAt.IsZero(),
+		WatchdogEnabled: t.watchdogMaxStall > 0,
+		StuckDetections: t.stuckDetections,
+	}
+	if h.CycleInProgress {
+		h.CycleRunningSeconds = now.Sub(t.cycleStartedAt).Seconds()
+	}
+	if t.lastCompletedAt.IsZero() {
+		h.Status = "starting"
+	} else {
+		h.LastCompletedAt = t.lastCompletedAt.UTC().Format(time.RFC3339)
+		h.SecondsSinceLastUpdate = now.Sub(t.lastCompletedAt).Seconds()
+	}
+	if h.WatchdogEnabled {
+		h.WatchdogMaxStallSecs = t.watchdogMaxStall.Seconds()
+		h.WatchdogAction = string(t.watchdogAction)
+		if now.Sub(t.lastActivityTime()) > t.watchdogMaxStall {
+			h.Status = "stuck"
+		}
+	}
+	return h
+}